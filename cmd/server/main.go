@@ -29,6 +29,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -67,6 +68,7 @@ func main() {
 	var vertexImport string
 	var configPath string
 	var password string
+	var printConfigSchema bool
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&login, "login", false, "Login Google Account")
@@ -82,6 +84,7 @@ func main() {
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
 	flag.StringVar(&password, "password", "", "")
+	flag.BoolVar(&printConfigSchema, "config-schema", false, "Print the JSON schema of the config file and exit")
 
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
@@ -113,6 +116,16 @@ func main() {
 	// Parse the command-line flags.
 	flag.Parse()
 
+	if printConfigSchema {
+		schema, errSchema := config.JSONSchema()
+		if errSchema != nil {
+			log.Errorf("failed to generate config schema: %v", errSchema)
+			os.Exit(1)
+		}
+		fmt.Println(string(schema))
+		return
+	}
+
 	// Core application variables.
 	var err error
 	var cfg *config.Config
@@ -405,6 +418,7 @@ func main() {
 		}
 	}
 	usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
+	coreusage.SetAggregateOnly(cfg.UsageAggregateOnly)
 	coreauth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 
 	if err = logging.ConfigureLogOutput(cfg); err != nil {
@@ -0,0 +1,112 @@
+package managedkeys
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+const (
+	// ProviderName identifies the managed key access provider in access results.
+	ProviderName = "managed-api-key"
+
+	// MetadataAllowedModels carries a scoped token's comma-separated model allow-list
+	// in the access result metadata.
+	MetadataAllowedModels = "allowed_models"
+)
+
+type uncountedKey struct{}
+
+// WithoutUsage marks a request context so that authenticating it does not count
+// against the key's request budget.
+func WithoutUsage(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncountedKey{}, true)
+}
+
+func usageExempt(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	exempt, _ := ctx.Value(uncountedKey{}).(bool)
+	return exempt
+}
+
+type provider struct {
+	store *Store
+}
+
+// NewProvider returns an access provider that validates requests against the store.
+func NewProvider(store *Store) sdkaccess.Provider {
+	return &provider{store: store}
+}
+
+func (p *provider) Identifier() string { return ProviderName }
+
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, error) {
+	if p == nil || p.store == nil || r == nil {
+		return nil, sdkaccess.ErrNotHandled
+	}
+	if err := p.store.Err(); err != nil {
+		return nil, err
+	}
+	candidates := requestCredentials(r)
+	if len(candidates) == 0 {
+		return nil, sdkaccess.ErrNoCredentials
+	}
+	lookup := p.store.Lookup
+	if usageExempt(ctx) {
+		lookup = p.store.Peek
+	}
+	for _, candidate := range candidates {
+		key, ok := lookup(candidate.value)
+		if !ok {
+			continue
+		}
+		metadata := map[string]string{
+			"source": candidate.source,
+			"key_id": key.ID,
+		}
+		if key.Scope != nil && len(key.Scope.Models) > 0 {
+			metadata[MetadataAllowedModels] = strings.Join(key.Scope.Models, ",")
+		}
+		return &sdkaccess.Result{
+			Provider:  ProviderName,
+			Principal: candidate.value,
+			Metadata:  metadata,
+		}, nil
+	}
+	return nil, sdkaccess.ErrInvalidCredential
+}
+
+type credential struct {
+	value  string
+	source string
+}
+
+// requestCredentials collects the credential locations accepted by the inline config provider.
+func requestCredentials(r *http.Request) []credential {
+	var out []credential
+	add := func(value, source string) {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, credential{value: value, source: source})
+		}
+	}
+	if header := r.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+			add(parts[1], "authorization")
+		} else {
+			add(header, "authorization")
+		}
+	}
+	add(r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key")
+	add(r.Header.Get("X-Api-Key"), "x-api-key")
+	if r.URL != nil {
+		query := r.URL.Query()
+		add(query.Get("key"), "query-key")
+		add(query.Get("auth_token"), "query-auth-token")
+	}
+	return out
+}
@@ -0,0 +1,719 @@
+// Package managedkeys stores inbound client API keys outside of config.yaml.
+// Only SHA-256 digests of the keys are persisted; the plaintext value is returned
+// once when a key is created or rotated and cannot be recovered afterwards.
+package managedkeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultFileName is the file used to persist managed keys next to the config file.
+	DefaultFileName = "api-keys.json"
+
+	// KeyPrefix marks generated keys so they are easy to recognise in client configs.
+	KeyPrefix = "sk-cpa-"
+
+	schemaVersion = 1
+	displayLength = len(KeyPrefix) + 6
+
+	// usageFlushDelay bounds how long per-request usage counters stay in memory before
+	// they are written to disk.
+	usageFlushDelay = 5 * time.Second
+)
+
+var (
+	// ErrKeyNotFound is returned when the referenced key ID does not exist.
+	ErrKeyNotFound = errors.New("managed keys: key not found")
+	// ErrKeyRevoked is returned when an operation targets a revoked key.
+	ErrKeyRevoked = errors.New("managed keys: key revoked")
+	// ErrDuplicateKey is returned when importing a key whose digest already exists.
+	ErrDuplicateKey = errors.New("managed keys: key already exists")
+	// ErrExpiryRequired is returned when minting a scoped token without an expiry.
+	ErrExpiryRequired = errors.New("managed keys: scoped tokens require an expiry")
+)
+
+// Scope restricts what a minted token may be used for. Zero limits are unlimited.
+type Scope struct {
+	// Models lists the client-facing model names the token may call. A trailing "*" matches a prefix.
+	Models []string `json:"models,omitempty"`
+	// MaxRequests caps the number of authenticated requests.
+	MaxRequests int64 `json:"max_requests,omitempty"`
+	// MaxTokens caps the total tokens reported by upstream usage.
+	MaxTokens int64 `json:"max_tokens,omitempty"`
+}
+
+// AllowsModel reports whether the scope permits the given model.
+func (s *Scope) AllowsModel(model string) bool {
+	if s == nil || len(s.Models) == 0 {
+		return true
+	}
+	return ModelAllowed(s.Models, model)
+}
+
+// ModelAllowed matches a model against an allow-list. Entries ending in "*" match by prefix.
+func ModelAllowed(allowed []string, model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" || entry == model {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok && strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage tracks consumption of a managed key.
+type Usage struct {
+	Requests int64 `json:"requests"`
+	Tokens   int64 `json:"tokens"`
+}
+
+// Key describes a managed inbound API key. The plaintext secret is never stored.
+type Key struct {
+	ID          string     `json:"id"`
+	Prefix      string     `json:"prefix"`
+	Hash        string     `json:"hash"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	Scope       *Scope     `json:"scope,omitempty"`
+	Usage       Usage      `json:"usage"`
+}
+
+// Active reports whether the key may authenticate requests at the given time.
+func (k Key) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return false
+	}
+	return !k.Exhausted()
+}
+
+// validate checks invariants that must hold after any change to the key.
+func (k *Key) validate() error {
+	if k.Scope != nil && k.ExpiresAt == nil {
+		return ErrExpiryRequired
+	}
+	return nil
+}
+
+// Exhausted reports whether a scoped key has used up its request or token budget.
+func (k Key) Exhausted() bool {
+	if k.Scope == nil {
+		return false
+	}
+	if k.Scope.MaxRequests > 0 && k.Usage.Requests >= k.Scope.MaxRequests {
+		return true
+	}
+	if k.Scope.MaxTokens > 0 && k.Usage.Tokens >= k.Scope.MaxTokens {
+		return true
+	}
+	return false
+}
+
+type storeData struct {
+	SchemaVersion int    `json:"schema_version"`
+	Keys          []*Key `json:"keys"`
+}
+
+// Store persists managed keys as a JSON document and indexes them by digest.
+type Store struct {
+	mu       sync.RWMutex
+	filePath string
+	keys     map[string]*Key
+	byHash   map[string]*Key
+	onChange func()
+	// dirty marks usage counters that changed since the last write; flushTimer is the
+	// pending debounced write for them.
+	dirty      bool
+	flushTimer *time.Timer
+	// loadErr is set when the backing file exists but cannot be read. The store then
+	// refuses to persist anything so the unreadable file is never overwritten.
+	loadErr error
+}
+
+// NewStore opens (or creates) the managed key store at path.
+// An empty path keeps the store in memory only.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		filePath: strings.TrimSpace(path),
+		keys:     make(map[string]*Key),
+		byHash:   make(map[string]*Key),
+	}
+	if err := s.load(); err != nil {
+		s.loadErr = err
+		return s, err
+	}
+	return s, nil
+}
+
+// Err returns the error that prevented the backing file from loading, if any.
+// A store in this state authenticates nothing and rejects all changes.
+func (s *Store) Err() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadErr
+}
+
+// Path returns the backing file path.
+func (s *Store) Path() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.filePath
+}
+
+// Reload replaces the in-memory keys with the contents of the backing file,
+// e.g. after the file was restored from a backup.
+func (s *Store) Reload() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	s.keys = make(map[string]*Key)
+	s.byHash = make(map[string]*Key)
+	err := s.load()
+	s.loadErr = err
+	s.dirty = false
+	s.mu.Unlock()
+	s.notify()
+	return err
+}
+
+// SetOnChange registers a callback invoked after the set of keys changes.
+func (s *Store) SetOnChange(fn func()) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.onChange = fn
+	s.mu.Unlock()
+}
+
+// HashKey returns the digest persisted for a plaintext key.
+func HashKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// List returns copies of all keys ordered by creation time.
+func (s *Store) List() []Key {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	out := make([]Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		out = append(out, *key)
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out
+}
+
+// Get returns a copy of the key identified by id.
+func (s *Store) Get(id string) (Key, bool) {
+	if s == nil {
+		return Key{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return Key{}, false
+	}
+	return *key, true
+}
+
+// Len returns the number of stored keys, including revoked and expired ones.
+func (s *Store) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.keys)
+}
+
+// ActiveCount returns the number of keys that can currently authenticate.
+func (s *Store) ActiveCount() int {
+	if s == nil {
+		return 0
+	}
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := 0
+	for _, key := range s.keys {
+		if key.Active(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// Create generates a new key and returns its plaintext value together with the stored record.
+func (s *Store) Create(description string, expiresAt *time.Time) (string, Key, error) {
+	return s.create(description, expiresAt, nil)
+}
+
+// Mint generates a short-lived token restricted by scope. An expiry is mandatory.
+func (s *Store) Mint(description string, expiresAt time.Time, scope Scope) (string, Key, error) {
+	if expiresAt.IsZero() {
+		return "", Key{}, ErrExpiryRequired
+	}
+	scope.Models = normalizeModels(scope.Models)
+	if scope.MaxRequests < 0 {
+		scope.MaxRequests = 0
+	}
+	if scope.MaxTokens < 0 {
+		scope.MaxTokens = 0
+	}
+	return s.create(description, &expiresAt, &scope)
+}
+
+func (s *Store) create(description string, expiresAt *time.Time, scope *Scope) (string, Key, error) {
+	if s == nil {
+		return "", Key{}, fmt.Errorf("managed keys: store is nil")
+	}
+	plaintext, err := generateSecret()
+	if err != nil {
+		return "", Key{}, err
+	}
+	id, err := generateID()
+	if err != nil {
+		return "", Key{}, err
+	}
+	record := &Key{
+		ID:          id,
+		Prefix:      displayPrefix(plaintext),
+		Hash:        HashKey(plaintext),
+		Description: strings.TrimSpace(description),
+		CreatedAt:   time.Now().UTC(),
+		ExpiresAt:   normalizeTime(expiresAt),
+		Scope:       scope,
+	}
+	s.mu.Lock()
+	s.keys[record.ID] = record
+	s.byHash[record.Hash] = record
+	if err = s.saveLocked(); err != nil {
+		delete(s.keys, record.ID)
+		delete(s.byHash, record.Hash)
+		s.mu.Unlock()
+		return "", Key{}, err
+	}
+	out := *record
+	s.mu.Unlock()
+	s.notify()
+	return plaintext, out, nil
+}
+
+// Import stores the digest of an existing plaintext key, typically migrated from config.yaml.
+func (s *Store) Import(plaintext, description string) (Key, error) {
+	if s == nil {
+		return Key{}, fmt.Errorf("managed keys: store is nil")
+	}
+	plaintext = strings.TrimSpace(plaintext)
+	if plaintext == "" {
+		return Key{}, fmt.Errorf("managed keys: empty key")
+	}
+	id, err := generateID()
+	if err != nil {
+		return Key{}, err
+	}
+	hash := HashKey(plaintext)
+	s.mu.Lock()
+	if existing, ok := s.byHash[hash]; ok {
+		out := *existing
+		s.mu.Unlock()
+		return out, ErrDuplicateKey
+	}
+	record := &Key{
+		ID:          id,
+		Prefix:      displayPrefix(plaintext),
+		Hash:        hash,
+		Description: strings.TrimSpace(description),
+		CreatedAt:   time.Now().UTC(),
+	}
+	s.keys[record.ID] = record
+	s.byHash[record.Hash] = record
+	if err = s.saveLocked(); err != nil {
+		delete(s.keys, record.ID)
+		delete(s.byHash, record.Hash)
+		s.mu.Unlock()
+		return Key{}, err
+	}
+	out := *record
+	s.mu.Unlock()
+	s.notify()
+	return out, nil
+}
+
+// Rotate replaces the secret of an active key while keeping its ID, description and expiry.
+func (s *Store) Rotate(id string) (string, Key, error) {
+	if s == nil {
+		return "", Key{}, fmt.Errorf("managed keys: store is nil")
+	}
+	plaintext, err := generateSecret()
+	if err != nil {
+		return "", Key{}, err
+	}
+	s.mu.Lock()
+	record, ok := s.keys[id]
+	if !ok {
+		s.mu.Unlock()
+		return "", Key{}, ErrKeyNotFound
+	}
+	if record.RevokedAt != nil {
+		s.mu.Unlock()
+		return "", Key{}, ErrKeyRevoked
+	}
+	previous := *record
+	now := time.Now().UTC()
+	delete(s.byHash, record.Hash)
+	record.Hash = HashKey(plaintext)
+	record.Prefix = displayPrefix(plaintext)
+	record.RotatedAt = &now
+	s.byHash[record.Hash] = record
+	if err = s.saveLocked(); err != nil {
+		delete(s.byHash, record.Hash)
+		*record = previous
+		s.byHash[record.Hash] = record
+		s.mu.Unlock()
+		return "", Key{}, err
+	}
+	out := *record
+	s.mu.Unlock()
+	s.notify()
+	return plaintext, out, nil
+}
+
+// Update changes the description and/or expiry of a key. Nil arguments leave fields untouched;
+// a zero expiry clears it.
+func (s *Store) Update(id string, description *string, expiresAt *time.Time) (Key, error) {
+	if s == nil {
+		return Key{}, fmt.Errorf("managed keys: store is nil")
+	}
+	s.mu.Lock()
+	record, ok := s.keys[id]
+	if !ok {
+		s.mu.Unlock()
+		return Key{}, ErrKeyNotFound
+	}
+	previous := *record
+	if description != nil {
+		record.Description = strings.TrimSpace(*description)
+	}
+	if expiresAt != nil {
+		record.ExpiresAt = normalizeTime(expiresAt)
+	}
+	if err := record.validate(); err != nil {
+		*record = previous
+		s.mu.Unlock()
+		return Key{}, err
+	}
+	if err := s.saveLocked(); err != nil {
+		*record = previous
+		s.mu.Unlock()
+		return Key{}, err
+	}
+	out := *record
+	s.mu.Unlock()
+	s.notify()
+	return out, nil
+}
+
+// Revoke marks a key as revoked. Revoked keys stay listed for auditing until deleted.
+func (s *Store) Revoke(id string) (Key, error) {
+	if s == nil {
+		return Key{}, fmt.Errorf("managed keys: store is nil")
+	}
+	s.mu.Lock()
+	record, ok := s.keys[id]
+	if !ok {
+		s.mu.Unlock()
+		return Key{}, ErrKeyNotFound
+	}
+	if record.RevokedAt != nil {
+		out := *record
+		s.mu.Unlock()
+		return out, nil
+	}
+	now := time.Now().UTC()
+	record.RevokedAt = &now
+	if err := s.saveLocked(); err != nil {
+		record.RevokedAt = nil
+		s.mu.Unlock()
+		return Key{}, err
+	}
+	out := *record
+	s.mu.Unlock()
+	s.notify()
+	return out, nil
+}
+
+// Delete removes a key record entirely.
+func (s *Store) Delete(id string) error {
+	if s == nil {
+		return fmt.Errorf("managed keys: store is nil")
+	}
+	s.mu.Lock()
+	record, ok := s.keys[id]
+	if !ok {
+		s.mu.Unlock()
+		return ErrKeyNotFound
+	}
+	delete(s.keys, id)
+	delete(s.byHash, record.Hash)
+	if err := s.saveLocked(); err != nil {
+		s.keys[id] = record
+		s.byHash[record.Hash] = record
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// Lookup resolves a plaintext key to its active record and counts the request against it.
+// Usage counters are kept in memory and written to disk shortly afterwards.
+func (s *Store) Lookup(plaintext string) (Key, bool) {
+	return s.lookup(plaintext, true)
+}
+
+// Peek resolves a plaintext key to its active record without counting a request,
+// for endpoints that only report on the key such as GET /v1/limits.
+func (s *Store) Peek(plaintext string) (Key, bool) {
+	return s.lookup(plaintext, false)
+}
+
+func (s *Store) lookup(plaintext string, count bool) (Key, bool) {
+	if s == nil || plaintext == "" {
+		return Key{}, false
+	}
+	hash := HashKey(plaintext)
+	now := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.byHash[hash]
+	if !ok || !record.Active(now) {
+		return Key{}, false
+	}
+	if count {
+		record.LastUsedAt = &now
+		record.Usage.Requests++
+		s.markDirtyLocked()
+	}
+	return *record, true
+}
+
+// RecordTokens adds upstream-reported token usage to the key matching plaintext.
+func (s *Store) RecordTokens(plaintext string, tokens int64) {
+	if s == nil || plaintext == "" || tokens <= 0 {
+		return
+	}
+	hash := HashKey(plaintext)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.byHash[hash]
+	if !ok {
+		return
+	}
+	record.Usage.Tokens += tokens
+	s.markDirtyLocked()
+}
+
+// Flush writes pending usage counters to disk.
+func (s *Store) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+		s.flushTimer = nil
+	}
+	if !s.dirty {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// markDirtyLocked schedules a debounced write of the usage counters.
+func (s *Store) markDirtyLocked() {
+	s.dirty = true
+	if s.flushTimer != nil || s.filePath == "" {
+		return
+	}
+	s.flushTimer = time.AfterFunc(usageFlushDelay, func() {
+		if err := s.Flush(); err != nil {
+			log.Warnf("managed keys: failed to persist usage: %v", err)
+		}
+	})
+}
+
+func (s *Store) notify() {
+	s.mu.RLock()
+	fn := s.onChange
+	s.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+func (s *Store) load() error {
+	if s.filePath == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("managed keys: read failed: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	var loaded storeData
+	if err = json.Unmarshal(raw, &loaded); err != nil {
+		return fmt.Errorf("managed keys: unmarshal failed: %w", err)
+	}
+	for _, key := range loaded.Keys {
+		if key == nil || key.ID == "" || key.Hash == "" {
+			continue
+		}
+		s.keys[key.ID] = key
+		s.byHash[key.Hash] = key
+	}
+	return nil
+}
+
+func (s *Store) saveLocked() error {
+	if s.loadErr != nil {
+		return fmt.Errorf("managed keys: refusing to overwrite unreadable store: %w", s.loadErr)
+	}
+	if s.filePath == "" {
+		return nil
+	}
+	data := storeData{SchemaVersion: schemaVersion, Keys: make([]*Key, 0, len(s.keys))}
+	for _, key := range s.keys {
+		data.Keys = append(data.Keys, key)
+	}
+	sort.Slice(data.Keys, func(i, j int) bool { return data.Keys[i].ID < data.Keys[j].ID })
+
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("managed keys: marshal failed: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(s.filePath), 0o700); err != nil {
+		return fmt.Errorf("managed keys: create dir failed: %w", err)
+	}
+	tmpFile := s.filePath + ".tmp"
+	if err = os.WriteFile(tmpFile, raw, 0o600); err != nil {
+		return fmt.Errorf("managed keys: write tmp failed: %w", err)
+	}
+	if err = os.Rename(tmpFile, s.filePath); err != nil {
+		_ = os.Remove(tmpFile)
+		return fmt.Errorf("managed keys: rename failed: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("managed keys: generate secret failed: %w", err)
+	}
+	return KeyPrefix + hex.EncodeToString(buf), nil
+}
+
+func generateID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("managed keys: generate id failed: %w", err)
+	}
+	return "key_" + hex.EncodeToString(buf), nil
+}
+
+func displayPrefix(plaintext string) string {
+	if strings.HasPrefix(plaintext, KeyPrefix) && len(plaintext) > displayLength {
+		return plaintext[:displayLength]
+	}
+	// Imported keys have no known structure; reveal at most a quarter of them.
+	n := len(plaintext) / 4
+	if n > 4 {
+		n = 4
+	}
+	return plaintext[:n]
+}
+
+func normalizeModels(models []string) []string {
+	if len(models) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(models))
+	seen := make(map[string]struct{}, len(models))
+	for _, model := range models {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+		key := strings.ToLower(model)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, model)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func normalizeTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,267 @@
+package managedkeys
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+func TestStore_CreateLookupAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	plaintext, key, err := store.Create("ci runner", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !strings.HasPrefix(plaintext, KeyPrefix) {
+		t.Fatalf("expected generated key to start with %q, got %q", KeyPrefix, plaintext)
+	}
+	if key.Hash != HashKey(plaintext) {
+		t.Fatal("expected stored hash to match plaintext digest")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store file: %v", err)
+	}
+	if strings.Contains(string(raw), plaintext) {
+		t.Fatal("plaintext key must not be persisted")
+	}
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	got, ok := reloaded.Lookup(plaintext)
+	if !ok {
+		t.Fatal("expected reloaded store to resolve key")
+	}
+	if got.ID != key.ID || got.Description != "ci runner" {
+		t.Fatalf("unexpected record after reload: %+v", got)
+	}
+	if _, ok = reloaded.Lookup("sk-cpa-unknown"); ok {
+		t.Fatal("expected unknown key lookup to fail")
+	}
+}
+
+func TestStore_RotateRevokeAndExpiry(t *testing.T) {
+	store, err := NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	oldSecret, key, err := store.Create("", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	newSecret, rotated, err := store.Rotate(key.ID)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if rotated.ID != key.ID || rotated.RotatedAt == nil {
+		t.Fatalf("unexpected rotated record: %+v", rotated)
+	}
+	if _, ok := store.Lookup(oldSecret); ok {
+		t.Fatal("expected old secret to stop working after rotation")
+	}
+	if _, ok := store.Lookup(newSecret); !ok {
+		t.Fatal("expected new secret to authenticate")
+	}
+
+	if _, err = store.Revoke(key.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, ok := store.Lookup(newSecret); ok {
+		t.Fatal("expected revoked key to be rejected")
+	}
+	if _, _, err = store.Rotate(key.ID); err != ErrKeyRevoked {
+		t.Fatalf("expected ErrKeyRevoked, got %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	expired, _, err := store.Create("expired", &past)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, ok := store.Lookup(expired); ok {
+		t.Fatal("expected expired key to be rejected")
+	}
+	if store.ActiveCount() != 0 {
+		t.Fatalf("expected no active keys, got %d", store.ActiveCount())
+	}
+}
+
+func TestStore_ImportDeduplicates(t *testing.T) {
+	store, _ := NewStore("")
+	if _, err := store.Import("legacy-key-1", "from config"); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if _, err := store.Import("legacy-key-1", "from config"); err != ErrDuplicateKey {
+		t.Fatalf("expected ErrDuplicateKey, got %v", err)
+	}
+	keys := store.List()
+	if len(keys) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(keys))
+	}
+	if len(keys[0].Prefix) > 4 {
+		t.Fatalf("prefix reveals too much of imported key: %q", keys[0].Prefix)
+	}
+}
+
+func TestProvider_Authenticate(t *testing.T) {
+	store, _ := NewStore("")
+	secret, key, _ := store.Create("", nil)
+	p := NewProvider(store)
+
+	req := httptest.NewRequest("GET", "/v1/models", nil)
+	if _, err := p.Authenticate(req.Context(), req); err != sdkaccess.ErrNoCredentials {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer wrong")
+	if _, err := p.Authenticate(req.Context(), req); err != sdkaccess.ErrInvalidCredential {
+		t.Fatalf("expected ErrInvalidCredential, got %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+secret)
+	res, err := p.Authenticate(req.Context(), req)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if res.Metadata["key_id"] != key.ID {
+		t.Fatalf("expected key_id %q, got %q", key.ID, res.Metadata["key_id"])
+	}
+}
+
+func TestStore_UnreadableFileFailsClosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("write store file: %v", err)
+	}
+	store, err := NewStore(path)
+	if err == nil || store.Err() == nil {
+		t.Fatal("expected load error for corrupt store")
+	}
+	if _, _, err = store.Create("", nil); err == nil {
+		t.Fatal("expected Create to fail on an unreadable store")
+	}
+	raw, _ := os.ReadFile(path)
+	if string(raw) != "{not json" {
+		t.Fatalf("corrupt store file was overwritten: %q", raw)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer anything")
+	_, err = NewProvider(store).Authenticate(req.Context(), req)
+	if err == nil || err == sdkaccess.ErrInvalidCredential || err == sdkaccess.ErrNotHandled {
+		t.Fatalf("expected a hard authentication error, got %v", err)
+	}
+}
+
+func TestStore_MintScopedToken(t *testing.T) {
+	store, _ := NewStore("")
+	if _, _, err := store.Mint("demo", time.Time{}, Scope{}); err != ErrExpiryRequired {
+		t.Fatalf("expected ErrExpiryRequired, got %v", err)
+	}
+
+	secret, key, err := store.Mint("demo", time.Now().Add(time.Hour), Scope{
+		Models:      []string{"gemini-2.5-*", " claude-sonnet-4-5 ", "gemini-2.5-*"},
+		MaxRequests: 2,
+		MaxTokens:   100,
+	})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if len(key.Scope.Models) != 2 {
+		t.Fatalf("expected normalized model list, got %v", key.Scope.Models)
+	}
+	if !key.Scope.AllowsModel("gemini-2.5-flash") || !key.Scope.AllowsModel("claude-sonnet-4-5") {
+		t.Fatal("expected scope to allow listed models")
+	}
+	if key.Scope.AllowsModel("gpt-5") {
+		t.Fatal("expected scope to reject unlisted model")
+	}
+
+	if _, ok := store.Lookup(secret); !ok {
+		t.Fatal("expected first request to be allowed")
+	}
+	store.RecordTokens(secret, 150)
+	if _, ok := store.Lookup(secret); ok {
+		t.Fatal("expected token budget exhaustion to reject the token")
+	}
+	got, _ := store.Get(key.ID)
+	if !got.Exhausted() || got.Usage.Requests != 1 || got.Usage.Tokens != 150 {
+		t.Fatalf("unexpected usage state: %+v", got.Usage)
+	}
+}
+
+func TestStore_UpdateKeepsScopedExpiry(t *testing.T) {
+	store, _ := NewStore("")
+	expires := time.Now().Add(time.Hour).UTC()
+	_, key, err := store.Mint("demo", expires, Scope{MaxRequests: 1})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if _, err = store.Update(key.ID, nil, &time.Time{}); err != ErrExpiryRequired {
+		t.Fatalf("expected ErrExpiryRequired, got %v", err)
+	}
+	got, _ := store.Get(key.ID)
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expires) {
+		t.Fatalf("expected expiry to be kept, got %v", got.ExpiresAt)
+	}
+}
+
+func TestStore_UsageIsFlushedLazily(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	store, _ := NewStore(path)
+	secret, key, err := store.Create("", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, ok := store.Lookup(secret); !ok {
+		t.Fatal("expected lookup to succeed")
+	}
+	onDisk, _ := NewStore(path)
+	if got, _ := onDisk.Get(key.ID); got.Usage.Requests != 0 {
+		t.Fatalf("expected usage to stay in memory until flushed, got %d", got.Usage.Requests)
+	}
+	if err = store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	onDisk, _ = NewStore(path)
+	if got, _ := onDisk.Get(key.ID); got.Usage.Requests != 1 {
+		t.Fatalf("expected flushed usage, got %d", got.Usage.Requests)
+	}
+}
+
+func TestProvider_WithoutUsageDoesNotCount(t *testing.T) {
+	store, _ := NewStore("")
+	secret, key, _ := store.Mint("", time.Now().Add(time.Hour), Scope{MaxRequests: 1})
+	p := NewProvider(store)
+
+	req := httptest.NewRequest("GET", "/v1/limits", nil)
+	req.Header.Set("Authorization", "Bearer "+secret)
+	for i := 0; i < 3; i++ {
+		if _, err := p.Authenticate(WithoutUsage(req.Context()), req); err != nil {
+			t.Fatalf("uncounted lookup %d failed: %v", i, err)
+		}
+	}
+	if got, _ := store.Get(key.ID); got.Usage.Requests != 0 {
+		t.Fatalf("expected no counted requests, got %d", got.Usage.Requests)
+	}
+	if _, err := p.Authenticate(req.Context(), req); err != nil {
+		t.Fatalf("counted lookup failed: %v", err)
+	}
+	if _, err := p.Authenticate(req.Context(), req); err != sdkaccess.ErrInvalidCredential {
+		t.Fatalf("expected exhausted token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package managedkeys
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// UsagePlugin charges upstream-reported token usage to the managed key that issued the request.
+type UsagePlugin struct {
+	store atomic.Pointer[Store]
+}
+
+var (
+	sharedUsagePlugin       = &UsagePlugin{}
+	registerUsagePluginOnce sync.Once
+)
+
+// NewUsagePlugin returns a usage plugin bound to store.
+func NewUsagePlugin(store *Store) *UsagePlugin {
+	p := &UsagePlugin{}
+	p.store.Store(store)
+	return p
+}
+
+// BindUsage charges usage to store through a single plugin registered with the default
+// usage manager. Calling it again, e.g. when the server is rebuilt, rebinds that plugin
+// instead of registering another one.
+func BindUsage(store *Store) {
+	sharedUsagePlugin.store.Store(store)
+	registerUsagePluginOnce.Do(func() {
+		coreusage.RegisterPlugin(sharedUsagePlugin)
+	})
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p *UsagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	if p == nil || record.APIKey == "" {
+		return
+	}
+	store := p.store.Load()
+	if store == nil {
+		return
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens <= 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	store.RecordTokens(record.APIKey, tokens)
+}
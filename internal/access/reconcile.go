@@ -134,19 +134,41 @@ func ReconcileProviders(oldCfg, newCfg *config.Config, existing []sdkaccess.Prov
 }
 
 // ApplyAccessProviders reconciles the configured access providers against the
-// currently registered providers and updates the manager. It logs a concise
-// summary of the detected changes and returns whether any provider changed.
-func ApplyAccessProviders(manager *sdkaccess.Manager, oldCfg, newCfg *config.Config) (bool, error) {
+// currently registered providers and updates the manager. Extra providers that are
+// not driven by configuration (such as the managed key store) are appended after the
+// configured ones. It logs a concise summary of the detected changes and returns
+// whether any provider changed.
+func ApplyAccessProviders(manager *sdkaccess.Manager, oldCfg, newCfg *config.Config, extra ...sdkaccess.Provider) (bool, error) {
 	if manager == nil || newCfg == nil {
 		return false, nil
 	}
 
-	existing := manager.Providers()
+	extraIDs := make(map[string]struct{}, len(extra))
+	for _, provider := range extra {
+		if provider != nil {
+			extraIDs[provider.Identifier()] = struct{}{}
+		}
+	}
+	existing := make([]sdkaccess.Provider, 0, len(manager.Providers()))
+	for _, provider := range manager.Providers() {
+		if provider == nil {
+			continue
+		}
+		if _, ok := extraIDs[provider.Identifier()]; ok {
+			continue
+		}
+		existing = append(existing, provider)
+	}
 	providers, added, updated, removed, err := ReconcileProviders(oldCfg, newCfg, existing)
 	if err != nil {
 		log.Errorf("failed to reconcile request auth providers: %v", err)
 		return false, fmt.Errorf("reconciling access providers: %w", err)
 	}
+	for _, provider := range extra {
+		if provider != nil {
+			providers = append(providers, provider)
+		}
+	}
 
 	manager.SetProviders(providers)
 
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// modelCapabilities reports the features the proxy honours for a model once requests are
+// translated for every provider that may serve it.
+type modelCapabilities struct {
+	ID        string   `json:"id"`
+	Providers []string `json:"providers"`
+	Tools     bool     `json:"tools"`
+	// Vision is null when no serving provider's image support is known.
+	Vision *bool `json:"vision"`
+	// JSONSchema reports whether OpenAI response_format json_schema is forwarded.
+	JSONSchema       bool                 `json:"json_schema"`
+	Thinking         thinkingCapabilities `json:"thinking"`
+	MaxContextTokens int                  `json:"max_context_tokens,omitempty"`
+	MaxOutputTokens  int                  `json:"max_output_tokens,omitempty"`
+}
+
+// thinkingCapabilities describes reasoning controls, including "model(suffix)" forms.
+type thinkingCapabilities struct {
+	Supported   bool     `json:"supported"`
+	Passthrough bool     `json:"passthrough,omitempty"`
+	Levels      []string `json:"levels,omitempty"`
+	MinBudget   int      `json:"min_budget,omitempty"`
+	MaxBudget   int      `json:"max_budget,omitempty"`
+	Suffixes    []string `json:"suffixes,omitempty"`
+}
+
+// visionProviders accept image input through their request translators.
+var visionProviders = map[string]bool{
+	"gemini": true, "gemini-cli": true, "vertex": true, "aistudio": true,
+	"antigravity": true, "claude": true, "codex": true,
+	"qwen": false, "iflow": false,
+}
+
+// schemaProviders receive OpenAI response_format json_schema unchanged or translated.
+// Other built-in providers drop it during translation.
+var schemaProviders = map[string]bool{
+	"codex":  true,
+	"gemini": false, "gemini-cli": false, "vertex": false, "aistudio": false,
+	"antigravity": false, "claude": false, "qwen": false, "iflow": false,
+}
+
+// capabilitiesHandler serves GET /v1/capabilities, optionally filtered by ?model=.
+func (s *Server) capabilitiesHandler(c *gin.Context) {
+	allowed := s.callerKeyLimits(c).Models
+	filter := strings.TrimSpace(c.Query("model"))
+	reg := registry.GetGlobalRegistry()
+
+	var out []modelCapabilities
+	for _, model := range reg.GetAvailableModels("openai") {
+		id, _ := model["id"].(string)
+		if id == "" || (filter != "" && id != filter) {
+			continue
+		}
+		if len(allowed) > 0 && !managedkeys.ModelAllowed(allowed, id) {
+			continue
+		}
+		providers := reg.GetModelProviders(id)
+		infos := make([]*registry.ModelInfo, 0, len(providers))
+		for _, provider := range providers {
+			infos = append(infos, reg.GetModelInfo(id, provider))
+		}
+		out = append(out, resolveModelCapabilities(id, providers, infos))
+	}
+	if filter != "" && len(out) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"message": "model not found: " + filter, "type": "invalid_request_error"}})
+		return
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": out})
+}
+
+// resolveModelCapabilities combines per-provider model info conservatively: a feature is
+// reported only when every provider that may serve the model honours it.
+func resolveModelCapabilities(id string, providers []string, infos []*registry.ModelInfo) modelCapabilities {
+	caps := modelCapabilities{ID: id, Providers: providers, Tools: true, JSONSchema: len(providers) > 0}
+
+	visionKnown, visionAll := false, true
+	for _, provider := range providers {
+		vision, known := visionProviders[provider]
+		if !known && modelLooksMultimodal(id) {
+			// OpenAI-compatible upstreams pass images through unchanged.
+			vision, known = true, true
+		}
+		if known {
+			visionKnown = true
+			visionAll = visionAll && vision
+		}
+		if schema, builtin := schemaProviders[provider]; builtin && !schema {
+			caps.JSONSchema = false
+		}
+	}
+	if visionKnown {
+		caps.Vision = &visionAll
+	}
+
+	for _, info := range infos {
+		if info == nil {
+			continue
+		}
+		if info.UserDefined && info.Thinking == nil {
+			caps.Thinking.Passthrough = true
+		}
+		if info.Thinking != nil && !caps.Thinking.Supported {
+			caps.Thinking = describeThinking(info.Thinking)
+		}
+		caps.MaxContextTokens = minPositive(caps.MaxContextTokens, firstPositive(info.ContextLength, info.InputTokenLimit))
+		caps.MaxOutputTokens = minPositive(caps.MaxOutputTokens, firstPositive(info.MaxCompletionTokens, info.OutputTokenLimit))
+		if len(info.SupportedParameters) > 0 && !containsFold(info.SupportedParameters, "tools") {
+			caps.Tools = false
+		}
+	}
+	return caps
+}
+
+func describeThinking(support *registry.ThinkingSupport) thinkingCapabilities {
+	out := thinkingCapabilities{Supported: true}
+	if len(support.Levels) > 0 {
+		out.Levels = append([]string(nil), support.Levels...)
+		for _, level := range support.Levels {
+			out.Suffixes = append(out.Suffixes, "("+level+")")
+		}
+	} else {
+		out.MinBudget = support.Min
+		out.MaxBudget = support.Max
+		out.Suffixes = append(out.Suffixes, "(<budget>)")
+	}
+	if support.ZeroAllowed {
+		out.Suffixes = append(out.Suffixes, "(none)")
+	}
+	if support.DynamicAllowed {
+		out.Suffixes = append(out.Suffixes, "(auto)")
+	}
+	return out
+}
+
+// modelLooksMultimodal guesses image support for models served by OpenAI-compatible upstreams.
+func modelLooksMultimodal(id string) bool {
+	lower := strings.ToLower(id)
+	for _, marker := range []string{"vision", "-vl", "gpt-4o", "gpt-4.1", "gpt-5", "claude", "gemini"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func minPositive(current, candidate int) int {
+	if candidate <= 0 {
+		return current
+	}
+	if current <= 0 || candidate < current {
+		return candidate
+	}
+	return current
+}
+
+func firstPositive(values ...int) int {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), target) {
+			return true
+		}
+	}
+	return false
+}
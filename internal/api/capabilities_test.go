@@ -0,0 +1,45 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+func TestResolveModelCapabilitiesIsConservativeAcrossProviders(t *testing.T) {
+	gemini := &registry.ModelInfo{
+		ID:               "gemini-2.5-pro",
+		InputTokenLimit:  1048576,
+		OutputTokenLimit: 65536,
+		Thinking:         &registry.ThinkingSupport{Min: 128, Max: 32768, DynamicAllowed: true},
+	}
+	caps := resolveModelCapabilities("gemini-2.5-pro", []string{"gemini", "antigravity"}, []*registry.ModelInfo{gemini, {ContextLength: 200000}})
+
+	if !caps.Tools || caps.JSONSchema {
+		t.Fatalf("tools/json_schema = %t/%t, want true/false", caps.Tools, caps.JSONSchema)
+	}
+	if caps.Vision == nil || !*caps.Vision {
+		t.Fatalf("vision = %v, want true", caps.Vision)
+	}
+	if caps.MaxContextTokens != 200000 || caps.MaxOutputTokens != 65536 {
+		t.Fatalf("limits = %d/%d", caps.MaxContextTokens, caps.MaxOutputTokens)
+	}
+	if !caps.Thinking.Supported || caps.Thinking.MaxBudget != 32768 || len(caps.Thinking.Suffixes) != 2 {
+		t.Fatalf("thinking = %+v", caps.Thinking)
+	}
+}
+
+func TestResolveModelCapabilitiesOpenAICompatible(t *testing.T) {
+	caps := resolveModelCapabilities("my-model", []string{"openrouter"}, []*registry.ModelInfo{{UserDefined: true}})
+	if caps.Vision != nil {
+		t.Fatalf("vision = %v, want unknown", *caps.Vision)
+	}
+	if !caps.JSONSchema || !caps.Thinking.Passthrough {
+		t.Fatalf("expected json schema and thinking passthrough, got %+v", caps)
+	}
+
+	caps = resolveModelCapabilities("qwen3-coder-plus", []string{"qwen", "codex"}, nil)
+	if caps.Vision == nil || *caps.Vision || caps.JSONSchema {
+		t.Fatalf("expected qwen to disable vision and json schema, got %+v", caps)
+	}
+}
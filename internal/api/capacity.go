@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+// modelCapacity summarises how much of a model the credential pool can serve right now.
+type modelCapacity struct {
+	ID        string   `json:"id"`
+	Providers []string `json:"providers"`
+	// Credentials counts enabled credentials for the model; Available those not cooling
+	// down or out of quota.
+	Credentials int `json:"credentials"`
+	Available   int `json:"available"`
+	// RemainingPercent averages the remaining quota of credentials that report one. It is
+	// null when none does, e.g. for API key providers.
+	RemainingPercent *float64 `json:"remaining_percent"`
+	// NextResetAt is the soonest quota reset or cooldown end among unavailable credentials.
+	NextResetAt *time.Time `json:"next_reset_at,omitempty"`
+}
+
+// capacityHandler serves GET /v1/capacity, optionally filtered by ?model=.
+func (s *Server) capacityHandler(c *gin.Context) {
+	allowed := s.callerKeyLimits(c).Models
+	filter := strings.TrimSpace(c.Query("model"))
+	reg := registry.GetGlobalRegistry()
+	var auths []*coreauth.Auth
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		auths = s.handlers.AuthManager.List()
+	}
+
+	now := time.Now()
+	var out []modelCapacity
+	for _, model := range reg.GetAvailableModels("openai") {
+		id, _ := model["id"].(string)
+		if id == "" || (filter != "" && id != filter) {
+			continue
+		}
+		if len(allowed) > 0 && !managedkeys.ModelAllowed(allowed, id) {
+			continue
+		}
+		pool := make([]*coreauth.Auth, 0, len(auths))
+		for _, auth := range auths {
+			if auth != nil && reg.ClientSupportsModel(auth.ID, id) {
+				pool = append(pool, auth)
+			}
+		}
+		capacity := resolveModelCapacity(id, pool, now)
+		capacity.Providers = reg.GetModelProviders(id)
+		out = append(out, capacity)
+	}
+	if filter != "" && len(out) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"message": "model not found: " + filter, "type": "invalid_request_error"}})
+		return
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": out})
+}
+
+// resolveModelCapacity aggregates the credentials registered for a model. Disabled
+// credentials and those waiting for a re-login are not counted.
+func resolveModelCapacity(id string, auths []*coreauth.Auth, now time.Time) modelCapacity {
+	capacity := modelCapacity{ID: id}
+	var percentSum float64
+	var percentCount int
+	var nextReset time.Time
+	considerReset := func(t time.Time) {
+		if t.After(now) && (nextReset.IsZero() || t.Before(nextReset)) {
+			nextReset = t
+		}
+	}
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled || auth.NeedsRelogin() {
+			continue
+		}
+		capacity.Credentials++
+		recoverAt, cooling, _ := authModelCooldown(auth, id, now)
+		available := !cooling
+		if cooling {
+			considerReset(recoverAt)
+		}
+		if entry, ok := quota.GetModelQuotaFromMetadata(auth.Metadata, id); ok {
+			percentSum += entry.Percent
+			percentCount++
+			if entry.Percent <= 0 {
+				available = false
+				considerReset(entry.ResetTime)
+			}
+		}
+		if available {
+			capacity.Available++
+		}
+	}
+	if percentCount > 0 {
+		remaining := percentSum / float64(percentCount)
+		capacity.RemainingPercent = &remaining
+	}
+	if !nextReset.IsZero() {
+		capacity.NextResetAt = &nextReset
+	}
+	return capacity
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func TestResolveModelCapacityAggregatesPool(t *testing.T) {
+	now := time.Now()
+	soon := now.Add(10 * time.Minute)
+	later := now.Add(2 * time.Hour)
+	withQuota := func(id string, percent float64, reset time.Time) *coreauth.Auth {
+		auth := &coreauth.Auth{ID: id, Provider: "antigravity", Metadata: map[string]any{}}
+		quota.UpdateMetadata(auth.Metadata, "antigravity", map[string]quota.ModelQuota{"gemini-3-pro": {Percent: percent, ResetTime: reset}}, now)
+		return auth
+	}
+	cooling := withQuota("ag-cooling", 60, later)
+	cooling.ModelStates = map[string]*coreauth.ModelState{"gemini-3-pro": {Unavailable: true, NextRetryAfter: soon}}
+	auths := []*coreauth.Auth{
+		withQuota("ag-full", 100, time.Time{}),
+		withQuota("ag-empty", 0, later),
+		cooling,
+		{ID: "ag-disabled", Disabled: true},
+		{ID: "ag-relogin", Status: coreauth.StatusNeedsRelogin},
+	}
+
+	capacity := resolveModelCapacity("gemini-3-pro", auths, now)
+	if capacity.Credentials != 3 || capacity.Available != 1 {
+		t.Fatalf("credentials/available = %d/%d, want 3/1", capacity.Credentials, capacity.Available)
+	}
+	if capacity.RemainingPercent == nil || *capacity.RemainingPercent != 160.0/3 {
+		t.Fatalf("remaining percent = %v", capacity.RemainingPercent)
+	}
+	if capacity.NextResetAt == nil || !capacity.NextResetAt.Equal(soon) {
+		t.Fatalf("next reset = %v, want %v", capacity.NextResetAt, soon)
+	}
+
+	capacity = resolveModelCapacity("gpt-4o", []*coreauth.Auth{{ID: "key"}}, now)
+	if capacity.Available != 1 || capacity.RemainingPercent != nil || capacity.NextResetAt != nil {
+		t.Fatalf("api key capacity = %+v", capacity)
+	}
+}
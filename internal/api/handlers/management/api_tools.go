@@ -13,6 +13,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/geminicli"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
@@ -670,6 +671,13 @@ func buildProxyTransport(proxyStr string) *http.Transport {
 		log.WithError(errParse).Debug("parse proxy URL failed")
 		return nil
 	}
+	if transport, isUnix, errUnix := util.UnixProxyTransport(proxyURL); isUnix {
+		if errUnix != nil {
+			log.WithError(errUnix).Debug("create unix socket proxy transport failed")
+			return nil
+		}
+		return transport
+	}
 	if proxyURL.Scheme == "" || proxyURL.Host == "" {
 		log.Debug("proxy URL missing scheme/host")
 		return nil
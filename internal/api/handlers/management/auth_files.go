@@ -26,6 +26,7 @@ import (
 	geminiAuth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/gemini"
 	iflowauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/iflow"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/qwen"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
@@ -233,7 +234,11 @@ func stopForwarderInstance(port int, forwarder *callbackForwarder) {
 }
 
 func (h *Handler) managementCallbackURL(path string) (string, error) {
-	if h == nil || h.cfg == nil || h.cfg.Port <= 0 {
+	if h == nil || h.cfg == nil {
+		return "", fmt.Errorf("server port is not configured")
+	}
+	hostPort := callbackHostPort(h.cfg)
+	if hostPort == "" {
 		return "", fmt.Errorf("server port is not configured")
 	}
 	if !strings.HasPrefix(path, "/") {
@@ -243,7 +248,30 @@ func (h *Handler) managementCallbackURL(path string) (string, error) {
 	if h.cfg.TLS.Enable {
 		scheme = "https"
 	}
-	return fmt.Sprintf("%s://127.0.0.1:%d%s", scheme, h.cfg.Port, path), nil
+	return fmt.Sprintf("%s://%s%s", scheme, hostPort, path), nil
+}
+
+// callbackHostPort returns the first TCP listener that serves OAuth callback routes,
+// with wildcard hosts replaced by the IPv4 loopback address.
+func callbackHostPort(cfg *config.Config) string {
+	for _, l := range cfg.EffectiveListeners() {
+		if scope, err := l.NormalizedScope(); err != nil || scope == config.ListenerScopeManagement {
+			continue
+		}
+		network, address, err := l.Endpoint()
+		if err != nil || network != "tcp" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || port == "" || port == "0" {
+			continue
+		}
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		return net.JoinHostPort(host, port)
+	}
+	return ""
 }
 
 func (h *Handler) ListAuthFiles(c *gin.Context) {
@@ -393,6 +421,7 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 		"status_message": auth.StatusMessage,
 		"disabled":       auth.Disabled,
 		"unavailable":    auth.Unavailable,
+		"needs_relogin":  auth.NeedsRelogin(),
 		"runtime_only":   runtimeOnly,
 		"source":         "memory",
 		"size":           int64(0),
@@ -418,6 +447,15 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if !auth.LastRefreshedAt.IsZero() {
 		entry["last_refresh"] = auth.LastRefreshedAt
 	}
+	if !auth.LastUsedAt.IsZero() {
+		entry["last_used_at"] = auth.LastUsedAt
+	}
+	if archivedAt, ok := auth.ArchivedAt(); ok {
+		entry["archived_at"] = archivedAt
+		if reason, _ := auth.Metadata[coreauth.MetadataArchivedReason].(string); reason != "" {
+			entry["archived_reason"] = reason
+		}
+	}
 	if path != "" {
 		entry["path"] = path
 		entry["source"] = "file"
@@ -656,6 +694,7 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 		return
 	}
 	ctx := c.Request.Context()
+	now := time.Now()
 	if all := c.Query("all"); all == "true" || all == "1" || all == "*" {
 		entries, err := os.ReadDir(h.cfg.AuthDir)
 		if err != nil {
@@ -677,14 +716,11 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 					full = abs
 				}
 			}
-			if err = os.Remove(full); err == nil {
-				if errDel := h.deleteTokenRecord(ctx, full); errDel != nil {
-					c.JSON(500, gin.H{"error": errDel.Error()})
-					return
-				}
-				deleted++
-				h.disableAuth(ctx, full)
+			if err = h.removeAuthFile(ctx, full, now); err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
 			}
+			deleted++
 		}
 		c.JSON(200, gin.H{"status": "ok", "deleted": deleted})
 		return
@@ -700,19 +736,14 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 			full = abs
 		}
 	}
-	if err := os.Remove(full); err != nil {
+	if err := h.removeAuthFile(ctx, full, now); err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(404, gin.H{"error": "file not found"})
 		} else {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to remove file: %v", err)})
+			c.JSON(500, gin.H{"error": err.Error()})
 		}
 		return
 	}
-	if err := h.deleteTokenRecord(ctx, full); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-	h.disableAuth(ctx, full)
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
@@ -853,6 +884,7 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 	} else {
 		targetAuth.Status = coreauth.StatusActive
 		targetAuth.StatusMessage = ""
+		targetAuth.ClearArchive(time.Now())
 	}
 	targetAuth.UpdatedAt = time.Now()
 
@@ -864,6 +896,111 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok", "disabled": *req.Disabled})
 }
 
+// RunAuthGC runs the stale auth sweep immediately using the current auth-gc thresholds
+// and returns the archived and deleted auth IDs.
+func (h *Handler) RunAuthGC(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	report := h.authManager.SweepStaleAuths(c.Request.Context(), time.Now())
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAuthFileModelStates returns the per-model cooldown and quota state of every auth, or
+// of the auth selected by the name query parameter.
+func (h *Handler) GetAuthFileModelStates(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	name := strings.TrimSpace(c.Query("name"))
+	var auths []*coreauth.Auth
+	if name != "" {
+		auth := h.findAuthByName(name)
+		if auth == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+			return
+		}
+		auths = []*coreauth.Auth{auth}
+	} else {
+		auths = h.authManager.List()
+	}
+
+	entries := make([]gin.H, 0, len(auths))
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		states := auth.ModelStates
+		if states == nil {
+			states = map[string]*coreauth.ModelState{}
+		}
+		entries = append(entries, gin.H{
+			"id":               auth.ID,
+			"name":             auth.FileName,
+			"provider":         auth.Provider,
+			"status":           auth.Status,
+			"unavailable":      auth.Unavailable,
+			"next_retry_after": auth.NextRetryAfter,
+			"quota":            auth.Quota,
+			"model_states":     states,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i]["id"].(string) < entries[j]["id"].(string)
+	})
+	c.JSON(http.StatusOK, gin.H{"auths": entries})
+}
+
+// DeleteAuthFileModelStates clears the model states of an auth so wrongly-marked models are
+// selectable again without waiting out their cooldown. The model query parameter may be
+// repeated; without it every model state of the auth is cleared.
+func (h *Handler) DeleteAuthFileModelStates(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	auth := h.findAuthByName(name)
+	if auth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	var models []string
+	for _, model := range c.QueryArray("model") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	cleared, ok := h.authManager.ClearModelStates(c.Request.Context(), auth.ID, models...)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "id": auth.ID, "cleared": cleared})
+}
+
+// findAuthByName resolves an auth by ID or file name.
+func (h *Handler) findAuthByName(name string) *coreauth.Auth {
+	if h.authManager == nil || name == "" {
+		return nil
+	}
+	if auth, ok := h.authManager.GetByID(name); ok {
+		return auth
+	}
+	for _, auth := range h.authManager.List() {
+		if auth.FileName == name {
+			return auth
+		}
+	}
+	return nil
+}
+
 func (h *Handler) disableAuth(ctx context.Context, id string) {
 	if h == nil || h.authManager == nil {
 		return
@@ -0,0 +1,186 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	log "github.com/sirupsen/logrus"
+)
+
+// backupPassphraseHeader lets callers supply a passphrase other than the configured one.
+const backupPassphraseHeader = "X-Backup-Passphrase"
+
+// BackupSources returns the on-disk and in-memory state included in backups.
+func (h *Handler) BackupSources() backup.Sources {
+	src := backup.Sources{
+		ConfigPath: h.configFilePath,
+		Usage:      h.usageStats,
+	}
+	if h.cfg != nil {
+		src.AuthDir = h.cfg.AuthDir
+	}
+	if h.managedKeys != nil {
+		src.ManagedKeysPath = h.managedKeys.Path()
+	}
+	if h.quotaStore != nil {
+		src.QuotaPath = h.quotaStore.Path()
+	}
+	return src
+}
+
+// SetQuotaStore sets the running quota store so backups read and restores reload it.
+func (h *Handler) SetQuotaStore(store *quota.Store) { h.quotaStore = store }
+
+// restoreAuthFile persists a restored auth file through the token store and registers
+// it with the auth manager so it is usable without a restart.
+func (h *Handler) restoreAuthFile(ctx context.Context, path string, data []byte) error {
+	metadata := make(map[string]any)
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("invalid auth file %s: %w", filepath.Base(path), err)
+	}
+	provider, _ := metadata["type"].(string)
+	if provider == "" {
+		provider = "unknown"
+	}
+	label := provider
+	if email, ok := metadata["email"].(string); ok && email != "" {
+		label = email
+	}
+	disabled, _ := metadata["disabled"].(bool)
+	now := time.Now()
+	record := &coreauth.Auth{
+		ID:         h.authIDForPath(path),
+		Provider:   provider,
+		FileName:   filepath.Base(path),
+		Label:      label,
+		Status:     coreauth.StatusActive,
+		Disabled:   disabled,
+		Attributes: map[string]string{"path": path, "source": path},
+		Metadata:   metadata,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if disabled {
+		record.Status = coreauth.StatusDisabled
+	}
+	if lastRefresh, ok := extractLastRefreshTimestamp(metadata); ok {
+		record.LastRefreshedAt = lastRefresh
+	}
+	if _, err := h.saveTokenRecord(ctx, record); err != nil {
+		return fmt.Errorf("failed to save auth %s: %w", record.ID, err)
+	}
+	if h.authManager == nil {
+		return nil
+	}
+	if existing, ok := h.authManager.GetByID(record.ID); ok {
+		record.CreatedAt = existing.CreatedAt
+		record.Runtime = existing.Runtime
+		_, err := h.authManager.Update(ctx, record)
+		return err
+	}
+	_, err := h.authManager.Register(ctx, record)
+	return err
+}
+
+func (h *Handler) backupPassphrase(c *gin.Context) string {
+	if p := strings.TrimSpace(c.GetHeader(backupPassphraseHeader)); p != "" {
+		return p
+	}
+	if h.cfg == nil {
+		return ""
+	}
+	return h.cfg.Backup.ResolvedPassphrase()
+}
+
+// DownloadBackup streams an encrypted archive of the current proxy state.
+func (h *Handler) DownloadBackup(c *gin.Context) {
+	passphrase := h.backupPassphrase(c)
+	if passphrase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "backup passphrase is not configured; set backup.passphrase or send " + backupPassphraseHeader})
+		return
+	}
+	if err := h.quotaStore.Flush(); err != nil {
+		log.Warnf("failed to flush quota store before backup: %v", err)
+	}
+	if err := h.managedKeys.Flush(); err != nil {
+		log.Warnf("failed to flush managed keys before backup: %v", err)
+	}
+	var buf bytes.Buffer
+	manifest, err := backup.Create(&buf, h.BackupSources(), passphrase)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create backup: %v", err)})
+		return
+	}
+	name := "cliproxy-backup-" + manifest.CreatedAt.Format("20060102-150405") + backup.FileExtension
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+}
+
+// RestoreBackup decrypts an uploaded archive and writes its contents back. The archive is
+// sent as the raw request body or as a multipart "file" field. Pass include_config=true to
+// also overwrite the config file.
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	passphrase := h.backupPassphrase(c)
+	if passphrase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "backup passphrase is not configured; set backup.passphrase or send " + backupPassphraseHeader})
+		return
+	}
+
+	var body io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		opened, errOpen := file.Open()
+		if errOpen != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+			return
+		}
+		defer func() { _ = opened.Close() }()
+		body = opened
+	}
+
+	ctx := c.Request.Context()
+	opts := backup.RestoreOptions{
+		Config: strings.EqualFold(strings.TrimSpace(c.Query("include_config")), "true"),
+		SaveAuth: func(path string, data []byte) error {
+			return h.restoreAuthFile(ctx, path, data)
+		},
+	}
+	if h.cfg != nil && h.cfg.Backup.MaxRestoreMB > 0 {
+		opts.MaxSize = int64(h.cfg.Backup.MaxRestoreMB) << 20
+	}
+	h.mu.Lock()
+	result, err := backup.Restore(body, h.BackupSources(), passphrase, opts)
+	h.mu.Unlock()
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, backup.ErrBadPassphrase) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if result.Quota && h.quotaStore != nil {
+		if errReload := h.quotaStore.Reload(); errReload != nil {
+			log.Warnf("failed to reload restored quota store: %v", errReload)
+		}
+	}
+	if result.ManagedKeys && h.managedKeys != nil {
+		if errReload := h.managedKeys.Reload(); errReload != nil {
+			log.Warnf("failed to reload restored managed keys: %v", errReload)
+		}
+	}
+	log.Infof("restored backup from %s: %d auth files, config=%t, quota=%t, managed keys=%t",
+		result.Manifest.CreatedAt.Format(time.RFC3339), result.AuthFiles, result.Config, result.Quota, result.ManagedKeys)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "restored": result})
+}
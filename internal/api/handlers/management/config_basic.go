@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -182,6 +183,16 @@ func (h *Handler) GetConfigYAML(c *gin.Context) {
 	_, _ = c.Writer.Write(data)
 }
 
+// GetConfigSchema returns the JSON schema of the config file.
+func (h *Handler) GetConfigSchema(c *gin.Context) {
+	schema, err := config.JSONSchema()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "schema_failed", "message": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/schema+json", schema)
+}
+
 // Debug
 func (h *Handler) GetDebug(c *gin.Context) { c.JSON(200, gin.H{"debug": h.cfg.Debug}) }
 func (h *Handler) PutDebug(c *gin.Context) { h.updateBoolField(c, func(v bool) { h.cfg.Debug = v }) }
@@ -248,6 +259,123 @@ func (h *Handler) PutRequestLog(c *gin.Context) {
 	h.updateBoolField(c, func(v bool) { h.cfg.RequestLog = v })
 }
 
+// Request log levels
+func (h *Handler) GetRequestLogLevels(c *gin.Context) {
+	c.JSON(200, gin.H{"request-log-levels": h.cfg.RequestLogLevels})
+}
+
+// PutRequestLogLevels replaces the per-provider and per-key request log levels.
+func (h *Handler) PutRequestLogLevels(c *gin.Context) {
+	var body struct {
+		Value *config.RequestLogLevels `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Value == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	levels := *body.Value
+	if levels.Default != "" {
+		normalized, ok := config.NormalizeRequestLogLevel(levels.Default)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid level %q", levels.Default)})
+			return
+		}
+		levels.Default = normalized
+	}
+	var err error
+	if levels.Providers, err = normalizeRequestLogLevelMap(levels.Providers); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if levels.Keys, err = normalizeRequestLogLevelMap(levels.Keys); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.cfg.RequestLogLevels = levels
+	h.cfg.SanitizeRequestLogLevels()
+	h.persist(c)
+}
+
+// PatchRequestLogLevels sets or clears a single level.
+// Body: {"scope": "default"|"provider"|"key", "name": "antigravity", "level": "metadata"}; an empty level removes the override.
+func (h *Handler) PatchRequestLogLevels(c *gin.Context) {
+	var body struct {
+		Scope string `json:"scope"`
+		Name  string `json:"name"`
+		Level string `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	level := ""
+	if strings.TrimSpace(body.Level) != "" {
+		normalized, ok := config.NormalizeRequestLogLevel(body.Level)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid level %q", body.Level)})
+			return
+		}
+		level = normalized
+	}
+	name := strings.TrimSpace(body.Name)
+	levels := &h.cfg.RequestLogLevels
+	switch strings.ToLower(strings.TrimSpace(body.Scope)) {
+	case "default", "":
+		levels.Default = level
+	case "provider", "providers":
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		levels.Providers = setRequestLogLevel(levels.Providers, strings.ToLower(name), level)
+	case "key", "keys":
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		name, _ = config.NormalizeRequestLogKeyName(name)
+		levels.Keys = setRequestLogLevel(levels.Keys, name, level)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be default, provider or key"})
+		return
+	}
+	h.persist(c)
+}
+
+func normalizeRequestLogLevelMap(in map[string]string) (map[string]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(in))
+	for name, raw := range in {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		level, ok := config.NormalizeRequestLogLevel(raw)
+		if !ok {
+			return nil, fmt.Errorf("invalid level %q for %q", raw, name)
+		}
+		out[name] = level
+	}
+	return out, nil
+}
+
+func setRequestLogLevel(levels map[string]string, name, level string) map[string]string {
+	if level == "" {
+		delete(levels, name)
+		if len(levels) == 0 {
+			return nil
+		}
+		return levels
+	}
+	if levels == nil {
+		levels = make(map[string]string)
+	}
+	levels[name] = level
+	return levels
+}
+
 // Websocket auth
 func (h *Handler) GetWebsocketAuth(c *gin.Context) {
 	c.JSON(200, gin.H{"ws-auth": h.cfg.WebsocketAuth})
@@ -281,17 +409,7 @@ func (h *Handler) PutForceModelPrefix(c *gin.Context) {
 }
 
 func normalizeRoutingStrategy(strategy string) (string, bool) {
-	normalized := strings.ToLower(strings.TrimSpace(strategy))
-	switch normalized {
-	case "", "round-robin", "roundrobin", "rr":
-		return "round-robin", true
-	case "fill-first", "fillfirst", "ff":
-		return "fill-first", true
-	case "quota-weighted", "quota-weight", "quota", "qw":
-		return "quota-weighted", true
-	default:
-		return "", false
-	}
+	return coreauth.CanonicalSelectorName(strategy)
 }
 
 // RoutingStrategy
@@ -313,7 +431,7 @@ func (h *Handler) PutRoutingStrategy(c *gin.Context) {
 	}
 	normalized, ok := normalizeRoutingStrategy(*body.Value)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid strategy"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid strategy", "strategies": coreauth.SelectorNames()})
 		return
 	}
 	h.cfg.Routing.Strategy = normalized
@@ -0,0 +1,154 @@
+package management
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+// dashboardRecentErrors caps the number of failed requests returned in a dashboard summary.
+const dashboardRecentErrors = 20
+
+type dashboardAuth struct {
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	Provider       string             `json:"provider"`
+	Label          string             `json:"label,omitempty"`
+	Status         coreauth.Status    `json:"status"`
+	StatusMessage  string             `json:"status_message,omitempty"`
+	Disabled       bool               `json:"disabled"`
+	Unavailable    bool               `json:"unavailable"`
+	NextRetryAfter *time.Time         `json:"next_retry_after,omitempty"`
+	Quota          map[string]float64 `json:"quota,omitempty"`
+}
+
+type dashboardTotals struct {
+	Auths        int `json:"auths"`
+	Active       int `json:"active"`
+	Cooling      int `json:"cooling"`
+	Disabled     int `json:"disabled"`
+	NeedsRelogin int `json:"needs_relogin"`
+}
+
+type dashboardUsage struct {
+	Enabled       bool  `json:"enabled"`
+	TotalRequests int64 `json:"total_requests"`
+	SuccessCount  int64 `json:"success_count"`
+	FailureCount  int64 `json:"failure_count"`
+	TotalTokens   int64 `json:"total_tokens"`
+}
+
+type dashboardError struct {
+	Timestamp time.Time `json:"timestamp"`
+	API       string    `json:"api"`
+	Model     string    `json:"model"`
+	Source    string    `json:"source,omitempty"`
+	AuthIndex string    `json:"auth_index,omitempty"`
+}
+
+// GetDashboardSummary returns a compact view of auth health, quota, usage counters and
+// recent failures for the embedded dashboard.
+func (h *Handler) GetDashboardSummary(c *gin.Context) {
+	now := time.Now()
+	auths := make([]dashboardAuth, 0)
+	var totals dashboardTotals
+	if h.authManager != nil {
+		for _, auth := range h.authManager.List() {
+			if auth == nil {
+				continue
+			}
+			entry := dashboardAuth{
+				ID:            auth.ID,
+				Name:          auth.FileName,
+				Provider:      strings.TrimSpace(auth.Provider),
+				Label:         auth.Label,
+				Status:        auth.Status,
+				StatusMessage: auth.StatusMessage,
+				Disabled:      auth.Disabled,
+				Unavailable:   auth.Unavailable && auth.NextRetryAfter.After(now),
+			}
+			if entry.Name == "" {
+				entry.Name = auth.ID
+			}
+			if entry.Unavailable {
+				next := auth.NextRetryAfter
+				entry.NextRetryAfter = &next
+			}
+			if models := quota.ModelsFromMetadata(auth.Metadata); len(models) > 0 {
+				entry.Quota = make(map[string]float64, len(models))
+				for model, q := range models {
+					entry.Quota[model] = q.Percent
+				}
+			}
+			totals.Auths++
+			switch {
+			case entry.Disabled || entry.Status == coreauth.StatusDisabled:
+				totals.Disabled++
+			case auth.NeedsRelogin():
+				totals.NeedsRelogin++
+			case entry.Unavailable:
+				totals.Cooling++
+			default:
+				totals.Active++
+			}
+			auths = append(auths, entry)
+		}
+	}
+	sort.Slice(auths, func(i, j int) bool {
+		if auths[i].Provider != auths[j].Provider {
+			return auths[i].Provider < auths[j].Provider
+		}
+		return auths[i].Name < auths[j].Name
+	})
+
+	stats := dashboardUsage{Enabled: usage.StatisticsEnabled()}
+	recent := make([]dashboardError, 0)
+	if h.usageStats != nil {
+		snapshot := h.usageStats.Snapshot()
+		stats.TotalRequests = snapshot.TotalRequests
+		stats.SuccessCount = snapshot.SuccessCount
+		stats.FailureCount = snapshot.FailureCount
+		stats.TotalTokens = snapshot.TotalTokens
+		recent = recentFailures(snapshot, dashboardRecentErrors)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"generated_at":  now.UTC(),
+		"totals":        totals,
+		"usage":         stats,
+		"auths":         auths,
+		"recent_errors": recent,
+	})
+}
+
+// recentFailures returns the newest failed request details across all APIs and models.
+func recentFailures(snapshot usage.StatisticsSnapshot, limit int) []dashboardError {
+	out := make([]dashboardError, 0)
+	for api, apiSnapshot := range snapshot.APIs {
+		for model, modelSnapshot := range apiSnapshot.Models {
+			for _, detail := range modelSnapshot.Details {
+				if !detail.Failed {
+					continue
+				}
+				out = append(out, dashboardError{
+					Timestamp: detail.Timestamp,
+					API:       api,
+					Model:     model,
+					Source:    detail.Source,
+					AuthIndex: detail.AuthIndex,
+				})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.After(out[j].Timestamp) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 )
 
 const (
@@ -74,6 +75,9 @@ func (h *Handler) GetLogs(c *gin.Context) {
 	}
 
 	lines, total, latest := acc.result()
+	for i := range lines {
+		lines[i] = string(util.RedactSensitiveText([]byte(lines[i])))
+	}
 	if latest == 0 || latest < cutoff {
 		latest = cutoff
 	}
@@ -145,8 +149,8 @@ func (h *Handler) DeleteLogs(c *gin.Context) {
 	})
 }
 
-// GetRequestErrorLogs lists error request log files when RequestLog is disabled.
-// It returns an empty list when RequestLog is enabled.
+// GetRequestErrorLogs lists error request log files when request logging is disabled.
+// It returns an empty list when any request log level is active.
 func (h *Handler) GetRequestErrorLogs(c *gin.Context) {
 	if h == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
@@ -156,7 +160,7 @@ func (h *Handler) GetRequestErrorLogs(c *gin.Context) {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
 		return
 	}
-	if h.cfg.RequestLog {
+	if h.cfg.RequestLogActive() {
 		c.JSON(http.StatusOK, gin.H{"files": []any{}})
 		return
 	}
@@ -294,7 +298,7 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 		return
 	}
 
-	c.FileAttachment(fullPath, matchedFile)
+	serveRedactedLogFile(c, fullPath, matchedFile)
 }
 
 // DownloadRequestErrorLog downloads a specific error request log file by name.
@@ -350,7 +354,7 @@ func (h *Handler) DownloadRequestErrorLog(c *gin.Context) {
 		return
 	}
 
-	c.FileAttachment(fullPath, name)
+	serveRedactedLogFile(c, fullPath, name)
 }
 
 func (h *Handler) logDirectory() string {
@@ -581,3 +585,15 @@ func timestampRotationOrder(name string) (int64, bool) {
 	}
 	return math.MaxInt64 - parsed.Unix(), true
 }
+
+// serveRedactedLogFile sends a log file as an attachment with credentials masked, so that
+// logs written before redaction was configured do not leak tokens through the management API.
+func serveRedactedLogFile(c *gin.Context, fullPath, name string) {
+	data, errRead := os.ReadFile(fullPath)
+	if errRead != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errRead)})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", util.RedactSensitiveText(data))
+}
@@ -0,0 +1,299 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// SetManagedKeyStore wires the hashed inbound API key store used by the managed-api-keys endpoints.
+func (h *Handler) SetManagedKeyStore(store *managedkeys.Store) { h.managedKeys = store }
+
+type managedKeyRequest struct {
+	Description *string `json:"description"`
+	ExpiresAt   *string `json:"expires_at"`
+	ExpiresIn   *int64  `json:"expires_in"`
+}
+
+// expiry resolves expires_at (RFC3339) or expires_in (seconds). An empty expires_at clears the expiry.
+func (r managedKeyRequest) expiry() (*time.Time, error) {
+	if r.ExpiresIn != nil {
+		if *r.ExpiresIn <= 0 {
+			return nil, fmt.Errorf("expires_in must be positive")
+		}
+		t := time.Now().Add(time.Duration(*r.ExpiresIn) * time.Second)
+		return &t, nil
+	}
+	if r.ExpiresAt == nil {
+		return nil, nil
+	}
+	raw := strings.TrimSpace(*r.ExpiresAt)
+	if raw == "" {
+		return &time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("expires_at must be RFC3339")
+	}
+	return &t, nil
+}
+
+func managedKeyView(key managedkeys.Key) gin.H {
+	entry := gin.H{
+		"id":          key.ID,
+		"prefix":      key.Prefix,
+		"description": key.Description,
+		"created_at":  key.CreatedAt,
+		"active":      key.Active(time.Now()),
+	}
+	if key.ExpiresAt != nil {
+		entry["expires_at"] = *key.ExpiresAt
+	}
+	if key.RotatedAt != nil {
+		entry["rotated_at"] = *key.RotatedAt
+	}
+	if key.RevokedAt != nil {
+		entry["revoked_at"] = *key.RevokedAt
+	}
+	if key.LastUsedAt != nil {
+		entry["last_used_at"] = *key.LastUsedAt
+	}
+	if key.Scope != nil {
+		entry["scope"] = key.Scope
+		entry["exhausted"] = key.Exhausted()
+	}
+	entry["usage"] = key.Usage
+	return entry
+}
+
+func (h *Handler) requireManagedKeys(c *gin.Context) bool {
+	if h.managedKeys == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "managed key store unavailable"})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) managedKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, managedkeys.ErrKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "key not found"})
+	case errors.Is(err, managedkeys.ErrKeyRevoked):
+		c.JSON(http.StatusConflict, gin.H{"error": "key revoked"})
+	case errors.Is(err, managedkeys.ErrExpiryRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListManagedKeys returns metadata for all managed inbound API keys. Secrets are never returned.
+func (h *Handler) ListManagedKeys(c *gin.Context) {
+	if !h.requireManagedKeys(c) {
+		return
+	}
+	keys := h.managedKeys.List()
+	out := make([]gin.H, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, managedKeyView(key))
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": out})
+}
+
+// CreateManagedKey generates a new inbound API key. The plaintext key is only returned in this response.
+func (h *Handler) CreateManagedKey(c *gin.Context) {
+	if !h.requireManagedKeys(c) {
+		return
+	}
+	var body managedKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	expiresAt, err := body.expiry()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	description := ""
+	if body.Description != nil {
+		description = *body.Description
+	}
+	plaintext, key, err := h.managedKeys.Create(description, expiresAt)
+	if err != nil {
+		h.managedKeyError(c, err)
+		return
+	}
+	view := managedKeyView(key)
+	view["key"] = plaintext
+	c.JSON(http.StatusOK, view)
+}
+
+// defaultScopedTokenTTL applies when a mint request specifies no expiry.
+const defaultScopedTokenTTL = time.Hour
+
+// MintScopedToken issues a short-lived token limited to specific models and/or a request or token budget.
+// It is intended for demos and CI jobs that should not receive a long-lived key.
+func (h *Handler) MintScopedToken(c *gin.Context) {
+	if !h.requireManagedKeys(c) {
+		return
+	}
+	var body struct {
+		managedKeyRequest
+		Models      []string `json:"models"`
+		MaxRequests int64    `json:"max_requests"`
+		MaxTokens   int64    `json:"max_tokens"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	expiresAt, err := body.expiry()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if expiresAt == nil || expiresAt.IsZero() {
+		t := time.Now().Add(defaultScopedTokenTTL)
+		expiresAt = &t
+	}
+	if !expiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiry must be in the future"})
+		return
+	}
+	if body.MaxRequests < 0 || body.MaxTokens < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limits must not be negative"})
+		return
+	}
+	description := ""
+	if body.Description != nil {
+		description = *body.Description
+	}
+	plaintext, key, err := h.managedKeys.Mint(description, *expiresAt, managedkeys.Scope{
+		Models:      body.Models,
+		MaxRequests: body.MaxRequests,
+		MaxTokens:   body.MaxTokens,
+	})
+	if err != nil {
+		h.managedKeyError(c, err)
+		return
+	}
+	view := managedKeyView(key)
+	view["key"] = plaintext
+	c.JSON(http.StatusOK, view)
+}
+
+// PatchManagedKey updates the description or expiry of a managed key.
+func (h *Handler) PatchManagedKey(c *gin.Context) {
+	if !h.requireManagedKeys(c) {
+		return
+	}
+	var body managedKeyRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	expiresAt, err := body.expiry()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	key, err := h.managedKeys.Update(c.Param("id"), body.Description, expiresAt)
+	if err != nil {
+		h.managedKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, managedKeyView(key))
+}
+
+// RotateManagedKey issues a new secret for an existing key; the previous secret stops working immediately.
+func (h *Handler) RotateManagedKey(c *gin.Context) {
+	if !h.requireManagedKeys(c) {
+		return
+	}
+	plaintext, key, err := h.managedKeys.Rotate(c.Param("id"))
+	if err != nil {
+		h.managedKeyError(c, err)
+		return
+	}
+	view := managedKeyView(key)
+	view["key"] = plaintext
+	c.JSON(http.StatusOK, view)
+}
+
+// DeleteManagedKey revokes a managed key, or removes it entirely when purge=true.
+func (h *Handler) DeleteManagedKey(c *gin.Context) {
+	if !h.requireManagedKeys(c) {
+		return
+	}
+	id := c.Param("id")
+	if purge := c.Query("purge"); purge == "true" || purge == "1" {
+		if err := h.managedKeys.Delete(id); err != nil {
+			h.managedKeyError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		return
+	}
+	key, err := h.managedKeys.Revoke(id)
+	if err != nil {
+		h.managedKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, managedKeyView(key))
+}
+
+// ImportManagedKeys moves the plaintext api-keys from config.yaml into the hashed store.
+// When keep_config is true the config entries are left untouched.
+func (h *Handler) ImportManagedKeys(c *gin.Context) {
+	if !h.requireManagedKeys(c) {
+		return
+	}
+	var body struct {
+		KeepConfig bool `json:"keep_config"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	imported, skipped := 0, 0
+	for i, raw := range h.cfg.APIKeys {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		_, err := h.managedKeys.Import(raw, fmt.Sprintf("imported from config (api-keys[%d])", i))
+		if errors.Is(err, managedkeys.ErrDuplicateKey) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			h.managedKeyError(c, err)
+			return
+		}
+		imported++
+	}
+	if !body.KeepConfig && len(h.cfg.APIKeys) > 0 {
+		h.mu.Lock()
+		h.cfg.APIKeys = nil
+		h.cfg.Access.Providers = nil
+		err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+		h.mu.Unlock()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "imported": imported, "skipped": skipped})
+}
@@ -0,0 +1,255 @@
+package management
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+const (
+	defaultPlanHorizon    = 7 * 24 * time.Hour
+	maxPlanHorizon        = 30 * 24 * time.Hour
+	defaultPlanWindow     = 24 * time.Hour
+	minPlanStep           = 5 * time.Minute
+	maxPlanSteps          = 2000
+	maxPlanExtraAccounts  = 256
+	maxPlanWorkloadModels = 20
+)
+
+// poolPlanRequest describes a hypothetical workload. Providers report quota only as a
+// percentage, so each model states how many tokens one credential serves per quota window.
+type poolPlanRequest struct {
+	HorizonHours float64            `json:"horizon_hours"`
+	Workload     []poolPlanWorkload `json:"workload"`
+}
+
+type poolPlanWorkload struct {
+	Model               string  `json:"model"`
+	RequestsPerDay      float64 `json:"requests_per_day"`
+	AvgInputTokens      float64 `json:"avg_input_tokens"`
+	AvgOutputTokens     float64 `json:"avg_output_tokens"`
+	TokensPerCredential float64 `json:"tokens_per_credential"`
+	// WindowHours is how often a credential's quota resets; defaults to 24.
+	WindowHours float64 `json:"window_hours"`
+}
+
+// poolPlanResult is the simulated outcome of one workload entry.
+type poolPlanResult struct {
+	Model          string  `json:"model"`
+	Credentials    int     `json:"credentials"`
+	DemandPerDay   float64 `json:"demand_tokens_per_day"`
+	CapacityPerDay float64 `json:"capacity_tokens_per_day"`
+	// RemainingPercent averages the current quota of the pool; credentials without a
+	// reported quota count as full.
+	RemainingPercent float64    `json:"remaining_percent"`
+	ExhaustedAt      *time.Time `json:"exhausted_at,omitempty"`
+	UnservedPercent  float64    `json:"unserved_percent"`
+	// AdditionalCredentials is how many fresh credentials would have served the whole
+	// horizon; -1 when more than the planner tries.
+	AdditionalCredentials int `json:"additional_credentials"`
+}
+
+// planCredential is the simulated quota state of one credential.
+type planCredential struct {
+	remaining float64
+	resetAt   time.Time
+}
+
+// PostPoolPlan simulates a workload against the current credential pool and reports when
+// each model's quota would run out and how many credentials would have to be added.
+func (h *Handler) PostPoolPlan(c *gin.Context) {
+	var req poolPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if msg := validatePoolPlan(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	var auths []*coreauth.Auth
+	if h.authManager != nil {
+		auths = h.authManager.List()
+	}
+	now := time.Now()
+	horizon := time.Duration(req.HorizonHours * float64(time.Hour))
+	results := make([]poolPlanResult, 0, len(req.Workload))
+	for _, workload := range req.Workload {
+		results = append(results, planWorkload(workload, poolPlanCredentials(auths, workload, now), now, horizon))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"generated_at":  now,
+		"horizon_hours": req.HorizonHours,
+		"models":        results,
+	})
+}
+
+// validatePoolPlan applies defaults and returns a message describing the first invalid field.
+func validatePoolPlan(req *poolPlanRequest) string {
+	if req.HorizonHours == 0 {
+		req.HorizonHours = defaultPlanHorizon.Hours()
+	}
+	if req.HorizonHours < 0 || req.HorizonHours > maxPlanHorizon.Hours() {
+		return fmt.Sprintf("horizon_hours must be between 0 and %.0f", maxPlanHorizon.Hours())
+	}
+	if len(req.Workload) == 0 || len(req.Workload) > maxPlanWorkloadModels {
+		return fmt.Sprintf("workload must list between 1 and %d models", maxPlanWorkloadModels)
+	}
+	for i := range req.Workload {
+		w := &req.Workload[i]
+		w.Model = strings.TrimSpace(w.Model)
+		switch {
+		case w.Model == "":
+			return fmt.Sprintf("workload[%d].model is required", i)
+		case w.RequestsPerDay < 0 || w.AvgInputTokens < 0 || w.AvgOutputTokens < 0:
+			return fmt.Sprintf("workload[%d] rates must not be negative", i)
+		case w.TokensPerCredential <= 0:
+			return fmt.Sprintf("workload[%d].tokens_per_credential must be positive", i)
+		case w.WindowHours < 0:
+			return fmt.Sprintf("workload[%d].window_hours must not be negative", i)
+		}
+		if w.WindowHours == 0 {
+			w.WindowHours = defaultPlanWindow.Hours()
+		}
+	}
+	return ""
+}
+
+// poolPlanCredentials returns the usable credentials for the workload's model with their
+// remaining tokens. Credentials without a known reset start a full window now.
+func poolPlanCredentials(auths []*coreauth.Auth, workload poolPlanWorkload, now time.Time) []planCredential {
+	reg := registry.GetGlobalRegistry()
+	window := time.Duration(workload.WindowHours * float64(time.Hour))
+	var out []planCredential
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled || auth.NeedsRelogin() {
+			continue
+		}
+		if !reg.ClientSupportsModel(auth.ID, workload.Model) {
+			continue
+		}
+		credential := planCredential{remaining: workload.TokensPerCredential, resetAt: now.Add(window)}
+		if entry, ok := quota.GetModelQuotaFromMetadata(auth.Metadata, workload.Model); ok {
+			credential.remaining = workload.TokensPerCredential * entry.Percent / 100
+			if entry.ResetTime.After(now) {
+				credential.resetAt = entry.ResetTime
+			}
+		}
+		out = append(out, credential)
+	}
+	return out
+}
+
+// planWorkload simulates the workload on the pool and on pools with extra fresh credentials.
+func planWorkload(workload poolPlanWorkload, pool []planCredential, now time.Time, horizon time.Duration) poolPlanResult {
+	window := time.Duration(workload.WindowHours * float64(time.Hour))
+	demandPerDay := workload.RequestsPerDay * (workload.AvgInputTokens + workload.AvgOutputTokens)
+	result := poolPlanResult{
+		Model:          workload.Model,
+		Credentials:    len(pool),
+		DemandPerDay:   demandPerDay,
+		CapacityPerDay: float64(len(pool)) * workload.TokensPerCredential * 24 / workload.WindowHours,
+	}
+	if len(pool) > 0 {
+		var sum float64
+		for _, credential := range pool {
+			sum += credential.remaining / workload.TokensPerCredential * 100
+		}
+		result.RemainingPercent = sum / float64(len(pool))
+	}
+
+	exhaustedAt, unserved := simulatePool(pool, demandPerDay, workload.TokensPerCredential, window, now, horizon)
+	if !exhaustedAt.IsZero() {
+		result.ExhaustedAt = &exhaustedAt
+	}
+	if total := demandPerDay * horizon.Hours() / 24; total > 0 {
+		result.UnservedPercent = math.Round(unserved/total*10000) / 100
+	}
+
+	withExtra := func(extra int) bool {
+		grown := append(append(make([]planCredential, 0, len(pool)+extra), pool...), make([]planCredential, extra)...)
+		for i := len(pool); i < len(grown); i++ {
+			grown[i] = planCredential{remaining: workload.TokensPerCredential, resetAt: now.Add(window)}
+		}
+		at, _ := simulatePool(grown, demandPerDay, workload.TokensPerCredential, window, now, horizon)
+		return at.IsZero()
+	}
+	if exhaustedAt.IsZero() {
+		return result
+	}
+	// Double until enough, then bisect: pools usually need only a few more accounts.
+	low, high := 1, 1
+	for !withExtra(high) {
+		if high == maxPlanExtraAccounts {
+			result.AdditionalCredentials = -1
+			return result
+		}
+		low, high = high+1, min(high*2, maxPlanExtraAccounts)
+	}
+	for low < high {
+		mid := (low + high) / 2
+		if withExtra(mid) {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	result.AdditionalCredentials = low
+	return result
+}
+
+// simulatePool drains the pool at a constant rate in fixed steps (at most maxPlanSteps), spreading demand evenly
+// over credentials with quota left as the round-robin selector does, and restoring each
+// credential to full capacity at its reset. It returns when demand first went unserved and
+// how many tokens were not served over the horizon.
+func simulatePool(initial []planCredential, demandPerDay, capacity float64, window time.Duration, now time.Time, horizon time.Duration) (time.Time, float64) {
+	pool := append([]planCredential(nil), initial...)
+	step := max(minPlanStep, horizon/maxPlanSteps)
+	perStep := demandPerDay * step.Hours() / 24
+	var exhaustedAt time.Time
+	var unserved float64
+	for elapsed := time.Duration(0); elapsed < horizon; elapsed += step {
+		t := now.Add(elapsed)
+		for i := range pool {
+			for !pool[i].resetAt.After(t) {
+				pool[i].remaining = capacity
+				pool[i].resetAt = pool[i].resetAt.Add(window)
+			}
+		}
+		demand := perStep
+		for demand > 1e-9 {
+			active := 0
+			share := math.Inf(1)
+			for _, credential := range pool {
+				if credential.remaining > 1e-9 {
+					active++
+					share = math.Min(share, credential.remaining)
+				}
+			}
+			if active == 0 {
+				break
+			}
+			share = math.Min(share, demand/float64(active))
+			for i := range pool {
+				if pool[i].remaining > 1e-9 {
+					pool[i].remaining -= share
+				}
+			}
+			demand -= share * float64(active)
+		}
+		if demand > 1e-9 {
+			unserved += demand
+			if exhaustedAt.IsZero() {
+				exhaustedAt = t
+			}
+		}
+	}
+	return exhaustedAt, unserved
+}
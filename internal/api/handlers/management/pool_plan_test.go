@@ -0,0 +1,61 @@
+package management
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanWorkloadReportsExhaustionAndMissingCredentials(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	workload := poolPlanWorkload{
+		Model:               "gemini-3-pro",
+		RequestsPerDay:      1000,
+		AvgInputTokens:      1200,
+		AvgOutputTokens:     300,
+		TokensPerCredential: 1_000_000,
+		WindowHours:         24,
+	}
+	pool := []planCredential{{remaining: 500_000, resetAt: now.Add(12 * time.Hour)}}
+
+	result := planWorkload(workload, pool, now, 72*time.Hour)
+	if result.Credentials != 1 || result.RemainingPercent != 50 || result.DemandPerDay != 1_500_000 || result.CapacityPerDay != 1_000_000 {
+		t.Fatalf("summary = %+v", result)
+	}
+	if result.ExhaustedAt == nil || result.ExhaustedAt.Sub(now) != 8*time.Hour {
+		t.Fatalf("exhausted at = %v, want 8h after start", result.ExhaustedAt)
+	}
+	if result.UnservedPercent <= 0 || result.AdditionalCredentials != 1 {
+		t.Fatalf("unserved = %v%%, additional = %d, want >0 and 1", result.UnservedPercent, result.AdditionalCredentials)
+	}
+
+	workload.RequestsPerDay = 100
+	result = planWorkload(workload, pool, now, 72*time.Hour)
+	if result.ExhaustedAt != nil || result.UnservedPercent != 0 || result.AdditionalCredentials != 0 {
+		t.Fatalf("light workload = %+v", result)
+	}
+
+	result = planWorkload(workload, nil, now, 72*time.Hour)
+	if result.ExhaustedAt == nil || !result.ExhaustedAt.Equal(now) || result.AdditionalCredentials != 1 {
+		t.Fatalf("empty pool = %+v", result)
+	}
+}
+
+func TestValidatePoolPlanAppliesDefaults(t *testing.T) {
+	req := poolPlanRequest{Workload: []poolPlanWorkload{{Model: " gemini-3-pro ", RequestsPerDay: 10, TokensPerCredential: 1000}}}
+	if msg := validatePoolPlan(&req); msg != "" {
+		t.Fatalf("valid request rejected: %s", msg)
+	}
+	if req.HorizonHours != 168 || req.Workload[0].WindowHours != 24 || req.Workload[0].Model != "gemini-3-pro" {
+		t.Fatalf("defaults not applied: %+v", req)
+	}
+	for _, bad := range []poolPlanRequest{
+		{},
+		{HorizonHours: 1000, Workload: []poolPlanWorkload{{Model: "m", TokensPerCredential: 1}}},
+		{Workload: []poolPlanWorkload{{Model: "m"}}},
+		{Workload: []poolPlanWorkload{{TokensPerCredential: 1}}},
+	} {
+		if msg := validatePoolPlan(&bad); msg == "" {
+			t.Fatalf("invalid request accepted: %+v", bad)
+		}
+	}
+}
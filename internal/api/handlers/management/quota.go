@@ -1,6 +1,16 @@
 package management
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
 
 // Quota exceeded toggles
 func (h *Handler) GetSwitchProject(c *gin.Context) {
@@ -16,3 +26,192 @@ func (h *Handler) GetSwitchPreviewModel(c *gin.Context) {
 func (h *Handler) PutSwitchPreviewModel(c *gin.Context) {
 	h.updateBoolField(c, func(v bool) { h.cfg.QuotaExceeded.SwitchPreviewModel = v })
 }
+
+// GetQuotaRefreshes lists the pending scheduled Antigravity quota refreshes with their fire
+// times, earliest first.
+func (h *Handler) GetQuotaRefreshes(c *gin.Context) {
+	pending := executor.PendingQuotaRefreshes()
+	entries := make([]gin.H, 0, len(pending))
+	for _, entry := range pending {
+		item := gin.H{"id": entry.AuthID, "fire_at": entry.FireAt}
+		if auth := h.findAuthByName(entry.AuthID); auth != nil {
+			item["name"] = auth.FileName
+			item["provider"] = auth.Provider
+		}
+		entries = append(entries, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"refreshes": entries})
+}
+
+// DeleteQuotaRefresh cancels the pending quota refresh of the auth named by the name query
+// parameter.
+func (h *Handler) DeleteQuotaRefresh(c *gin.Context) {
+	authID, ok := h.quotaRefreshAuthID(c)
+	if !ok {
+		return
+	}
+	if !executor.CancelQuotaRefresh(authID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no pending quota refresh"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "id": authID})
+}
+
+// TriggerQuotaRefresh runs the quota refresh of the auth named by the name query parameter
+// immediately, replacing any pending scheduled refresh.
+func (h *Handler) TriggerQuotaRefresh(c *gin.Context) {
+	authID, ok := h.quotaRefreshAuthID(c)
+	if !ok {
+		return
+	}
+	if err := executor.TriggerQuotaRefresh(c.Request.Context(), authID); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "id": authID})
+}
+
+func (h *Handler) quotaRefreshAuthID(c *gin.Context) (string, bool) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return "", false
+	}
+	if auth := h.findAuthByName(name); auth != nil {
+		return auth.ID, true
+	}
+	return name, true
+}
+
+// quotaAuth is one credential in the GET /quota response.
+type quotaAuth struct {
+	ID        string `json:"id"`
+	AuthIndex string `json:"auth_index"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	// Source is "store" when the percentages come from the quota store, "metadata" when they
+	// were read from the auth file, and empty when no snapshot is known.
+	Source    string       `json:"source,omitempty"`
+	UpdatedAt *time.Time   `json:"updated_at,omitempty"`
+	Models    []quotaModel `json:"models"`
+}
+
+// quotaModel is the quota of one model of a credential.
+type quotaModel struct {
+	Model          string     `json:"model"`
+	Percent        *float64   `json:"percent,omitempty"`
+	ResetTime      *time.Time `json:"reset_time,omitempty"`
+	Unavailable    bool       `json:"unavailable,omitempty"`
+	NextRetryAfter *time.Time `json:"next_retry_after,omitempty"`
+	QuotaExceeded  bool       `json:"quota_exceeded,omitempty"`
+	NextRecoverAt  *time.Time `json:"next_recover_at,omitempty"`
+}
+
+// GetQuota returns the remaining quota percentage and reset time of every model of every
+// credential, merged with the models' cooldown state, so dashboards need not parse auth
+// files. The optional provider and name query parameters narrow the result.
+func (h *Handler) GetQuota(c *gin.Context) {
+	provider := strings.ToLower(strings.TrimSpace(c.Query("provider")))
+	name := strings.TrimSpace(c.Query("name"))
+	now := time.Now()
+	auths := make([]quotaAuth, 0)
+	if h.authManager != nil {
+		for _, auth := range h.authManager.List() {
+			if auth == nil {
+				continue
+			}
+			if provider != "" && !strings.EqualFold(strings.TrimSpace(auth.Provider), provider) {
+				continue
+			}
+			if name != "" && auth.FileName != name && auth.ID != name {
+				continue
+			}
+			auths = append(auths, h.buildQuotaAuth(auth, now))
+		}
+	}
+	sort.Slice(auths, func(i, j int) bool {
+		if auths[i].Provider != auths[j].Provider {
+			return auths[i].Provider < auths[j].Provider
+		}
+		return auths[i].Name < auths[j].Name
+	})
+	c.JSON(http.StatusOK, gin.H{"auths": auths})
+}
+
+func (h *Handler) buildQuotaAuth(auth *coreauth.Auth, now time.Time) quotaAuth {
+	entry := quotaAuth{
+		ID:        auth.ID,
+		AuthIndex: auth.EnsureIndex(),
+		Name:      auth.FileName,
+		Provider:  strings.TrimSpace(auth.Provider),
+		Models:    make([]quotaModel, 0),
+	}
+	if entry.Name == "" {
+		entry.Name = auth.ID
+	}
+	var models map[string]quota.ModelQuota
+	if h.quotaStore != nil {
+		if stored, ok := h.quotaStore.GetEntry(auth.ID); ok && len(stored.Models) > 0 {
+			models = stored.Models
+			entry.Source = "store"
+			if !stored.UpdatedAt.IsZero() {
+				updated := stored.UpdatedAt
+				entry.UpdatedAt = &updated
+			}
+		}
+	}
+	if models == nil {
+		if models = quota.ModelsFromMetadata(auth.Metadata); len(models) > 0 {
+			entry.Source = "metadata"
+		}
+	}
+
+	byKey := make(map[string]*quotaModel)
+	lookup := func(model string) *quotaModel {
+		key := quota.NormalizeModelKey(model)
+		if key == "" {
+			key = model
+		}
+		if m := byKey[key]; m != nil {
+			return m
+		}
+		m := &quotaModel{Model: key}
+		byKey[key] = m
+		return m
+	}
+	for model, q := range models {
+		m := lookup(model)
+		percent := q.Percent
+		m.Percent = &percent
+		if !q.ResetTime.IsZero() {
+			reset := q.ResetTime
+			m.ResetTime = &reset
+		}
+	}
+	for model, state := range auth.ModelStates {
+		if state == nil {
+			continue
+		}
+		cooling := state.Unavailable && state.NextRetryAfter.After(now)
+		exceeded := state.Quota.Exceeded && state.Quota.NextRecoverAt.After(now)
+		if !cooling && !exceeded {
+			continue
+		}
+		m := lookup(model)
+		if cooling {
+			m.Unavailable = true
+			next := state.NextRetryAfter
+			m.NextRetryAfter = &next
+		}
+		if exceeded {
+			m.QuotaExceeded = true
+			recoverAt := state.Quota.NextRecoverAt
+			m.NextRecoverAt = &recoverAt
+		}
+	}
+	for _, m := range byKey {
+		entry.Models = append(entry.Models, *m)
+	}
+	sort.Slice(entry.Models, func(i, j int) bool { return entry.Models[i].Model < entry.Models[j].Model })
+	return entry
+}
@@ -0,0 +1,71 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func TestGetQuotaMergesStoreAndModelStates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, err := quota.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	reset := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	store.Set("ag-1", "antigravity", map[string]quota.ModelQuota{"gemini-3-pro": {Percent: 25, ResetTime: reset}}, time.Now())
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	retry := time.Now().Add(time.Hour)
+	auths := []*coreauth.Auth{
+		{ID: "ag-1", FileName: "ag-1.json", Provider: "antigravity", ModelStates: map[string]*coreauth.ModelState{
+			"claude-sonnet-4-5": {Unavailable: true, NextRetryAfter: retry, Quota: coreauth.QuotaState{Exceeded: true, NextRecoverAt: retry}},
+		}},
+		{ID: "codex-1", FileName: "codex-1.json", Provider: "codex"},
+	}
+	for _, auth := range auths {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register: %v", errRegister)
+		}
+	}
+	h := &Handler{authManager: manager, quotaStore: store}
+
+	rec := serveManagement(h.GetQuota, http.MethodGet, "/?provider=antigravity", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("quota = %d %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Auths []quotaAuth `json:"auths"`
+	}
+	if err = json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Auths) != 1 || body.Auths[0].ID != "ag-1" || body.Auths[0].Source != "store" || body.Auths[0].AuthIndex == "" {
+		t.Fatalf("unexpected auths: %+v", body.Auths)
+	}
+	models := body.Auths[0].Models
+	if len(models) != 2 {
+		t.Fatalf("expected 2 models, got %+v", models)
+	}
+	cooling, pro := models[0], models[1]
+	if cooling.Model != "claude-sonnet-4-5" || !cooling.Unavailable || !cooling.QuotaExceeded || cooling.NextRecoverAt == nil || cooling.Percent != nil {
+		t.Fatalf("unexpected cooling model: %+v", cooling)
+	}
+	if pro.Model != "gemini-3-pro" || pro.Percent == nil || *pro.Percent != 25 || pro.ResetTime == nil || !pro.ResetTime.Equal(reset) {
+		t.Fatalf("unexpected quota model: %+v", pro)
+	}
+
+	rec = serveManagement(h.GetQuota, http.MethodGet, "/", "")
+	if err = json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Auths) != 2 || body.Auths[1].Provider != "codex" || body.Auths[1].Source != "" || len(body.Auths[1].Models) != 0 {
+		t.Fatalf("unexpected unfiltered auths: %+v", body.Auths)
+	}
+}
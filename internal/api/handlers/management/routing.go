@@ -0,0 +1,76 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// GetRoutingDecisions returns recently sampled selector decisions. The optional model and
+// auth_id query parameters filter the list.
+func (h *Handler) GetRoutingDecisions(c *gin.Context) {
+	var decisions []coreauth.SelectionDecision
+	if h.authManager != nil {
+		decisions = h.authManager.SelectionDecisions()
+	}
+	model := strings.TrimSpace(c.Query("model"))
+	authID := strings.TrimSpace(c.Query("auth_id"))
+	out := make([]coreauth.SelectionDecision, 0, len(decisions))
+	for _, decision := range decisions {
+		if model != "" && decision.Model != model {
+			continue
+		}
+		if authID != "" && !decisionMentionsAuth(decision, authID) {
+			continue
+		}
+		out = append(out, decision)
+	}
+	enabled := h.cfg != nil && h.cfg.Routing.DecisionLog.Enabled
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "decisions": out})
+}
+
+func decisionMentionsAuth(decision coreauth.SelectionDecision, authID string) bool {
+	for _, candidate := range decision.Candidates {
+		if candidate.AuthID == authID {
+			return true
+		}
+	}
+	for _, excluded := range decision.Excluded {
+		if excluded.AuthID == authID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLoadShedding reports per-provider upstream health, which providers are currently
+// restricted to essential traffic, and recent shedding transitions.
+func (h *Handler) GetLoadShedding(c *gin.Context) {
+	var (
+		providers []coreauth.LoadSheddingStatus
+		events    []coreauth.LoadSheddingEvent
+	)
+	if h.authManager != nil {
+		providers, events = h.authManager.LoadSheddingStatus()
+	}
+	enabled := h.cfg != nil && h.cfg.LoadShedding.Enabled
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "providers": providers, "events": events})
+}
+
+// GetAvailability reports each provider's availability over the last 24 hours, 7 days and
+// 30 days as the share of windows with traffic in which most upstream requests succeeded.
+func (h *Handler) GetAvailability(c *gin.Context) {
+	providers := make([]coreauth.ProviderAvailability, 0)
+	var since time.Time
+	if h.authManager != nil {
+		providers, since = h.authManager.AvailabilityReport()
+	}
+	body := gin.H{"window_minutes": int(coreauth.AvailabilityWindow / time.Minute), "providers": providers}
+	if !since.IsZero() {
+		body["tracking_since"] = since.UTC()
+	}
+	c.JSON(http.StatusOK, body)
+}
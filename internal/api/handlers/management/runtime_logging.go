@@ -0,0 +1,103 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// runtimeLoggingPatch changes logging at runtime without touching the config file.
+// Empty strings and empty lists clear the corresponding override.
+type runtimeLoggingPatch struct {
+	Level          *string   `json:"level"`
+	DebugProviders *[]string `json:"debug-providers"`
+	DebugAuths     *[]string `json:"debug-auths"`
+	RequestCapture *string   `json:"request-capture"`
+}
+
+// GetRuntimeLogging returns the log level, targeted debug filters and request capture state.
+func (h *Handler) GetRuntimeLogging(c *gin.Context) {
+	c.JSON(http.StatusOK, h.runtimeLoggingStatus())
+}
+
+// PatchRuntimeLogging applies runtime logging overrides. They last until the process exits
+// or they are cleared; the config file is not modified.
+func (h *Handler) PatchRuntimeLogging(c *gin.Context) {
+	var body runtimeLoggingPatch
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	var level *log.Level
+	if body.Level != nil && strings.TrimSpace(*body.Level) != "" {
+		parsed, err := log.ParseLevel(strings.TrimSpace(*body.Level))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid level"})
+			return
+		}
+		level = &parsed
+	}
+	capture := ""
+	if body.RequestCapture != nil && strings.TrimSpace(*body.RequestCapture) != "" {
+		normalized, ok := config.NormalizeRequestLogLevel(*body.RequestCapture)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request-capture level"})
+			return
+		}
+		capture = normalized
+	}
+
+	if body.Level != nil {
+		util.SetLogLevelOverride(level)
+	}
+	if body.DebugProviders != nil || body.DebugAuths != nil {
+		status := util.CurrentLogLevelStatus()
+		providers, auths := status.DebugProviders, status.DebugAuths
+		if body.DebugProviders != nil {
+			providers = *body.DebugProviders
+		}
+		if body.DebugAuths != nil {
+			auths = *body.DebugAuths
+		}
+		util.SetDebugTargets(providers, auths)
+	}
+	if body.RequestCapture != nil {
+		h.setRequestCapture(capture)
+	}
+	c.JSON(http.StatusOK, h.runtimeLoggingStatus())
+}
+
+// DeleteRuntimeLogging clears all runtime logging overrides and returns to the config.
+func (h *Handler) DeleteRuntimeLogging(c *gin.Context) {
+	util.SetLogLevelOverride(nil)
+	util.SetDebugTargets(nil, nil)
+	h.setRequestCapture("")
+	c.JSON(http.StatusOK, h.runtimeLoggingStatus())
+}
+
+func (h *Handler) setRequestCapture(level string) {
+	logging.SetRequestCaptureOverride(level)
+	if h.requestLogToggle != nil && h.cfg != nil {
+		h.requestLogToggle(logging.RequestCaptureActive(&h.cfg.SDKConfig))
+	}
+}
+
+func (h *Handler) runtimeLoggingStatus() gin.H {
+	active := false
+	if h.cfg != nil {
+		active = logging.RequestCaptureActive(&h.cfg.SDKConfig)
+	}
+	return gin.H{
+		"log": util.CurrentLogLevelStatus(),
+		"request-capture": gin.H{
+			"override": logging.RequestCaptureOverride(),
+			"active":   active,
+		},
+	}
+}
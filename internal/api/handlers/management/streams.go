@@ -0,0 +1,70 @@
+package management
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	sdkhandlers "github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// GetLiveStreams lists the streaming responses that can currently be followed.
+func (h *Handler) GetLiveStreams(c *gin.Context) {
+	enabled := h.cfg != nil && h.cfg.StreamFanout
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "streams": sdkhandlers.LiveStreams()})
+}
+
+// GetLiveStreamEvents follows a live stream by stream or upstream response ID as server-sent
+// events: a "chunk" event per chunk sent to the client, replaying those sent before the
+// subscription, a "truncated" event first when the earlier chunks were too large to keep,
+// and a "done" event when the stream ends. Slow subscribers are disconnected without "done".
+func (h *Handler) GetLiveStreamEvents(c *gin.Context) {
+	chunks, truncated, cancel, ok := sdkhandlers.SubscribeStream(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	if truncated {
+		_, _ = c.Writer.WriteString("event: truncated\ndata: {}\n\n")
+	}
+	c.Writer.Flush()
+
+	finished := false
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case chunk, open := <-chunks:
+			if !open {
+				finished = true
+				return false
+			}
+			_, _ = w.Write(liveStreamEvent(chunk))
+			return true
+		}
+	})
+	if finished {
+		_, _ = c.Writer.WriteString("event: done\ndata: {}\n\n")
+		c.Writer.Flush()
+	}
+}
+
+// liveStreamEvent frames a chunk as a "chunk" event; each of its lines becomes a data line.
+func liveStreamEvent(chunk []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("event: chunk\n")
+	for _, line := range bytes.Split(bytes.TrimRight(chunk, "\n"), []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
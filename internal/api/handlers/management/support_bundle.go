@@ -0,0 +1,304 @@
+package management
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// supportBundleLogLines is the number of recent log lines included in a support bundle.
+	supportBundleLogLines = 500
+	// supportBundleErrors caps the recent failed requests included in a support bundle.
+	supportBundleErrors = 100
+)
+
+// supportSecretKeys are config key substrings whose values are redacted in support bundles.
+var supportSecretKeys = []string{"key", "secret", "token", "password", "passphrase", "authorization", "cookie", "credential"}
+
+// supportAuth is the sanitized state of one auth in a support bundle.
+type supportAuth struct {
+	ID             string          `json:"id"`
+	Provider       string          `json:"provider"`
+	Status         coreauth.Status `json:"status"`
+	StatusMessage  string          `json:"status_message,omitempty"`
+	Disabled       bool            `json:"disabled"`
+	Unavailable    bool            `json:"unavailable"`
+	NeedsRelogin   bool            `json:"needs_relogin"`
+	NextRetryAfter *time.Time      `json:"next_retry_after,omitempty"`
+	LastError      *coreauth.Error `json:"last_error,omitempty"`
+	LastRefreshed  *time.Time      `json:"last_refreshed_at,omitempty"`
+}
+
+// supportAliases replaces auth IDs, which often contain account e-mails, with stable
+// placeholders so the files of a bundle still refer to the same auths.
+type supportAliases map[string]string
+
+func (a supportAliases) of(id string) string {
+	if id == "" {
+		return ""
+	}
+	if alias, ok := a[id]; ok {
+		return alias
+	}
+	alias := "auth-" + strconv.Itoa(len(a)+1)
+	a[id] = alias
+	return alias
+}
+
+// DownloadSupportBundle returns a zip archive for bug reports: version information, the
+// config with secrets redacted, auth states, quota, selector state and recent errors and
+// log lines. Auth IDs are replaced by placeholders and credentials are masked throughout.
+func (h *Handler) DownloadSupportBundle(c *gin.Context) {
+	now := time.Now().UTC()
+	aliases := make(supportAliases)
+	files := make(map[string][]byte)
+	addJSON := func(name string, value any) {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			data = []byte(fmt.Sprintf("{\"error\": %q}\n", err.Error()))
+		}
+		files[name] = util.RedactSensitiveText(data)
+	}
+
+	addJSON("version.json", gin.H{
+		"version":      buildinfo.Version,
+		"commit":       buildinfo.Commit,
+		"build_date":   buildinfo.BuildDate,
+		"go_version":   runtime.Version(),
+		"os":           runtime.GOOS,
+		"arch":         runtime.GOARCH,
+		"generated_at": now,
+	})
+
+	if h.cfg != nil {
+		config, err := redactedConfigYAML(h.cfg)
+		if err != nil {
+			config = []byte("# failed to render config: " + err.Error() + "\n")
+		}
+		files["config.yaml"] = config
+	}
+
+	auths := make([]supportAuth, 0)
+	quotas := make(map[string]map[string]quota.ModelQuota)
+	var (
+		decisions []coreauth.SelectionDecision
+		shedding  []coreauth.LoadSheddingStatus
+		events    []coreauth.LoadSheddingEvent
+	)
+	if h.authManager != nil {
+		list := h.authManager.List()
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+		for _, auth := range list {
+			if auth == nil {
+				continue
+			}
+			entry := supportAuth{
+				ID:            aliases.of(auth.ID),
+				Provider:      strings.TrimSpace(auth.Provider),
+				Status:        auth.Status,
+				StatusMessage: auth.StatusMessage,
+				Disabled:      auth.Disabled,
+				Unavailable:   auth.Unavailable && auth.NextRetryAfter.After(now),
+				NeedsRelogin:  auth.NeedsRelogin(),
+				LastError:     auth.LastError,
+			}
+			if entry.Unavailable {
+				next := auth.NextRetryAfter
+				entry.NextRetryAfter = &next
+			}
+			if !auth.LastRefreshedAt.IsZero() {
+				refreshed := auth.LastRefreshedAt
+				entry.LastRefreshed = &refreshed
+			}
+			auths = append(auths, entry)
+			if stored, ok := h.quotaStore.GetEntry(auth.ID); ok && len(stored.Models) > 0 {
+				quotas[entry.ID] = stored.Models
+			} else if models := quota.ModelsFromMetadata(auth.Metadata); len(models) > 0 {
+				quotas[entry.ID] = models
+			}
+		}
+		decisions = h.authManager.SelectionDecisions()
+		for i := range decisions {
+			decisions[i].Selected = aliases.of(decisions[i].Selected)
+			for j := range decisions[i].Candidates {
+				decisions[i].Candidates[j].AuthID = aliases.of(decisions[i].Candidates[j].AuthID)
+			}
+			for j := range decisions[i].Excluded {
+				decisions[i].Excluded[j].AuthID = aliases.of(decisions[i].Excluded[j].AuthID)
+			}
+		}
+		shedding, events = h.authManager.LoadSheddingStatus()
+	}
+	addJSON("auths.json", auths)
+	addJSON("quota.json", quotas)
+	addJSON("selector.json", gin.H{"decisions": decisions, "load_shedding": shedding, "load_shedding_events": events})
+
+	recent := make([]dashboardError, 0)
+	if h.usageStats != nil {
+		recent = recentFailures(h.usageStats.Snapshot(), supportBundleErrors)
+		for i := range recent {
+			recent[i].Source = util.RedactSecret(recent[i].Source)
+		}
+	}
+	addJSON("recent-errors.json", recent)
+
+	if lines := h.recentLogLines(supportBundleLogLines); len(lines) > 0 {
+		files["main.log"] = util.RedactSensitiveText([]byte(strings.Join(lines, "\n") + "\n"))
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err == nil {
+			_, err = w.Write(files[name])
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create support bundle: %v", err)})
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create support bundle: %v", err)})
+		return
+	}
+	name := "cliproxy-support-" + now.Format("20060102-150405") + ".zip"
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// recentLogLines returns up to limit of the newest lines of the log files, or nil when
+// logging to file is disabled or the logs cannot be read.
+func (h *Handler) recentLogLines(limit int) []string {
+	if h.cfg == nil || !h.cfg.LoggingToFile {
+		return nil
+	}
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		return nil
+	}
+	files, err := h.collectLogFiles(dir)
+	if err != nil {
+		return nil
+	}
+	acc := newLogAccumulator(0, limit)
+	for _, file := range files {
+		if errConsume := acc.consumeFile(file); errConsume != nil {
+			return nil
+		}
+	}
+	lines, _, _ := acc.result()
+	return lines
+}
+
+// supportRedaction tells redactConfigNode what to redact below a config key.
+type supportRedaction int
+
+const (
+	redactNothing supportRedaction = iota
+	// redactValues redacts every scalar value below the key.
+	redactValues
+	// redactMapKeys redacts the keys of the map below the key, as per-client-key override
+	// maps are keyed by the client API key.
+	redactMapKeys
+)
+
+// redactedConfigYAML renders cfg as YAML with credentials removed: values of secret-like
+// keys, the keys of per-key override maps, request headers and passwords in URLs.
+func redactedConfigYAML(cfg any) ([]byte, error) {
+	raw, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err = yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	redactConfigNode(&doc, redactNothing)
+	return yaml.Marshal(&doc)
+}
+
+func redactConfigNode(node *yaml.Node, mode supportRedaction) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			redactConfigNode(child, mode)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if child.Kind == yaml.ScalarNode {
+				redactConfigNode(child, mode)
+			} else {
+				redactConfigNode(child, redactNothing)
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			name := strings.ToLower(key.Value)
+			switch {
+			case mode == redactMapKeys:
+				key.Value = util.RedactedPlaceholder
+				redactConfigNode(value, redactNothing)
+			case mode == redactValues || name == "headers":
+				redactConfigNode(value, redactValues)
+			case isSupportSecretKey(name) && value.Kind == yaml.MappingNode:
+				if name == "keys" || strings.HasSuffix(name, "-keys") {
+					redactConfigNode(value, redactMapKeys)
+				} else {
+					redactConfigNode(value, redactNothing)
+				}
+			case isSupportSecretKey(name):
+				redactConfigNode(value, redactValues)
+			default:
+				redactConfigNode(value, redactNothing)
+			}
+		}
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			return
+		}
+		if mode == redactValues {
+			node.Value = util.RedactedPlaceholder
+			node.Tag = "!!str"
+			node.Style = 0
+			return
+		}
+		if strings.Contains(node.Value, "://") {
+			if parsed, errParse := url.Parse(node.Value); errParse == nil && parsed.User != nil {
+				parsed.User = url.User(util.RedactedPlaceholder)
+				node.Value = parsed.String()
+			}
+		}
+	}
+}
+
+func isSupportSecretKey(name string) bool {
+	for _, secret := range supportSecretKeys {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// tombstoneDirName holds tombstones inside the auth dir. Tombstones do not end in .json,
+	// so the watcher and token stores never load them as auths.
+	tombstoneDirName          = ".tombstones"
+	tombstoneExt              = ".tombstone"
+	defaultTombstoneRetention = 7 * 24 * time.Hour
+	tombstonePurgeInterval    = time.Hour
+)
+
+// authTombstone records an auth file deleted through the management API so it can be
+// restored, with its quota snapshot, until it expires. Deleting a file with the same name
+// again replaces the earlier tombstone.
+type authTombstone struct {
+	Name      string            `json:"name"`
+	ID        string            `json:"id"`
+	Provider  string            `json:"provider,omitempty"`
+	Label     string            `json:"label,omitempty"`
+	DeletedAt time.Time         `json:"deleted_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Data      []byte            `json:"data"`
+	Quota     *quota.StoreEntry `json:"quota,omitempty"`
+}
+
+// tombstoneRetention returns how long deleted auth files stay restorable; 0 disables tombstones.
+func (h *Handler) tombstoneRetention() time.Duration {
+	if h.cfg == nil || h.cfg.AuthTombstoneDays == 0 {
+		return defaultTombstoneRetention
+	}
+	if h.cfg.AuthTombstoneDays < 0 {
+		return 0
+	}
+	return time.Duration(h.cfg.AuthTombstoneDays) * 24 * time.Hour
+}
+
+func (h *Handler) tombstonePath(name string) string {
+	return filepath.Join(h.cfg.AuthDir, tombstoneDirName, name+tombstoneExt)
+}
+
+// removeAuthFile deletes one auth file, recording a tombstone first when retention is
+// enabled, and drops the auth's quota snapshot from the quota store.
+func (h *Handler) removeAuthFile(ctx context.Context, path string, now time.Time) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	authID := h.authIDForPath(path)
+	if retention := h.tombstoneRetention(); retention > 0 {
+		tombstone := authTombstone{
+			Name:      filepath.Base(path),
+			ID:        authID,
+			Provider:  gjson.GetBytes(data, "type").String(),
+			Label:     gjson.GetBytes(data, "email").String(),
+			DeletedAt: now.UTC(),
+			ExpiresAt: now.Add(retention).UTC(),
+			Data:      data,
+		}
+		if h.quotaStore != nil {
+			if entry, ok := h.quotaStore.GetEntry(authID); ok {
+				tombstone.Quota = entry
+			}
+		}
+		if err = h.writeTombstone(tombstone); err != nil {
+			return fmt.Errorf("failed to write tombstone: %w", err)
+		}
+	}
+	if err = os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+	if err = h.deleteTokenRecord(ctx, path); err != nil {
+		return err
+	}
+	h.disableAuth(ctx, path)
+	if h.quotaStore != nil {
+		h.quotaStore.Delete(authID)
+	}
+	return nil
+}
+
+func (h *Handler) writeTombstone(tombstone authTombstone) error {
+	path := h.tombstonePath(tombstone.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(tombstone)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (h *Handler) readTombstone(name string) (*authTombstone, error) {
+	data, err := os.ReadFile(h.tombstonePath(name))
+	if err != nil {
+		return nil, err
+	}
+	var tombstone authTombstone
+	if err = json.Unmarshal(data, &tombstone); err != nil {
+		return nil, fmt.Errorf("invalid tombstone %s: %w", name, err)
+	}
+	return &tombstone, nil
+}
+
+// loadTombstones returns the unexpired tombstones, newest first, deleting expired ones.
+func (h *Handler) loadTombstones(now time.Time) ([]*authTombstone, error) {
+	if h.cfg == nil || h.cfg.AuthDir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(h.cfg.AuthDir, tombstoneDirName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []*authTombstone
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), tombstoneExt) {
+			continue
+		}
+		tombstone, errRead := h.readTombstone(strings.TrimSuffix(entry.Name(), tombstoneExt))
+		if errRead != nil {
+			log.Warnf("management: skipping auth tombstone: %v", errRead)
+			continue
+		}
+		if !now.Before(tombstone.ExpiresAt) {
+			if errRemove := os.Remove(h.tombstonePath(tombstone.Name)); errRemove != nil && !errors.Is(errRemove, fs.ErrNotExist) {
+				log.Warnf("management: failed to purge auth tombstone %s: %v", tombstone.Name, errRemove)
+			} else {
+				log.Infof("management: purged auth tombstone %s", tombstone.Name)
+			}
+			continue
+		}
+		out = append(out, tombstone)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeletedAt.After(out[j].DeletedAt) })
+	return out, nil
+}
+
+// startTombstonePurge periodically deletes expired auth tombstones.
+func (h *Handler) startTombstonePurge() {
+	go func() {
+		ticker := time.NewTicker(tombstonePurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := h.loadTombstones(time.Now()); err != nil {
+				log.Warnf("management: failed to purge auth tombstones: %v", err)
+			}
+		}
+	}()
+}
+
+// ListAuthTombstones lists deleted auth files that can still be restored.
+func (h *Handler) ListAuthTombstones(c *gin.Context) {
+	tombstones, err := h.loadTombstones(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read tombstones: %v", err)})
+		return
+	}
+	out := make([]gin.H, 0, len(tombstones))
+	for _, tombstone := range tombstones {
+		out = append(out, gin.H{
+			"name":       tombstone.Name,
+			"id":         tombstone.ID,
+			"provider":   tombstone.Provider,
+			"label":      tombstone.Label,
+			"deleted_at": tombstone.DeletedAt,
+			"expires_at": tombstone.ExpiresAt,
+			"has_quota":  tombstone.Quota != nil,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"tombstones": out})
+}
+
+// RestoreAuthFile recreates a deleted auth file from its tombstone and puts its quota
+// snapshot back into the quota store.
+func (h *Handler) RestoreAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || name != filepath.Base(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid name"})
+		return
+	}
+	tombstone, err := h.readTombstone(name)
+	if err == nil && !time.Now().Before(tombstone.ExpiresAt) {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tombstone not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	dst := filepath.Join(h.cfg.AuthDir, name)
+	if !filepath.IsAbs(dst) {
+		if abs, errAbs := filepath.Abs(dst); errAbs == nil {
+			dst = abs
+		}
+	}
+	if _, errStat := os.Stat(dst); errStat == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "auth file already exists"})
+		return
+	}
+	if errWrite := os.WriteFile(dst, tombstone.Data, 0o600); errWrite != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
+		return
+	}
+	if errReg := h.registerAuthFromFile(c.Request.Context(), dst, tombstone.Data); errReg != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errReg.Error()})
+		return
+	}
+	quotaRestored := false
+	if tombstone.Quota != nil && h.quotaStore != nil {
+		quotaRestored = h.quotaStore.Set(h.authIDForPath(dst), tombstone.Quota.Provider, tombstone.Quota.Models, tombstone.Quota.UpdatedAt)
+	}
+	if errRemove := os.Remove(h.tombstonePath(name)); errRemove != nil {
+		log.Warnf("management: failed to remove auth tombstone %s: %v", name, errRemove)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "id": h.authIDForPath(dst), "quota_restored": quotaRestored})
+}
@@ -0,0 +1,123 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func newTombstoneTestHandler(t *testing.T) (*Handler, *quota.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	store, err := quota.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("quota store: %v", err)
+	}
+	h := &Handler{
+		cfg:         &config.Config{AuthDir: dir},
+		authManager: coreauth.NewManager(nil, nil, nil),
+		tokenStore:  sdkAuth.NewFileTokenStore(),
+		quotaStore:  store,
+	}
+	return h, store
+}
+
+func serveManagement(h gin.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h(c)
+	return rec
+}
+
+func TestDeleteAuthFileKeepsRestorableTombstone(t *testing.T) {
+	h, store := newTombstoneTestHandler(t)
+	name := "user@example.com.json"
+	content := []byte(`{"type":"gemini","email":"user@example.com","token":"secret"}`)
+	path := filepath.Join(h.cfg.AuthDir, name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("write auth file: %v", err)
+	}
+	updated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.Set(name, "gemini", map[string]quota.ModelQuota{"gemini-3-pro": {Percent: 40, UpdatedAt: updated}}, updated)
+
+	if rec := serveManagement(h.DeleteAuthFile, http.MethodDelete, "/?name="+name, ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("auth file still present: %v", err)
+	}
+	if _, ok := store.GetEntry(name); ok {
+		t.Fatal("quota entry kept after delete")
+	}
+
+	rec := serveManagement(h.ListAuthTombstones, http.MethodGet, "/", "")
+	var listed struct {
+		Tombstones []map[string]any `json:"tombstones"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || len(listed.Tombstones) != 1 {
+		t.Fatalf("tombstones = %s", rec.Body.String())
+	}
+	if got := listed.Tombstones[0]; got["name"] != name || got["label"] != "user@example.com" || got["has_quota"] != true || got["data"] != nil {
+		t.Fatalf("tombstone summary = %v", got)
+	}
+
+	if rec = serveManagement(h.RestoreAuthFile, http.MethodPost, "/", `{"name":"`+name+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("restore = %d %s", rec.Code, rec.Body.String())
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(content) {
+		t.Fatalf("restored file = %q, %v", data, err)
+	}
+	if percent, ok := store.GetPercent(name, "gemini-3-pro"); !ok || percent != 40 {
+		t.Fatalf("restored quota = %v, %v", percent, ok)
+	}
+	if auth, ok := h.authManager.GetByID(name); !ok || auth.Disabled {
+		t.Fatalf("restored auth = %+v, %v", auth, ok)
+	}
+	if rec = serveManagement(h.RestoreAuthFile, http.MethodPost, "/", `{"name":"`+name+`"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("second restore = %d, want 404", rec.Code)
+	}
+}
+
+func TestExpiredTombstonesArePurged(t *testing.T) {
+	h, _ := newTombstoneTestHandler(t)
+	h.cfg.AuthTombstoneDays = 1
+	now := time.Now()
+	for name, deletedAt := range map[string]time.Time{"old.json": now.Add(-48 * time.Hour), "new.json": now.Add(-time.Hour)} {
+		if err := h.writeTombstone(authTombstone{Name: name, ID: name, DeletedAt: deletedAt, ExpiresAt: deletedAt.Add(h.tombstoneRetention()), Data: []byte(`{}`)}); err != nil {
+			t.Fatalf("write tombstone: %v", err)
+		}
+	}
+	tombstones, err := h.loadTombstones(now)
+	if err != nil || len(tombstones) != 1 || tombstones[0].Name != "new.json" {
+		t.Fatalf("tombstones = %v, %v", tombstones, err)
+	}
+	if _, err = os.Stat(h.tombstonePath("old.json")); !os.IsNotExist(err) {
+		t.Fatalf("expired tombstone not purged: %v", err)
+	}
+
+	h.cfg.AuthTombstoneDays = -1
+	path := filepath.Join(h.cfg.AuthDir, "gone.json")
+	if err = os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write auth file: %v", err)
+	}
+	if err = h.removeAuthFile(t.Context(), path, now); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err = os.Stat(h.tombstonePath("gone.json")); !os.IsNotExist(err) {
+		t.Fatalf("tombstone written while disabled: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package management
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,15 +22,32 @@ type usageImportPayload struct {
 	Usage   usage.StatisticsSnapshot `json:"usage"`
 }
 
-// GetUsageStatistics returns the in-memory request statistics snapshot.
+// GetUsageStatistics returns the in-memory request statistics snapshot, along with the
+// tokens and estimated cost per inbound API key and model.
+//
+// Query parameters api_key and from, to (RFC 3339, YYYY-MM-DD or unix seconds) narrow the
+// per-key report; the snapshot is always complete.
 func (h *Handler) GetUsageStatistics(c *gin.Context) {
+	from, err := parseUsageTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", err)})
+		return
+	}
+	to, err := parseUsageTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", err)})
+		return
+	}
 	var snapshot usage.StatisticsSnapshot
+	keys := []usage.KeyUsageReport{}
 	if h != nil && h.usageStats != nil {
 		snapshot = h.usageStats.Snapshot()
+		keys = h.usageStats.KeyReport(strings.TrimSpace(c.Query("api_key")), from, to)
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"usage":           snapshot,
 		"failed_requests": snapshot.FailureCount,
+		"keys":            keys,
 	})
 }
 
@@ -77,3 +96,15 @@ func (h *Handler) ImportUsageStatistics(c *gin.Context) {
 		"failed_requests": snapshot.FailureCount,
 	})
 }
+
+// GetTokenCalibration reports how local prompt token estimates compare with the input
+// tokens reported upstream, per model and estimate size.
+func (h *Handler) GetTokenCalibration(c *gin.Context) {
+	c.JSON(http.StatusOK, usage.GetTokenCalibration().Report())
+}
+
+// DeleteTokenCalibration discards the collected calibration samples.
+func (h *Handler) DeleteTokenCalibration(c *gin.Context) {
+	usage.GetTokenCalibration().Reset()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
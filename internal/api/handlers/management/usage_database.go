@@ -0,0 +1,110 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagedb"
+)
+
+// SetUsageDatabase sets the usage database queried by the usage history endpoints; nil
+// disables them.
+func (h *Handler) SetUsageDatabase(store *usagedb.Store) { h.usageDB.Store(store) }
+
+// GetUsageRecords lists persisted usage records, newest first.
+//
+// Query parameters: from, to (RFC 3339, YYYY-MM-DD or unix seconds), provider, model,
+// api_key, auth_id, failed=true, limit (default 100, max 1000) and offset.
+func (h *Handler) GetUsageRecords(c *gin.Context) {
+	store := h.usageDB.Load()
+	if store == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "usage database not configured"})
+		return
+	}
+	query, err := parseUsageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	records, err := store.Records(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("query usage database: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"records": records})
+}
+
+// GetUsageSummary aggregates persisted usage records by the group_by query parameter
+// (provider, model, api_key, auth_id, source or day; default model). It accepts the same
+// filters as GetUsageRecords.
+func (h *Handler) GetUsageSummary(c *gin.Context) {
+	store := h.usageDB.Load()
+	if store == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "usage database not configured"})
+		return
+	}
+	query, err := parseUsageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	groupBy := strings.TrimSpace(c.DefaultQuery("group_by", "model"))
+	if !usagedb.ValidGroup(groupBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be one of provider, model, api_key, auth_id, source, day"})
+		return
+	}
+	rows, err := store.Summary(c.Request.Context(), query, groupBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("query usage database: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"group_by": groupBy, "rows": rows})
+}
+
+func parseUsageQuery(c *gin.Context) (usagedb.Query, error) {
+	var q usagedb.Query
+	var err error
+	if q.From, err = parseUsageTime(c.Query("from")); err != nil {
+		return q, fmt.Errorf("invalid from: %w", err)
+	}
+	if q.To, err = parseUsageTime(c.Query("to")); err != nil {
+		return q, fmt.Errorf("invalid to: %w", err)
+	}
+	q.Provider = strings.TrimSpace(c.Query("provider"))
+	q.Model = strings.TrimSpace(c.Query("model"))
+	q.APIKey = strings.TrimSpace(c.Query("api_key"))
+	q.AuthID = strings.TrimSpace(c.Query("auth_id"))
+	q.FailedOnly, _ = strconv.ParseBool(c.Query("failed"))
+	if q.Limit, err = parseLimit(c.Query("limit")); err != nil {
+		return q, fmt.Errorf("invalid limit: %w", err)
+	}
+	if raw := strings.TrimSpace(c.Query("offset")); raw != "" {
+		if q.Offset, err = strconv.Atoi(raw); err != nil || q.Offset < 0 {
+			return q, fmt.Errorf("invalid offset: must be a non-negative integer")
+		}
+	}
+	return q, nil
+}
+
+// parseUsageTime accepts RFC 3339 timestamps, dates (midnight UTC) and unix seconds.
+func parseUsageTime(raw string) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want RFC 3339, YYYY-MM-DD or unix seconds")
+	}
+	return t, nil
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+// limitsKey describes the calling API key and its remaining budget.
+type limitsKey struct {
+	Type              string     `json:"type"`
+	ID                string     `json:"id,omitempty"`
+	Prefix            string     `json:"prefix,omitempty"`
+	Description       string     `json:"description,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	Models            []string   `json:"models,omitempty"`
+	Requests          int64      `json:"requests"`
+	Tokens            int64      `json:"tokens"`
+	MaxRequests       int64      `json:"max_requests,omitempty"`
+	MaxTokens         int64      `json:"max_tokens,omitempty"`
+	RemainingRequests *int64     `json:"remaining_requests,omitempty"`
+	RemainingTokens   *int64     `json:"remaining_tokens,omitempty"`
+}
+
+// limitsModel summarises pool-level availability for a single model.
+type limitsModel struct {
+	ID             string     `json:"id"`
+	Credentials    int        `json:"credentials"`
+	Ready          int        `json:"ready"`
+	Cooling        int        `json:"cooling"`
+	RateLimited    int        `json:"rate_limited"`
+	NextRecoverAt  *time.Time `json:"next_recover_at,omitempty"`
+	RateLimitReset *time.Time `json:"rate_limit_reset_at,omitempty"`
+	QuotaRemaining *float64   `json:"quota_remaining_percent,omitempty"`
+}
+
+// limitsRateLimits reports the caller's request and token caps together with upstream
+// rate limiting across the models it may use.
+type limitsRateLimits struct {
+	LimitRequests     int64      `json:"limit_requests,omitempty"`
+	RemainingRequests *int64     `json:"remaining_requests,omitempty"`
+	LimitTokens       int64      `json:"limit_tokens,omitempty"`
+	RemainingTokens   *int64     `json:"remaining_tokens,omitempty"`
+	RateLimitedModels int        `json:"rate_limited_models"`
+	ResetAt           *time.Time `json:"reset_at,omitempty"`
+}
+
+// uncountedLimitsLookup marks GET /v1/limits so that authenticating it with a managed key
+// does not spend the key's request budget. It must run before AuthMiddleware.
+func uncountedLimitsLookup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet && c.FullPath() == "/v1/limits" {
+			c.Request = c.Request.WithContext(managedkeys.WithoutUsage(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// limitsHandler serves GET /v1/limits: the caller's remaining key budget and the
+// availability of every model it may use, plus a one-line summary for CLI status bars.
+func (s *Server) limitsHandler(c *gin.Context) {
+	now := time.Now()
+	key := s.callerKeyLimits(c)
+
+	var auths []*coreauth.Auth
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		auths = s.handlers.AuthManager.List()
+	}
+	models := poolModelLimits(auths, key.Models, now)
+
+	ready := 0
+	rateLimits := limitsRateLimits{
+		LimitRequests:     key.MaxRequests,
+		RemainingRequests: key.RemainingRequests,
+		LimitTokens:       key.MaxTokens,
+		RemainingTokens:   key.RemainingTokens,
+	}
+	for _, m := range models {
+		if m.Ready > 0 {
+			ready++
+		} else if m.RateLimited > 0 {
+			rateLimits.RateLimitedModels++
+		}
+		if m.RateLimitReset != nil && (rateLimits.ResetAt == nil || m.RateLimitReset.Before(*rateLimits.ResetAt)) {
+			rateLimits.ResetAt = m.RateLimitReset
+		}
+	}
+	setRateLimitHeaders(c, rateLimits, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":         key,
+		"rate_limits": rateLimits,
+		"models":      models,
+		"status_line": limitsStatusLine(key, ready, len(models)),
+	})
+}
+
+// setRateLimitHeaders mirrors the rate limit summary in the x-ratelimit-* headers used by
+// OpenAI-compatible clients.
+func setRateLimitHeaders(c *gin.Context, limits limitsRateLimits, now time.Time) {
+	if limits.LimitRequests > 0 && limits.RemainingRequests != nil {
+		c.Header("x-ratelimit-limit-requests", strconv.FormatInt(limits.LimitRequests, 10))
+		c.Header("x-ratelimit-remaining-requests", strconv.FormatInt(*limits.RemainingRequests, 10))
+	}
+	if limits.LimitTokens > 0 && limits.RemainingTokens != nil {
+		c.Header("x-ratelimit-limit-tokens", strconv.FormatInt(limits.LimitTokens, 10))
+		c.Header("x-ratelimit-remaining-tokens", strconv.FormatInt(*limits.RemainingTokens, 10))
+	}
+	if limits.ResetAt != nil {
+		c.Header("x-ratelimit-reset-requests", limits.ResetAt.Sub(now).Round(time.Second).String())
+	}
+}
+
+// callerKeyLimits resolves the authenticated key from the access middleware context.
+func (s *Server) callerKeyLimits(c *gin.Context) limitsKey {
+	out := limitsKey{Type: "static"}
+	if provider, ok := c.Get("accessProvider"); ok {
+		if name, _ := provider.(string); name != "" {
+			out.Type = name
+		}
+	}
+	if out.Type != managedkeys.ProviderName || s.managedKeys == nil {
+		return out
+	}
+	rawMeta, _ := c.Get("accessMetadata")
+	meta, _ := rawMeta.(map[string]string)
+	record, ok := s.managedKeys.Get(meta["key_id"])
+	if !ok {
+		return out
+	}
+	out.ID = record.ID
+	out.Prefix = record.Prefix
+	out.Description = record.Description
+	out.ExpiresAt = record.ExpiresAt
+	out.Requests = record.Usage.Requests
+	out.Tokens = record.Usage.Tokens
+	if scope := record.Scope; scope != nil {
+		out.Models = scope.Models
+		out.MaxRequests = scope.MaxRequests
+		out.MaxTokens = scope.MaxTokens
+		if scope.MaxRequests > 0 {
+			remaining := max(scope.MaxRequests-record.Usage.Requests, 0)
+			out.RemainingRequests = &remaining
+		}
+		if scope.MaxTokens > 0 {
+			remaining := max(scope.MaxTokens-record.Usage.Tokens, 0)
+			out.RemainingTokens = &remaining
+		}
+	}
+	return out
+}
+
+// poolModelLimits aggregates per-model credential readiness across all enabled auths.
+// When allowed is non-empty only models permitted by that list are reported.
+func poolModelLimits(auths []*coreauth.Auth, allowed []string, now time.Time) []limitsModel {
+	reg := registry.GetGlobalRegistry()
+	byModel := make(map[string]*limitsModel)
+	quotaSums := make(map[string]float64)
+	quotaCounts := make(map[string]int)
+
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled || auth.NeedsRelogin() {
+			continue
+		}
+		for _, info := range reg.GetModelsForClient(auth.ID) {
+			if info == nil || info.ID == "" {
+				continue
+			}
+			if len(allowed) > 0 && !managedkeys.ModelAllowed(allowed, info.ID) {
+				continue
+			}
+			entry := byModel[info.ID]
+			if entry == nil {
+				entry = &limitsModel{ID: info.ID}
+				byModel[info.ID] = entry
+			}
+			entry.Credentials++
+
+			recoverAt, cooling, rateLimited := authModelCooldown(auth, info.ID, now)
+			if cooling {
+				entry.Cooling++
+				if !recoverAt.IsZero() && (entry.NextRecoverAt == nil || recoverAt.Before(*entry.NextRecoverAt)) {
+					t := recoverAt
+					entry.NextRecoverAt = &t
+				}
+				if rateLimited {
+					entry.RateLimited++
+					if !recoverAt.IsZero() && (entry.RateLimitReset == nil || recoverAt.Before(*entry.RateLimitReset)) {
+						t := recoverAt
+						entry.RateLimitReset = &t
+					}
+				}
+			} else {
+				entry.Ready++
+			}
+
+			if percent, ok := quota.GetPercentFromMetadata(auth.Metadata, info.ID); ok {
+				quotaSums[info.ID] += percent
+				quotaCounts[info.ID]++
+			}
+		}
+	}
+
+	out := make([]limitsModel, 0, len(byModel))
+	for id, entry := range byModel {
+		if n := quotaCounts[id]; n > 0 {
+			avg := quotaSums[id] / float64(n)
+			entry.QuotaRemaining = &avg
+		}
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// authModelCooldown reports whether the auth is currently blocked for the model, when it
+// recovers, and whether the block comes from an upstream rate limit.
+func authModelCooldown(auth *coreauth.Auth, model string, now time.Time) (time.Time, bool, bool) {
+	if state := auth.ModelStates[model]; state != nil && state.Unavailable && state.NextRetryAfter.After(now) {
+		return state.NextRetryAfter, true, state.Quota.Exceeded
+	}
+	if auth.Unavailable && auth.NextRetryAfter.After(now) {
+		return auth.NextRetryAfter, true, auth.Quota.Exceeded
+	}
+	return time.Time{}, false, false
+}
+
+// limitsStatusLine renders a compact summary such as "sk-cpa-ab12 | 40/100 req | 3/5 models ready".
+func limitsStatusLine(key limitsKey, ready, total int) string {
+	parts := make([]string, 0, 4)
+	if key.Prefix != "" {
+		parts = append(parts, key.Prefix)
+	}
+	if key.MaxRequests > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d req", key.Requests, key.MaxRequests))
+	}
+	if key.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d tok", key.Tokens, key.MaxTokens))
+	}
+	if key.ExpiresAt != nil {
+		parts = append(parts, "expires in "+time.Until(*key.ExpiresAt).Round(time.Minute).String())
+	}
+	parts = append(parts, fmt.Sprintf("%d/%d models ready", ready, total))
+	return strings.Join(parts, " | ")
+}
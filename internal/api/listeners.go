@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// boundListener pairs an open socket with the HTTP server that serves it.
+type boundListener struct {
+	listener net.Listener
+	server   *http.Server
+	tls      bool
+}
+
+// openListeners binds every configured listener. Listeners scoped to "all" share the
+// primary server; scoped listeners get their own server wrapping the engine with a route
+// filter. On failure every socket opened so far is closed.
+func (s *Server) openListeners(useTLS bool) ([]boundListener, error) {
+	configured := s.cfg.EffectiveListeners()
+	bound := make([]boundListener, 0, len(configured))
+	scoped := make(map[string]*http.Server)
+	closeAll := func() {
+		for _, b := range bound {
+			_ = b.listener.Close()
+		}
+	}
+
+	for _, lc := range configured {
+		scope, err := lc.NormalizedScope()
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		network, address, err := lc.Endpoint()
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		ln, err := listen(network, address, lc.SocketMode)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+
+		srv := s.server
+		if scope != config.ListenerScopeAll {
+			if scoped[scope] == nil {
+				scoped[scope] = &http.Server{Handler: scopeHandler(scope, s.server.Handler)}
+			}
+			srv = scoped[scope]
+		}
+		bound = append(bound, boundListener{listener: ln, server: srv, tls: useTLS && network == "tcp"})
+		log.Debugf("API server listening on %s://%s (scope: %s)", network, address, scope)
+	}
+
+	s.scopedServersMu.Lock()
+	s.scopedServers = s.scopedServers[:0]
+	for _, srv := range scoped {
+		s.scopedServers = append(s.scopedServers, srv)
+	}
+	s.scopedServersMu.Unlock()
+	return bound, nil
+}
+
+func (s *Server) scopedServerList() []*http.Server {
+	s.scopedServersMu.Lock()
+	defer s.scopedServersMu.Unlock()
+	return append([]*http.Server(nil), s.scopedServers...)
+}
+
+// listen opens a TCP or Unix listener. Stale Unix socket files are removed first so a
+// restart after a crash does not fail with "address already in use".
+func listen(network, address, socketMode string) (net.Listener, error) {
+	if network != "unix" {
+		return net.Listen(network, address)
+	}
+	abstract := strings.HasPrefix(address, "@")
+	if !abstract {
+		if info, err := os.Lstat(address); err == nil {
+			if info.Mode()&fs.ModeSocket == 0 {
+				return nil, fmt.Errorf("listener unix:%s: path exists and is not a socket", address)
+			}
+			if err = os.Remove(address); err != nil {
+				return nil, fmt.Errorf("listener unix:%s: remove stale socket: %w", address, err)
+			}
+		}
+	}
+	mode := strings.TrimSpace(socketMode)
+	if mode == "" || abstract {
+		return net.Listen(network, address)
+	}
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("listener unix:%s: socket-mode %q: %w", address, socketMode, err)
+	}
+	var ln net.Listener
+	err = withSocketUmask(uint32(perm), func() error {
+		var errListen error
+		ln, errListen = net.Listen(network, address)
+		return errListen
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(address, os.FileMode(perm)); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("listener unix:%s: socket-mode %q: %w", address, socketMode, err)
+	}
+	return ln, nil
+}
+
+// serve blocks until the listener's server is shut down or fails.
+func (b boundListener) serve(certFile, keyFile string) error {
+	var err error
+	if b.tls {
+		err = b.server.ServeTLS(b.listener, certFile, keyFile)
+	} else {
+		err = b.server.Serve(b.listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// scopeHandler restricts next to management routes or to everything else.
+func scopeHandler(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isManagementPath(r.URL.Path) != (scope == config.ListenerScopeManagement) {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isManagementPath(path string) bool {
+	return path == "/v0/management" || strings.HasPrefix(path, "/v0/management/") ||
+		path == "/management.html" ||
+		path == "/dashboard" || strings.HasPrefix(path, "/dashboard/")
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestUnixListenersHonorScope(t *testing.T) {
+	server := newTestServer(t)
+	server.managementRoutesEnabled.Store(true)
+
+	dir := t.TempDir()
+	apiSock := filepath.Join(dir, "api.sock")
+	mgmtSock := filepath.Join(dir, "mgmt.sock")
+	// A stale socket file from a previous run must not prevent binding.
+	if stale, err := net.Listen("unix", apiSock); err == nil {
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		_ = stale.Close()
+	}
+	server.cfg.Listeners = []proxyconfig.ListenerConfig{
+		{Address: "unix:" + apiSock, Scope: "api", SocketMode: "0600"},
+		{Address: "unix://" + mgmtSock, Scope: "management"},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- server.Start() }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Stop(ctx)
+		if err := <-done; err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	})
+
+	get := func(sock, path string) int {
+		t.Helper()
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+			},
+		}}
+		var resp *http.Response
+		var err error
+		for i := 0; i < 50; i++ {
+			if resp, err = client.Get("http://unix" + path); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("GET %s via %s: %v", path, sock, err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get(apiSock, "/"); code != http.StatusOK {
+		t.Fatalf("api listener root: got %d", code)
+	}
+	if code := get(apiSock, "/dashboard/"); code != http.StatusNotFound {
+		t.Fatalf("api listener must not serve management routes, got %d", code)
+	}
+	if code := get(mgmtSock, "/"); code != http.StatusNotFound {
+		t.Fatalf("management listener must not serve API routes, got %d", code)
+	}
+	if code := get(mgmtSock, "/dashboard/"); code != http.StatusOK {
+		t.Fatalf("management listener dashboard: got %d", code)
+	}
+
+	info, err := os.Stat(apiSock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("socket mode = %o, want 600", perm)
+	}
+}
+
+func TestListenRejectsInvalidSocketModeBeforeBinding(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "bad.sock")
+	if _, err := listen("unix", sock, "rw-"); err == nil {
+		t.Fatal("expected invalid socket-mode to fail")
+	}
+	if _, err := os.Lstat(sock); !os.IsNotExist(err) {
+		t.Fatalf("socket must not be created for an invalid mode, stat err = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+// metricsHandler serves GET /metrics when metrics are enabled: the collected counters and
+// histograms followed by credential gauges computed from the current pool.
+func (s *Server) metricsHandler(c *gin.Context) {
+	cfg := s.cfg
+	if cfg == nil || !cfg.Metrics.Enabled {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if token := cfg.Metrics.BearerToken; token != "" {
+		got := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = metrics.Default().Write(&buf)
+	var auths []*coreauth.Auth
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		auths = s.handlers.AuthManager.List()
+	}
+	states, quotas := s.credentialGauges(auths)
+	metrics.WriteGauge(&buf, "cliproxy_auths", "Credentials by provider and status.", states)
+	metrics.WriteGauge(&buf, "cliproxy_auth_quota_percent", "Remaining quota percent reported for each credential and model.", quotas)
+	metrics.WriteGauge(&buf, "cliproxy_goroutines", "Goroutines currently running.", []metrics.GaugeSample{{Value: float64(runtime.NumGoroutine())}})
+	if s.cfg.Streaming.LeakWatchdog.Enabled {
+		metrics.WriteGauge(&buf, "cliproxy_active_streams", "Streaming responses tracked by the leak watchdog.", []metrics.GaugeSample{{Value: float64(handlers.ActiveStreams())}})
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+}
+
+// credentialGauges counts credentials per provider and status and lists their quota
+// percentages, preferring the quota store over the snapshot in auth metadata.
+func (s *Server) credentialGauges(auths []*coreauth.Auth) ([]metrics.GaugeSample, []metrics.GaugeSample) {
+	type stateKey struct{ provider, status string }
+	counts := make(map[stateKey]int)
+	var quotas []metrics.GaugeSample
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		status := string(auth.Status)
+		switch {
+		case auth.Disabled:
+			status = string(coreauth.StatusDisabled)
+		case auth.NeedsRelogin():
+			status = string(coreauth.StatusNeedsRelogin)
+		case status == "":
+			status = string(coreauth.StatusUnknown)
+		}
+		counts[stateKey{auth.Provider, status}]++
+
+		var models map[string]quota.ModelQuota
+		if entry, ok := s.quotaStore.GetEntry(auth.ID); ok {
+			models = entry.Models
+		} else {
+			models = quota.ModelsFromMetadata(auth.Metadata)
+		}
+		index := auth.EnsureIndex()
+		for model, entry := range models {
+			quotas = append(quotas, metrics.GaugeSample{
+				Labels: []string{"auth_index", index, "provider", auth.Provider, "model", model},
+				Value:  entry.Percent,
+			})
+		}
+	}
+
+	states := make([]metrics.GaugeSample, 0, len(counts))
+	for key, count := range counts {
+		states = append(states, metrics.GaugeSample{Labels: []string{"provider", key.provider, "status", key.status}, Value: float64(count)})
+	}
+	sortSamples(states)
+	sortSamples(quotas)
+	return states, quotas
+}
+
+func sortSamples(samples []metrics.GaugeSample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 )
 
 // RequestLoggingMiddleware creates a Gin middleware that logs HTTP requests and responses.
@@ -20,8 +21,23 @@ import (
 // and uses the provided RequestLogger to record this data. When logging is disabled in the
 // logger, it still captures data so that upstream errors can be persisted.
 func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
+	return RequestLoggingMiddlewareWithPolicy(logger, nil)
+}
+
+// RequestLogPolicy decides how much of each request is logged and which finished requests are kept.
+type RequestLogPolicy interface {
+	// Level returns none, metadata or full once the upstream provider and API key are known.
+	Level(c *gin.Context) string
+	// Keep reports whether a finished request should be written, given its outcome and duration.
+	Keep(c *gin.Context, failed bool, elapsed time.Duration) bool
+}
+
+// RequestLoggingMiddlewareWithPolicy behaves like RequestLoggingMiddleware but consults policy
+// for the per-request log level and for sampling of finished requests.
+func RequestLoggingMiddlewareWithPolicy(logger logging.RequestLogger, policy RequestLogPolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if logger == nil {
+		// Aggregation-only usage mode forbids retaining payloads, including error logs.
+		if logger == nil || coreusage.AggregateOnly() {
 			c.Next()
 			return
 		}
@@ -48,8 +64,11 @@ func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
 
 		// Create response writer wrapper
 		wrapper := NewResponseWriterWrapper(c.Writer, logger, requestInfo)
-		if !logger.IsEnabled() {
-			wrapper.logOnErrorOnly = true
+		// Error responses are still persisted when the resolved level is none.
+		wrapper.logOnErrorOnly = true
+		if policy != nil {
+			wrapper.resolveLevel = func() string { return policy.Level(c) }
+			wrapper.keep = func(failed bool, elapsed time.Duration) bool { return policy.Keep(c, failed, elapsed) }
 		}
 		c.Writer = wrapper
 
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 )
@@ -28,17 +29,20 @@ type RequestInfo struct {
 // It is designed to handle both standard and streaming responses, ensuring that logging operations do not block the client response.
 type ResponseWriterWrapper struct {
 	gin.ResponseWriter
-	body                *bytes.Buffer              // body is a buffer to store the response body for non-streaming responses.
-	isStreaming         bool                       // isStreaming indicates whether the response is a streaming type (e.g., text/event-stream).
-	streamWriter        logging.StreamingLogWriter // streamWriter is a writer for handling streaming log entries.
-	chunkChannel        chan []byte                // chunkChannel is a channel for asynchronously passing response chunks to the logger.
-	streamDone          chan struct{}              // streamDone signals when the streaming goroutine completes.
-	logger              logging.RequestLogger      // logger is the instance of the request logger service.
-	requestInfo         *RequestInfo               // requestInfo holds the details of the original request.
-	statusCode          int                        // statusCode stores the HTTP status code of the response.
-	headers             map[string][]string        // headers stores the response headers.
-	logOnErrorOnly      bool                       // logOnErrorOnly enables logging only when an error response is detected.
-	firstChunkTimestamp time.Time                  // firstChunkTimestamp captures TTFB for streaming responses.
+	body                *bytes.Buffer                  // body is a buffer to store the response body for non-streaming responses.
+	isStreaming         bool                           // isStreaming indicates whether the response is a streaming type (e.g., text/event-stream).
+	streamWriter        logging.StreamingLogWriter     // streamWriter is a writer for handling streaming log entries.
+	chunkChannel        chan []byte                    // chunkChannel is a channel for asynchronously passing response chunks to the logger.
+	streamDone          chan struct{}                  // streamDone signals when the streaming goroutine completes.
+	logger              logging.RequestLogger          // logger is the instance of the request logger service.
+	requestInfo         *RequestInfo                   // requestInfo holds the details of the original request.
+	statusCode          int                            // statusCode stores the HTTP status code of the response.
+	headers             map[string][]string            // headers stores the response headers.
+	logOnErrorOnly      bool                           // logOnErrorOnly enables logging only when an error response is detected.
+	resolveLevel        func() string                  // resolveLevel returns the request log level for this request; nil follows the logger toggle.
+	level               string                         // level caches the resolved request log level.
+	keep                func(bool, time.Duration) bool // keep applies sampling to a finished request; nil keeps everything.
+	firstChunkTimestamp time.Time                      // firstChunkTimestamp captures TTFB for streaming responses.
 }
 
 // NewResponseWriterWrapper creates and initializes a new ResponseWriterWrapper.
@@ -95,9 +99,31 @@ func (w *ResponseWriterWrapper) Write(data []byte) (int, error) {
 	return n, err
 }
 
+// logLevel resolves the request log level once the upstream provider is known and caches it.
+func (w *ResponseWriterWrapper) logLevel() string {
+	if w.level != "" {
+		return w.level
+	}
+	switch {
+	case w.logger == nil || !w.logger.IsEnabled():
+		w.level = config.RequestLogLevelNone
+	case w.resolveLevel == nil:
+		w.level = config.RequestLogLevelFull
+	default:
+		w.level = w.resolveLevel()
+		if w.level == "" {
+			w.level = config.RequestLogLevelNone
+		}
+	}
+	return w.level
+}
+
 func (w *ResponseWriterWrapper) shouldBufferResponseBody() bool {
-	if w.logger != nil && w.logger.IsEnabled() {
+	switch w.logLevel() {
+	case config.RequestLogLevelFull:
 		return true
+	case config.RequestLogLevelMetadata:
+		return false
 	}
 	if !w.logOnErrorOnly {
 		return false
@@ -155,22 +181,29 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 	w.isStreaming = w.detectStreaming(contentType)
 
 	// If streaming, initialize streaming log writer
-	if w.isStreaming && w.logger.IsEnabled() {
+	if level := w.logLevel(); w.isStreaming && level != config.RequestLogLevelNone {
+		requestBody := w.requestInfo.Body
+		if level == config.RequestLogLevelMetadata {
+			requestBody = nil
+		}
 		streamWriter, err := w.logger.LogStreamingRequest(
 			w.requestInfo.URL,
 			w.requestInfo.Method,
 			w.requestInfo.Headers,
-			w.requestInfo.Body,
+			requestBody,
 			w.requestInfo.RequestID,
 		)
 		if err == nil {
 			w.streamWriter = streamWriter
-			w.chunkChannel = make(chan []byte, 100) // Buffered channel for async writes
-			doneChan := make(chan struct{})
-			w.streamDone = doneChan
+			// Metadata-only logging records status and headers without forwarding chunks.
+			if level == config.RequestLogLevelFull {
+				w.chunkChannel = make(chan []byte, 100) // Buffered channel for async writes
+				doneChan := make(chan struct{})
+				w.streamDone = doneChan
 
-			// Start async chunk processor
-			go w.processStreamingChunks(doneChan)
+				// Start async chunk processor
+				go w.processStreamingChunks(doneChan)
+			}
 
 			// Write status immediately
 			_ = streamWriter.WriteStatus(statusCode, w.headers)
@@ -275,10 +308,13 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 	}
 
 	hasAPIError := len(slicesAPIResponseError) > 0 || finalStatusCode >= http.StatusBadRequest
-	forceLog := w.logOnErrorOnly && hasAPIError && !w.logger.IsEnabled()
-	if !w.logger.IsEnabled() && !forceLog {
+	level := w.logLevel()
+	enabled := level != config.RequestLogLevelNone
+	forceLog := w.logOnErrorOnly && hasAPIError && !enabled
+	if !enabled && !forceLog {
 		return nil
 	}
+	sampled := forceLog || w.keep == nil || w.keep(hasAPIError, time.Since(w.requestInfo.Timestamp))
 
 	if w.isStreaming && w.streamWriter != nil {
 		if w.chunkChannel != nil {
@@ -291,6 +327,15 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 			w.streamDone = nil
 		}
 
+		if !sampled {
+			streamWriter := w.streamWriter
+			w.streamWriter = nil
+			if discarder, ok := streamWriter.(interface{ Discard() error }); ok {
+				return discarder.Discard()
+			}
+			return streamWriter.Close()
+		}
+
 		w.streamWriter.SetFirstChunkTimestamp(w.firstChunkTimestamp)
 
 		// Write API Request and Response to the streaming log before closing
@@ -310,7 +355,14 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		return nil
 	}
 
-	return w.logRequest(finalStatusCode, w.cloneHeaders(), w.body.Bytes(), w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog)
+	if !sampled {
+		return nil
+	}
+	body := w.body.Bytes()
+	if level == config.RequestLogLevelMetadata {
+		body = nil
+	}
+	return w.logRequest(finalStatusCode, w.cloneHeaders(), body, w.extractAPIRequest(c), w.extractAPIResponse(c), w.extractAPIResponseTimestamp(c), slicesAPIResponseError, forceLog, level == config.RequestLogLevelMetadata)
 }
 
 func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
@@ -361,13 +413,13 @@ func (w *ResponseWriterWrapper) extractAPIResponseTimestamp(c *gin.Context) time
 	return time.Time{}
 }
 
-func (w *ResponseWriterWrapper) logRequest(statusCode int, headers map[string][]string, body []byte, apiRequestBody, apiResponseBody []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool) error {
+func (w *ResponseWriterWrapper) logRequest(statusCode int, headers map[string][]string, body []byte, apiRequestBody, apiResponseBody []byte, apiResponseTimestamp time.Time, apiResponseErrors []*interfaces.ErrorMessage, forceLog, omitRequestBody bool) error {
 	if w.requestInfo == nil {
 		return nil
 	}
 
 	var requestBody []byte
-	if len(w.requestInfo.Body) > 0 {
+	if len(w.requestInfo.Body) > 0 && !omitRequestBody {
 		requestBody = w.requestInfo.Body
 	}
 
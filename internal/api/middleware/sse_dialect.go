@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/tidwall/gjson"
+)
+
+// SSEDialectMiddleware rewrites the SSE framing of streamed responses to the dialect
+// configured for the calling client key. It must run after authentication so the key is
+// known. Responses that are not event streams pass through untouched.
+func SSEDialectMiddleware(cfg func() *config.SDKConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dialect := cfg().SSEDialectFor(logging.ClientKeyIdentifiers(c)...)
+		if !dialect.Active() {
+			c.Next()
+			return
+		}
+		writer := &sseDialectWriter{ResponseWriter: c.Writer, dialect: dialect}
+		c.Writer = writer
+		c.Next()
+		writer.finish(c.Request.Context().Err() == nil)
+	}
+}
+
+// sseDialectWriter filters an event stream line by line. Partial lines are held back until
+// their newline arrives.
+type sseDialectWriter struct {
+	gin.ResponseWriter
+	dialect config.SSEDialect
+
+	checked bool
+	isSSE   bool
+	pending []byte
+	// eventOpen is set while lines of the current event have been written, so the blank line
+	// ending an event is only written for events that were not removed entirely.
+	eventOpen bool
+	started   bool
+	sawDone   bool
+	failed    bool
+}
+
+func (w *sseDialectWriter) Write(p []byte) (int, error) {
+	if !w.checked {
+		w.checked = true
+		w.isSSE = strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+	}
+	if !w.isSSE {
+		return w.ResponseWriter.Write(p)
+	}
+	w.pending = append(w.pending, p...)
+	end := bytes.LastIndexByte(w.pending, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	out := w.filter(w.pending[:end+1])
+	w.pending = append([]byte(nil), w.pending[end+1:]...)
+	if len(out) > 0 {
+		if _, err := w.ResponseWriter.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *sseDialectWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// filter rewrites complete lines according to the dialect.
+func (w *sseDialectWriter) filter(lines []byte) []byte {
+	var out bytes.Buffer
+	if !w.started && w.dialect.RetryMS > 0 {
+		out.WriteString("retry: " + strconv.Itoa(w.dialect.RetryMS) + "\n\n")
+	}
+	w.started = true
+	for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		field := bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(field) == 0:
+			if w.eventOpen {
+				out.WriteString("\n")
+				w.eventOpen = false
+			}
+			continue
+		case field[0] == ':':
+			if w.dialect.Comments != nil && !*w.dialect.Comments {
+				continue
+			}
+		case bytes.HasPrefix(field, []byte("event:")):
+			if string(bytes.TrimSpace(field[len("event:"):])) == "error" {
+				w.failed = true
+			}
+			if w.dialect.EventNames != nil && !*w.dialect.EventNames {
+				continue
+			}
+		case bytes.HasPrefix(field, []byte("data:")):
+			data := bytes.TrimSpace(field[len("data:"):])
+			if string(data) == "[DONE]" {
+				w.sawDone = true
+				if w.dialect.DoneSentinel != nil && !*w.dialect.DoneSentinel {
+					continue
+				}
+			} else if gjson.GetBytes(data, "error").Exists() {
+				w.failed = true
+			}
+		}
+		out.Write(field)
+		out.WriteString("\n")
+		w.eventOpen = true
+	}
+	return out.Bytes()
+}
+
+// finish writes any held-back partial line and, when the dialect requires a done sentinel,
+// terminates a completed stream that did not send one.
+func (w *sseDialectWriter) finish(completed bool) {
+	if !w.isSSE {
+		return
+	}
+	var out []byte
+	if len(w.pending) > 0 {
+		out = w.filter(append(w.pending, '\n'))
+		w.pending = nil
+	}
+	if completed && !w.sawDone && !w.failed && w.dialect.DoneSentinel != nil && *w.dialect.DoneSentinel {
+		if w.eventOpen {
+			out = append(out, '\n')
+			w.eventOpen = false
+		}
+		out = append(out, "data: [DONE]\n\n"...)
+	}
+	if len(out) > 0 {
+		_, _ = w.ResponseWriter.Write(out)
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func serveSSE(t *testing.T, cfg *config.SDKConfig, apiKey string, writes ...string) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { c.Set("apiKey", apiKey) })
+	engine.Use(SSEDialectMiddleware(func() *config.SDKConfig { return cfg }))
+	engine.POST("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		for _, w := range writes {
+			_, _ = c.Writer.Write([]byte(w))
+			c.Writer.Flush()
+		}
+	})
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/stream", nil))
+	return recorder.Body.String()
+}
+
+func TestSSEDialectRewritesClaudeStream(t *testing.T) {
+	off, on := false, true
+	cfg := &config.SDKConfig{Streaming: config.StreamingConfig{Dialect: config.SSEDialectConfig{
+		Keys: map[string]config.SSEDialect{"strict-key": {DoneSentinel: &on, EventNames: &off, Comments: &off, RetryMS: 3000}},
+	}}}
+	writes := []string{
+		"event: message_start\ndata: {\"type\":\"message_start\"}\n\n",
+		": keep-alive\n\n",
+		"event: content_block_delta\nda",
+		"ta: {\"type\":\"content_block_delta\"}\n\n",
+	}
+	want := "retry: 3000\n\n" +
+		"data: {\"type\":\"message_start\"}\n\n" +
+		"data: {\"type\":\"content_block_delta\"}\n\n" +
+		"data: [DONE]\n\n"
+	if got := serveSSE(t, cfg, "strict-key", writes...); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	unchanged := "event: message_start\ndata: {\"type\":\"message_start\"}\n\n: keep-alive\n\nevent: content_block_delta\ndata: {\"type\":\"content_block_delta\"}\n\n"
+	if got := serveSSE(t, cfg, "other-key", writes...); got != unchanged {
+		t.Errorf("stream of a key without dialect changed: %q", got)
+	}
+}
+
+func TestSSEDialectDoneSentinel(t *testing.T) {
+	off, on := false, true
+	cfg := &config.SDKConfig{Streaming: config.StreamingConfig{Dialect: config.SSEDialectConfig{SSEDialect: config.SSEDialect{DoneSentinel: &off}}}}
+	if got := serveSSE(t, cfg, "k", "data: {\"id\":\"1\"}\n\n", "data: [DONE]\n\n"); got != "data: {\"id\":\"1\"}\n\n" {
+		t.Errorf("body = %q", got)
+	}
+
+	cfg.Streaming.Dialect.DoneSentinel = &on
+	failed := "event: error\ndata: {\"type\":\"error\"}\n\n"
+	if got := serveSSE(t, cfg, "k", failed); got != failed {
+		t.Errorf("done sentinel appended to a failed stream: %q", got)
+	}
+	if got := serveSSE(t, cfg, "k", "data: {}\n\n", "data: [DONE]\n\n"); got != "data: {}\n\ndata: [DONE]\n\n" {
+		t.Errorf("done sentinel duplicated: %q", got)
+	}
+}
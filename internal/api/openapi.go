@@ -0,0 +1,272 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
+)
+
+// openAPIOperation describes a known inbound endpoint in the generated document.
+type openAPIOperation struct {
+	summary string
+	// body names a request schema in components.schemas, if the endpoint takes one.
+	body string
+}
+
+// openAPIOperations documents the inbound endpoints that carry model requests. Other
+// registered routes are listed with a generic description.
+var openAPIOperations = map[string]openAPIOperation{
+	"GET /v1/models":                                {summary: "List available models"},
+	"POST /v1/chat/completions":                     {summary: "OpenAI chat completions", body: "ChatCompletionRequest"},
+	"POST /v1/completions":                          {summary: "OpenAI legacy completions", body: "CompletionRequest"},
+	"POST /v1/embeddings":                           {summary: "OpenAI embeddings, served by Gemini embedding models and OpenAI-compatible providers", body: "EmbeddingsRequest"},
+	"POST /v1/images/generations":                   {summary: "OpenAI image generation, served by Gemini image models such as gemini-3-pro-image", body: "ImageGenerationRequest"},
+	"POST /v1/audio/transcriptions":                 {summary: "OpenAI audio transcription (multipart upload), served by Gemini models and OpenAI-compatible providers"},
+	"POST /v1/messages":                             {summary: "Anthropic messages", body: "MessagesRequest"},
+	"POST /v1/messages/count_tokens":                {summary: "Anthropic token counting", body: "MessagesRequest"},
+	"POST /v1/messages/count_tokens/batch":          {summary: "Batch Anthropic token counting", body: "CountTokensBatchRequest"},
+	"POST /v1/messages/batches":                     {summary: "Create an Anthropic message batch", body: "MessageBatchRequest"},
+	"GET /v1/messages/batches":                      {summary: "List the caller's message batches"},
+	"GET /v1/messages/batches/{id}":                 {summary: "Status of a message batch"},
+	"GET /v1/messages/batches/{id}/results":         {summary: "JSONL results of an ended message batch"},
+	"POST /v1/messages/batches/{id}/cancel":         {summary: "Cancel the unstarted requests of a message batch"},
+	"DELETE /v1/messages/batches/{id}":              {summary: "Delete an ended message batch and its results"},
+	"POST /v1/responses":                            {summary: "OpenAI responses", body: "ResponsesRequest"},
+	"POST /v1/responses/compact":                    {summary: "Compact an OpenAI responses conversation", body: "ResponsesRequest"},
+	"GET /v1/realtime":                              {summary: "OpenAI Realtime API over WebSocket (text only), bridged to streaming chat completions; ?model= is required"},
+	"POST /v1/agent/completions":                    {summary: "Agent mode: the proxy runs server-side tools until the model answers", body: "ChatCompletionRequest"},
+	"POST /v1/jobs":                                 {summary: "Schedule a request to run later or once a model's quota has recovered", body: "JobRequest"},
+	"GET /v1/jobs":                                  {summary: "List the caller's scheduled jobs"},
+	"GET /v1/jobs/{id}":                             {summary: "Status and result of a scheduled job"},
+	"DELETE /v1/jobs/{id}":                          {summary: "Cancel a scheduled job, or remove a finished one"},
+	"GET /v1/limits":                                {summary: "Remaining key budget and model availability for the caller"},
+	"GET /v1/openapi.json":                          {summary: "This OpenAPI document"},
+	"GET /v1/capabilities":                          {summary: "Features honoured per model after translation"},
+	"GET /v1/capacity":                              {summary: "Remaining pool quota, available credentials and soonest reset per model"},
+	"GET /metrics":                                  {summary: "Prometheus metrics (when metrics.enabled is set)"},
+	"GET /readyz":                                   {summary: "Readiness with the startup preflight report"},
+	"GET /v1beta/models":                            {summary: "List available models (Gemini format)"},
+	"POST /v1beta/models/{action}":                  {summary: "Gemini generateContent, streamGenerateContent and countTokens", body: "GeminiRequest"},
+	"GET /v1beta/models/{action}":                   {summary: "Get a model (Gemini format)"},
+	"GET /v0/management/config":                     {summary: "Current configuration"},
+	"GET /v0/management/config/schema":              {summary: "JSON schema of the config file"},
+	"GET /v0/management/usage":                      {summary: "Usage statistics with tokens and estimated cost per API key and model"},
+	"GET /v0/management/auth-files":                 {summary: "List auth files"},
+	"GET /v0/management/auth-files/model-states":    {summary: "Per-model cooldown and quota state of each auth"},
+	"DELETE /v0/management/auth-files/model-states": {summary: "Clear model states of an auth (all, or the given model parameters)"},
+	"GET /v0/management/auth-files/tombstones":      {summary: "Deleted auth files that can still be restored"},
+	"POST /v0/management/auth-files/restore":        {summary: "Restore a deleted auth file and its quota snapshot from its tombstone"},
+	"POST /v0/management/pool-plan":                 {summary: "Simulate a workload against current pool quotas: exhaustion time and credentials to add"},
+	"GET /v0/management/quota":                      {summary: "Remaining quota percentage, reset time and cooldown state per credential and model"},
+	"GET /v0/management/quota-refreshes":            {summary: "Pending scheduled Antigravity quota refreshes"},
+	"DELETE /v0/management/quota-refreshes":         {summary: "Cancel the pending quota refresh of an auth"},
+	"POST /v0/management/quota-refreshes/trigger":   {summary: "Run the quota refresh of an auth now"},
+	"GET /v0/management/runtime-logging":            {summary: "Runtime log level, debug targets and request capture state"},
+	"PATCH /v0/management/runtime-logging":          {summary: "Change runtime logging without editing the config file"},
+	"GET /v0/management/usage/calibration":          {summary: "Token estimator calibration report"},
+	"DELETE /v0/management/usage/calibration":       {summary: "Reset token estimator calibration samples"},
+	"GET /v0/management/usage/records":              {summary: "Persisted usage records from the usage database, newest first"},
+	"GET /v0/management/usage/summary":              {summary: "Persisted usage aggregated by provider, model, API key, auth or day"},
+}
+
+// openAPIHandler serves an OpenAPI 3 document for the routes currently registered on the
+// engine, with the available model IDs injected into the Model schema.
+func (s *Server) openAPIHandler(openaiHandlers *openai.OpenAIAPIHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var models []string
+		for _, model := range openaiHandlers.Models() {
+			if id, ok := model["id"].(string); ok && id != "" {
+				models = append(models, id)
+			}
+		}
+		c.JSON(http.StatusOK, buildOpenAPIDocument(s.engine.Routes(), models))
+	}
+}
+
+func buildOpenAPIDocument(routes gin.RoutesInfo, models []string) gin.H {
+	paths := make(map[string]gin.H)
+	for _, route := range routes {
+		if route.Method == http.MethodHead || route.Method == http.MethodOptions {
+			continue
+		}
+		path := openAPIPath(route.Path)
+		item := paths[path]
+		if item == nil {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = openAPIOperationFor(route.Method, path)
+	}
+
+	sort.Strings(models)
+	modelSchema := gin.H{"type": "string", "description": "Model ID served by this instance."}
+	if len(models) > 0 {
+		modelSchema["enum"] = models
+	}
+	modelRequest := func(description string) gin.H {
+		return gin.H{
+			"type":                 "object",
+			"description":          description,
+			"required":             []string{"model"},
+			"properties":           gin.H{"model": gin.H{"$ref": "#/components/schemas/Model"}, "stream": gin.H{"type": "boolean"}},
+			"additionalProperties": true,
+		}
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":       "CLI Proxy API",
+			"version":     buildinfo.Version,
+			"description": "Inbound endpoints of this proxy instance. Request bodies follow the upstream OpenAI, Anthropic and Gemini APIs; only the fields the proxy routes on are described.",
+		},
+		"servers": []gin.H{{"url": "/"}},
+		"paths":   paths,
+		"components": gin.H{
+			"securitySchemes": gin.H{
+				"apiKey":        gin.H{"type": "http", "scheme": "bearer", "description": "Client API key. x-api-key, x-goog-api-key and the key query parameter are also accepted."},
+				"managementKey": gin.H{"type": "http", "scheme": "bearer", "description": "Management secret. The X-Management-Key header is also accepted."},
+			},
+			"schemas": gin.H{
+				"Model":                 modelSchema,
+				"ChatCompletionRequest": modelRequest("OpenAI chat completions request."),
+				"CompletionRequest":     modelRequest("OpenAI completions request."),
+				"EmbeddingsRequest":     modelRequest("OpenAI embeddings request; input is a string or an array."),
+				"ImageGenerationRequest": gin.H{
+					"type":        "object",
+					"description": "OpenAI image generation request. model defaults to gemini-3-pro-image; size selects the aspect ratio.",
+					"required":    []string{"prompt"},
+					"properties": gin.H{
+						"model":           gin.H{"type": "string"},
+						"prompt":          gin.H{"type": "string"},
+						"n":               gin.H{"type": "integer", "minimum": 1, "maximum": 4},
+						"size":            gin.H{"type": "string", "enum": []string{"auto", "256x256", "512x512", "1024x1024", "1536x1024", "1024x1536", "1792x1024", "1024x1792"}},
+						"quality":         gin.H{"type": "string"},
+						"response_format": gin.H{"type": "string", "enum": []string{"b64_json", "url"}},
+					},
+				},
+				"MessagesRequest":  modelRequest("Anthropic messages request."),
+				"ResponsesRequest": modelRequest("OpenAI responses request."),
+				"GeminiRequest": gin.H{
+					"type":                 "object",
+					"description":          "Gemini generateContent request. The model is taken from the path, e.g. /v1beta/models/{model}:generateContent.",
+					"additionalProperties": true,
+				},
+				"CountTokensBatchRequest": gin.H{
+					"type":        "object",
+					"description": "Counts each distinct payload with its own upstream count_tokens call; results are returned in request order.",
+					"required":    []string{"requests"},
+					"properties": gin.H{
+						"requests": gin.H{"type": "array", "maxItems": 64, "items": gin.H{"$ref": "#/components/schemas/MessagesRequest"}},
+					},
+				},
+				"MessageBatchRequest": gin.H{
+					"type":        "object",
+					"description": "Messages requests run through the credential pool with bounded concurrency. Results are fetched as JSONL once the batch has ended.",
+					"required":    []string{"requests"},
+					"properties": gin.H{
+						"requests": gin.H{
+							"type":     "array",
+							"minItems": 1,
+							"items": gin.H{
+								"type":     "object",
+								"required": []string{"custom_id", "params"},
+								"properties": gin.H{
+									"custom_id": gin.H{"type": "string", "pattern": "^[a-zA-Z0-9_-]{1,64}$"},
+									"params":    gin.H{"$ref": "#/components/schemas/MessagesRequest"},
+								},
+							},
+						},
+					},
+				},
+				"JobRequest": gin.H{
+					"type":        "object",
+					"description": "A request to run later. It starts once run_at (or delay_seconds) has passed and the when_quota condition holds.",
+					"required":    []string{"body"},
+					"properties": gin.H{
+						"endpoint":      gin.H{"type": "string", "enum": []string{"/v1/chat/completions", "/v1/messages", "/v1/responses", "/v1/embeddings"}, "default": "/v1/chat/completions"},
+						"body":          gin.H{"type": "object", "description": "The non-streaming request for the endpoint.", "additionalProperties": true},
+						"run_at":        gin.H{"type": "string", "format": "date-time"},
+						"delay_seconds": gin.H{"type": "integer", "minimum": 0},
+						"when_quota": gin.H{
+							"type": "object",
+							"properties": gin.H{
+								"model":                 gin.H{"type": "string", "description": "Defaults to the model of the request."},
+								"min_remaining_percent": gin.H{"type": "number", "minimum": 0, "maximum": 100},
+							},
+						},
+						"webhook_url": gin.H{"type": "string", "format": "uri", "description": "Receives the finished job; the host must be listed in scheduled-jobs.webhook-hosts."},
+						"requeue":     gin.H{"type": "boolean", "description": "Retry after the pool's reset time when every credential is exhausted. Defaults to scheduled-jobs.requeue-exhausted."},
+					},
+				},
+			},
+		},
+	}
+}
+
+func openAPIOperationFor(method, path string) gin.H {
+	known, ok := openAPIOperations[method+" "+path]
+	summary := known.summary
+	if !ok {
+		summary = method + " " + path
+	}
+	op := gin.H{
+		"summary":   summary,
+		"tags":      []string{openAPITag(path)},
+		"responses": gin.H{"200": gin.H{"description": "Success"}, "default": gin.H{"description": "Error"}},
+	}
+	switch {
+	case strings.HasPrefix(path, "/v0/management"):
+		op["security"] = []gin.H{{"managementKey": []string{}}}
+	case strings.HasPrefix(path, "/v1/"), strings.HasPrefix(path, "/v1beta/"):
+		op["security"] = []gin.H{{"apiKey": []string{}}}
+	}
+	if known.body != "" {
+		op["requestBody"] = gin.H{
+			"required": true,
+			"content":  gin.H{"application/json": gin.H{"schema": gin.H{"$ref": "#/components/schemas/" + known.body}}},
+		}
+	} else if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+		op["requestBody"] = gin.H{"content": gin.H{"application/json": gin.H{"schema": gin.H{"type": "object"}}}}
+	}
+	var params []gin.H
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, gin.H{"name": strings.Trim(segment, "{}"), "in": "path", "required": true, "schema": gin.H{"type": "string"}})
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	return op
+}
+
+// openAPIPath converts gin parameters (":id", "*action") to OpenAPI templates ("{id}").
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func openAPITag(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/v0/management"):
+		return "management"
+	case strings.HasPrefix(path, "/v1beta"):
+		return "gemini"
+	case strings.HasPrefix(path, "/v1/messages"):
+		return "anthropic"
+	case strings.HasPrefix(path, "/v1/"):
+		return "openai"
+	default:
+		return "server"
+	}
+}
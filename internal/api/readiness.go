@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/preflight"
+)
+
+// SetPreflightReport records the startup preflight for GET /readyz.
+func (s *Server) SetPreflightReport(report preflight.Report) {
+	if s == nil {
+		return
+	}
+	s.preflight.Store(&report)
+}
+
+// readinessHandler serves GET /readyz. The proxy is ready once it serves requests;
+// preflight warnings are reported alongside but do not fail readiness, as most of them
+// degrade the proxy rather than stop it.
+func (s *Server) readinessHandler(c *gin.Context) {
+	body := gin.H{"status": "ready"}
+	report := s.preflight.Load()
+	if report == nil {
+		body["preflight"] = gin.H{"status": "pending"}
+		c.JSON(http.StatusOK, body)
+		return
+	}
+	status := preflight.StatusOK
+	warnings := report.Warnings()
+	if len(warnings) > 0 {
+		status = preflight.StatusWarning
+	} else {
+		warnings = []preflight.Check{}
+	}
+	body["preflight"] = gin.H{
+		"status":     status,
+		"checked_at": report.CheckedAt,
+		"warnings":   warnings,
+		"checks":     report.Checks,
+	}
+	c.JSON(http.StatusOK, body)
+}
@@ -20,22 +20,38 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
 	managementHandlers "github.com/router-for-me/CLIProxyAPI/v6/internal/api/handlers/management"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/dashboard"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/preflight"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagedb"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagesink"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/agent"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/batches"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/jobs"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -59,9 +75,9 @@ type ServerOption func(*serverOptionConfig)
 func defaultRequestLoggerFactory(cfg *config.Config, configPath string) logging.RequestLogger {
 	configDir := filepath.Dir(configPath)
 	if base := util.WritablePath(); base != "" {
-		return logging.NewFileRequestLogger(cfg.RequestLog, filepath.Join(base, "logs"), configDir, cfg.ErrorLogsMaxFiles)
+		return logging.NewFileRequestLogger(cfg.RequestLogActive(), filepath.Join(base, "logs"), configDir, cfg.ErrorLogsMaxFiles)
 	}
-	return logging.NewFileRequestLogger(cfg.RequestLog, "logs", configDir, cfg.ErrorLogsMaxFiles)
+	return logging.NewFileRequestLogger(cfg.RequestLogActive(), "logs", configDir, cfg.ErrorLogsMaxFiles)
 }
 
 // WithMiddleware appends additional Gin middleware during server construction.
@@ -120,6 +136,10 @@ type Server struct {
 	// server is the underlying HTTP server.
 	server *http.Server
 
+	// scopedServers serve listeners restricted to API or management routes.
+	scopedServers   []*http.Server
+	scopedServersMu sync.Mutex
+
 	// handlers contains the API handlers for processing requests.
 	handlers *handlers.BaseAPIHandler
 
@@ -133,6 +153,9 @@ type Server struct {
 	// accessManager handles request authentication providers.
 	accessManager *sdkaccess.Manager
 
+	// managedKeys stores hashed inbound API keys managed through the management API.
+	managedKeys *managedkeys.Store
+
 	// requestLogger is the request logger instance for dynamic configuration updates.
 	requestLogger logging.RequestLogger
 	loggerToggle  func(bool)
@@ -152,6 +175,15 @@ type Server struct {
 	// management handler
 	mgmt *managementHandlers.Handler
 
+	// usageDB persists usage records when a usage database is configured.
+	usageDB *usagedb.Store
+
+	// quotaStore holds the quota snapshots reported as metrics.
+	quotaStore *quota.Store
+
+	// preflight is the startup preflight report served by GET /readyz.
+	preflight atomic.Pointer[preflight.Report]
+
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
@@ -203,6 +235,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Add middleware
 	engine.Use(logging.GinLogrusLogger())
 	engine.Use(logging.GinLogrusRecovery())
+	engine.Use(metrics.Middleware())
+	engine.Use(tracing.Middleware())
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
@@ -211,12 +245,15 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Resolve logs directory relative to the configuration file directory.
 	var requestLogger logging.RequestLogger
 	var toggle func(bool)
+	var s *Server
 	if !cfg.CommercialMode {
 		if optionState.requestLoggerFactory != nil {
 			requestLogger = optionState.requestLoggerFactory(cfg, configFilePath)
 		}
 		if requestLogger != nil {
-			engine.Use(middleware.RequestLoggingMiddleware(requestLogger))
+			engine.Use(middleware.RequestLoggingMiddlewareWithPolicy(requestLogger, logging.ConfigRequestLogPolicy(func() *config.SDKConfig {
+				return &s.cfg.SDKConfig
+			})))
 			if setter, ok := requestLogger.(interface{ SetEnabled(bool) }); ok {
 				toggle = setter.SetEnabled
 			}
@@ -234,7 +271,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	envManagementSecret := envAdminPasswordSet && envAdminPassword != ""
 
 	// Create server instance
-	s := &Server{
+	s = &Server{
 		engine:              engine,
 		handlers:            handlers.NewBaseAPIHandlers(&cfg.SDKConfig, authManager),
 		cfg:                 cfg,
@@ -247,8 +284,14 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		wsRoutes:            make(map[string]struct{}),
 	}
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
+	util.SetLogRedaction(cfg.LogRedaction.FullRedaction(), cfg.LogRedaction.Headers, cfg.LogRedaction.Fields)
+	tracing.Configure(cfg.Tracing)
+	sdktranslator.SetStreamValidation(cfg.DebugStreamValidation)
+	cache.SetSignaturePolicies(cfg.ThoughtSignaturePolicies)
+	pricing.Set(cfg.ModelPrices)
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
+	s.initManagedKeys()
 	s.applyAccessConfig(nil, cfg)
 	if authManager != nil {
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
@@ -257,12 +300,16 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
+	s.mgmt.SetManagedKeyStore(s.managedKeys)
 	if optionState.localPassword != "" {
 		s.mgmt.SetLocalPassword(optionState.localPassword)
 	}
 	logDir := logging.ResolveLogDirectory(cfg)
 	s.mgmt.SetLogDirectory(logDir)
+	s.mgmt.SetRequestLogToggle(s.setRequestLogEnabled)
 	s.localPassword = optionState.localPassword
+	s.applyUsageDatabase(nil, cfg)
+	usagesink.Configure(cfg.UsageSinks)
 
 	// Setup routes
 	s.setupRoutes()
@@ -304,32 +351,75 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	return s
 }
 
+// messageBatchesDir returns where Message Batches are stored: the configured directory, or
+// "batches" under the auth directory.
+func (s *Server) messageBatchesDir() string {
+	if dir := strings.TrimSpace(s.cfg.MessageBatches.Dir); dir != "" {
+		if resolved, err := util.ResolveAuthDir(dir); err == nil {
+			return resolved
+		}
+		return dir
+	}
+	authDir, err := util.ResolveAuthDir(s.cfg.AuthDir)
+	if err != nil || authDir == "" {
+		return "batches"
+	}
+	return filepath.Join(authDir, "batches")
+}
+
 // setupRoutes configures the API routes for the server.
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
+	dashboard.Register(s.engine, s.dashboardEnabled)
+	s.engine.GET("/metrics", s.metricsHandler)
+	s.engine.GET("/readyz", s.readinessHandler)
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	agentHandlers := agent.NewAgentAPIHandler(s.handlers)
+	jobsHandlers := jobs.NewJobsAPIHandler(s.handlers)
+	batchesHandlers := batches.NewMessageBatchesAPIHandler(s.handlers, s.messageBatchesDir())
 
 	// OpenAI compatible API routes
+	sseDialect := middleware.SSEDialectMiddleware(func() *config.SDKConfig { return &s.cfg.SDKConfig })
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(uncountedLimitsLookup(), AuthMiddleware(s.accessManager), sseDialect)
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/images/generations", openaiHandlers.ImageGenerations)
+		v1.POST("/audio/transcriptions", openaiHandlers.AudioTranscriptions)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/count_tokens/batch", claudeCodeHandlers.ClaudeCountTokensBatch)
+		v1.POST("/messages/batches", batchesHandlers.Create)
+		v1.GET("/messages/batches", batchesHandlers.List)
+		v1.GET("/messages/batches/:id", batchesHandlers.Get)
+		v1.GET("/messages/batches/:id/results", batchesHandlers.Results)
+		v1.POST("/messages/batches/:id/cancel", batchesHandlers.Cancel)
+		v1.DELETE("/messages/batches/:id", batchesHandlers.Delete)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.GET("/realtime", openaiHandlers.Realtime)
+		v1.POST("/agent/completions", agentHandlers.Completions)
+		v1.POST("/jobs", jobsHandlers.Create)
+		v1.GET("/jobs", jobsHandlers.List)
+		v1.GET("/jobs/:id", jobsHandlers.Get)
+		v1.DELETE("/jobs/:id", jobsHandlers.Cancel)
+		v1.GET("/limits", s.limitsHandler)
+		v1.GET("/openapi.json", s.openAPIHandler(openaiHandlers))
+		v1.GET("/capabilities", s.capabilitiesHandler)
+		v1.GET("/capacity", s.capacityHandler)
 	}
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(AuthMiddleware(s.accessManager), sseDialect)
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
@@ -475,11 +565,20 @@ func (s *Server) registerManagementRoutes() {
 	mgmt := s.engine.Group("/v0/management")
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
 	{
+		mgmt.GET("/dashboard", s.mgmt.GetDashboardSummary)
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
+		mgmt.GET("/usage/calibration", s.mgmt.GetTokenCalibration)
+		mgmt.DELETE("/usage/calibration", s.mgmt.DeleteTokenCalibration)
+		mgmt.GET("/usage/records", s.mgmt.GetUsageRecords)
+		mgmt.GET("/usage/summary", s.mgmt.GetUsageSummary)
+		mgmt.GET("/backup", s.mgmt.DownloadBackup)
+		mgmt.POST("/backup/restore", s.mgmt.RestoreBackup)
+		mgmt.GET("/support-bundle", s.mgmt.DownloadSupportBundle)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
+		mgmt.GET("/config/schema", s.mgmt.GetConfigSchema)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
 
@@ -487,6 +586,16 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/debug", s.mgmt.PutDebug)
 		mgmt.PATCH("/debug", s.mgmt.PutDebug)
 
+		mgmt.GET("/routing/decisions", s.mgmt.GetRoutingDecisions)
+		mgmt.GET("/load-shedding", s.mgmt.GetLoadShedding)
+		mgmt.GET("/availability", s.mgmt.GetAvailability)
+		mgmt.GET("/streams", s.mgmt.GetLiveStreams)
+		mgmt.GET("/streams/:id/events", s.mgmt.GetLiveStreamEvents)
+
+		mgmt.GET("/runtime-logging", s.mgmt.GetRuntimeLogging)
+		mgmt.PATCH("/runtime-logging", s.mgmt.PatchRuntimeLogging)
+		mgmt.DELETE("/runtime-logging", s.mgmt.DeleteRuntimeLogging)
+
 		mgmt.GET("/logging-to-file", s.mgmt.GetLoggingToFile)
 		mgmt.PUT("/logging-to-file", s.mgmt.PutLoggingToFile)
 		mgmt.PATCH("/logging-to-file", s.mgmt.PutLoggingToFile)
@@ -518,11 +627,25 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/quota-exceeded/switch-preview-model", s.mgmt.PutSwitchPreviewModel)
 		mgmt.PATCH("/quota-exceeded/switch-preview-model", s.mgmt.PutSwitchPreviewModel)
 
+		mgmt.POST("/pool-plan", s.mgmt.PostPoolPlan)
+		mgmt.GET("/quota", s.mgmt.GetQuota)
+		mgmt.GET("/quota-refreshes", s.mgmt.GetQuotaRefreshes)
+		mgmt.DELETE("/quota-refreshes", s.mgmt.DeleteQuotaRefresh)
+		mgmt.POST("/quota-refreshes/trigger", s.mgmt.TriggerQuotaRefresh)
+
 		mgmt.GET("/api-keys", s.mgmt.GetAPIKeys)
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
 
+		mgmt.GET("/managed-api-keys", s.mgmt.ListManagedKeys)
+		mgmt.POST("/managed-api-keys", s.mgmt.CreateManagedKey)
+		mgmt.POST("/managed-api-keys/import", s.mgmt.ImportManagedKeys)
+		mgmt.POST("/managed-api-keys/tokens", s.mgmt.MintScopedToken)
+		mgmt.PATCH("/managed-api-keys/:id", s.mgmt.PatchManagedKey)
+		mgmt.DELETE("/managed-api-keys/:id", s.mgmt.DeleteManagedKey)
+		mgmt.POST("/managed-api-keys/:id/rotate", s.mgmt.RotateManagedKey)
+
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
 		mgmt.PATCH("/gemini-api-key", s.mgmt.PatchGeminiKey)
@@ -536,6 +659,9 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
+		mgmt.GET("/request-log-levels", s.mgmt.GetRequestLogLevels)
+		mgmt.PUT("/request-log-levels", s.mgmt.PutRequestLogLevels)
+		mgmt.PATCH("/request-log-levels", s.mgmt.PatchRequestLogLevels)
 		mgmt.GET("/ws-auth", s.mgmt.GetWebsocketAuth)
 		mgmt.PUT("/ws-auth", s.mgmt.PutWebsocketAuth)
 		mgmt.PATCH("/ws-auth", s.mgmt.PutWebsocketAuth)
@@ -615,7 +741,12 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
+		mgmt.GET("/auth-files/tombstones", s.mgmt.ListAuthTombstones)
+		mgmt.POST("/auth-files/restore", s.mgmt.RestoreAuthFile)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
+		mgmt.POST("/auth-files/gc", s.mgmt.RunAuthGC)
+		mgmt.GET("/auth-files/model-states", s.mgmt.GetAuthFileModelStates)
+		mgmt.DELETE("/auth-files/model-states", s.mgmt.DeleteAuthFileModelStates)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
@@ -640,6 +771,16 @@ func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	}
 }
 
+// dashboardEnabled reports whether the embedded dashboard may be served. It follows the
+// control panel switch and requires the management API it polls to be reachable.
+func (s *Server) dashboardEnabled() bool {
+	cfg := s.cfg
+	if cfg == nil || cfg.RemoteManagement.DisableControlPanel {
+		return false
+	}
+	return s.managementRoutesEnabled.Load()
+}
+
 func (s *Server) serveManagementControlPanel(c *gin.Context) {
 	cfg := s.cfg
 	if cfg == nil || cfg.RemoteManagement.DisableControlPanel {
@@ -775,27 +916,52 @@ func (s *Server) Start() error {
 	}
 
 	useTLS := s.cfg != nil && s.cfg.TLS.Enable
+	var cert, key string
 	if useTLS {
-		cert := strings.TrimSpace(s.cfg.TLS.Cert)
-		key := strings.TrimSpace(s.cfg.TLS.Key)
+		cert = strings.TrimSpace(s.cfg.TLS.Cert)
+		key = strings.TrimSpace(s.cfg.TLS.Key)
 		if cert == "" || key == "" {
 			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
 		}
-		log.Debugf("Starting API server on %s with TLS", s.server.Addr)
-		if errServeTLS := s.server.ListenAndServeTLS(cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
-			return fmt.Errorf("failed to start HTTPS server: %v", errServeTLS)
-		}
-		return nil
 	}
 
-	log.Debugf("Starting API server on %s", s.server.Addr)
-	if errServe := s.server.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
-		return fmt.Errorf("failed to start HTTP server: %v", errServe)
+	listeners, errListen := s.openListeners(useTLS)
+	if errListen != nil {
+		return fmt.Errorf("failed to start HTTP server: %v", errListen)
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l boundListener) {
+			errCh <- l.serve(cert, key)
+		}(l)
 	}
 
+	// Every listener must stop before Start returns; the first failure closes the rest.
+	var errServe error
+	for range listeners {
+		if err := <-errCh; err != nil && errServe == nil {
+			errServe = err
+			s.closeServers()
+		}
+	}
+	if errServe != nil {
+		if useTLS {
+			return fmt.Errorf("failed to start HTTPS server: %v", errServe)
+		}
+		return fmt.Errorf("failed to start HTTP server: %v", errServe)
+	}
 	return nil
 }
 
+// closeServers immediately closes the primary and scoped HTTP servers.
+func (s *Server) closeServers() {
+	_ = s.server.Close()
+	for _, srv := range s.scopedServerList() {
+		_ = srv.Close()
+	}
+}
+
 // Stop gracefully shuts down the API server without interrupting any
 // active connections.
 //
@@ -814,10 +980,25 @@ func (s *Server) Stop(ctx context.Context) error {
 		}
 	}
 
-	// Shutdown the HTTP server.
+	// Shutdown the HTTP servers.
+	for _, srv := range s.scopedServerList() {
+		if err := srv.Shutdown(ctx); err != nil {
+			return fmt.Errorf("failed to shutdown HTTP server: %v", err)
+		}
+	}
 	if err := s.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
+	if err := s.managedKeys.Flush(); err != nil {
+		log.Warnf("failed to persist managed API key usage: %v", err)
+	}
+	if err := tracing.Flush(ctx); err != nil {
+		log.Warnf("failed to export pending trace spans: %v", err)
+	}
+	if err := s.usageDB.Close(); err != nil {
+		log.Warnf("failed to close usage database: %v", err)
+	}
+	usagesink.Close()
 
 	log.Debug("API server stopped")
 	return nil
@@ -847,11 +1028,87 @@ func (s *Server) applyAccessConfig(oldCfg, newCfg *config.Config) {
 	if s == nil || s.accessManager == nil || newCfg == nil {
 		return
 	}
-	if _, err := access.ApplyAccessProviders(s.accessManager, oldCfg, newCfg); err != nil {
+	if s.managedKeys != nil && s.managedKeys.Err() != nil {
+		// The key file exists but is unreadable: fail closed by making the managed key
+		// provider the only one, which rejects every request until the file is fixed.
+		s.accessManager.SetProviders([]sdkaccess.Provider{managedkeys.NewProvider(s.managedKeys)})
+		return
+	}
+	var extra []sdkaccess.Provider
+	// Keep the provider registered while any record exists so that expiring or revoking the
+	// last managed key locks the proxy instead of falling back to unauthenticated access.
+	if s.managedKeys != nil && s.managedKeys.Len() > 0 {
+		extra = append(extra, managedkeys.NewProvider(s.managedKeys))
+	}
+	if _, err := access.ApplyAccessProviders(s.accessManager, oldCfg, newCfg, extra...); err != nil {
 		return
 	}
 }
 
+// initManagedKeys opens the hashed API key store that lives next to the config file
+// and re-applies access providers whenever keys are created, rotated or revoked.
+func (s *Server) initManagedKeys() {
+	path := managedKeysPath(s.configFilePath)
+	store, err := managedkeys.NewStore(path)
+	if err != nil {
+		log.Errorf("failed to load managed API keys from %s, denying all requests until it is repaired: %v", path, err)
+	}
+	store.SetOnChange(func() {
+		s.applyAccessConfig(s.cfg, s.cfg)
+	})
+	managedkeys.BindUsage(store)
+	s.managedKeys = store
+}
+
+// applyUsageDatabase opens the usage database when it is first configured and reopens it
+// when its settings change. Records are written to it through the usage plugin.
+func (s *Server) applyUsageDatabase(oldCfg, newCfg *config.Config) {
+	if oldCfg != nil && oldCfg.UsageDatabase == newCfg.UsageDatabase {
+		return
+	}
+	previous := s.usageDB
+	s.usageDB = nil
+	if strings.TrimSpace(newCfg.UsageDatabase.Driver) != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		store, err := usagedb.Open(ctx, newCfg.UsageDatabase)
+		cancel()
+		if err != nil {
+			log.Errorf("failed to open usage database, usage records are not persisted: %v", err)
+		} else {
+			s.usageDB = store
+		}
+	}
+	usagedb.Bind(s.usageDB)
+	s.mgmt.SetUsageDatabase(s.usageDB)
+	if previous != nil {
+		if err := previous.Close(); err != nil {
+			log.Warnf("failed to close previous usage database: %v", err)
+		}
+	}
+}
+
+func managedKeysPath(configFilePath string) string {
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, managedkeys.DefaultFileName)
+	}
+	if strings.TrimSpace(configFilePath) == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(configFilePath), managedkeys.DefaultFileName)
+}
+
+// setRequestLogEnabled enables or disables the request logger, if one is configured.
+func (s *Server) setRequestLogEnabled(enabled bool) {
+	if s.requestLogger == nil {
+		return
+	}
+	if s.loggerToggle != nil {
+		s.loggerToggle(enabled)
+	} else if toggler, ok := s.requestLogger.(interface{ SetEnabled(bool) }); ok {
+		toggler.SetEnabled(enabled)
+	}
+}
+
 // UpdateClients updates the server's client list and configuration.
 // This method is called when the configuration or authentication tokens change.
 //
@@ -868,16 +1125,18 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	// Update request logger enabled state if it has changed
 	previousRequestLog := false
 	if oldCfg != nil {
-		previousRequestLog = oldCfg.RequestLog
+		previousRequestLog = logging.RequestCaptureActive(&oldCfg.SDKConfig)
 	}
-	if s.requestLogger != nil && (oldCfg == nil || previousRequestLog != cfg.RequestLog) {
-		if s.loggerToggle != nil {
-			s.loggerToggle(cfg.RequestLog)
-		} else if toggler, ok := s.requestLogger.(interface{ SetEnabled(bool) }); ok {
-			toggler.SetEnabled(cfg.RequestLog)
-		}
+	if requestLogActive := logging.RequestCaptureActive(&cfg.SDKConfig); s.requestLogger != nil && (oldCfg == nil || previousRequestLog != requestLogActive) {
+		s.setRequestLogEnabled(requestLogActive)
 	}
 
+	util.SetLogRedaction(cfg.LogRedaction.FullRedaction(), cfg.LogRedaction.Headers, cfg.LogRedaction.Fields)
+	tracing.Configure(cfg.Tracing)
+	sdktranslator.SetStreamValidation(cfg.DebugStreamValidation)
+	cache.SetSignaturePolicies(cfg.ThoughtSignaturePolicies)
+	pricing.Set(cfg.ModelPrices)
+
 	if oldCfg == nil || oldCfg.LoggingToFile != cfg.LoggingToFile || oldCfg.LogsMaxTotalSizeMB != cfg.LogsMaxTotalSizeMB {
 		if err := logging.ConfigureLogOutput(cfg); err != nil {
 			log.Errorf("failed to reconfigure log output: %v", err)
@@ -887,6 +1146,13 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	if oldCfg == nil || oldCfg.UsageStatisticsEnabled != cfg.UsageStatisticsEnabled {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
+	if oldCfg == nil || oldCfg.UsageAggregateOnly != cfg.UsageAggregateOnly {
+		coreusage.SetAggregateOnly(cfg.UsageAggregateOnly)
+	}
+	s.applyUsageDatabase(oldCfg, cfg)
+	if oldCfg != nil && !reflect.DeepEqual(oldCfg.UsageSinks, cfg.UsageSinks) {
+		usagesink.Configure(cfg.UsageSinks)
+	}
 
 	if s.requestLogger != nil && (oldCfg == nil || oldCfg.ErrorLogsMaxFiles != cfg.ErrorLogsMaxFiles) {
 		if setter, ok := s.requestLogger.(interface{ SetErrorLogsMaxFiles(int) }); ok {
@@ -1001,6 +1267,26 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	)
 }
 
+// SetQuotaStore exposes the running quota store to metrics, backup and restore.
+func (s *Server) SetQuotaStore(store *quota.Store) {
+	if s == nil {
+		return
+	}
+	s.quotaStore = store
+	if s.mgmt == nil {
+		return
+	}
+	s.mgmt.SetQuotaStore(store)
+}
+
+// BackupSources returns the proxy state included in backup archives.
+func (s *Server) BackupSources() backup.Sources {
+	if s == nil || s.mgmt == nil {
+		return backup.Sources{}
+	}
+	return s.mgmt.BackupSources()
+}
+
 func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 	if s == nil {
 		return
@@ -1,18 +1,24 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	gin "github.com/gin-gonic/gin"
+	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
 	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
 )
 
 func newTestServer(t *testing.T) *Server {
@@ -109,3 +115,101 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestLimitsEndpointReportsScopedBudgetAndPool(t *testing.T) {
+	configaccess.Register()
+	server := newTestServer(t)
+
+	authRecord := &auth.Auth{ID: "limits-test-auth", Provider: "gemini"}
+	if _, err := server.handlers.AuthManager.Register(context.Background(), authRecord); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(authRecord.ID, "gemini", []*registry.ModelInfo{{ID: "limits-test-model"}, {ID: "limits-other-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(authRecord.ID) })
+
+	secret, _, err := server.managedKeys.Mint("cli", time.Now().Add(time.Hour), managedkeys.Scope{
+		Models:      []string{"limits-test-*"},
+		MaxRequests: 10,
+	})
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/limits", nil)
+	req.Header.Set("Authorization", "Bearer "+secret)
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	body := rr.Body.String()
+	if got := gjson.Get(body, "key.remaining_requests").Int(); got != 10 {
+		t.Fatalf("expected limits lookup not to spend the budget, got %d remaining: %s", got, body)
+	}
+	if got := gjson.Get(body, "rate_limits.limit_requests").Int(); got != 10 {
+		t.Fatalf("expected rate_limits.limit_requests 10, got %d: %s", got, body)
+	}
+	if got := rr.Header().Get("x-ratelimit-remaining-requests"); got != "10" {
+		t.Fatalf("expected x-ratelimit-remaining-requests 10, got %q", got)
+	}
+	models := gjson.Get(body, "models").Array()
+	if len(models) != 1 || models[0].Get("id").String() != "limits-test-model" || models[0].Get("ready").Int() != 1 {
+		t.Fatalf("unexpected models: %s", body)
+	}
+	if line := gjson.Get(body, "status_line").String(); !strings.Contains(line, "0/10 req") || !strings.Contains(line, "1/1 models ready") {
+		t.Fatalf("unexpected status line: %q", line)
+	}
+}
+
+func TestDashboardServedOnlyWithManagement(t *testing.T) {
+	server := newTestServer(t)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if rr := get("/dashboard/"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without management secret, got %d", rr.Code)
+	}
+
+	server.managementRoutesEnabled.Store(true)
+	rr := get("/dashboard/")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "assets/app.js") {
+		t.Fatalf("unexpected index response: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr = get("/dashboard/assets/app.js"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/dashboard") {
+		t.Fatalf("unexpected asset response: %d", rr.Code)
+	}
+
+	server.cfg.RemoteManagement.DisableControlPanel = true
+	if rr = get("/dashboard/"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 with control panel disabled, got %d", rr.Code)
+	}
+}
+
+func TestOpenAPIDocumentListsRegisteredRoutes(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rr.Code, rr.Body.String())
+	}
+
+	body := rr.Body.String()
+	if got := gjson.Get(body, "openapi").String(); got != "3.0.3" {
+		t.Fatalf("openapi = %q", got)
+	}
+	if !gjson.Get(body, `paths./v1/chat/completions.post.requestBody`).Exists() {
+		t.Fatalf("chat completions operation missing or without body")
+	}
+	if !gjson.Get(body, `paths./v1beta/models/{action}.post`).Exists() {
+		t.Fatalf("expected gin wildcard converted to a path template")
+	}
+}
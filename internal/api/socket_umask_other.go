@@ -0,0 +1,21 @@
+//go:build !windows
+
+package api
+
+import (
+	"sync"
+	"syscall"
+)
+
+// umaskMu serialises umask changes, which are process wide.
+var umaskMu sync.Mutex
+
+// withSocketUmask runs listen with a umask that creates the socket file with at most
+// perm, so it is never reachable with wider permissions before the final chmod.
+func withSocketUmask(perm uint32, listen func() error) error {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	old := syscall.Umask(int(^perm & 0o777))
+	defer syscall.Umask(old)
+	return listen()
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package api
+
+// withSocketUmask runs listen directly; Windows has no umask and ignores Unix permission bits.
+func withSocketUmask(_ uint32, listen func() error) error {
+	return listen()
+}
@@ -84,7 +84,12 @@ func (g *GeminiAuth) GetAuthenticatedClient(ctx context.Context, ts *GeminiToken
 	proxyURL, err := url.Parse(cfg.ProxyURL)
 	if err == nil {
 		var transport *http.Transport
-		if proxyURL.Scheme == "socks5" {
+		if unixTransport, isUnix, errUnix := util.UnixProxyTransport(proxyURL); isUnix {
+			if errUnix != nil {
+				return nil, fmt.Errorf("create unix socket proxy transport failed: %w", errUnix)
+			}
+			transport = unixTransport
+		} else if proxyURL.Scheme == "socks5" {
 			// Handle SOCKS5 proxy.
 			username := proxyURL.User.Username()
 			password, _ := proxyURL.User.Password()
@@ -0,0 +1,431 @@
+// Package backup produces and restores encrypted archives of proxy state: auth files,
+// the quota store, usage statistics, managed API keys and the config file.
+//
+// Archives are gzip-compressed tarballs sealed with AES-256-GCM under a key derived
+// from a passphrase with scrypt, so they can be copied between hosts safely.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/migration"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	formatVersion = 1
+	magic         = "CPAB"
+	saltSize      = 16
+
+	// MaxArchiveSize bounds archives accepted for restore.
+	MaxArchiveSize = 256 << 20
+	// DefaultMaxRestoreSize bounds the decompressed contents of a restored archive when
+	// RestoreOptions.MaxSize is unset.
+	DefaultMaxRestoreSize = 1 << 30
+
+	manifestName = "manifest.json"
+	authPrefix   = "auths/"
+	configName   = "config/config.yaml"
+	quotaName    = "quota/quota.json"
+	usageName    = "usage/usage.json"
+	keysName     = "keys/managed-keys.json"
+)
+
+// ErrBadPassphrase is returned when an archive cannot be decrypted.
+var ErrBadPassphrase = errors.New("backup: wrong passphrase or corrupted archive")
+
+// Sources locates the state included in an archive. Empty fields are skipped.
+type Sources struct {
+	AuthDir         string
+	ConfigPath      string
+	QuotaPath       string
+	ManagedKeysPath string
+	Usage           *usage.RequestStatistics
+}
+
+// Manifest describes the contents of an archive.
+type Manifest struct {
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	AuthFiles   int       `json:"auth_files"`
+	Config      bool      `json:"config"`
+	Quota       bool      `json:"quota"`
+	Usage       bool      `json:"usage"`
+	ManagedKeys bool      `json:"managed_keys"`
+}
+
+// RestoreOptions controls which parts of an archive are written back.
+type RestoreOptions struct {
+	// Config overwrites the local config file. Off by default because ports, paths and
+	// secrets are usually host specific.
+	Config bool
+	// SaveAuth, when set, persists each auth file instead of writing it under AuthDir
+	// directly. path is the resolved target inside AuthDir.
+	SaveAuth func(path string, data []byte) error
+	// MaxSize bounds the decompressed size of the archive in bytes. Default is
+	// DefaultMaxRestoreSize.
+	MaxSize int64
+}
+
+// RestoreResult reports what a restore changed.
+type RestoreResult struct {
+	Manifest     Manifest `json:"manifest"`
+	AuthFiles    int      `json:"auth_files"`
+	Config       bool     `json:"config"`
+	Quota        bool     `json:"quota"`
+	ManagedKeys  bool     `json:"managed_keys"`
+	UsageAdded   int64    `json:"usage_added"`
+	UsageSkipped int64    `json:"usage_skipped"`
+}
+
+type usagePayload struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Usage      usage.StatisticsSnapshot `json:"usage"`
+}
+
+// Create writes an encrypted archive of src to w.
+func Create(w io.Writer, src Sources, passphrase string) (Manifest, error) {
+	if strings.TrimSpace(passphrase) == "" {
+		return Manifest{}, fmt.Errorf("backup: passphrase is required")
+	}
+	manifest := Manifest{Version: formatVersion, CreatedAt: time.Now().UTC()}
+
+	var plain bytes.Buffer
+	gz := gzip.NewWriter(&plain)
+	tw := tar.NewWriter(gz)
+
+	if dir := strings.TrimSpace(src.AuthDir); dir != "" {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if d.Name() == migration.BackupDirName {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+				return nil
+			}
+			rel, errRel := filepath.Rel(dir, p)
+			if errRel != nil {
+				return nil
+			}
+			added, errAdd := addFile(tw, authPrefix+filepath.ToSlash(rel), p)
+			if errAdd != nil {
+				return errAdd
+			}
+			if added {
+				manifest.AuthFiles++
+			}
+			return nil
+		})
+		if err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	var err error
+	if manifest.Config, err = addFile(tw, configName, src.ConfigPath); err != nil {
+		return Manifest{}, err
+	}
+	if manifest.Quota, err = addFile(tw, quotaName, src.QuotaPath); err != nil {
+		return Manifest{}, err
+	}
+	if manifest.ManagedKeys, err = addFile(tw, keysName, src.ManagedKeysPath); err != nil {
+		return Manifest{}, err
+	}
+	if src.Usage != nil {
+		raw, errMarshal := json.Marshal(usagePayload{Version: 1, ExportedAt: manifest.CreatedAt, Usage: src.Usage.Snapshot()})
+		if errMarshal != nil {
+			return Manifest{}, fmt.Errorf("backup: marshal usage: %w", errMarshal)
+		}
+		if err = addBytes(tw, usageName, raw, manifest.CreatedAt); err != nil {
+			return Manifest{}, err
+		}
+		manifest.Usage = true
+	}
+
+	rawManifest, err := json.Marshal(manifest)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("backup: marshal manifest: %w", err)
+	}
+	if err = addBytes(tw, manifestName, rawManifest, manifest.CreatedAt); err != nil {
+		return Manifest{}, err
+	}
+	if err = tw.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("backup: close tar: %w", err)
+	}
+	if err = gz.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("backup: close gzip: %w", err)
+	}
+
+	sealed, err := seal(plain.Bytes(), passphrase)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if _, err = w.Write(sealed); err != nil {
+		return Manifest{}, fmt.Errorf("backup: write archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// Restore decrypts the archive read from r and writes its contents to dst.
+// Auth files are added or overwritten but never deleted; usage statistics are merged.
+func Restore(r io.Reader, dst Sources, passphrase string, opts RestoreOptions) (RestoreResult, error) {
+	var result RestoreResult
+	sealed, err := io.ReadAll(io.LimitReader(r, MaxArchiveSize+1))
+	if err != nil {
+		return result, fmt.Errorf("backup: read archive: %w", err)
+	}
+	if len(sealed) > MaxArchiveSize {
+		return result, fmt.Errorf("backup: archive exceeds %d bytes", MaxArchiveSize)
+	}
+	plain, err := open(sealed, passphrase)
+	if err != nil {
+		return result, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return result, fmt.Errorf("backup: invalid archive: %w", err)
+	}
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxRestoreSize
+	}
+	// The limit covers the whole tar stream, headers included, so an archive cannot expand
+	// past it however its entries are laid out.
+	stream := &io.LimitedReader{R: gz, N: maxSize + 1}
+	tr := tar.NewReader(stream)
+	for {
+		hdr, errNext := tr.Next()
+		if errNext == io.EOF {
+			break
+		}
+		if stream.N <= 0 {
+			return result, fmt.Errorf("backup: archive expands beyond %d bytes", maxSize)
+		}
+		if errNext != nil {
+			return result, fmt.Errorf("backup: invalid archive: %w", errNext)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Size > stream.N-1 {
+			return result, fmt.Errorf("backup: entry %s expands beyond %d bytes", hdr.Name, maxSize)
+		}
+		data, errRead := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if errRead != nil {
+			return result, fmt.Errorf("backup: read %s: %w", hdr.Name, errRead)
+		}
+		name := path.Clean(hdr.Name)
+		switch {
+		case name == manifestName:
+			if err = json.Unmarshal(data, &result.Manifest); err != nil {
+				return result, fmt.Errorf("backup: invalid manifest: %w", err)
+			}
+			if result.Manifest.Version > formatVersion {
+				return result, fmt.Errorf("backup: archive version %d is newer than supported %d", result.Manifest.Version, formatVersion)
+			}
+		case strings.HasPrefix(name, authPrefix):
+			if dst.AuthDir == "" {
+				continue
+			}
+			target, ok := safeJoin(dst.AuthDir, strings.TrimPrefix(name, authPrefix))
+			if !ok {
+				return result, fmt.Errorf("backup: unsafe path %q in archive", hdr.Name)
+			}
+			if opts.SaveAuth != nil {
+				err = opts.SaveAuth(target, data)
+			} else {
+				err = writeFile(target, data)
+			}
+			if err != nil {
+				return result, err
+			}
+			result.AuthFiles++
+		case name == configName:
+			if !opts.Config || dst.ConfigPath == "" {
+				continue
+			}
+			if err = writeFile(dst.ConfigPath, data); err != nil {
+				return result, err
+			}
+			result.Config = true
+		case name == quotaName:
+			if dst.QuotaPath == "" {
+				continue
+			}
+			if err = writeFile(dst.QuotaPath, data); err != nil {
+				return result, err
+			}
+			result.Quota = true
+		case name == keysName:
+			if dst.ManagedKeysPath == "" {
+				continue
+			}
+			if err = writeFile(dst.ManagedKeysPath, data); err != nil {
+				return result, err
+			}
+			result.ManagedKeys = true
+		case name == usageName:
+			if dst.Usage == nil {
+				continue
+			}
+			var payload usagePayload
+			if err = json.Unmarshal(data, &payload); err != nil {
+				return result, fmt.Errorf("backup: invalid usage snapshot: %w", err)
+			}
+			merged := dst.Usage.MergeSnapshot(payload.Usage)
+			result.UsageAdded = merged.Added
+			result.UsageSkipped = merged.Skipped
+		}
+	}
+	return result, nil
+}
+
+func addFile(tw *tar.Writer, name, src string) (bool, error) {
+	if strings.TrimSpace(src) == "" {
+		return false, nil
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("backup: stat %s: %w", src, err)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return false, fmt.Errorf("backup: read %s: %w", src, err)
+	}
+	return true, addBytes(tw, name, data, info.ModTime())
+}
+
+func addBytes(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("backup: add %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("backup: add %s: %w", name, err)
+	}
+	return nil
+}
+
+// safeJoin resolves rel under base, rejecting absolute paths and parent traversal.
+func safeJoin(base, rel string) (string, bool) {
+	if rel == "" || path.IsAbs(rel) || strings.HasPrefix(rel, "../") || rel == ".." {
+		return "", false
+	}
+	target := filepath.Join(base, filepath.FromSlash(rel))
+	relCheck, err := filepath.Rel(base, target)
+	if err != nil || strings.HasPrefix(relCheck, "..") {
+		return "", false
+	}
+	return target, true
+}
+
+func writeFile(target string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return fmt.Errorf("backup: create dir for %s: %w", target, err)
+	}
+	tmp := target + ".restoring"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("backup: write %s: %w", target, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("backup: replace %s: %w", target, err)
+	}
+	return nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// seal encrypts plain as magic | version | salt | nonce | ciphertext, authenticating the header.
+func seal(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("backup: generate salt: %w", err)
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, fmt.Errorf("backup: derive key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("backup: generate nonce: %w", err)
+	}
+	header := make([]byte, 0, len(magic)+1+saltSize+len(nonce))
+	header = append(header, magic...)
+	header = append(header, formatVersion)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+	return aead.Seal(header, nonce, plain, header), nil
+}
+
+func open(sealed []byte, passphrase string) ([]byte, error) {
+	headerLen := len(magic) + 1 + saltSize
+	if len(sealed) < headerLen || string(sealed[:len(magic)]) != magic {
+		return nil, fmt.Errorf("backup: not a backup archive")
+	}
+	if version := int(sealed[len(magic)]); version > formatVersion {
+		return nil, fmt.Errorf("backup: archive version %d is newer than supported %d", version, formatVersion)
+	}
+	salt := sealed[len(magic)+1 : headerLen]
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, fmt.Errorf("backup: derive key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < headerLen+aead.NonceSize() {
+		return nil, ErrBadPassphrase
+	}
+	header := sealed[:headerLen+aead.NonceSize()]
+	nonce := header[headerLen:]
+	plain, err := aead.Open(nil, nonce, sealed[len(header):], header)
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+	return plain, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("backup: init cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("backup: init gcm: %w", err)
+	}
+	return aead, nil
+}
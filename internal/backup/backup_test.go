@@ -0,0 +1,142 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestCreateRestoreRoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	authDir := filepath.Join(srcDir, "auths")
+	mustWrite(t, filepath.Join(authDir, "codex.json"), `{"type":"codex"}`)
+	mustWrite(t, filepath.Join(authDir, "team", "gemini.json"), `{"type":"gemini"}`)
+	mustWrite(t, filepath.Join(authDir, ".migrations", "x", "old.json"), `{}`)
+	mustWrite(t, filepath.Join(srcDir, "config.yaml"), "port: 8317\n")
+	mustWrite(t, filepath.Join(srcDir, "keys.json"), `{"keys":[]}`)
+
+	var archive bytes.Buffer
+	manifest, err := Create(&archive, Sources{
+		AuthDir:         authDir,
+		ConfigPath:      filepath.Join(srcDir, "config.yaml"),
+		ManagedKeysPath: filepath.Join(srcDir, "keys.json"),
+	}, "secret")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if manifest.AuthFiles != 2 || !manifest.Config || !manifest.ManagedKeys || manifest.Quota {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if bytes.Contains(archive.Bytes(), []byte("codex")) {
+		t.Fatal("archive is not encrypted")
+	}
+
+	if _, err = Restore(bytes.NewReader(archive.Bytes()), Sources{}, "wrong", RestoreOptions{}); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatalf("expected ErrBadPassphrase, got %v", err)
+	}
+
+	dstDir := t.TempDir()
+	dst := Sources{
+		AuthDir:         filepath.Join(dstDir, "auths"),
+		ConfigPath:      filepath.Join(dstDir, "config.yaml"),
+		ManagedKeysPath: filepath.Join(dstDir, "keys.json"),
+	}
+	result, err := Restore(bytes.NewReader(archive.Bytes()), dst, "secret", RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if result.AuthFiles != 2 || result.Config || !result.ManagedKeys {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dst.AuthDir, "team", "gemini.json")); string(raw) != `{"type":"gemini"}` {
+		t.Fatalf("nested auth file not restored: %q", raw)
+	}
+	if _, err = os.Stat(dst.ConfigPath); !os.IsNotExist(err) {
+		t.Fatal("config must not be restored unless requested")
+	}
+
+	if result, err = Restore(bytes.NewReader(archive.Bytes()), dst, "secret", RestoreOptions{Config: true}); err != nil || !result.Config {
+		t.Fatalf("config restore failed: %+v %v", result, err)
+	}
+
+	hooked := Sources{AuthDir: filepath.Join(t.TempDir(), "auths")}
+	saved := map[string]string{}
+	opts := RestoreOptions{SaveAuth: func(path string, data []byte) error {
+		saved[path] = string(data)
+		return nil
+	}}
+	if result, err = Restore(bytes.NewReader(archive.Bytes()), hooked, "secret", opts); err != nil || result.AuthFiles != 2 {
+		t.Fatalf("hooked restore failed: %+v %v", result, err)
+	}
+	if saved[filepath.Join(hooked.AuthDir, "codex.json")] != `{"type":"codex"}` {
+		t.Fatalf("SaveAuth not called with resolved path: %v", saved)
+	}
+	if _, err = os.Stat(hooked.AuthDir); !os.IsNotExist(err) {
+		t.Fatal("auth files must not be written directly when SaveAuth is set")
+	}
+}
+
+func TestRestoreRejectsArchivesExpandingPastMaxSize(t *testing.T) {
+	authDir := filepath.Join(t.TempDir(), "auths")
+	mustWrite(t, filepath.Join(authDir, "big.json"), `{"pad":"`+strings.Repeat("a", 64<<10)+`"}`)
+	var archive bytes.Buffer
+	if _, err := Create(&archive, Sources{AuthDir: authDir}, "secret"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if archive.Len() > 16<<10 {
+		t.Fatalf("archive of %d bytes should compress well below the limit", archive.Len())
+	}
+
+	dst := Sources{AuthDir: filepath.Join(t.TempDir(), "auths")}
+	_, err := Restore(bytes.NewReader(archive.Bytes()), dst, "secret", RestoreOptions{MaxSize: 16 << 10})
+	if err == nil || !strings.Contains(err.Error(), "expands beyond") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+	if _, errStat := os.Stat(filepath.Join(dst.AuthDir, "big.json")); !os.IsNotExist(errStat) {
+		t.Fatalf("oversized entry must not be written: %v", errStat)
+	}
+	if _, err = Restore(bytes.NewReader(archive.Bytes()), dst, "secret", RestoreOptions{}); err != nil {
+		t.Fatalf("Restore with default limit: %v", err)
+	}
+}
+
+func TestWriteScheduledPrunesOldArchives(t *testing.T) {
+	dir := t.TempDir()
+	for _, stamp := range []string{"20240101-000000", "20240102-000000", "20240103-000000"} {
+		mustWrite(t, filepath.Join(dir, filePrefix+stamp+FileExtension), "old")
+	}
+	path, err := WriteScheduled(config.BackupConfig{Passphrase: "secret", Dir: dir, Keep: 2}, Sources{})
+	if err != nil {
+		t.Fatalf("WriteScheduled: %v", err)
+	}
+	archives := listArchives(dir)
+	if len(archives) != 2 || archives[1] != filepath.Base(path) {
+		t.Fatalf("unexpected archives after prune: %v", archives)
+	}
+}
+
+func TestSafeJoinRejectsTraversal(t *testing.T) {
+	for _, rel := range []string{"../evil.json", "/etc/passwd", "a/../../evil.json", ".."} {
+		if _, ok := safeJoin("/base", rel); ok {
+			t.Fatalf("safeJoin accepted %q", rel)
+		}
+	}
+	if got, ok := safeJoin("/base", "team/a.json"); !ok || got != filepath.Join("/base", "team", "a.json") {
+		t.Fatalf("safeJoin rejected a valid path: %q", got)
+	}
+}
+
+func mustWrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,138 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// FileExtension is the suffix of archive files.
+	FileExtension = ".cpab"
+
+	filePrefix      = "cliproxy-backup-"
+	defaultInterval = 24 * time.Hour
+	defaultKeep     = 7
+	// idleRecheck is how often a disabled schedule re-reads the config.
+	idleRecheck = time.Hour
+)
+
+// StartScheduler writes an archive to backup.dir every backup.interval-hours until ctx is
+// cancelled. current is called on every cycle so config reloads take effect.
+func StartScheduler(ctx context.Context, current func() (*config.Config, Sources)) {
+	go func() {
+		for {
+			wait := idleRecheck
+			if cfg, src := current(); cfg != nil && strings.TrimSpace(cfg.Backup.Dir) != "" {
+				wait = defaultInterval
+				if cfg.Backup.IntervalHours > 0 {
+					wait = time.Duration(cfg.Backup.IntervalHours) * time.Hour
+				}
+				if latest, ok := latestArchiveTime(cfg.Backup.Dir); ok && time.Since(latest) < wait {
+					wait -= time.Since(latest)
+				} else if path, err := WriteScheduled(cfg.Backup, src); err != nil {
+					log.Warnf("scheduled backup failed: %v", err)
+				} else {
+					log.Infof("scheduled backup written to %s", path)
+				}
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// WriteScheduled writes one archive into cfg.Dir and prunes archives beyond cfg.Keep.
+func WriteScheduled(cfg config.BackupConfig, src Sources) (string, error) {
+	passphrase := cfg.ResolvedPassphrase()
+	if passphrase == "" {
+		return "", fmt.Errorf("backup: no passphrase configured")
+	}
+	dir, err := util.ResolveAuthDir(strings.TrimSpace(cfg.Dir))
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("backup: create dir: %w", err)
+	}
+	name := filePrefix + time.Now().UTC().Format("20060102-150405") + FileExtension
+	target := filepath.Join(dir, name)
+	tmp := target + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("backup: create archive: %w", err)
+	}
+	if _, err = Create(file, src, passphrase); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("backup: close archive: %w", err)
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("backup: finalize archive: %w", err)
+	}
+
+	keep := cfg.Keep
+	if keep <= 0 {
+		keep = defaultKeep
+	}
+	archives := listArchives(dir)
+	for i := 0; i < len(archives)-keep; i++ {
+		if errRemove := os.Remove(filepath.Join(dir, archives[i])); errRemove != nil {
+			log.Warnf("failed to prune old backup %s: %v", archives[i], errRemove)
+		}
+	}
+	return target, nil
+}
+
+// listArchives returns scheduled archive names in dir, oldest first.
+func listArchives(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, FileExtension) {
+			names = append(names, name)
+		}
+	}
+	// Timestamped names sort chronologically.
+	sort.Strings(names)
+	return names
+}
+
+func latestArchiveTime(dir string) (time.Time, bool) {
+	resolved, err := util.ResolveAuthDir(strings.TrimSpace(dir))
+	if err != nil {
+		return time.Time{}, false
+	}
+	archives := listArchives(resolved)
+	if len(archives) == 0 {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(archives[len(archives)-1], filePrefix), FileExtension)
+	ts, err := time.Parse("20060102-150405", stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// SkipThoughtSignatureValidator is the thoughtSignature value that makes Gemini-family
+// upstreams accept a function call without a genuine signature.
+const SkipThoughtSignatureValidator = "skip_thought_signature_validator"
+
+// SignaturePolicy decides which thoughtSignature a function call part is sent with.
+type SignaturePolicy string
+
+const (
+	// SignaturePolicyAlways replaces every function call signature with the sentinel.
+	SignaturePolicyAlways SignaturePolicy = "always"
+	// SignaturePolicyMissing keeps valid client signatures and adds the sentinel otherwise.
+	SignaturePolicyMissing SignaturePolicy = "missing"
+	// SignaturePolicyNever keeps valid client signatures and never adds the sentinel.
+	SignaturePolicyNever SignaturePolicy = "never"
+)
+
+type signaturePolicyRule struct {
+	pattern string
+	policy  SignaturePolicy
+}
+
+var signaturePolicies atomic.Pointer[[]signaturePolicyRule]
+
+// SetSignaturePolicies installs the configured per-model policies; it is called at startup
+// and on every config reload. Entries with an unknown policy are ignored.
+func SetSignaturePolicies(entries []config.ThoughtSignaturePolicy) {
+	rules := make([]signaturePolicyRule, 0, len(entries))
+	for _, entry := range entries {
+		pattern := strings.TrimSpace(entry.Model)
+		policy := SignaturePolicy(strings.ToLower(strings.TrimSpace(entry.Policy)))
+		switch policy {
+		case SignaturePolicyAlways, SignaturePolicyMissing, SignaturePolicyNever:
+		default:
+			log.Warnf("thought-signature-policies: ignoring %q for model %q, want always, missing or never", entry.Policy, entry.Model)
+			continue
+		}
+		if pattern == "" {
+			continue
+		}
+		rules = append(rules, signaturePolicyRule{pattern: pattern, policy: policy})
+	}
+	signaturePolicies.Store(&rules)
+}
+
+// SignaturePolicyFor returns the configured policy of modelName, or fallback when no entry
+// matches. Translators pass their built-in behaviour as fallback.
+func SignaturePolicyFor(modelName string, fallback SignaturePolicy) SignaturePolicy {
+	if rules := signaturePolicies.Load(); rules != nil {
+		for _, rule := range *rules {
+			if matchWildcard(rule.pattern, modelName) {
+				return rule.policy
+			}
+		}
+	}
+	return fallback
+}
+
+// FunctionCallSignature returns the thoughtSignature to send on a function call part of
+// modelName that the client sent with existing (empty when none), under the configured
+// policy or fallback. ok is false when the part should carry no signature.
+func FunctionCallSignature(modelName, existing string, fallback SignaturePolicy) (signature string, ok bool) {
+	valid := existing != SkipThoughtSignatureValidator && len(existing) >= MinValidSignatureLen
+	switch SignaturePolicyFor(modelName, fallback) {
+	case SignaturePolicyAlways:
+		return SkipThoughtSignatureValidator, true
+	case SignaturePolicyNever:
+		if valid {
+			return existing, true
+		}
+		return "", false
+	default:
+		if valid {
+			return existing, true
+		}
+		return SkipThoughtSignatureValidator, true
+	}
+}
+
+// matchWildcard matches value against pattern, where '*' matches any run of characters.
+func matchWildcard(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
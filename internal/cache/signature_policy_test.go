@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestFunctionCallSignaturePolicies(t *testing.T) {
+	SetSignaturePolicies([]config.ThoughtSignaturePolicy{
+		{Model: "gemini-3-*", Policy: "never"},
+		{Model: "gemini-*-flash", Policy: "Missing"},
+		{Model: "*", Policy: "sometimes"},
+	})
+	defer SetSignaturePolicies(nil)
+
+	valid := strings.Repeat("s", MinValidSignatureLen)
+	cases := []struct {
+		model, existing string
+		fallback        SignaturePolicy
+		want            string
+		wantOK          bool
+	}{
+		// Configured "never": valid signatures pass, the sentinel is never added.
+		{"gemini-3-pro", valid, SignaturePolicyAlways, valid, true},
+		{"gemini-3-pro", "", SignaturePolicyAlways, "", false},
+		{"gemini-3-pro", SkipThoughtSignatureValidator, SignaturePolicyMissing, "", false},
+		// Configured "missing" overrides the translator's "always".
+		{"gemini-2.5-flash", valid, SignaturePolicyAlways, valid, true},
+		{"gemini-2.5-flash", "short", SignaturePolicyAlways, SkipThoughtSignatureValidator, true},
+		// Unmatched models (the invalid "*" entry is dropped) use the fallback.
+		{"gemini-2.5-pro", valid, SignaturePolicyAlways, SkipThoughtSignatureValidator, true},
+		{"gemini-2.5-pro", valid, SignaturePolicyMissing, valid, true},
+	}
+	for _, tc := range cases {
+		got, ok := FunctionCallSignature(tc.model, tc.existing, tc.fallback)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("FunctionCallSignature(%q, %q, %s) = %q, %v; want %q, %v", tc.model, tc.existing, tc.fallback, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"gemini-*-pro", "gemini-2.5-pro", true},
+		{"gemini-*-pro", "gemini-2.5-pro-preview", false},
+		{"*flash*", "gemini-2.5-flash-lite", true},
+		{"a*a", "a", false},
+		{"claude-opus-4-5", "claude-opus-4-5", true},
+	} {
+		if got := matchWildcard(tc.pattern, tc.value); got != tc.want {
+			t.Errorf("matchWildcard(%q, %q) = %v, want %v", tc.pattern, tc.value, got, tc.want)
+		}
+	}
+}
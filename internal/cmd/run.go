@@ -12,6 +12,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/daemon"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
 	log "github.com/sirupsen/logrus"
 )
@@ -25,18 +26,44 @@ import (
 //   - configPath: The path to the configuration file
 //   - localPassword: Optional password accepted for local management requests
 func StartService(cfg *config.Config, configPath string, localPassword string) {
-	builder := cliproxy.NewBuilder().
-		WithConfig(cfg).
-		WithConfigPath(configPath).
-		WithLocalManagementPassword(localPassword)
+	if daemon.IsService() {
+		// Running under the Windows service control manager: stop requests cancel the context.
+		if err := daemon.RunService(windowsServiceName, func(ctx context.Context) {
+			runService(ctx, cfg, configPath, localPassword)
+		}); err != nil {
+			log.Errorf("windows service exited with error: %v", err)
+		}
+		return
+	}
 
 	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+	runService(ctxSignal, cfg, configPath, localPassword)
+}
+
+// windowsServiceName is the service name registered with the Windows service control manager.
+const windowsServiceName = "CLIProxyAPI"
+
+func runService(ctx context.Context, cfg *config.Config, configPath string, localPassword string) {
+	builder := cliproxy.NewBuilder().
+		WithConfig(cfg).
+		WithConfigPath(configPath).
+		WithLocalManagementPassword(localPassword).
+		WithHooks(cliproxy.Hooks{
+			OnAfterStart: func(*cliproxy.Service) {
+				daemon.Ready()
+				if listeners := cfg.EffectiveListeners(); len(listeners) > 0 {
+					if network, address, err := listeners[0].Endpoint(); err == nil {
+						daemon.StartWatchdog(ctx, daemon.ListenerHealthy(network, address))
+					}
+				}
+			},
+		})
 
-	runCtx := ctxSignal
+	runCtx := ctx
 	if localPassword != "" {
 		var keepAliveCancel context.CancelFunc
-		runCtx, keepAliveCancel = context.WithCancel(ctxSignal)
+		runCtx, keepAliveCancel = context.WithCancel(ctx)
 		builder = builder.WithServerOptions(api.WithKeepAliveEndpoint(10*time.Second, func() {
 			log.Warn("keep-alive endpoint idle for 10s, shutting down")
 			keepAliveCancel()
@@ -50,6 +77,7 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 	}
 
 	err = service.Run(runCtx)
+	daemon.Stopping()
 	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Errorf("proxy service exited with error: %v", err)
 	}
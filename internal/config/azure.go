@@ -0,0 +1,128 @@
+package config
+
+import "strings"
+
+// DefaultAzureOpenAIAPIVersion is the api-version sent when a credential does not set one.
+const DefaultAzureOpenAIAPIVersion = "2024-10-21"
+
+// AzureOpenAIKey represents the configuration for an Azure OpenAI resource. Requests are
+// authenticated with the resource API key, or with an Azure AD token obtained through the
+// client credentials flow when TenantID, ClientID and ClientSecret are set instead.
+type AzureOpenAIKey struct {
+	// APIKey is the Azure OpenAI resource key, sent in the "api-key" header.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// TenantID, ClientID and ClientSecret identify an Azure AD application used to obtain
+	// bearer tokens for the Cognitive Services scope when APIKey is empty.
+	TenantID     string `yaml:"tenant-id,omitempty" json:"tenant-id,omitempty"`
+	ClientID     string `yaml:"client-id,omitempty" json:"client-id,omitempty"`
+	ClientSecret string `yaml:"client-secret,omitempty" json:"client-secret,omitempty"`
+
+	// AuthorityHost overrides the Azure AD login endpoint, e.g. for sovereign clouds.
+	// Defaults to "https://login.microsoftonline.com".
+	AuthorityHost string `yaml:"authority-host,omitempty" json:"authority-host,omitempty"`
+
+	// Endpoint is the resource endpoint, e.g. "https://my-resource.openai.azure.com".
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+
+	// APIVersion is the api-version query parameter; defaults to DefaultAzureOpenAIAPIVersion.
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-4o").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Deployments maps client-facing model names to the deployments of this resource.
+	Deployments []AzureOpenAIDeployment `yaml:"deployments" json:"deployments"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// GetAPIKey returns the identity of the credential: the resource key when set,
+// otherwise the Azure AD client ID.
+func (k AzureOpenAIKey) GetAPIKey() string {
+	if k.APIKey != "" {
+		return k.APIKey
+	}
+	return k.ClientID
+}
+func (k AzureOpenAIKey) GetBaseURL() string { return k.Endpoint }
+
+// AzureOpenAIDeployment maps an Azure deployment to the model name clients use.
+type AzureOpenAIDeployment struct {
+	// Name is the deployment name in the Azure OpenAI resource.
+	Name string `yaml:"name" json:"name"`
+
+	// Model is the OpenAI model served by the deployment, e.g. "gpt-4o". It is used as the
+	// client-facing name when Alias is empty and to look up model capabilities.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+
+	// Alias is the client-facing model name that maps to the deployment.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+}
+
+func (d AzureOpenAIDeployment) GetName() string { return d.Name }
+
+// GetAlias returns the client-facing name of the deployment: Alias, falling back to Model.
+func (d AzureOpenAIDeployment) GetAlias() string {
+	if d.Alias != "" {
+		return d.Alias
+	}
+	return d.Model
+}
+
+// SanitizeAzureOpenAIKeys normalizes Azure OpenAI credentials and drops entries without an
+// endpoint or without usable credentials.
+func (cfg *Config) SanitizeAzureOpenAIKeys() {
+	if cfg == nil {
+		return
+	}
+
+	out := cfg.AzureOpenAIKey[:0]
+	for i := range cfg.AzureOpenAIKey {
+		entry := cfg.AzureOpenAIKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.TenantID = strings.TrimSpace(entry.TenantID)
+		entry.ClientID = strings.TrimSpace(entry.ClientID)
+		entry.ClientSecret = strings.TrimSpace(entry.ClientSecret)
+		entry.Endpoint = strings.TrimRight(strings.TrimSpace(entry.Endpoint), "/")
+		if entry.Endpoint == "" {
+			continue
+		}
+		if entry.APIKey == "" && (entry.TenantID == "" || entry.ClientID == "" || entry.ClientSecret == "") {
+			continue
+		}
+		entry.AuthorityHost = strings.TrimRight(strings.TrimSpace(entry.AuthorityHost), "/")
+		entry.APIVersion = strings.TrimSpace(entry.APIVersion)
+		if entry.APIVersion == "" {
+			entry.APIVersion = DefaultAzureOpenAIAPIVersion
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		deployments := make([]AzureOpenAIDeployment, 0, len(entry.Deployments))
+		for _, deployment := range entry.Deployments {
+			deployment.Name = strings.TrimSpace(deployment.Name)
+			deployment.Model = strings.TrimSpace(deployment.Model)
+			deployment.Alias = strings.TrimSpace(deployment.Alias)
+			if deployment.Name != "" {
+				deployments = append(deployments, deployment)
+			}
+		}
+		entry.Deployments = deployments
+		out = append(out, entry)
+	}
+	cfg.AzureOpenAIKey = out
+}
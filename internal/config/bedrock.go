@@ -0,0 +1,107 @@
+package config
+
+import "strings"
+
+// BedrockKey represents the configuration for an AWS Bedrock credential. Requests are
+// signed with SigV4 using the access key pair, or sent with a Bedrock API key as a
+// bearer token when APIKey is set instead.
+type BedrockKey struct {
+	// AccessKeyID and SecretAccessKey are the IAM credentials used for SigV4 signing.
+	AccessKeyID     string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+
+	// SessionToken is the optional STS session token for temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// APIKey is a Bedrock API key, used instead of the access key pair when set.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Region is the AWS region hosting the models, e.g. "us-east-1".
+	Region string `yaml:"region" json:"region"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL overrides the regional bedrock-runtime endpoint, e.g. for VPC endpoints.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps Bedrock model or inference profile IDs to client-facing aliases.
+	Models []BedrockModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// GetAPIKey returns the identity of the credential: the Bedrock API key when set,
+// otherwise the access key ID.
+func (k BedrockKey) GetAPIKey() string {
+	if k.APIKey != "" {
+		return k.APIKey
+	}
+	return k.AccessKeyID
+}
+func (k BedrockKey) GetBaseURL() string { return k.BaseURL }
+
+// BedrockModel maps a Bedrock model ID to the alias clients use.
+type BedrockModel struct {
+	// Name is the Bedrock model or inference profile ID,
+	// e.g. "us.anthropic.claude-sonnet-4-5-20250929-v1:0".
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m BedrockModel) GetName() string  { return m.Name }
+func (m BedrockModel) GetAlias() string { return m.Alias }
+
+// SanitizeBedrockKeys normalizes Bedrock credentials and drops entries without a region
+// or without usable credentials.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil {
+		return
+	}
+
+	out := cfg.BedrockKey[:0]
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+		entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+		entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.Region = strings.TrimSpace(entry.Region)
+		if entry.Region == "" {
+			continue
+		}
+		if entry.APIKey == "" && (entry.AccessKeyID == "" || entry.SecretAccessKey == "") {
+			continue
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.BaseURL = strings.TrimSpace(entry.BaseURL)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]BedrockModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+		out = append(out, entry)
+	}
+	cfg.BedrockKey = out
+}
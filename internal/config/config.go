@@ -9,7 +9,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -35,6 +37,10 @@ type Config struct {
 	// TLS config controls HTTPS server settings.
 	TLS TLSConfig `yaml:"tls" json:"tls"`
 
+	// Listeners replaces the host/port binding with explicit bind addresses when non-empty.
+	// Entries may be TCP (IPv4 or bracketed IPv6) or Unix domain sockets.
+	Listeners []ListenerConfig `yaml:"listeners,omitempty" json:"-"`
+
 	// RemoteManagement nests management-related options under 'remote-management'.
 	RemoteManagement RemoteManagement `yaml:"remote-management" json:"-"`
 
@@ -44,6 +50,11 @@ type Config struct {
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
+	// DebugStreamValidation checks every translated stream chunk against the client format's
+	// schema (OpenAI chunks, Anthropic events, Responses events, Gemini chunks) and logs
+	// violations with the upstream line they came from. Chunks are never modified.
+	DebugStreamValidation bool `yaml:"debug-stream-validation,omitempty" json:"debug-stream-validation,omitempty"`
+
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
@@ -61,9 +72,25 @@ type Config struct {
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// LogRedaction controls how credentials are masked in request logs and management log endpoints.
+	LogRedaction LogRedactionConfig `yaml:"log-redaction,omitempty" json:"log-redaction,omitempty"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageAggregateOnly records usage solely as provider/model/day counters. Client keys,
+	// credentials and request payloads are dropped before any usage sink or log sees them.
+	UsageAggregateOnly bool `yaml:"usage-aggregate-only,omitempty" json:"usage-aggregate-only,omitempty"`
+
+	// UsageDatabase persists every usage record to Postgres so history survives restarts.
+	UsageDatabase UsageDatabaseConfig `yaml:"usage-database,omitempty" json:"usage-database,omitempty"`
+
+	// UsageSinks stream usage records to external observability systems as they happen.
+	UsageSinks []UsageSinkConfig `yaml:"usage-sinks,omitempty" json:"usage-sinks,omitempty"`
+
+	// ModelPrices set the per-model token prices used to estimate the cost of usage.
+	ModelPrices []ModelPrice `yaml:"model-prices,omitempty" json:"model-prices,omitempty"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -75,9 +102,72 @@ type Config struct {
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
 
+	// QuotaPolling controls how often provider quota endpoints are polled.
+	QuotaPolling QuotaPollingConfig `yaml:"quota-polling,omitempty" json:"quota-polling,omitempty"`
+
+	// QuotaStore selects where quota snapshots and per-model states are kept.
+	QuotaStore QuotaStoreConfig `yaml:"quota-store,omitempty" json:"quota-store,omitempty"`
+
+	// Cluster coordinates credential usage with other instances sharing the credentials.
+	Cluster ClusterConfig `yaml:"cluster,omitempty" json:"cluster,omitempty"`
+
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// LoadShedding restricts degraded providers to essential traffic until they recover.
+	LoadShedding LoadSheddingConfig `yaml:"load-shedding,omitempty" json:"load-shedding,omitempty"`
+
+	// StandbyPrewarm warms the other providers of a model once one of them starts failing.
+	StandbyPrewarm StandbyPrewarmConfig `yaml:"standby-prewarm,omitempty" json:"standby-prewarm,omitempty"`
+
+	// Hedging races a second auth against streaming requests whose first chunk is slow.
+	Hedging HedgingConfig `yaml:"hedging,omitempty" json:"hedging,omitempty"`
+
+	// Fallback maps a model to the providers to try in order. A provider is only used once the
+	// auths of the providers before it are exhausted or failing for the request.
+	Fallback map[string][]string `yaml:"fallback,omitempty" json:"fallback,omitempty"`
+
+	// RefreshLimits spreads background token refreshes out, optionally per provider.
+	RefreshLimits RefreshLimitsConfig `yaml:"refresh-limits,omitempty" json:"refresh-limits,omitempty"`
+
+	// Metrics exposes request, upstream, token and quota metrics for Prometheus.
+	Metrics MetricsConfig `yaml:"metrics,omitempty" json:"metrics,omitempty"`
+
+	// Preflight tunes the environment checks run at startup.
+	Preflight PreflightConfig `yaml:"preflight,omitempty" json:"preflight,omitempty"`
+
+	// Tracing exports OpenTelemetry spans to an OTLP/HTTP collector.
+	Tracing TracingConfig `yaml:"tracing,omitempty" json:"tracing,omitempty"`
+
+	// ModelListCache persists the served model lists so /models answers right after a restart.
+	ModelListCache ModelListCacheConfig `yaml:"model-list-cache,omitempty" json:"model-list-cache,omitempty"`
+
+	// ThoughtSignaturePolicies decide, per model, when function calls sent to Gemini-family
+	// upstreams carry the skip_thought_signature_validator sentinel. The first matching entry wins.
+	ThoughtSignaturePolicies []ThoughtSignaturePolicy `yaml:"thought-signature-policies,omitempty" json:"thought-signature-policies,omitempty"`
+
+	// AuthGC archives and optionally deletes auths that stay unused or failing for too long.
+	AuthGC AuthGCConfig `yaml:"auth-gc,omitempty" json:"auth-gc,omitempty"`
+
+	// AuthTombstoneDays keeps auth files deleted through the management API restorable for
+	// this many days. 0 uses the default of 7 days; a negative value deletes immediately.
+	AuthTombstoneDays int `yaml:"auth-tombstone-days,omitempty" json:"auth-tombstone-days,omitempty"`
+
+	// Backup configures encrypted state archives produced on a schedule and via the management API.
+	Backup BackupConfig `yaml:"backup,omitempty" json:"backup,omitempty"`
+
+	// Timeouts bounds the phases of upstream HTTP requests, optionally per provider.
+	Timeouts TimeoutsConfig `yaml:"timeouts,omitempty" json:"timeouts,omitempty"`
+
+	// DNSCache caches upstream hostname resolutions and reuses them while lookups fail.
+	DNSCache DNSCacheConfig `yaml:"dns-cache,omitempty" json:"dns-cache,omitempty"`
+
+	// UpstreamRetry retries transient upstream 5xx responses and connection resets.
+	UpstreamRetry UpstreamRetryConfig `yaml:"upstream-retry,omitempty" json:"upstream-retry,omitempty"`
+
+	// UpstreamHosts tunes HTTP/2 use and stream multiplexing per upstream host.
+	UpstreamHosts []UpstreamHostConfig `yaml:"upstream-hosts,omitempty" json:"upstream-hosts,omitempty"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -90,6 +180,15 @@ type Config struct {
 	// ClaudeKey defines a list of Claude API key configurations as specified in the YAML configuration file.
 	ClaudeKey []ClaudeKey `yaml:"claude-api-key" json:"claude-api-key"`
 
+	// BedrockKey defines AWS Bedrock credentials for running Claude models through Bedrock.
+	BedrockKey []BedrockKey `yaml:"bedrock-api-key,omitempty" json:"bedrock-api-key,omitempty"`
+
+	// AzureOpenAIKey defines Azure OpenAI resources and their deployment mappings.
+	AzureOpenAIKey []AzureOpenAIKey `yaml:"azure-openai-api-key,omitempty" json:"azure-openai-api-key,omitempty"`
+
+	// OpenRouterKey defines OpenRouter API keys; their models are discovered from OpenRouter.
+	OpenRouterKey []OpenRouterKey `yaml:"openrouter-api-key,omitempty" json:"openrouter-api-key,omitempty"`
+
 	// OpenAICompatibility defines OpenAI API compatibility configurations for external providers.
 	OpenAICompatibility []OpenAICompatibility `yaml:"openai-compatibility" json:"openai-compatibility"`
 
@@ -114,9 +213,88 @@ type Config struct {
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
+	// StrictConfig rejects the config file when it contains unknown keys instead of only
+	// logging them. Typos such as "oauth-model-alais" otherwise silently fall back to defaults.
+	StrictConfig bool `yaml:"strict-config,omitempty" json:"strict-config,omitempty"`
+
+	// RequestTemplates overrides the fixed fields a provider adds to every upstream request,
+	// keyed by provider (currently "antigravity").
+	RequestTemplates map[string]RequestTemplate `yaml:"request-templates,omitempty" json:"request-templates,omitempty"`
+
+	// AntigravityModelConfigFile points to a JSON or YAML file whose entries override the
+	// built-in Antigravity model configuration (thinking ranges, max completion tokens).
+	// The file is re-read when it changes.
+	AntigravityModelConfigFile string `yaml:"antigravity-model-config-file,omitempty" json:"antigravity-model-config-file,omitempty"`
+
+	// ProviderPlugins holds settings for compiled-in third-party providers, keyed by provider
+	// name. Each entry is passed unchanged to the provider's factory.
+	ProviderPlugins map[string]map[string]any `yaml:"provider-plugins,omitempty" json:"provider-plugins,omitempty"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
 }
 
+// LogRedactionConfig configures credential masking in logs.
+type LogRedactionConfig struct {
+	// Mode is "partial" (default, keeps the first and last characters) or "full" (replaces secrets entirely).
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// Headers lists additional header names (case-insensitive substrings) whose values are masked.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Fields lists additional JSON or form field names whose values are masked in logged bodies.
+	Fields []string `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// FullRedaction reports whether secrets should be replaced entirely rather than partially shown.
+func (c LogRedactionConfig) FullRedaction() bool {
+	return strings.EqualFold(strings.TrimSpace(c.Mode), "full")
+}
+
+// AuthGCConfig configures archiving of stale auths.
+type AuthGCConfig struct {
+	// Enabled turns on the periodic stale auth sweep.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// UnusedDays archives auths without a successful request for this many days. 0 disables the check.
+	UnusedDays int `yaml:"unused-days,omitempty" json:"unused-days,omitempty"`
+
+	// FailingDays archives auths that upstream has kept rejecting (401/403 or failed token refresh)
+	// for this many days. Quota and request errors do not count. 0 disables the check.
+	FailingDays int `yaml:"failing-days,omitempty" json:"failing-days,omitempty"`
+
+	// DeleteAfterDays deletes archived auth files after this many days. 0 keeps them forever.
+	DeleteAfterDays int `yaml:"delete-after-days,omitempty" json:"delete-after-days,omitempty"`
+
+	// IntervalMinutes is the time between sweeps. Default is 60.
+	IntervalMinutes int `yaml:"interval-minutes,omitempty" json:"interval-minutes,omitempty"`
+}
+
+// BackupConfig configures encrypted backups of auth files, quota, usage, managed keys and config.
+type BackupConfig struct {
+	// Passphrase encrypts archives. The BACKUP_PASSPHRASE environment variable is used when empty.
+	Passphrase string `yaml:"passphrase,omitempty" json:"-"`
+
+	// Dir is where scheduled archives are written. Scheduled backups are off when empty.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// IntervalHours is the time between scheduled archives. Default is 24.
+	IntervalHours int `yaml:"interval-hours,omitempty" json:"interval-hours,omitempty"`
+
+	// Keep is how many scheduled archives are retained. Default is 7.
+	Keep int `yaml:"keep,omitempty" json:"keep,omitempty"`
+
+	// MaxRestoreMB bounds the decompressed size of an archive being restored. Default is 1024.
+	MaxRestoreMB int `yaml:"max-restore-mb,omitempty" json:"max-restore-mb,omitempty"`
+}
+
+// ResolvedPassphrase returns the configured passphrase or the BACKUP_PASSPHRASE environment variable.
+func (c BackupConfig) ResolvedPassphrase() string {
+	if p := strings.TrimSpace(c.Passphrase); p != "" {
+		return p
+	}
+	return strings.TrimSpace(os.Getenv("BACKUP_PASSPHRASE"))
+}
+
 // TLSConfig holds HTTPS server settings.
 type TLSConfig struct {
 	// Enable toggles HTTPS server mode.
@@ -127,6 +305,386 @@ type TLSConfig struct {
 	Key string `yaml:"key" json:"key"`
 }
 
+// TimeoutSet holds upstream request timeouts in seconds. Zero leaves a phase unbounded.
+type TimeoutSet struct {
+	// ConnectSeconds bounds establishing the TCP (or proxy) connection.
+	ConnectSeconds int `yaml:"connect-seconds,omitempty" json:"connect-seconds,omitempty"`
+	// TLSHandshakeSeconds bounds the TLS handshake.
+	TLSHandshakeSeconds int `yaml:"tls-handshake-seconds,omitempty" json:"tls-handshake-seconds,omitempty"`
+	// ResponseHeaderSeconds bounds the wait for response headers after the request is sent.
+	ResponseHeaderSeconds int `yaml:"response-header-seconds,omitempty" json:"response-header-seconds,omitempty"`
+	// StreamIdleSeconds aborts a response body that delivers no data for this long.
+	StreamIdleSeconds int `yaml:"stream-idle-seconds,omitempty" json:"stream-idle-seconds,omitempty"`
+	// TotalSeconds bounds the whole request including reading the body.
+	TotalSeconds int `yaml:"total-seconds,omitempty" json:"total-seconds,omitempty"`
+}
+
+// TimeoutsConfig holds default upstream timeouts and per-provider overrides.
+type TimeoutsConfig struct {
+	TimeoutSet `yaml:",inline" json:",inline"`
+	// Providers overrides individual fields per provider, keyed by provider identifier
+	// (e.g. "antigravity", "claude", "codex", or an openai-compatibility name).
+	Providers map[string]TimeoutSet `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// For returns the timeouts for provider: defaults with the provider's non-zero fields applied.
+func (t TimeoutsConfig) For(provider string) TimeoutSet {
+	out := t.TimeoutSet
+	var override TimeoutSet
+	found := false
+	for key, set := range t.Providers {
+		if strings.EqualFold(strings.TrimSpace(key), strings.TrimSpace(provider)) {
+			override, found = set, true
+			break
+		}
+	}
+	if !found {
+		return out
+	}
+	if override.ConnectSeconds > 0 {
+		out.ConnectSeconds = override.ConnectSeconds
+	}
+	if override.TLSHandshakeSeconds > 0 {
+		out.TLSHandshakeSeconds = override.TLSHandshakeSeconds
+	}
+	if override.ResponseHeaderSeconds > 0 {
+		out.ResponseHeaderSeconds = override.ResponseHeaderSeconds
+	}
+	if override.StreamIdleSeconds > 0 {
+		out.StreamIdleSeconds = override.StreamIdleSeconds
+	}
+	if override.TotalSeconds > 0 {
+		out.TotalSeconds = override.TotalSeconds
+	}
+	return out
+}
+
+// QuotaStoreConfig selects the quota store backend. The default keeps quota in a JSON file
+// on the local node; the Redis backend shares quota snapshots and per-model states between
+// proxy replicas behind a load balancer. Changes take effect on restart.
+type QuotaStoreConfig struct {
+	// Backend is "file" (default) or "redis".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// RedisURL addresses the Redis server: redis://[[user]:password@]host[:port][/db], or
+	// rediss:// for TLS.
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+	// RedisKey is the hash holding the entries. Default is "cliproxy:quota".
+	RedisKey string `yaml:"redis-key,omitempty" json:"redis-key,omitempty"`
+	// SyncIntervalSeconds is how often a replica exchanges changes with the shared store.
+	// Default is 5.
+	SyncIntervalSeconds int `yaml:"sync-interval-seconds,omitempty" json:"sync-interval-seconds,omitempty"`
+}
+
+// ClusterConfig enables cluster mode, in which instances serving the same credentials share
+// round-robin offsets, per-model cooldowns and quota state, and refresh each OAuth token on
+// one instance at a time. Changes take effect on restart.
+type ClusterConfig struct {
+	// Enabled turns cluster mode on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Backend is the coordination store. Only "redis" (default) is supported.
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// RedisURL addresses the Redis server: redis://[[user]:password@]host[:port][/db], or
+	// rediss:// for TLS.
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+	// KeyPrefix prefixes every key the cluster writes. Default is "cliproxy:".
+	KeyPrefix string `yaml:"key-prefix,omitempty" json:"key-prefix,omitempty"`
+}
+
+// PreflightConfig tunes the startup preflight, whose warnings are logged and reported by
+// GET /readyz.
+type PreflightConfig struct {
+	// SkipConnectivity skips probing the configured providers, e.g. in air-gapped setups.
+	SkipConnectivity bool `yaml:"skip-connectivity,omitempty" json:"skip-connectivity,omitempty"`
+	// MinOpenFiles is the open file limit below which a warning is reported. Default is 4096.
+	MinOpenFiles int `yaml:"min-open-files,omitempty" json:"min-open-files,omitempty"`
+}
+
+// MetricsConfig controls the Prometheus /metrics endpoint.
+type MetricsConfig struct {
+	// Enabled serves GET /metrics in the Prometheus text format.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	// BearerToken, when set, must be sent by the scraper as "Authorization: Bearer <token>".
+	BearerToken string `yaml:"bearer-token,omitempty" json:"bearer-token,omitempty"`
+}
+
+// TracingConfig controls OpenTelemetry trace export.
+type TracingConfig struct {
+	// Enabled records spans and propagates traceparent to upstream requests.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	// Endpoint is the OTLP/HTTP collector URL, e.g. "http://localhost:4318". Spans are posted
+	// as JSON to <endpoint>/v1/traces unless the URL already names that path.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// Headers are sent with every export request, e.g. collector API keys.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// ServiceName is reported as the service.name resource attribute. Default "cli-proxy-api".
+	ServiceName string `yaml:"service-name,omitempty" json:"service-name,omitempty"`
+	// SampleRatio is the fraction of new traces recorded, between 0 and 1. 0 records all;
+	// requests carrying a traceparent follow the caller's sampling decision.
+	SampleRatio float64 `yaml:"sample-ratio,omitempty" json:"sample-ratio,omitempty"`
+}
+
+// ModelListCacheConfig configures the persisted model-list cache.
+type ModelListCacheConfig struct {
+	// Disable serves the model endpoints from the live registry only.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+	// Path is the cache file. Default is model-list-cache.json in the user cache directory.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// WarmupSeconds bounds how long after startup the cached lists are served while credentials
+	// register their models. Default is 60.
+	WarmupSeconds int `yaml:"warmup-seconds,omitempty" json:"warmup-seconds,omitempty"`
+}
+
+// ThoughtSignaturePolicy sets the function-call thoughtSignature policy for matching models.
+type ThoughtSignaturePolicy struct {
+	// Model is the model name or wildcard pattern (e.g., "gemini-3-*").
+	Model string `yaml:"model" json:"model"`
+	// Policy is "always" (replace every signature with the sentinel), "missing" (keep valid
+	// client signatures, add the sentinel otherwise) or "never" (send no sentinel).
+	Policy string `yaml:"policy" json:"policy"`
+}
+
+// UsageDatabaseConfig selects the database that usage records are written to.
+type UsageDatabaseConfig struct {
+	// Driver is "postgres". Empty disables the usage database.
+	Driver string `yaml:"driver,omitempty" json:"driver,omitempty"`
+	// DSN is the Postgres connection string.
+	DSN string `yaml:"dsn,omitempty" json:"dsn,omitempty"`
+	// Table names the usage table. Default "usage_records".
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+	// RetentionDays deletes records older than this many days. 0 keeps them forever.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// UsageSinkConfig configures one destination that usage records are streamed to.
+type UsageSinkConfig struct {
+	// Type is "statsd", "otlp" or "webhook".
+	Type string `yaml:"type" json:"type"`
+	// Endpoint is the StatsD host:port (UDP), the OTLP/HTTP collector URL, or the webhook URL.
+	// OTLP metrics are posted to <endpoint>/v1/metrics unless the URL already names that path.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Headers are sent with every OTLP and webhook request, e.g. API keys.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Prefix starts every StatsD metric name. Default "cliproxy".
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// Tags sends provider, model and status as DogStatsD tags instead of metric name segments.
+	Tags bool `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// ServiceName is the OTLP service.name resource attribute. Default "cli-proxy-api".
+	ServiceName string `yaml:"service-name,omitempty" json:"service-name,omitempty"`
+	// FlushIntervalSeconds is how often batched records are sent. Default 1, or 10 for OTLP.
+	FlushIntervalSeconds int `yaml:"flush-interval-seconds,omitempty" json:"flush-interval-seconds,omitempty"`
+}
+
+// ModelPrice is the price of one model's tokens in USD per million tokens.
+type ModelPrice struct {
+	// Model is the model name or wildcard pattern (e.g., "claude-sonnet-*"); the first match wins.
+	Model string `yaml:"model" json:"model"`
+	// Provider restricts the entry to one provider (e.g., "gemini", "vertex"). Such entries
+	// only rank credentials for the cost routing strategy; cost estimates use entries without
+	// a provider.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Input prices prompt tokens.
+	Input float64 `yaml:"input" json:"input"`
+	// Output prices completion and reasoning tokens.
+	Output float64 `yaml:"output" json:"output"`
+	// CachedInput prices prompt tokens served from the provider cache. 0 uses Input.
+	CachedInput float64 `yaml:"cached-input,omitempty" json:"cached-input,omitempty"`
+}
+
+// QuotaPollingConfig configures the quota poller. Quota read from response headers is
+// recorded whether or not polling is enabled.
+type QuotaPollingConfig struct {
+	// Disabled stops all quota polling.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// IntervalSeconds is the time between two polls of a credential. Default is 180; values
+	// below 30 are raised to 30.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// JitterSeconds adds a random delay up to this to every wait, so instances sharing
+	// credentials do not poll in step. Default is 0.
+	JitterSeconds int `yaml:"jitter-seconds,omitempty" json:"jitter-seconds,omitempty"`
+	// Providers overrides the settings per provider, keyed by provider identifier.
+	Providers map[string]QuotaPollingProvider `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// QuotaPollingProvider overrides the quota polling of one provider.
+type QuotaPollingProvider struct {
+	// Disabled stops polling the provider.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// IntervalSeconds replaces the default interval for the provider.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+}
+
+// For returns the polling settings for provider: defaults with the provider's override applied.
+func (q QuotaPollingConfig) For(provider string) QuotaPollingProvider {
+	out := QuotaPollingProvider{Disabled: q.Disabled, IntervalSeconds: q.IntervalSeconds}
+	for key, set := range q.Providers {
+		if !strings.EqualFold(strings.TrimSpace(key), strings.TrimSpace(provider)) {
+			continue
+		}
+		if set.Disabled {
+			out.Disabled = true
+		}
+		if set.IntervalSeconds != 0 {
+			out.IntervalSeconds = set.IntervalSeconds
+		}
+		break
+	}
+	return out
+}
+
+// RefreshLimitSet bounds background token refreshes of one provider. Zero uses the default;
+// a negative value disables the limit.
+type RefreshLimitSet struct {
+	// MaxConcurrent caps the refreshes of the provider running at once. Default is 4.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+	// PerMinute caps the refreshes of the provider started per minute. Default is unlimited.
+	PerMinute int `yaml:"per-minute,omitempty" json:"per-minute,omitempty"`
+	// JitterSeconds delays each due refresh by a random time up to this, so credentials that
+	// expire together do not refresh together. Default is 10.
+	JitterSeconds int `yaml:"jitter-seconds,omitempty" json:"jitter-seconds,omitempty"`
+	// SkewSeconds is how long before its expiry a token is refreshed. Default is the
+	// provider's own refresh lead (e.g. 3000 for antigravity).
+	SkewSeconds int `yaml:"skew-seconds,omitempty" json:"skew-seconds,omitempty"`
+}
+
+// RefreshLimitsConfig holds default refresh limits and per-provider overrides.
+type RefreshLimitsConfig struct {
+	RefreshLimitSet `yaml:",inline" json:",inline"`
+	// Providers overrides individual fields per provider, keyed by provider identifier.
+	Providers map[string]RefreshLimitSet `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// DetectClockDrift measures the clock offset to each provider from the Date headers of
+	// its responses and refreshes tokens earlier by that offset.
+	DetectClockDrift bool `yaml:"detect-clock-drift,omitempty" json:"detect-clock-drift,omitempty"`
+}
+
+// For returns the refresh limits for provider: defaults with the provider's non-zero fields applied.
+func (r RefreshLimitsConfig) For(provider string) RefreshLimitSet {
+	out := r.RefreshLimitSet
+	for key, set := range r.Providers {
+		if !strings.EqualFold(strings.TrimSpace(key), strings.TrimSpace(provider)) {
+			continue
+		}
+		if set.MaxConcurrent != 0 {
+			out.MaxConcurrent = set.MaxConcurrent
+		}
+		if set.PerMinute != 0 {
+			out.PerMinute = set.PerMinute
+		}
+		if set.JitterSeconds != 0 {
+			out.JitterSeconds = set.JitterSeconds
+		}
+		if set.SkewSeconds != 0 {
+			out.SkewSeconds = set.SkewSeconds
+		}
+		break
+	}
+	return out
+}
+
+// DNSCacheConfig configures the upstream DNS cache.
+type DNSCacheConfig struct {
+	// Enabled turns the cache on for upstream requests made by executors.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// TTLSeconds is how long an answer is reused without a new lookup. Default is 60.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// StaleSeconds is how long an expired answer may still be used when lookups fail. Default is 3600.
+	StaleSeconds int `yaml:"stale-seconds,omitempty" json:"stale-seconds,omitempty"`
+}
+
+// UpstreamRetryConfig configures retries of transient upstream failures shared by all executors.
+type UpstreamRetryConfig struct {
+	// MaxAttempts is the total number of tries per upstream request. 0 or 1 disables retries.
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
+	// InitialBackoffMs is the wait before the first retry; later waits double. Default is 250.
+	InitialBackoffMs int `yaml:"initial-backoff-ms,omitempty" json:"initial-backoff-ms,omitempty"`
+	// MaxBackoffMs caps a single wait. Default is 4000.
+	MaxBackoffMs int `yaml:"max-backoff-ms,omitempty" json:"max-backoff-ms,omitempty"`
+}
+
+// UpstreamHostConfig controls connections to upstream hosts matching Host.
+type UpstreamHostConfig struct {
+	// Host is an exact hostname or a "*.example.com" suffix pattern.
+	Host string `yaml:"host" json:"host"`
+	// DisableHTTP2 forces HTTP/1.1 for this host.
+	DisableHTTP2 bool `yaml:"disable-http2,omitempty" json:"disable-http2,omitempty"`
+	// MaxStreamsPerConnection caps concurrent requests multiplexed on one HTTP/2 connection.
+	// Additional requests open new connections. Zero leaves multiplexing to the server limit.
+	MaxStreamsPerConnection int `yaml:"max-streams-per-connection,omitempty" json:"max-streams-per-connection,omitempty"`
+	// MaxConnections caps connections to the host when MaxStreamsPerConnection is set;
+	// requests beyond the combined capacity wait. Zero means unlimited.
+	MaxConnections int `yaml:"max-connections,omitempty" json:"max-connections,omitempty"`
+}
+
+// Matches reports whether hostname is covered by this entry.
+func (h UpstreamHostConfig) Matches(hostname string) bool {
+	pattern := strings.ToLower(strings.TrimSpace(h.Host))
+	hostname = strings.ToLower(strings.TrimSpace(hostname))
+	if pattern == "" || hostname == "" {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return hostname == suffix || strings.HasSuffix(hostname, "."+suffix)
+	}
+	return hostname == pattern
+}
+
+// Listener scopes restrict which routes a listener serves.
+const (
+	ListenerScopeAll        = "all"
+	ListenerScopeAPI        = "api"
+	ListenerScopeManagement = "management"
+)
+
+// ListenerConfig describes one bind address of the API server.
+type ListenerConfig struct {
+	// Address is "host:port" ("[::1]:8317" for IPv6) or "unix:/path/to.sock".
+	// On Linux "unix:@name" binds an abstract-namespace socket.
+	Address string `yaml:"address" json:"address"`
+	// Scope limits the listener to "api" or "management" routes. Empty means "all".
+	Scope string `yaml:"scope,omitempty" json:"scope,omitempty"`
+	// SocketMode sets the permission bits of a Unix socket file, e.g. "0660".
+	SocketMode string `yaml:"socket-mode,omitempty" json:"socket-mode,omitempty"`
+}
+
+// Endpoint returns the network and address to pass to net.Listen.
+func (l ListenerConfig) Endpoint() (network, address string, err error) {
+	addr := strings.TrimSpace(l.Address)
+	if rest, ok := strings.CutPrefix(addr, "unix:"); ok {
+		rest = strings.TrimPrefix(rest, "//")
+		if rest == "" {
+			return "", "", fmt.Errorf("listener %q: empty socket path", l.Address)
+		}
+		return "unix", rest, nil
+	}
+	if _, _, errSplit := net.SplitHostPort(addr); errSplit != nil {
+		return "", "", fmt.Errorf("listener %q: %w", l.Address, errSplit)
+	}
+	return "tcp", addr, nil
+}
+
+// NormalizedScope returns the listener scope in canonical form.
+func (l ListenerConfig) NormalizedScope() (string, error) {
+	switch scope := strings.ToLower(strings.TrimSpace(l.Scope)); scope {
+	case "", ListenerScopeAll:
+		return ListenerScopeAll, nil
+	case ListenerScopeAPI, ListenerScopeManagement:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("listener %q: unknown scope %q", l.Address, l.Scope)
+	}
+}
+
+// EffectiveListeners returns the configured listeners, or a single listener built from
+// host and port when none are configured.
+func (cfg *Config) EffectiveListeners() []ListenerConfig {
+	if cfg == nil {
+		return nil
+	}
+	if len(cfg.Listeners) > 0 {
+		return cfg.Listeners
+	}
+	return []ListenerConfig{{Address: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))}}
+}
+
 // PprofConfig holds pprof HTTP server settings.
 type PprofConfig struct {
 	// Enable toggles the pprof HTTP debug server.
@@ -161,8 +719,61 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first", "quota-weighted".
+	// Supported values: "round-robin" (default), "fill-first", "quota-weighted", "cost".
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// DecisionLog samples credential selection decisions for debugging uneven account usage.
+	DecisionLog RoutingDecisionLogConfig `yaml:"decision-log,omitempty" json:"decision-log,omitempty"`
+}
+
+// LoadSheddingConfig configures the automatic essential-only mode for unhealthy providers.
+type LoadSheddingConfig struct {
+	// Enabled turns load shedding on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// ErrorRate is the upstream failure ratio (5xx and network errors) that starts shedding. Default is 0.5.
+	ErrorRate float64 `yaml:"error-rate,omitempty" json:"error-rate,omitempty"`
+	// MinRequests is the number of requests in the window required before shedding. Default is 20.
+	MinRequests int `yaml:"min-requests,omitempty" json:"min-requests,omitempty"`
+	// WindowSeconds is the length of the error rate window. Default is 60.
+	WindowSeconds int `yaml:"window-seconds,omitempty" json:"window-seconds,omitempty"`
+	// RecoverSeconds is how long a shedding provider must go without failures to recover. Default is 60.
+	RecoverSeconds int `yaml:"recover-seconds,omitempty" json:"recover-seconds,omitempty"`
+	// EssentialKeys lists client API keys or managed key IDs still served while shedding.
+	EssentialKeys []string `yaml:"essential-keys,omitempty" json:"essential-keys,omitempty"`
+}
+
+// StandbyPrewarmConfig configures warming of standby providers when the primary degrades.
+type StandbyPrewarmConfig struct {
+	// Enabled turns standby pre-warming on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// ErrorRate is the upstream failure ratio that marks a provider as degraded. Default is 0.2.
+	ErrorRate float64 `yaml:"error-rate,omitempty" json:"error-rate,omitempty"`
+	// MinRequests is the number of requests in the window required before a provider counts as degraded. Default is 5.
+	MinRequests int `yaml:"min-requests,omitempty" json:"min-requests,omitempty"`
+	// IntervalSeconds is the minimum time between two warm-ups of the same standby provider. Default is 300.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+}
+
+// HedgingConfig configures hedged streaming requests.
+type HedgingConfig struct {
+	// Enabled turns hedging on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// DelayMS is how long to wait for the first chunk before starting the same request on a
+	// second auth. Default is 5000.
+	DelayMS int `yaml:"delay-ms,omitempty" json:"delay-ms,omitempty"`
+	// Providers limits hedging to requests that may be served by one of these providers
+	// (e.g. "antigravity"). Empty hedges every streaming request.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// RoutingDecisionLogConfig configures sampled logging of selector decisions.
+type RoutingDecisionLogConfig struct {
+	// Enabled turns decision logging on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// SampleRate is the fraction of selections logged, from 0 to 1. Default is 0.01.
+	SampleRate float64 `yaml:"sample-rate,omitempty" json:"sample-rate,omitempty"`
+	// Buffer is how many recent decisions the management API keeps. Default is 200.
+	Buffer int `yaml:"buffer,omitempty" json:"buffer,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -263,6 +874,25 @@ type PayloadRule struct {
 	Params map[string]any `yaml:"params" json:"params"`
 }
 
+// RequestTemplate describes fields a provider executor sets on every upstream request.
+// Entries are merged over the provider's built-in template path by path.
+type RequestTemplate struct {
+	// Static maps JSON paths (gjson/sjson syntax) to literal values.
+	Static map[string]any `yaml:"static,omitempty" json:"static,omitempty"`
+	// Generated maps JSON paths to a generator producing a value per request: "uuid",
+	// "request-id", "session-id", "random-session-id" or "project-id". "none" removes a
+	// built-in field.
+	Generated map[string]string `yaml:"generated,omitempty" json:"generated,omitempty"`
+	// SessionStrategy selects how the "session-id" generator derives IDs:
+	//   - "stable-hash": hash of the first user text (default)
+	//   - "first-turn": hash of the whole first user turn, including non-text parts
+	//   - "per-request": a new random ID for every request
+	//   - "client-provided": hash of the client's SessionHeader, else "stable-hash"
+	SessionStrategy string `yaml:"session-strategy,omitempty" json:"session-strategy,omitempty"`
+	// SessionHeader is the client header read by "client-provided". Defaults to X-Session-Id.
+	SessionHeader string `yaml:"session-header,omitempty" json:"session-header,omitempty"`
+}
+
 // PayloadModelRule ties a model name pattern to a specific translator protocol.
 type PayloadModelRule struct {
 	// Name is the model name or wildcard pattern (e.g., "gpt-*", "*-5", "gemini-*-pro").
@@ -540,6 +1170,20 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	unknownKeys, errKeys := FindUnknownKeys(data)
+	if errKeys == nil && len(unknownKeys) > 0 {
+		if cfg.StrictConfig && !optional {
+			msgs := make([]string, 0, len(unknownKeys))
+			for _, key := range unknownKeys {
+				msgs = append(msgs, key.String())
+			}
+			return nil, fmt.Errorf("strict-config: %s", strings.Join(msgs, "; "))
+		}
+		for _, key := range unknownKeys {
+			log.Warnf("config: %s; the key is ignored", key)
+		}
+	}
+
 	var legacy legacyConfigData
 	if errLegacy := yaml.Unmarshal(data, &legacy); errLegacy == nil {
 		if cfg.migrateLegacyGeminiKeys(legacy.LegacyGeminiKeys) {
@@ -600,6 +1244,15 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Claude key headers
 	cfg.SanitizeClaudeKeys()
 
+	// Sanitize Bedrock credentials: drop entries without region or credentials
+	cfg.SanitizeBedrockKeys()
+
+	// Sanitize Azure OpenAI credentials: drop entries without endpoint or credentials
+	cfg.SanitizeAzureOpenAIKeys()
+
+	// Sanitize OpenRouter keys: drop entries without an API key and apply the default base URL
+	cfg.SanitizeOpenRouterKeys()
+
 	// Sanitize OpenAI compatibility providers: drop entries without base-url
 	cfg.SanitizeOpenAICompatibility()
 
@@ -612,6 +1265,11 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Replace plaintext API keys in per-key request log levels with their digests.
+	if cfg.SanitizeRequestLogLevels() {
+		cfg.legacyMigrationPending = true
+	}
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
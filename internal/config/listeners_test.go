@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestListenerEndpoint(t *testing.T) {
+	cases := []struct {
+		address, network, want string
+		wantErr                bool
+	}{
+		{address: "[::1]:8317", network: "tcp", want: "[::1]:8317"},
+		{address: ":8317", network: "tcp", want: ":8317"},
+		{address: "unix:@cliproxy", network: "unix", want: "@cliproxy"},
+		{address: "unix:", wantErr: true},
+		{address: "localhost", wantErr: true},
+	}
+	for _, tc := range cases {
+		network, address, err := ListenerConfig{Address: tc.address}.Endpoint()
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error", tc.address)
+			}
+			continue
+		}
+		if err != nil || network != tc.network || address != tc.want {
+			t.Errorf("%q: got %s %s %v", tc.address, network, address, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import "strings"
+
+// DefaultOpenRouterBaseURL is the OpenRouter API root used when a key does not set one.
+const DefaultOpenRouterBaseURL = "https://openrouter.ai/api/v1"
+
+// OpenRouterKey represents the configuration for an OpenRouter API key. The models served
+// through the key are discovered from the OpenRouter model list; Models only adds aliases.
+type OpenRouterKey struct {
+	// APIKey is the OpenRouter API key, sent as a bearer token.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// BaseURL is the OpenRouter API root; defaults to DefaultOpenRouterBaseURL.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "teamA/openai/gpt-4o").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this API key if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models defines client-facing aliases for OpenRouter model IDs.
+	Models []OpenRouterModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this key, e.g. the
+	// "HTTP-Referer" and "X-Title" attribution headers.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+func (k OpenRouterKey) GetAPIKey() string  { return k.APIKey }
+func (k OpenRouterKey) GetBaseURL() string { return k.BaseURL }
+
+// OpenRouterModel maps a client-facing alias to an OpenRouter model ID.
+type OpenRouterModel struct {
+	// Name is the OpenRouter model ID, e.g. "anthropic/claude-sonnet-4.5".
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m OpenRouterModel) GetName() string  { return m.Name }
+func (m OpenRouterModel) GetAlias() string { return m.Alias }
+
+// SanitizeOpenRouterKeys normalizes OpenRouter keys, applies the default base URL and drops
+// entries without an API key.
+func (cfg *Config) SanitizeOpenRouterKeys() {
+	if cfg == nil {
+		return
+	}
+
+	out := cfg.OpenRouterKey[:0]
+	for i := range cfg.OpenRouterKey {
+		entry := cfg.OpenRouterKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		entry.BaseURL = strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.BaseURL == "" {
+			entry.BaseURL = DefaultOpenRouterBaseURL
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]OpenRouterModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" && model.Alias != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+		out = append(out, entry)
+	}
+	cfg.OpenRouterKey = out
+}
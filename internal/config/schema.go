@@ -0,0 +1,226 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownKey is a config key that does not map to any configuration field.
+type UnknownKey struct {
+	// Path is the dotted location of the key, e.g. "routing.stratgy".
+	Path string
+	// Line and Column locate the key in the YAML source.
+	Line   int
+	Column int
+	// Suggestion is the closest known key at the same level, when one is close enough.
+	Suggestion string
+}
+
+func (k UnknownKey) String() string {
+	msg := fmt.Sprintf("line %d: unknown config key %q", k.Line, k.Path)
+	if k.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", k.Suggestion)
+	}
+	return msg
+}
+
+// legacyKeyPatterns lists keys that are no longer part of Config but are still read by the
+// loader's migrations. "[]" stands for any sequence index.
+var legacyKeyPatterns = map[string]bool{
+	"generative-language-api-key":          true,
+	"oauth-model-mappings":                 true,
+	"amp-upstream-url":                     true,
+	"amp-upstream-api-key":                 true,
+	"amp-restrict-management-to-localhost": true,
+	"amp-model-mappings":                   true,
+	"openai-compatibility[].api-keys":      true,
+}
+
+// FindUnknownKeys reports keys in the YAML document data that do not correspond to a
+// Config field. Keys read by legacy migrations are accepted.
+func FindUnknownKeys(data []byte) ([]UnknownKey, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var unknown []UnknownKey
+	walkKnownKeys(&root, reflect.TypeOf(Config{}), "", "", &unknown)
+	return unknown, nil
+}
+
+func walkKnownKeys(node *yaml.Node, t reflect.Type, path, pattern string, unknown *[]UnknownKey) {
+	if node == nil {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkKnownKeys(child, t, path, pattern, unknown)
+		}
+		return
+	case yaml.AliasNode:
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			childPath, childPattern := joinKeyPath(path, key.Value), joinKeyPath(pattern, key.Value)
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				if !legacyKeyPatterns[childPattern] {
+					*unknown = append(*unknown, UnknownKey{
+						Path:       childPath,
+						Line:       key.Line,
+						Column:     key.Column,
+						Suggestion: closestKey(key.Value, fields),
+					})
+				}
+				continue
+			}
+			walkKnownKeys(value, fieldType, childPath, childPattern, unknown)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			walkKnownKeys(node.Content[i+1], t.Elem(), joinKeyPath(path, key), pattern+"{}", unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			walkKnownKeys(item, t.Elem(), path+"["+strconv.Itoa(i)+"]", pattern+"[]", unknown)
+		}
+	}
+}
+
+func joinKeyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// yamlFields maps the YAML keys of struct type t to their field types, flattening inline
+// structs the way yaml.v3 does.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			inner := field.Type
+			for inner.Kind() == reflect.Ptr {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				for k, v := range yamlFields(inner) {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// closestKey returns the known key nearest to key by edit distance, or "" when none is
+// plausibly a typo of it.
+func closestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", len(key)/3+2
+	for candidate := range fields {
+		if d := editDistance(key, candidate); d < bestDist || (d == bestDist && best != "" && candidate < best) {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// JSONSchema returns a JSON schema (draft 2020-12) describing the YAML config file. It is
+// generated from the Config struct, so it always matches the running binary.
+func JSONSchema() ([]byte, error) {
+	schema := schemaFor(reflect.TypeOf(Config{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "CLIProxyAPI configuration"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := yamlFields(t)
+		props := make(map[string]any, len(fields))
+		for name, fieldType := range fields {
+			props[name] = schemaFor(fieldType)
+		}
+		return map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const typoConfig = `port: 8317
+oauth-model-alais:
+  gemini-cli:
+    - name: gemini-2.5-pro
+      alias: g25
+oauth-model-alias:
+  codex:
+    - name: gpt-5
+      alais: g5
+routing:
+  strategy: round-robin
+generative-language-api-key:
+  - legacy
+openai-compatibility:
+  - name: legacy
+    base-url: https://example.com
+    api-keys: [k]
+`
+
+func TestFindUnknownKeysReportsTyposWithLines(t *testing.T) {
+	unknown, err := FindUnknownKeys([]byte(typoConfig))
+	if err != nil {
+		t.Fatalf("FindUnknownKeys: %v", err)
+	}
+	if len(unknown) != 2 {
+		t.Fatalf("expected 2 unknown keys, got %v", unknown)
+	}
+	if got := unknown[0]; got.Path != "oauth-model-alais" || got.Line != 2 || got.Suggestion != "oauth-model-alias" {
+		t.Fatalf("unexpected first key: %+v", got)
+	}
+	if got := unknown[1]; got.Path != "oauth-model-alias.codex[0].alais" || got.Line != 9 || got.Suggestion != "alias" {
+		t.Fatalf("unexpected nested key: %+v", got)
+	}
+}
+
+func TestLoadConfigStrictRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("strict-config: true\n"+typoConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "line 3") || !strings.Contains(err.Error(), `did you mean "oauth-model-alias"`) {
+		t.Fatalf("expected strict load failure with line numbers, got %v", err)
+	}
+
+	if err = os.WriteFile(path, []byte(typoConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadConfig(path); err != nil {
+		t.Fatalf("non-strict load must tolerate unknown keys: %v", err)
+	}
+}
+
+func TestJSONSchemaDescribesConfig(t *testing.T) {
+	raw, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema: %v", err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type                 string          `json:"type"`
+			AdditionalProperties json.RawMessage `json:"additionalProperties"`
+		} `json:"properties"`
+	}
+	if err = json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	for key, typ := range map[string]string{"port": "integer", "api-keys": "array", "routing": "object", "debug": "boolean"} {
+		if got := schema.Properties[key].Type; got != typ {
+			t.Fatalf("property %s type = %q, want %q", key, got, typ)
+		}
+	}
+	if string(schema.Properties["routing"].AdditionalProperties) != "false" {
+		t.Fatal("struct sections must reject unknown keys")
+	}
+}
@@ -4,6 +4,12 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -14,9 +20,17 @@ type SDKConfig struct {
 	// credentials as well.
 	ForceModelPrefix bool `yaml:"force-model-prefix" json:"force-model-prefix"`
 
-	// RequestLog enables or disables detailed request logging functionality.
+	// RequestLog is the legacy request logging switch. It is equivalent to setting
+	// RequestLogLevels.Default to "full" and is only consulted when that default is empty;
+	// a matching provider or key level always takes precedence over it.
 	RequestLog bool `yaml:"request-log" json:"request-log"`
 
+	// RequestLogLevels sets request logging per upstream provider and per client API key.
+	RequestLogLevels RequestLogLevels `yaml:"request-log-levels,omitempty" json:"request-log-levels,omitempty"`
+
+	// RequestLogSampling thins out request logs while keeping errors and slow requests.
+	RequestLogSampling RequestLogSampling `yaml:"request-log-sampling,omitempty" json:"request-log-sampling,omitempty"`
+
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
@@ -29,6 +43,265 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// SystemPromptDedup collapses redundant system prompt blocks and tells executors when a
+	// conversation resends an unchanged system prompt so they can lean on provider prompt caching.
+	SystemPromptDedup SystemPromptDedupConfig `yaml:"system-prompt-dedup,omitempty" json:"system-prompt-dedup,omitempty"`
+
+	// RequestNormalization repairs common client payload mistakes before a request is
+	// processed, so the proxy absorbs them instead of forwarding an upstream 400.
+	RequestNormalization RequestNormalizationConfig `yaml:"request-normalization,omitempty" json:"request-normalization,omitempty"`
+
+	// StrictKeys lists client API keys or managed key IDs whose requests are rejected with 400
+	// when they carry parameters (logprobs, n > 1, penalties, ...) the target provider cannot honor.
+	StrictKeys []string `yaml:"strict-keys,omitempty" json:"strict-keys,omitempty"`
+
+	// OutputBudget caps the cumulative output tokens a single conversation may consume.
+	OutputBudget OutputBudgetConfig `yaml:"output-budget,omitempty" json:"output-budget,omitempty"`
+
+	// ConversationSummary appends the conversation's cumulative token usage and estimated
+	// cost to the end of every response.
+	ConversationSummary ConversationSummaryConfig `yaml:"conversation-summary,omitempty" json:"conversation-summary,omitempty"`
+
+	// ResponseCache answers repeated identical requests from a response cache instead of
+	// calling the upstream again.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
+
+	// StreamFanout lets management clients subscribe to streaming responses while they are
+	// live, receiving the same chunks as the client without a second upstream request.
+	StreamFanout bool `yaml:"stream-fanout,omitempty" json:"stream-fanout,omitempty"`
+
+	// AgentMode enables /v1/agent/completions, where the proxy runs the tool-use loop itself
+	// with tools registered on the server.
+	AgentMode AgentModeConfig `yaml:"agent-mode,omitempty" json:"agent-mode,omitempty"`
+
+	// OutputPostProcess rewrites response text (regex replacements, banned strings, markdown
+	// fixes) before it reaches the client, in streamed and non-streamed responses.
+	OutputPostProcess OutputPostProcessConfig `yaml:"output-postprocess,omitempty" json:"output-postprocess,omitempty"`
+
+	// LanguageRouting redirects requests to another model based on the script that dominates
+	// the prompt, e.g. sending CJK-heavy prompts to a model that tokenizes them cheaply.
+	LanguageRouting []LanguageRoute `yaml:"language-routing,omitempty" json:"language-routing,omitempty"`
+
+	// ContextRouting sends requests whose estimated prompt does not fit the requested model's
+	// context window to a dated variant of the model with a larger one.
+	ContextRouting ContextRoutingConfig `yaml:"context-routing,omitempty" json:"context-routing,omitempty"`
+
+	// PromptTemplates are named prompts that chat requests invoke with the "template" field
+	// instead of embedding them; the proxy expands them with the request's "variables".
+	PromptTemplates []PromptTemplate `yaml:"prompt-templates,omitempty" json:"prompt-templates,omitempty"`
+
+	// ScheduledJobs enables /v1/jobs, which queues requests to run at a later time or once
+	// a model's quota has recovered, with results fetched by job ID or posted to a webhook.
+	ScheduledJobs ScheduledJobsConfig `yaml:"scheduled-jobs,omitempty" json:"scheduled-jobs,omitempty"`
+
+	// MessageBatches enables /v1/messages/batches, an emulation of Anthropic's Message Batches
+	// API that runs each request of a batch through the credential pool.
+	MessageBatches MessageBatchesConfig `yaml:"message-batches,omitempty" json:"message-batches,omitempty"`
+}
+
+// MessageBatchesConfig configures Message Batches emulation. Batches and their results are
+// written to disk as requests finish, so results survive a restart; requests that had not
+// finished when the proxy stopped are reported as errored.
+type MessageBatchesConfig struct {
+	// Enabled exposes the batches endpoints.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Dir is where batches and results are stored. Default is "batches" under auth-dir.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// MaxConcurrency caps the batch requests in flight across all batches. Default is 4.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+	// MaxRequests caps the requests in one batch. Default is 10000.
+	MaxRequests int `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+	// ResultTTLHours is how long ended batches and their results are kept. Default is 720.
+	ResultTTLHours int `yaml:"result-ttl-hours,omitempty" json:"result-ttl-hours,omitempty"`
+}
+
+// ScheduledJobsConfig configures deferred request execution. Jobs are kept in memory and do
+// not survive a restart.
+type ScheduledJobsConfig struct {
+	// Enabled exposes the jobs endpoints.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxPending caps the jobs waiting to run across all clients. Default is 100.
+	MaxPending int `yaml:"max-pending,omitempty" json:"max-pending,omitempty"`
+	// MaxWaitMinutes is the longest a job may wait for its start time or quota condition
+	// before it expires. Default is 1440.
+	MaxWaitMinutes int `yaml:"max-wait-minutes,omitempty" json:"max-wait-minutes,omitempty"`
+	// ResultTTLMinutes is how long finished jobs and their results are kept. Default is 1440.
+	ResultTTLMinutes int `yaml:"result-ttl-minutes,omitempty" json:"result-ttl-minutes,omitempty"`
+	// CheckIntervalSeconds is how often start times and quota conditions are checked. Default is 30.
+	CheckIntervalSeconds int `yaml:"check-interval-seconds,omitempty" json:"check-interval-seconds,omitempty"`
+	// WebhookHosts lists the hosts results may be delivered to. Empty disables webhooks.
+	WebhookHosts []string `yaml:"webhook-hosts,omitempty" json:"webhook-hosts,omitempty"`
+	// RequeueExhausted requeues jobs that fail because every credential for their model is
+	// exhausted, to run after the pool's reset time. Jobs may override it with "requeue".
+	RequeueExhausted bool `yaml:"requeue-exhausted,omitempty" json:"requeue-exhausted,omitempty"`
+	// MaxRequeues caps how often a job is requeued before it is marked failed. Default is 3.
+	MaxRequeues int `yaml:"max-requeues,omitempty" json:"max-requeues,omitempty"`
+}
+
+// PromptTemplate is a named, versioned prompt expanded server-side. Placeholders of the form
+// {{name}} in System and Messages are replaced with the request variables, falling back to
+// Variables; a placeholder with neither is an error.
+type PromptTemplate struct {
+	// Name identifies the template in requests.
+	Name string `yaml:"name" json:"name"`
+	// Version distinguishes revisions of a template. Requests use the highest version unless
+	// they ask for one with "name@version". Default is 1.
+	Version int `yaml:"version,omitempty" json:"version,omitempty"`
+	// System is prepended to the system prompt of the request.
+	System string `yaml:"system,omitempty" json:"system,omitempty"`
+	// Messages are inserted before the messages of the request.
+	Messages []PromptTemplateMessage `yaml:"messages,omitempty" json:"messages,omitempty"`
+	// Variables holds default values for placeholders.
+	Variables map[string]string `yaml:"variables,omitempty" json:"variables,omitempty"`
+	// AllowedKeys lists the client API keys or managed key IDs that may use the template.
+	// Empty allows every key.
+	AllowedKeys []string `yaml:"allowed-keys,omitempty" json:"allowed-keys,omitempty"`
+}
+
+// PromptTemplateMessage is a conversation turn of a prompt template.
+type PromptTemplateMessage struct {
+	// Role is "user" or "assistant".
+	Role string `yaml:"role" json:"role"`
+	// Content is the text of the turn.
+	Content string `yaml:"content" json:"content"`
+}
+
+// LanguageRoute redirects requests whose prompt is written mostly in one script.
+// Routes are evaluated in order and the first match wins.
+type LanguageRoute struct {
+	// Script is "cjk", "cyrillic", "arabic", "western", "other", or "non-western" for the
+	// combined share of every script except western.
+	Script string `yaml:"script" json:"script"`
+	// MinShare is the fraction (0-1] of prompt letters that must belong to Script. Default is 0.5.
+	MinShare float64 `yaml:"min-share,omitempty" json:"min-share,omitempty"`
+	// Models lists the requested models the route applies to; a trailing "*" matches a
+	// prefix. Empty applies to every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// Target is the model requests are sent to instead.
+	Target string `yaml:"target" json:"target"`
+}
+
+// Output post-processing rule types.
+const (
+	// OutputRuleRegex replaces matches of Pattern with Replace ($1 expands capture groups).
+	OutputRuleRegex = "regex"
+	// OutputRuleBan removes every occurrence of Words, case-insensitively, or replaces it with Replace.
+	OutputRuleBan = "ban"
+	// OutputRuleStripGoogleURLs removes google.com links, as the web search path does.
+	OutputRuleStripGoogleURLs = "strip-google-urls"
+	// OutputRuleCloseCodeFences closes an unterminated ``` code block. Non-streamed responses only.
+	OutputRuleCloseCodeFences = "close-code-fences"
+)
+
+// OutputRule is one step of the output post-processing pipeline.
+type OutputRule struct {
+	// Type is one of "regex", "ban", "strip-google-urls" or "close-code-fences".
+	Type string `yaml:"type" json:"type"`
+	// Pattern is the regular expression of a "regex" rule.
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	// Replace is the replacement text of "regex" and "ban" rules. Empty removes the match.
+	Replace string `yaml:"replace,omitempty" json:"replace,omitempty"`
+	// Words lists the strings a "ban" rule filters.
+	Words []string `yaml:"words,omitempty" json:"words,omitempty"`
+}
+
+// OutputPostProcessConfig holds the default post-processing rules and per-key rule sets.
+// Rules run in order on every text field of a response. In streams they apply to each chunk
+// separately, so a pattern split across two chunks is not matched.
+type OutputPostProcessConfig struct {
+	// Rules apply to every client key without an override.
+	Rules []OutputRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+
+	// Keys replaces the rules per client API key or managed key ID. An empty list disables
+	// post-processing for that key.
+	Keys map[string][]OutputRule `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// AgentModeConfig configures the server-driven tool loop endpoint.
+type AgentModeConfig struct {
+	// Enabled exposes the agent endpoint.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxTurns caps the model calls of one agent request. Default is 8.
+	MaxTurns int `yaml:"max-turns,omitempty" json:"max-turns,omitempty"`
+	// ToolTimeoutSeconds bounds a single tool call. Default is 30.
+	ToolTimeoutSeconds int `yaml:"tool-timeout-seconds,omitempty" json:"tool-timeout-seconds,omitempty"`
+}
+
+// OutputBudgetConfig configures per-conversation output token budgets. Each request is capped
+// to the tokens left in its conversation's budget, so generation stops with the format's
+// max-tokens finish reason once the budget runs out; further requests are rejected with 429.
+type OutputBudgetConfig struct {
+	// Enabled turns budget enforcement on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxTokens is the cumulative output token budget per conversation. <= 0 disables enforcement.
+	MaxTokens int64 `yaml:"max-tokens" json:"max-tokens"`
+	// TTLMinutes is how long an idle conversation's spend is remembered. Default is 1440.
+	TTLMinutes int `yaml:"ttl-minutes,omitempty" json:"ttl-minutes,omitempty"`
+}
+
+// ConversationSummaryConfig configures the conversation spend summary. Conversations are
+// identified like output budgets; non-streaming responses get a top-level
+// "cliproxy_conversation" field and streams end with one extra chunk carrying it.
+type ConversationSummaryConfig struct {
+	// Enabled turns summaries on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// TTLMinutes is how long an idle conversation's totals are remembered. Default is 1440.
+	TTLMinutes int `yaml:"ttl-minutes,omitempty" json:"ttl-minutes,omitempty"`
+}
+
+// ContextRoutingConfig configures context-window based variant selection. Variants of a
+// model are the available models that share its name once a trailing date (-20250929 or
+// -2025-09-29) is removed.
+type ContextRoutingConfig struct {
+	// Enabled turns variant selection on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Compact drops the oldest turns of a conversation that fits no variant until it fits the
+	// variant with the largest context window.
+	Compact bool `yaml:"compact" json:"compact"`
+	// OutputReserveTokens is the room kept for the response when the request sets no max
+	// output tokens. Default is 4096.
+	OutputReserveTokens int `yaml:"output-reserve-tokens,omitempty" json:"output-reserve-tokens,omitempty"`
+}
+
+// RequestNormalizationConfig toggles the repairs applied to inbound request bodies. Each
+// repair only touches values the request format would reject.
+type RequestNormalizationConfig struct {
+	// WrapContent wraps message content, Gemini contents and parts sent as a single string or
+	// object into the array the format expects, and turns bare strings inside content arrays
+	// into text parts.
+	WrapContent bool `yaml:"wrap-content" json:"wrap-content"`
+	// DefaultRoles assigns a role to messages without one: assistant or model for messages
+	// carrying tool calls, tool for OpenAI tool results, user otherwise.
+	DefaultRoles bool `yaml:"default-roles" json:"default-roles"`
+	// CoerceBooleans turns 0/1 and "true"/"false" in known boolean fields (stream, logprobs,
+	// parallel_tool_calls, ...) into JSON booleans.
+	CoerceBooleans bool `yaml:"coerce-booleans" json:"coerce-booleans"`
+}
+
+// ResponseCacheConfig configures the response cache. Entries are keyed by the client
+// credential, endpoint format, model and normalized request body, so only byte-for-byte
+// equivalent requests of the same caller share a response.
+type ResponseCacheConfig struct {
+	// Enabled turns the cache on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// TTLSeconds is how long a response is served from the cache. Default is 300.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// MaxSizeMB bounds the memory held by cached responses; the least recently used entries
+	// are evicted first. Default is 64.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+	// StaleIfDegradedSeconds keeps responses this long past their TTL to answer identical
+	// requests that load shedding rejects because every provider of their model is degraded.
+	// 0 disables stale responses.
+	StaleIfDegradedSeconds int `yaml:"stale-if-degraded-seconds,omitempty" json:"stale-if-degraded-seconds,omitempty"`
+}
+
+// SystemPromptDedupConfig configures system prompt deduplication.
+type SystemPromptDedupConfig struct {
+	// Enabled turns deduplication on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// TTLMinutes is how long a conversation's system prompt is remembered. Default is 60.
+	TTLMinutes int `yaml:"ttl-minutes,omitempty" json:"ttl-minutes,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -41,6 +314,293 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// Coalesce batches small streaming chunks before flushing them to the client.
+	Coalesce StreamCoalesceConfig `yaml:"coalesce,omitempty" json:"coalesce,omitempty"`
+
+	// Dialect adapts the SSE framing of streamed responses for clients with strict parsers.
+	Dialect SSEDialectConfig `yaml:"dialect,omitempty" json:"dialect,omitempty"`
+
+	// LeakWatchdog detects streams whose pipeline outlives its client or stops making progress.
+	LeakWatchdog LeakWatchdogConfig `yaml:"leak-watchdog,omitempty" json:"leak-watchdog,omitempty"`
+}
+
+// LeakWatchdogConfig configures the stream leak watchdog. A stream counts as leaked when its
+// client disconnected more than GraceSeconds ago, or when neither the upstream nor the client
+// made progress for IdleSeconds, while its forwarding goroutine is still running.
+type LeakWatchdogConfig struct {
+	// Enabled tracks streaming responses and checks them periodically.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// IntervalSeconds is how often streams are checked. Default is 30.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// GraceSeconds is how long a stream may keep running after its client disconnected.
+	// Default is 30.
+	GraceSeconds int `yaml:"grace-seconds,omitempty" json:"grace-seconds,omitempty"`
+	// IdleSeconds is how long a stream may go without progress. Default is 600.
+	IdleSeconds int `yaml:"idle-seconds,omitempty" json:"idle-seconds,omitempty"`
+	// ForceClose cancels leaked streams instead of only logging them.
+	ForceClose bool `yaml:"force-close,omitempty" json:"force-close,omitempty"`
+}
+
+// SSEDialect adjusts how server-sent events are framed for a client. Unset fields keep the
+// framing of the response format.
+type SSEDialect struct {
+	// DoneSentinel controls the "data: [DONE]" terminator: false removes it, true appends it
+	// to successful streams of formats that do not send one.
+	DoneSentinel *bool `yaml:"done-sentinel,omitempty" json:"done-sentinel,omitempty"`
+
+	// EventNames set to false removes "event:" lines, leaving only data lines.
+	EventNames *bool `yaml:"event-names,omitempty" json:"event-names,omitempty"`
+
+	// Comments set to false removes comment lines, including keep-alive heartbeats.
+	Comments *bool `yaml:"comments,omitempty" json:"comments,omitempty"`
+
+	// RetryMS, when > 0, sends a "retry:" field with this reconnection delay before the
+	// first event.
+	RetryMS int `yaml:"retry-ms,omitempty" json:"retry-ms,omitempty"`
+}
+
+// Active reports whether the dialect changes the framing at all.
+func (d SSEDialect) Active() bool {
+	return d.DoneSentinel != nil || d.EventNames != nil || d.Comments != nil || d.RetryMS > 0
+}
+
+// SSEDialectConfig holds the default SSE dialect and per-key overrides.
+type SSEDialectConfig struct {
+	SSEDialect `yaml:",inline" json:",inline"`
+
+	// Keys overrides the dialect per client API key or managed key ID.
+	Keys map[string]SSEDialect `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// StreamCoalesceSettings controls when buffered streaming chunks are flushed. Buffered data is
+// flushed once it is FlushIntervalMS old or FlushBytes large, whichever comes first. Coalescing
+// is disabled when both values are <= 0.
+type StreamCoalesceSettings struct {
+	// FlushIntervalMS is the longest time, in milliseconds, a chunk may wait before being flushed.
+	FlushIntervalMS int `yaml:"flush-interval-ms,omitempty" json:"flush-interval-ms,omitempty"`
+
+	// FlushBytes flushes buffered data as soon as it reaches this many bytes.
+	FlushBytes int `yaml:"flush-bytes,omitempty" json:"flush-bytes,omitempty"`
+}
+
+// Enabled reports whether the settings buffer chunks at all.
+func (s StreamCoalesceSettings) Enabled() bool {
+	return s.FlushIntervalMS > 0 || s.FlushBytes > 0
+}
+
+// StreamCoalesceConfig holds the default coalescing settings and per-key overrides.
+type StreamCoalesceConfig struct {
+	StreamCoalesceSettings `yaml:",inline" json:",inline"`
+
+	// Keys overrides the settings per client API key or managed key ID. An override with both
+	// values <= 0 disables coalescing for that key.
+	Keys map[string]StreamCoalesceSettings `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// Request log levels control how much of a request/response cycle is written to request logs.
+const (
+	// RequestLogLevelNone disables request logging (error logs are still captured).
+	RequestLogLevelNone = "none"
+	// RequestLogLevelMetadata logs URLs, headers, status codes and timings but omits bodies.
+	RequestLogLevelMetadata = "metadata"
+	// RequestLogLevelFull logs complete request and response bodies.
+	RequestLogLevelFull = "full"
+)
+
+// RequestLogLevels maps providers and client API keys to request log levels.
+type RequestLogLevels struct {
+	// Default applies when no provider or key override matches. Empty falls back to RequestLog.
+	Default string `yaml:"default,omitempty" json:"default,omitempty"`
+
+	// Providers overrides the level per upstream provider identifier (e.g. "antigravity").
+	Providers map[string]string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// Keys overrides the level per client key, addressed by managed key ID or by the
+	// "sha256:<hex>" digest of the API key. Key overrides win over providers.
+	Keys map[string]string `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// requestLogKeyRefPrefix marks a per-key request log level addressed by key digest.
+const requestLogKeyRefPrefix = "sha256:"
+
+// RequestLogKeyRef returns the digest reference used to address a client API key in
+// RequestLogLevels.Keys, so that plaintext keys never need to appear in the config.
+func RequestLogKeyRef(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return requestLogKeyRefPrefix + hex.EncodeToString(sum[:])
+}
+
+// NormalizeRequestLogKeyName canonicalises a RequestLogLevels.Keys entry. Managed key IDs
+// and digest references are kept; anything else is treated as a plaintext API key and
+// replaced by its digest, which is reported through the second return value.
+func NormalizeRequestLogKeyName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", false
+	case strings.HasPrefix(strings.ToLower(name), requestLogKeyRefPrefix):
+		return strings.ToLower(name), false
+	case strings.HasPrefix(name, "key_"):
+		return name, false
+	default:
+		return RequestLogKeyRef(name), true
+	}
+}
+
+// SanitizeRequestLogLevels replaces plaintext API keys in RequestLogLevels.Keys with their
+// digests and reports whether any entry was rewritten.
+func (c *SDKConfig) SanitizeRequestLogLevels() bool {
+	if c == nil || len(c.RequestLogLevels.Keys) == 0 {
+		return false
+	}
+	changed := false
+	keys := make(map[string]string, len(c.RequestLogLevels.Keys))
+	for name, level := range c.RequestLogLevels.Keys {
+		normalized, hashed := NormalizeRequestLogKeyName(name)
+		if normalized == "" {
+			continue
+		}
+		changed = changed || hashed
+		keys[normalized] = level
+	}
+	c.RequestLogLevels.Keys = keys
+	return changed
+}
+
+// RequestLogSampling configures which finished requests are written to request logs.
+// It only applies when Enabled is true; otherwise every logged request is kept.
+type RequestLogSampling struct {
+	// Enabled turns sampling on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// SuccessRate is the fraction (0-1) of successful requests to keep.
+	SuccessRate float64 `yaml:"success-rate" json:"success-rate"`
+
+	// ErrorRate is the fraction (0-1) of failed requests (status >= 400 or upstream errors) to keep.
+	ErrorRate float64 `yaml:"error-rate" json:"error-rate"`
+
+	// SlowThresholdSeconds keeps every request taking at least this long. <= 0 disables slow capture.
+	SlowThresholdSeconds float64 `yaml:"slow-threshold-seconds,omitempty" json:"slow-threshold-seconds,omitempty"`
+}
+
+// NormalizeRequestLogLevel canonicalises a level name, reporting false for unknown values.
+func NormalizeRequestLogLevel(level string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "none", "off", "false":
+		return RequestLogLevelNone, true
+	case "metadata", "meta", "headers":
+		return RequestLogLevelMetadata, true
+	case "full", "body", "true":
+		return RequestLogLevelFull, true
+	default:
+		return "", false
+	}
+}
+
+// RequestLogLevelFor resolves the effective request log level for a provider and the
+// identifiers of the calling client key. Key overrides take precedence over provider
+// overrides, which take precedence over the default.
+func (c *SDKConfig) RequestLogLevelFor(provider string, keys ...string) string {
+	if c == nil {
+		return RequestLogLevelNone
+	}
+	levels := c.RequestLogLevels
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if level, ok := NormalizeRequestLogLevel(levels.Keys[key]); ok {
+			return level
+		}
+	}
+	if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+		for name, raw := range levels.Providers {
+			if strings.EqualFold(strings.TrimSpace(name), provider) {
+				if level, ok := NormalizeRequestLogLevel(raw); ok {
+					return level
+				}
+			}
+		}
+	}
+	if level, ok := NormalizeRequestLogLevel(levels.Default); ok {
+		return level
+	}
+	if c.RequestLog {
+		return RequestLogLevelFull
+	}
+	return RequestLogLevelNone
+}
+
+// RequestLogActive reports whether any provider or key may resolve to a level other than none.
+func (c *SDKConfig) RequestLogActive() bool {
+	if c == nil {
+		return false
+	}
+	if c.RequestLogLevelFor("") != RequestLogLevelNone {
+		return true
+	}
+	for _, overrides := range []map[string]string{c.RequestLogLevels.Providers, c.RequestLogLevels.Keys} {
+		for _, raw := range overrides {
+			if level, ok := NormalizeRequestLogLevel(raw); ok && level != RequestLogLevelNone {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StreamCoalesceFor resolves the chunk coalescing settings for the identifiers of the calling
+// client key. Key overrides take precedence over the default settings.
+func (c *SDKConfig) StreamCoalesceFor(keys ...string) StreamCoalesceSettings {
+	if c == nil {
+		return StreamCoalesceSettings{}
+	}
+	coalesce := c.Streaming.Coalesce
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if settings, ok := coalesce.Keys[key]; ok {
+			return settings
+		}
+	}
+	return coalesce.StreamCoalesceSettings
+}
+
+// SSEDialectFor resolves the SSE dialect for the identifiers of the calling client key. Key
+// overrides take precedence over the default dialect.
+func (c *SDKConfig) SSEDialectFor(keys ...string) SSEDialect {
+	if c == nil {
+		return SSEDialect{}
+	}
+	dialect := c.Streaming.Dialect
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if settings, ok := dialect.Keys[key]; ok {
+			return settings
+		}
+	}
+	return dialect.SSEDialect
+}
+
+// OutputRulesFor resolves the output post-processing rules for the identifiers of the calling
+// client key. The first identifier with a per-key rule set wins over the default rules.
+func (c *SDKConfig) OutputRulesFor(keys ...string) []OutputRule {
+	if c == nil {
+		return nil
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if rules, ok := c.OutputPostProcess.Keys[key]; ok {
+			return rules
+		}
+	}
+	return c.OutputPostProcess.Rules
 }
 
 // AccessConfig groups request authentication providers.
@@ -22,6 +22,12 @@ const (
 	// OpenaiResponse represents the OpenAI response format identifier.
 	OpenaiResponse = "openai-response"
 
+	// OpenAIEmbedding represents the OpenAI embeddings request format identifier.
+	OpenAIEmbedding = "openai-embedding"
+
+	// OpenAITranscription represents the OpenAI audio transcription request format identifier.
+	OpenAITranscription = "openai-transcription"
+
 	// Antigravity represents the Antigravity response format identifier.
 	Antigravity = "antigravity"
 )
@@ -0,0 +1,75 @@
+// Package daemon integrates the proxy with host init systems: systemd readiness and
+// watchdog notifications on Linux and the service control manager on Windows.
+// Every entry point is a no-op when the process is not supervised.
+package daemon
+
+import (
+	"context"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Ready tells the init system that startup finished and the proxy is serving.
+func Ready() {
+	if err := notify("READY=1\nSTATUS=Serving"); err != nil {
+		log.Debugf("daemon: readiness notification failed: %v", err)
+	}
+}
+
+// Stopping tells the init system that a graceful shutdown has started.
+func Stopping() {
+	_ = notify("STOPPING=1\nSTATUS=Shutting down")
+}
+
+// StartWatchdog pings the init system watchdog at half the configured interval for as
+// long as healthy reports true, so a wedged process is restarted instead of lingering.
+// It returns immediately when no watchdog is configured.
+func StartWatchdog(ctx context.Context, healthy func() bool) {
+	interval := watchdogInterval()
+	if interval <= 0 {
+		return
+	}
+	ping := interval / 2
+	log.Infof("systemd watchdog enabled (interval=%s)", interval)
+	go func() {
+		ticker := time.NewTicker(ping)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if healthy != nil && !healthy() {
+					log.Warn("daemon: health check failed, skipping watchdog ping")
+					continue
+				}
+				if err := notify("WATCHDOG=1"); err != nil {
+					log.Debugf("daemon: watchdog ping failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// ListenerHealthy returns a health check that succeeds while the proxy accepts
+// connections on the given listener. Wildcard TCP hosts probe the loopback interface.
+func ListenerHealthy(network, address string) func() bool {
+	if network == "tcp" {
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			if host == "" || host == "0.0.0.0" || host == "::" {
+				host = "127.0.0.1"
+			}
+			address = net.JoinHostPort(host, port)
+		}
+	}
+	return func() bool {
+		conn, err := net.DialTimeout(network, address, 2*time.Second)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}
+}
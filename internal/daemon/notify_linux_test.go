@@ -0,0 +1,46 @@
+//go:build linux
+
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReadySendsNotification(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	Ready()
+
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read notification: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1\nSTATUS=Serving" {
+		t.Fatalf("unexpected notification %q", got)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if got := watchdogInterval(); got != 30*time.Second {
+		t.Fatalf("watchdogInterval() = %s, want 30s", got)
+	}
+	t.Setenv("WATCHDOG_PID", "1")
+	if got := watchdogInterval(); got != 0 {
+		t.Fatalf("watchdog for another pid should be ignored, got %s", got)
+	}
+}
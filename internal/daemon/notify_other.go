@@ -0,0 +1,9 @@
+//go:build !linux
+
+package daemon
+
+import "time"
+
+func notify(string) error { return nil }
+
+func watchdogInterval() time.Duration { return 0 }
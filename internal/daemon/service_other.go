@@ -0,0 +1,16 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"context"
+	"errors"
+)
+
+// IsService reports whether the process was started by the Windows service control manager.
+func IsService() bool { return false }
+
+// RunService is only supported on Windows.
+func RunService(string, func(ctx context.Context)) error {
+	return errors.New("daemon: windows services are not supported on this platform")
+}
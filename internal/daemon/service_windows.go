@@ -0,0 +1,63 @@
+//go:build windows
+
+package daemon
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc"
+)
+
+// IsService reports whether the process was started by the Windows service control manager.
+func IsService() bool {
+	ok, err := svc.IsWindowsService()
+	if err != nil {
+		log.Debugf("daemon: service detection failed: %v", err)
+		return false
+	}
+	return ok
+}
+
+// RunService runs fn under the service control manager. The context passed to fn is
+// cancelled on stop or shutdown requests, and RunService returns once fn returns.
+func RunService(name string, fn func(ctx context.Context)) error {
+	return svc.Run(name, &serviceHandler{fn: fn})
+}
+
+type serviceHandler struct {
+	fn func(ctx context.Context)
+}
+
+func (h *serviceHandler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.fn(ctx)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: accepted}
+
+	for {
+		select {
+		case <-done:
+			status <- svc.Status{State: svc.StopPending}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending, WaitHint: uint32((30 * time.Second).Milliseconds())}
+				cancel()
+				<-done
+				return false, 0
+			}
+		}
+	}
+}
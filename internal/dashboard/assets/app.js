@@ -0,0 +1,154 @@
+(function () {
+  'use strict';
+
+  var API = '../v0/management';
+  var POLL_MS = 5000;
+  var KEY_STORAGE = 'cliproxy-dashboard-key';
+  var samples = [];
+  var timer = null;
+
+  function $(id) { return document.getElementById(id); }
+
+  function text(tag, value, cls) {
+    var el = document.createElement(tag);
+    el.textContent = value == null ? '' : String(value);
+    if (cls) { el.className = cls; }
+    return el;
+  }
+
+  function fetchSummary() {
+    var key = sessionStorage.getItem(KEY_STORAGE) || '';
+    return fetch(API + '/dashboard', { headers: { 'Authorization': 'Bearer ' + key } })
+      .then(function (res) {
+        if (res.status === 401 || res.status === 403) {
+          throw new Error('unauthorized');
+        }
+        if (!res.ok) { throw new Error('HTTP ' + res.status); }
+        return res.json();
+      });
+  }
+
+  function authState(auth) {
+    if (auth.disabled || auth.status === 'disabled') { return 'disabled'; }
+    if (auth.unavailable) { return 'cooling'; }
+    if (auth.status === 'error') { return 'error'; }
+    return 'active';
+  }
+
+  function renderQuota(cell, quota) {
+    var models = Object.keys(quota || {}).sort();
+    if (!models.length) { cell.textContent = '-'; return; }
+    models.forEach(function (model) {
+      var pct = Math.round(quota[model]);
+      var wrap = text('span', model + ' ' + pct + '%', 'quota');
+      var bar = text('span', '', 'bar' + (pct < 20 ? ' low' : ''));
+      var fill = document.createElement('span');
+      fill.style.width = pct + '%';
+      bar.appendChild(fill);
+      wrap.appendChild(bar);
+      cell.appendChild(wrap);
+    });
+  }
+
+  function renderThroughput(usage) {
+    var now = Date.now();
+    samples.push({ t: now, n: usage.total_requests });
+    while (samples.length > 1 && now - samples[0].t > 60000) { samples.shift(); }
+    if (samples.length < 2) { $('throughput').textContent = '-'; return; }
+    var first = samples[0];
+    var minutes = (now - first.t) / 60000;
+    var rate = minutes > 0 ? (usage.total_requests - first.n) / minutes : 0;
+    $('throughput').textContent = rate.toFixed(1);
+  }
+
+  function render(data) {
+    var totals = data.totals || {};
+    $('auths-total').textContent = totals.auths;
+    $('auths-active').textContent = totals.active;
+    $('auths-cooling').textContent = totals.cooling;
+    $('auths-disabled').textContent = totals.disabled;
+    $('updated').textContent = 'updated ' + new Date(data.generated_at).toLocaleTimeString();
+
+    var usage = data.usage || {};
+    $('usage-disabled').hidden = !!usage.enabled;
+    renderThroughput(usage);
+    $('success-rate').textContent = usage.total_requests
+      ? (100 * usage.success_count / usage.total_requests).toFixed(1) + '%'
+      : '-';
+
+    var rows = $('auth-rows');
+    rows.replaceChildren();
+    (data.auths || []).forEach(function (auth) {
+      var state = authState(auth);
+      var tr = document.createElement('tr');
+      tr.appendChild(text('td', auth.provider));
+      tr.appendChild(text('td', auth.label || auth.name));
+      var status = state;
+      if (state === 'cooling' && auth.next_retry_after) {
+        status += ' until ' + new Date(auth.next_retry_after).toLocaleTimeString();
+      }
+      tr.appendChild(text('td', status, 'status-' + state));
+      var quotaCell = document.createElement('td');
+      renderQuota(quotaCell, auth.quota);
+      tr.appendChild(quotaCell);
+      tr.appendChild(text('td', auth.status_message));
+      rows.appendChild(tr);
+    });
+
+    var errors = $('error-rows');
+    errors.replaceChildren();
+    var recent = data.recent_errors || [];
+    if (!recent.length) {
+      var empty = document.createElement('tr');
+      var cell = text('td', 'No recent errors', 'muted');
+      cell.colSpan = 4;
+      empty.appendChild(cell);
+      errors.appendChild(empty);
+    }
+    recent.forEach(function (item) {
+      var tr = document.createElement('tr');
+      tr.appendChild(text('td', new Date(item.timestamp).toLocaleString()));
+      tr.appendChild(text('td', item.api));
+      tr.appendChild(text('td', item.model));
+      tr.appendChild(text('td', item.source));
+      errors.appendChild(tr);
+    });
+  }
+
+  function showLogin(message) {
+    clearTimeout(timer);
+    $('app').hidden = true;
+    $('login').hidden = false;
+    $('login-error').textContent = message || '';
+  }
+
+  function poll() {
+    fetchSummary().then(function (data) {
+      $('login').hidden = true;
+      $('app').hidden = false;
+      render(data);
+      timer = setTimeout(poll, POLL_MS);
+    }).catch(function (err) {
+      if (err.message === 'unauthorized') {
+        sessionStorage.removeItem(KEY_STORAGE);
+        showLogin('Invalid management key');
+        return;
+      }
+      $('updated').textContent = 'update failed: ' + err.message;
+      timer = setTimeout(poll, POLL_MS);
+    });
+  }
+
+  $('login').addEventListener('submit', function (event) {
+    event.preventDefault();
+    sessionStorage.setItem(KEY_STORAGE, $('key').value);
+    samples = [];
+    poll();
+  });
+
+  if (sessionStorage.getItem(KEY_STORAGE)) {
+    poll();
+  } else {
+    showLogin();
+  }
+})();
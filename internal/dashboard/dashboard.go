@@ -0,0 +1,54 @@
+// Package dashboard serves the minimal web dashboard compiled into the binary. It renders
+// auth health, quota, throughput and recent errors from the management API and is meant
+// for deployments that do not run the separate Management Center.
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Register mounts the dashboard under /dashboard. enabled is consulted per request so
+// config reloads can toggle it without re-registering routes.
+func Register(engine *gin.Engine, enabled func() bool) {
+	static, err := fs.Sub(assets, "assets")
+	if err != nil {
+		panic(err)
+	}
+	index, err := fs.ReadFile(static, "index.html")
+	if err != nil {
+		panic(err)
+	}
+	files := http.FileServer(http.FS(static))
+	guard := func(c *gin.Context) bool {
+		if enabled != nil && !enabled() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return false
+		}
+		return true
+	}
+
+	engine.GET("/dashboard", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/dashboard/")
+	})
+	engine.GET("/dashboard/", func(c *gin.Context) {
+		if !guard(c) {
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+	engine.GET("/dashboard/assets/*file", func(c *gin.Context) {
+		if !guard(c) {
+			return
+		}
+		c.Request.URL.Path = c.Param("file")
+		files.ServeHTTP(c.Writer, c.Request)
+	})
+}
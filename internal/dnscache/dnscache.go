@@ -0,0 +1,152 @@
+// Package dnscache caches upstream hostname resolutions for outbound HTTP clients.
+// Fresh entries are served for the configured TTL; after that a lookup is attempted and,
+// if it fails, the last successful answer keeps being served until the stale window ends.
+// Go's resolver does not expose record TTLs, so the TTL is configured rather than learned.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultTTL is how long a resolution is served without re-querying.
+	DefaultTTL = time.Minute
+	// DefaultStaleTTL is how long a resolution may be reused while lookups fail.
+	DefaultStaleTTL = time.Hour
+	// maxEntries bounds the cache; it is cleared when full.
+	maxEntries = 4096
+)
+
+type entry struct {
+	addrs    []net.IPAddr
+	resolved time.Time
+}
+
+// Resolver is a caching hostname resolver safe for concurrent use.
+type Resolver struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	staleTTL time.Duration
+	entries  map[string]entry
+	group    singleflight.Group
+
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+	now    func() time.Time
+}
+
+// New returns a resolver using the system resolver for lookups.
+func New(ttl, staleTTL time.Duration) *Resolver {
+	r := &Resolver{
+		entries: make(map[string]entry),
+		lookup:  net.DefaultResolver.LookupIPAddr,
+		now:     time.Now,
+	}
+	r.SetTTL(ttl, staleTTL)
+	return r
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Resolver
+)
+
+// Shared returns the process-wide resolver with its TTLs updated to the given values.
+func Shared(ttl, staleTTL time.Duration) *Resolver {
+	sharedOnce.Do(func() { shared = New(ttl, staleTTL) })
+	shared.SetTTL(ttl, staleTTL)
+	return shared
+}
+
+// SetTTL updates the fresh and stale lifetimes. Non-positive values select the defaults.
+func (r *Resolver) SetTTL(ttl, staleTTL time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if staleTTL <= 0 {
+		staleTTL = DefaultStaleTTL
+	}
+	r.mu.Lock()
+	r.ttl, r.staleTTL = ttl, staleTTL
+	r.mu.Unlock()
+}
+
+// LookupIPAddr resolves host, serving cached answers while fresh and stale answers when
+// the lookup fails.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	now := r.now()
+	r.mu.Lock()
+	cached, ok := r.entries[host]
+	ttl, staleTTL := r.ttl, r.staleTTL
+	r.mu.Unlock()
+	if ok && now.Sub(cached.resolved) < ttl {
+		return cached.addrs, nil
+	}
+
+	result, err, _ := r.group.Do(host, func() (any, error) {
+		addrs, errLookup := r.lookup(ctx, host)
+		if errLookup != nil {
+			return nil, errLookup
+		}
+		if len(addrs) == 0 {
+			return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		r.store(host, addrs)
+		return addrs, nil
+	})
+	if err == nil {
+		return result.([]net.IPAddr), nil
+	}
+	if ok && now.Sub(cached.resolved) < ttl+staleTTL && !errors.Is(err, context.Canceled) {
+		log.Debugf("dns cache: lookup of %s failed, serving stale answer: %v", host, err)
+		return cached.addrs, nil
+	}
+	return nil, err
+}
+
+func (r *Resolver) store(host string, addrs []net.IPAddr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.entries[host]; !exists && len(r.entries) >= maxEntries {
+		r.entries = make(map[string]entry)
+	}
+	r.entries[host] = entry{addrs: addrs, resolved: r.now()}
+}
+
+// DialFunc matches net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// WrapDial returns a dial function that resolves hostnames through r and tries each
+// address in order. IP literals are passed through unchanged.
+func (r *Resolver) WrapDial(dial DialFunc) DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+		addrs, err := r.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var firstErr error
+		for _, addr := range addrs {
+			conn, errDial := dial(ctx, network, net.JoinHostPort(addr.String(), port))
+			if errDial == nil {
+				return conn, nil
+			}
+			if firstErr == nil {
+				firstErr = errDial
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, firstErr
+	}
+}
@@ -0,0 +1,68 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestResolverServesStaleOnFailure(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	calls := 0
+	fail := false
+	r := New(time.Minute, time.Hour)
+	r.now = func() time.Time { return now }
+	r.lookup = func(context.Context, string) ([]net.IPAddr, error) {
+		calls++
+		if fail {
+			return nil, errors.New("temporary failure in name resolution")
+		}
+		return []net.IPAddr{{IP: net.ParseIP("192.0.2.10")}}, nil
+	}
+
+	if _, err := r.LookupIPAddr(context.Background(), "api.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.LookupIPAddr(context.Background(), "api.example.com"); err != nil || calls != 1 {
+		t.Fatalf("fresh entry should be cached, calls=%d err=%v", calls, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	fail = true
+	addrs, err := r.LookupIPAddr(context.Background(), "api.example.com")
+	if err != nil || len(addrs) != 1 || calls != 2 {
+		t.Fatalf("expected stale answer after failed refresh, addrs=%v calls=%d err=%v", addrs, calls, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err = r.LookupIPAddr(context.Background(), "api.example.com"); err == nil {
+		t.Fatal("answers past the stale window must not be served")
+	}
+}
+
+func TestWrapDialUsesResolvedAddress(t *testing.T) {
+	r := New(time.Minute, time.Hour)
+	r.lookup = func(context.Context, string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("192.0.2.1")}, {IP: net.ParseIP("192.0.2.2")}}, nil
+	}
+	var dialed []string
+	dial := r.WrapDial(func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		if address == "192.0.2.2:443" {
+			client, server := net.Pipe()
+			_ = server.Close()
+			return client, nil
+		}
+		return nil, errors.New("connection refused")
+	})
+	conn, err := dial(context.Background(), "tcp", "api.example.com:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if len(dialed) != 2 || dialed[0] != "192.0.2.1:443" {
+		t.Fatalf("unexpected dial order: %v", dialed)
+	}
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	debugRequestTTL = 10 * time.Minute
+	maxDebugRequest = 4096
+)
+
+var debugRequests = struct {
+	sync.Mutex
+	ids map[string]time.Time
+}{ids: make(map[string]time.Time)}
+
+// MarkDebugRequest enables debug logging for the request in ctx when provider or authID
+// matches a targeted debug filter.
+func MarkDebugRequest(ctx context.Context, provider, authID string) {
+	if !util.DebugTargeted(provider, authID) {
+		return
+	}
+	requestID := GetRequestID(ctx)
+	if requestID == "" {
+		return
+	}
+	now := time.Now()
+	debugRequests.Lock()
+	defer debugRequests.Unlock()
+	if len(debugRequests.ids) >= maxDebugRequest {
+		for id, expires := range debugRequests.ids {
+			if now.After(expires) {
+				delete(debugRequests.ids, id)
+			}
+		}
+	}
+	if len(debugRequests.ids) < maxDebugRequest {
+		debugRequests.ids[requestID] = now.Add(debugRequestTTL)
+	}
+}
+
+func debugRequestMarked(requestID string) bool {
+	if requestID == "" {
+		return false
+	}
+	debugRequests.Lock()
+	defer debugRequests.Unlock()
+	expires, ok := debugRequests.ids[requestID]
+	if ok && time.Now().After(expires) {
+		delete(debugRequests.ids, requestID)
+		return false
+	}
+	return ok
+}
+
+// entryVisible drops entries above the base level unless they belong to a targeted request.
+func entryVisible(entry *log.Entry) bool {
+	if entry.Level <= util.BaseLogLevel() || !util.DebugTargetsActive() {
+		return true
+	}
+	if requestID, ok := entry.Data["request_id"].(string); ok && debugRequestMarked(requestID) {
+		return true
+	}
+	provider, _ := entry.Data["provider"].(string)
+	authID, _ := entry.Data["auth_id"].(string)
+	return util.DebugTargeted(provider, authID)
+}
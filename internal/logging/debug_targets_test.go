@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestTargetedDebugOnlyShowsMatchingRequests(t *testing.T) {
+	previous := log.GetLevel()
+	info := log.InfoLevel
+	util.SetLogLevelOverride(&info)
+	util.SetDebugTargets([]string{"Antigravity"}, []string{"auth-1"})
+	t.Cleanup(func() {
+		util.SetDebugTargets(nil, nil)
+		util.SetLogLevelOverride(nil)
+		log.SetLevel(previous)
+	})
+
+	if log.GetLevel() != log.DebugLevel {
+		t.Fatalf("logger level = %s, want debug while targets are set", log.GetLevel())
+	}
+
+	MarkDebugRequest(WithRequestID(context.Background(), "req-target"), "antigravity", "other")
+	MarkDebugRequest(WithRequestID(context.Background(), "req-other"), "codex", "auth-2")
+
+	cases := []struct {
+		name  string
+		level log.Level
+		data  log.Fields
+		want  bool
+	}{
+		{"info always visible", log.InfoLevel, log.Fields{}, true},
+		{"untargeted debug hidden", log.DebugLevel, log.Fields{}, false},
+		{"marked request visible", log.DebugLevel, log.Fields{"request_id": "req-target"}, true},
+		{"unmarked request hidden", log.DebugLevel, log.Fields{"request_id": "req-other"}, false},
+		{"auth field visible", log.DebugLevel, log.Fields{"auth_id": "AUTH-1"}, true},
+	}
+	for _, tc := range cases {
+		entry := &log.Entry{Logger: log.StandardLogger(), Level: tc.level, Data: tc.data}
+		if got := entryVisible(entry); got != tc.want {
+			t.Errorf("%s: visible = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+
+	util.SetDebugTargets(nil, nil)
+	if log.GetLevel() != log.InfoLevel {
+		t.Fatalf("logger level = %s, want info after clearing targets", log.GetLevel())
+	}
+}
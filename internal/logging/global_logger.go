@@ -25,7 +25,8 @@ var (
 )
 
 // LogFormatter defines a custom log format for logrus.
-// This formatter adds timestamp, level, request ID, and source location to each log entry.
+// This formatter adds timestamp, level, request ID, and source location to each log entry,
+// and masks credentials in the rendered line so they never reach stdout or the log files.
 // Format: [2025-12-23 20:14:04] [debug] [manager.go:524] | a1b2c3d4 | Use API key sk-9...0RHO for model gpt-5.2
 type LogFormatter struct{}
 
@@ -33,7 +34,11 @@ type LogFormatter struct{}
 var logFieldOrder = []string{"provider", "model", "mode", "budget", "level", "original_mode", "original_value", "min", "max", "clamped_to", "error"}
 
 // Format renders a single log entry with custom formatting.
+// Entries hidden by targeted debug filters render as nothing.
 func (m *LogFormatter) Format(entry *log.Entry) ([]byte, error) {
+	if !entryVisible(entry) {
+		return nil, nil
+	}
 	var buffer *bytes.Buffer
 	if entry.Buffer != nil {
 		buffer = entry.Buffer
@@ -75,7 +80,7 @@ func (m *LogFormatter) Format(entry *log.Entry) ([]byte, error) {
 	} else {
 		formatted = fmt.Sprintf("[%s] [%s] [%s] %s%s\n", timestamp, reqID, levelStr, message, fieldsStr)
 	}
-	buffer.WriteString(formatted)
+	buffer.Write(util.RedactSensitiveText([]byte(formatted)))
 
 	return buffer.Bytes(), nil
 }
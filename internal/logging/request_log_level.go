@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// ginRequestLogProviderKey is the Gin context key for the upstream provider serving the request.
+const ginRequestLogProviderKey = "__request_log_provider__"
+
+// SetGinRequestLogProvider records the upstream provider so request log levels can be resolved per provider.
+func SetGinRequestLogProvider(c *gin.Context, provider string) {
+	if c != nil && provider != "" {
+		c.Set(ginRequestLogProviderKey, provider)
+	}
+}
+
+// GetGinRequestLogProvider returns the upstream provider recorded for the request, if any.
+func GetGinRequestLogProvider(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	if value, exists := c.Get(ginRequestLogProviderKey); exists {
+		if provider, ok := value.(string); ok {
+			return provider
+		}
+	}
+	return ""
+}
+
+// ClientKeyIdentifiers returns the identifiers a per-key override may match: the
+// authenticated API key and, for managed keys, its key ID.
+func ClientKeyIdentifiers(c *gin.Context) []string {
+	if c == nil {
+		return nil
+	}
+	var out []string
+	if value, exists := c.Get("apiKey"); exists {
+		if key, ok := value.(string); ok && key != "" {
+			out = append(out, key)
+		}
+	}
+	if value, exists := c.Get("accessMetadata"); exists {
+		if metadata, ok := value.(map[string]string); ok && metadata["key_id"] != "" {
+			out = append(out, metadata["key_id"])
+		}
+	}
+	return out
+}
+
+// ClientKeyRefs returns the identifiers a per-key request log level may be configured
+// under: the managed key ID and the digest of the authenticated API key. Unlike
+// ClientKeyIdentifiers it never returns the plaintext key.
+func ClientKeyRefs(c *gin.Context) []string {
+	if c == nil {
+		return nil
+	}
+	var out []string
+	if value, exists := c.Get("accessMetadata"); exists {
+		if metadata, ok := value.(map[string]string); ok && metadata["key_id"] != "" {
+			out = append(out, metadata["key_id"])
+		}
+	}
+	if value, exists := c.Get("apiKey"); exists {
+		if key, ok := value.(string); ok && key != "" {
+			out = append(out, config.RequestLogKeyRef(key))
+		}
+	}
+	return out
+}
+
+// ResolveRequestLogLevel returns the request log level for the Gin request, taking the
+// recorded upstream provider and the calling client key into account. Nothing is logged in
+// usage aggregation-only mode.
+func ResolveRequestLogLevel(c *gin.Context, cfg *config.SDKConfig) string {
+	if coreusage.AggregateOnly() {
+		return config.RequestLogLevelNone
+	}
+	if level := RequestCaptureOverride(); level != "" {
+		return level
+	}
+	if cfg == nil {
+		return config.RequestLogLevelNone
+	}
+	return cfg.RequestLogLevelFor(GetGinRequestLogProvider(c), ClientKeyRefs(c)...)
+}
+
+// requestCaptureOverride holds a runtime request log level applied to every request.
+var requestCaptureOverride atomic.Pointer[string]
+
+// SetRequestCaptureOverride forces the request log level for every request until cleared
+// with an empty level. The override is not persisted to the config file.
+func SetRequestCaptureOverride(level string) {
+	if level == "" {
+		requestCaptureOverride.Store(nil)
+		return
+	}
+	requestCaptureOverride.Store(&level)
+}
+
+// RequestCaptureOverride returns the runtime request log level override, if any.
+func RequestCaptureOverride() string {
+	if level := requestCaptureOverride.Load(); level != nil {
+		return *level
+	}
+	return ""
+}
+
+// RequestCaptureActive reports whether the request logger should be enabled, honouring the
+// runtime override before the configured levels.
+func RequestCaptureActive(cfg *config.SDKConfig) bool {
+	if coreusage.AggregateOnly() {
+		return false
+	}
+	if level := RequestCaptureOverride(); level != "" {
+		return level != config.RequestLogLevelNone
+	}
+	return cfg.RequestLogActive()
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestResolveRequestLogLevelPrecedence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.SDKConfig{
+		RequestLog: true,
+		RequestLogLevels: config.RequestLogLevels{
+			Providers: map[string]string{"antigravity": "metadata"},
+			Keys:      map[string]string{config.RequestLogKeyRef("key-debug"): "full", "key_123": "none"},
+		},
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if got := ResolveRequestLogLevel(c, cfg); got != config.RequestLogLevelFull {
+		t.Fatalf("expected request-log fallback to full, got %q", got)
+	}
+
+	SetGinRequestLogProvider(c, "Antigravity")
+	if got := ResolveRequestLogLevel(c, cfg); got != config.RequestLogLevelMetadata {
+		t.Fatalf("expected provider override, got %q", got)
+	}
+
+	c.Set("apiKey", "key-debug")
+	if got := ResolveRequestLogLevel(c, cfg); got != config.RequestLogLevelFull {
+		t.Fatalf("expected key override to win over provider, got %q", got)
+	}
+
+	c.Set("apiKey", "sk-cpa-secret")
+	c.Set("accessMetadata", map[string]string{"key_id": "key_123"})
+	if got := ResolveRequestLogLevel(c, cfg); got != config.RequestLogLevelNone {
+		t.Fatalf("expected managed key id override, got %q", got)
+	}
+}
+
+func TestSanitizeRequestLogLevelsHashesPlaintextKeys(t *testing.T) {
+	cfg := &config.SDKConfig{
+		RequestLogLevels: config.RequestLogLevels{
+			Keys: map[string]string{"key-debug": "full", "key_123": "none"},
+		},
+	}
+	if !cfg.SanitizeRequestLogLevels() {
+		t.Fatal("expected plaintext key to be rewritten")
+	}
+	if _, ok := cfg.RequestLogLevels.Keys["key-debug"]; ok {
+		t.Fatal("plaintext key must not remain in the config")
+	}
+	if cfg.RequestLogLevels.Keys[config.RequestLogKeyRef("key-debug")] != "full" || cfg.RequestLogLevels.Keys["key_123"] != "none" {
+		t.Fatalf("unexpected keys after sanitize: %v", cfg.RequestLogLevels.Keys)
+	}
+	if cfg.SanitizeRequestLogLevels() {
+		t.Fatal("expected sanitize to be idempotent")
+	}
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", "key-debug")
+	if got := ResolveRequestLogLevel(c, cfg); got != config.RequestLogLevelFull {
+		t.Fatalf("expected digest override to match, got %q", got)
+	}
+}
+
+func TestRequestLogActive(t *testing.T) {
+	cfg := &config.SDKConfig{}
+	if cfg.RequestLogActive() {
+		t.Fatal("expected logging inactive by default")
+	}
+	cfg.RequestLogLevels.Providers = map[string]string{"codex": "metadata"}
+	if !cfg.RequestLogActive() {
+		t.Fatal("expected provider override to activate logging")
+	}
+}
+
+func TestSampleRequestLog(t *testing.T) {
+	sampling := config.RequestLogSampling{Enabled: true, SuccessRate: 0, ErrorRate: 1, SlowThresholdSeconds: 5}
+	if SampleRequestLog(sampling, false, time.Second) {
+		t.Fatal("expected fast success to be dropped at rate 0")
+	}
+	if !SampleRequestLog(sampling, true, time.Second) {
+		t.Fatal("expected error to be kept at rate 1")
+	}
+	if !SampleRequestLog(sampling, false, 6*time.Second) {
+		t.Fatal("expected slow request to be kept")
+	}
+	if !SampleRequestLog(config.RequestLogSampling{}, false, 0) {
+		t.Fatal("expected disabled sampling to keep everything")
+	}
+}
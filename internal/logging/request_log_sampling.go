@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// SampleRequestLog reports whether a finished request should be written to the request log.
+// Requests at or above the slow threshold are always kept; otherwise successes and failures
+// are kept with their configured probabilities.
+func SampleRequestLog(sampling config.RequestLogSampling, failed bool, elapsed time.Duration) bool {
+	if !sampling.Enabled {
+		return true
+	}
+	if sampling.SlowThresholdSeconds > 0 && elapsed >= time.Duration(sampling.SlowThresholdSeconds*float64(time.Second)) {
+		return true
+	}
+	rate := sampling.SuccessRate
+	if failed {
+		rate = sampling.ErrorRate
+	}
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	default:
+		return rand.Float64() < rate
+	}
+}
+
+// ConfigRequestLogPolicy resolves request log levels and sampling from the live SDK configuration.
+type ConfigRequestLogPolicy func() *config.SDKConfig
+
+// Level returns the request log level for the provider and client key of the request.
+func (p ConfigRequestLogPolicy) Level(c *gin.Context) string {
+	return ResolveRequestLogLevel(c, p())
+}
+
+// Keep applies the configured sampling rules to a finished request.
+func (p ConfigRequestLogPolicy) Keep(_ *gin.Context, failed bool, elapsed time.Duration) bool {
+	cfg := p()
+	if cfg == nil {
+		return true
+	}
+	return SampleRequestLog(cfg.RequestLogSampling, failed, elapsed)
+}
@@ -500,7 +500,7 @@ func (l *FileRequestLogger) writeRequestBodyTempFile(body []byte) (string, error
 	}
 	tmpPath := tmpFile.Name()
 
-	if _, errCopy := io.Copy(tmpFile, bytes.NewReader(body)); errCopy != nil {
+	if _, errCopy := io.Copy(tmpFile, bytes.NewReader(util.RedactSensitiveText(body))); errCopy != nil {
 		_ = tmpFile.Close()
 		_ = os.Remove(tmpPath)
 		return "", errCopy
@@ -543,7 +543,7 @@ func (l *FileRequestLogger) writeNonStreamingLog(
 	if errWrite := writeAPISection(w, "=== API RESPONSE ===\n", "=== API RESPONSE", apiResponse, apiResponseTimestamp); errWrite != nil {
 		return errWrite
 	}
-	return writeResponseSection(w, statusCode, true, responseHeaders, bytes.NewReader(response), decompressErr, true)
+	return writeResponseSection(w, statusCode, true, responseHeaders, bytes.NewReader(util.RedactSensitiveText(response)), decompressErr, true)
 }
 
 func writeRequestInfoWithBody(
@@ -604,7 +604,7 @@ func writeRequestInfoWithBody(
 		if errClose := bodyFile.Close(); errClose != nil {
 			log.WithError(errClose).Warn("failed to close request body temp file")
 		}
-	} else if _, errWrite := w.Write(body); errWrite != nil {
+	} else if _, errWrite := w.Write(util.RedactSensitiveText(body)); errWrite != nil {
 		return errWrite
 	}
 
@@ -618,6 +618,7 @@ func writeAPISection(w io.Writer, sectionHeader string, sectionPrefix string, pa
 	if len(payload) == 0 {
 		return nil
 	}
+	payload = util.RedactSensitiveText(payload)
 
 	if bytes.HasPrefix(payload, []byte(sectionPrefix)) {
 		if _, errWrite := w.Write(payload); errWrite != nil {
@@ -687,7 +688,7 @@ func writeResponseSection(w io.Writer, statusCode int, statusWritten bool, respo
 	if responseHeaders != nil {
 		for key, values := range responseHeaders {
 			for _, value := range values {
-				if _, errWrite := io.WriteString(w, fmt.Sprintf("%s: %s\n", key, value)); errWrite != nil {
+				if _, errWrite := io.WriteString(w, fmt.Sprintf("%s: %s\n", key, util.MaskSensitiveHeaderValue(key, value))); errWrite != nil {
 					return errWrite
 				}
 			}
@@ -1024,9 +1025,8 @@ func (w *FileStreamingLogWriter) WriteChunkAsync(chunk []byte) {
 		return
 	}
 
-	// Make a copy of the chunk to avoid data races
-	chunkCopy := make([]byte, len(chunk))
-	copy(chunkCopy, chunk)
+	// Redaction returns a fresh slice, which also avoids data races on the caller's buffer
+	chunkCopy := util.RedactSensitiveText(chunk)
 
 	// Non-blocking send
 	select {
@@ -1145,6 +1145,12 @@ func (w *FileStreamingLogWriter) Close() error {
 	return writeErr
 }
 
+// Discard drops the streaming log without writing it, e.g. when the request was not sampled.
+func (w *FileStreamingLogWriter) Discard() error {
+	w.logFilePath = ""
+	return w.Close()
+}
+
 // asyncWriter runs in a goroutine to buffer chunks from the channel.
 // It continuously reads chunks from the channel and appends them to a temp file for later assembly.
 func (w *FileStreamingLogWriter) asyncWriter() {
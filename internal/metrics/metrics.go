@@ -0,0 +1,292 @@
+// Package metrics collects request, upstream and token metrics and renders them in the
+// Prometheus text exposition format. It keeps its own small registry instead of depending
+// on the Prometheus client library; gauges computed at scrape time, such as quota
+// percentages, are written by the caller through WriteGauge.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// latencyBuckets are the histogram upper bounds in seconds. Upstream requests include long
+// streamed generations, so the buckets reach several minutes.
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Collector holds the metrics recorded since startup.
+type Collector struct {
+	httpRequests     *counterVec
+	httpDuration     *histogramVec
+	upstreamRequests *counterVec
+	upstreamDuration *histogramVec
+	tokens           *counterVec
+	normalizations   *counterVec
+	streamLeaks      *counterVec
+}
+
+var defaultCollector = NewCollector()
+
+func init() {
+	coreusage.RegisterPlugin(defaultCollector)
+}
+
+// Default returns the collector fed by the usage plugin and the HTTP middleware.
+func Default() *Collector { return defaultCollector }
+
+// NewCollector constructs an empty collector.
+func NewCollector() *Collector {
+	return &Collector{
+		httpRequests:     newCounterVec("cliproxy_http_requests_total", "Inbound HTTP requests by method, route and status code.", "method", "route", "code"),
+		httpDuration:     newHistogramVec("cliproxy_http_request_duration_seconds", "Inbound HTTP request latency by method and route.", "method", "route"),
+		upstreamRequests: newCounterVec("cliproxy_upstream_requests_total", "Upstream provider requests by provider, model and status code.", "provider", "model", "code"),
+		upstreamDuration: newHistogramVec("cliproxy_upstream_request_duration_seconds", "Upstream provider request latency by provider and model.", "provider", "model"),
+		tokens:           newCounterVec("cliproxy_tokens_total", "Tokens reported by upstream providers by provider, model and type.", "provider", "model", "type"),
+		normalizations:   newCounterVec("cliproxy_request_normalizations_total", "Inbound requests repaired by request normalization by format and quirk.", "format", "quirk"),
+		streamLeaks:      newCounterVec("cliproxy_stream_leaks_total", "Leaked streams found by the leak watchdog by reason and action taken.", "reason", "action"),
+	}
+}
+
+// Middleware records every inbound request. Unmatched routes share one label value so
+// scanners cannot grow the label set.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		defaultCollector.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// ObserveHTTP records one inbound request.
+func (c *Collector) ObserveHTTP(method, route string, code int, duration time.Duration) {
+	c.httpRequests.add(1, method, route, strconv.Itoa(code))
+	c.httpDuration.observe(duration.Seconds(), method, route)
+}
+
+// ObserveNormalization records one inbound request of format repaired for quirk.
+func (c *Collector) ObserveNormalization(format, quirk string) {
+	c.normalizations.add(1, format, quirk)
+}
+
+// ObserveStreamLeak records one leaked stream found for reason and the action taken on it.
+func (c *Collector) ObserveStreamLeak(reason, action string) {
+	c.streamLeaks.add(1, reason, action)
+}
+
+// HandleUsage implements coreusage.Plugin and records one upstream request.
+func (c *Collector) HandleUsage(_ context.Context, record coreusage.Record) {
+	code := "error"
+	if record.StatusCode > 0 {
+		code = strconv.Itoa(record.StatusCode)
+	}
+	c.upstreamRequests.add(1, record.Provider, record.Model, code)
+	if record.Latency > 0 {
+		c.upstreamDuration.observe(record.Latency.Seconds(), record.Provider, record.Model)
+	}
+	for _, tokens := range []struct {
+		kind  string
+		count int64
+	}{
+		{"input", record.Detail.InputTokens},
+		{"output", record.Detail.OutputTokens},
+		{"reasoning", record.Detail.ReasoningTokens},
+		{"cached", record.Detail.CachedTokens},
+	} {
+		if tokens.count > 0 {
+			c.tokens.add(float64(tokens.count), record.Provider, record.Model, tokens.kind)
+		}
+	}
+}
+
+// Write renders every collected metric.
+func (c *Collector) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	c.httpRequests.write(bw)
+	c.httpDuration.write(bw)
+	c.upstreamRequests.write(bw)
+	c.upstreamDuration.write(bw)
+	c.tokens.write(bw)
+	c.normalizations.write(bw)
+	c.streamLeaks.write(bw)
+	return bw.Flush()
+}
+
+// GaugeSample is one labelled value of a gauge. Labels are name/value pairs.
+type GaugeSample struct {
+	Labels []string
+	Value  float64
+}
+
+// WriteGauge renders a gauge computed at scrape time.
+func WriteGauge(w io.Writer, name, help string, samples []GaugeSample) {
+	if len(samples) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, sample := range samples {
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(sample.Labels), formatValue(sample.Value))
+	}
+}
+
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+}
+
+func (v *counterVec) add(delta float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	entry := v.values[key]
+	if entry == nil {
+		entry = &counterValue{labels: pairLabels(v.labels, labelValues)}
+		v.values[key] = entry
+	}
+	entry.value += delta
+}
+
+func (v *counterVec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", v.name, v.help, v.name)
+	for _, key := range sortedKeys(v.values) {
+		entry := v.values[key]
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(entry.labels), formatValue(entry.value))
+	}
+}
+
+type histogramVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogramVec(name, help string, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, values: make(map[string]*histogramValue)}
+}
+
+func (v *histogramVec) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	entry := v.values[key]
+	if entry == nil {
+		entry = &histogramValue{labels: pairLabels(v.labels, labelValues), counts: make([]uint64, len(latencyBuckets))}
+		v.values[key] = entry
+	}
+	for i, bound := range latencyBuckets {
+		if value <= bound {
+			entry.counts[i]++
+		}
+	}
+	entry.sum += value
+	entry.count++
+}
+
+func (v *histogramVec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", v.name, v.help, v.name)
+	for _, key := range sortedKeys(v.values) {
+		entry := v.values[key]
+		for i, bound := range latencyBuckets {
+			labels := append(append([]string(nil), entry.labels...), "le", formatValue(bound))
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, formatLabels(labels), entry.counts[i])
+		}
+		labels := append(append([]string(nil), entry.labels...), "le", "+Inf")
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, formatLabels(labels), entry.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", v.name, formatLabels(entry.labels), formatValue(entry.sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", v.name, formatLabels(entry.labels), entry.count)
+	}
+}
+
+func pairLabels(names, values []string) []string {
+	out := make([]string, 0, 2*len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		out = append(out, name, value)
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatLabels renders name/value pairs as {a="x",b="y"}, escaping values as the text
+// format requires.
+func formatLabels(pairs []string) string {
+	if len(pairs) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
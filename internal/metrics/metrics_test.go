@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestCollectorWritesPrometheusText(t *testing.T) {
+	c := NewCollector()
+	c.ObserveHTTP("POST", "/v1/chat/completions", 200, 300*time.Millisecond)
+	c.HandleUsage(context.Background(), coreusage.Record{
+		Provider:   "antigravity",
+		Model:      "gemini-3-pro",
+		StatusCode: 200,
+		Latency:    2 * time.Second,
+		Detail:     coreusage.Detail{InputTokens: 120, OutputTokens: 30},
+	})
+	c.HandleUsage(context.Background(), coreusage.Record{Provider: "antigravity", Model: "gemini-3-pro", Failed: true, StatusCode: 429})
+	c.HandleUsage(context.Background(), coreusage.Record{Provider: "codex", Model: `gpt-"5"`, Failed: true})
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	WriteGauge(&buf, "cliproxy_auth_quota_percent", "Remaining quota.", []GaugeSample{{Labels: []string{"auth_index", "a1", "model", "gemini-3-pro"}, Value: 42.5}})
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE cliproxy_http_requests_total counter\n",
+		`cliproxy_http_requests_total{method="POST",route="/v1/chat/completions",code="200"} 1`,
+		`cliproxy_http_request_duration_seconds_bucket{method="POST",route="/v1/chat/completions",le="0.25"} 0`,
+		`cliproxy_http_request_duration_seconds_bucket{method="POST",route="/v1/chat/completions",le="0.5"} 1`,
+		`cliproxy_upstream_requests_total{provider="antigravity",model="gemini-3-pro",code="429"} 1`,
+		`cliproxy_upstream_requests_total{provider="codex",model="gpt-\"5\"",code="error"} 1`,
+		`cliproxy_upstream_request_duration_seconds_count{provider="antigravity",model="gemini-3-pro"} 1`,
+		`cliproxy_upstream_request_duration_seconds_bucket{provider="antigravity",model="gemini-3-pro",le="+Inf"} 1`,
+		`cliproxy_tokens_total{provider="antigravity",model="gemini-3-pro",type="input"} 120`,
+		"# TYPE cliproxy_auth_quota_percent gauge\n",
+		`cliproxy_auth_quota_percent{auth_index="a1",model="gemini-3-pro"} 42.5`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `type="reasoning"`) {
+		t.Fatalf("zero token counts must not be exported:\n%s", out)
+	}
+}
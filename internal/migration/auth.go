@@ -0,0 +1,95 @@
+package migration
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuthVersionKey stores the schema version inside auth metadata.
+const AuthVersionKey = "cliproxy_schema_version"
+
+// legacyExpireKeys are expiry fields written by older logins, in lookup priority order.
+var legacyExpireKeys = []string{"expire", "expires_at", "expiresAt", "expiry"}
+
+// AuthSteps lists auth metadata migrations in version order. Append new steps; never renumber.
+var AuthSteps = []Step{
+	{Version: 1, Name: "normalize-expiry", Apply: normalizeAuthExpiry},
+}
+
+// Report summarises a migration pass.
+type Report struct {
+	Scanned  int
+	Migrated int
+	Failed   int
+}
+
+// NewAuthRunner returns the runner for auth metadata. Backups of migrated auths are written
+// under backupDir.
+func NewAuthRunner(backupDir string) *Runner {
+	return &Runner{Kind: "auth", VersionKey: AuthVersionKey, Steps: AuthSteps, BaseDir: backupDir}
+}
+
+// normalizeAuthExpiry makes "expired" the canonical RFC3339 expiry field.
+func normalizeAuthExpiry(doc map[string]any) bool {
+	if current, ok := doc["expired"]; ok {
+		if _, isString := current.(string); isString {
+			return false
+		}
+		if ts, okTime := parseLegacyTime(current); okTime {
+			doc["expired"] = ts.UTC().Format(time.RFC3339)
+			return true
+		}
+		return false
+	}
+	for _, key := range legacyExpireKeys {
+		if ts, ok := parseLegacyTime(doc[key]); ok {
+			doc["expired"] = ts.UTC().Format(time.RFC3339)
+			return true
+		}
+	}
+	return false
+}
+
+func parseLegacyTime(v any) (time.Time, bool) {
+	if s, ok := v.(string); ok {
+		s = strings.TrimSpace(s)
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+			if ts, err := time.Parse(layout, s); err == nil {
+				return ts, true
+			}
+		}
+	}
+	raw, ok := int64Of(v)
+	if !ok || raw <= 0 {
+		return time.Time{}, false
+	}
+	if raw >= 1_000_000_000_000 {
+		return time.UnixMilli(raw), true
+	}
+	return time.Unix(raw, 0), true
+}
+
+func int64Of(v any) (int64, bool) {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, true
+		}
+		if f, err := value.Float64(); err == nil {
+			return int64(f), true
+		}
+	case float64:
+		return int64(value), true
+	case int64:
+		return value, true
+	case int:
+		return int64(value), true
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
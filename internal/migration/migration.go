@@ -0,0 +1,186 @@
+// Package migration upgrades persisted auth and quota JSON documents to the current schema
+// at startup. Each document records the schema version it was last migrated to; pending
+// steps run in order and the original is backed up before anything is rewritten.
+package migration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BackupDirName is the directory, created under the runner's base directory, that holds
+// pre-migration copies. Backups use a ".bak" suffix so auth loaders and watchers that only
+// read "*.json" ignore them.
+const BackupDirName = ".migrations"
+
+// Step upgrades a decoded JSON document to Version. Apply must be idempotent and
+// reports whether it changed the document.
+type Step struct {
+	Version int
+	Name    string
+	Apply   func(doc map[string]any) bool
+}
+
+// Runner applies an ordered list of steps to JSON documents of one kind.
+type Runner struct {
+	// Kind names the document family in logs (e.g. "auth", "quota").
+	Kind string
+	// VersionKey is the top-level field storing the document's schema version.
+	VersionKey string
+	// Steps are applied in ascending Version order.
+	Steps []Step
+	// BaseDir anchors backup paths so files in nested directories keep their layout.
+	// When empty, backups are written next to each migrated file.
+	BaseDir string
+
+	stamp string
+}
+
+// Latest returns the schema version produced by the final step.
+func (r *Runner) Latest() int {
+	latest := 0
+	for _, step := range r.Steps {
+		if step.Version > latest {
+			latest = step.Version
+		}
+	}
+	return latest
+}
+
+// MigrateDocument applies pending steps to doc in place and reports whether any step changed
+// it, together with the version it started from. The version field is only stamped when the
+// content changed, so documents that need no upgrade are never rewritten.
+func (r *Runner) MigrateDocument(doc map[string]any) (bool, int) {
+	current := versionOf(doc[r.VersionKey])
+	latest := r.Latest()
+	if current >= latest {
+		return false, current
+	}
+	changed := false
+	for _, step := range r.Steps {
+		if step.Version <= current {
+			continue
+		}
+		if step.Apply != nil && step.Apply(doc) {
+			log.Debugf("%s migration %d (%s) applied", r.Kind, step.Version, step.Name)
+			changed = true
+		}
+	}
+	if changed {
+		doc[r.VersionKey] = latest
+	}
+	return changed, current
+}
+
+// MigrateFile upgrades the file at path in place. It returns true when the file was rewritten,
+// which only happens when a step changed its content; the original is backed up first.
+func (r *Runner) MigrateFile(path string) (bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("migration: read %s: %w", path, err)
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return false, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err = decoder.Decode(&doc); err != nil || doc == nil {
+		// Not a JSON object: leave it for the regular loaders to report.
+		return false, nil
+	}
+
+	if current := versionOf(doc[r.VersionKey]); current > r.Latest() {
+		log.Warnf("%s file %s has schema version %d, newer than supported %d", r.Kind, path, current, r.Latest())
+		return false, nil
+	}
+	changed, from := r.MigrateDocument(doc)
+	if !changed {
+		return false, nil
+	}
+
+	name := path
+	if r.BaseDir != "" {
+		if rel, errRel := filepath.Rel(r.BaseDir, path); errRel == nil {
+			name = rel
+		}
+	}
+	if err = r.Backup(name, raw); err != nil {
+		return false, err
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return false, fmt.Errorf("migration: marshal %s: %w", path, err)
+	}
+	if err = writeFileAtomic(path, out); err != nil {
+		return false, err
+	}
+	log.Infof("migrated %s file %s from schema version %d to %d", r.Kind, filepath.Base(path), from, r.Latest())
+	return true, nil
+}
+
+// Backup stores raw as name+".bak" under BaseDir/.migrations/<run timestamp>/. Names that
+// would escape the backup directory are reduced to their base name. Without a BaseDir the
+// backup is written next to name.
+func (r *Runner) Backup(name string, raw []byte) error {
+	base := r.BaseDir
+	rel := filepath.Clean(filepath.FromSlash(name))
+	if base == "" {
+		base = filepath.Dir(rel)
+		rel = filepath.Base(rel)
+	}
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filepath.Base(rel)
+	}
+	if r.stamp == "" {
+		r.stamp = time.Now().UTC().Format("20060102-150405")
+	}
+	target := filepath.Join(base, BackupDirName, r.stamp, rel+".bak")
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return fmt.Errorf("migration: create backup dir: %w", err)
+	}
+	if err := os.WriteFile(target, raw, 0o600); err != nil {
+		return fmt.Errorf("migration: write backup for %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := path + ".migrating"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return fmt.Errorf("migration: write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("migration: replace %s: %w", path, err)
+	}
+	return nil
+}
+
+func versionOf(v any) int {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return int(i)
+		}
+	case float64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
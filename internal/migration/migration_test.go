@@ -0,0 +1,100 @@
+package migration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateFileUpgradesAndBacksUp(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "team")
+	if err := os.MkdirAll(nested, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"type":"antigravity","expires_at":1700000000,"timestamp":1700000000}`
+	current := `{"type":"codex","expired":"2030-01-01T00:00:00Z"}`
+	legacyPath := filepath.Join(nested, "legacy.json")
+	currentPath := filepath.Join(dir, "current.json")
+	writeFile(t, legacyPath, legacy)
+	writeFile(t, currentPath, current)
+
+	runner := NewAuthRunner(dir)
+	migrated, err := runner.MigrateFile(legacyPath)
+	if err != nil || !migrated {
+		t.Fatalf("expected legacy file to be migrated, migrated=%v err=%v", migrated, err)
+	}
+	doc := readDoc(t, legacyPath)
+	if doc["expired"] != "2023-11-14T22:13:20Z" {
+		t.Fatalf("expired = %v", doc["expired"])
+	}
+	if doc["timestamp"] != float64(1700000000) {
+		t.Fatalf("timestamp must be left alone, got %v", doc["timestamp"])
+	}
+	if doc[AuthVersionKey] != float64(runner.Latest()) {
+		t.Fatalf("version = %v", doc[AuthVersionKey])
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, BackupDirName, "*", "team", "legacy.json.bak"))
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup of legacy.json, got %v", backups)
+	}
+	if raw, _ := os.ReadFile(backups[0]); string(raw) != legacy {
+		t.Fatalf("backup content mismatch: %s", raw)
+	}
+
+	if migrated, err = runner.MigrateFile(currentPath); err != nil || migrated {
+		t.Fatalf("file needing no change must not be rewritten, migrated=%v err=%v", migrated, err)
+	}
+	if raw, _ := os.ReadFile(currentPath); string(raw) != current {
+		t.Fatalf("unchanged file was rewritten: %s", raw)
+	}
+	if migrated, _ = runner.MigrateFile(legacyPath); migrated {
+		t.Fatal("second pass should be a no-op")
+	}
+}
+
+func TestMigrateFileSkipsNewerVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.json")
+	writeFile(t, path, `{"cliproxy_schema_version":999,"expire":1700000000}`)
+	runner := &Runner{Kind: "auth", VersionKey: AuthVersionKey, Steps: AuthSteps}
+	migrated, err := runner.MigrateFile(path)
+	if err != nil || migrated {
+		t.Fatalf("expected untouched file, migrated=%v err=%v", migrated, err)
+	}
+	if _, ok := readDoc(t, path)["expired"]; ok {
+		t.Fatal("newer file must not be rewritten")
+	}
+}
+
+func TestBackupKeepsNamesInsideBackupDir(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewAuthRunner(dir)
+	if err := runner.Backup("../outside.json", []byte("{}")); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, BackupDirName, "*", "outside.json.bak")); len(matches) != 1 {
+		t.Fatalf("expected backup inside %s, got %v", BackupDirName, matches)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readDoc(t *testing.T, path string) map[string]any {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
@@ -0,0 +1,24 @@
+package migration
+
+// QuotaVersionKey is the schema version field of the quota store file.
+const QuotaVersionKey = "schema_version"
+
+// QuotaSteps lists quota store migrations in version order. Append new steps; never renumber.
+var QuotaSteps = []Step{
+	{Version: 1, Name: "auth-quotas-map", Apply: ensureAuthQuotasMap},
+}
+
+// MigrateQuotaFile upgrades the quota store file at path, backing it up alongside.
+func MigrateQuotaFile(path string) (bool, error) {
+	runner := &Runner{Kind: "quota", VersionKey: QuotaVersionKey, Steps: QuotaSteps}
+	return runner.MigrateFile(path)
+}
+
+// ensureAuthQuotasMap stamps files written before the store was versioned. Their layout
+// matches version 1 apart from a possibly missing or null auth_quotas object.
+func ensureAuthQuotasMap(doc map[string]any) bool {
+	if _, ok := doc["auth_quotas"].(map[string]any); !ok {
+		doc["auth_quotas"] = map[string]any{}
+	}
+	return false
+}
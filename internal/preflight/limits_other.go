@@ -0,0 +1,6 @@
+//go:build !unix
+
+package preflight
+
+// openFileLimit reports no limit on platforms without rlimits.
+func openFileLimit() (soft, hard uint64, ok bool) { return 0, 0, false }
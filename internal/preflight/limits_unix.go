@@ -0,0 +1,14 @@
+//go:build unix
+
+package preflight
+
+import "syscall"
+
+// openFileLimit returns the soft and hard limits on open file descriptors.
+func openFileLimit() (soft, hard uint64, ok bool) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, 0, false
+	}
+	return uint64(limit.Cur), uint64(limit.Max), true
+}
@@ -0,0 +1,322 @@
+// Package preflight checks the environment the proxy starts in: file descriptor limits,
+// writable data directories, the local clock and outbound connectivity to the configured
+// providers. Problems are reported as warnings with a suggested fix, so they surface at
+// startup instead of as unexplained failures under load.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Check statuses.
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+)
+
+const (
+	// defaultMinOpenFiles is the open file limit below which a warning is reported. Every
+	// client connection and upstream request holds a descriptor.
+	defaultMinOpenFiles = 4096
+	// probeTimeout bounds each connectivity probe.
+	probeTimeout = 5 * time.Second
+	// maxClockOffset is the clock difference to a provider above which a warning is reported.
+	maxClockOffset = time.Minute
+)
+
+// defaultEndpoints are the upstream endpoints of providers whose credentials carry no base URL.
+var defaultEndpoints = map[string]string{
+	"gemini":      "https://generativelanguage.googleapis.com",
+	"gemini-cli":  "https://cloudcode-pa.googleapis.com",
+	"antigravity": "https://cloudcode-pa.googleapis.com",
+	"vertex":      "https://aiplatform.googleapis.com",
+	"claude":      "https://api.anthropic.com",
+	"codex":       "https://chatgpt.com",
+	"qwen":        "https://portal.qwen.ai",
+	"openrouter":  "https://openrouter.ai",
+}
+
+// Check is the outcome of one preflight check.
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report collects the checks of one preflight run.
+type Report struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Check   `json:"checks"`
+}
+
+// Warnings returns the checks that did not pass.
+func (r Report) Warnings() []Check {
+	var out []Check
+	for _, check := range r.Checks {
+		if check.Status != StatusOK {
+			out = append(out, check)
+		}
+	}
+	return out
+}
+
+// Log writes each warning to the log, or one line when every check passed.
+func (r Report) Log() {
+	warnings := r.Warnings()
+	if len(warnings) == 0 {
+		log.Infof("startup preflight passed (%d checks)", len(r.Checks))
+		return
+	}
+	for _, check := range warnings {
+		log.Warnf("startup preflight: %s: %s", check.Name, check.Message)
+	}
+}
+
+// Options configure a preflight run.
+type Options struct {
+	Config *config.Config
+	// Providers are the providers of the loaded credentials; their default endpoints are
+	// probed in addition to the base URLs in the config.
+	Providers []string
+	// HTTPClient overrides the client used for connectivity probes.
+	HTTPClient *http.Client
+}
+
+// Run performs every check. Connectivity probes run concurrently and are bounded by ctx.
+func Run(ctx context.Context, opts Options) Report {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	report := Report{CheckedAt: time.Now().UTC()}
+	report.Checks = append(report.Checks, checkOpenFiles(cfg.Preflight.MinOpenFiles))
+	report.Checks = append(report.Checks, checkDirectories(cfg)...)
+	if cfg.Preflight.SkipConnectivity {
+		report.Checks = append(report.Checks, checkClock(nil))
+		return report
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = util.SetProxy(&cfg.SDKConfig, &http.Client{})
+	}
+	probes := probeEndpoints(ctx, client, endpoints(cfg, opts.Providers))
+	report.Checks = append(report.Checks, checkClock(probes))
+	for _, probe := range probes {
+		report.Checks = append(report.Checks, probe.check())
+	}
+	return report
+}
+
+func checkOpenFiles(minimum int) Check {
+	check := Check{Name: "open-files", Status: StatusOK}
+	if minimum <= 0 {
+		minimum = defaultMinOpenFiles
+	}
+	soft, hard, ok := openFileLimit()
+	if !ok {
+		check.Message = "not limited on this platform"
+		return check
+	}
+	check.Message = fmt.Sprintf("limit %d", soft)
+	if soft < uint64(minimum) {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("open file limit is %d (hard limit %d); concurrent streams will fail with \"too many open files\" under load. Raise it to at least %d with `ulimit -n` or LimitNOFILE= in the systemd unit", soft, hard, minimum)
+	}
+	return check
+}
+
+// checkDirectories verifies that the directories the proxy writes to accept new files.
+func checkDirectories(cfg *config.Config) []Check {
+	type dir struct {
+		name, path, impact string
+	}
+	var dirs []dir
+	if authDir, err := util.ResolveAuthDir(cfg.AuthDir); err == nil && authDir != "" {
+		dirs = append(dirs, dir{"auth-dir", authDir, "new logins and refreshed tokens cannot be saved"})
+	}
+	if cfg.LoggingToFile {
+		dirs = append(dirs, dir{"log-dir", logging.ResolveLogDirectory(cfg), "logs are lost"})
+	}
+	if backend := strings.ToLower(strings.TrimSpace(cfg.QuotaStore.Backend)); backend == "" || backend == "file" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			dirs = append(dirs, dir{"quota-dir", filepath.Join(cacheDir, "cliproxy"), "quota snapshots do not survive a restart"})
+		}
+	}
+	checks := make([]Check, 0, len(dirs))
+	for _, d := range dirs {
+		check := Check{Name: d.name, Status: StatusOK, Message: d.path}
+		if err := probeWritable(d.path); err != nil {
+			check.Status = StatusWarning
+			check.Message = fmt.Sprintf("%s is not writable (%v); %s. Fix its ownership or permissions", d.path, err, d.impact)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func probeWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, ".preflight-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	_ = file.Close()
+	return os.Remove(name)
+}
+
+// endpoint is an upstream URL probed for connectivity.
+type endpoint struct {
+	url       string
+	providers []string
+}
+
+// endpoints lists the upstream URLs of the configured providers, one per host.
+func endpoints(cfg *config.Config, providers []string) []endpoint {
+	byHost := make(map[string]*endpoint)
+	add := func(provider, raw string) {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			raw = defaultEndpoints[provider]
+		}
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Host == "" {
+			return
+		}
+		key := parsed.Scheme + "://" + parsed.Host
+		entry := byHost[key]
+		if entry == nil {
+			entry = &endpoint{url: key}
+			byHost[key] = entry
+		}
+		for _, existing := range entry.providers {
+			if existing == provider {
+				return
+			}
+		}
+		entry.providers = append(entry.providers, provider)
+	}
+	for _, key := range cfg.GeminiKey {
+		add("gemini", key.BaseURL)
+	}
+	for _, key := range cfg.ClaudeKey {
+		add("claude", key.BaseURL)
+	}
+	for _, key := range cfg.CodexKey {
+		add("codex", key.BaseURL)
+	}
+	for _, key := range cfg.VertexCompatAPIKey {
+		add("vertex", key.BaseURL)
+	}
+	for _, compat := range cfg.OpenAICompatibility {
+		add(compat.Name, compat.BaseURL)
+	}
+	for _, provider := range providers {
+		if _, known := defaultEndpoints[provider]; known {
+			add(provider, "")
+		}
+	}
+	out := make([]endpoint, 0, len(byHost))
+	for _, entry := range byHost {
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].url < out[j].url })
+	return out
+}
+
+// probe is the outcome of one connectivity probe.
+type probe struct {
+	endpoint
+	err error
+	// offset is how far the server's clock is ahead of the local one; zero without a Date header.
+	offset time.Duration
+}
+
+func (p probe) check() Check {
+	check := Check{Name: "connectivity:" + p.url, Status: StatusOK, Message: strings.Join(p.providers, ", ")}
+	if p.err != nil {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("cannot reach %s (%s): %v. Check DNS, firewall rules and proxy-url", p.url, strings.Join(p.providers, ", "), p.err)
+	}
+	return check
+}
+
+func probeEndpoints(ctx context.Context, client *http.Client, targets []endpoint) []probe {
+	probes := make([]probe, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target endpoint) {
+			defer wg.Done()
+			probes[i] = probe{endpoint: target}
+			probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+			req, err := http.NewRequestWithContext(probeCtx, http.MethodHead, target.url, nil)
+			if err != nil {
+				probes[i].err = err
+				return
+			}
+			sent := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				probes[i].err = err
+				return
+			}
+			_ = resp.Body.Close()
+			received := time.Now()
+			if serverTime, errParse := http.ParseTime(resp.Header.Get("Date")); errParse == nil {
+				probes[i].offset = serverTime.Sub(sent.Add(received.Sub(sent) / 2))
+			}
+		}(i, target)
+	}
+	wg.Wait()
+	return probes
+}
+
+// checkClock flags a local clock that is implausible or differs from the providers' clocks.
+func checkClock(probes []probe) Check {
+	check := Check{Name: "clock", Status: StatusOK, Message: time.Now().UTC().Format(time.RFC3339)}
+	if time.Now().Year() < 2025 {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("local clock reads %s; TLS certificates and OAuth tokens will be rejected. Synchronise the clock with NTP", time.Now().UTC().Format(time.RFC3339))
+		return check
+	}
+	var worst probe
+	for _, p := range probes {
+		if p.err == nil && abs(p.offset) > abs(worst.offset) {
+			worst = p
+		}
+	}
+	if abs(worst.offset) > maxClockOffset {
+		direction := "behind"
+		if worst.offset < 0 {
+			direction = "ahead of"
+		}
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("local clock is %s %s %s; tokens may be refreshed too late or rejected as not yet valid. Synchronise the clock with NTP", abs(worst.offset).Round(time.Second), direction, worst.url)
+	}
+	return check
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
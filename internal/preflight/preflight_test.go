@@ -0,0 +1,65 @@
+package preflight
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestRunReportsUnreachableProvidersAndClockDrift(t *testing.T) {
+	skewed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(10*time.Minute).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer skewed.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.Preflight.MinOpenFiles = 1
+	cfg.QuotaStore.Backend = "redis"
+	cfg.ClaudeKey = []config.ClaudeKey{{BaseURL: skewed.URL + "/v1"}, {BaseURL: skewed.URL}}
+	cfg.OpenAICompatibility = []config.OpenAICompatibility{{Name: "local", BaseURL: unreachable.URL + "/v1"}}
+
+	report := Run(context.Background(), Options{Config: cfg, HTTPClient: skewed.Client()})
+	checks := make(map[string]Check)
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	if len(report.Checks) != 5 {
+		t.Fatalf("expected open-files, auth-dir, clock and one probe per host, got %+v", report.Checks)
+	}
+	for _, name := range []string{"open-files", "auth-dir", "connectivity:" + skewed.URL} {
+		if checks[name].Status != StatusOK {
+			t.Fatalf("%s: %+v", name, checks[name])
+		}
+	}
+	if probe := checks["connectivity:"+unreachable.URL]; probe.Status != StatusWarning || !strings.Contains(probe.Message, "local") {
+		t.Fatalf("unreachable provider not reported: %+v", probe)
+	}
+	if clock := checks["clock"]; clock.Status != StatusWarning || !strings.Contains(clock.Message, "behind "+skewed.URL) {
+		t.Fatalf("clock drift not reported: %+v", clock)
+	}
+	if len(report.Warnings()) != 2 {
+		t.Fatalf("expected 2 warnings, got %+v", report.Warnings())
+	}
+}
+
+func TestRunSkipsConnectivity(t *testing.T) {
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.Preflight.SkipConnectivity = true
+	cfg.Preflight.MinOpenFiles = 1
+	cfg.QuotaStore.Backend = "redis"
+	cfg.GeminiKey = []config.GeminiKey{{APIKey: "k"}}
+	report := Run(context.Background(), Options{Config: cfg, Providers: []string{"claude"}})
+	for _, check := range report.Checks {
+		if strings.HasPrefix(check.Name, "connectivity:") {
+			t.Fatalf("connectivity probed although skipped: %+v", check)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package pricing converts token usage into estimated USD costs using the model price table
+// from the configuration. Estimates follow the token counts reported upstream and ignore
+// provider-specific discounts such as batch or long-context pricing.
+package pricing
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// CostHeader is the response header carrying the estimated USD cost of a non-streaming
+// request whose model has a configured price.
+const CostHeader = "X-CLIProxy-Cost"
+
+var prices atomic.Pointer[[]config.ModelPrice]
+
+// Set installs the configured price table; it is called at startup and on every config
+// reload. Entries without a model are ignored.
+func Set(entries []config.ModelPrice) {
+	table := make([]config.ModelPrice, 0, len(entries))
+	for _, entry := range entries {
+		entry.Model = strings.TrimSpace(entry.Model)
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		if entry.Model == "" {
+			continue
+		}
+		table = append(table, entry)
+	}
+	prices.Store(&table)
+}
+
+// Lookup returns the first configured price without a provider whose model pattern matches
+// model.
+func Lookup(model string) (config.ModelPrice, bool) {
+	return LookupProvider("", model)
+}
+
+// LookupProvider returns the first configured price whose model pattern matches model and
+// whose provider is unset or equal to provider.
+func LookupProvider(provider, model string) (config.ModelPrice, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if table := prices.Load(); table != nil {
+		for _, price := range *table {
+			if price.Provider != "" && price.Provider != provider {
+				continue
+			}
+			if matchModelPattern(price.Model, model) {
+				return price, true
+			}
+		}
+	}
+	return config.ModelPrice{}, false
+}
+
+// Estimate returns the estimated USD cost of detail for model, and false when no price is
+// configured for it. Cached tokens are billed at the cached input price and reasoning tokens
+// at the output price.
+func Estimate(model string, detail coreusage.Detail) (float64, bool) {
+	price, ok := Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	cached := min(detail.CachedTokens, detail.InputTokens)
+	cachedPrice := price.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+	// Providers disagree on whether reasoning tokens are part of the output count; the total
+	// beyond the prompt covers both cases.
+	output := detail.OutputTokens + detail.ReasoningTokens
+	if beyondPrompt := detail.TotalTokens - detail.InputTokens; beyondPrompt > 0 && beyondPrompt < output {
+		output = beyondPrompt
+	}
+	cost := float64(detail.InputTokens-cached)*price.Input + float64(cached)*cachedPrice + float64(output)*price.Output
+	return Round(cost / 1e6), true
+}
+
+// Round rounds a USD amount to a millionth of a dollar.
+func Round(cost float64) float64 { return math.Round(cost*1e6) / 1e6 }
+
+// Format renders a USD amount for the cost header, e.g. "0.004215".
+func Format(cost float64) string { return strconv.FormatFloat(Round(cost), 'f', 6, 64) }
+
+// matchModelPattern matches model against pattern, where '*' matches any run of characters.
+func matchModelPattern(pattern, model string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == model
+	}
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(model, part)
+		if i < 0 {
+			return false
+		}
+		model = model[i+len(part):]
+	}
+	return strings.HasSuffix(model, last)
+}
@@ -0,0 +1,59 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestEstimate(t *testing.T) {
+	Set([]config.ModelPrice{
+		{Model: "claude-*", Input: 3, Output: 15, CachedInput: 0.3},
+		{Model: "gemini-*-pro", Input: 1.25, Output: 10},
+		{Model: " ", Input: 100},
+	})
+	defer Set(nil)
+
+	cases := []struct {
+		model  string
+		detail coreusage.Detail
+		want   float64
+		priced bool
+	}{
+		// 0.5M uncached * 3 + 0.5M cached * 0.3 + 0.1M * 15.
+		{"claude-sonnet-4-5", coreusage.Detail{InputTokens: 1_000_000, CachedTokens: 500_000, OutputTokens: 100_000}, 3.15, true},
+		// Gemini reports thoughts apart from candidates: both are billed as output.
+		{"gemini-2.5-pro", coreusage.Detail{InputTokens: 1000, OutputTokens: 100, ReasoningTokens: 400, TotalTokens: 1500}, 0.00625, true},
+		// OpenAI counts reasoning inside completion tokens: the total caps the output.
+		{"gemini-3-pro", coreusage.Detail{InputTokens: 1000, OutputTokens: 500, ReasoningTokens: 400, TotalTokens: 1500}, 0.00625, true},
+		{"gpt-5", coreusage.Detail{InputTokens: 1000}, 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := Estimate(tc.model, tc.detail)
+		if got != tc.want || ok != tc.priced {
+			t.Errorf("Estimate(%q, %+v) = %v, %v; want %v, %v", tc.model, tc.detail, got, ok, tc.want, tc.priced)
+		}
+	}
+	if got := Format(0.00625); got != "0.006250" {
+		t.Errorf("Format = %q", got)
+	}
+}
+
+func TestLookupProvider(t *testing.T) {
+	Set([]config.ModelPrice{
+		{Model: "gemini-2.5-flash", Provider: " Vertex ", Input: 0.3, Output: 2.5},
+		{Model: "gemini-*", Input: 0.5, Output: 3},
+	})
+	defer Set(nil)
+
+	if price, ok := LookupProvider("vertex", "gemini-2.5-flash"); !ok || price.Input != 0.3 {
+		t.Errorf("vertex price = %+v, %v", price, ok)
+	}
+	if price, ok := LookupProvider("gemini", "gemini-2.5-flash"); !ok || price.Input != 0.5 {
+		t.Errorf("gemini price = %+v, %v", price, ok)
+	}
+	if price, ok := Lookup("gemini-2.5-flash"); !ok || price.Input != 0.5 {
+		t.Errorf("provider-scoped entry used for estimates: %+v, %v", price, ok)
+	}
+}
@@ -0,0 +1,144 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	log "github.com/sirupsen/logrus"
+)
+
+const claudeOAuthBeta = "oauth-2025-04-20"
+
+var claudeUsageURL = "https://api.anthropic.com/api/oauth/usage"
+
+// claudeUsageWindows are the subscription windows of the usage endpoint that limit every
+// model. Model family windows such as seven_day_opus only limit part of the traffic.
+var claudeUsageWindows = []string{"five_hour", "seven_day"}
+
+// claudeHeaderWindows are the unified rate limit windows reported in response headers.
+var claudeHeaderWindows = []string{"5h", "7d"}
+
+// observedStore receives quota snapshots read from response headers; it is set
+// while a poller with a store runs.
+var observedStore atomic.Pointer[quota.Store]
+
+// isClaudeSubscription reports whether auth is a Claude Pro/Max OAuth login rather than a
+// configured API key, which has no subscription usage to poll.
+func isClaudeSubscription(auth *coreauth.Auth) bool {
+	return auth.Attributes == nil || strings.TrimSpace(auth.Attributes["api_key"]) == ""
+}
+
+func (p *Poller) pollClaude(ctx context.Context, auth *coreauth.Auth) {
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Anthropic-Beta", claudeOAuthBeta)
+
+	status, payload, errReq := p.doRequest(ctx, auth, http.MethodGet, claudeUsageURL, nil, headers)
+	if errReq != nil {
+		log.WithError(errReq).Warnf("quota poller: claude request failed (auth=%s)", auth.ID)
+		return
+	}
+	if status < http.StatusOK || status >= http.StatusMultipleChoices {
+		log.Warnf("quota poller: claude status=%d (auth=%s body=%s)", status, auth.ID, summarizePayload(payload))
+		return
+	}
+	models := extractClaudeQuota(payload)
+	if len(models) == 0 {
+		return
+	}
+	p.persistQuota(ctx, auth, "claude", models)
+}
+
+// extractClaudeQuota reads the subscription usage windows of GET /api/oauth/usage. The
+// remaining percentage is that of the most used window, which resets at its reset time.
+func extractClaudeQuota(payload []byte) map[string]quota.ModelQuota {
+	var root map[string]any
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return nil
+	}
+	var (
+		entry quota.ModelQuota
+		found bool
+	)
+	for _, name := range claudeUsageWindows {
+		window := toRecord(root[name])
+		if window == nil {
+			continue
+		}
+		used, ok := readFloat(window["utilization"])
+		if !ok {
+			continue
+		}
+		percent := clampPercent(100 - used)
+		if !found || percent < entry.Percent {
+			entry = quota.ModelQuota{Percent: percent, ResetTime: parseResetTime(window["resets_at"])}
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return map[string]quota.ModelQuota{"*": entry}
+}
+
+// extractClaudeHeaderQuota reads the unified rate limit headers Anthropic returns to
+// subscription logins: a 0-1 utilization and a Unix reset time per window. A rejected
+// status means no quota is left until the overall reset.
+func extractClaudeHeaderQuota(headers http.Header) map[string]quota.ModelQuota {
+	var (
+		entry quota.ModelQuota
+		found bool
+	)
+	for _, window := range claudeHeaderWindows {
+		prefix := "Anthropic-Ratelimit-Unified-" + window
+		used, err := strconv.ParseFloat(strings.TrimSpace(headers.Get(prefix+"-Utilization")), 64)
+		if err != nil {
+			continue
+		}
+		percent := clampPercent(100 - used*100)
+		if !found || percent < entry.Percent {
+			entry = quota.ModelQuota{Percent: percent, ResetTime: unixResetTime(headers.Get(prefix + "-Reset"))}
+			found = true
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(headers.Get("Anthropic-Ratelimit-Unified-Status")), "rejected") {
+		entry.Percent = 0
+		if reset := unixResetTime(headers.Get("Anthropic-Ratelimit-Unified-Reset")); !reset.IsZero() {
+			entry.ResetTime = reset
+		}
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	return map[string]quota.ModelQuota{"*": entry}
+}
+
+func unixResetTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// ObserveClaudeRateLimits records the quota reported in the rate limit headers of a Claude
+// response for authID, so routing sees usage changes between polls.
+func ObserveClaudeRateLimits(authID string, headers http.Header) {
+	store := observedStore.Load()
+	if store == nil || authID == "" || headers == nil {
+		return
+	}
+	models := extractClaudeHeaderQuota(headers)
+	if len(models) == 0 {
+		return
+	}
+	store.Set(authID, "claude", models, time.Now().UTC())
+}
@@ -0,0 +1,60 @@
+package quota
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func TestExtractClaudeQuotaUsesMostUsedWindow(t *testing.T) {
+	payload := []byte(`{
+		"five_hour": {"utilization": 12.0, "resets_at": "2026-10-16T04:59:59.943648+00:00"},
+		"seven_day": {"utilization": 64.0, "resets_at": "2026-10-20T08:00:00+00:00"},
+		"seven_day_opus": {"utilization": 90.0, "resets_at": null},
+		"seven_day_oauth_apps": null
+	}`)
+	models := extractClaudeQuota(payload)
+	entry, ok := models["*"]
+	if !ok || entry.Percent != 36 {
+		t.Fatalf("expected 36%% remaining from the seven day window, got %+v", models)
+	}
+	if want := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC); !entry.ResetTime.Equal(want) {
+		t.Fatalf("reset = %v, want %v", entry.ResetTime, want)
+	}
+	if extractClaudeQuota([]byte(`{"error":"unauthorized"}`)) != nil {
+		t.Fatal("a payload without usage windows must not report quota")
+	}
+}
+
+func TestObserveClaudeRateLimitsUpdatesStore(t *testing.T) {
+	store, err := quota.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	observedStore.Store(store)
+	t.Cleanup(func() { observedStore.Store(nil) })
+
+	headers := http.Header{}
+	headers.Set("Anthropic-Ratelimit-Unified-5h-Utilization", "0.25")
+	headers.Set("Anthropic-Ratelimit-Unified-5h-Reset", "1792000000")
+	headers.Set("Anthropic-Ratelimit-Unified-7d-Utilization", "0.1")
+	ObserveClaudeRateLimits("claude-1", headers)
+	got, ok := store.GetModelQuota("claude-1", "claude-sonnet-4-5")
+	if !ok || got.Percent != 75 || !got.ResetTime.Equal(time.Unix(1792000000, 0)) {
+		t.Fatalf("unexpected quota from headers: %+v %v", got, ok)
+	}
+
+	headers.Set("Anthropic-Ratelimit-Unified-Status", "rejected")
+	headers.Set("Anthropic-Ratelimit-Unified-Reset", "1792003600")
+	ObserveClaudeRateLimits("claude-1", headers)
+	if got, _ = store.GetModelQuota("claude-1", "claude-opus-4-1"); got.Percent != 0 || !got.ResetTime.Equal(time.Unix(1792003600, 0)) {
+		t.Fatalf("a rejected status must report no quota left: %+v", got)
+	}
+
+	ObserveClaudeRateLimits("claude-2", http.Header{})
+	if _, ok = store.GetEntry("claude-2"); ok {
+		t.Fatal("responses without rate limit headers must not create entries")
+	}
+}
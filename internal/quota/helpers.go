@@ -327,6 +327,49 @@ func codexWindowPercent(window map[string]any, allowed, limitReached bool) (floa
 	return clampPercent(100 - used), true
 }
 
+// extractOpenRouterQuota reads the credit limit of an OpenRouter key from GET /key. Keys
+// without a limit have no quota to report.
+func extractOpenRouterQuota(payload []byte, now time.Time) map[string]quota.ModelQuota {
+	var root map[string]any
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return nil
+	}
+	data := toRecord(root["data"])
+	if data == nil {
+		return nil
+	}
+	limit, okLimit := readFloat(data["limit"])
+	remaining, okRemain := readFloat(data["limit_remaining"])
+	if !okLimit || !okRemain || limit <= 0 {
+		return nil
+	}
+	entry := quota.ModelQuota{
+		Percent:   clampPercent(remaining / limit * 100),
+		ResetTime: openRouterLimitReset(normalizeString(data["limit_reset"]), now),
+	}
+	return map[string]quota.ModelQuota{"*": entry}
+}
+
+// openRouterLimitReset returns when a key limit with the given reset period is restored.
+// OpenRouter resets limits at midnight UTC; weeks start on Monday.
+func openRouterLimitReset(period string, now time.Time) time.Time {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch strings.ToLower(period) {
+	case "daily":
+		return midnight.AddDate(0, 0, 1)
+	case "weekly":
+		days := (8 - int(midnight.Weekday())) % 7
+		if days == 0 {
+			days = 7
+		}
+		return midnight.AddDate(0, 0, days)
+	case "monthly":
+		return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Time{}
+}
+
 func addModelQuota(dst map[string]quota.ModelQuota, model string, entry quota.ModelQuota) {
 	if dst == nil {
 		return
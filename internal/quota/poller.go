@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"strings"
 	"sync"
@@ -18,6 +19,7 @@ import (
 
 const (
 	defaultPollInterval   = 3 * time.Minute
+	minPollInterval       = 30 * time.Second
 	defaultRequestTimeout = 20 * time.Second
 	maxConcurrentRequests = 5
 )
@@ -37,6 +39,9 @@ var (
 	codexUsageURL     = "https://chatgpt.com/backend-api/wham/usage"
 )
 
+// polledProviders are the providers with a quota endpoint the poller calls.
+var polledProviders = []string{"antigravity", "codex", "gemini-cli", "claude", "openrouter"}
+
 // Poller periodically fetches quota data for stored auth entries.
 type Poller struct {
 	manager        *coreauth.Manager
@@ -45,7 +50,11 @@ type Poller struct {
 	requestTimeout time.Duration
 	maxConcurrency int
 	aliasMap       map[string]string
+	polling        config.QuotaPollingConfig
 	mu             sync.RWMutex
+
+	// lastPolled is when each auth was last polled; only the polling loop uses it.
+	lastPolled map[string]time.Time
 }
 
 // NewPoller constructs a quota poller.
@@ -60,6 +69,7 @@ func NewPoller(manager *coreauth.Manager, store *quota.Store) *Poller {
 		requestTimeout: defaultRequestTimeout,
 		maxConcurrency: maxConcurrentRequests,
 		aliasMap:       defaultAntigravityAliasMap(),
+		lastPolled:     make(map[string]time.Time),
 	}
 }
 
@@ -71,16 +81,58 @@ func (p *Poller) Store() *quota.Store {
 	return p.store
 }
 
-// SetConfig updates the alias map used for antigravity model matching.
+// SetConfig updates the alias map used for antigravity model matching and the polling
+// intervals. New intervals apply once the current wait ends.
 func (p *Poller) SetConfig(cfg *config.Config) {
 	if p == nil {
 		return
 	}
 	p.mu.Lock()
 	p.aliasMap = aliasMapFromConfig(cfg)
+	p.polling = config.QuotaPollingConfig{}
+	if cfg != nil {
+		p.polling = cfg.QuotaPolling
+	}
 	p.mu.Unlock()
 }
 
+// providerInterval returns how often auths of provider are polled, and false when polling
+// of provider is disabled.
+func (p *Poller) providerInterval(provider string) (time.Duration, bool) {
+	p.mu.RLock()
+	settings := p.polling.For(provider)
+	p.mu.RUnlock()
+	if settings.Disabled {
+		return 0, false
+	}
+	interval := p.interval
+	if settings.IntervalSeconds > 0 {
+		interval = time.Duration(settings.IntervalSeconds) * time.Second
+	}
+	return max(interval, minPollInterval), true
+}
+
+// nextDelay is the wait before the next poll: the shortest interval of any enabled provider
+// plus a random jitter.
+func (p *Poller) nextDelay() time.Duration {
+	delay := time.Duration(0)
+	for _, provider := range polledProviders {
+		if interval, ok := p.providerInterval(provider); ok && (delay == 0 || interval < delay) {
+			delay = interval
+		}
+	}
+	if delay == 0 {
+		delay = p.interval
+	}
+	p.mu.RLock()
+	jitter := time.Duration(p.polling.JitterSeconds) * time.Second
+	p.mu.RUnlock()
+	if jitter > 0 {
+		delay += rand.N(jitter)
+	}
+	return delay
+}
+
 // Start launches the polling loop in a background goroutine.
 func (p *Poller) Start(ctx context.Context) {
 	if p == nil {
@@ -89,8 +141,11 @@ func (p *Poller) Start(ctx context.Context) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if p.store != nil {
+		observedStore.Store(p.store)
+	}
 	go p.run(ctx)
-	log.Infof("quota poller started (interval=%s)", p.interval)
+	log.Infof("quota poller started (interval=%s)", p.nextDelay())
 }
 
 func (p *Poller) run(ctx context.Context) {
@@ -124,30 +179,58 @@ func (p *Poller) poll(ctx context.Context) time.Duration {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	delay := p.nextDelay()
 	auths := p.manager.List()
 	if len(auths) == 0 {
-		return p.interval
+		return delay
 	}
+	now := time.Now()
+	seen := make(map[string]struct{}, len(auths))
+	defer func() {
+		for authID := range p.lastPolled {
+			if _, ok := seen[authID]; !ok {
+				delete(p.lastPolled, authID)
+			}
+		}
+	}()
 	sem := make(chan struct{}, p.maxConcurrency)
 	var wg sync.WaitGroup
 	for _, auth := range auths {
 		if auth == nil || strings.TrimSpace(auth.ID) == "" {
 			continue
 		}
+		seen[auth.ID] = struct{}{}
 		if shouldSkipAuth(auth) {
 			continue
 		}
 		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
 		switch provider {
 		case "antigravity", "codex", "gemini-cli":
+		case "claude":
+			if !isClaudeSubscription(auth) {
+				continue
+			}
+		case "openrouter":
+			// OpenAI compatibility entries may be named "openrouter" but point anywhere.
+			if auth.Attributes != nil && auth.Attributes["compat_name"] != "" {
+				continue
+			}
 		default:
 			continue
 		}
+		interval, enabled := p.providerInterval(provider)
+		if !enabled {
+			continue
+		}
+		if last, ok := p.lastPolled[auth.ID]; ok && now.Sub(last) < interval {
+			continue
+		}
 		select {
 		case sem <- struct{}{}:
 		case <-ctx.Done():
-			return p.interval
+			return delay
 		}
+		p.lastPolled[auth.ID] = now
 		wg.Add(1)
 		authCopy := auth
 		go func() {
@@ -160,13 +243,17 @@ func (p *Poller) poll(ctx context.Context) time.Duration {
 				p.pollCodex(ctx, authCopy)
 			case "gemini-cli":
 				p.pollGeminiCLI(ctx, authCopy)
+			case "claude":
+				p.pollClaude(ctx, authCopy)
+			case "openrouter":
+				p.pollOpenRouter(ctx, authCopy)
 			default:
 				return
 			}
 		}()
 	}
 	wg.Wait()
-	return p.interval
+	return delay
 }
 
 func (p *Poller) pollAntigravity(ctx context.Context, auth *coreauth.Auth) {
@@ -260,6 +347,33 @@ func (p *Poller) pollGeminiCLI(ctx context.Context, auth *coreauth.Auth) {
 	p.persistQuota(ctx, auth, "gemini-cli", models)
 }
 
+func (p *Poller) pollOpenRouter(ctx context.Context, auth *coreauth.Auth) {
+	baseURL := config.DefaultOpenRouterBaseURL
+	if auth.Attributes != nil {
+		if base := strings.TrimSpace(auth.Attributes["base_url"]); base != "" {
+			baseURL = strings.TrimSuffix(base, "/")
+		}
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+
+	status, payload, errReq := p.doRequest(ctx, auth, http.MethodGet, baseURL+"/key", nil, headers)
+	if errReq != nil {
+		log.WithError(errReq).Warnf("quota poller: openrouter request failed (auth=%s)", auth.ID)
+		return
+	}
+	if status < http.StatusOK || status >= http.StatusMultipleChoices {
+		log.Warnf("quota poller: openrouter status=%d (auth=%s body=%s)", status, auth.ID, summarizePayload(payload))
+		return
+	}
+	models := extractOpenRouterQuota(payload, time.Now())
+	if len(models) == 0 {
+		return
+	}
+	p.persistQuota(ctx, auth, "openrouter", models)
+}
+
 func (p *Poller) doRequest(ctx context.Context, auth *coreauth.Auth, method, targetURL string, body []byte, headers http.Header) (int, []byte, error) {
 	if p == nil || p.manager == nil {
 		return 0, nil, errors.New("quota poller: manager not initialized")
@@ -0,0 +1,90 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestPollerIntervalsFollowConfig(t *testing.T) {
+	p := NewPoller(coreauth.NewManager(nil, nil, nil), nil)
+	p.SetConfig(&config.Config{QuotaPolling: config.QuotaPollingConfig{
+		IntervalSeconds: 600,
+		Providers: map[string]config.QuotaPollingProvider{
+			"Codex":      {IntervalSeconds: 60},
+			"openrouter": {IntervalSeconds: 5},
+			"claude":     {Disabled: true},
+		},
+	}})
+
+	if got, ok := p.providerInterval("antigravity"); !ok || got != 10*time.Minute {
+		t.Fatalf("antigravity interval = %v %v, want the 10m default", got, ok)
+	}
+	if got, _ := p.providerInterval("codex"); got != time.Minute {
+		t.Fatalf("codex interval = %v, want 1m", got)
+	}
+	if got, _ := p.providerInterval("openrouter"); got != minPollInterval {
+		t.Fatalf("openrouter interval = %v, want the %v floor", got, minPollInterval)
+	}
+	if _, ok := p.providerInterval("claude"); ok {
+		t.Fatal("claude polling must be disabled")
+	}
+	if got := p.nextDelay(); got != minPollInterval {
+		t.Fatalf("next delay = %v, want the shortest interval", got)
+	}
+
+	p.SetConfig(&config.Config{QuotaPolling: config.QuotaPollingConfig{JitterSeconds: 30}})
+	for i := 0; i < 20; i++ {
+		if got := p.nextDelay(); got < defaultPollInterval || got >= defaultPollInterval+30*time.Second {
+			t.Fatalf("jittered delay %v outside [%v, %v)", got, defaultPollInterval, defaultPollInterval+30*time.Second)
+		}
+	}
+}
+
+func TestPollerSkipsAuthsUntilTheirIntervalElapses(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "codex-1", Provider: "codex", Metadata: map[string]any{}},
+		{ID: "gemini-1", Provider: "gemini-cli", Metadata: map[string]any{}},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	p := NewPoller(manager, nil)
+	p.SetConfig(&config.Config{QuotaPolling: config.QuotaPollingConfig{
+		Providers: map[string]config.QuotaPollingProvider{"gemini-cli": {Disabled: true}},
+	}})
+
+	p.poll(context.Background())
+	first, ok := p.lastPolled["codex-1"]
+	if !ok {
+		t.Fatal("codex auth must be polled")
+	}
+	if _, ok = p.lastPolled["gemini-1"]; ok {
+		t.Fatal("disabled provider must not be polled")
+	}
+
+	p.poll(context.Background())
+	if !p.lastPolled["codex-1"].Equal(first) {
+		t.Fatal("auth must not be polled again before its interval elapses")
+	}
+
+	p.lastPolled["codex-1"] = first.Add(-defaultPollInterval)
+	p.lastPolled["removed"] = first
+	p.poll(context.Background())
+	if p.lastPolled["codex-1"].Equal(first.Add(-defaultPollInterval)) {
+		t.Fatal("auth must be polled once its interval elapsed")
+	}
+	if _, ok = p.lastPolled["removed"]; ok {
+		t.Fatal("removed auths must be forgotten")
+	}
+
+	p.SetConfig(&config.Config{QuotaPolling: config.QuotaPollingConfig{Disabled: true}})
+	if _, ok = p.providerInterval("codex"); ok {
+		t.Fatal("the global flag must disable every provider")
+	}
+}
@@ -0,0 +1,78 @@
+package quota
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+// rateLimitKinds are the OpenAI-style x-ratelimit header families; the quota left is that
+// of the most used one.
+var rateLimitKinds = []string{"requests", "tokens"}
+
+// extractRateLimitHeaderQuota reads the x-ratelimit-limit-*, x-ratelimit-remaining-* and
+// x-ratelimit-reset-* headers returned by OpenAI-compatible providers such as Qwen and iFlow.
+func extractRateLimitHeaderQuota(headers http.Header, now time.Time) map[string]quota.ModelQuota {
+	var (
+		entry quota.ModelQuota
+		found bool
+	)
+	for _, kind := range rateLimitKinds {
+		limit, errLimit := strconv.ParseFloat(strings.TrimSpace(headers.Get("X-Ratelimit-Limit-"+kind)), 64)
+		remaining, errRemaining := strconv.ParseFloat(strings.TrimSpace(headers.Get("X-Ratelimit-Remaining-"+kind)), 64)
+		if errLimit != nil || errRemaining != nil || limit <= 0 {
+			continue
+		}
+		percent := clampPercent(remaining / limit * 100)
+		if !found || percent < entry.Percent {
+			entry = quota.ModelQuota{Percent: percent, ResetTime: rateLimitResetTime(headers.Get("X-Ratelimit-Reset-"+kind), now)}
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return map[string]quota.ModelQuota{"*": entry}
+}
+
+// rateLimitResetTime parses a reset header, which is either a duration such as "6m0s" or
+// "20ms", a number of seconds, or a Unix time.
+func rateLimitResetTime(value string, now time.Time) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return time.Time{}
+		}
+		// Values beyond a year are timestamps rather than delays.
+		if seconds > 365*24*60*60 {
+			return time.Unix(int64(seconds), 0).UTC()
+		}
+		return now.Add(time.Duration(seconds * float64(time.Second))).UTC()
+	}
+	if delay, err := time.ParseDuration(value); err == nil && delay > 0 {
+		return now.Add(delay).UTC()
+	}
+	return time.Time{}
+}
+
+// ObserveRateLimits records the quota reported in the rate limit headers of a response from
+// an OpenAI-compatible provider for authID. Qwen and iFlow accounts have no usage endpoint
+// to poll, so this is how their quota reaches weighted selection.
+func ObserveRateLimits(authID, provider string, headers http.Header) {
+	store := observedStore.Load()
+	if store == nil || authID == "" || headers == nil {
+		return
+	}
+	now := time.Now().UTC()
+	models := extractRateLimitHeaderQuota(headers, now)
+	if len(models) == 0 {
+		return
+	}
+	store.Set(authID, provider, models, now)
+}
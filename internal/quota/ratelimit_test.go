@@ -0,0 +1,56 @@
+package quota
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func TestExtractRateLimitHeaderQuotaUsesMostUsedLimit(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	headers := http.Header{}
+	headers.Set("X-Ratelimit-Limit-Requests", "2000")
+	headers.Set("X-Ratelimit-Remaining-Requests", "500")
+	headers.Set("X-Ratelimit-Reset-Requests", "6m0s")
+	headers.Set("X-Ratelimit-Limit-Tokens", "1000000")
+	headers.Set("X-Ratelimit-Remaining-Tokens", "900000")
+	headers.Set("X-Ratelimit-Reset-Tokens", "20ms")
+
+	entry, ok := extractRateLimitHeaderQuota(headers, now)["*"]
+	if !ok || entry.Percent != 25 || !entry.ResetTime.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("expected 25%% remaining of the request limit, got %+v", entry)
+	}
+
+	if got := rateLimitResetTime("30", now); !got.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("seconds reset = %v", got)
+	}
+	if got := rateLimitResetTime("1792000000", now); !got.Equal(time.Unix(1792000000, 0)) {
+		t.Fatalf("timestamp reset = %v", got)
+	}
+	if extractRateLimitHeaderQuota(http.Header{}, now) != nil {
+		t.Fatal("responses without rate limit headers must not report quota")
+	}
+}
+
+func TestObserveRateLimitsUpdatesStore(t *testing.T) {
+	store, err := quota.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	observedStore.Store(store)
+	t.Cleanup(func() { observedStore.Store(nil) })
+
+	headers := http.Header{}
+	headers.Set("X-Ratelimit-Limit-Requests", "60")
+	headers.Set("X-Ratelimit-Remaining-Requests", "0")
+	ObserveRateLimits("iflow-1", "iflow", headers)
+	got, ok := store.GetModelQuota("iflow-1", "qwen3-coder-plus")
+	if !ok || got.Percent != 0 {
+		t.Fatalf("unexpected quota from headers: %+v %v", got, ok)
+	}
+	if entry, _ := store.GetEntry("iflow-1"); entry == nil || entry.Provider != "iflow" {
+		t.Fatalf("expected an iflow entry, got %+v", entry)
+	}
+}
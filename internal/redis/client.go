@@ -0,0 +1,226 @@
+// Package redis is a minimal Redis client for the commands the proxy's shared state needs.
+// It speaks RESP over a single connection per client and serialises commands, which is
+// enough for periodic syncs and short coordination calls.
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timeout bounds dialing and every command round trip.
+const Timeout = 5 * time.Second
+
+// Client runs commands on one Redis server. The connection is opened on first use and
+// re-established after network errors.
+type Client struct {
+	mu       sync.Mutex
+	addr     string
+	useTLS   bool
+	username string
+	password string
+	db       int
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+// Error is an error reply from the server; the connection stays usable after it.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// New returns a client for rawURL, redis://[[user]:password@]host[:port][/db] or rediss://
+// for TLS. It does not connect.
+func New(rawURL string) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("redis: invalid url: %w", err)
+	}
+	c := &Client{}
+	switch parsed.Scheme {
+	case "redis":
+	case "rediss":
+		c.useTLS = true
+	default:
+		return nil, fmt.Errorf("redis: invalid url scheme %q", parsed.Scheme)
+	}
+	c.addr = parsed.Host
+	if parsed.Port() == "" {
+		c.addr = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if parsed.User != nil {
+		c.username = parsed.User.Username()
+		c.password, _ = parsed.User.Password()
+	}
+	if db := strings.Trim(parsed.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("redis: invalid database %q", db)
+		}
+	}
+	return c, nil
+}
+
+// Do runs one command and returns its reply: a string, int64, []any or nil. A command that
+// fails on a broken connection is retried once on a new one.
+func (c *Client) Do(args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		reply, err := c.roundTrip(args, Timeout)
+		var replyErr Error
+		if err == nil || errors.As(err, &replyErr) || attempt > 0 {
+			return reply, err
+		}
+		c.closeLocked()
+	}
+}
+
+// DoOnce runs one command within timeout and never retries it, for commands that must not
+// run twice such as INCRBY. A command that fails on the network closes the connection, so
+// the next command reconnects.
+func (c *Client) DoOnce(timeout time.Duration, args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reply, err := c.roundTrip(args, timeout)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		c.closeLocked()
+	}
+	return reply, err
+}
+
+// Close closes the connection; the next command reconnects.
+func (c *Client) Close() {
+	c.mu.Lock()
+	c.closeLocked()
+	c.mu.Unlock()
+}
+
+func (c *Client) roundTrip(args []string, timeout time.Duration) (any, error) {
+	if c.conn == nil {
+		if err := c.connectLocked(timeout); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if err := WriteCommand(c.conn, args); err != nil {
+		return nil, err
+	}
+	return ReadReply(c.reader)
+}
+
+func (c *Client) connectLocked(timeout time.Duration) error {
+	dialer := &net.Dialer{Timeout: timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.useTLS {
+		host, _, _ := net.SplitHostPort(c.addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.addr, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+	} else {
+		conn, err = dialer.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+	var setup [][]string
+	if c.password != "" {
+		if c.username != "" {
+			setup = append(setup, []string{"AUTH", c.username, c.password})
+		} else {
+			setup = append(setup, []string{"AUTH", c.password})
+		}
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, cmd := range setup {
+		if _, err = c.roundTrip(cmd, timeout); err != nil {
+			c.closeLocked()
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) closeLocked() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.conn, c.reader = nil, nil
+}
+
+// WriteCommand encodes args as a RESP command.
+func WriteCommand(w io.Writer, args []string) error {
+	var sb strings.Builder
+	sb.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		sb.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ReadReply decodes one RESP value. Error replies are returned as Error.
+func ReadReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, errSize := strconv.Atoi(line[1:])
+		if errSize != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line[1:])
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, errCount := strconv.Atoi(line[1:])
+		if errCount != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line[1:])
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, 0, count)
+		for i := 0; i < count; i++ {
+			item, errItem := ReadReply(r)
+			if errItem != nil {
+				return nil, errItem
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
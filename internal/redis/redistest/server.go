@@ -0,0 +1,178 @@
+// Package redistest runs an in-memory stand-in for a Redis server in tests. It implements
+// the commands the proxy uses and nothing else.
+package redistest
+
+import (
+	"bufio"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redis"
+)
+
+// Server is an in-memory Redis stand-in listening on a loopback port.
+type Server struct {
+	mu      sync.Mutex
+	strings map[string]stringValue
+	hashes  map[string]map[string]string
+}
+
+type stringValue struct {
+	value   string
+	expires time.Time
+}
+
+// Start runs a server until the test ends and returns its redis:// URL.
+func Start(t testing.TB) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	srv := &Server{strings: make(map[string]stringValue), hashes: make(map[string]map[string]string)}
+	go func() {
+		for {
+			conn, errAccept := ln.Accept()
+			if errAccept != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return "redis://" + ln.Addr().String()
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := redis.ReadReply(r)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]any)
+		if len(items) == 0 {
+			return
+		}
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		_, _ = conn.Write([]byte(s.handle(args)))
+	}
+}
+
+func (s *Server) handle(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	switch strings.ToUpper(args[0]) {
+	case "PING", "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		if value, ok := s.get(args[1], now); ok {
+			return bulk(value)
+		}
+		return "$-1\r\n"
+	case "SET":
+		var expires time.Time
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				if i+1 < len(args) {
+					ms, _ := strconv.Atoi(args[i+1])
+					expires = now.Add(time.Duration(ms) * time.Millisecond)
+					i++
+				}
+			}
+		}
+		if _, exists := s.get(args[1], now); exists && nx {
+			return "$-1\r\n"
+		}
+		s.strings[args[1]] = stringValue{value: args[2], expires: expires}
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.get(key, now); ok {
+				delete(s.strings, key)
+				deleted++
+			}
+		}
+		return ":" + strconv.Itoa(deleted) + "\r\n"
+	case "INCR", "INCRBY":
+		value, _ := s.get(args[1], now)
+		n, _ := strconv.ParseInt(value, 10, 64)
+		delta := int64(1)
+		if len(args) > 2 {
+			delta, _ = strconv.ParseInt(args[2], 10, 64)
+		}
+		n += delta
+		s.strings[args[1]] = stringValue{value: strconv.FormatInt(n, 10)}
+		return ":" + strconv.FormatInt(n, 10) + "\r\n"
+	case "EVAL":
+		// The only script the proxy runs deletes KEYS[1] when it still holds ARGV[1].
+		if len(args) >= 5 {
+			if value, ok := s.get(args[3], now); ok && value == args[4] {
+				delete(s.strings, args[3])
+				return ":1\r\n"
+			}
+		}
+		return ":0\r\n"
+	case "HSET":
+		hash := s.hashes[args[1]]
+		if hash == nil {
+			hash = make(map[string]string)
+			s.hashes[args[1]] = hash
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			hash[args[i]] = args[i+1]
+		}
+		return ":1\r\n"
+	case "HDEL":
+		for _, field := range args[2:] {
+			delete(s.hashes[args[1]], field)
+		}
+		return ":1\r\n"
+	case "HGETALL":
+		hash := s.hashes[args[1]]
+		fields := make([]string, 0, len(hash))
+		for field := range hash {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		var sb strings.Builder
+		sb.WriteString("*" + strconv.Itoa(2*len(fields)) + "\r\n")
+		for _, field := range fields {
+			sb.WriteString(bulk(field))
+			sb.WriteString(bulk(hash[field]))
+		}
+		return sb.String()
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func (s *Server) get(key string, now time.Time) (string, bool) {
+	value, ok := s.strings[key]
+	if !ok {
+		return "", false
+	}
+	if !value.expires.IsZero() && !now.Before(value.expires) {
+		delete(s.strings, key)
+		return "", false
+	}
+	return value.value, true
+}
+
+func bulk(value string) string {
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
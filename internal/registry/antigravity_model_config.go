@@ -0,0 +1,168 @@
+package registry
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultAntigravityModelConfig holds the built-in Antigravity model configuration.
+//
+//go:embed antigravity_model_config.json
+var defaultAntigravityModelConfig []byte
+
+// antigravityModelConfigCheckInterval bounds how often the override file is stat'ed for changes.
+const antigravityModelConfigCheckInterval = 10 * time.Second
+
+type antigravityModelConfigSource struct {
+	mu        sync.Mutex
+	path      string
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+	models    map[string]*AntigravityModelConfig
+}
+
+var antigravityModelConfig = &antigravityModelConfigSource{}
+
+// GetAntigravityModelConfig returns the configuration for antigravity models.
+// Keys use upstream model names returned by the Antigravity models endpoint.
+// The built-in defaults are merged with the override file set through
+// SetAntigravityModelConfigFile, which is re-read when it changes on disk.
+func GetAntigravityModelConfig() map[string]*AntigravityModelConfig {
+	src := antigravityModelConfig
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.models == nil {
+		src.models = mustDefaultAntigravityModelConfig()
+	}
+	if src.path != "" && time.Since(src.lastCheck) >= antigravityModelConfigCheckInterval {
+		src.lastCheck = time.Now()
+		if info, err := os.Stat(src.path); err == nil && (!info.ModTime().Equal(src.modTime) || info.Size() != src.size) {
+			if err = src.loadLocked(); err != nil {
+				log.Warnf("antigravity model config: keeping previous configuration: %v", err)
+			}
+		}
+	}
+
+	out := make(map[string]*AntigravityModelConfig, len(src.models))
+	for modelID, entry := range src.models {
+		out[modelID] = entry.clone()
+	}
+	return out
+}
+
+// SetAntigravityModelConfigFile sets the JSON or YAML file whose entries override the
+// built-in Antigravity model configuration, and loads it. An empty path restores the
+// built-in defaults. When the file cannot be loaded the previous configuration stays active.
+func SetAntigravityModelConfigFile(path string) error {
+	src := antigravityModelConfig
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	path = strings.TrimSpace(path)
+	if path == src.path && src.models != nil {
+		return nil
+	}
+	src.path = path
+	src.modTime, src.size = time.Time{}, 0
+	src.lastCheck = time.Now()
+	return src.loadLocked()
+}
+
+func (s *antigravityModelConfigSource) loadLocked() error {
+	if s.models == nil {
+		s.models = mustDefaultAntigravityModelConfig()
+	}
+	models := mustDefaultAntigravityModelConfig()
+	if s.path == "" {
+		s.models = models
+		return nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("antigravity model config: %w", err)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("antigravity model config: %w", err)
+	}
+	overrides, err := parseAntigravityModelConfig(data, s.path)
+	if err != nil {
+		return fmt.Errorf("antigravity model config %s: %w", s.path, err)
+	}
+	// A null entry removes a built-in model; any other entry replaces it.
+	for modelID, entry := range overrides {
+		if entry == nil {
+			delete(models, modelID)
+			continue
+		}
+		models[modelID] = entry
+	}
+	s.models = models
+	s.modTime, s.size = info.ModTime(), info.Size()
+	log.Infof("antigravity model config: loaded %d override(s) from %s", len(overrides), s.path)
+	return nil
+}
+
+// parseAntigravityModelConfig decodes a model config document. YAML files use the same
+// keys as the JSON format.
+func parseAntigravityModelConfig(data []byte, path string) (map[string]*AntigravityModelConfig, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc map[string]any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		data = raw
+	}
+	var models map[string]*AntigravityModelConfig
+	if err := json.Unmarshal(data, &models); err != nil {
+		return nil, err
+	}
+	for modelID, entry := range models {
+		if entry == nil {
+			continue
+		}
+		switch entry.SchemaMode {
+		case "", SchemaModeGemini, SchemaModeAntigravity:
+		default:
+			return nil, fmt.Errorf("model %s: unknown schema_mode %q", modelID, entry.SchemaMode)
+		}
+	}
+	return models, nil
+}
+
+func mustDefaultAntigravityModelConfig() map[string]*AntigravityModelConfig {
+	models, err := parseAntigravityModelConfig(defaultAntigravityModelConfig, "default.json")
+	if err != nil {
+		panic(fmt.Sprintf("registry: invalid embedded antigravity model config: %v", err))
+	}
+	return models
+}
+
+func (c *AntigravityModelConfig) clone() *AntigravityModelConfig {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	if c.Thinking != nil {
+		thinking := *c.Thinking
+		thinking.Levels = append([]string(nil), c.Thinking.Levels...)
+		out.Thinking = &thinking
+	}
+	return &out
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAntigravityModelConfigOverrideFile(t *testing.T) {
+	t.Cleanup(func() { _ = SetAntigravityModelConfigFile("") })
+
+	defaults := GetAntigravityModelConfig()
+	if defaults["claude-opus-4-6-thinking"] == nil || defaults["claude-opus-4-6-thinking"].MaxCompletionTokens != 128000 {
+		t.Fatalf("embedded defaults missing claude-opus-4-6-thinking: %+v", defaults["claude-opus-4-6-thinking"])
+	}
+
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	override := "gemini-3-flash:\n  thinking: {min: 256, max: 16384, levels: [low, high]}\ngpt-oss-120b-medium: null\nnew-model:\n  max_completion_tokens: 4096\n"
+	if err := os.WriteFile(path, []byte(override), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAntigravityModelConfigFile(path); err != nil {
+		t.Fatalf("load override: %v", err)
+	}
+
+	cfg := GetAntigravityModelConfig()
+	if flash := cfg["gemini-3-flash"]; flash == nil || flash.Thinking.Min != 256 || len(flash.Thinking.Levels) != 2 {
+		t.Fatalf("gemini-3-flash = %+v, want override", flash)
+	}
+	if _, ok := cfg["gpt-oss-120b-medium"]; ok {
+		t.Fatal("null entry should remove the built-in model")
+	}
+	if cfg["new-model"] == nil || cfg["new-model"].MaxCompletionTokens != 4096 {
+		t.Fatalf("new-model = %+v", cfg["new-model"])
+	}
+	if cfg["claude-sonnet-4-5"] == nil {
+		t.Fatal("untouched built-in entries should remain")
+	}
+
+	// Callers get copies; mutating one must not leak into the next lookup.
+	cfg["gemini-3-flash"].Thinking.Max = 1
+	if GetAntigravityModelConfig()["gemini-3-flash"].Thinking.Max != 16384 {
+		t.Fatal("returned config shares state with the registry")
+	}
+
+	// A broken rewrite keeps the previous configuration.
+	if err := os.WriteFile(path, []byte("gemini-3-flash: [oops"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(path, future, future)
+	antigravityModelConfig.mu.Lock()
+	antigravityModelConfig.lastCheck = time.Time{}
+	antigravityModelConfig.mu.Unlock()
+	if GetAntigravityModelConfig()["gemini-3-flash"].Thinking.Min != 256 {
+		t.Fatal("invalid override file should keep the previous configuration")
+	}
+
+	// A valid rewrite is picked up without calling SetAntigravityModelConfigFile again.
+	if err := os.WriteFile(path, []byte(`{"gemini-3-flash": {"thinking": {"min": 512, "max": 8192}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future = future.Add(time.Minute)
+	_ = os.Chtimes(path, future, future)
+	antigravityModelConfig.mu.Lock()
+	antigravityModelConfig.lastCheck = time.Time{}
+	antigravityModelConfig.mu.Unlock()
+	if got := GetAntigravityModelConfig()["gemini-3-flash"].Thinking.Min; got != 512 {
+		t.Fatalf("gemini-3-flash min = %d after rewrite, want 512", got)
+	}
+}
+
+func TestAntigravityModelConfigSchemaMode(t *testing.T) {
+	t.Cleanup(func() { _ = SetAntigravityModelConfigFile("") })
+
+	if info := LookupStaticModelInfo("claude-sonnet-4-5"); info == nil || info.SchemaMode != SchemaModeAntigravity {
+		t.Fatalf("claude-sonnet-4-5 = %+v, want antigravity schema", info)
+	}
+	if info := LookupStaticModelInfo("gemini-3-flash"); info == nil || info.SchemaMode != "" {
+		t.Fatalf("gemini-3-flash = %+v, want provider default schema", info)
+	}
+
+	path := filepath.Join(t.TempDir(), "models.json")
+	if err := os.WriteFile(path, []byte(`{"new-model": {"schema_mode": "claude"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAntigravityModelConfigFile(path); err == nil {
+		t.Fatal("expected an error for an unknown schema_mode")
+	}
+}
@@ -56,6 +56,7 @@ func GetStaticModelDefinitionsByChannel(channel string) []*ModelInfo {
 				Type:                "antigravity",
 				Thinking:            entry.Thinking,
 				MaxCompletionTokens: entry.MaxCompletionTokens,
+				SchemaMode:          entry.SchemaMode,
 			})
 		}
 		sort.Slice(models, func(i, j int) bool {
@@ -98,6 +99,7 @@ func LookupStaticModelInfo(modelID string) *ModelInfo {
 			ID:                  modelID,
 			Thinking:            cfg.Thinking,
 			MaxCompletionTokens: cfg.MaxCompletionTokens,
+			SchemaMode:          cfg.SchemaMode,
 		}
 	}
 
@@ -0,0 +1,237 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultModelListWarmup is how long after startup cached model lists may be served.
+	DefaultModelListWarmup = 60 * time.Second
+	modelListRefreshPeriod = 30 * time.Second
+)
+
+// modelListHandlerTypes are the handler types whose model lists are cached.
+var modelListHandlerTypes = []string{"openai", "claude", "gemini"}
+
+type modelListCacheFile struct {
+	SavedAt time.Time                   `json:"saved_at"`
+	Lists   map[string][]map[string]any `json:"lists"`
+}
+
+// ModelListCache persists the model lists served by the /models endpoints. Right after a
+// restart, while credentials are still registering their models, it answers with the lists
+// of the previous run and flags them as stale; the live registry takes over once it lists
+// at least as many models or the warm-up window ends.
+type ModelListCache struct {
+	mu        sync.Mutex
+	registry  *ModelRegistry
+	path      string
+	lists     map[string][]map[string]any
+	savedAt   time.Time
+	warmUntil time.Time
+	// live marks handler types whose registry list has caught up with the cache.
+	live  map[string]bool
+	dirty bool
+	nowFn func() time.Time
+}
+
+var globalModelListCache = &ModelListCache{nowFn: time.Now}
+
+// GetModelListCache returns the shared model-list cache.
+func GetModelListCache() *ModelListCache { return globalModelListCache }
+
+// Configure loads the cache file at path and serves its lists for up to warmup from now.
+// An empty path disables the cache.
+func (c *ModelListCache) Configure(registry *ModelRegistry, path string, warmup time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.registry = registry
+	c.path = path
+	c.lists = make(map[string][]map[string]any)
+	c.live = make(map[string]bool)
+	c.savedAt = time.Time{}
+	c.dirty = false
+	c.warmUntil = c.nowFn().Add(warmup)
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(err).Warn("model list cache: failed to read cache file")
+		}
+		return
+	}
+	var file modelListCacheFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		log.WithError(err).Warn("model list cache: ignoring unreadable cache file")
+		return
+	}
+	for handlerType, models := range file.Lists {
+		c.lists[handlerType] = models
+	}
+	c.savedAt = file.SavedAt
+}
+
+// Models returns the model list for handlerType. stale is true when the list comes from a
+// previous run because the registry is still warming up.
+func (c *ModelListCache) Models(handlerType string) (models []map[string]any, stale bool) {
+	c.mu.Lock()
+	registry := c.registry
+	c.mu.Unlock()
+	if registry == nil {
+		registry = GetGlobalRegistry()
+	}
+	live := registry.GetAvailableModels(handlerType)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" || c.live[handlerType] {
+		return live, false
+	}
+	cached := c.lists[handlerType]
+	if len(live) < len(cached) && c.nowFn().Before(c.warmUntil) {
+		return cloneModelList(cached), true
+	}
+	c.live[handlerType] = true
+	return live, false
+}
+
+// SavedAt returns when the cached lists were last written, or the zero time when none were.
+func (c *ModelListCache) SavedAt() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.savedAt
+}
+
+// Refresh stores the current registry lists of the handler types that have caught up and
+// writes the cache file when they changed.
+func (c *ModelListCache) Refresh() error {
+	c.mu.Lock()
+	registry := c.registry
+	path := c.path
+	c.mu.Unlock()
+	if path == "" {
+		return nil
+	}
+	if registry == nil {
+		registry = GetGlobalRegistry()
+	}
+	live := make(map[string][]map[string]any, len(modelListHandlerTypes))
+	for _, handlerType := range modelListHandlerTypes {
+		live[handlerType] = registry.GetAvailableModels(handlerType)
+	}
+
+	c.mu.Lock()
+	now := c.nowFn()
+	for handlerType, models := range live {
+		if !c.live[handlerType] && len(models) < len(c.lists[handlerType]) && now.Before(c.warmUntil) {
+			continue
+		}
+		c.live[handlerType] = true
+		if len(models) == 0 {
+			// Keep the last known list rather than persisting an empty pool.
+			continue
+		}
+		normalized, err := normalizeModelList(models)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		if !reflect.DeepEqual(normalized, c.lists[handlerType]) {
+			c.lists[handlerType] = normalized
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	file := modelListCacheFile{SavedAt: now.UTC(), Lists: make(map[string][]map[string]any, len(c.lists))}
+	for handlerType, models := range c.lists {
+		file.Lists[handlerType] = models
+	}
+	c.dirty = false
+	c.savedAt = file.SavedAt
+	c.mu.Unlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("model list cache: encode: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("model list cache: create dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("model list cache: write: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("model list cache: replace: %w", err)
+	}
+	return nil
+}
+
+// Start refreshes the cache in the background until ctx is done.
+func (c *ModelListCache) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(modelListRefreshPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(); err != nil {
+					log.WithError(err).Warn("model list cache: refresh failed")
+				}
+			}
+		}
+	}()
+}
+
+// normalizeModelList round-trips models through JSON so they compare equal to lists loaded
+// from the cache file, and orders them by identifier since the registry lists models in map
+// order.
+func normalizeModelList(models []map[string]any) ([]map[string]any, error) {
+	data, err := json.Marshal(models)
+	if err != nil {
+		return nil, fmt.Errorf("model list cache: encode: %w", err)
+	}
+	var out []map[string]any
+	if err = json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("model list cache: decode: %w", err)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return modelListKey(out[i]) < modelListKey(out[j]) })
+	return out, nil
+}
+
+func modelListKey(model map[string]any) string {
+	if id, ok := model["id"].(string); ok && id != "" {
+		return id
+	}
+	name, _ := model["name"].(string)
+	return name
+}
+
+func cloneModelList(models []map[string]any) []map[string]any {
+	out := make([]map[string]any, len(models))
+	for i, model := range models {
+		clone := make(map[string]any, len(model))
+		for k, v := range model {
+			clone[k] = v
+		}
+		out[i] = clone
+	}
+	return out
+}
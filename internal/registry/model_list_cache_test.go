@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestModelListCacheServesPreviousRunUntilRegistryCatchesUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model-list-cache.json")
+
+	// First run: two models registered, persisted on refresh.
+	previous := newTestModelRegistry()
+	previous.RegisterClient("auth-1", "claude", []*ModelInfo{{ID: "claude-a", Object: "model"}, {ID: "claude-b", Object: "model"}})
+	first := &ModelListCache{nowFn: time.Now}
+	first.Configure(previous, path, time.Minute)
+	if err := first.Refresh(); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Restart: the registry is still empty, so the cached list is served as stale.
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	current := newTestModelRegistry()
+	cache := &ModelListCache{nowFn: func() time.Time { return now }}
+	cache.Configure(current, path, time.Minute)
+	models, stale := cache.Models("openai")
+	if !stale || len(models) != 2 || models[0]["id"] != "claude-a" {
+		t.Fatalf("Models during warm-up = %v, %v", models, stale)
+	}
+	if cache.SavedAt().IsZero() {
+		t.Fatal("SavedAt not loaded from the cache file")
+	}
+	// A refresh during warm-up must not overwrite the cache with the partial list.
+	current.RegisterClient("auth-1", "claude", []*ModelInfo{{ID: "claude-a", Object: "model"}})
+	if err := cache.Refresh(); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if models, stale = cache.Models("openai"); !stale || len(models) != 2 {
+		t.Fatalf("Models with a partial registry = %v, %v", models, stale)
+	}
+
+	// Once the registry lists as many models, it takes over for good.
+	current.RegisterClient("auth-2", "claude", []*ModelInfo{{ID: "claude-c", Object: "model"}, {ID: "claude-d", Object: "model"}})
+	if models, stale = cache.Models("openai"); stale || len(models) != 3 {
+		t.Fatalf("Models after catch-up = %v, %v", models, stale)
+	}
+	current.UnregisterClient("auth-2")
+	if models, stale = cache.Models("openai"); stale || len(models) != 1 {
+		t.Fatalf("Models after the registry shrank = %v, %v", models, stale)
+	}
+
+	// Without a cache file the registry is served from the start, and the warm-up window ends.
+	fresh := &ModelListCache{nowFn: time.Now}
+	fresh.Configure(newTestModelRegistry(), filepath.Join(t.TempDir(), "missing.json"), time.Minute)
+	if _, stale = fresh.Models("openai"); stale {
+		t.Fatal("list without a cache file reported stale")
+	}
+	expired := &ModelListCache{nowFn: func() time.Time { return now }}
+	expired.Configure(newTestModelRegistry(), path, time.Minute)
+	now = now.Add(2 * time.Minute)
+	if models, stale = expired.Models("openai"); stale || len(models) != 0 {
+		t.Fatalf("Models after warm-up = %v, %v", models, stale)
+	}
+}
+
+func TestModelListCacheDisabled(t *testing.T) {
+	reg := newTestModelRegistry()
+	reg.RegisterClient("auth-1", "claude", []*ModelInfo{{ID: "claude-a", Object: "model"}})
+	cache := &ModelListCache{nowFn: time.Now}
+	cache.Configure(reg, "", time.Minute)
+	if models, stale := cache.Models("openai"); stale || len(models) != 1 {
+		t.Fatalf("Models = %v, %v", models, stale)
+	}
+	if err := cache.Refresh(); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+}
@@ -52,12 +52,25 @@ type ModelInfo struct {
 	// This is optional and currently used for Gemini thinking budget normalization.
 	Thinking *ThinkingSupport `json:"thinking,omitempty"`
 
+	// SchemaMode selects how tool schemas and system instructions are shaped for
+	// providers that proxy several model families (e.g. Antigravity). Empty means
+	// the provider default; see SchemaModeGemini and SchemaModeAntigravity.
+	SchemaMode string `json:"schema_mode,omitempty"`
+
 	// UserDefined indicates this model was defined through config file's models[]
 	// array (e.g., openai-compatibility.*.models[], *-api-key.models[]).
 	// UserDefined models have thinking configuration passed through without validation.
 	UserDefined bool `json:"-"`
 }
 
+const (
+	// SchemaModeGemini sends tool schemas cleaned for the Gemini function-calling dialect.
+	SchemaModeGemini = "gemini"
+	// SchemaModeAntigravity sends tool schemas cleaned for Claude-backed Antigravity models
+	// and duplicates the system instruction in the layout those models expect.
+	SchemaModeAntigravity = "antigravity"
+)
+
 // ThinkingSupport describes a model family's supported internal reasoning budget range.
 // Values are interpreted in provider-native token units.
 type ThinkingSupport struct {
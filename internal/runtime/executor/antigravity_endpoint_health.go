@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// endpointHealthAlpha weights the newest sample in the success and latency averages.
+	endpointHealthAlpha = 0.2
+	// endpointHealthMinSamples is the number of samples required before an endpoint is reordered.
+	endpointHealthMinSamples = 5
+	// endpointHealthStaleAfter forgets endpoints without recent traffic so a demoted endpoint
+	// gets another chance once it may have recovered.
+	endpointHealthStaleAfter = 10 * time.Minute
+	// endpointHealthBucket groups scores so small differences keep the default order.
+	endpointHealthBucket = 0.05
+)
+
+type endpointHealth struct {
+	samples    int
+	success    float64
+	latencyMs  float64
+	lastSample time.Time
+}
+
+// endpointHealthTracker scores upstream base URLs by recent success rate and latency.
+type endpointHealthTracker struct {
+	mu    sync.Mutex
+	stats map[string]*endpointHealth
+	now   func() time.Time
+}
+
+var antigravityEndpoints = &endpointHealthTracker{stats: make(map[string]*endpointHealth), now: time.Now}
+
+func (t *endpointHealthTracker) record(base string, ok bool, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	h := t.stats[base]
+	if h == nil || now.Sub(h.lastSample) > endpointHealthStaleAfter {
+		h = &endpointHealth{success: 1}
+		t.stats[base] = h
+	}
+	outcome := 0.0
+	if ok {
+		outcome = 1
+		ms := float64(latency.Milliseconds())
+		if h.latencyMs == 0 {
+			h.latencyMs = ms
+		} else {
+			h.latencyMs += endpointHealthAlpha * (ms - h.latencyMs)
+		}
+	}
+	h.success += endpointHealthAlpha * (outcome - h.success)
+	h.samples++
+	h.lastSample = now
+}
+
+// score favours success rate and applies a bounded penalty for latency.
+func (h *endpointHealth) score() float64 {
+	penalty := 0.2 * h.latencyMs / (h.latencyMs + 5000)
+	return h.success - penalty
+}
+
+// order returns urls sorted healthiest first. Endpoints without enough recent samples rank
+// as perfectly healthy, and ties keep the given order.
+func (t *endpointHealthTracker) order(urls []string) []string {
+	if len(urls) < 2 {
+		return urls
+	}
+	t.mu.Lock()
+	now := t.now()
+	buckets := make(map[string]float64, len(urls))
+	for _, u := range urls {
+		score := 1.0
+		if h := t.stats[u]; h != nil && h.samples >= endpointHealthMinSamples && now.Sub(h.lastSample) <= endpointHealthStaleAfter {
+			score = h.score()
+		}
+		buckets[u] = math.Floor(score / endpointHealthBucket)
+	}
+	t.mu.Unlock()
+
+	ordered := append([]string(nil), urls...)
+	sort.SliceStable(ordered, func(i, j int) bool { return buckets[ordered[i]] > buckets[ordered[j]] })
+	if ordered[0] != urls[0] {
+		log.Debugf("antigravity executor: preferring healthier base url %s over %s", ordered[0], urls[0])
+	}
+	return ordered
+}
+
+// endpointHealthTransport records the outcome of every request against its base URL.
+type endpointHealthTransport struct {
+	base    http.RoundTripper
+	tracker *endpointHealthTracker
+}
+
+func (t *endpointHealthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	endpoint := req.URL.Scheme + "://" + req.URL.Host
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	switch {
+	case err != nil:
+		if !errors.Is(err, context.Canceled) {
+			t.tracker.record(endpoint, false, 0)
+		}
+	case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+		t.tracker.record(endpoint, false, 0)
+	case resp.StatusCode < http.StatusMultipleChoices:
+		t.tracker.record(endpoint, true, time.Since(start))
+	}
+	return resp, err
+}
+
+// trackAntigravityEndpoints makes client report per-endpoint health for fallback ordering.
+func trackAntigravityEndpoints(client *http.Client) *http.Client {
+	client.Transport = &endpointHealthTransport{base: client.Transport, tracker: antigravityEndpoints}
+	return client
+}
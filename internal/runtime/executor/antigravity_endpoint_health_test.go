@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestEndpointHealthTracker(now *time.Time) *endpointHealthTracker {
+	return &endpointHealthTracker{stats: make(map[string]*endpointHealth), now: func() time.Time { return *now }}
+}
+
+func TestEndpointHealthKeepsDefaultOrderWithoutSamples(t *testing.T) {
+	now := time.Now()
+	tracker := newTestEndpointHealthTracker(&now)
+	urls := []string{"https://a", "https://b"}
+	for i := 0; i < endpointHealthMinSamples-1; i++ {
+		tracker.record("https://a", false, 0)
+	}
+	if got := tracker.order(urls); !reflect.DeepEqual(got, urls) {
+		t.Fatalf("order = %v, want %v", got, urls)
+	}
+}
+
+func TestEndpointHealthPrefersHealthierEndpoint(t *testing.T) {
+	now := time.Now()
+	tracker := newTestEndpointHealthTracker(&now)
+	urls := []string{"https://a", "https://b"}
+	for i := 0; i < 10; i++ {
+		tracker.record("https://a", i%2 == 0, 100*time.Millisecond)
+		tracker.record("https://b", true, 100*time.Millisecond)
+	}
+	if got := tracker.order(urls); !reflect.DeepEqual(got, []string{"https://b", "https://a"}) {
+		t.Fatalf("order = %v, want b first", got)
+	}
+
+	now = now.Add(endpointHealthStaleAfter + time.Minute)
+	if got := tracker.order(urls); !reflect.DeepEqual(got, urls) {
+		t.Fatalf("stale order = %v, want default", got)
+	}
+}
+
+func TestEndpointHealthPrefersFasterEndpoint(t *testing.T) {
+	now := time.Now()
+	tracker := newTestEndpointHealthTracker(&now)
+	for i := 0; i < 10; i++ {
+		tracker.record("https://a", true, 8*time.Second)
+		tracker.record("https://b", true, 200*time.Millisecond)
+	}
+	if got := tracker.order([]string{"https://a", "https://b"}); got[0] != "https://b" {
+		t.Fatalf("order = %v, want b first", got)
+	}
+}
+
+func TestEndpointHealthTransportRecordsOutcome(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	tracker := newTestEndpointHealthTracker(&now)
+	client := &http.Client{Transport: &endpointHealthTransport{tracker: tracker}}
+	for _, code := range []int{http.StatusOK, http.StatusBadRequest, http.StatusServiceUnavailable} {
+		status = code
+		resp, err := client.Get(srv.URL + "/v1internal:generateContent")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	h := tracker.stats[srv.URL]
+	if h == nil || h.samples != 2 {
+		t.Fatalf("stats = %+v, want 2 samples", h)
+	}
+	if h.success >= 1 {
+		t.Fatalf("success = %v, want below 1 after a 503", h.success)
+	}
+}
@@ -111,7 +111,9 @@ func (e *AntigravityExecutor) HttpRequest(ctx context.Context, auth *cliproxyaut
 // Execute 执行到 Antigravity API 的非流式请求。
 func (e *AntigravityExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	if opts.Alt == "responses/compact" {
-		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+		return compactResponsesBySummary(ctx, req, opts, func(ctx context.Context, r cliproxyexecutor.Request, o cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+			return e.Execute(ctx, auth, r, o)
+		})
 	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 	isClaude := strings.Contains(strings.ToLower(baseModel), "claude")
@@ -129,6 +131,7 @@ func (e *AntigravityExecutor) Execute(ctx context.Context, auth *cliproxyauth.Au
 	}
 
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	reporter.estimateInput(req.Payload)
 	defer reporter.trackFailure(ctx, &err)
 
 	from := opts.SourceFormat
@@ -139,8 +142,8 @@ func (e *AntigravityExecutor) Execute(ctx context.Context, auth *cliproxyauth.Au
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	originalTranslated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, originalPayload, false)
+	translated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, false)
 
 	translated, err = thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -151,7 +154,7 @@ func (e *AntigravityExecutor) Execute(ctx context.Context, auth *cliproxyauth.Au
 	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, "antigravity", "request", translated, originalTranslated, requestedModel)
 
 	baseURLs := antigravityBaseURLFallbackOrder(auth)
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := trackAntigravityEndpoints(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 
 	attempts := antigravityRetryAttempts(auth, e.cfg)
 
@@ -277,6 +280,7 @@ func (e *AntigravityExecutor) executeClaudeNonStream(ctx context.Context, auth *
 	}
 
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	reporter.estimateInput(req.Payload)
 	defer reporter.trackFailure(ctx, &err)
 
 	from := opts.SourceFormat
@@ -287,8 +291,8 @@ func (e *AntigravityExecutor) executeClaudeNonStream(ctx context.Context, auth *
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	originalTranslated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, originalPayload, true)
+	translated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, true)
 
 	translated, err = thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -299,9 +303,10 @@ func (e *AntigravityExecutor) executeClaudeNonStream(ctx context.Context, auth *
 	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, "antigravity", "request", translated, originalTranslated, requestedModel)
 
 	baseURLs := antigravityBaseURLFallbackOrder(auth)
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := trackAntigravityEndpoints(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 
 	attempts := antigravityRetryAttempts(auth, e.cfg)
+	thinkingRetried := false
 
 attemptLoop:
 	for attempt := 0; attempt < attempts; attempt++ {
@@ -380,6 +385,13 @@ attemptLoop:
 						continue attemptLoop
 					}
 				}
+				if retryPayload, ok := retryWithoutAntigravityThinking(ctx, baseModel, httpResp.StatusCode, bodyBytes, translated, thinkingRetried); ok {
+					thinkingRetried = true
+					translated = retryPayload
+					// The recovery retry does not use up one of the configured attempts.
+					attempts++
+					continue attemptLoop
+				}
 				sErr := statusErr{code: httpResp.StatusCode, msg: string(bodyBytes)}
 				if httpResp.StatusCode == http.StatusTooManyRequests {
 					if retryAfter, parseErr := parseRetryDelay(bodyBytes); parseErr == nil && retryAfter != nil {
@@ -654,7 +666,7 @@ func (e *AntigravityExecutor) convertStreamToNonStream(stream []byte) []byte {
 // ExecuteStream 执行到 Antigravity API 的流式请求。
 func (e *AntigravityExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
 	if opts.Alt == "responses/compact" {
-		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+		return nil, statusErr{code: http.StatusBadRequest, msg: "streaming not supported for /responses/compact"}
 	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
@@ -669,6 +681,7 @@ func (e *AntigravityExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 	}
 
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	reporter.estimateInput(req.Payload)
 	defer reporter.trackFailure(ctx, &err)
 
 	isClaude := strings.Contains(strings.ToLower(req.Model), "claude")
@@ -687,8 +700,8 @@ func (e *AntigravityExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	originalTranslated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, originalPayload, true)
+	translated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, true)
 
 	translated, err = thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -699,9 +712,10 @@ func (e *AntigravityExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, "antigravity", "request", translated, originalTranslated, requestedModel)
 
 	baseURLs := antigravityBaseURLFallbackOrder(auth)
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := trackAntigravityEndpoints(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 
 	attempts := antigravityRetryAttempts(auth, e.cfg)
+	thinkingRetried := false
 
 attemptLoop:
 	for attempt := 0; attempt < attempts; attempt++ {
@@ -779,6 +793,13 @@ attemptLoop:
 						continue attemptLoop
 					}
 				}
+				if retryPayload, ok := retryWithoutAntigravityThinking(ctx, baseModel, httpResp.StatusCode, bodyBytes, translated, thinkingRetried); ok {
+					thinkingRetried = true
+					translated = retryPayload
+					// The recovery retry does not use up one of the configured attempts.
+					attempts++
+					continue attemptLoop
+				}
 				sErr := statusErr{code: httpResp.StatusCode, msg: string(bodyBytes)}
 				if httpResp.StatusCode == http.StatusTooManyRequests {
 					if retryAfter, parseErr := parseRetryDelay(bodyBytes); parseErr == nil && retryAfter != nil {
@@ -871,7 +892,20 @@ func (e *AntigravityExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Au
 }
 
 // CountTokens counts tokens for the given request using the Antigravity API.
+// Identical concurrent or recently repeated requests share one upstream call.
 func (e *AntigravityExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	key := countTokensKey([]byte(req.Model), []byte(opts.SourceFormat.String()), []byte(opts.Alt), req.Payload)
+	payload, err := antigravityCountTokens.Do(ctx, key, func(callCtx context.Context) ([]byte, error) {
+		resp, errCount := e.countTokens(callCtx, auth, req, opts)
+		return resp.Payload, errCount
+	})
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	return cliproxyexecutor.Response{Payload: payload}, nil
+}
+
+func (e *AntigravityExecutor) countTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	token, updatedAuth, errToken := e.ensureAccessToken(ctx, auth)
@@ -890,7 +924,7 @@ func (e *AntigravityExecutor) CountTokens(ctx context.Context, auth *cliproxyaut
 	respCtx := context.WithValue(ctx, "alt", opts.Alt)
 
 	// Prepare payload once (doesn't depend on baseURL)
-	payload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	payload := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, false)
 
 	payload, err := thinking.ApplyThinking(payload, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -902,7 +936,7 @@ func (e *AntigravityExecutor) CountTokens(ctx context.Context, auth *cliproxyaut
 	payload = deleteJSONField(payload, "request.safetySettings")
 
 	baseURLs := antigravityBaseURLFallbackOrder(auth)
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := trackAntigravityEndpoints(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -1030,7 +1064,7 @@ func FetchAntigravityModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *c
 	}
 
 	baseURLs := antigravityBaseURLFallbackOrder(auth)
-	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpClient := trackAntigravityEndpoints(newProxyAwareHTTPClient(ctx, cfg, auth, 0))
 
 	for idx, baseURL := range baseURLs {
 		modelsURL := baseURL + antigravityModelsPath
@@ -1115,6 +1149,9 @@ func FetchAntigravityModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *c
 				OwnedBy:     antigravityAuthType,
 				Type:        antigravityAuthType,
 			}
+			if strings.Contains(strings.ToUpper(modelData.Get("modelProvider").String()), "ANTHROPIC") {
+				modelInfo.SchemaMode = registry.SchemaModeAntigravity
+			}
 			// Look up Thinking support from static config using upstream model name.
 			if modelCfg != nil {
 				if modelCfg.Thinking != nil {
@@ -1123,6 +1160,9 @@ func FetchAntigravityModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *c
 				if modelCfg.MaxCompletionTokens > 0 {
 					modelInfo.MaxCompletionTokens = modelCfg.MaxCompletionTokens
 				}
+				if modelCfg.SchemaMode != "" {
+					modelInfo.SchemaMode = modelCfg.SchemaMode
+				}
 			}
 			models = append(models, modelInfo)
 		}
@@ -1137,7 +1177,7 @@ func (e *AntigravityExecutor) ensureAccessToken(ctx context.Context, auth *clipr
 	}
 	accessToken := metaStringValue(auth.Metadata, "access_token")
 	expiry := tokenExpiry(auth.Metadata)
-	if accessToken != "" && expiry.After(time.Now().Add(refreshSkew)) {
+	if accessToken != "" && expiry.After(time.Now().Add(cliproxyauth.RefreshSkew(e.cfg, antigravityAuthType, refreshSkew))) {
 		return accessToken, nil, nil
 	}
 	refreshCtx := context.Background()
@@ -1298,10 +1338,10 @@ func (e *AntigravityExecutor) buildRequest(ctx context.Context, auth *cliproxyau
 			projectID = strings.TrimSpace(pid)
 		}
 	}
-	payload = geminiToAntigravity(modelName, payload, projectID)
+	payload = geminiToAntigravity(e.cfg, modelName, payload, projectID, ginRequestHeaders(ctx))
 	payload, _ = sjson.SetBytes(payload, "model", modelName)
 
-	useAntigravitySchema := strings.Contains(modelName, "claude") || strings.Contains(modelName, "gemini-3-pro-high")
+	useAntigravitySchema := antigravitySchemaMode(modelName) == registry.SchemaModeAntigravity
 	payloadStr := string(payload)
 	paths := make([]string, 0)
 	util.Walk(gjson.Parse(payloadStr), "", "parametersJsonSchema", &paths)
@@ -1329,7 +1369,11 @@ func (e *AntigravityExecutor) buildRequest(ctx context.Context, auth *cliproxyau
 	}
 
 	if strings.Contains(modelName, "claude") {
-		payloadStr, _ = sjson.Set(payloadStr, "request.toolConfig.functionCallingConfig.mode", "VALIDATED")
+		// Keep explicit NONE/ANY (including a forced function via allowedFunctionNames); only
+		// the default AUTO mode is upgraded to VALIDATED.
+		if mode := strings.ToUpper(gjson.Get(payloadStr, "request.toolConfig.functionCallingConfig.mode").String()); mode == "" || mode == "AUTO" {
+			payloadStr, _ = sjson.Set(payloadStr, "request.toolConfig.functionCallingConfig.mode", "VALIDATED")
+		}
 	} else {
 		payloadStr, _ = sjson.Delete(payloadStr, "request.generationConfig.maxOutputTokens")
 	}
@@ -1357,7 +1401,7 @@ func (e *AntigravityExecutor) buildRequest(ctx context.Context, auth *cliproxyau
 		authType, authValue = auth.AccountInfo()
 	}
 	var payloadLog []byte
-	if e.cfg != nil && e.cfg.RequestLog {
+	if e.cfg != nil && e.cfg.RequestLogActive() {
 		payloadLog = []byte(payloadStr)
 	}
 	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
@@ -1527,11 +1571,11 @@ func antigravityBaseURLFallbackOrder(auth *cliproxyauth.Auth) []string {
 	if base := resolveCustomAntigravityBaseURL(auth); base != "" {
 		return []string{base}
 	}
-	return []string{
+	return antigravityEndpoints.order([]string{
 		antigravityBaseURLDaily,
 		antigravitySandboxBaseURLDaily,
 		// antigravityBaseURLProd,
-	}
+	})
 }
 
 func resolveCustomAntigravityBaseURL(auth *cliproxyauth.Auth) string {
@@ -1554,19 +1598,15 @@ func resolveCustomAntigravityBaseURL(auth *cliproxyauth.Auth) string {
 	return ""
 }
 
-func geminiToAntigravity(modelName string, payload []byte, projectID string) []byte {
+// geminiToAntigravity wraps a Gemini CLI payload for Antigravity. clientHeaders are the
+// inbound request headers, consulted by the "client-provided" session strategy.
+func geminiToAntigravity(cfg *config.Config, modelName string, payload []byte, projectID string, clientHeaders http.Header) []byte {
 	template, _ := sjson.Set(string(payload), "model", modelName)
-	template, _ = sjson.Set(template, "userAgent", "antigravity")
-	template, _ = sjson.Set(template, "requestType", "agent")
-
-	// Use real project ID from auth if available, otherwise generate random (legacy fallback)
-	if projectID != "" {
-		template, _ = sjson.Set(template, "project", projectID)
-	} else {
-		template, _ = sjson.Set(template, "project", generateProjectID())
-	}
-	template, _ = sjson.Set(template, "requestId", generateRequestID())
-	template, _ = sjson.Set(template, "request.sessionId", generateStableSessionID(payload))
+	template = string(applyRequestTemplate(cfg, "antigravity", antigravityRequestTemplate, []byte(template), requestTemplateInput{
+		payload:   payload,
+		projectID: projectID,
+		headers:   clientHeaders,
+	}))
 
 	template, _ = sjson.Delete(template, "request.safetySettings")
 	if toolConfig := gjson.Get(template, "toolConfig"); toolConfig.Exists() && !gjson.Get(template, "request.toolConfig").Exists() {
@@ -1594,9 +1634,7 @@ func generateStableSessionID(payload []byte) string {
 			if content.Get("role").String() == "user" {
 				text := content.Get("parts.0.text").String()
 				if text != "" {
-					h := sha256.Sum256([]byte(text))
-					n := int64(binary.BigEndian.Uint64(h[:8])) & 0x7FFFFFFFFFFFFFFF
-					return "-" + strconv.FormatInt(n, 10)
+					return sessionIDFromText(text)
 				}
 			}
 		}
@@ -1604,6 +1642,26 @@ func generateStableSessionID(payload []byte) string {
 	return generateSessionID()
 }
 
+// generateFirstTurnSessionID hashes the whole first user turn, so conversations that open
+// with the same text but different attachments get distinct sessions.
+func generateFirstTurnSessionID(payload []byte) string {
+	for _, content := range gjson.GetBytes(payload, "request.contents").Array() {
+		if content.Get("role").String() == "user" {
+			if parts := content.Get("parts"); parts.Exists() {
+				return sessionIDFromText(parts.Raw)
+			}
+		}
+	}
+	return generateSessionID()
+}
+
+// sessionIDFromText maps text to a session ID in the upstream "-<int63>" format.
+func sessionIDFromText(text string) string {
+	h := sha256.Sum256([]byte(text))
+	n := int64(binary.BigEndian.Uint64(h[:8])) & 0x7FFFFFFFFFFFFFFF
+	return "-" + strconv.FormatInt(n, 10)
+}
+
 func generateProjectID() string {
 	adjectives := []string{"useful", "bright", "swift", "calm", "bold"}
 	nouns := []string{"fuze", "wave", "spark", "flow", "core"}
@@ -1630,6 +1688,20 @@ func antigravityEffectiveMaxTokens(model string, payload []byte) (max int, fromM
 	return 0, false
 }
 
+// antigravitySchemaMode returns the schema mode declared for a model by the registered
+// model metadata, falling back to the static model config. Unknown models use the
+// Gemini schema.
+func antigravitySchemaMode(model string) string {
+	modelInfo := registry.GetGlobalRegistry().GetModelInfo(model, "antigravity")
+	if modelInfo == nil || modelInfo.SchemaMode == "" {
+		modelInfo = registry.LookupStaticModelInfo(model)
+	}
+	if modelInfo != nil && modelInfo.SchemaMode != "" {
+		return modelInfo.SchemaMode
+	}
+	return registry.SchemaModeGemini
+}
+
 // antigravityMinThinkingBudget returns the minimum thinking budget for a model.
 // Falls back to -1 if no model info is found.
 func antigravityMinThinkingBudget(model string) int {
@@ -1714,10 +1786,10 @@ func (e *AntigravityExecutor) executeGeminiWebSearch(ctx context.Context, auth *
 			projectID = strings.TrimSpace(pid)
 		}
 	}
-	geminiPayload = string(geminiToAntigravity(webSearchGeminiModel, []byte(geminiPayload), projectID))
+	geminiPayload = string(geminiToAntigravity(e.cfg, webSearchGeminiModel, []byte(geminiPayload), projectID, ginRequestHeaders(ctx)))
 
 	baseURLs := antigravityBaseURLFallbackOrder(auth)
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := trackAntigravityEndpoints(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 
 	for _, baseURL := range baseURLs {
 		base := strings.TrimSuffix(baseURL, "/")
@@ -1763,6 +1835,7 @@ func (e *AntigravityExecutor) executeGeminiWebSearch(ctx context.Context, auth *
 // 这是一个非流式实现，返回 Claude 格式的响应
 func (e *AntigravityExecutor) executeWebSearchOnly(ctx context.Context, auth *cliproxyauth.Auth, token string, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	reporter.estimateInput(req.Payload)
 
 	query := extractUserQuery(req.Payload)
 	if query == "" {
@@ -1791,6 +1864,7 @@ func (e *AntigravityExecutor) executeWebSearchOnly(ctx context.Context, auth *cl
 // 这是一个流式实现，返回 Claude SSE 格式的响应
 func (e *AntigravityExecutor) executeWebSearchOnlyStream(ctx context.Context, auth *cliproxyauth.Auth, token string, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	reporter.estimateInput(req.Payload)
 
 	query := extractUserQuery(req.Payload)
 	if query == "" {
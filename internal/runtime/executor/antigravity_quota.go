@@ -4,6 +4,11 @@ package executor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -26,11 +31,20 @@ type QuotaRefreshFunc func(ctx context.Context, authID string)
 type quotaRecoveryScheduler struct {
 	mu        sync.Mutex
 	timers    map[string]*time.Timer // authID -> timer
+	fireAt    map[string]time.Time   // authID -> scheduled fire time
 	refreshFn QuotaRefreshFunc
+	statePath string
 }
 
 var globalQuotaScheduler = &quotaRecoveryScheduler{
 	timers: make(map[string]*time.Timer),
+	fireAt: make(map[string]time.Time),
+}
+
+// QuotaRefreshEntry describes a pending scheduled quota refresh.
+type QuotaRefreshEntry struct {
+	AuthID string    `json:"auth_id"`
+	FireAt time.Time `json:"fire_at"`
 }
 
 // SetQuotaRefreshFunc registers the function to call when quota needs refresh.
@@ -47,27 +61,39 @@ func scheduleQuotaRefresh(authID string, resetTime time.Time) {
 		return
 	}
 
-	refreshAt := resetTime.Add(quotaRecoveryBuffer)
+	globalQuotaScheduler.mu.Lock()
+	defer globalQuotaScheduler.mu.Unlock()
+	globalQuotaScheduler.scheduleLocked(authID, resetTime.Add(quotaRecoveryBuffer))
+	globalQuotaScheduler.persistLocked()
+}
+
+// scheduleLocked (re)arms the timer of authID to fire at refreshAt. Callers hold mu.
+func (q *quotaRecoveryScheduler) scheduleLocked(authID string, refreshAt time.Time) {
 	delay := time.Until(refreshAt)
 	if delay <= 0 {
 		// Already past the refresh time, refresh immediately in background
 		delay = time.Second
 	}
 
-	globalQuotaScheduler.mu.Lock()
-	defer globalQuotaScheduler.mu.Unlock()
-
 	// Cancel existing timer if any
-	if existing, ok := globalQuotaScheduler.timers[authID]; ok {
+	if existing, ok := q.timers[authID]; ok {
 		existing.Stop()
 	}
 
 	// Schedule new timer
-	globalQuotaScheduler.timers[authID] = time.AfterFunc(delay, func() {
-		globalQuotaScheduler.mu.Lock()
-		delete(globalQuotaScheduler.timers, authID)
-		fn := globalQuotaScheduler.refreshFn
-		globalQuotaScheduler.mu.Unlock()
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		if q.timers[authID] != timer {
+			// Replaced or cancelled while this timer was firing.
+			q.mu.Unlock()
+			return
+		}
+		delete(q.timers, authID)
+		delete(q.fireAt, authID)
+		q.persistLocked()
+		fn := q.refreshFn
+		q.mu.Unlock()
 
 		if fn != nil {
 			log.Debugf("antigravity quota: triggering scheduled refresh for auth %s", authID)
@@ -76,23 +102,139 @@ func scheduleQuotaRefresh(authID string, resetTime time.Time) {
 			fn(ctx, authID)
 		}
 	})
+	q.timers[authID] = timer
+	q.fireAt[authID] = refreshAt
 
 	log.Debugf("antigravity quota: scheduled refresh for auth %s at %s (in %s)", authID, refreshAt.Format(time.RFC3339), delay.Round(time.Second))
 }
 
-// CancelQuotaRefresh cancels any pending quota refresh for the given auth.
-func CancelQuotaRefresh(authID string) {
-	if authID == "" {
+// cancelLocked stops the pending refresh of authID. Callers hold mu.
+func (q *quotaRecoveryScheduler) cancelLocked(authID string) bool {
+	timer, ok := q.timers[authID]
+	if !ok {
+		return false
+	}
+	timer.Stop()
+	delete(q.timers, authID)
+	delete(q.fireAt, authID)
+	return true
+}
+
+// persistLocked writes the pending refreshes to statePath so they survive restarts.
+// Callers hold mu.
+func (q *quotaRecoveryScheduler) persistLocked() {
+	if q.statePath == "" {
 		return
 	}
+	entries := q.entriesLocked()
+	raw, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		log.Warnf("antigravity quota: failed to encode scheduled refreshes: %v", err)
+		return
+	}
+	tmp := q.statePath + ".tmp"
+	if err = os.WriteFile(tmp, raw, 0o600); err != nil {
+		log.Warnf("antigravity quota: failed to persist scheduled refreshes: %v", err)
+		return
+	}
+	if err = os.Rename(tmp, q.statePath); err != nil {
+		log.Warnf("antigravity quota: failed to persist scheduled refreshes: %v", err)
+	}
+}
+
+func (q *quotaRecoveryScheduler) entriesLocked() []QuotaRefreshEntry {
+	entries := make([]QuotaRefreshEntry, 0, len(q.fireAt))
+	for authID, at := range q.fireAt {
+		entries = append(entries, QuotaRefreshEntry{AuthID: authID, FireAt: at})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].FireAt.Equal(entries[j].FireAt) {
+			return entries[i].FireAt.Before(entries[j].FireAt)
+		}
+		return entries[i].AuthID < entries[j].AuthID
+	})
+	return entries
+}
+
+// RestoreQuotaRefreshes makes the scheduler persist its pending refreshes to path and
+// re-arms the refreshes saved there by a previous run. Refreshes whose time has passed
+// fire shortly after restore.
+func RestoreQuotaRefreshes(path string) error {
+	globalQuotaScheduler.mu.Lock()
+	defer globalQuotaScheduler.mu.Unlock()
+
+	globalQuotaScheduler.statePath = path
+	if path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("antigravity quota: read scheduled refreshes: %w", err)
+	}
+	var entries []QuotaRefreshEntry
+	if err = json.Unmarshal(raw, &entries); err != nil {
+		return fmt.Errorf("antigravity quota: decode scheduled refreshes: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.AuthID == "" || entry.FireAt.IsZero() {
+			continue
+		}
+		if _, pending := globalQuotaScheduler.timers[entry.AuthID]; pending {
+			continue
+		}
+		globalQuotaScheduler.scheduleLocked(entry.AuthID, entry.FireAt)
+	}
+	globalQuotaScheduler.persistLocked()
+	return nil
+}
 
+// PendingQuotaRefreshes lists the scheduled quota refreshes, earliest first.
+func PendingQuotaRefreshes() []QuotaRefreshEntry {
 	globalQuotaScheduler.mu.Lock()
 	defer globalQuotaScheduler.mu.Unlock()
+	return globalQuotaScheduler.entriesLocked()
+}
+
+// CancelQuotaRefresh cancels any pending quota refresh for the given auth and reports
+// whether one was pending.
+func CancelQuotaRefresh(authID string) bool {
+	if authID == "" {
+		return false
+	}
+
+	globalQuotaScheduler.mu.Lock()
+	defer globalQuotaScheduler.mu.Unlock()
+
+	if !globalQuotaScheduler.cancelLocked(authID) {
+		return false
+	}
+	globalQuotaScheduler.persistLocked()
+	return true
+}
+
+// TriggerQuotaRefresh runs the quota refresh of authID now, replacing any pending scheduled
+// refresh. It fails when no refresh function has been registered.
+func TriggerQuotaRefresh(ctx context.Context, authID string) error {
+	if authID == "" {
+		return fmt.Errorf("antigravity quota: auth id is required")
+	}
+
+	globalQuotaScheduler.mu.Lock()
+	fn := globalQuotaScheduler.refreshFn
+	if fn != nil && globalQuotaScheduler.cancelLocked(authID) {
+		globalQuotaScheduler.persistLocked()
+	}
+	globalQuotaScheduler.mu.Unlock()
 
-	if timer, ok := globalQuotaScheduler.timers[authID]; ok {
-		timer.Stop()
-		delete(globalQuotaScheduler.timers, authID)
+	if fn == nil {
+		return fmt.Errorf("antigravity quota: refresh is not available")
 	}
+	log.Debugf("antigravity quota: triggering manual refresh for auth %s", authID)
+	fn(ctx, authID)
+	return nil
 }
 
 // quotaInfo holds parsed quota data from the API response.
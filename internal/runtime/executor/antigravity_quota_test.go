@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func resetQuotaSchedulerForTest(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		globalQuotaScheduler.mu.Lock()
+		for id := range globalQuotaScheduler.timers {
+			globalQuotaScheduler.cancelLocked(id)
+		}
+		globalQuotaScheduler.statePath = ""
+		globalQuotaScheduler.refreshFn = nil
+		globalQuotaScheduler.mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestQuotaRefreshesPersistAcrossRestore(t *testing.T) {
+	resetQuotaSchedulerForTest(t)
+	path := filepath.Join(t.TempDir(), "refresh.json")
+	if err := RestoreQuotaRefreshes(path); err != nil {
+		t.Fatalf("restore with missing file: %v", err)
+	}
+
+	reset := time.Now().Add(time.Hour)
+	scheduleQuotaRefresh("a", reset)
+	scheduleQuotaRefresh("b", reset.Add(time.Hour))
+	if pending := PendingQuotaRefreshes(); len(pending) != 2 || pending[0].AuthID != "a" {
+		t.Fatalf("pending = %+v, want a then b", pending)
+	}
+	if !CancelQuotaRefresh("b") || CancelQuotaRefresh("b") {
+		t.Fatal("cancel should succeed once")
+	}
+
+	// Simulate a restart: drop the in-memory timers without touching the state file.
+	globalQuotaScheduler.mu.Lock()
+	globalQuotaScheduler.cancelLocked("a")
+	globalQuotaScheduler.statePath = ""
+	globalQuotaScheduler.mu.Unlock()
+
+	if err := RestoreQuotaRefreshes(path); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	pending := PendingQuotaRefreshes()
+	want := reset.Add(quotaRecoveryBuffer)
+	if len(pending) != 1 || pending[0].AuthID != "a" || !pending[0].FireAt.Equal(want) {
+		t.Fatalf("pending after restore = %+v, want only a at %s", pending, want)
+	}
+}
+
+func TestQuotaRefreshRestoreRearmsSavedEntries(t *testing.T) {
+	resetQuotaSchedulerForTest(t)
+	path := filepath.Join(t.TempDir(), "refresh.json")
+	fireAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	data := `[{"auth_id":"a","fire_at":"` + fireAt.Format(time.RFC3339) + `"}]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RestoreQuotaRefreshes(path); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	pending := PendingQuotaRefreshes()
+	if len(pending) != 1 || pending[0].AuthID != "a" || !pending[0].FireAt.Equal(fireAt) {
+		t.Fatalf("pending = %+v, want a at %s", pending, fireAt)
+	}
+}
+
+func TestTriggerQuotaRefreshRunsNowAndClearsPending(t *testing.T) {
+	resetQuotaSchedulerForTest(t)
+	if err := TriggerQuotaRefresh(context.Background(), "a"); err == nil {
+		t.Fatal("expected an error without a refresh function")
+	}
+
+	var refreshed []string
+	SetQuotaRefreshFunc(func(_ context.Context, authID string) {
+		refreshed = append(refreshed, authID)
+	})
+	scheduleQuotaRefresh("a", time.Now().Add(time.Hour))
+	if err := TriggerQuotaRefresh(context.Background(), "a"); err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	if len(refreshed) != 1 || refreshed[0] != "a" {
+		t.Fatalf("refreshed = %v, want [a]", refreshed)
+	}
+	if pending := PendingQuotaRefreshes(); len(pending) != 0 {
+		t.Fatalf("pending = %+v, want none", pending)
+	}
+}
@@ -0,0 +1,377 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	azureADDefaultAuthorityHost = "https://login.microsoftonline.com"
+	azureCognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+	// azureADTokenSkew renews cached Azure AD tokens this long before they expire.
+	azureADTokenSkew = 2 * time.Minute
+)
+
+// AzureOpenAIExecutor runs OpenAI chat completions against Azure OpenAI deployments.
+// The model routed to the executor is the deployment name; the conductor maps
+// client-facing model names to deployments through the configured aliases.
+type AzureOpenAIExecutor struct {
+	cfg *config.Config
+
+	tokenMu sync.Mutex
+	tokens  map[string]azureADToken
+}
+
+type azureADToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+func NewAzureOpenAIExecutor(cfg *config.Config) *AzureOpenAIExecutor {
+	return &AzureOpenAIExecutor{cfg: cfg, tokens: make(map[string]azureADToken)}
+}
+
+func (e *AzureOpenAIExecutor) Identifier() string { return "azure-openai" }
+
+// azureOpenAICredentials holds the per-auth connection settings of an Azure OpenAI resource.
+type azureOpenAICredentials struct {
+	endpoint      string
+	apiVersion    string
+	apiKey        string
+	tenantID      string
+	clientID      string
+	clientSecret  string
+	authorityHost string
+}
+
+func azureOpenAICreds(a *cliproxyauth.Auth) azureOpenAICredentials {
+	c := azureOpenAICredentials{apiVersion: config.DefaultAzureOpenAIAPIVersion, authorityHost: azureADDefaultAuthorityHost}
+	if a == nil || a.Attributes == nil {
+		return c
+	}
+	c.endpoint = strings.TrimRight(strings.TrimSpace(a.Attributes["base_url"]), "/")
+	if v := strings.TrimSpace(a.Attributes["api_version"]); v != "" {
+		c.apiVersion = v
+	}
+	c.tenantID = strings.TrimSpace(a.Attributes["tenant_id"])
+	c.clientID = strings.TrimSpace(a.Attributes["client_id"])
+	c.clientSecret = a.Attributes["client_secret"]
+	if v := strings.TrimRight(strings.TrimSpace(a.Attributes["authority_host"]), "/"); v != "" {
+		c.authorityHost = v
+	}
+	if c.tenantID == "" || c.clientID == "" || c.clientSecret == "" {
+		c.apiKey = strings.TrimSpace(a.Attributes["api_key"])
+	}
+	return c
+}
+
+// deploymentURL returns the URL of an operation on a deployment, with the api-version set.
+func (c azureOpenAICredentials) deploymentURL(deployment, operation string) string {
+	return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		c.endpoint, url.PathEscape(deployment), operation, url.QueryEscape(c.apiVersion))
+}
+
+// authorize sets the api-key header, or an Azure AD bearer token for app credentials.
+func (e *AzureOpenAIExecutor) authorize(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) error {
+	c := azureOpenAICreds(auth)
+	if c.apiKey != "" {
+		req.Header.Set("api-key", c.apiKey)
+		return nil
+	}
+	if c.tenantID == "" || c.clientID == "" || c.clientSecret == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "azure openai executor: missing credentials"}
+	}
+	token, err := e.adToken(ctx, auth, c)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// adToken returns a cached Azure AD token for the Cognitive Services scope, requesting a
+// new one with the client credentials grant when the cached token is close to expiry.
+func (e *AzureOpenAIExecutor) adToken(ctx context.Context, auth *cliproxyauth.Auth, c azureOpenAICredentials) (string, error) {
+	key := c.authorityHost + "|" + c.tenantID + "|" + c.clientID
+	e.tokenMu.Lock()
+	defer e.tokenMu.Unlock()
+	if cached, ok := e.tokens[key]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+		"scope":         {azureCognitiveServicesScope},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", c.authorityHost, url.PathEscape(c.tenantID))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 30*time.Second)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("azure openai executor: token request failed: %w", err)
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close token response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return "", statusErr{code: http.StatusUnauthorized, msg: fmt.Sprintf("azure openai executor: token request failed with status %d: %s", httpResp.StatusCode, body)}
+	}
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = json.Unmarshal(body, &payload); err != nil || payload.AccessToken == "" {
+		return "", statusErr{code: http.StatusUnauthorized, msg: "azure openai executor: token response missing access_token"}
+	}
+	expiresAt := time.Now().Add(time.Duration(payload.ExpiresIn)*time.Second - azureADTokenSkew)
+	e.tokens[key] = azureADToken{value: payload.AccessToken, expiresAt: expiresAt}
+	return payload.AccessToken, nil
+}
+
+// PrepareRequest injects the Azure OpenAI credentials into the outgoing HTTP request.
+func (e *AzureOpenAIExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return e.authorize(req.Context(), auth, req)
+}
+
+// HttpRequest injects the Azure OpenAI credentials into the request and executes it.
+func (e *AzureOpenAIExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("azure openai executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// buildBody translates the request to an OpenAI chat completions body.
+func (e *AzureOpenAIExecutor) buildBody(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string, stream bool) ([]byte, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, originalPayload, stream)
+	translated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, stream)
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	translated = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel)
+	return thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+}
+
+// post sends a chat completions request to the deployment and returns the successful response.
+func (e *AzureOpenAIExecutor) post(ctx context.Context, auth *cliproxyauth.Auth, deployment string, body []byte, stream bool) (*http.Response, error) {
+	c := azureOpenAICreds(auth)
+	if c.endpoint == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing azure openai endpoint"}
+	}
+	requestURL := c.deploymentURL(deployment, "chat/completions")
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "cli-proxy-azure-openai")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	if err = e.authorize(ctx, auth, httpReq); err != nil {
+		return nil, err
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       requestURL,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+func (e *AzureOpenAIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	reporter.estimateInput(req.Payload)
+	defer reporter.trackFailure(ctx, &err)
+
+	translated, err := e.buildBody(ctx, req, opts, baseModel, false)
+	if err != nil {
+		return resp, err
+	}
+	httpResp, err := e.post(ctx, auth, baseModel, translated, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, body)
+	reporter.publish(ctx, parseOpenAIUsage(body))
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, sdktranslator.FromString("openai"), opts.SourceFormat, req.Model, opts.OriginalRequest, translated, body, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *AzureOpenAIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	reporter.estimateInput(req.Payload)
+	defer reporter.trackFailure(ctx, &err)
+
+	translated, err := e.buildBody(ctx, req, opts, baseModel, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.post(ctx, auth, baseModel, translated, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("azure openai executor: close response body error: %v", errClose)
+			}
+		}()
+		to := sdktranslator.FromString("openai")
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseOpenAIStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			if !bytes.HasPrefix(line, []byte("data:")) {
+				continue
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return stream, nil
+}
+
+// CountTokens estimates the prompt size locally; Azure OpenAI has no token counting endpoint.
+func (e *AzureOpenAIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, false)
+
+	enc, err := tokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+}
+
+// Refresh is a no-op; Azure AD tokens are requested on demand and cached per credential.
+func (e *AzureOpenAIExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	_ = ctx
+	return auth, nil
+}
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const azureTestCompletion = `{"id":"c1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`
+
+func TestAzureOpenAIExecutorAPIKey(t *testing.T) {
+	var gotURL, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotKey = r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(azureTestCompletion))
+	}))
+	defer server.Close()
+
+	exec := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "azure-1", Provider: "azure-openai", Attributes: map[string]string{
+		"api_key":     "resource-key",
+		"base_url":    server.URL,
+		"api_version": "2024-06-01",
+	}}
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "my-gpt4o",
+		Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotURL != "/openai/deployments/my-gpt4o/chat/completions?api-version=2024-06-01" {
+		t.Fatalf("url = %s", gotURL)
+	}
+	if gotKey != "resource-key" {
+		t.Fatalf("api-key = %q", gotKey)
+	}
+	if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "hi" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestAzureOpenAIExecutorADTokenIsCached(t *testing.T) {
+	var tokenRequests atomic.Int32
+	var gotForm, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+			tokenRequests.Add(1)
+			body, _ := io.ReadAll(r.Body)
+			gotForm = string(body)
+			_, _ = w.Write([]byte(`{"access_token":"aad-token","expires_in":3600}`))
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(azureTestCompletion))
+	}))
+	defer server.Close()
+
+	exec := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "azure-2", Provider: "azure-openai", Attributes: map[string]string{
+		"api_key":        "client",
+		"base_url":       server.URL,
+		"tenant_id":      "tenant",
+		"client_id":      "client",
+		"client_secret":  "secret",
+		"authority_host": server.URL,
+	}}
+	req := cliproxyexecutor.Request{Model: "dep", Payload: []byte(`{"messages":[{"role":"user","content":"hello"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+	for i := 0; i < 2; i++ {
+		if _, err := exec.Execute(context.Background(), auth, req, opts); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	if gotAuth != "Bearer aad-token" {
+		t.Fatalf("Authorization = %q", gotAuth)
+	}
+	if n := tokenRequests.Load(); n != 1 {
+		t.Fatalf("token requests = %d, want 1", n)
+	}
+	if !strings.Contains(gotForm, "grant_type=client_credentials") || !strings.Contains(gotForm, "cognitiveservices.azure.com") {
+		t.Fatalf("token form = %s", gotForm)
+	}
+}
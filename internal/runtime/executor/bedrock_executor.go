@@ -0,0 +1,444 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// bedrockAnthropicVersion is the Messages API version Bedrock expects in the request body.
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// BedrockExecutor runs Anthropic Claude models on AWS Bedrock through the InvokeModel API.
+// Requests in any source format are translated to the Claude Messages format, which is
+// Bedrock's native body for Anthropic models, and signed with SigV4 (or sent with a
+// Bedrock API key as a bearer token).
+type BedrockExecutor struct {
+	cfg *config.Config
+}
+
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor { return &BedrockExecutor{cfg: cfg} }
+
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// bedrockCredentials holds the per-auth connection settings of a Bedrock credential.
+type bedrockCredentials struct {
+	aws     awsCredentials
+	apiKey  string
+	region  string
+	baseURL string
+}
+
+func bedrockCreds(a *cliproxyauth.Auth) bedrockCredentials {
+	var c bedrockCredentials
+	if a == nil || a.Attributes == nil {
+		return c
+	}
+	c.region = strings.TrimSpace(a.Attributes["region"])
+	c.baseURL = strings.TrimRight(strings.TrimSpace(a.Attributes["base_url"]), "/")
+	c.aws = awsCredentials{
+		AccessKeyID:     a.Attributes["access_key_id"],
+		SecretAccessKey: a.Attributes["secret_access_key"],
+		SessionToken:    a.Attributes["session_token"],
+	}
+	if c.aws.AccessKeyID == "" || c.aws.SecretAccessKey == "" {
+		c.apiKey = a.Attributes["api_key"]
+	}
+	return c
+}
+
+func (c bedrockCredentials) endpoint() string {
+	if c.baseURL != "" {
+		return c.baseURL
+	}
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", c.region)
+}
+
+// sign authenticates req for Bedrock. body must be the exact request payload.
+func (c bedrockCredentials) sign(req *http.Request, body []byte) error {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		return nil
+	}
+	if c.aws.AccessKeyID == "" || c.aws.SecretAccessKey == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "bedrock executor: missing credentials"}
+	}
+	if c.region == "" {
+		return statusErr{code: http.StatusBadRequest, msg: "bedrock executor: missing region"}
+	}
+	signAWSRequestV4(req, body, c.aws, c.region, "bedrock", time.Now())
+	return nil
+}
+
+// PrepareRequest signs an arbitrary Bedrock request with the auth's credentials.
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		_ = req.Body.Close()
+		body = data
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	return bedrockCreds(auth).sign(req, body)
+}
+
+// HttpRequest signs the request with the Bedrock credentials and executes it.
+func (e *BedrockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bedrock executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// buildBody translates the request to a Bedrock InvokeModel body for an Anthropic model.
+func (e *BedrockExecutor) buildBody(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, baseModel string) ([]byte, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, originalPayload, true)
+	body := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, true)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	requestedModel := payloadRequestedModel(opts, req.Model)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = disableThinkingIfToolChoiceForced(body)
+
+	// Bedrock takes the model from the URL, streaming from the action and betas from the body.
+	var betas []string
+	betas, body = extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	if len(betas) > 0 {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	return body, nil
+}
+
+// invoke sends body to the InvokeModel action of model and returns the successful response.
+func (e *BedrockExecutor) invoke(ctx context.Context, auth *cliproxyauth.Auth, model, action string, body []byte) (*http.Response, error) {
+	creds := bedrockCreds(auth)
+	url := creds.endpoint() + "/model/" + awsURIEscape(model) + "/" + action
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if action == "invoke-with-response-stream" {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	if err = creds.sign(httpReq, body); err != nil {
+		return nil, err
+	}
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	reporter.estimateInput(req.Payload)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body, err := e.buildBody(ctx, req, opts, baseModel)
+	if err != nil {
+		return resp, err
+	}
+
+	// The Claude non-stream translators consume SSE, so other formats use the streaming
+	// action and translate the collected events.
+	if from == to {
+		httpResp, errInvoke := e.invoke(ctx, auth, baseModel, "invoke", body)
+		if errInvoke != nil {
+			return resp, errInvoke
+		}
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("response body close error: %v", errClose)
+			}
+		}()
+		data, errRead := io.ReadAll(httpResp.Body)
+		if errRead != nil {
+			recordAPIResponseError(ctx, e.cfg, errRead)
+			return resp, errRead
+		}
+		appendAPIResponseChunk(ctx, e.cfg, data)
+		reporter.publish(ctx, parseClaudeUsage(data))
+		return cliproxyexecutor.Response{Payload: data}, nil
+	}
+
+	httpResp, err := e.invoke(ctx, auth, baseModel, "invoke-with-response-stream", body)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+	var sse bytes.Buffer
+	err = readBedrockStream(httpResp.Body, func(event []byte) error {
+		line := bedrockSSEData(event)
+		appendAPIResponseChunk(ctx, e.cfg, line)
+		if detail, ok := parseClaudeStreamUsage(line); ok {
+			reporter.publish(ctx, detail)
+		}
+		sse.Write(line)
+		sse.WriteByte('\n')
+		return nil
+	})
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, sse.Bytes(), &param)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	reporter.estimateInput(req.Payload)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body, err := e.buildBody(ctx, req, opts, baseModel)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.invoke(ctx, auth, baseModel, "invoke-with-response-stream", body)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("response body close error: %v", errClose)
+			}
+		}()
+
+		var param any
+		errRead := readBedrockStream(httpResp.Body, func(event []byte) error {
+			line := bedrockSSEData(event)
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			if from == to {
+				// Claude clients get the events in Anthropic's own SSE framing.
+				chunk := fmt.Sprintf("event: %s\n%s\n\n", gjson.GetBytes(event, "type").String(), line)
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk)}
+				return nil
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, body, line, &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+			return nil
+		})
+		if errRead != nil {
+			recordAPIResponseError(ctx, e.cfg, errRead)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errRead}
+		}
+	}()
+	return stream, nil
+}
+
+// CountTokens estimates the prompt size locally; Bedrock has no free token counting for
+// every model.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequestContext(ctx, from, to, baseModel, req.Payload, false)
+
+	enc, err := tokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: token counting failed: %w", err)
+	}
+
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+}
+
+// Refresh is a no-op for static AWS credentials.
+func (e *BedrockExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	log.Debugf("bedrock executor: refresh called")
+	_ = ctx
+	return auth, nil
+}
+
+// readBedrockStream decodes an InvokeModelWithResponseStream body and calls fn with each
+// Claude stream event. Exceptions sent in the stream are returned as status errors.
+func readBedrockStream(r io.Reader, fn func(event []byte) error) error {
+	for {
+		msg, err := readAWSEventStreamMessage(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		switch msg.Headers[":message-type"] {
+		case "exception", "error":
+			kind := msg.Headers[":exception-type"]
+			if kind == "" {
+				kind = msg.Headers[":error-code"]
+			}
+			return statusErr{code: bedrockExceptionStatus(kind), msg: fmt.Sprintf("bedrock %s: %s", kind, string(msg.Payload))}
+		}
+		if msg.Headers[":event-type"] != "chunk" {
+			continue
+		}
+		encoded := gjson.GetBytes(msg.Payload, "bytes").String()
+		if encoded == "" {
+			continue
+		}
+		event, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("bedrock executor: decode stream chunk: %w", err)
+		}
+		if err = fn(event); err != nil {
+			return err
+		}
+	}
+}
+
+// bedrockSSEData turns a Claude stream event into an SSE data line, dropping the
+// Bedrock-only invocation metrics.
+func bedrockSSEData(event []byte) []byte {
+	if gjson.GetBytes(event, "amazon-bedrock-invocationMetrics").Exists() {
+		event, _ = sjson.DeleteBytes(event, "amazon-bedrock-invocationMetrics")
+	}
+	line := make([]byte, 0, len(event)+6)
+	line = append(line, "data: "...)
+	return append(line, event...)
+}
+
+func bedrockExceptionStatus(kind string) int {
+	switch kind {
+	case "throttlingException":
+		return http.StatusTooManyRequests
+	case "validationException":
+		return http.StatusBadRequest
+	case "accessDeniedException":
+		return http.StatusForbidden
+	case "resourceNotFoundException":
+		return http.StatusNotFound
+	case "modelTimeoutException":
+		return http.StatusGatewayTimeout
+	case "serviceUnavailableException":
+		return http.StatusServiceUnavailable
+	case "modelStreamErrorException":
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// TestSignAWSRequestV4 checks the signer against the "get-vanilla" case of the AWS SigV4 test suite.
+func TestSignAWSRequestV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequestV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAWSCanonicalURIDoubleEncodesModelIDs(t *testing.T) {
+	path := "/model/" + awsURIEscape("anthropic.claude-v2:1") + "/invoke"
+	if path != "/model/anthropic.claude-v2%3A1/invoke" {
+		t.Fatalf("escaped path = %s", path)
+	}
+	if got := awsCanonicalURI(path); got != "/model/anthropic.claude-v2%253A1/invoke" {
+		t.Fatalf("canonical URI = %s", got)
+	}
+}
+
+func encodeAWSEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var hdr bytes.Buffer
+	for name, value := range headers {
+		hdr.WriteByte(byte(len(name)))
+		hdr.WriteString(name)
+		hdr.WriteByte(7)
+		_ = binary.Write(&hdr, binary.BigEndian, uint16(len(value)))
+		hdr.WriteString(value)
+	}
+	total := uint32(12 + hdr.Len() + len(payload) + 4)
+	var msg bytes.Buffer
+	_ = binary.Write(&msg, binary.BigEndian, total)
+	_ = binary.Write(&msg, binary.BigEndian, uint32(hdr.Len()))
+	_ = binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(hdr.Bytes())
+	msg.Write(payload)
+	_ = binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func bedrockChunk(event string) []byte {
+	payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+	return encodeAWSEventStreamMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   "chunk",
+		":content-type": "application/json",
+	}, []byte(payload))
+}
+
+func TestReadBedrockStream(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(bedrockChunk(`{"type":"message_start","message":{"usage":{"input_tokens":3}}}`))
+	stream.Write(bedrockChunk(`{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":3}}`))
+	stream.Write(encodeAWSEventStreamMessage(map[string]string{
+		":message-type":   "exception",
+		":exception-type": "throttlingException",
+	}, []byte(`{"message":"slow down"}`)))
+
+	var events []string
+	err := readBedrockStream(&stream, func(event []byte) error {
+		events = append(events, string(bedrockSSEData(event)))
+		return nil
+	})
+	if len(events) != 2 || !strings.HasPrefix(events[0], `data: {"type":"message_start"`) {
+		t.Fatalf("events = %q", events)
+	}
+	if strings.Contains(events[1], "invocationMetrics") {
+		t.Fatalf("invocation metrics should be dropped: %s", events[1])
+	}
+	var se statusErr
+	if !errors.As(err, &se) || se.code != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want 429 status error", err)
+	}
+
+	corrupt := bedrockChunk(`{"type":"ping"}`)
+	corrupt[len(corrupt)-1] ^= 0xff
+	if err = readBedrockStream(bytes.NewReader(corrupt), func([]byte) error { return nil }); err == nil {
+		t.Fatal("expected checksum error")
+	}
+}
+
+func newBedrockTestAuth(baseURL string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{ID: "bedrock-1", Provider: "bedrock", Attributes: map[string]string{
+		"api_key":           "AKID",
+		"access_key_id":     "AKID",
+		"secret_access_key": "secret",
+		"region":            "us-west-2",
+		"base_url":          baseURL,
+	}}
+}
+
+func TestBedrockExecutorInvoke(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	exec := NewBedrockExecutor(&config.Config{})
+	resp, err := exec.Execute(context.Background(), newBedrockTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "us.anthropic.claude-sonnet-4-5-20250929-v1:0",
+		Payload: []byte(`{"model":"x","max_tokens":10,"stream":false,"betas":["b1"],"messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotPath != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%3A0/invoke" {
+		t.Fatalf("path = %s", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(gotAuth, "/us-west-2/bedrock/aws4_request") {
+		t.Fatalf("Authorization = %s", gotAuth)
+	}
+	if gjson.GetBytes(gotBody, "model").Exists() || gjson.GetBytes(gotBody, "stream").Exists() {
+		t.Fatalf("model and stream must not be sent: %s", gotBody)
+	}
+	if gjson.GetBytes(gotBody, "anthropic_version").String() != bedrockAnthropicVersion || gjson.GetBytes(gotBody, "anthropic_beta.0").String() != "b1" {
+		t.Fatalf("body = %s", gotBody)
+	}
+	if gjson.GetBytes(resp.Payload, "content.0.text").String() != "hi" {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestBedrockExecutorStreamsClaudeEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/invoke-with-response-stream") {
+			http.Error(w, "wrong action", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		_, _ = w.Write(bedrockChunk(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}`))
+		_, _ = w.Write(bedrockChunk(`{"type":"message_stop"}`))
+	}))
+	defer server.Close()
+
+	exec := NewBedrockExecutor(&config.Config{})
+	stream, err := exec.ExecuteStream(context.Background(), newBedrockTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "anthropic.claude-3-haiku-20240307-v1:0",
+		Payload: []byte(`{"max_tokens":10,"stream":true,"messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var out strings.Builder
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		out.Write(chunk.Payload)
+	}
+	want := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hi\"}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+	if out.String() != want {
+		t.Fatalf("stream =\n%q\nwant\n%q", out.String(), want)
+	}
+}
@@ -0,0 +1,242 @@
+package executor
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// awsCredentials is an IAM key pair with an optional STS session token.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+const awsSigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// signAWSRequestV4 signs req in place with AWS Signature Version 4. body must be the exact
+// request payload. Host, Content-Type and every X-Amz-* header present are signed.
+func signAWSRequestV4(req *http.Request, body []byte, creds awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	req.Header.Del("Authorization")
+
+	payloadHash := sha256Hex(body)
+	canonicalHeaders, signedHeaders := awsCanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalURI(req.URL.EscapedPath()),
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{awsSigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigV4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func awsCanonicalHeaders(req *http.Request) (canonical, signed string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, vals := range req.Header {
+		lower := strings.ToLower(name)
+		if lower != "content-type" && !strings.HasPrefix(lower, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, len(vals))
+		for i, v := range vals {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(values[name])
+		b.WriteByte('\n')
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// awsCanonicalURI encodes each segment of the already-escaped path once more, as SigV4
+// requires for every service except S3.
+func awsCanonicalURI(escapedPath string) string {
+	if escapedPath == "" {
+		return "/"
+	}
+	segments := strings.Split(escapedPath, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(query map[string][]string) string {
+	if len(query) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(query))
+	for key, vals := range query {
+		for _, v := range vals {
+			pairs = append(pairs, awsURIEscape(key)+"="+awsURIEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEscape percent-encodes everything except the RFC 3986 unreserved characters.
+func awsURIEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEventStreamMessage is one frame of the application/vnd.amazon.eventstream encoding.
+type awsEventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// readAWSEventStreamMessage reads the next frame from r. It returns io.EOF at a clean end
+// of stream. Only string headers are kept; other header types are skipped.
+func readAWSEventStreamMessage(r io.Reader) (awsEventStreamMessage, error) {
+	var msg awsEventStreamMessage
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(r, prelude); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return msg, fmt.Errorf("event stream: truncated prelude")
+		}
+		return msg, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return msg, fmt.Errorf("event stream: prelude checksum mismatch")
+	}
+	if totalLen < 16 || headersLen > totalLen-16 || totalLen > 16<<20 {
+		return msg, fmt.Errorf("event stream: invalid frame length %d", totalLen)
+	}
+	rest := make([]byte, totalLen-12)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return msg, fmt.Errorf("event stream: truncated frame: %w", err)
+	}
+	body, trailer := rest[:len(rest)-4], rest[len(rest)-4:]
+	crc := crc32.NewIEEE()
+	crc.Write(prelude)
+	crc.Write(body)
+	if crc.Sum32() != binary.BigEndian.Uint32(trailer) {
+		return msg, fmt.Errorf("event stream: message checksum mismatch")
+	}
+
+	headers, err := parseAWSEventStreamHeaders(body[:headersLen])
+	if err != nil {
+		return msg, err
+	}
+	msg.Headers = headers
+	msg.Payload = body[headersLen:]
+	return msg, nil
+}
+
+func parseAWSEventStreamHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	buf := bytes.NewReader(data)
+	for buf.Len() > 0 {
+		nameLen, _ := buf.ReadByte()
+		name := make([]byte, nameLen)
+		if _, err := io.ReadFull(buf, name); err != nil {
+			return nil, fmt.Errorf("event stream: truncated header name")
+		}
+		kind, err := buf.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("event stream: truncated header type")
+		}
+		var skip int64
+		switch kind {
+		case 0, 1: // boolean true / false
+		case 2: // byte
+			skip = 1
+		case 3: // int16
+			skip = 2
+		case 4: // int32
+			skip = 4
+		case 5, 8: // int64, timestamp
+			skip = 8
+		case 9: // uuid
+			skip = 16
+		case 6, 7: // byte array, string
+			var n uint16
+			if err = binary.Read(buf, binary.BigEndian, &n); err != nil {
+				return nil, fmt.Errorf("event stream: truncated header length")
+			}
+			value := make([]byte, n)
+			if _, err = io.ReadFull(buf, value); err != nil {
+				return nil, fmt.Errorf("event stream: truncated header value")
+			}
+			if kind == 7 {
+				headers[string(name)] = string(value)
+			}
+		default:
+			return nil, fmt.Errorf("event stream: unknown header type %d", kind)
+		}
+		if skip > 0 {
+			if _, err = buf.Seek(skip, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return headers, nil
+}
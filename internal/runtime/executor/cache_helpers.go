@@ -3,6 +3,8 @@ package executor
 import (
 	"sync"
 	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 )
 
 type codexCache struct {
@@ -66,3 +68,16 @@ func setCodexCache(key string, cache codexCache) {
 	codexCacheMap[key] = cache
 	codexCacheMu.Unlock()
 }
+
+// conversationCacheKey returns the conversation identifier attached by system prompt
+// deduplication, or "" when it is disabled or the client sent no session marker.
+func conversationCacheKey(opts cliproxyexecutor.Options) string {
+	key, _ := opts.Metadata[cliproxyexecutor.ConversationKeyMetadataKey].(string)
+	return key
+}
+
+// systemPromptRepeated reports whether the conversation resent its previous system prompt.
+func systemPromptRepeated(opts cliproxyexecutor.Options) bool {
+	repeat, _ := opts.Metadata[cliproxyexecutor.SystemPromptRepeatMetadataKey].(bool)
+	return repeat
+}
@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"net/http"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// clockDriftTransport reports the Date header of each upstream response so the clock offset
+// to the provider can be taken into account when tokens are refreshed.
+type clockDriftTransport struct {
+	base     http.RoundTripper
+	provider string
+}
+
+func (t *clockDriftTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	sent := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err == nil && resp != nil {
+		cliproxyauth.ObserveServerDate(t.provider, resp.Header.Get("Date"), sent, time.Now())
+	}
+	return resp, err
+}
@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// countTokensCacheTTL bounds how long an identical countTokens payload reuses a result.
+	// Agent clients re-count the same conversation on every keystroke-level edit.
+	countTokensCacheTTL = 30 * time.Second
+	// countTokensCacheMax caps cached results; the cache is cleared when it fills.
+	countTokensCacheMax = 1024
+	// countTokensCallTimeout bounds a shared upstream call, which no single caller owns.
+	countTokensCallTimeout = 60 * time.Second
+)
+
+type countTokensEntry struct {
+	payload []byte
+	expire  time.Time
+}
+
+// countTokensCoalescer collapses concurrent identical countTokens calls into one upstream
+// request and briefly caches successful results.
+type countTokensCoalescer struct {
+	group   singleflight.Group
+	mu      sync.Mutex
+	entries map[string]countTokensEntry
+}
+
+var antigravityCountTokens = &countTokensCoalescer{entries: make(map[string]countTokensEntry)}
+
+// countTokensKey identifies a countTokens call by everything that affects its result.
+// Credentials are deliberately left out: the count depends only on the request.
+func countTokensKey(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		_, _ = h.Write(part)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Do returns a cached result for key, joins an in-flight call for key, or runs fn.
+// The shared call runs on a context detached from ctx, so a caller that goes away does
+// not fail the others waiting on it; ctx only bounds how long this caller waits.
+func (c *countTokensCoalescer) Do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	now := time.Now()
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expire) {
+		c.mu.Unlock()
+		return entry.payload, nil
+	}
+	c.mu.Unlock()
+
+	shared := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(key, func() (any, error) {
+		callCtx, cancel := context.WithTimeout(shared, countTokensCallTimeout)
+		defer cancel()
+		payload, errFn := fn(callCtx)
+		if errFn != nil {
+			return nil, errFn
+		}
+		c.store(key, payload)
+		return payload, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+func (c *countTokensCoalescer) store(key string, payload []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= countTokensCacheMax {
+		c.entries = make(map[string]countTokensEntry)
+	}
+	c.entries[key] = countTokensEntry{payload: payload, expire: time.Now().Add(countTokensCacheTTL)}
+}
@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCountTokensCoalescerSharesConcurrentCalls(t *testing.T) {
+	c := &countTokensCoalescer{entries: make(map[string]countTokensEntry)}
+	key := countTokensKey([]byte("model"), []byte(`{"messages":[]}`))
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte(`{"input_tokens":7}`), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := c.Do(context.Background(), key, fn)
+			if err != nil {
+				t.Errorf("call %d: %v", i, err)
+				return
+			}
+			results[i] = string(out)
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", got)
+	}
+	for i, r := range results {
+		if r != `{"input_tokens":7}` {
+			t.Fatalf("result %d = %q", i, r)
+		}
+	}
+
+	// A repeat within the TTL is served from cache.
+	if _, err := c.Do(context.Background(), key, fn); err != nil || calls.Load() != 1 {
+		t.Fatalf("expected cached result, calls=%d err=%v", calls.Load(), err)
+	}
+}
+
+func TestCountTokensCoalescerDoesNotCacheErrors(t *testing.T) {
+	c := &countTokensCoalescer{entries: make(map[string]countTokensEntry)}
+	var calls int
+	fn := func(context.Context) ([]byte, error) {
+		calls++
+		return nil, errors.New("upstream unavailable")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Do(context.Background(), "k", fn); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("errors must not be cached, calls=%d", calls)
+	}
+}
+
+func TestCountTokensCoalescerSurvivesLeaderCancellation(t *testing.T) {
+	c := &countTokensCoalescer{entries: make(map[string]countTokensEntry)}
+	release := make(chan struct{})
+	fn := func(ctx context.Context) ([]byte, error) {
+		select {
+		case <-release:
+			return []byte(`{"input_tokens":3}`), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := c.Do(leaderCtx, "k", fn)
+		leaderErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	follower := make(chan string, 1)
+	go func() {
+		out, err := c.Do(context.Background(), "k", fn)
+		if err != nil {
+			t.Errorf("follower: %v", err)
+		}
+		follower <- string(out)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leader error = %v, want context.Canceled", err)
+	}
+	close(release)
+	if got := <-follower; got != `{"input_tokens":3}` {
+		t.Fatalf("follower result = %q", got)
+	}
+}
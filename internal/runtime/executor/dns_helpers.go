@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/dnscache"
+)
+
+// sharedDNSCache returns the process-wide resolver when the DNS cache is enabled.
+func sharedDNSCache(cfg *config.Config) *dnscache.Resolver {
+	if cfg == nil || !cfg.DNSCache.Enabled {
+		return nil
+	}
+	return dnscache.Shared(time.Duration(cfg.DNSCache.TTLSeconds)*time.Second, time.Duration(cfg.DNSCache.StaleSeconds)*time.Second)
+}
+
+// applyDNSCache routes a transport's direct dials through resolver. Transports with a custom
+// dialer (SOCKS, Unix socket proxies) resolve elsewhere and are left untouched.
+func applyDNSCache(transport *http.Transport, resolver *dnscache.Resolver) {
+	if resolver == nil || transport.DialContext != nil {
+		return
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport.DialContext = resolver.WrapDial(dialer.DialContext)
+}
+
+// dnsCacheTransports caches DNS-caching clones of shared transports.
+var dnsCacheTransports sync.Map
+
+// withDNSCache returns base (or the default transport) dialing through resolver.
+func withDNSCache(base http.RoundTripper, resolver *dnscache.Resolver) http.RoundTripper {
+	if resolver == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if cached, ok := dnsCacheTransports.Load(base); ok {
+		return cached.(http.RoundTripper)
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return base
+	}
+	clone := transport.Clone()
+	applyDNSCache(clone, resolver)
+	actual, _ := dnsCacheTransports.LoadOrStore(base, clone)
+	return actual.(http.RoundTripper)
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/embeddings"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeminiExecutorEmbeddings(t *testing.T) {
+	var gotPath, gotKey string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("x-goog-api-key")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`))
+	}))
+	defer server.Close()
+
+	payload := []byte(`{"model":"gemini-embedding-001","input":["a","b"]}`)
+	auth := &cliproxyauth.Auth{ID: "g-1", Provider: "gemini", Attributes: map[string]string{"api_key": "g-key", "base_url": server.URL}}
+	resp, err := NewGeminiExecutor(&config.Config{}).Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gemini-embedding-001", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FromString(constant.OpenAIEmbedding),
+		OriginalRequest: payload,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotPath != "/v1beta/models/gemini-embedding-001:batchEmbedContents" || gotKey != "g-key" {
+		t.Fatalf("request path=%q key=%q", gotPath, gotKey)
+	}
+	if gjson.GetBytes(gotBody, "requests.#").Int() != 2 {
+		t.Fatalf("unexpected upstream body %s", gotBody)
+	}
+	if gjson.GetBytes(resp.Payload, "data.#").Int() != 2 || gjson.GetBytes(resp.Payload, "data.1.embedding.0").Float() != 0.3 {
+		t.Fatalf("unexpected response %s", resp.Payload)
+	}
+}
+
+func TestOpenAICompatExecutorEmbeddings(t *testing.T) {
+	var gotPath, gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		gotModel = gjson.GetBytes(body, "model").String()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.5]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":1,"total_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	payload := []byte(`{"model":"embed","input":"hello"}`)
+	auth := &cliproxyauth.Auth{ID: "c-1", Provider: "compat", Attributes: map[string]string{"api_key": "k", "base_url": server.URL + "/v1"}}
+	resp, err := NewOpenAICompatExecutor("compat", &config.Config{}).Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "text-embedding-3-small", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat:    sdktranslator.FromString(constant.OpenAIEmbedding),
+		OriginalRequest: payload,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if gotPath != "/v1/embeddings" || gotModel != "text-embedding-3-small" {
+		t.Fatalf("request path=%q model=%q", gotPath, gotModel)
+	}
+	if gjson.GetBytes(resp.Payload, "data.0.embedding.0").Float() != 0.5 {
+		t.Fatalf("unexpected response %s", resp.Payload)
+	}
+}
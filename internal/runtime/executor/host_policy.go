@@ -0,0 +1,225 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/dnscache"
+	log "github.com/sirupsen/logrus"
+)
+
+// hostPolicyTransport applies per-host HTTP/2 and multiplexing limits on top of a base transport.
+type hostPolicyTransport struct {
+	base     *http.Transport
+	policies []config.UpstreamHostConfig
+
+	mu    sync.Mutex
+	pools map[string]*hostPool
+}
+
+type hostPolicyKey struct {
+	base http.RoundTripper
+	sig  string
+}
+
+// hostPolicyTransports caches policy transports for shared base transports so their
+// connection shards are reused across requests.
+var hostPolicyTransports sync.Map
+
+// withHostPolicies wraps a shared round tripper with the configured host policies.
+func withHostPolicies(base http.RoundTripper, policies []config.UpstreamHostConfig) http.RoundTripper {
+	if len(policies) == 0 {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		log.Debugf("upstream hosts: cannot apply host policies to %T", base)
+		return base
+	}
+	key := hostPolicyKey{base: base, sig: fmt.Sprintf("%v", policies)}
+	if cached, okLoad := hostPolicyTransports.Load(key); okLoad {
+		return cached.(http.RoundTripper)
+	}
+	actual, _ := hostPolicyTransports.LoadOrStore(key, newHostPolicyTransport(transport, policies))
+	return actual.(http.RoundTripper)
+}
+
+type proxyHostPolicyKey struct {
+	proxyURL string
+	resolver *dnscache.Resolver
+	timeouts config.TimeoutSet
+	sig      string
+}
+
+// proxyHostPolicyTransports caches policy transports for proxied clients, whose base
+// transport is otherwise built per client, so host limits hold across requests.
+var proxyHostPolicyTransports sync.Map
+
+// proxyHostPolicyTransport returns the policy transport for requests through proxyURL, or
+// nil when the proxy URL is invalid.
+func proxyHostPolicyTransport(proxyURL string, resolver *dnscache.Resolver, timeouts config.TimeoutSet, policies []config.UpstreamHostConfig) http.RoundTripper {
+	key := proxyHostPolicyKey{proxyURL: proxyURL, resolver: resolver, timeouts: timeouts, sig: fmt.Sprintf("%v", policies)}
+	if cached, ok := proxyHostPolicyTransports.Load(key); ok {
+		return cached.(http.RoundTripper)
+	}
+	transport := buildProxyTransport(proxyURL)
+	if transport == nil {
+		return nil
+	}
+	applyDNSCache(transport, resolver)
+	applyTransportTimeouts(transport, timeouts)
+	actual, _ := proxyHostPolicyTransports.LoadOrStore(key, newHostPolicyTransport(transport, policies))
+	return actual.(http.RoundTripper)
+}
+
+func newHostPolicyTransport(base *http.Transport, policies []config.UpstreamHostConfig) *hostPolicyTransport {
+	return &hostPolicyTransport{base: base, policies: policies, pools: make(map[string]*hostPool)}
+}
+
+func (t *hostPolicyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy, ok := t.match(req.URL.Hostname())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	t.mu.Lock()
+	pool := t.pools[req.URL.Host]
+	if pool == nil {
+		pool = newHostPool(t.base, policy)
+		t.pools[req.URL.Host] = pool
+	}
+	t.mu.Unlock()
+	return pool.roundTrip(req)
+}
+
+func (t *hostPolicyTransport) match(hostname string) (config.UpstreamHostConfig, bool) {
+	for _, policy := range t.policies {
+		if policy.Matches(hostname) {
+			return policy, true
+		}
+	}
+	return config.UpstreamHostConfig{}, false
+}
+
+// hostPool spreads requests to one host over connection shards. Each shard is a transport
+// limited to a single connection, so capping a shard's in-flight requests caps the streams
+// multiplexed on that connection.
+type hostPool struct {
+	template   *http.Transport
+	maxStreams int
+	maxConns   int
+
+	mu      sync.Mutex
+	shards  []*hostShard
+	changed chan struct{}
+}
+
+type hostShard struct {
+	transport *http.Transport
+	inflight  int
+}
+
+func newHostPool(base *http.Transport, policy config.UpstreamHostConfig) *hostPool {
+	template := base.Clone()
+	if policy.DisableHTTP2 {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		template.Protocols = protocols
+		template.ForceAttemptHTTP2 = false
+		// A TLS config that already offers h2 via ALPN would still negotiate it.
+		if template.TLSClientConfig != nil {
+			template.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+	} else {
+		template.ForceAttemptHTTP2 = true
+	}
+	pool := &hostPool{template: template, maxStreams: policy.MaxStreamsPerConnection, changed: make(chan struct{})}
+	if pool.maxStreams > 0 {
+		template.MaxConnsPerHost = 1
+		pool.maxConns = policy.MaxConnections
+	}
+	return pool
+}
+
+func (p *hostPool) roundTrip(req *http.Request) (*http.Response, error) {
+	if p.maxStreams <= 0 {
+		return p.template.RoundTrip(req)
+	}
+	shard, err := p.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := shard.transport.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		p.release(shard)
+		return resp, err
+	}
+	resp.Body = &releaseOnDoneBody{ReadCloser: resp.Body, release: func() { p.release(shard) }}
+	return resp, nil
+}
+
+// acquire reserves a stream slot on the least loaded shard, opening a new shard when all
+// are full and the connection cap allows it, otherwise waiting for a slot.
+func (p *hostPool) acquire(ctx context.Context) (*hostShard, error) {
+	for {
+		p.mu.Lock()
+		var best *hostShard
+		for _, shard := range p.shards {
+			if shard.inflight < p.maxStreams && (best == nil || shard.inflight < best.inflight) {
+				best = shard
+			}
+		}
+		if best == nil && (p.maxConns <= 0 || len(p.shards) < p.maxConns) {
+			best = &hostShard{transport: p.template.Clone()}
+			p.shards = append(p.shards, best)
+		}
+		if best != nil {
+			best.inflight++
+			p.mu.Unlock()
+			return best, nil
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *hostPool) release(shard *hostShard) {
+	p.mu.Lock()
+	shard.inflight--
+	close(p.changed)
+	p.changed = make(chan struct{})
+	p.mu.Unlock()
+}
+
+// releaseOnDoneBody frees its stream slot once the body is drained, fails or is closed.
+type releaseOnDoneBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnDoneBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *releaseOnDoneBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
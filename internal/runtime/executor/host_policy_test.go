@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func newHTTP2TestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(handler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHostPolicyCapsStreamsPerConnection(t *testing.T) {
+	var mu sync.Mutex
+	conns := map[string]bool{}
+	server := newHTTP2TestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns[r.RemoteAddr] = true
+		mu.Unlock()
+		if r.ProtoMajor != 2 {
+			t.Errorf("expected HTTP/2, got %s", r.Proto)
+		}
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "ok")
+	})
+	host, _ := url.Parse(server.URL)
+
+	base := server.Client().Transport.(*http.Transport)
+	rt := newHostPolicyTransport(base, []config.UpstreamHostConfig{{Host: host.Hostname(), MaxStreamsPerConnection: 1}})
+	client := &http.Client{Transport: rt}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, _ = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if len(conns) != 3 {
+		t.Fatalf("expected 3 connections for 3 concurrent streams, got %d", len(conns))
+	}
+}
+
+func TestHostPolicyDisablesHTTP2(t *testing.T) {
+	server := newHTTP2TestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	})
+	base := server.Client().Transport.(*http.Transport)
+	rt := newHostPolicyTransport(base, []config.UpstreamHostConfig{{Host: "127.0.0.1", DisableHTTP2: true}})
+
+	resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "HTTP/1.1" {
+		t.Fatalf("expected HTTP/1.1, got %s", body)
+	}
+}
+
+func TestHostPolicySharedAcrossClientsThroughSameProxy(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(proxyServer.Close)
+
+	cfg := &config.Config{UpstreamHosts: []config.UpstreamHostConfig{{Host: "upstream.invalid", MaxStreamsPerConnection: 1, MaxConnections: 1}}}
+	cfg.ProxyURL = proxyServer.URL
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		client := newProxyAwareHTTPClient(context.Background(), cfg, nil, 0)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get("http://upstream.invalid/v1/models")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, _ = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if peak != 1 {
+		t.Fatalf("clients through the same proxy must share one limiter, saw %d concurrent requests", peak)
+	}
+}
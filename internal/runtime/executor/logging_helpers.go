@@ -51,13 +51,18 @@ type upstreamAttempt struct {
 
 // recordAPIRequest stores the upstream request metadata in Gin context for request logging.
 func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
+	if cfg == nil || !cfg.RequestLogActive() {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
 	if ginCtx == nil {
 		return
 	}
+	logging.SetGinRequestLogProvider(ginCtx, info.Provider)
+	level := requestLogLevel(ginCtx, cfg)
+	if level == config.RequestLogLevelNone {
+		return
+	}
 
 	attempts := getAttempts(ginCtx)
 	index := len(attempts) + 1
@@ -79,7 +84,9 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 	builder.WriteString("\nHeaders:\n")
 	writeHeaders(builder, info.Headers)
 	builder.WriteString("\nBody:\n")
-	if len(info.Body) > 0 {
+	if level == config.RequestLogLevelMetadata {
+		builder.WriteString(omittedBodyMarker)
+	} else if len(info.Body) > 0 {
 		builder.WriteString(string(info.Body))
 	} else {
 		builder.WriteString("<empty>")
@@ -98,11 +105,11 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 
 // recordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
 func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
-	if cfg == nil || !cfg.RequestLog {
+	if cfg == nil || !cfg.RequestLogActive() {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if ginCtx == nil || requestLogLevel(ginCtx, cfg) == config.RequestLogLevelNone {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -124,11 +131,11 @@ func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status i
 
 // recordAPIResponseError adds an error entry for the latest attempt when no HTTP response is available.
 func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error) {
-	if cfg == nil || !cfg.RequestLog || err == nil {
+	if cfg == nil || !cfg.RequestLogActive() || err == nil {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if ginCtx == nil || requestLogLevel(ginCtx, cfg) == config.RequestLogLevelNone {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -149,7 +156,7 @@ func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 
 // appendAPIResponseChunk appends an upstream response chunk to Gin context for request logging.
 func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	if cfg == nil || !cfg.RequestLogActive() {
 		return
 	}
 	data := bytes.TrimSpace(chunk)
@@ -160,8 +167,18 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	if ginCtx == nil {
 		return
 	}
+	level := requestLogLevel(ginCtx, cfg)
+	if level == config.RequestLogLevelNone {
+		return
+	}
 	attempts, attempt := ensureAttempt(ginCtx)
 	ensureResponseIntro(attempt)
+	if level == config.RequestLogLevelMetadata {
+		if attempt.bodyStarted {
+			return
+		}
+		data = []byte(omittedBodyMarker)
+	}
 
 	if !attempt.headersWritten {
 		attempt.response.WriteString("Headers:\n")
@@ -182,6 +199,17 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	updateAggregatedResponse(ginCtx, attempts)
 }
 
+// omittedBodyMarker replaces payloads when the request log level is metadata.
+const omittedBodyMarker = "<omitted: metadata request logging>"
+
+// requestLogLevel resolves the request log level for the provider and client key of the request.
+func requestLogLevel(ginCtx *gin.Context, cfg *config.Config) string {
+	if cfg == nil {
+		return config.RequestLogLevelNone
+	}
+	return logging.ResolveRequestLogLevel(ginCtx, &cfg.SDKConfig)
+}
+
 func ginContextFrom(ctx context.Context) *gin.Context {
 	ginCtx, _ := ctx.Value("gin").(*gin.Context)
 	return ginCtx
@@ -302,7 +330,7 @@ func formatAuthInfo(info upstreamRequestLog) string {
 	switch authType {
 	case "api_key":
 		if authValue != "" {
-			parts = append(parts, fmt.Sprintf("type=api_key value=%s", util.HideAPIKey(authValue)))
+			parts = append(parts, fmt.Sprintf("type=api_key value=%s", util.RedactSecret(authValue)))
 		} else {
 			parts = append(parts, "type=api_key")
 		}
@@ -311,7 +339,7 @@ func formatAuthInfo(info upstreamRequestLog) string {
 	default:
 		if authType != "" {
 			if authValue != "" {
-				parts = append(parts, fmt.Sprintf("type=%s value=%s", authType, authValue))
+				parts = append(parts, fmt.Sprintf("type=%s value=%s", authType, util.RedactSecret(authValue)))
 			} else {
 				parts = append(parts, fmt.Sprintf("type=%s", authType))
 			}
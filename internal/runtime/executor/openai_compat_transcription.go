@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// transcriptionUpload rebuilds the multipart form of an OpenAI transcription request from its
+// JSON form, in which "file" carries the base64 audio. The upstream is always asked for JSON
+// so that the handler can render the requested response_format itself.
+func transcriptionUpload(payload []byte) ([]byte, string, error) {
+	root := gjson.ParseBytes(payload)
+	audio, err := base64.StdEncoding.DecodeString(root.Get("file.data").String())
+	if err != nil {
+		return nil, "", statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("invalid audio data: %v", err)}
+	}
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	fields := [][2]string{{"model", root.Get("model").String()}, {"response_format", "json"}}
+	if root.Get("response_format").String() == "verbose_json" {
+		fields[1][1] = "verbose_json"
+	}
+	for _, key := range []string{"language", "prompt"} {
+		if value := root.Get(key).String(); value != "" {
+			fields = append(fields, [2]string{key, value})
+		}
+	}
+	if temperature := root.Get("temperature"); temperature.Exists() {
+		fields = append(fields, [2]string{"temperature", strconv.FormatFloat(temperature.Float(), 'g', -1, 64)})
+	}
+	for _, field := range fields {
+		if err = form.WriteField(field[0], field[1]); err != nil {
+			return nil, "", err
+		}
+	}
+	name := root.Get("file.name").String()
+	if name == "" {
+		name = "audio"
+	}
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = part.Write(audio); err != nil {
+		return nil, "", err
+	}
+	if err = form.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), form.FormDataContentType(), nil
+}
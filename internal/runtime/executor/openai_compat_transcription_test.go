@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"testing"
+)
+
+func TestTranscriptionUploadRebuildsMultipartForm(t *testing.T) {
+	body, contentType, err := transcriptionUpload([]byte(`{"model":"whisper-1","file":{"name":"clip.wav","mime_type":"audio/wav","data":"UklGRg=="},"response_format":"text","language":"en","temperature":0.1}`))
+	if err != nil {
+		t.Fatalf("transcriptionUpload: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("content type %q: %v", contentType, err)
+	}
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("read form: %v", err)
+	}
+	if form.Value["model"][0] != "whisper-1" || form.Value["response_format"][0] != "json" || form.Value["language"][0] != "en" || form.Value["temperature"][0] != "0.1" {
+		t.Fatalf("form values = %v", form.Value)
+	}
+	file, err := form.File["file"][0].Open()
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	audio, _ := io.ReadAll(file)
+	if string(audio) != "RIFF" || form.File["file"][0].Filename != "clip.wav" {
+		t.Fatalf("file = %q (%s)", audio, form.File["file"][0].Filename)
+	}
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// OpenRouterExecutor forwards requests to OpenRouter's OpenAI-compatible chat completions API.
+// Auths carry the API key and base URL in their attributes, like OpenAI-compatible providers.
+type OpenRouterExecutor struct {
+	*OpenAICompatExecutor
+}
+
+func NewOpenRouterExecutor(cfg *config.Config) *OpenRouterExecutor {
+	return &OpenRouterExecutor{OpenAICompatExecutor: NewOpenAICompatExecutor("openrouter", cfg)}
+}
+
+// FetchOpenRouterModels lists the models available to an OpenRouter key from {base-url}/models.
+// It returns nil when the list cannot be fetched. Models are marked user-defined so thinking
+// settings pass through unvalidated; OpenRouter checks them against the routed provider.
+func FetchOpenRouterModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	baseURL := config.DefaultOpenRouterBaseURL
+	var apiKey string
+	if auth != nil && auth.Attributes != nil {
+		if v := strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/"); v != "" {
+			baseURL = v
+		}
+		apiKey = strings.TrimSpace(auth.Attributes["api_key"])
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/models", nil)
+	if err != nil {
+		log.Warnf("openrouter executor: build models request failed: %v", err)
+		return nil
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		log.Warnf("openrouter executor: fetch models failed: %v", err)
+		return nil
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("openrouter executor: close models response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		log.Warnf("openrouter executor: read models response failed: %v", err)
+		return nil
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		log.Warnf("openrouter executor: fetch models status=%d body=%s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), body))
+		return nil
+	}
+	return parseOpenRouterModels(body)
+}
+
+func parseOpenRouterModels(body []byte) []*registry.ModelInfo {
+	data := gjson.GetBytes(body, "data")
+	if !data.IsArray() {
+		return nil
+	}
+	now := time.Now().Unix()
+	models := make([]*registry.ModelInfo, 0, len(data.Array()))
+	seen := make(map[string]struct{})
+	for _, item := range data.Array() {
+		id := strings.TrimSpace(item.Get("id").String())
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[strings.ToLower(id)]; ok {
+			continue
+		}
+		seen[strings.ToLower(id)] = struct{}{}
+		created := item.Get("created").Int()
+		if created == 0 {
+			created = now
+		}
+		displayName := item.Get("name").String()
+		if displayName == "" {
+			displayName = id
+		}
+		info := &registry.ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             created,
+			OwnedBy:             "openrouter",
+			Type:                "openrouter",
+			DisplayName:         displayName,
+			Description:         item.Get("description").String(),
+			ContextLength:       int(item.Get("context_length").Int()),
+			MaxCompletionTokens: int(item.Get("top_provider.max_completion_tokens").Int()),
+			UserDefined:         true,
+		}
+		for _, param := range item.Get("supported_parameters").Array() {
+			info.SupportedParameters = append(info.SupportedParameters, param.String())
+		}
+		models = append(models, info)
+	}
+	return models
+}
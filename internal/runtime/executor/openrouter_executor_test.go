@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestFetchOpenRouterModels(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"anthropic/claude-sonnet-4.5","name":"Anthropic: Claude Sonnet 4.5","created":1759161676,"context_length":1000000,
+			 "top_provider":{"max_completion_tokens":64000},"supported_parameters":["tools","reasoning"]},
+			{"id":"openai/gpt-4o-mini","context_length":128000},
+			{"id":""}
+		]}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "or-1", Provider: "openrouter", Attributes: map[string]string{"api_key": "sk-or", "base_url": server.URL + "/api/v1"}}
+	models := FetchOpenRouterModels(context.Background(), auth, &config.Config{})
+	if gotPath != "/api/v1/models" || gotAuth != "Bearer sk-or" {
+		t.Fatalf("request path=%q auth=%q", gotPath, gotAuth)
+	}
+	if len(models) != 2 {
+		t.Fatalf("expected 2 models, got %d", len(models))
+	}
+	claude := models[0]
+	if claude.ID != "anthropic/claude-sonnet-4.5" || claude.DisplayName != "Anthropic: Claude Sonnet 4.5" || claude.ContextLength != 1000000 || claude.MaxCompletionTokens != 64000 {
+		t.Fatalf("unexpected model info %+v", claude)
+	}
+	if len(claude.SupportedParameters) != 2 || !claude.UserDefined || claude.Type != "openrouter" {
+		t.Fatalf("unexpected model metadata %+v", claude)
+	}
+	if models[1].DisplayName != "openai/gpt-4o-mini" {
+		t.Fatalf("display name should fall back to the id, got %q", models[1].DisplayName)
+	}
+}
+
+func TestOpenRouterExecutorExecute(t *testing.T) {
+	var gotPath, gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		gotModel = gjson.GetBytes(body, "model").String()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	exec := NewOpenRouterExecutor(&config.Config{})
+	if exec.Identifier() != "openrouter" {
+		t.Fatalf("identifier = %q", exec.Identifier())
+	}
+	auth := &cliproxyauth.Auth{ID: "or-1", Provider: "openrouter", Attributes: map[string]string{"api_key": "sk-or", "base_url": server.URL + "/api/v1"}}
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "openai/gpt-4o-mini",
+		Payload: []byte(`{"model":"openai/gpt-4o-mini","messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/api/v1/chat/completions" || gotModel != "openai/gpt-4o-mini" {
+		t.Fatalf("upstream path=%q model=%q", gotPath, gotModel)
+	}
+	if gjson.GetBytes(resp.Payload, "choices.0.message.content").String() != "hi" {
+		t.Fatalf("unexpected response %s", resp.Payload)
+	}
+}
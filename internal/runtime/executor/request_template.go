@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+// requestTemplateNone removes a built-in template field.
+const requestTemplateNone = "none"
+
+// Session strategies understood by the "session-id" generator.
+const (
+	sessionStrategyStableHash     = "stable-hash"
+	sessionStrategyFirstTurn      = "first-turn"
+	sessionStrategyPerRequest     = "per-request"
+	sessionStrategyClientProvided = "client-provided"
+	defaultSessionHeader          = "X-Session-Id"
+)
+
+// antigravityRequestTemplate is the built-in template of the Antigravity executor.
+var antigravityRequestTemplate = config.RequestTemplate{
+	Static: map[string]any{
+		"userAgent":   "antigravity",
+		"requestType": "agent",
+	},
+	Generated: map[string]string{
+		"project":           "project-id",
+		"requestId":         "request-id",
+		"request.sessionId": "session-id",
+	},
+	// Clients that send X-Session-Id get one session per conversation even when several
+	// conversations open with the same text.
+	SessionStrategy: sessionStrategyClientProvided,
+}
+
+// requestTemplateInput carries the per-request data generators draw from.
+type requestTemplateInput struct {
+	// payload is the request before templating; session IDs are derived from it.
+	payload   []byte
+	projectID string
+	// headers are the inbound client headers, or nil outside an HTTP request.
+	headers         http.Header
+	sessionStrategy string
+	sessionHeader   string
+}
+
+var requestTemplateGenerators = map[string]func(requestTemplateInput) string{
+	"uuid":              func(requestTemplateInput) string { return uuid.NewString() },
+	"request-id":        func(requestTemplateInput) string { return generateRequestID() },
+	"session-id":        sessionIDForStrategy,
+	"random-session-id": func(requestTemplateInput) string { return generateSessionID() },
+	"project-id": func(in requestTemplateInput) string {
+		if in.projectID != "" {
+			return in.projectID
+		}
+		return generateProjectID()
+	},
+}
+
+// applyRequestTemplate sets the fields of provider's request template on payload. Entries
+// from cfg.RequestTemplates override defaults path by path.
+func applyRequestTemplate(cfg *config.Config, provider string, defaults config.RequestTemplate, payload []byte, in requestTemplateInput) []byte {
+	static := make(map[string]any, len(defaults.Static))
+	generated := make(map[string]string, len(defaults.Generated))
+	for path, value := range defaults.Static {
+		static[path] = value
+	}
+	for path, generator := range defaults.Generated {
+		generated[path] = generator
+	}
+	in.sessionStrategy, in.sessionHeader = defaults.SessionStrategy, defaults.SessionHeader
+	if cfg != nil {
+		if override, ok := cfg.RequestTemplates[provider]; ok {
+			if strategy := strings.ToLower(strings.TrimSpace(override.SessionStrategy)); strategy != "" {
+				in.sessionStrategy = strategy
+			}
+			if header := strings.TrimSpace(override.SessionHeader); header != "" {
+				in.sessionHeader = header
+			}
+			for path, value := range override.Static {
+				delete(generated, path)
+				static[path] = value
+			}
+			for path, generator := range override.Generated {
+				delete(static, path)
+				generated[path] = strings.ToLower(strings.TrimSpace(generator))
+			}
+		}
+	}
+
+	for _, path := range sortedKeys(static) {
+		payload, _ = sjson.SetBytes(payload, path, static[path])
+	}
+	for _, path := range sortedKeys(generated) {
+		name := generated[path]
+		if name == requestTemplateNone {
+			payload, _ = sjson.DeleteBytes(payload, path)
+			continue
+		}
+		generate, ok := requestTemplateGenerators[name]
+		if !ok {
+			log.Warnf("request template %s: unknown generator %q for %s", provider, name, path)
+			continue
+		}
+		payload, _ = sjson.SetBytes(payload, path, generate(in))
+	}
+	return payload
+}
+
+// sessionIDForStrategy derives a session ID according to in.sessionStrategy.
+func sessionIDForStrategy(in requestTemplateInput) string {
+	switch in.sessionStrategy {
+	case sessionStrategyPerRequest:
+		return generateSessionID()
+	case sessionStrategyFirstTurn:
+		return generateFirstTurnSessionID(in.payload)
+	case sessionStrategyClientProvided:
+		header := in.sessionHeader
+		if header == "" {
+			header = defaultSessionHeader
+		}
+		if value := strings.TrimSpace(in.headers.Get(header)); value != "" {
+			return sessionIDFromText(value)
+		}
+	case "", sessionStrategyStableHash:
+	default:
+		log.Warnf("request template: unknown session strategy %q, using %s", in.sessionStrategy, sessionStrategyStableHash)
+	}
+	return generateStableSessionID(in.payload)
+}
+
+// ginRequestHeaders returns the inbound client headers carried by ctx, if any.
+func ginRequestHeaders(ctx context.Context) http.Header {
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		return ginCtx.Request.Header
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
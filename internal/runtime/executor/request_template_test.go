@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeminiToAntigravityDefaultTemplate(t *testing.T) {
+	payload := []byte(`{"request":{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}}`)
+	out := geminiToAntigravity(nil, "gemini-3-pro", payload, "proj-1", nil)
+	for path, want := range map[string]string{
+		"userAgent":   "antigravity",
+		"requestType": "agent",
+		"project":     "proj-1",
+		"model":       "gemini-3-pro",
+	} {
+		if got := gjson.GetBytes(out, path).String(); got != want {
+			t.Fatalf("%s = %q, want %q", path, got, want)
+		}
+	}
+	if !strings.HasPrefix(gjson.GetBytes(out, "requestId").String(), "agent-") {
+		t.Fatalf("requestId not generated: %s", out)
+	}
+	if got, want := gjson.GetBytes(out, "request.sessionId").String(), generateStableSessionID(payload); got != want {
+		t.Fatalf("sessionId = %q, want stable %q", got, want)
+	}
+}
+
+func TestGeminiToAntigravityConfiguredTemplate(t *testing.T) {
+	cfg := &config.Config{RequestTemplates: map[string]config.RequestTemplate{
+		"antigravity": {
+			Static:    map[string]any{"userAgent": "antigravity/1.2", "requestId": "fixed"},
+			Generated: map[string]string{"request.sessionId": "none", "traceId": "UUID"},
+		},
+	}}
+	out := geminiToAntigravity(cfg, "gemini-3-pro", []byte(`{"request":{}}`), "", nil)
+	if got := gjson.GetBytes(out, "userAgent").String(); got != "antigravity/1.2" {
+		t.Fatalf("userAgent = %q", got)
+	}
+	if got := gjson.GetBytes(out, "requestId").String(); got != "fixed" {
+		t.Fatalf("static value must replace the built-in generator, got %q", got)
+	}
+	if gjson.GetBytes(out, "request.sessionId").Exists() {
+		t.Fatal("\"none\" must remove the built-in field")
+	}
+	if len(gjson.GetBytes(out, "traceId").String()) != 36 {
+		t.Fatalf("traceId not generated: %s", out)
+	}
+	if gjson.GetBytes(out, "project").String() == "" {
+		t.Fatal("untouched built-in fields must still be set")
+	}
+}
+
+func TestSessionStrategies(t *testing.T) {
+	withImage := []byte(`{"request":{"contents":[{"role":"user","parts":[{"text":"hi"},{"inlineData":{"data":"AAA"}}]}]}}`)
+	textOnly := []byte(`{"request":{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}}`)
+	headers := http.Header{}
+	headers.Set("X-Conversation", "conv-42")
+
+	sessionFor := func(strategy string, payload []byte, h http.Header) string {
+		cfg := &config.Config{RequestTemplates: map[string]config.RequestTemplate{
+			"antigravity": {SessionStrategy: strategy, SessionHeader: "X-Conversation"},
+		}}
+		return gjson.GetBytes(geminiToAntigravity(cfg, "m", payload, "p", h), "request.sessionId").String()
+	}
+
+	if sessionFor("stable-hash", withImage, nil) != sessionFor("stable-hash", textOnly, nil) {
+		t.Fatal("stable-hash only looks at the first user text")
+	}
+	if sessionFor("first-turn", withImage, nil) == sessionFor("first-turn", textOnly, nil) {
+		t.Fatal("first-turn must distinguish turns with different parts")
+	}
+	if sessionFor("first-turn", withImage, nil) != sessionFor("first-turn", withImage, nil) {
+		t.Fatal("first-turn must be stable")
+	}
+	if sessionFor("per-request", textOnly, nil) == sessionFor("per-request", textOnly, nil) {
+		t.Fatal("per-request must generate a new ID every time")
+	}
+	client := sessionFor("client-provided", textOnly, headers)
+	if client != sessionIDFromText("conv-42") || client == sessionFor("client-provided", withImage, nil) {
+		t.Fatalf("client-provided must use the header, got %q", client)
+	}
+	if sessionFor("client-provided", textOnly, nil) != generateStableSessionID(textOnly) {
+		t.Fatal("client-provided must fall back to stable-hash without the header")
+	}
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// responsesCompactPrompt asks the model for a summary that can stand in for the conversation.
+const responsesCompactPrompt = "Summarize the conversation so far so that it can replace the full history. " +
+	"Keep the user's goals and constraints, decisions made, the state of any work in progress, " +
+	"tool results that are still relevant, and open questions. Reply with the summary only."
+
+// compactResponsesBySummary serves /responses/compact for providers without a native compaction
+// endpoint. The conversation is summarized with a regular Responses request through execute and
+// returned as a single compaction item, which the Responses translators expand back into a
+// summary turn when the client sends it as input.
+func compactResponsesBySummary(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, execute func(context.Context, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error)) (cliproxyexecutor.Response, error) {
+	if opts.SourceFormat.String() != "openai-response" {
+		return cliproxyexecutor.Response{}, statusErr{code: http.StatusBadRequest, msg: "/responses/compact requires an OpenAI Responses request"}
+	}
+	payload := buildResponsesCompactRequest(req.Payload)
+	summaryReq := req
+	summaryReq.Payload = payload
+	summaryOpts := opts
+	summaryOpts.Alt = ""
+	summaryOpts.OriginalRequest = payload
+	resp, err := execute(ctx, summaryReq, summaryOpts)
+	if err != nil {
+		return resp, err
+	}
+	summary := responsesOutputText(resp.Payload)
+	if strings.TrimSpace(summary) == "" {
+		return cliproxyexecutor.Response{}, statusErr{code: http.StatusBadGateway, msg: "/responses/compact: upstream returned an empty summary"}
+	}
+
+	out := []byte(`{"id":"","object":"response.compaction","created_at":0,"model":"","output":[]}`)
+	id := gjson.GetBytes(resp.Payload, "id").String()
+	if id == "" {
+		id = "resp_" + randomHex(12)
+	}
+	out, _ = sjson.SetBytes(out, "id", id)
+	out, _ = sjson.SetBytes(out, "created_at", time.Now().Unix())
+	out, _ = sjson.SetBytes(out, "model", gjson.GetBytes(req.Payload, "model").String())
+	item := []byte(`{"id":"","type":"compaction","encrypted_content":""}`)
+	item, _ = sjson.SetBytes(item, "id", "cmp_"+randomHex(12))
+	item, _ = sjson.SetBytes(item, "encrypted_content", util.EncodeCompactionSummary(summary))
+	out, _ = sjson.SetRawBytes(out, "output.-1", item)
+	if usage := gjson.GetBytes(resp.Payload, "usage"); usage.Exists() {
+		out, _ = sjson.SetRawBytes(out, "usage", []byte(usage.Raw))
+	}
+	return cliproxyexecutor.Response{Payload: out, Metadata: resp.Metadata}, nil
+}
+
+// buildResponsesCompactRequest turns a compact request into a Responses request that asks for
+// a summary. Tools stay declared so earlier tool calls remain valid, but may not be called.
+func buildResponsesCompactRequest(payload []byte) []byte {
+	out, _ := sjson.DeleteBytes(payload, "stream")
+	out, _ = sjson.DeleteBytes(out, "previous_response_id")
+	if input := gjson.GetBytes(out, "input"); input.Type == gjson.String {
+		out, _ = sjson.SetBytes(out, "input", []map[string]string{{"role": "user", "content": input.String()}})
+	}
+	prompt := []byte(`{"type":"message","role":"user","content":[{"type":"input_text","text":""}]}`)
+	prompt, _ = sjson.SetBytes(prompt, "content.0.text", responsesCompactPrompt)
+	out, _ = sjson.SetRawBytes(out, "input.-1", prompt)
+	if gjson.GetBytes(out, "tools").Exists() {
+		out, _ = sjson.SetBytes(out, "tool_choice", "none")
+	}
+	return out
+}
+
+// responsesOutputText concatenates the output_text parts of a Responses API response.
+func responsesOutputText(payload []byte) string {
+	var b strings.Builder
+	for _, item := range gjson.GetBytes(payload, "output").Array() {
+		if item.Get("type").String() != "message" {
+			continue
+		}
+		for _, part := range item.Get("content").Array() {
+			if part.Get("type").String() == "output_text" {
+				b.WriteString(part.Get("text").String())
+			}
+		}
+	}
+	return b.String()
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
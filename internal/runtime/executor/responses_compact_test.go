@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestCompactResponsesBySummary(t *testing.T) {
+	var gotPayload []byte
+	var gotAlt string
+	execute := func(_ context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		gotPayload, gotAlt = req.Payload, opts.Alt
+		return cliproxyexecutor.Response{Payload: []byte(`{"id":"resp_1","output":[
+			{"type":"reasoning","summary":[]},
+			{"type":"message","role":"assistant","content":[{"type":"output_text","text":"The user wants a haiku."}]}
+		],"usage":{"input_tokens":40,"output_tokens":6,"total_tokens":46}}`)}, nil
+	}
+	req := cliproxyexecutor.Request{Model: "gemini-3-pro-preview", Payload: []byte(`{"model":"gemini-3-pro-preview","input":"Write a haiku","stream":true,"tools":[{"type":"function","name":"f"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response"), Alt: "responses/compact"}
+
+	resp, err := compactResponsesBySummary(context.Background(), req, opts, execute)
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if gotAlt != "" || gjson.GetBytes(gotPayload, "stream").Exists() || gjson.GetBytes(gotPayload, "tool_choice").String() != "none" {
+		t.Fatalf("summary request = %s (alt %q)", gotPayload, gotAlt)
+	}
+	if n := gjson.GetBytes(gotPayload, "input.#").Int(); n != 2 || gjson.GetBytes(gotPayload, "input.0.content").String() != "Write a haiku" {
+		t.Fatalf("summary input = %s", gjson.GetBytes(gotPayload, "input").Raw)
+	}
+	if gjson.GetBytes(resp.Payload, "object").String() != "response.compaction" || gjson.GetBytes(resp.Payload, "usage.total_tokens").Int() != 46 {
+		t.Fatalf("compact response = %s", resp.Payload)
+	}
+	item := gjson.GetBytes(resp.Payload, "output.0")
+	summary, ok := util.DecodeCompactionSummary(item.Get("encrypted_content").String())
+	if item.Get("type").String() != "compaction" || !ok || summary != "The user wants a haiku." {
+		t.Fatalf("compaction item = %s", item.Raw)
+	}
+
+	opts.SourceFormat = sdktranslator.FromString("openai")
+	if _, err := compactResponsesBySummary(context.Background(), req, opts, execute); err == nil {
+		t.Fatal("expected an error for a non-Responses source format")
+	}
+}
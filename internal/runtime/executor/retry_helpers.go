@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRetryInitialBackoff = 250 * time.Millisecond
+	defaultRetryMaxBackoff     = 4 * time.Second
+)
+
+// retryTransport retries transient upstream failures with bounded exponential backoff.
+// Only requests whose body can be replayed are retried.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+	initial     time.Duration
+	max         time.Duration
+}
+
+// withUpstreamRetry wraps base with the upstream-retry policy, if configured.
+func withUpstreamRetry(base http.RoundTripper, cfg *config.Config) http.RoundTripper {
+	if cfg == nil || cfg.UpstreamRetry.MaxAttempts <= 1 {
+		return base
+	}
+	rt := &retryTransport{
+		base:        base,
+		maxAttempts: cfg.UpstreamRetry.MaxAttempts,
+		initial:     time.Duration(cfg.UpstreamRetry.InitialBackoffMs) * time.Millisecond,
+		max:         time.Duration(cfg.UpstreamRetry.MaxBackoffMs) * time.Millisecond,
+	}
+	if rt.initial <= 0 {
+		rt.initial = defaultRetryInitialBackoff
+	}
+	if rt.max <= 0 {
+		rt.max = defaultRetryMaxBackoff
+	}
+	return rt
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 1; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if !replayable || attempt >= t.maxAttempts || !retryableUpstreamFailure(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+		delay := t.backoff(attempt)
+		log.Debugf("upstream retry: %s %s failed (%s), retrying in %s (attempt %d/%d)", req.Method, req.URL.Host, describeUpstreamFailure(resp, err), delay, attempt+1, t.maxAttempts)
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		if req.GetBody != nil {
+			body, errBody := req.GetBody()
+			if errBody != nil {
+				return nil, errBody
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// backoff returns the wait before retry number attempt, doubling from the initial delay up
+// to the cap, with jitter over the upper half.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.initial
+	for i := 1; i < attempt && delay < t.max; i++ {
+		delay *= 2
+	}
+	if delay > t.max {
+		delay = t.max
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryableUpstreamFailure reports whether a response or error is a transient upstream failure.
+// 503 and 429 are left to the executors and the auth manager, which understand quota and capacity.
+func retryableUpstreamFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func describeUpstreamFailure(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestUpstreamRetryRetriesTransient5xx(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("body = %q, want replayed payload", body)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{UpstreamRetry: config.UpstreamRetryConfig{MaxAttempts: 3, InitialBackoffMs: 1, MaxBackoffMs: 2}}
+	client := &http.Client{Transport: withUpstreamRetry(nil, cfg)}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("status = %d after %d calls, want 200 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestUpstreamRetryStopsAtMaxAttemptsAndSkipsOtherStatuses(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		want   int32
+	}{
+		{http.StatusGatewayTimeout, 2},
+		{http.StatusServiceUnavailable, 1},
+		{http.StatusTooManyRequests, 1},
+		{http.StatusBadRequest, 1},
+	} {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(tc.status)
+		}))
+		cfg := &config.Config{UpstreamRetry: config.UpstreamRetryConfig{MaxAttempts: 2, InitialBackoffMs: 1}}
+		client := &http.Client{Transport: withUpstreamRetry(nil, cfg)}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		_ = resp.Body.Close()
+		srv.Close()
+		if resp.StatusCode != tc.status || calls.Load() != tc.want {
+			t.Fatalf("status %d: got %d after %d calls, want %d calls", tc.status, resp.StatusCode, calls.Load(), tc.want)
+		}
+	}
+}
+
+func TestUpstreamRetryDisabledByDefault(t *testing.T) {
+	if rt := withUpstreamRetry(http.DefaultTransport, &config.Config{}); rt != http.DefaultTransport {
+		t.Fatalf("expected base transport when upstream-retry is not configured")
+	}
+}
+
+func TestUpstreamRetryBackoffIsBounded(t *testing.T) {
+	rt := withUpstreamRetry(nil, &config.Config{UpstreamRetry: config.UpstreamRetryConfig{MaxAttempts: 10, InitialBackoffMs: 100, MaxBackoffMs: 1000}}).(*retryTransport)
+	for attempt := 1; attempt < 10; attempt++ {
+		if d := rt.backoff(attempt); d > rt.max || d < rt.initial/2 {
+			t.Fatalf("backoff(%d) = %s out of bounds", attempt, d)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// thinkingStartErrorPatterns match Claude's rejection of a request whose final assistant turn
+// does not open with a thinking block while thinking is enabled. Resumed Claude Code sessions
+// hit it when earlier turns were produced without thinking or their signed blocks were lost.
+var thinkingStartErrorPatterns = []string{
+	"must start with a thinking block",
+	"expected `thinking` or `redacted_thinking`",
+}
+
+// isThinkingStartError reports whether an upstream error body is a thinking-start rejection.
+func isThinkingStartError(statusCode int, body []byte) bool {
+	if statusCode != http.StatusBadRequest {
+		return false
+	}
+	msg := strings.ToLower(string(body))
+	for _, pattern := range thinkingStartErrorPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryWithoutClaudeThinking returns the Claude request to retry after a thinking-start
+// rejection: the same request with thinking disabled. Injecting a placeholder thinking block
+// is not an option because the upstream verifies thinking signatures. The retry happens once
+// per request and only when thinking was actually enabled.
+func retryWithoutClaudeThinking(ctx context.Context, statusCode int, errBody, body []byte, retried bool) ([]byte, bool) {
+	if retried || !isThinkingStartError(statusCode, errBody) {
+		return nil, false
+	}
+	switch gjson.GetBytes(body, "thinking.type").String() {
+	case "", "disabled":
+		return nil, false
+	}
+	out, err := sjson.SetBytes(body, "thinking.type", "disabled")
+	if err != nil {
+		return nil, false
+	}
+	out, _ = sjson.DeleteBytes(out, "thinking.budget_tokens")
+	logWithRequestID(ctx).Info("claude executor: final assistant turn lacks a thinking block, retrying with thinking disabled")
+	return out, true
+}
+
+// retryWithoutAntigravityThinking is retryWithoutClaudeThinking for Claude models served
+// through Antigravity, whose requests carry thinking in generationConfig.thinkingConfig.
+func retryWithoutAntigravityThinking(ctx context.Context, model string, statusCode int, errBody, payload []byte, retried bool) ([]byte, bool) {
+	if retried || !strings.Contains(strings.ToLower(model), "claude") || !isThinkingStartError(statusCode, errBody) {
+		return nil, false
+	}
+	if !gjson.GetBytes(payload, "request.generationConfig.thinkingConfig").Exists() {
+		return nil, false
+	}
+	out, err := sjson.DeleteBytes(payload, "request.generationConfig.thinkingConfig")
+	if err != nil {
+		return nil, false
+	}
+	logWithRequestID(ctx).Info("antigravity executor: final assistant turn lacks a thinking block, retrying with thinking disabled")
+	return out, true
+}
@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const thinkingStartError = `{"type":"error","error":{"type":"invalid_request_error","message":"messages.1.content.0.type: Expected ` + "`thinking` or `redacted_thinking`" + `, but found ` + "`text`" + `. When ` + "`thinking`" + ` is enabled, a final ` + "`assistant`" + ` message must start with a thinking block."}}`
+
+func TestClaudeExecutorRetriesThinkingStartErrorWithoutThinking(t *testing.T) {
+	var thinkingTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		thinkingTypes = append(thinkingTypes, gjson.GetBytes(body, "thinking.type").String())
+		w.Header().Set("Content-Type", "application/json")
+		if len(thinkingTypes) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, thinkingStartError)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"done"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	registry.GetGlobalRegistry().RegisterClient("claude-thinking-recovery", "claude", []*registry.ModelInfo{{ID: "claude-sonnet-4-5", Thinking: &registry.ThinkingSupport{Min: 1024, Max: 32000}}})
+	defer registry.GetGlobalRegistry().UnregisterClient("claude-thinking-recovery")
+
+	exec := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "claude-1", Provider: "claude", Attributes: map[string]string{"api_key": "sk-ant", "base_url": server.URL}}
+	payload := []byte(`{"model":"claude-sonnet-4-5","max_tokens":4096,"thinking":{"type":"enabled","budget_tokens":2048},"messages":[{"role":"user","content":"go on"},{"role":"assistant","content":[{"type":"text","text":"partial"}]}]}`)
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "claude-sonnet-4-5", Payload: payload}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(thinkingTypes) != 2 || thinkingTypes[0] != "enabled" || thinkingTypes[1] != "disabled" {
+		t.Fatalf("upstream thinking types = %v, want [enabled disabled]", thinkingTypes)
+	}
+	if gjson.GetBytes(resp.Payload, "content.0.text").String() != "done" {
+		t.Fatalf("unexpected response %s", resp.Payload)
+	}
+}
+
+func TestThinkingStartRecoveryRetriesOnce(t *testing.T) {
+	body := []byte(`{"thinking":{"type":"enabled","budget_tokens":2048}}`)
+	if _, ok := retryWithoutClaudeThinking(context.Background(), http.StatusBadRequest, []byte(thinkingStartError), body, true); ok {
+		t.Fatal("recovery retried a request that was already retried")
+	}
+	if _, ok := retryWithoutClaudeThinking(context.Background(), http.StatusBadRequest, []byte(thinkingStartError), []byte(`{"thinking":{"type":"disabled"}}`), false); ok {
+		t.Fatal("recovery retried a request without thinking")
+	}
+	if _, ok := retryWithoutClaudeThinking(context.Background(), http.StatusTooManyRequests, []byte(thinkingStartError), body, false); ok {
+		t.Fatal("recovery retried a non-400 error")
+	}
+
+	payload := []byte(`{"request":{"generationConfig":{"thinkingConfig":{"thinkingBudget":2048}}}}`)
+	if _, ok := retryWithoutAntigravityThinking(context.Background(), "gemini-3-pro", http.StatusBadRequest, []byte(thinkingStartError), payload, false); ok {
+		t.Fatal("recovery retried a non-Claude Antigravity model")
+	}
+	out, ok := retryWithoutAntigravityThinking(context.Background(), "claude-opus-4-5-thinking", http.StatusBadRequest, []byte(thinkingStartError), payload, false)
+	if !ok || gjson.GetBytes(out, "request.generationConfig.thinkingConfig").Exists() {
+		t.Fatalf("antigravity recovery = %s, %v", out, ok)
+	}
+}
@@ -0,0 +1,193 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// resolveTimeouts returns the configured upstream timeouts for the auth's provider.
+func resolveTimeouts(cfg *config.Config, auth *cliproxyauth.Auth) config.TimeoutSet {
+	if cfg == nil {
+		return config.TimeoutSet{}
+	}
+	provider := ""
+	if auth != nil {
+		provider = auth.Provider
+	}
+	return cfg.Timeouts.For(provider)
+}
+
+func hasTransportTimeouts(t config.TimeoutSet) bool {
+	return t.ConnectSeconds > 0 || t.TLSHandshakeSeconds > 0 || t.ResponseHeaderSeconds > 0
+}
+
+// applyTransportTimeouts sets connect, TLS handshake and response header timeouts on transport.
+func applyTransportTimeouts(transport *http.Transport, t config.TimeoutSet) {
+	if t.TLSHandshakeSeconds > 0 {
+		transport.TLSHandshakeTimeout = time.Duration(t.TLSHandshakeSeconds) * time.Second
+	}
+	if t.ResponseHeaderSeconds > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(t.ResponseHeaderSeconds) * time.Second
+	}
+	if t.ConnectSeconds > 0 {
+		connect := time.Duration(t.ConnectSeconds) * time.Second
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{KeepAlive: 30 * time.Second}).DialContext
+		}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialCtx, cancel := context.WithTimeout(ctx, connect)
+			defer cancel()
+			return dial(dialCtx, network, addr)
+		}
+	}
+}
+
+type timeoutTransportKey struct {
+	base     http.RoundTripper
+	timeouts config.TimeoutSet
+}
+
+// timeoutTransports caches timeout-adjusted clones of shared transports so connection pools
+// survive across requests. Keys are bounded by the shared transports in use.
+var timeoutTransports sync.Map
+
+// withTransportTimeouts returns base (or the default transport) with t applied. Shared
+// transports are cloned once per timeout set. Other round trippers, such as wrapped transports
+// from a RoundTripperProvider, cannot have their dials changed: they get a deadline for the
+// response headers covering connect, TLS handshake and response header timeouts together, and
+// a warning is logged when only connect or TLS timeouts are set.
+func withTransportTimeouts(base http.RoundTripper, t config.TimeoutSet) http.RoundTripper {
+	if !hasTransportTimeouts(t) {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		if t.ResponseHeaderSeconds <= 0 {
+			warnUnappliedTimeouts(base)
+			return base
+		}
+		limit := time.Duration(t.ConnectSeconds+t.TLSHandshakeSeconds+t.ResponseHeaderSeconds) * time.Second
+		return &headerDeadlineTransport{base: base, limit: limit}
+	}
+	key := timeoutTransportKey{base: base, timeouts: t}
+	if cached, okLoad := timeoutTransports.Load(key); okLoad {
+		return cached.(http.RoundTripper)
+	}
+	clone := transport.Clone()
+	applyTransportTimeouts(clone, t)
+	actual, _ := timeoutTransports.LoadOrStore(key, clone)
+	return actual.(http.RoundTripper)
+}
+
+// unappliedTimeoutWarnings remembers the round tripper types already warned about.
+var unappliedTimeoutWarnings sync.Map
+
+func warnUnappliedTimeouts(base http.RoundTripper) {
+	name := fmt.Sprintf("%T", base)
+	if _, warned := unappliedTimeoutWarnings.LoadOrStore(name, struct{}{}); warned {
+		return
+	}
+	log.Warnf("upstream timeouts: connect and TLS handshake timeouts cannot be applied to %s; set response-header-seconds to bound it", name)
+}
+
+// headerDeadlineTransport fails requests whose response headers take longer than limit.
+type headerDeadlineTransport struct {
+	base  http.RoundTripper
+	limit time.Duration
+}
+
+func (t *headerDeadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.limit, cancel)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if !timer.Stop() {
+		cancel()
+		if resp != nil && resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		return nil, fmt.Errorf("upstream sent no response headers within %s", t.limit)
+	}
+	if err != nil || resp == nil || resp.Body == nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnCloseBody releases the request context of a response once its body is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// idleTimeoutTransport aborts response bodies that stay silent longer than idle.
+type idleTimeoutTransport struct {
+	base http.RoundTripper
+	idle time.Duration
+}
+
+func (t *idleTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+	resp.Body = newIdleTimeoutBody(resp.Body, t.idle)
+	return resp, nil
+}
+
+type idleTimeoutBody struct {
+	body     io.ReadCloser
+	idle     time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, idle time.Duration) *idleTimeoutBody {
+	b := &idleTimeoutBody{body: body, idle: idle}
+	b.timer = time.AfterFunc(idle, func() {
+		b.timedOut.Store(true)
+		_ = b.body.Close()
+	})
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if b.timedOut.Load() {
+		return n, fmt.Errorf("upstream stream idle for more than %s", b.idle)
+	}
+	if n > 0 {
+		b.timer.Reset(b.idle)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.body.Close()
+}
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestProxyAwareClientAbortsIdleStream(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		_, _ = io.WriteString(w, "data: first\n\n")
+		flusher.Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := &config.Config{Timeouts: config.TimeoutsConfig{
+		Providers: map[string]config.TimeoutSet{"Claude": {StreamIdleSeconds: 1}},
+	}}
+	client := newProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{Provider: "claude"}, 0)
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	start := time.Now()
+	body, err := io.ReadAll(resp.Body)
+	if err == nil || !strings.Contains(err.Error(), "idle") {
+		t.Fatalf("expected idle timeout error, got %v", err)
+	}
+	if !strings.Contains(string(body), "data: first") {
+		t.Fatalf("data before the stall should be delivered, got %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("idle timeout took too long: %s", elapsed)
+	}
+}
+
+func TestTimeoutsForMergesProviderOverrides(t *testing.T) {
+	timeouts := config.TimeoutsConfig{
+		TimeoutSet: config.TimeoutSet{ConnectSeconds: 5, ResponseHeaderSeconds: 60},
+		Providers:  map[string]config.TimeoutSet{"antigravity": {ResponseHeaderSeconds: 300}},
+	}
+	got := timeouts.For("antigravity")
+	if got.ConnectSeconds != 5 || got.ResponseHeaderSeconds != 300 {
+		t.Fatalf("unexpected merged timeouts: %+v", got)
+	}
+	if other := timeouts.For("codex"); other.ResponseHeaderSeconds != 60 {
+		t.Fatalf("unexpected default timeouts: %+v", other)
+	}
+}
+
+// wrappedRoundTripper stands in for a RoundTripperProvider transport that is not an *http.Transport.
+type wrappedRoundTripper struct{ base http.RoundTripper }
+
+func (w wrappedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return w.base.RoundTrip(req)
+}
+
+func TestProxyAwareClientBoundsHeadersOfWrappedRoundTripper(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := &config.Config{Timeouts: config.TimeoutsConfig{TimeoutSet: config.TimeoutSet{ResponseHeaderSeconds: 1}}}
+	ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(wrappedRoundTripper{base: http.DefaultTransport}))
+	client := newProxyAwareHTTPClient(ctx, cfg, &cliproxyauth.Auth{Provider: "claude"}, 0)
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("expected the response header deadline to fail the request")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("response header deadline took too long: %s", elapsed)
+	}
+}
@@ -5,6 +5,7 @@ package executor
 import (
 	"math"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 )
 
@@ -18,43 +19,10 @@ func NewTokenEstimator() *TokenEstimator {
 	return &TokenEstimator{}
 }
 
-// isWesternChar 判断字符是否为西文字符。
-// 西文字符包括 ASCII、拉丁扩展等字符块。
+// isWesternChar 判断字符是否为西文字符，规则见 util.IsWesternRune。
 // 非西文字符（中日韩、阿拉伯文等）消耗更多 token。
 func isWesternChar(c rune) bool {
-	// ASCII (U+0000..U+007F)
-	if c <= 0x007F {
-		return true
-	}
-	// 拉丁-1 补充 (U+0080..U+00FF)
-	if c >= 0x0080 && c <= 0x00FF {
-		return true
-	}
-	// 拉丁扩展-A (U+0100..U+017F)
-	if c >= 0x0100 && c <= 0x017F {
-		return true
-	}
-	// 拉丁扩展-B (U+0180..U+024F)
-	if c >= 0x0180 && c <= 0x024F {
-		return true
-	}
-	// 拉丁扩展附加 (U+1E00..U+1EFF)
-	if c >= 0x1E00 && c <= 0x1EFF {
-		return true
-	}
-	// 拉丁扩展-C (U+2C60..U+2C7F)
-	if c >= 0x2C60 && c <= 0x2C7F {
-		return true
-	}
-	// 拉丁扩展-D (U+A720..U+A7FF)
-	if c >= 0xA720 && c <= 0xA7FF {
-		return true
-	}
-	// 拉丁扩展-E (U+AB30..U+AB6F)
-	if c >= 0xAB30 && c <= 0xAB6F {
-		return true
-	}
-	return false
+	return util.IsWesternRune(c)
 }
 
 // countCharUnits 计算字符串的字符单位。
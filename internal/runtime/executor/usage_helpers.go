@@ -3,12 +3,16 @@ package executor
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/tidwall/gjson"
@@ -23,6 +27,8 @@ type usageReporter struct {
 	apiKey      string
 	source      string
 	requestedAt time.Time
+	estimated   int64
+	statusCode  int
 	once        sync.Once
 }
 
@@ -42,6 +48,15 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 	return reporter
 }
 
+// estimateInput records a local prompt token estimate for payload so the usage statistics
+// can reconcile it with the upstream-reported input tokens.
+func (r *usageReporter) estimateInput(payload []byte) {
+	if r == nil || len(payload) == 0 || !internalusage.StatisticsEnabled() {
+		return
+	}
+	r.estimated = globalTokenEstimator.EstimateTotalTokens(payload)
+}
+
 func (r *usageReporter) publish(ctx context.Context, detail usage.Detail) {
 	r.publishWithOutcome(ctx, detail, false)
 }
@@ -55,6 +70,10 @@ func (r *usageReporter) trackFailure(ctx context.Context, errPtr *error) {
 		return
 	}
 	if *errPtr != nil {
+		var status interface{ StatusCode() int }
+		if errors.As(*errPtr, &status) {
+			r.statusCode = status.StatusCode()
+		}
 		r.publishFailure(ctx)
 	}
 }
@@ -82,11 +101,33 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 			AuthIndex:   r.authIndex,
 			RequestedAt: r.requestedAt,
 			Failed:      failed,
+			StatusCode:  r.outcomeStatus(failed),
+			Latency:     time.Since(r.requestedAt),
 			Detail:      detail,
+
+			EstimatedInputTokens: r.estimated,
 		})
+		if !failed {
+			setCostHeader(ctx, r.model, detail)
+		}
 	})
 }
 
+// setCostHeader adds the estimated cost of detail to the client response when its headers
+// have not been written yet, which is the case for non-streaming requests.
+func setCostHeader(ctx context.Context, model string, detail usage.Detail) {
+	if ctx == nil {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Writer.Written() {
+		return
+	}
+	if cost, ok := pricing.Estimate(model, detail); ok {
+		ginCtx.Header(pricing.CostHeader, pricing.Format(cost))
+	}
+}
+
 // ensurePublished guarantees that a usage record is emitted exactly once.
 // It is safe to call multiple times; only the first call wins due to once.Do.
 // This is used to ensure request counting even when upstream responses do not
@@ -105,11 +146,22 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 			AuthIndex:   r.authIndex,
 			RequestedAt: r.requestedAt,
 			Failed:      false,
+			StatusCode:  r.outcomeStatus(false),
+			Latency:     time.Since(r.requestedAt),
 			Detail:      usage.Detail{},
 		})
 	})
 }
 
+// outcomeStatus returns the upstream status to report: the failing status when known,
+// otherwise 200 for successful requests.
+func (r *usageReporter) outcomeStatus(failed bool) int {
+	if failed || r.statusCode != 0 {
+		return r.statusCode
+	}
+	return http.StatusOK
+}
+
 func apiKeyFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
@@ -1,6 +1,15 @@
 package executor
 
-import "testing"
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
 
 func TestParseOpenAIUsageChatCompletions(t *testing.T) {
 	data := []byte(`{"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3,"prompt_tokens_details":{"cached_tokens":4},"completion_tokens_details":{"reasoning_tokens":5}}}`)
@@ -41,3 +50,27 @@ func TestParseOpenAIUsageResponses(t *testing.T) {
 		t.Fatalf("reasoning tokens = %d, want %d", detail.ReasoningTokens, 9)
 	}
 }
+
+func TestUsageReporterSetsCostHeader(t *testing.T) {
+	pricing.Set([]config.ModelPrice{{Model: "gpt-5", Input: 1, Output: 10}})
+	defer pricing.Set(nil)
+
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	reporter := newUsageReporter(ctx, "codex", "gpt-5", nil)
+	reporter.publish(ctx, usage.Detail{InputTokens: 1000, OutputTokens: 100})
+	if got := recorder.Header().Get(pricing.CostHeader); got != "0.002000" {
+		t.Fatalf("cost header = %q, want 0.002000", got)
+	}
+
+	// Streamed responses have already sent their headers.
+	recorder = httptest.NewRecorder()
+	ginCtx, _ = gin.CreateTestContext(recorder)
+	ginCtx.Writer.WriteHeaderNow()
+	ctx = context.WithValue(context.Background(), "gin", ginCtx)
+	newUsageReporter(ctx, "codex", "gpt-5", nil).publish(ctx, usage.Detail{InputTokens: 1000})
+	if got := recorder.Header().Get(pricing.CostHeader); got != "" {
+		t.Fatalf("cost header after write = %q, want none", got)
+	}
+}
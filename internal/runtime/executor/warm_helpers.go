@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	iflowauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/iflow"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// warmUpstream sends a HEAD request to baseURL through the same client stack used for real
+// requests, so DNS, TCP and TLS setup are done before a failover lands on the provider. Any
+// HTTP response counts as success; only transport errors are reported.
+func warmUpstream(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, baseURL string) error {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return nil
+	}
+	httpReq, errReq := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if errReq != nil {
+		return errReq
+	}
+	resp, errDo := newProxyAwareHTTPClient(ctx, cfg, auth, 0).Do(httpReq)
+	if errDo != nil {
+		return errDo
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// Warm opens a connection to the Claude endpoint used by auth.
+func (e *ClaudeExecutor) Warm(ctx context.Context, auth *cliproxyauth.Auth) error {
+	_, baseURL := claudeCreds(auth)
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	return warmUpstream(ctx, e.cfg, auth, baseURL)
+}
+
+// Warm opens a connection to the Codex endpoint used by auth.
+func (e *CodexExecutor) Warm(ctx context.Context, auth *cliproxyauth.Auth) error {
+	_, baseURL := codexCreds(auth)
+	if baseURL == "" {
+		baseURL = "https://chatgpt.com/backend-api/codex"
+	}
+	return warmUpstream(ctx, e.cfg, auth, baseURL)
+}
+
+// Warm opens a connection to the Gemini endpoint used by auth.
+func (e *GeminiExecutor) Warm(ctx context.Context, auth *cliproxyauth.Auth) error {
+	return warmUpstream(ctx, e.cfg, auth, resolveGeminiBaseURL(auth))
+}
+
+// Warm opens a connection to the preferred Antigravity endpoint.
+func (e *AntigravityExecutor) Warm(ctx context.Context, auth *cliproxyauth.Auth) error {
+	baseURLs := antigravityBaseURLFallbackOrder(auth)
+	if len(baseURLs) == 0 {
+		return nil
+	}
+	return warmUpstream(ctx, e.cfg, auth, baseURLs[0])
+}
+
+// Warm opens a connection to the Qwen endpoint used by auth.
+func (e *QwenExecutor) Warm(ctx context.Context, auth *cliproxyauth.Auth) error {
+	_, baseURL := qwenCreds(auth)
+	if baseURL == "" {
+		baseURL = "https://portal.qwen.ai/v1"
+	}
+	return warmUpstream(ctx, e.cfg, auth, baseURL)
+}
+
+// Warm opens a connection to the iFlow endpoint used by auth.
+func (e *IFlowExecutor) Warm(ctx context.Context, auth *cliproxyauth.Auth) error {
+	_, baseURL := iflowCreds(auth)
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = iflowauth.DefaultAPIBaseURL
+	}
+	return warmUpstream(ctx, e.cfg, auth, baseURL)
+}
+
+// Warm opens a connection to the OpenAI-compatible endpoint configured for auth.
+func (e *OpenAICompatExecutor) Warm(ctx context.Context, auth *cliproxyauth.Auth) error {
+	baseURL, _ := e.resolveCredentials(auth)
+	return warmUpstream(ctx, e.cfg, auth, baseURL)
+}
@@ -0,0 +1,219 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultServiceName = "cli-proxy-api"
+	exportInterval     = 5 * time.Second
+	exportBatchSize    = 512
+	// maxQueuedSpans bounds memory while the collector is unreachable; newer spans are dropped.
+	maxQueuedSpans = 8192
+	exportTimeout  = 10 * time.Second
+)
+
+// exporter batches finished spans and posts them to the collector in OTLP JSON.
+type exporter struct {
+	enabled atomic.Bool
+	cfg     atomic.Pointer[config.TracingConfig]
+	once    sync.Once
+	client  *http.Client
+	wake    chan struct{}
+
+	mu      sync.Mutex
+	queue   []*Span
+	dropped int
+}
+
+var defaultExporter = &exporter{client: &http.Client{Timeout: exportTimeout}, wake: make(chan struct{}, 1)}
+
+// Configure applies the tracing config; it is called at startup and on every config reload.
+// Spans already queued are still exported to the new endpoint.
+func Configure(cfg config.TracingConfig) {
+	cfg.Endpoint = strings.TrimSpace(cfg.Endpoint)
+	defaultExporter.cfg.Store(&cfg)
+	enabled := cfg.Enabled && cfg.Endpoint != ""
+	if cfg.Enabled && cfg.Endpoint == "" {
+		log.Warn("tracing: enabled without an endpoint; spans are not recorded")
+	}
+	defaultExporter.enabled.Store(enabled)
+	if enabled {
+		defaultExporter.once.Do(func() { go defaultExporter.run() })
+	}
+}
+
+// Enabled reports whether spans are currently recorded.
+func Enabled() bool { return defaultExporter.enabled.Load() }
+
+// Flush exports queued spans immediately, e.g. before shutdown.
+func Flush(ctx context.Context) error { return defaultExporter.flush(ctx) }
+
+func (e *exporter) sampleRatio() float64 {
+	cfg := e.cfg.Load()
+	if cfg == nil || cfg.SampleRatio <= 0 {
+		return 1
+	}
+	return cfg.SampleRatio
+}
+
+func (e *exporter) enqueue(span *Span) {
+	e.mu.Lock()
+	if len(e.queue) >= maxQueuedSpans {
+		e.dropped++
+		e.mu.Unlock()
+		return
+	}
+	e.queue = append(e.queue, span)
+	full := len(e.queue) >= exportBatchSize
+	e.mu.Unlock()
+	if full {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *exporter) run() {
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.wake:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := e.flush(ctx); err != nil {
+			log.Warnf("tracing: export failed: %v", err)
+		}
+		cancel()
+	}
+}
+
+func (e *exporter) flush(ctx context.Context) error {
+	cfg := e.cfg.Load()
+	e.mu.Lock()
+	spans := e.queue
+	e.queue = nil
+	dropped := e.dropped
+	e.dropped = 0
+	e.mu.Unlock()
+	if dropped > 0 {
+		log.Warnf("tracing: dropped %d spans while the export queue was full", dropped)
+	}
+	if len(spans) == 0 || cfg == nil || cfg.Endpoint == "" {
+		return nil
+	}
+	for len(spans) > 0 {
+		batch := spans[:min(len(spans), exportBatchSize)]
+		spans = spans[len(batch):]
+		if err := e.post(ctx, cfg, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) post(ctx context.Context, cfg *config.TracingConfig, spans []*Span) error {
+	body, err := json.Marshal(encodeSpans(cfg, spans))
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// encodeSpans builds an OTLP ExportTraceServiceRequest in its JSON mapping: IDs are hex and
+// 64-bit integers are decimal strings.
+func encodeSpans(cfg *config.TracingConfig, spans []*Span) map[string]any {
+	service := strings.TrimSpace(cfg.ServiceName)
+	if service == "" {
+		service = defaultServiceName
+	}
+	encoded := make([]map[string]any, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		item := map[string]any{
+			"traceId":           hex.EncodeToString(span.traceID[:]),
+			"spanId":            hex.EncodeToString(span.spanID[:]),
+			"name":              span.name,
+			"kind":              int(span.kind),
+			"startTimeUnixNano": strconv.FormatInt(span.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.end.UnixNano(), 10),
+			"attributes":        encodeAttributes(span.attrs),
+		}
+		if span.parentID != [8]byte{} {
+			item["parentSpanId"] = hex.EncodeToString(span.parentID[:])
+		}
+		if span.errMsg != "" {
+			item["status"] = map[string]any{"code": 2, "message": span.errMsg}
+		}
+		span.mu.Unlock()
+		encoded = append(encoded, item)
+	}
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": encodeAttributes([]Attribute{String("service.name", service)})},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+func encodeAttributes(attrs []Attribute) []map[string]any {
+	out := make([]map[string]any, 0, len(attrs))
+	for _, attr := range attrs {
+		var value map[string]any
+		switch v := attr.Value.(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]any{"key": attr.Key, "value": value})
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware starts a server span for every inbound request, continuing the caller's trace
+// when it sends a traceparent header.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled() {
+			c.Next()
+			return
+		}
+		ctx := Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := Start(ctx, c.Request.Method+" "+routeOf(c), KindServer, String("http.request.method", c.Request.Method))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		route := routeOf(c)
+		span.SetAttributes(String("http.route", route), Int("http.response.status_code", c.Writer.Status()))
+		span.SetName(c.Request.Method + " " + route)
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		} else if c.Writer.Status() >= http.StatusInternalServerError {
+			span.setError(http.StatusText(c.Writer.Status()))
+		}
+		span.End()
+	}
+}
+
+// routeOf returns the matched route pattern; unmatched paths share one name so scanners do
+// not produce a span name per URL.
+func routeOf(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
+
+// Transport wraps base so each upstream HTTP attempt is recorded as a client span and
+// carries the traceparent header. Requests made outside a trace pass through unchanged.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if SpanFromContext(req.Context()) == nil {
+		return base.RoundTrip(req)
+	}
+	ctx, span := Start(req.Context(), "upstream "+req.Method, KindClient,
+		String("http.request.method", req.Method),
+		String("server.address", req.URL.Host),
+		// The query is left out: some providers take the API key as a query parameter.
+		String("url.full", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+	)
+	if span == nil {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	span.SetAttributes(Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.setError(resp.Status)
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		span.End()
+		return resp, nil
+	}
+	// The span covers the whole response so streamed generations report their full latency.
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody ends the client span when the response body is exhausted or closed.
+type spanBody struct {
+	io.ReadCloser
+	span *Span
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.span.End()
+	} else if err != nil {
+		b.span.RecordError(err)
+		b.span.End()
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	b.span.End()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,251 @@
+// Package tracing records OpenTelemetry spans for inbound requests, executor attempts, token
+// refreshes, request translation and upstream HTTP calls, and exports them to an OTLP/HTTP
+// collector. Like the metrics package it implements the small subset of the protocol the
+// proxy needs instead of depending on the OpenTelemetry SDK. Trace context is propagated to
+// upstream providers with the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the OpenTelemetry span kind.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// TraceparentHeader carries trace context between services.
+const TraceparentHeader = "traceparent"
+
+// Attribute is one key/value annotation of a span. Value is a string, int64, float64 or bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int returns an integer attribute.
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Span is one timed operation of a trace. A nil *Span is valid and records nothing, so
+// callers do not need to check whether tracing is enabled.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     Kind
+	start    time.Time
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []Attribute
+	errMsg string
+	ended  bool
+}
+
+// spanContext identifies a span of a trace started by another service.
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+type unsampledKey struct{}
+
+// Start begins a span as a child of the span in ctx. Without a parent a new trace is
+// started, subject to the configured sample ratio. It returns ctx unchanged and a nil span
+// when tracing is disabled or the trace is not sampled.
+func Start(ctx context.Context, name string, kind Kind, attrs ...Attribute) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !Enabled() || ctx.Value(unsampledKey{}) != nil {
+		return ctx, nil
+	}
+	span := &Span{name: name, kind: kind, start: time.Now(), attrs: attrs}
+	switch parent, remote := SpanFromContext(ctx), remoteFromContext(ctx); {
+	case parent != nil:
+		span.traceID, span.parentID = parent.traceID, parent.spanID
+	case remote != nil:
+		if !remote.sampled {
+			return context.WithValue(ctx, unsampledKey{}, true), nil
+		}
+		span.traceID, span.parentID = remote.traceID, remote.spanID
+	default:
+		if !sampled() {
+			return context.WithValue(ctx, unsampledKey{}, true), nil
+		}
+		fillRandom(span.traceID[:])
+	}
+	fillRandom(span.spanID[:])
+	return ContextWithSpan(ctx, span), span
+}
+
+// ContextWithSpan returns a copy of ctx carrying span as the parent of later spans.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span of ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func remoteFromContext(ctx context.Context) *spanContext {
+	remote, _ := ctx.Value(remoteKey{}).(*spanContext)
+	return remote
+}
+
+// SetAttributes adds annotations to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed. Context cancellation is recorded as an attribute
+// instead, since a client going away is not an error of the proxy.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		s.SetAttributes(Bool("cliproxy.canceled", true))
+		return
+	}
+	s.setError(err.Error())
+}
+
+// setError marks the span as failed with msg.
+func (s *Span) setError(msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = msg
+	s.mu.Unlock()
+}
+
+// SetName renames the span, e.g. once the matched route is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export. Later calls are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	defaultExporter.enqueue(s)
+}
+
+// Traceparent formats the span's context as a W3C traceparent header value.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(s.traceID[:]) + "-" + hex.EncodeToString(s.spanID[:]) + "-01"
+}
+
+// Inject sets the traceparent header for the span in ctx, if any.
+func Inject(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil && header != nil {
+		header.Set(TraceparentHeader, span.Traceparent())
+	}
+}
+
+// Extract returns ctx carrying the trace context of an inbound traceparent header, so
+// spans started from it join the caller's trace. Malformed headers are ignored.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if remote, ok := parseTraceparent(header.Get(TraceparentHeader)); ok {
+		return context.WithValue(ctx, remoteKey{}, remote)
+	}
+	return ctx
+}
+
+// parseTraceparent parses "00-<32 hex trace id>-<16 hex span id>-<2 hex flags>".
+func parseTraceparent(value string) (*spanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return nil, false
+	}
+	var remote spanContext
+	var flags [1]byte
+	if _, err := hex.Decode(remote.traceID[:], []byte(parts[1])); err != nil {
+		return nil, false
+	}
+	if _, err := hex.Decode(remote.spanID[:], []byte(parts[2])); err != nil {
+		return nil, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return nil, false
+	}
+	if remote.traceID == [16]byte{} || remote.spanID == [8]byte{} {
+		return nil, false
+	}
+	remote.sampled = flags[0]&1 == 1
+	return &remote, true
+}
+
+func sampled() bool {
+	ratio := defaultExporter.sampleRatio()
+	return ratio >= 1 || rand.Float64() < ratio
+}
+
+func fillRandom(b []byte) {
+	for {
+		for i := range b {
+			b[i] = byte(rand.Uint32())
+		}
+		for _, v := range b {
+			if v != 0 {
+				return
+			}
+		}
+	}
+}
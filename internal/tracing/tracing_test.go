@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	remote, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || !remote.sampled || remote.traceID[0] != 0x4b || remote.spanID[7] != 0xb7 {
+		t.Fatalf("valid header = %+v, %v", remote, ok)
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		if _, ok = parseTraceparent(bad); ok {
+			t.Fatalf("invalid header %q accepted", bad)
+		}
+	}
+}
+
+func TestSpansAreExportedWithPropagatedContext(t *testing.T) {
+	var mu sync.Mutex
+	var exported []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("export path = %s", r.URL.Path)
+		}
+		mu.Lock()
+		exported, _ = io.ReadAll(r.Body)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get(TraceparentHeader)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	Configure(config.TracingConfig{Enabled: true, Endpoint: collector.URL, ServiceName: "test"})
+	defer Configure(config.TracingConfig{})
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := Start(Extract(context.Background(), header), "root", KindServer)
+	client := &http.Client{Transport: Transport(nil)}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/v1/generate?key=secret", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("upstream request: %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	root.End()
+
+	if !strings.HasPrefix(upstreamTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Fatalf("upstream traceparent = %q, want the caller's trace", upstreamTraceparent)
+	}
+	if err = Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	var payload struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Attributes   []struct {
+						Key   string         `json:"key"`
+						Value map[string]any `json:"value"`
+					} `json:"attributes"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err = json.Unmarshal(exported, &payload); err != nil {
+		t.Fatalf("decode export: %v\n%s", err, exported)
+	}
+	spans := payload.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2: %s", len(spans), exported)
+	}
+	client0, server := spans[0], spans[1]
+	if client0.Name != "upstream GET" || server.Name != "root" {
+		t.Fatalf("span order = %s, %s", client0.Name, server.Name)
+	}
+	if server.ParentSpanID != "00f067aa0ba902b7" || client0.ParentSpanID != server.SpanID || client0.TraceID != server.TraceID {
+		t.Fatalf("span links: client %+v, server %+v", client0, server)
+	}
+	if upstreamTraceparent != "00-"+client0.TraceID+"-"+client0.SpanID+"-01" {
+		t.Fatalf("upstream traceparent %q does not name the client span", upstreamTraceparent)
+	}
+	for _, attr := range client0.Attributes {
+		if attr.Key == "url.full" && strings.Contains(attr.Value["stringValue"].(string), "secret") {
+			t.Fatalf("url.full leaks the query: %v", attr.Value)
+		}
+	}
+}
+
+func TestUnsampledCallerRecordsNothing(t *testing.T) {
+	Configure(config.TracingConfig{Enabled: true, Endpoint: "http://127.0.0.1:1"})
+	defer Configure(config.TracingConfig{})
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	ctx, span := Start(Extract(context.Background(), header), "root", KindServer)
+	if span != nil {
+		t.Fatal("span recorded for an unsampled caller")
+	}
+	if _, child := Start(ctx, "child", KindInternal); child != nil {
+		t.Fatal("child span recorded for an unsampled trace")
+	}
+	span.SetAttributes(String("k", "v"))
+	span.RecordError(io.EOF)
+	span.End()
+}
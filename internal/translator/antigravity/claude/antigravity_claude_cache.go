@@ -0,0 +1,47 @@
+package claude
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Antigravity has no cache_control: the request translator drops the breakpoints and the
+// upstream caches prompt prefixes implicitly, reporting only the tokens it read from cache.
+// The response translators report those as cache_read_input_tokens; the upstream does not
+// say what it wrote to cache, so cache_creation_input_tokens is never reported.
+
+// stripCacheControl removes the cache_control markers of a tool result's content blocks,
+// which would otherwise reach the model as part of the function response.
+func stripCacheControl(raw string) string {
+	result := gjson.Parse(raw)
+	if result.IsObject() {
+		raw, _ = sjson.Delete(raw, "cache_control")
+		return raw
+	}
+	for i, block := range result.Array() {
+		if block.Get("cache_control").Exists() {
+			raw, _ = sjson.Delete(raw, fmt.Sprintf("%d.cache_control", i))
+		}
+	}
+	return raw
+}
+
+// claudeCacheUsage splits an upstream prompt token count into Claude's uncached input and
+// cache read tokens, which together add up to the prompt.
+func claudeCacheUsage(promptTokens, cachedTokens int64) (input, read int64) {
+	read = min(max(cachedTokens, 0), promptTokens)
+	return promptTokens - read, read
+}
+
+// setClaudeUsageInput writes the input side of a Claude usage object at path, including
+// cache_read_input_tokens when the upstream reported cached tokens.
+func setClaudeUsageInput(usageJSON, path string, promptTokens, cachedTokens int64) string {
+	input, read := claudeCacheUsage(promptTokens, cachedTokens)
+	usageJSON, _ = sjson.Set(usageJSON, path+".input_tokens", input)
+	if read > 0 {
+		usageJSON, _ = sjson.Set(usageJSON, path+".cache_read_input_tokens", read)
+	}
+	return usageJSON
+}
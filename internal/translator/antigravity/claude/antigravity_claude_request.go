@@ -90,6 +90,9 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 				contentResults := contentsResult.Array()
 				numContents := len(contentResults)
 				var currentMessageThinkingSignature string
+				// Images returned by tool results are sent as inlineData parts after every
+				// functionResponse of the turn, since tool results must lead the message.
+				var toolResultImageParts []string
 				for j := 0; j < numContents; j++ {
 					contentResult := contentResults[j]
 					contentTypeResult := contentResult.Get("type")
@@ -186,14 +189,15 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							partJSON := `{}`
 
 							// Use skip_thought_signature_validator for tool calls without valid thinking signature
+							// (or as the configured signature policy says).
 							// This is the approach used in opencode-google-antigravity-auth for Gemini
 							// and also works for Claude through Antigravity API
-							const skipSentinel = "skip_thought_signature_validator"
+							existingSignature := ""
 							if cache.HasValidSignature(modelName, currentMessageThinkingSignature) {
-								partJSON, _ = sjson.Set(partJSON, "thoughtSignature", currentMessageThinkingSignature)
-							} else {
-								// No valid signature - use skip sentinel to bypass validation
-								partJSON, _ = sjson.Set(partJSON, "thoughtSignature", skipSentinel)
+								existingSignature = currentMessageThinkingSignature
+							}
+							if signature, ok := cache.FunctionCallSignature(modelName, existingSignature, cache.SignaturePolicyMissing); ok {
+								partJSON, _ = sjson.Set(partJSON, "thoughtSignature", signature)
 							}
 
 							if functionID != "" {
@@ -222,15 +226,24 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 								responseData = functionResponseResult.String()
 								functionResponseJSON, _ = sjson.Set(functionResponseJSON, "response.result", responseData)
 							} else if functionResponseResult.IsArray() {
-								frResults := functionResponseResult.Array()
-								if len(frResults) == 1 {
-									functionResponseJSON, _ = sjson.SetRaw(functionResponseJSON, "response.result", frResults[0].Raw)
-								} else {
-									functionResponseJSON, _ = sjson.SetRaw(functionResponseJSON, "response.result", functionResponseResult.Raw)
+								var frResults []string
+								for _, block := range functionResponseResult.Array() {
+									if imagePart, ok := imageInlineDataPart(block); ok {
+										toolResultImageParts = append(toolResultImageParts, imagePart)
+										continue
+									}
+									frResults = append(frResults, block.Raw)
+								}
+								switch len(frResults) {
+								case 0:
+									functionResponseJSON, _ = sjson.Set(functionResponseJSON, "response.result", "")
+								case 1:
+									functionResponseJSON, _ = sjson.SetRaw(functionResponseJSON, "response.result", stripCacheControl(frResults[0]))
+								default:
+									functionResponseJSON, _ = sjson.SetRaw(functionResponseJSON, "response.result", stripCacheControl("["+strings.Join(frResults, ",")+"]"))
 								}
-
 							} else if functionResponseResult.IsObject() {
-								functionResponseJSON, _ = sjson.SetRaw(functionResponseJSON, "response.result", functionResponseResult.Raw)
+								functionResponseJSON, _ = sjson.SetRaw(functionResponseJSON, "response.result", stripCacheControl(functionResponseResult.Raw))
 							} else {
 								functionResponseJSON, _ = sjson.SetRaw(functionResponseJSON, "response.result", functionResponseResult.Raw)
 							}
@@ -239,23 +252,13 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							partJSON, _ = sjson.SetRaw(partJSON, "functionResponse", functionResponseJSON)
 							clientContentJSON, _ = sjson.SetRaw(clientContentJSON, "parts.-1", partJSON)
 						}
-					} else if contentTypeResult.Type == gjson.String && contentTypeResult.String() == "image" {
-						sourceResult := contentResult.Get("source")
-						if sourceResult.Get("type").String() == "base64" {
-							inlineDataJSON := `{}`
-							if mimeType := sourceResult.Get("media_type").String(); mimeType != "" {
-								inlineDataJSON, _ = sjson.Set(inlineDataJSON, "mime_type", mimeType)
-							}
-							if data := sourceResult.Get("data").String(); data != "" {
-								inlineDataJSON, _ = sjson.Set(inlineDataJSON, "data", data)
-							}
-
-							partJSON := `{}`
-							partJSON, _ = sjson.SetRaw(partJSON, "inlineData", inlineDataJSON)
-							clientContentJSON, _ = sjson.SetRaw(clientContentJSON, "parts.-1", partJSON)
-						}
+					} else if partJSON, ok := imageInlineDataPart(contentResult); ok {
+						clientContentJSON, _ = sjson.SetRaw(clientContentJSON, "parts.-1", partJSON)
 					}
 				}
+				for _, partJSON := range toolResultImageParts {
+					clientContentJSON, _ = sjson.SetRaw(clientContentJSON, "parts.-1", partJSON)
+				}
 
 				// Reorder parts for 'model' role to ensure thinking block is first
 				if role == "model" {
@@ -381,6 +384,13 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		out, _ = sjson.SetRaw(out, "request.tools", toolsJSON)
 	}
 
+	// tool_choice -> request.toolConfig.functionCallingConfig
+	if gjson.Get(out, "request.tools").Exists() {
+		if choice, ok := util.ParseToolChoice(gjson.GetBytes(rawJSON, "tool_choice")); ok {
+			out, _ = sjson.SetRaw(out, "request.toolConfig", choice.Gemini())
+		}
+	}
+
 	// Map Anthropic thinking -> Gemini thinkingBudget/include_thoughts when type==enabled
 	if t := gjson.GetBytes(rawJSON, "thinking"); enableThoughtTranslate && t.Exists() && t.IsObject() {
 		if t.Get("type").String() == "enabled" {
@@ -409,3 +419,24 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 
 	return outBytes
 }
+
+// imageInlineDataPart converts a Claude base64 image block to a Gemini inlineData part.
+func imageInlineDataPart(block gjson.Result) (string, bool) {
+	if block.Get("type").String() != "image" {
+		return "", false
+	}
+	sourceResult := block.Get("source")
+	if sourceResult.Get("type").String() != "base64" {
+		return "", false
+	}
+	inlineDataJSON := `{}`
+	if mimeType := sourceResult.Get("media_type").String(); mimeType != "" {
+		inlineDataJSON, _ = sjson.Set(inlineDataJSON, "mime_type", mimeType)
+	}
+	if data := sourceResult.Get("data").String(); data != "" {
+		inlineDataJSON, _ = sjson.Set(inlineDataJSON, "data", data)
+	}
+	partJSON := `{}`
+	partJSON, _ = sjson.SetRaw(partJSON, "inlineData", inlineDataJSON)
+	return partJSON, true
+}
@@ -355,6 +355,75 @@ func TestConvertClaudeRequestToAntigravity_ToolResult(t *testing.T) {
 	}
 }
 
+func TestConvertClaudeRequestToAntigravity_ToolResultImages(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "claude-3-5-sonnet-20240620",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{
+						"type": "tool_result",
+						"tool_use_id": "screenshot-call-1",
+						"content": [
+							{"type": "text", "text": "captured"},
+							{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}}
+						]
+					},
+					{
+						"type": "tool_result",
+						"tool_use_id": "photo-call-2",
+						"content": [
+							{"type": "image", "source": {"type": "base64", "media_type": "image/jpeg", "data": "/9j/4AAQ"}}
+						]
+					}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertClaudeRequestToAntigravity("claude-sonnet-4-5", inputJSON, false)
+	parts := gjson.GetBytes(output, "request.contents.0.parts").Array()
+	if len(parts) != 4 {
+		t.Fatalf("Expected 2 function responses and 2 images, got %d parts: %s", len(parts), output)
+	}
+	if got := parts[0].Get("functionResponse.response.result.text").String(); got != "captured" {
+		t.Errorf("Expected the text block as result, got '%s'", parts[0].Get("functionResponse.response.result").Raw)
+	}
+	if result := parts[1].Get("functionResponse.response.result"); !result.Exists() || result.String() != "" {
+		t.Errorf("Expected an empty result for an image-only tool result, got '%s'", result.Raw)
+	}
+	if parts[2].Get("inlineData.mime_type").String() != "image/png" || parts[2].Get("inlineData.data").String() != "iVBORw0KGgo=" {
+		t.Errorf("Expected the first image after the function responses, got '%s'", parts[2].Raw)
+	}
+	if parts[3].Get("inlineData.mime_type").String() != "image/jpeg" {
+		t.Errorf("Expected the second image last, got '%s'", parts[3].Raw)
+	}
+}
+
+func TestConvertClaudeRequestToAntigravity_StripsCacheControl(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "claude-sonnet-4-5",
+		"system": [{"type": "text", "text": "Be brief.", "cache_control": {"type": "ephemeral"}}],
+		"tools": [{"name": "lookup", "input_schema": {"type": "object"}, "cache_control": {"type": "ephemeral"}}],
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "lookup-call-1", "content": [{"type": "text", "text": "42", "cache_control": {"type": "ephemeral"}}]},
+				{"type": "text", "text": "Explain.", "cache_control": {"type": "ephemeral"}}
+			]}
+		]
+	}`)
+
+	output := string(ConvertClaudeRequestToAntigravity("claude-sonnet-4-5", inputJSON, false))
+
+	if strings.Contains(output, "cache_control") {
+		t.Fatalf("cache_control should not reach Antigravity: %s", output)
+	}
+	if got := gjson.Get(output, "request.contents.0.parts.0.functionResponse.response.result.text").String(); got != "42" {
+		t.Errorf("tool result text = %q, want 42", got)
+	}
+}
+
 func TestConvertClaudeRequestToAntigravity_ThinkingConfig(t *testing.T) {
 	// Note: This test requires the model to be registered in the registry
 	// with Thinking metadata. If the registry is not populated in test environment,
@@ -697,3 +766,22 @@ func TestConvertClaudeRequestToAntigravity_ToolAndThinking_NoExistingSystem(t *t
 		t.Errorf("Interleaved thinking hint should be in created systemInstruction, got: %v", sysInstruction.Raw)
 	}
 }
+
+func TestConvertClaudeRequestToAntigravity_ToolChoiceForcesNamedTool(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "claude-sonnet-4-5",
+		"messages": [{"role": "user", "content": "What's the weather?"}],
+		"tools": [{"name": "get_weather", "input_schema": {"type": "object", "properties": {}}}],
+		"tool_choice": {"type": "tool", "name": "get_weather"}
+	}`)
+
+	output := ConvertClaudeRequestToAntigravity("claude-sonnet-4-5", inputJSON, false)
+
+	config := gjson.GetBytes(output, "request.toolConfig.functionCallingConfig")
+	if config.Get("mode").String() != "ANY" {
+		t.Fatalf("mode = %q, want ANY", config.Get("mode").String())
+	}
+	if names := config.Get("allowedFunctionNames").Array(); len(names) != 1 || names[0].String() != "get_weather" {
+		t.Fatalf("allowedFunctionNames = %s, want [get_weather]", config.Get("allowedFunctionNames").Raw)
+	}
+}
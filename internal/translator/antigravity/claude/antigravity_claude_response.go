@@ -15,7 +15,6 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
-	log "github.com/sirupsen/logrus"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -100,7 +99,8 @@ func ConvertAntigravityResponseToClaude(_ context.Context, _ string, originalReq
 
 		// Use cpaUsageMetadata within the message_start event for Claude.
 		if promptTokenCount := gjson.GetBytes(rawJSON, "response.cpaUsageMetadata.promptTokenCount"); promptTokenCount.Exists() {
-			messageStartTemplate, _ = sjson.Set(messageStartTemplate, "message.usage.input_tokens", promptTokenCount.Int())
+			cachedTokenCount := gjson.GetBytes(rawJSON, "response.cpaUsageMetadata.cachedContentTokenCount").Int()
+			messageStartTemplate = setClaudeUsageInput(messageStartTemplate, "message.usage", promptTokenCount.Int(), cachedTokenCount)
 		}
 		if candidatesTokenCount := gjson.GetBytes(rawJSON, "response.cpaUsageMetadata.candidatesTokenCount"); candidatesTokenCount.Exists() {
 			messageStartTemplate, _ = sjson.Set(messageStartTemplate, "message.usage.output_tokens", candidatesTokenCount.Int())
@@ -267,6 +267,23 @@ func ConvertAntigravityResponseToClaude(_ context.Context, _ string, originalReq
 				}
 				params.ResponseType = 3
 				params.HasContent = true
+			} else if block, ok := claudeImageBlock(partResult); ok {
+				// Image output (e.g. gemini-3-pro-image) is sent as a complete base64 image block
+				if params.ResponseType != 0 {
+					output = output + "event: content_block_stop\n"
+					output = output + fmt.Sprintf(`data: {"type":"content_block_stop","index":%d}`, params.ResponseIndex)
+					output = output + "\n\n\n"
+					params.ResponseIndex++
+				}
+				output = output + "event: content_block_start\n"
+				data, _ := sjson.SetRaw(fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":{}}`, params.ResponseIndex), "content_block", block)
+				output = output + fmt.Sprintf("data: %s\n\n\n", data)
+				output = output + "event: content_block_stop\n"
+				output = output + fmt.Sprintf(`data: {"type":"content_block_stop","index":%d}`, params.ResponseIndex)
+				output = output + "\n\n\n"
+				params.ResponseIndex++
+				params.ResponseType = 0
+				params.HasContent = true
 			}
 		}
 	}
@@ -330,15 +347,9 @@ func appendFinalEvents(params *Params, output *string, force bool) {
 
 	*output = *output + "event: message_delta\n"
 	*output = *output + "data: "
-	delta := fmt.Sprintf(`{"type":"message_delta","delta":{"stop_reason":"%s","stop_sequence":null},"usage":{"input_tokens":%d,"output_tokens":%d}}`, stopReason, params.PromptTokenCount, usageOutputTokens)
-	// Add cache_read_input_tokens if cached tokens are present (indicates prompt caching is working)
-	if params.CachedTokenCount > 0 {
-		var err error
-		delta, err = sjson.Set(delta, "usage.cache_read_input_tokens", params.CachedTokenCount)
-		if err != nil {
-			log.Warnf("antigravity claude response: failed to set cache_read_input_tokens: %v", err)
-		}
-	}
+	delta := fmt.Sprintf(`{"type":"message_delta","delta":{"stop_reason":"%s","stop_sequence":null},"usage":{"input_tokens":0,"output_tokens":%d}}`, stopReason, usageOutputTokens)
+	// Report cache reads so Claude clients see prompt caching at work.
+	delta = setClaudeUsageInput(delta, "usage", params.PromptTokenCount+params.CachedTokenCount, params.CachedTokenCount)
 	*output = *output + delta + "\n\n\n"
 
 	params.HasSentFinalEvents = true
@@ -390,16 +401,9 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 	responseJSON := `{"id":"","type":"message","role":"assistant","model":"","content":null,"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}`
 	responseJSON, _ = sjson.Set(responseJSON, "id", root.Get("response.responseId").String())
 	responseJSON, _ = sjson.Set(responseJSON, "model", root.Get("response.modelVersion").String())
-	responseJSON, _ = sjson.Set(responseJSON, "usage.input_tokens", promptTokens)
+	// Report cache reads so Claude clients see prompt caching at work.
+	responseJSON = setClaudeUsageInput(responseJSON, "usage", promptTokens, cachedTokens)
 	responseJSON, _ = sjson.Set(responseJSON, "usage.output_tokens", outputTokens)
-	// Add cache_read_input_tokens if cached tokens are present (indicates prompt caching is working)
-	if cachedTokens > 0 {
-		var err error
-		responseJSON, err = sjson.Set(responseJSON, "usage.cache_read_input_tokens", cachedTokens)
-		if err != nil {
-			log.Warnf("antigravity claude response: failed to set cache_read_input_tokens: %v", err)
-		}
-	}
 
 	contentArrayInitialized := false
 	ensureContentArray := func() {
@@ -486,6 +490,14 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 				responseJSON, _ = sjson.SetRaw(responseJSON, "content.-1", toolBlock)
 				continue
 			}
+
+			if block, ok := claudeImageBlock(part); ok {
+				flushThinking()
+				flushText()
+				ensureContentArray()
+				responseJSON, _ = sjson.SetRaw(responseJSON, "content.-1", block)
+				continue
+			}
 		}
 	}
 
@@ -518,6 +530,29 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 	return responseJSON
 }
 
+// claudeImageBlock converts a Gemini inlineData part into a Claude base64 image block.
+func claudeImageBlock(part gjson.Result) (string, bool) {
+	inlineData := part.Get("inlineData")
+	if !inlineData.Exists() {
+		inlineData = part.Get("inline_data")
+	}
+	data := inlineData.Get("data").String()
+	if data == "" {
+		return "", false
+	}
+	mimeType := inlineData.Get("mimeType").String()
+	if mimeType == "" {
+		mimeType = inlineData.Get("mime_type").String()
+	}
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	block := `{"type":"image","source":{"type":"base64","media_type":"","data":""}}`
+	block, _ = sjson.Set(block, "source.media_type", mimeType)
+	block, _ = sjson.Set(block, "source.data", data)
+	return block, true
+}
+
 func ClaudeTokenCount(ctx context.Context, count int64) string {
 	return fmt.Sprintf(`{"input_tokens":%d}`, count)
-}
\ No newline at end of file
+}
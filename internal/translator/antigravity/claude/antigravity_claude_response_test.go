@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/tidwall/gjson"
 )
 
 // ============================================================================
@@ -244,3 +245,84 @@ func TestConvertAntigravityResponseToClaude_MultipleThinkingBlocks(t *testing.T)
 		t.Error("Second thinking block signature should be cached")
 	}
 }
+
+// ============================================================================
+// Image Output Tests
+// ============================================================================
+
+func TestConvertAntigravityResponseToClaude_InlineImageBlock(t *testing.T) {
+	requestJSON := []byte(`{"messages":[{"role":"user","content":"draw a cat"}]}`)
+	chunk := []byte(`{"response":{"candidates":[{"content":{"parts":[{"text":"Here you go"},{"inlineData":{"mimeType":"image/jpeg","data":"aGVsbG8="}}]},"finishReason":"STOP"}]}}`)
+
+	var param any
+	out := strings.Join(ConvertAntigravityResponseToClaude(context.Background(), "gemini-3-pro-image", requestJSON, requestJSON, chunk, &param), "")
+
+	var image gjson.Result
+	for _, line := range strings.Split(out, "\n") {
+		data := strings.TrimPrefix(line, "data: ")
+		if data == line || !gjson.Valid(data) {
+			continue
+		}
+		if block := gjson.Get(data, "content_block"); gjson.Get(data, "type").String() == "content_block_start" && block.Get("type").String() == "image" {
+			image = gjson.Parse(data)
+		}
+	}
+	if !image.Exists() {
+		t.Fatalf("expected image content_block_start, got: %s", out)
+	}
+	if got := image.Get("index").Int(); got != 1 {
+		t.Errorf("image block index = %d, want 1", got)
+	}
+	if got := image.Get("content_block.source.media_type").String(); got != "image/jpeg" {
+		t.Errorf("media_type = %q, want image/jpeg", got)
+	}
+	if got := image.Get("content_block.source.data").String(); got != "aGVsbG8=" {
+		t.Errorf("data = %q, want aGVsbG8=", got)
+	}
+}
+
+func TestConvertAntigravityResponseToClaudeNonStream_InlineImageBlock(t *testing.T) {
+	requestJSON := []byte(`{"messages":[{"role":"user","content":"draw a cat"}]}`)
+	responseJSON := []byte(`{"response":{"candidates":[{"content":{"parts":[{"text":"Here you go"},{"inlineData":{"data":"aGVsbG8="}}]},"finishReason":"STOP"}]}}`)
+
+	out := ConvertAntigravityResponseToClaudeNonStream(context.Background(), "gemini-3-pro-image", requestJSON, requestJSON, responseJSON, nil)
+
+	content := gjson.Get(out, "content").Array()
+	if len(content) != 2 {
+		t.Fatalf("expected text and image blocks, got: %s", gjson.Get(out, "content").Raw)
+	}
+	if content[0].Get("type").String() != "text" {
+		t.Errorf("content[0].type = %q, want text", content[0].Get("type").String())
+	}
+	if content[1].Get("type").String() != "image" || content[1].Get("source.type").String() != "base64" {
+		t.Fatalf("unexpected image block: %s", content[1].Raw)
+	}
+	if got := content[1].Get("source.media_type").String(); got != "image/png" {
+		t.Errorf("media_type = %q, want image/png", got)
+	}
+}
+
+func TestConvertAntigravityResponseToClaude_CacheUsage(t *testing.T) {
+	requestJSON := []byte(`{"system":[{"type":"text","text":"You are a careful assistant with a long, stable prompt.","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+	responseJSON := []byte(`{"response":{"candidates":[{"content":{"parts":[{"text":"hello"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":1000,"cachedContentTokenCount":200,"candidatesTokenCount":5,"totalTokenCount":1005}}}`)
+
+	checkUsage := func(name string, usage gjson.Result) {
+		t.Helper()
+		if usage.Get("input_tokens").Int() != 800 || usage.Get("cache_read_input_tokens").Int() != 200 || usage.Get("cache_creation_input_tokens").Exists() {
+			t.Errorf("%s usage = %s, want 800 input and 200 read without cache writes", name, usage.Raw)
+		}
+	}
+
+	nonStream := ConvertAntigravityResponseToClaudeNonStream(context.Background(), "claude-sonnet-4-5", requestJSON, requestJSON, responseJSON, nil)
+	checkUsage("non-stream", gjson.Get(nonStream, "usage"))
+
+	var param any
+	out := strings.Join(ConvertAntigravityResponseToClaude(context.Background(), "claude-sonnet-4-5", requestJSON, requestJSON, responseJSON, &param), "")
+	var delta gjson.Result
+	for _, line := range strings.Split(out, "\n") {
+		if data := strings.TrimPrefix(line, "data: "); data != line && gjson.Get(data, "type").String() == "message_delta" {
+			delta = gjson.Parse(data)
+		}
+	}
+	checkUsage("stream", delta.Get("usage"))
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -98,10 +99,11 @@ func ConvertGeminiRequestToAntigravity(modelName string, inputRawJSON []byte, _
 	}
 
 	// Gemini-specific handling for non-Claude models:
-	// - Add skip_thought_signature_validator to functionCall parts so upstream can bypass signature validation.
+	// - Add skip_thought_signature_validator to functionCall parts lacking a valid signature (or as the
+	//   configured signature policy says) so upstream can bypass signature validation.
 	// - Also mark thinking parts with the same sentinel when present (we keep the parts; we only annotate them).
 	if !strings.Contains(modelName, "claude") {
-		const skipSentinel = "skip_thought_signature_validator"
+		const skipSentinel = cache.SkipThoughtSignatureValidator
 
 		gjson.GetBytes(rawJSON, "request.contents").ForEach(func(contentIdx, content gjson.Result) bool {
 			if content.Get("role").String() == "model" {
@@ -114,10 +116,8 @@ func ConvertGeminiRequestToAntigravity(modelName string, inputRawJSON []byte, _
 					}
 					// Add skip sentinel to functionCall parts
 					if part.Get("functionCall").Exists() {
-						existingSig := part.Get("thoughtSignature").String()
-						if existingSig == "" || len(existingSig) < 50 {
-							rawJSON, _ = sjson.SetBytes(rawJSON, fmt.Sprintf("request.contents.%d.parts.%d.thoughtSignature", contentIdx.Int(), partIdx.Int()), skipSentinel)
-						}
+						path := fmt.Sprintf("request.contents.%d.parts.%d.thoughtSignature", contentIdx.Int(), partIdx.Int())
+						rawJSON = common.SetFunctionCallSignature(rawJSON, path, modelName, part.Get("thoughtSignature").String(), cache.SignaturePolicyMissing)
 					}
 					return true
 				})
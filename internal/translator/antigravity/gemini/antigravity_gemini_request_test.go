@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/tidwall/gjson"
 )
 
@@ -93,3 +95,34 @@ func TestConvertGeminiRequestToAntigravity_ParallelFunctionCalls(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertGeminiRequestToAntigravity_SignaturePolicy(t *testing.T) {
+	validSignature := "abc123validSignature1234567890123456789012345678901234567890"
+	inputJSON := []byte(fmt.Sprintf(`{
+		"model": "gemini-3-pro-preview",
+		"contents": [
+			{
+				"role": "model",
+				"parts": [
+					{"functionCall": {"name": "signed", "args": {}}, "thoughtSignature": "%s"},
+					{"functionCall": {"name": "unsigned", "args": {}}}
+				]
+			}
+		]
+	}`, validSignature))
+
+	cache.SetSignaturePolicies([]config.ThoughtSignaturePolicy{{Model: "gemini-3-*", Policy: "never"}})
+	parts := gjson.GetBytes(ConvertGeminiRequestToAntigravity("gemini-3-pro-preview", inputJSON, false), "request.contents.0.parts").Array()
+	if parts[0].Get("thoughtSignature").String() != validSignature || parts[1].Get("thoughtSignature").Exists() {
+		t.Fatalf("never: parts = %v", parts)
+	}
+
+	cache.SetSignaturePolicies([]config.ThoughtSignaturePolicy{{Model: "gemini-3-*", Policy: "always"}})
+	defer cache.SetSignaturePolicies(nil)
+	parts = gjson.GetBytes(ConvertGeminiRequestToAntigravity("gemini-3-pro-preview", inputJSON, false), "request.contents.0.parts").Array()
+	for i, part := range parts {
+		if sig := part.Get("thoughtSignature").String(); sig != cache.SkipThoughtSignatureValidator {
+			t.Fatalf("always: part %d signature = %q", i, sig)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToAntigravity_SystemAndDeveloperOrdering(t *testing.T) {
+	input := []byte(`{
+		"model": "gemini-3-pro-preview",
+		"messages": [
+			{"role": "system", "content": "You are a helpful assistant."},
+			{"role": "developer", "content": [
+				{"type": "text", "text": "Answer in French."},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}},
+				{"type": "text", "text": ""}
+			]},
+			{"role": "user", "content": "Hello"},
+			{"role": "developer", "content": {"type": "text", "text": "Keep it short."}},
+			{"role": "user", "content": "How are you?"}
+		]
+	}`)
+
+	out := ConvertOpenAIRequestToAntigravity("gemini-3-pro-preview", input, false)
+
+	parts := gjson.GetBytes(out, "request.systemInstruction.parts").Array()
+	want := []string{"You are a helpful assistant.", "Answer in French.", "Keep it short."}
+	if len(parts) != len(want) {
+		t.Fatalf("systemInstruction parts = %s, want %d text parts", gjson.GetBytes(out, "request.systemInstruction.parts").Raw, len(want))
+	}
+	for i, text := range want {
+		if got := parts[i].Get("text").String(); got != text {
+			t.Errorf("part %d = %q, want %q", i, got, text)
+		}
+	}
+
+	contents := gjson.GetBytes(out, "request.contents").Array()
+	if len(contents) != 2 {
+		t.Fatalf("contents = %d, want only the two user turns", len(contents))
+	}
+	for i, content := range contents {
+		if role := content.Get("role").String(); role != "user" {
+			t.Errorf("content %d role = %q, want user", i, role)
+		}
+	}
+}
+
+func TestConvertOpenAIRequestToAntigravity_SingleDeveloperMessageBecomesUserTurn(t *testing.T) {
+	input := []byte(`{"messages":[{"role":"developer","content":"Say hi."}]}`)
+
+	out := ConvertOpenAIRequestToAntigravity("gemini-3-pro-preview", input, false)
+
+	if gjson.GetBytes(out, "request.systemInstruction").Exists() {
+		t.Fatalf("unexpected systemInstruction: %s", out)
+	}
+	if got := gjson.GetBytes(out, "request.contents.0.parts.0.text").String(); got != "Say hi." {
+		t.Fatalf("contents[0] text = %q, want %q", got, "Say hi.")
+	}
+}
@@ -0,0 +1,89 @@
+package responses
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIResponsesRequestToAntigravity_DeveloperMessages(t *testing.T) {
+	input := []byte(`{
+		"model": "gemini-3-pro-preview",
+		"instructions": "Base instructions.",
+		"input": [
+			{"role": "system", "content": "System note."},
+			{"role": "developer", "content": [{"type": "input_text", "text": "Developer note."}]},
+			{"role": "user", "content": [{"type": "input_text", "text": "Hi"}]},
+			{"type": "message", "role": "developer", "content": [{"type": "input_text", "text": "Late developer note."}]}
+		]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToAntigravity("gemini-3-pro-preview", input, false)
+
+	parts := gjson.GetBytes(out, "request.systemInstruction.parts").Array()
+	want := []string{"Base instructions.", "System note.", "Developer note.", "Late developer note."}
+	if len(parts) != len(want) {
+		t.Fatalf("systemInstruction parts = %s, want %d parts", gjson.GetBytes(out, "request.systemInstruction.parts").Raw, len(want))
+	}
+	for i, text := range want {
+		if got := parts[i].Get("text").String(); got != text {
+			t.Errorf("part %d = %q, want %q", i, got, text)
+		}
+	}
+	contents := gjson.GetBytes(out, "request.contents").Array()
+	if len(contents) != 1 || contents[0].Get("role").String() != "user" {
+		t.Fatalf("contents = %s, want a single user turn", gjson.GetBytes(out, "request.contents").Raw)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToAntigravity_ReasoningAndToolCalls(t *testing.T) {
+	input := []byte(`{
+		"model": "gemini-3-pro-preview",
+		"input": [
+			{"type": "message", "role": "user", "content": "What is the weather?"},
+			{"type": "reasoning", "summary": [{"type": "summary_text", "text": "Need the tool."}], "encrypted_content": "sig-1"},
+			{"type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"},
+			{"type": "function_call_output", "call_id": "call_1", "output": [{"type": "input_text", "text": "Sunny"}]}
+		]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToAntigravity("gemini-3-pro-preview", input, false)
+
+	contents := gjson.GetBytes(out, "request.contents").Array()
+	if len(contents) != 3 {
+		t.Fatalf("contents = %s, want user, model and function turns", gjson.GetBytes(out, "request.contents").Raw)
+	}
+	if got := contents[0].Get("parts.0.text").String(); got != "What is the weather?" {
+		t.Errorf("user text = %q", got)
+	}
+	model := contents[1]
+	if !model.Get("parts.0.thought").Bool() || model.Get("parts.0.text").String() != "Need the tool." {
+		t.Errorf("model turn should start with the reasoning thought, got %s", model.Raw)
+	}
+	if got := model.Get("parts.1.functionCall.name").String(); got != "get_weather" {
+		t.Errorf("functionCall name = %q, want get_weather in the same turn as the thought", got)
+	}
+	if got := contents[2].Get("parts.0.functionResponse.response.result").String(); got != "Sunny" {
+		t.Errorf("functionResponse result = %q, want Sunny", got)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToAntigravity_Compaction(t *testing.T) {
+	input := []byte(`{"model": "gemini-3-pro-preview", "input": [
+		{"type": "compaction", "encrypted_content": "` + util.EncodeCompactionSummary("User is fixing a bug in main.go.") + `"},
+		{"type": "compaction", "encrypted_content": "gAAAAopaque"},
+		{"type": "message", "role": "user", "content": [{"type": "input_text", "text": "Continue"}]}
+	]}`)
+
+	out := ConvertOpenAIResponsesRequestToAntigravity("gemini-3-pro-preview", input, false)
+
+	contents := gjson.GetBytes(out, "request.contents").Array()
+	if len(contents) != 2 {
+		t.Fatalf("contents = %s, want the summary and the user turn", gjson.GetBytes(out, "request.contents").Raw)
+	}
+	if got := contents[0].Get("parts.0.text").String(); !strings.Contains(got, "User is fixing a bug in main.go.") {
+		t.Errorf("summary turn = %q", got)
+	}
+}
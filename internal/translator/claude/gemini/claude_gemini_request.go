@@ -258,6 +258,41 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 						} else if response := fr.Get("response"); response.Exists() {
 							toolResult, _ = sjson.Set(toolResult, "content", response.Raw)
 						}
+
+						// Multimodal function responses carry images in parts; Claude takes them as
+						// image blocks inside the tool result.
+						if frParts := fr.Get("parts"); frParts.IsArray() {
+							var images []string
+							frParts.ForEach(func(_, frPart gjson.Result) bool {
+								inlineData := frPart.Get("inlineData")
+								if !inlineData.Exists() {
+									inlineData = frPart.Get("inline_data")
+								}
+								data := inlineData.Get("data").String()
+								if data == "" {
+									return true
+								}
+								mimeType := inlineData.Get("mimeType").String()
+								if mimeType == "" {
+									mimeType = inlineData.Get("mime_type").String()
+								}
+								imageContent := `{"type":"image","source":{"type":"base64","media_type":"","data":""}}`
+								imageContent, _ = sjson.Set(imageContent, "source.media_type", mimeType)
+								imageContent, _ = sjson.Set(imageContent, "source.data", data)
+								images = append(images, imageContent)
+								return true
+							})
+							if len(images) > 0 {
+								contentBlocks := `[]`
+								if text := gjson.Get(toolResult, "content").String(); text != "" {
+									contentBlocks, _ = sjson.Set(contentBlocks, "-1", map[string]string{"type": "text", "text": text})
+								}
+								for _, image := range images {
+									contentBlocks, _ = sjson.SetRaw(contentBlocks, "-1", image)
+								}
+								toolResult, _ = sjson.SetRaw(toolResult, "content", contentBlocks)
+							}
+						}
 						msg, _ = sjson.SetRaw(msg, "content.-1", toolResult)
 						return true
 					}
@@ -343,18 +378,9 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool config mapping from Gemini format to Claude Code format
-	if toolConfig := root.Get("tool_config"); toolConfig.Exists() {
-		if funcCalling := toolConfig.Get("function_calling_config"); funcCalling.Exists() {
-			if mode := funcCalling.Get("mode"); mode.Exists() {
-				switch mode.String() {
-				case "AUTO":
-					out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"auto"}`)
-				case "NONE":
-					out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"none"}`)
-				case "ANY":
-					out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"any"}`)
-				}
-			}
+	if gjson.Get(out, "tools").Exists() {
+		if choice, ok := util.ParseGeminiToolConfig(root); ok {
+			out, _ = sjson.SetRaw(out, "tool_choice", choice.Claude())
 		}
 	}
 
@@ -0,0 +1,37 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiRequestToClaude_FunctionResponseImages(t *testing.T) {
+	inputJSON := []byte(`{
+		"contents": [
+			{"role": "model", "parts": [{"functionCall": {"name": "screenshot", "args": {}}}]},
+			{"role": "user", "parts": [{"functionResponse": {
+				"name": "screenshot",
+				"response": {"result": "captured"},
+				"parts": [{"inlineData": {"mimeType": "image/png", "data": "iVBORw0KGgo="}}]
+			}}]}
+		]
+	}`)
+
+	output := ConvertGeminiRequestToClaude("claude-sonnet-4-5", inputJSON, false)
+	toolUseID := gjson.GetBytes(output, "messages.0.content.0.id").String()
+	toolResult := gjson.GetBytes(output, "messages.1.content.0")
+	if toolResult.Get("type").String() != "tool_result" || toolResult.Get("tool_use_id").String() != toolUseID {
+		t.Fatalf("Expected a tool_result paired with the call, got %s", output)
+	}
+	content := toolResult.Get("content").Array()
+	if len(content) != 2 {
+		t.Fatalf("Expected text and image blocks, got %s", toolResult.Get("content").Raw)
+	}
+	if content[0].Get("type").String() != "text" || content[0].Get("text").String() != "captured" {
+		t.Errorf("Expected the result text first, got %s", content[0].Raw)
+	}
+	if content[1].Get("source.media_type").String() != "image/png" || content[1].Get("source.data").String() != "iVBORw0KGgo=" {
+		t.Errorf("Expected the image block, got %s", content[1].Raw)
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -288,27 +289,9 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool choice mapping from OpenAI format to Claude Code format
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Type {
-		case gjson.String:
-			choice := toolChoice.String()
-			switch choice {
-			case "none":
-				// Don't set tool_choice, Claude Code will not use tools
-			case "auto":
-				out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"auto"}`)
-			case "required":
-				out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"any"}`)
-			}
-		case gjson.JSON:
-			// Specific tool choice mapping
-			if toolChoice.Get("type").String() == "function" {
-				functionName := toolChoice.Get("function.name").String()
-				toolChoiceJSON := `{"type":"tool","name":""}`
-				toolChoiceJSON, _ = sjson.Set(toolChoiceJSON, "name", functionName)
-				out, _ = sjson.SetRaw(out, "tool_choice", toolChoiceJSON)
-			}
-		default:
+	if gjson.Get(out, "tools").Exists() {
+		if choice, ok := util.ParseToolChoice(root.Get("tool_choice")); ok {
+			out, _ = sjson.SetRaw(out, "tool_choice", choice.Claude())
 		}
 	}
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -311,27 +312,10 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 		}
 	}
 
-	// Map tool_choice similar to Chat Completions translator (optional in docs, safe to handle)
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Type {
-		case gjson.String:
-			switch toolChoice.String() {
-			case "auto":
-				out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"auto"}`)
-			case "none":
-				// Leave unset; implies no tools
-			case "required":
-				out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"any"}`)
-			}
-		case gjson.JSON:
-			if toolChoice.Get("type").String() == "function" {
-				fn := toolChoice.Get("function.name").String()
-				toolChoiceJSON := `{"name":"","type":"tool"}`
-				toolChoiceJSON, _ = sjson.Set(toolChoiceJSON, "name", fn)
-				out, _ = sjson.SetRaw(out, "tool_choice", toolChoiceJSON)
-			}
-		default:
-
+	// Map tool_choice; Responses uses {"type":"function","name":...} for a forced tool.
+	if gjson.Get(out, "tools").Exists() {
+		if choice, ok := util.ParseToolChoice(root.Get("tool_choice")); ok {
+			out, _ = sjson.SetRaw(out, "tool_choice", choice.Claude())
 		}
 	}
 
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -206,6 +207,16 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 			tool, _ = sjson.Set(tool, "strict", false)
 			template, _ = sjson.SetRaw(template, "tools.-1", tool)
 		}
+		if choice, ok := util.ParseToolChoice(rootResult.Get("tool_choice")); ok {
+			for i, name := range choice.Names {
+				if short, okShort := shortMap[name]; okShort {
+					choice.Names[i] = short
+				} else {
+					choice.Names[i] = shortenNameIfNeeded(name)
+				}
+			}
+			template, _ = sjson.SetRaw(template, "tool_choice", choice.OpenAIResponses())
+		}
 	}
 
 	// Add additional configuration parameters for the Codex API.
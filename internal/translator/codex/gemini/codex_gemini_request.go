@@ -236,6 +236,16 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 				out, _ = sjson.SetRaw(out, "tools.-1", tool)
 			}
 		}
+		if choice, ok := util.ParseGeminiToolConfig(root); ok {
+			for i, name := range choice.Names {
+				if short, okShort := shortMap[name]; okShort {
+					choice.Names[i] = short
+				} else {
+					choice.Names[i] = shortenNameIfNeeded(name)
+				}
+			}
+			out, _ = sjson.SetRaw(out, "tool_choice", choice.OpenAIResponses())
+		}
 	}
 
 	// Fixed flags aligning with Codex expectations
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -308,33 +309,20 @@ func ConvertOpenAIRequestToCodex(modelName string, inputRawJSON []byte, stream b
 	}
 
 	// Map tool_choice when present.
-	// Chat Completions: "tool_choice" can be a string ("auto"/"none") or an object (e.g. {"type":"function","function":{"name":"..."}}).
-	// Responses API: keep built-in tool choices as-is; flatten function choice to {"type":"function","name":"..."}.
+	// Function choices are converted to the Responses shape ({"type":"function","name":"..."}) with
+	// shortened names; built-in tool choices (e.g. {"type":"web_search"}) are already Responses-compatible.
 	if tc := gjson.GetBytes(rawJSON, "tool_choice"); tc.Exists() {
-		switch {
-		case tc.Type == gjson.String:
-			out, _ = sjson.Set(out, "tool_choice", tc.String())
-		case tc.IsObject():
-			tcType := tc.Get("type").String()
-			if tcType == "function" {
-				name := tc.Get("function.name").String()
-				if name != "" {
-					if short, ok := originalToolNameMap[name]; ok {
-						name = short
-					} else {
-						name = shortenNameIfNeeded(name)
-					}
-				}
-				choice := `{}`
-				choice, _ = sjson.Set(choice, "type", "function")
-				if name != "" {
-					choice, _ = sjson.Set(choice, "name", name)
+		if choice, ok := util.ParseToolChoice(tc); ok {
+			for i, name := range choice.Names {
+				if short, okShort := originalToolNameMap[name]; okShort {
+					choice.Names[i] = short
+				} else {
+					choice.Names[i] = shortenNameIfNeeded(name)
 				}
-				out, _ = sjson.SetRaw(out, "tool_choice", choice)
-			} else if tcType != "" {
-				// Built-in tool choices (e.g. {"type":"web_search"}) are already Responses-compatible.
-				out, _ = sjson.SetRaw(out, "tool_choice", tc.Raw)
 			}
+			out, _ = sjson.SetRaw(out, "tool_choice", choice.OpenAIResponses())
+		} else if tc.IsObject() && tc.Get("type").String() != "" {
+			out, _ = sjson.SetRaw(out, "tool_choice", tc.Raw)
 		}
 	}
 
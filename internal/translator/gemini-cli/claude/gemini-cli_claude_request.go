@@ -9,13 +9,13 @@ import (
 	"bytes"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
-const geminiCLIClaudeThoughtSignature = "skip_thought_signature_validator"
-
 // ConvertClaudeRequestToCLI parses and transforms a Claude Code API request into Gemini CLI API format.
 // It extracts the model name, system instruction, message contents, and tool declarations
 // from the raw JSON request and returns them in the format expected by the Gemini CLI API.
@@ -95,7 +95,11 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 						argsResult := gjson.Parse(functionArgs)
 						if argsResult.IsObject() && gjson.Valid(functionArgs) {
 							part := `{"thoughtSignature":"","functionCall":{"name":"","args":{}}}`
-							part, _ = sjson.Set(part, "thoughtSignature", geminiCLIClaudeThoughtSignature)
+							if signature, ok := cache.FunctionCallSignature(modelName, "", cache.SignaturePolicyMissing); ok {
+								part, _ = sjson.Set(part, "thoughtSignature", signature)
+							} else {
+								part, _ = sjson.Delete(part, "thoughtSignature")
+							}
 							part, _ = sjson.Set(part, "functionCall.name", functionName)
 							part, _ = sjson.SetRaw(part, "functionCall.args", functionArgs)
 							contentJSON, _ = sjson.SetRaw(contentJSON, "parts.-1", part)
@@ -171,6 +175,13 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 		}
 	}
 
+	// tool_choice -> request.toolConfig.functionCallingConfig
+	if gjson.Get(out, "request.tools").Exists() {
+		if choice, ok := util.ParseToolChoice(gjson.GetBytes(rawJSON, "tool_choice")); ok {
+			out, _ = sjson.SetRaw(out, "request.toolConfig", choice.Gemini())
+		}
+	}
+
 	// Map Anthropic thinking -> Gemini thinkingBudget/include_thoughts when type==enabled
 	if t := gjson.GetBytes(rawJSON, "thinking"); t.Exists() && t.IsObject() {
 		if t.Get("type").String() == "enabled" {
@@ -8,6 +8,7 @@ package gemini
 import (
 	"fmt"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -31,7 +32,7 @@ import (
 //
 // Returns:
 //   - []byte: The transformed request data in Gemini API format
-func ConvertGeminiRequestToGeminiCLI(_ string, inputRawJSON []byte, _ bool) []byte {
+func ConvertGeminiRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bool) []byte {
 	rawJSON := inputRawJSON
 	template := ""
 	template = `{"project":"","request":{},"model":""}`
@@ -100,7 +101,8 @@ func ConvertGeminiRequestToGeminiCLI(_ string, inputRawJSON []byte, _ bool) []by
 		if content.Get("role").String() == "model" {
 			content.Get("parts").ForEach(func(partKey, part gjson.Result) bool {
 				if part.Get("functionCall").Exists() {
-					rawJSON, _ = sjson.SetBytes(rawJSON, fmt.Sprintf("request.contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int()), "skip_thought_signature_validator")
+					path := fmt.Sprintf("request.contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int())
+					rawJSON = common.SetFunctionCallSignature(rawJSON, path, modelName, part.Get("thoughtSignature").String(), cache.SignaturePolicyAlways)
 				} else if part.Get("thoughtSignature").Exists() {
 					rawJSON, _ = sjson.SetBytes(rawJSON, fmt.Sprintf("request.contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int()), "skip_thought_signature_validator")
 				}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -142,25 +143,28 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 			content := m.Get("content")
 
 			if (role == "system" || role == "developer") && len(arr) > 1 {
-				// system -> request.systemInstruction as a user message style
+				// system and developer messages -> request.systemInstruction parts, in message order.
+				// Only text content is carried over; empty and non-text items are skipped.
+				var texts []string
 				if content.Type == gjson.String {
-					out, _ = sjson.SetBytes(out, "request.systemInstruction.role", "user")
-					out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), content.String())
-					systemPartIndex++
+					texts = append(texts, content.String())
 				} else if content.IsObject() && content.Get("type").String() == "text" {
-					out, _ = sjson.SetBytes(out, "request.systemInstruction.role", "user")
-					out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), content.Get("text").String())
-					systemPartIndex++
+					texts = append(texts, content.Get("text").String())
 				} else if content.IsArray() {
-					contents := content.Array()
-					if len(contents) > 0 {
-						out, _ = sjson.SetBytes(out, "request.systemInstruction.role", "user")
-						for j := 0; j < len(contents); j++ {
-							out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), contents[j].Get("text").String())
-							systemPartIndex++
+					for _, item := range content.Array() {
+						if item.Get("type").String() == "text" {
+							texts = append(texts, item.Get("text").String())
 						}
 					}
 				}
+				for _, text := range texts {
+					if strings.TrimSpace(text) == "" {
+						continue
+					}
+					out, _ = sjson.SetBytes(out, "request.systemInstruction.role", "user")
+					out, _ = sjson.SetBytes(out, fmt.Sprintf("request.systemInstruction.parts.%d.text", systemPartIndex), text)
+					systemPartIndex++
+				}
 			} else if role == "user" || ((role == "system" || role == "developer") && len(arr) == 1) {
 				// Build single user content node to avoid splitting into multiple contents
 				node := []byte(`{"role":"user","parts":[]}`)
@@ -250,7 +254,7 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 						fargs := tc.Get("function.arguments").String()
 						node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".functionCall.name", fname)
 						node, _ = sjson.SetRawBytes(node, "parts."+itoa(p)+".functionCall.args", []byte(fargs))
-						node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".thoughtSignature", geminiCLIFunctionThoughtSignature)
+						node = common.SetFunctionCallSignature(node, "parts."+itoa(p)+".thoughtSignature", modelName, "", cache.SignaturePolicyMissing)
 						p++
 						if fid != "" {
 							fIDs = append(fIDs, fid)
@@ -388,6 +392,13 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 		}
 	}
 
+	// tool_choice -> request.toolConfig.functionCallingConfig
+	if gjson.GetBytes(out, "request.tools").Exists() {
+		if choice, ok := util.ParseToolChoice(gjson.GetBytes(rawJSON, "tool_choice")); ok {
+			out, _ = sjson.SetRawBytes(out, "request.toolConfig", []byte(choice.Gemini()))
+		}
+	}
+
 	return common.AttachDefaultSafetySettings(out, "request.safetySettings")
 }
 
@@ -0,0 +1,18 @@
+package common
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/tidwall/sjson"
+)
+
+// SetFunctionCallSignature sets or removes the thoughtSignature at path for a function call
+// of modelName that the client sent with existing, following the configured signature
+// policy or fallback when none matches the model.
+func SetFunctionCallSignature(rawJSON []byte, path, modelName, existing string, fallback cache.SignaturePolicy) []byte {
+	if signature, ok := cache.FunctionCallSignature(modelName, existing, fallback); ok {
+		out, _ := sjson.SetBytes(rawJSON, path, signature)
+		return out
+	}
+	out, _ := sjson.DeleteBytes(rawJSON, path)
+	return out
+}
@@ -8,6 +8,7 @@ package geminiCLI
 import (
 	"fmt"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
@@ -17,7 +18,7 @@ import (
 // PrepareClaudeRequest parses and transforms a Claude API request into internal client format.
 // It extracts the model name, system instruction, message contents, and tool declarations
 // from the raw JSON request and returns them in the format expected by the internal client.
-func ConvertGeminiCLIRequestToGemini(_ string, inputRawJSON []byte, _ bool) []byte {
+func ConvertGeminiCLIRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
 	rawJSON := inputRawJSON
 	modelResult := gjson.GetBytes(rawJSON, "model")
 	rawJSON = []byte(gjson.GetBytes(rawJSON, "request").Raw)
@@ -49,7 +50,8 @@ func ConvertGeminiCLIRequestToGemini(_ string, inputRawJSON []byte, _ bool) []by
 		if content.Get("role").String() == "model" {
 			content.Get("parts").ForEach(func(partKey, part gjson.Result) bool {
 				if part.Get("functionCall").Exists() {
-					rawJSON, _ = sjson.SetBytes(rawJSON, fmt.Sprintf("contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int()), "skip_thought_signature_validator")
+					path := fmt.Sprintf("contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int())
+					rawJSON = common.SetFunctionCallSignature(rawJSON, path, modelName, part.Get("thoughtSignature").String(), cache.SignaturePolicyAlways)
 				} else if part.Get("thoughtSignature").Exists() {
 					rawJSON, _ = sjson.SetBytes(rawJSON, fmt.Sprintf("contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int()), "skip_thought_signature_validator")
 				}
@@ -6,6 +6,7 @@ package gemini
 import (
 	"fmt"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
@@ -17,7 +18,7 @@ import (
 //     The first message defaults to "user", then alternates user/model when needed.
 //
 // It keeps the payload otherwise unchanged.
-func ConvertGeminiRequestToGemini(_ string, inputRawJSON []byte, _ bool) []byte {
+func ConvertGeminiRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
 	rawJSON := inputRawJSON
 	// Fast path: if no contents field, only attach safety settings
 	contents := gjson.GetBytes(rawJSON, "contents")
@@ -80,7 +81,8 @@ func ConvertGeminiRequestToGemini(_ string, inputRawJSON []byte, _ bool) []byte
 		if content.Get("role").String() == "model" {
 			content.Get("parts").ForEach(func(partKey, part gjson.Result) bool {
 				if part.Get("functionCall").Exists() {
-					out, _ = sjson.SetBytes(out, fmt.Sprintf("contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int()), "skip_thought_signature_validator")
+					path := fmt.Sprintf("contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int())
+					out = common.SetFunctionCallSignature(out, path, modelName, part.Get("thoughtSignature").String(), cache.SignaturePolicyAlways)
 				} else if part.Get("thoughtSignature").Exists() {
 					out, _ = sjson.SetBytes(out, fmt.Sprintf("contents.%d.parts.%d.thoughtSignature", key.Int(), partKey.Int()), "skip_thought_signature_validator")
 				}
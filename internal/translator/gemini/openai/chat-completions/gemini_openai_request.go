@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -142,25 +143,28 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 			content := m.Get("content")
 
 			if (role == "system" || role == "developer") && len(arr) > 1 {
-				// system -> system_instruction as a user message style
+				// system and developer messages -> system_instruction parts, in message order.
+				// Only text content is carried over; empty and non-text items are skipped.
+				var texts []string
 				if content.Type == gjson.String {
-					out, _ = sjson.SetBytes(out, "system_instruction.role", "user")
-					out, _ = sjson.SetBytes(out, fmt.Sprintf("system_instruction.parts.%d.text", systemPartIndex), content.String())
-					systemPartIndex++
+					texts = append(texts, content.String())
 				} else if content.IsObject() && content.Get("type").String() == "text" {
-					out, _ = sjson.SetBytes(out, "system_instruction.role", "user")
-					out, _ = sjson.SetBytes(out, fmt.Sprintf("system_instruction.parts.%d.text", systemPartIndex), content.Get("text").String())
-					systemPartIndex++
+					texts = append(texts, content.Get("text").String())
 				} else if content.IsArray() {
-					contents := content.Array()
-					if len(contents) > 0 {
-						out, _ = sjson.SetBytes(out, "system_instruction.role", "user")
-						for j := 0; j < len(contents); j++ {
-							out, _ = sjson.SetBytes(out, fmt.Sprintf("system_instruction.parts.%d.text", systemPartIndex), contents[j].Get("text").String())
-							systemPartIndex++
+					for _, item := range content.Array() {
+						if item.Get("type").String() == "text" {
+							texts = append(texts, item.Get("text").String())
 						}
 					}
 				}
+				for _, text := range texts {
+					if strings.TrimSpace(text) == "" {
+						continue
+					}
+					out, _ = sjson.SetBytes(out, "system_instruction.role", "user")
+					out, _ = sjson.SetBytes(out, fmt.Sprintf("system_instruction.parts.%d.text", systemPartIndex), text)
+					systemPartIndex++
+				}
 			} else if role == "user" || ((role == "system" || role == "developer") && len(arr) == 1) {
 				// Build single user content node to avoid splitting into multiple contents
 				node := []byte(`{"role":"user","parts":[]}`)
@@ -256,7 +260,7 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						fargs := tc.Get("function.arguments").String()
 						node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".functionCall.name", fname)
 						node, _ = sjson.SetRawBytes(node, "parts."+itoa(p)+".functionCall.args", []byte(fargs))
-						node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".thoughtSignature", geminiFunctionThoughtSignature)
+						node = common.SetFunctionCallSignature(node, "parts."+itoa(p)+".thoughtSignature", modelName, "", cache.SignaturePolicyMissing)
 						p++
 						if fid != "" {
 							fIDs = append(fIDs, fid)
@@ -394,6 +398,13 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// tool_choice -> toolConfig.functionCallingConfig
+	if gjson.GetBytes(out, "tools").Exists() {
+		if choice, ok := util.ParseToolChoice(gjson.GetBytes(rawJSON, "tool_choice")); ok {
+			out, _ = sjson.SetRawBytes(out, "toolConfig", []byte(choice.Gemini()))
+		}
+	}
+
 	out = common.AttachDefaultSafetySettings(out, "safetySettings")
 
 	return out
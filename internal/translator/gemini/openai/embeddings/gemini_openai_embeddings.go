@@ -0,0 +1,91 @@
+// Package embeddings translates OpenAI embeddings requests into Gemini batchEmbedContents
+// requests and converts the returned vectors back into an OpenAI embeddings list.
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingRequestToGemini builds a batchEmbedContents request with one entry per
+// input. "input" may be a string or an array of strings; token arrays are not supported by
+// Gemini and are sent as their JSON text. "dimensions" maps to outputDimensionality.
+func ConvertOpenAIEmbeddingRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
+	model := "models/" + strings.TrimPrefix(modelName, "models/")
+	var inputs []string
+	input := gjson.GetBytes(inputRawJSON, "input")
+	if input.IsArray() {
+		for _, item := range input.Array() {
+			if item.Type == gjson.String {
+				inputs = append(inputs, item.String())
+			} else {
+				inputs = append(inputs, item.Raw)
+			}
+		}
+	} else if input.Exists() {
+		inputs = append(inputs, input.String())
+	}
+	dimensions := gjson.GetBytes(inputRawJSON, "dimensions").Int()
+
+	out := []byte(`{"requests":[]}`)
+	for _, text := range inputs {
+		entry := []byte(`{"model":"","content":{"parts":[{"text":""}]}}`)
+		entry, _ = sjson.SetBytes(entry, "model", model)
+		entry, _ = sjson.SetBytes(entry, "content.parts.0.text", text)
+		if dimensions > 0 {
+			entry, _ = sjson.SetBytes(entry, "outputDimensionality", dimensions)
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", entry)
+	}
+	return out
+}
+
+// ConvertGeminiEmbeddingResponseToOpenAI converts a batchEmbedContents response into an OpenAI
+// embeddings list. Vectors are base64 encoded little-endian float32 values when the original
+// request asked for encoding_format "base64". Gemini does not report token usage.
+func ConvertGeminiEmbeddingResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, _ []byte, rawJSON []byte, _ *any) string {
+	base64Encoded := gjson.GetBytes(originalRequestRawJSON, "encoding_format").String() == "base64"
+	var data bytes.Buffer
+	data.WriteByte('[')
+	for i, embedding := range gjson.GetBytes(rawJSON, "embeddings").Array() {
+		if i > 0 {
+			data.WriteByte(',')
+		}
+		item := []byte(`{"object":"embedding","index":0,"embedding":[]}`)
+		item, _ = sjson.SetBytes(item, "index", i)
+		values := embedding.Get("values").Array()
+		if base64Encoded {
+			buf := make([]byte, 4*len(values))
+			for j, v := range values {
+				binary.LittleEndian.PutUint32(buf[4*j:], math.Float32bits(float32(v.Float())))
+			}
+			item, _ = sjson.SetBytes(item, "embedding", base64.StdEncoding.EncodeToString(buf))
+		} else {
+			raw := make([]byte, 0, 12*len(values)+2)
+			raw = append(raw, '[')
+			for j, v := range values {
+				if j > 0 {
+					raw = append(raw, ',')
+				}
+				raw = strconv.AppendFloat(raw, v.Float(), 'g', -1, 32)
+			}
+			raw = append(raw, ']')
+			item, _ = sjson.SetRawBytes(item, "embedding", raw)
+		}
+		data.Write(item)
+	}
+	data.WriteByte(']')
+
+	out := []byte(`{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetRawBytes(out, "data", data.Bytes())
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	return string(out)
+}
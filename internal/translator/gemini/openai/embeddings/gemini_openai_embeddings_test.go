@@ -0,0 +1,53 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIEmbeddingRequestToGemini(t *testing.T) {
+	out := ConvertOpenAIEmbeddingRequestToGemini("gemini-embedding-001", []byte(`{"model":"gemini-embedding-001","input":["hello","world"],"dimensions":256}`), false)
+	requests := gjson.GetBytes(out, "requests").Array()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %s", out)
+	}
+	if requests[0].Get("model").String() != "models/gemini-embedding-001" || requests[1].Get("content.parts.0.text").String() != "world" {
+		t.Fatalf("unexpected request %s", out)
+	}
+	if requests[0].Get("outputDimensionality").Int() != 256 {
+		t.Fatalf("dimensions not mapped: %s", out)
+	}
+
+	out = ConvertOpenAIEmbeddingRequestToGemini("models/gemini-embedding-001", []byte(`{"input":"single"}`), false)
+	if got := gjson.GetBytes(out, "requests.#").Int(); got != 1 {
+		t.Fatalf("expected 1 request, got %d", got)
+	}
+	if gjson.GetBytes(out, "requests.0.model").String() != "models/gemini-embedding-001" || gjson.GetBytes(out, "requests.0.outputDimensionality").Exists() {
+		t.Fatalf("unexpected request %s", out)
+	}
+}
+
+func TestConvertGeminiEmbeddingResponseToOpenAI(t *testing.T) {
+	upstream := []byte(`{"embeddings":[{"values":[0.5,-1.25]},{"values":[2]}]}`)
+	out := ConvertGeminiEmbeddingResponseToOpenAI(context.Background(), "gemini-embedding-001", []byte(`{"input":["a","b"]}`), nil, upstream, nil)
+	if gjson.Get(out, "object").String() != "list" || gjson.Get(out, "model").String() != "gemini-embedding-001" {
+		t.Fatalf("unexpected envelope %s", out)
+	}
+	if gjson.Get(out, "data.1.index").Int() != 1 || gjson.Get(out, "data.0.embedding.1").Float() != -1.25 {
+		t.Fatalf("unexpected data %s", out)
+	}
+
+	out = ConvertGeminiEmbeddingResponseToOpenAI(context.Background(), "gemini-embedding-001", []byte(`{"input":"a","encoding_format":"base64"}`), nil, upstream, nil)
+	raw, err := base64.StdEncoding.DecodeString(gjson.Get(out, "data.0.embedding").String())
+	if err != nil || len(raw) != 8 {
+		t.Fatalf("invalid base64 embedding %s: %v", out, err)
+	}
+	if v := math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])); v != -1.25 {
+		t.Fatalf("expected -1.25, got %v", v)
+	}
+}
@@ -0,0 +1,18 @@
+package embeddings
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		OpenAIEmbedding,
+		Gemini,
+		ConvertOpenAIEmbeddingRequestToGemini,
+		interfaces.TranslateResponse{
+			NonStream: ConvertGeminiEmbeddingResponseToOpenAI,
+		},
+	)
+}
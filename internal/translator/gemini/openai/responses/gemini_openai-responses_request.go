@@ -1,9 +1,12 @@
 package responses
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -23,10 +26,8 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 	root := gjson.ParseBytes(rawJSON)
 
 	// Extract system instruction from OpenAI "instructions" field
-	if instructions := root.Get("instructions"); instructions.Exists() {
-		systemInstr := `{"parts":[{"text":""}]}`
-		systemInstr, _ = sjson.Set(systemInstr, "parts.0.text", instructions.String())
-		out, _ = sjson.SetRaw(out, "system_instruction", systemInstr)
+	if instructions := root.Get("instructions"); instructions.Exists() && instructions.String() != "" {
+		out = appendSystemInstructionText(out, instructions.String())
 	}
 
 	// Convert input messages to Gemini contents format
@@ -116,22 +117,19 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 
 			switch itemType {
 			case "message":
-				if strings.EqualFold(itemRole, "system") {
-					if contentArray := item.Get("content"); contentArray.Exists() && contentArray.IsArray() {
-						var builder strings.Builder
-						contentArray.ForEach(func(_, contentItem gjson.Result) bool {
-							text := contentItem.Get("text").String()
-							if builder.Len() > 0 && text != "" {
-								builder.WriteByte('\n')
+				// system and developer messages -> system_instruction parts, in input order.
+				if strings.EqualFold(itemRole, "system") || strings.EqualFold(itemRole, "developer") {
+					content := item.Get("content")
+					if content.Type == gjson.String {
+						out = appendSystemInstructionText(out, content.String())
+					} else if content.IsArray() {
+						content.ForEach(func(_, contentItem gjson.Result) bool {
+							switch contentItem.Get("type").String() {
+							case "", "input_text", "text":
+								out = appendSystemInstructionText(out, contentItem.Get("text").String())
 							}
-							builder.WriteString(text)
 							return true
 						})
-						if !gjson.Get(out, "system_instruction").Exists() {
-							systemInstr := `{"parts":[{"text":""}]}`
-							systemInstr, _ = sjson.Set(systemInstr, "parts.0.text", builder.String())
-							out, _ = sjson.SetRaw(out, "system_instruction", systemInstr)
-						}
 					}
 					continue
 				}
@@ -154,7 +152,7 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 						for _, part := range currentParts {
 							one, _ = sjson.SetRaw(one, "parts.-1", part)
 						}
-						out, _ = sjson.SetRaw(out, "contents.-1", one)
+						out = appendGeminiContent(out, one)
 						currentParts = nil
 					}
 
@@ -236,6 +234,16 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 					})
 
 					flush()
+				} else if content := item.Get("content"); content.Type == gjson.String && content.String() != "" {
+					role := "user"
+					switch strings.ToLower(itemRole) {
+					case "assistant", "model":
+						role = "model"
+					}
+					one := `{"role":"","parts":[{"text":""}]}`
+					one, _ = sjson.Set(one, "role", role)
+					one, _ = sjson.Set(one, "parts.0.text", content.String())
+					out = appendGeminiContent(out, one)
 				}
 
 			case "function_call":
@@ -246,7 +254,9 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 				modelContent := `{"role":"model","parts":[]}`
 				functionCall := `{"functionCall":{"name":"","args":{}}}`
 				functionCall, _ = sjson.Set(functionCall, "functionCall.name", name)
-				functionCall, _ = sjson.Set(functionCall, "thoughtSignature", geminiResponsesThoughtSignature)
+				if signature, ok := cache.FunctionCallSignature(modelName, "", cache.SignaturePolicyMissing); ok {
+					functionCall, _ = sjson.Set(functionCall, "thoughtSignature", signature)
+				}
 				functionCall, _ = sjson.Set(functionCall, "functionCall.id", item.Get("call_id").String())
 
 				// Parse arguments JSON string and set as args object
@@ -256,13 +266,23 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 				}
 
 				modelContent, _ = sjson.SetRaw(modelContent, "parts.-1", functionCall)
-				out, _ = sjson.SetRaw(out, "contents.-1", modelContent)
+				out = appendGeminiContent(out, modelContent)
 
 			case "function_call_output":
 				// Handle function call outputs - convert to function message with functionResponse
 				callID := item.Get("call_id").String()
 				// Use .Raw to preserve the JSON encoding (includes quotes for strings)
 				outputRaw := item.Get("output").Str
+				if output := item.Get("output"); output.IsArray() {
+					// Tool outputs may also be a list of content items; keep their text.
+					var texts []string
+					for _, part := range output.Array() {
+						if text := part.Get("text").String(); text != "" {
+							texts = append(texts, text)
+						}
+					}
+					outputRaw = strings.Join(texts, "\n")
+				}
 
 				functionContent := `{"role":"function","parts":[]}`
 				functionResponse := `{"functionResponse":{"name":"","response":{}}}`
@@ -306,6 +326,15 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 
 				thoughtContent, _ = sjson.SetRaw(thoughtContent, "parts.-1", thought)
 				out, _ = sjson.SetRaw(out, "contents.-1", thoughtContent)
+
+			case "compaction":
+				// Summaries produced by the proxy's /responses/compact replace the turns they cover.
+				// Opaque compaction items from other providers cannot be read and are dropped.
+				if summary, ok := util.DecodeCompactionSummary(item.Get("encrypted_content").String()); ok {
+					summaryContent := `{"role":"user","parts":[{"text":""}]}`
+					summaryContent, _ = sjson.Set(summaryContent, "parts.0.text", "Summary of the conversation so far:\n\n"+summary)
+					out, _ = sjson.SetRaw(out, "contents.-1", summaryContent)
+				}
 			}
 		}
 	} else if input.Exists() && input.Type == gjson.String {
@@ -356,6 +385,9 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 		// Only add tools if there are function declarations
 		if funcDecls := gjson.Get(geminiTools, "0.functionDeclarations"); funcDecls.Exists() && len(funcDecls.Array()) > 0 {
 			out, _ = sjson.SetRaw(out, "tools", geminiTools)
+			if choice, ok := util.ParseToolChoice(root.Get("tool_choice")); ok {
+				out, _ = sjson.SetRaw(out, "toolConfig", choice.Gemini())
+			}
 		}
 	}
 
@@ -416,3 +448,46 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 	result = common.AttachDefaultSafetySettings(result, "safetySettings")
 	return result
 }
+
+// appendGeminiContent adds a content to the request. A model turn that follows a turn holding
+// only thoughts is merged into it, so reasoning items stay attached to the output they led to.
+func appendGeminiContent(out, content string) string {
+	if gjson.Get(content, "role").String() == "model" {
+		last := gjson.Get(out, "contents.@reverse.0")
+		if last.Get("role").String() == "model" && onlyThoughtParts(last.Get("parts")) {
+			idx := gjson.Get(out, "contents.#").Int() - 1
+			for _, part := range gjson.Get(content, "parts").Array() {
+				out, _ = sjson.SetRaw(out, fmt.Sprintf("contents.%d.parts.-1", idx), part.Raw)
+			}
+			return out
+		}
+	}
+	out, _ = sjson.SetRaw(out, "contents.-1", content)
+	return out
+}
+
+func onlyThoughtParts(parts gjson.Result) bool {
+	items := parts.Array()
+	if len(items) == 0 {
+		return false
+	}
+	for _, part := range items {
+		if !part.Get("thought").Bool() {
+			return false
+		}
+	}
+	return true
+}
+
+// appendSystemInstructionText adds a non-empty text part to system_instruction.
+func appendSystemInstructionText(out, text string) string {
+	if strings.TrimSpace(text) == "" {
+		return out
+	}
+	if !gjson.Get(out, "system_instruction").Exists() {
+		out, _ = sjson.SetRaw(out, "system_instruction", `{"parts":[]}`)
+	}
+	part, _ := sjson.Set(`{"text":""}`, "text", text)
+	out, _ = sjson.SetRaw(out, "system_instruction.parts.-1", part)
+	return out
+}
@@ -40,6 +40,15 @@ type geminiToResponsesState struct {
 	FuncNames   map[int]string
 	FuncCallIDs map[int]string
 	FuncDone    map[int]bool
+
+	// image output aggregation (keyed by output_index)
+	Images map[int]geminiImageOutput
+}
+
+// geminiImageOutput is an inline image part rendered as an image_generation_call output item.
+type geminiImageOutput struct {
+	ID     string
+	Result string
 }
 
 // responseIDCounter provides a process-wide unique counter for synthesized response identifiers.
@@ -48,6 +57,40 @@ var responseIDCounter uint64
 // funcCallIDCounter provides a process-wide unique counter for function call identifiers.
 var funcCallIDCounter uint64
 
+// imageCallIDCounter provides a process-wide unique counter for image output identifiers.
+var imageCallIDCounter uint64
+
+// inlineImageData returns the base64 payload of a Gemini inlineData image part.
+func inlineImageData(part gjson.Result) (string, bool) {
+	inline := part.Get("inlineData")
+	if !inline.Exists() {
+		inline = part.Get("inline_data")
+	}
+	data := inline.Get("data").String()
+	if data == "" {
+		return "", false
+	}
+	mimeType := inline.Get("mimeType").String()
+	if mimeType == "" {
+		mimeType = inline.Get("mime_type").String()
+	}
+	if mimeType != "" && !strings.HasPrefix(mimeType, "image/") {
+		return "", false
+	}
+	return data, true
+}
+
+func newImageCallID() string {
+	return fmt.Sprintf("ig_%x_%d", time.Now().UnixNano(), atomic.AddUint64(&imageCallIDCounter, 1))
+}
+
+func imageGenerationItem(id, result string) string {
+	item := `{"id":"","type":"image_generation_call","status":"completed","result":""}`
+	item, _ = sjson.Set(item, "id", id)
+	item, _ = sjson.Set(item, "result", result)
+	return item
+}
+
 func pickRequestJSON(originalRequestRawJSON, requestRawJSON []byte) []byte {
 	if len(originalRequestRawJSON) > 0 && gjson.ValidBytes(originalRequestRawJSON) {
 		return originalRequestRawJSON
@@ -93,6 +136,7 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 			FuncNames:   make(map[int]string),
 			FuncCallIDs: make(map[int]string),
 			FuncDone:    make(map[int]bool),
+			Images:      make(map[int]geminiImageOutput),
 		}
 	}
 	st := (*param).(*geminiToResponsesState)
@@ -108,6 +152,9 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 	if st.FuncDone == nil {
 		st.FuncDone = make(map[int]bool)
 	}
+	if st.Images == nil {
+		st.Images = make(map[int]geminiImageOutput)
+	}
 
 	if bytes.HasPrefix(rawJSON, []byte("data:")) {
 		rawJSON = bytes.TrimSpace(rawJSON[5:])
@@ -224,7 +271,7 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 		st.NextIndex = 0
 	}
 
-	// Handle parts (text/thought/functionCall)
+	// Handle parts (text/thought/inlineData/functionCall)
 	if parts := root.Get("candidates.0.content.parts"); parts.Exists() && parts.IsArray() {
 		parts.ForEach(func(_, part gjson.Result) bool {
 			// Reasoning text
@@ -299,6 +346,29 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				return true
 			}
 
+			// Inline image output
+			if data, ok := inlineImageData(part); ok {
+				finalizeReasoning()
+				finalizeMessage()
+				idx := st.NextIndex
+				st.NextIndex++
+				img := geminiImageOutput{ID: newImageCallID(), Result: data}
+				st.Images[idx] = img
+
+				added := `{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{"id":"","type":"image_generation_call","status":"in_progress"}}`
+				added, _ = sjson.Set(added, "sequence_number", nextSeq())
+				added, _ = sjson.Set(added, "output_index", idx)
+				added, _ = sjson.Set(added, "item.id", img.ID)
+				out = append(out, emitEvent("response.output_item.added", added))
+
+				done := `{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{}}`
+				done, _ = sjson.Set(done, "sequence_number", nextSeq())
+				done, _ = sjson.Set(done, "output_index", idx)
+				done, _ = sjson.SetRaw(done, "item", imageGenerationItem(img.ID, img.Result))
+				out = append(out, emitEvent("response.output_item.done", done))
+				return true
+			}
+
 			// Function call
 			if fc := part.Get("functionCall"); fc.Exists() {
 				// Before emitting function-call outputs, finalize reasoning and the message (if open).
@@ -511,6 +581,10 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				outputsWrapper, _ = sjson.SetRaw(outputsWrapper, "arr.-1", item)
 				continue
 			}
+			if img, ok := st.Images[idx]; ok {
+				outputsWrapper, _ = sjson.SetRaw(outputsWrapper, "arr.-1", imageGenerationItem(img.ID, img.Result))
+				continue
+			}
 
 			if callID, ok := st.FuncCallIDs[idx]; ok && callID != "" {
 				args := "{}"
@@ -708,6 +782,10 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 				appendOutput(itemJSON)
 				return true
 			}
+			if data, ok := inlineImageData(p); ok {
+				appendOutput(imageGenerationItem(newImageCallID(), data))
+				return true
+			}
 			return true
 		})
 	}
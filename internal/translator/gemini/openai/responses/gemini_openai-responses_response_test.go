@@ -351,3 +351,73 @@ func TestConvertGeminiResponseToOpenAIResponses_ResponseOutputOrdering(t *testin
 		t.Fatalf("expected response.completed after message added: msgAdded=%d completed=%d", posMsgAdded, posCompleted)
 	}
 }
+
+func TestConvertGeminiResponseToOpenAIResponses_InlineImageOutput(t *testing.T) {
+	in := []string{
+		`data: {"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"Here you go"}]}}],"modelVersion":"gemini-3-pro-image","responseId":"req_img_1"},"traceId":"t1"}`,
+		`data: {"response":{"candidates":[{"content":{"role":"model","parts":[{"inlineData":{"mimeType":"image/png","data":"aGVsbG8="}}]},"finishReason":"STOP"}],"modelVersion":"gemini-3-pro-image","responseId":"req_img_1"},"traceId":"t1"}`,
+	}
+
+	var param any
+	var out []string
+	for _, line := range in {
+		out = append(out, ConvertGeminiResponseToOpenAIResponses(context.Background(), "gemini-3-pro-image", nil, nil, []byte(line), &param)...)
+	}
+
+	posMsgDone, posImageAdded, posImageDone := -1, -1, -1
+	var completed gjson.Result
+	for i, chunk := range out {
+		ev, data := parseSSEEvent(t, chunk)
+		switch ev {
+		case "response.output_item.added":
+			if data.Get("item.type").String() == "image_generation_call" {
+				posImageAdded = i
+			}
+		case "response.output_item.done":
+			switch data.Get("item.type").String() {
+			case "message":
+				posMsgDone = i
+			case "image_generation_call":
+				posImageDone = i
+				if got := data.Get("item.result").String(); got != "aGVsbG8=" {
+					t.Fatalf("unexpected image result: %q", got)
+				}
+				if got := data.Get("output_index").Int(); got != 1 {
+					t.Fatalf("unexpected image output_index: %d", got)
+				}
+			}
+		case "response.completed":
+			completed = data
+		}
+	}
+
+	if posMsgDone == -1 || posImageAdded == -1 || posImageDone == -1 {
+		t.Fatalf("missing events: msgDone=%d imageAdded=%d imageDone=%d", posMsgDone, posImageAdded, posImageDone)
+	}
+	if !(posMsgDone < posImageAdded && posImageAdded < posImageDone) {
+		t.Fatalf("unexpected ordering: msgDone=%d imageAdded=%d imageDone=%d", posMsgDone, posImageAdded, posImageDone)
+	}
+	if !completed.Exists() {
+		t.Fatalf("missing response.completed event")
+	}
+	if got := completed.Get("response.output.1.type").String(); got != "image_generation_call" {
+		t.Fatalf("unexpected output[1] type: %q", got)
+	}
+	if got := completed.Get("response.output.1.result").String(); got != "aGVsbG8=" {
+		t.Fatalf("unexpected output[1] result: %q", got)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAIResponsesNonStream_InlineImageOutput(t *testing.T) {
+	raw := []byte(`{"candidates":[{"content":{"role":"model","parts":[{"inlineData":{"mimeType":"image/png","data":"aGVsbG8="}}]},"finishReason":"STOP"}],"modelVersion":"gemini-3-pro-image","responseId":"req_img_2"}`)
+
+	out := ConvertGeminiResponseToOpenAIResponsesNonStream(context.Background(), "gemini-3-pro-image", nil, nil, raw, nil)
+
+	item := gjson.Get(out, "output.0")
+	if item.Get("type").String() != "image_generation_call" || item.Get("status").String() != "completed" {
+		t.Fatalf("unexpected output item: %s", item.Raw)
+	}
+	if got := item.Get("result").String(); got != "aGVsbG8=" {
+		t.Fatalf("unexpected image result: %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+// Package transcriptions translates OpenAI audio transcription requests into Gemini
+// generateContent requests carrying the audio inline, and converts the returned text into a
+// Whisper-style transcription.
+package transcriptions
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const transcribeInstruction = "Transcribe the speech in this audio verbatim. Reply with the transcript only, without timestamps, labels or commentary."
+
+// ConvertOpenAITranscriptionRequestToGemini builds a generateContent request from the JSON form
+// of a transcription upload: "file" holds the base64 audio and its mime_type, "language" and
+// "prompt" are passed to the model as hints and "temperature" maps to generationConfig.
+func ConvertOpenAITranscriptionRequestToGemini(_ string, inputRawJSON []byte, _ bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+	instruction := transcribeInstruction
+	if language := strings.TrimSpace(root.Get("language").String()); language != "" {
+		instruction += " The spoken language is " + language + "."
+	}
+	if prompt := strings.TrimSpace(root.Get("prompt").String()); prompt != "" {
+		instruction += " Context and spelling hints: " + prompt
+	}
+
+	out := []byte(`{"contents":[{"role":"user","parts":[{"inlineData":{"mimeType":"","data":""}},{"text":""}]}]}`)
+	out, _ = sjson.SetBytes(out, "contents.0.parts.0.inlineData.mimeType", root.Get("file.mime_type").String())
+	out, _ = sjson.SetBytes(out, "contents.0.parts.0.inlineData.data", root.Get("file.data").String())
+	out, _ = sjson.SetBytes(out, "contents.0.parts.1.text", instruction)
+	if temperature := root.Get("temperature"); temperature.Exists() {
+		out, _ = sjson.SetBytes(out, "generationConfig.temperature", temperature.Float())
+	}
+	return out
+}
+
+// ConvertGeminiTranscriptionResponseToOpenAI joins the answer text of a generateContent
+// response into {"text": ...} with token usage, like OpenAI's gpt-4o-transcribe models.
+func ConvertGeminiTranscriptionResponseToOpenAI(_ context.Context, _ string, _, _ []byte, rawJSON []byte, _ *any) string {
+	var text strings.Builder
+	gjson.GetBytes(rawJSON, "candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+		if !part.Get("thought").Bool() {
+			text.WriteString(part.Get("text").String())
+		}
+		return true
+	})
+	usage := gjson.GetBytes(rawJSON, "usageMetadata")
+	input := usage.Get("promptTokenCount").Int()
+	output := usage.Get("candidatesTokenCount").Int()
+
+	out := []byte(`{"text":"","usage":{"type":"tokens","input_tokens":0,"output_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "text", strings.TrimSpace(text.String()))
+	out, _ = sjson.SetBytes(out, "usage.input_tokens", input)
+	out, _ = sjson.SetBytes(out, "usage.output_tokens", output)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", input+output)
+	return string(out)
+}
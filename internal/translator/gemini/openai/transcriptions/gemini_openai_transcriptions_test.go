@@ -0,0 +1,32 @@
+package transcriptions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAITranscriptionRequestToGemini(t *testing.T) {
+	in := []byte(`{"model":"gemini-2.5-flash","file":{"name":"a.mp3","mime_type":"audio/mpeg","data":"SUQz"},"language":"de","prompt":"CLIProxyAPI","temperature":0.2}`)
+	out := ConvertOpenAITranscriptionRequestToGemini("gemini-2.5-flash", in, false)
+	if gjson.GetBytes(out, "contents.0.parts.0.inlineData.mimeType").String() != "audio/mpeg" || gjson.GetBytes(out, "contents.0.parts.0.inlineData.data").String() != "SUQz" {
+		t.Fatalf("audio not inlined: %s", out)
+	}
+	instruction := gjson.GetBytes(out, "contents.0.parts.1.text").String()
+	if !strings.Contains(instruction, "language is de") || !strings.Contains(instruction, "CLIProxyAPI") {
+		t.Fatalf("hints missing from instruction %q", instruction)
+	}
+	if gjson.GetBytes(out, "generationConfig.temperature").Float() != 0.2 {
+		t.Fatalf("temperature not mapped: %s", out)
+	}
+}
+
+func TestConvertGeminiTranscriptionResponseToOpenAI(t *testing.T) {
+	upstream := []byte(`{"candidates":[{"content":{"parts":[{"text":"listening","thought":true},{"text":"Hello there.\n"}]}}],"usageMetadata":{"promptTokenCount":40,"candidatesTokenCount":3}}`)
+	out := ConvertGeminiTranscriptionResponseToOpenAI(context.Background(), "gemini-2.5-flash", nil, nil, upstream, nil)
+	if gjson.Get(out, "text").String() != "Hello there." || gjson.Get(out, "usage.total_tokens").Int() != 43 {
+		t.Fatalf("unexpected transcription %s", out)
+	}
+}
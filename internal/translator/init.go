@@ -21,7 +21,9 @@ import (
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/gemini"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/gemini-cli"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/chat-completions"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/embeddings"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/responses"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/transcriptions"
 
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/claude"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/gemini"
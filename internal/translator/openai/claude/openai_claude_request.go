@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -285,21 +286,9 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool choice mapping - convert Anthropic tool_choice to OpenAI format
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Get("type").String() {
-		case "auto":
-			out, _ = sjson.Set(out, "tool_choice", "auto")
-		case "any":
-			out, _ = sjson.Set(out, "tool_choice", "required")
-		case "tool":
-			// Specific tool choice
-			toolName := toolChoice.Get("name").String()
-			toolChoiceJSON := `{"type":"function","function":{"name":""}}`
-			toolChoiceJSON, _ = sjson.Set(toolChoiceJSON, "function.name", toolName)
-			out, _ = sjson.SetRaw(out, "tool_choice", toolChoiceJSON)
-		default:
-			// Default to auto if not specified
-			out, _ = sjson.Set(out, "tool_choice", "auto")
+	if gjson.Get(out, "tools").Exists() {
+		if choice, ok := util.ParseToolChoice(root.Get("tool_choice")); ok {
+			out, _ = sjson.SetRaw(out, "tool_choice", choice.OpenAI())
 		}
 	}
 
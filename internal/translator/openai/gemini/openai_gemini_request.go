@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -302,18 +303,10 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 		})
 	}
 
-	// Tool choice mapping (Gemini doesn't have direct equivalent, but we can handle it)
-	if toolConfig := root.Get("toolConfig"); toolConfig.Exists() {
-		if functionCallingConfig := toolConfig.Get("functionCallingConfig"); functionCallingConfig.Exists() {
-			mode := functionCallingConfig.Get("mode").String()
-			switch mode {
-			case "NONE":
-				out, _ = sjson.Set(out, "tool_choice", "none")
-			case "AUTO":
-				out, _ = sjson.Set(out, "tool_choice", "auto")
-			case "ANY":
-				out, _ = sjson.Set(out, "tool_choice", "required")
-			}
+	// Tool choice mapping from Gemini functionCallingConfig
+	if gjson.Get(out, "tools").Exists() {
+		if choice, ok := util.ParseGeminiToolConfig(root); ok {
+			out, _ = sjson.SetRaw(out, "tool_choice", choice.OpenAI())
 		}
 	}
 
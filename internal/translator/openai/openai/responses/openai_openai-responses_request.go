@@ -3,6 +3,7 @@ package responses
 import (
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -209,9 +210,9 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 		}
 	}
 
-	// Convert tool_choice if present
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		out, _ = sjson.Set(out, "tool_choice", toolChoice.String())
+	// Convert tool_choice if present; built-in tool choices have no Chat Completions equivalent.
+	if choice, ok := util.ParseToolChoice(root.Get("tool_choice")); ok {
+		out, _ = sjson.SetRaw(out, "tool_choice", choice.OpenAI())
 	}
 
 	return []byte(out)
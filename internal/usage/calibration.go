@@ -0,0 +1,177 @@
+package usage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// calibrationBounds split samples by estimated prompt size, mirroring the size tiers the
+// local token estimator applies its correction factors to.
+var calibrationBounds = []int64{100, 200, 300, 800, 2000, 8000, 32000}
+
+// TokenCalibration compares local prompt token estimates with upstream-reported usage.
+type TokenCalibration struct {
+	mu     sync.Mutex
+	models map[string]*modelCalibration
+}
+
+type modelCalibration struct {
+	provider string
+	total    calibrationStats
+	buckets  []calibrationStats
+}
+
+type calibrationStats struct {
+	samples     int64
+	estimated   int64
+	reported    int64
+	absErrorSum float64
+}
+
+func (s *calibrationStats) add(estimated, reported int64) {
+	s.samples++
+	s.estimated += estimated
+	s.reported += reported
+	s.absErrorSum += math.Abs(float64(estimated-reported)) / float64(reported)
+}
+
+// CalibrationSummary describes estimator accuracy over a set of samples.
+type CalibrationSummary struct {
+	Samples         int64   `json:"samples"`
+	EstimatedTokens int64   `json:"estimated_tokens"`
+	ReportedTokens  int64   `json:"reported_tokens"`
+	MeanAbsErrorPct float64 `json:"mean_abs_error_pct"`
+	// BiasPct is positive when the estimator over-counts in aggregate.
+	BiasPct float64 `json:"bias_pct"`
+	// SuggestedFactor is the multiplier that would have made the aggregate estimate exact.
+	SuggestedFactor float64 `json:"suggested_factor"`
+}
+
+// CalibrationBucket is the summary for estimates within a size range.
+type CalibrationBucket struct {
+	Range string `json:"range"`
+	CalibrationSummary
+}
+
+// ModelCalibration is the calibration report for a single model.
+type ModelCalibration struct {
+	Provider string `json:"provider"`
+	CalibrationSummary
+	Buckets []CalibrationBucket `json:"buckets"`
+}
+
+// CalibrationReport is the calibration report across all models.
+type CalibrationReport struct {
+	Models map[string]ModelCalibration `json:"models"`
+}
+
+var defaultTokenCalibration = NewTokenCalibration()
+
+// GetTokenCalibration returns the shared calibration store.
+func GetTokenCalibration() *TokenCalibration { return defaultTokenCalibration }
+
+// NewTokenCalibration constructs an empty calibration store.
+func NewTokenCalibration() *TokenCalibration {
+	return &TokenCalibration{models: make(map[string]*modelCalibration)}
+}
+
+// Record adds a sample when the record carries both a local estimate and reported input usage.
+func (c *TokenCalibration) Record(record coreusage.Record) {
+	if c == nil || record.Failed || record.EstimatedInputTokens <= 0 {
+		return
+	}
+	reported := reportedPromptTokens(record)
+	if reported <= 0 {
+		return
+	}
+	model := strings.TrimSpace(record.Model)
+	if model == "" {
+		model = "unknown"
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.models[model]
+	if stats == nil {
+		stats = &modelCalibration{buckets: make([]calibrationStats, len(calibrationBounds)+1)}
+		c.models[model] = stats
+	}
+	stats.provider = record.Provider
+	stats.total.add(record.EstimatedInputTokens, reported)
+	stats.buckets[calibrationBucket(record.EstimatedInputTokens)].add(record.EstimatedInputTokens, reported)
+}
+
+// Reset discards all samples.
+func (c *TokenCalibration) Reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.models = make(map[string]*modelCalibration)
+	c.mu.Unlock()
+}
+
+// Report summarises the samples per model and size bucket.
+func (c *TokenCalibration) Report() CalibrationReport {
+	report := CalibrationReport{Models: make(map[string]ModelCalibration)}
+	if c == nil {
+		return report
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for model, stats := range c.models {
+		entry := ModelCalibration{Provider: stats.provider, CalibrationSummary: stats.total.summary()}
+		for i, bucket := range stats.buckets {
+			if bucket.samples == 0 {
+				continue
+			}
+			entry.Buckets = append(entry.Buckets, CalibrationBucket{Range: calibrationBucketLabel(i), CalibrationSummary: bucket.summary()})
+		}
+		report.Models[model] = entry
+	}
+	return report
+}
+
+func (s calibrationStats) summary() CalibrationSummary {
+	summary := CalibrationSummary{Samples: s.samples, EstimatedTokens: s.estimated, ReportedTokens: s.reported}
+	if s.samples == 0 || s.estimated == 0 || s.reported == 0 {
+		return summary
+	}
+	summary.MeanAbsErrorPct = roundCalibration(100 * s.absErrorSum / float64(s.samples))
+	summary.BiasPct = roundCalibration(100 * (float64(s.estimated)/float64(s.reported) - 1))
+	summary.SuggestedFactor = roundCalibration(float64(s.reported) / float64(s.estimated))
+	return summary
+}
+
+func calibrationBucket(estimated int64) int {
+	return sort.Search(len(calibrationBounds), func(i int) bool { return estimated < calibrationBounds[i] })
+}
+
+func calibrationBucketLabel(i int) string {
+	switch {
+	case i == 0:
+		return fmt.Sprintf("<%d", calibrationBounds[0])
+	case i >= len(calibrationBounds):
+		return fmt.Sprintf(">=%d", calibrationBounds[len(calibrationBounds)-1])
+	default:
+		return fmt.Sprintf("%d-%d", calibrationBounds[i-1], calibrationBounds[i]-1)
+	}
+}
+
+// reportedPromptTokens returns the full prompt size reported upstream. Anthropic reports
+// cache reads and writes separately from input_tokens; other providers include them.
+func reportedPromptTokens(record coreusage.Record) int64 {
+	reported := record.Detail.InputTokens
+	if strings.EqualFold(record.Provider, "claude") {
+		reported += record.Detail.CachedTokens
+	}
+	return reported
+}
+
+func roundCalibration(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
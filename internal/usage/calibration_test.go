@@ -0,0 +1,41 @@
+package usage
+
+import (
+	"testing"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestTokenCalibrationReport(t *testing.T) {
+	c := NewTokenCalibration()
+	c.Record(coreusage.Record{Provider: "antigravity", Model: "m", EstimatedInputTokens: 120, Detail: coreusage.Detail{InputTokens: 100}})
+	c.Record(coreusage.Record{Provider: "antigravity", Model: "m", EstimatedInputTokens: 900, Detail: coreusage.Detail{InputTokens: 1000}})
+	c.Record(coreusage.Record{Provider: "claude", Model: "c", EstimatedInputTokens: 50, Detail: coreusage.Detail{InputTokens: 10, CachedTokens: 40}})
+	// Ignored: failed, missing estimate, missing reported usage.
+	c.Record(coreusage.Record{Model: "m", Failed: true, EstimatedInputTokens: 10, Detail: coreusage.Detail{InputTokens: 10}})
+	c.Record(coreusage.Record{Model: "m", Detail: coreusage.Detail{InputTokens: 10}})
+	c.Record(coreusage.Record{Model: "m", EstimatedInputTokens: 10})
+
+	report := c.Report()
+	m := report.Models["m"]
+	if m.Samples != 2 || m.EstimatedTokens != 1020 || m.ReportedTokens != 1100 {
+		t.Fatalf("summary = %+v", m.CalibrationSummary)
+	}
+	if m.MeanAbsErrorPct != 15 {
+		t.Fatalf("mean abs error = %v, want 15", m.MeanAbsErrorPct)
+	}
+	if m.SuggestedFactor != 1.078 {
+		t.Fatalf("suggested factor = %v, want 1.078", m.SuggestedFactor)
+	}
+	if len(m.Buckets) != 2 || m.Buckets[0].Range != "100-199" || m.Buckets[1].Range != "800-1999" {
+		t.Fatalf("buckets = %+v", m.Buckets)
+	}
+	if claude := report.Models["c"]; claude.ReportedTokens != 50 || claude.BiasPct != 0 {
+		t.Fatalf("claude summary = %+v, want cached tokens counted", claude.CalibrationSummary)
+	}
+
+	c.Reset()
+	if len(c.Report().Models) != 0 {
+		t.Fatalf("expected empty report after reset")
+	}
+}
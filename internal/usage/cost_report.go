@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// KeyUsageReport is the usage of one inbound API key.
+type KeyUsageReport struct {
+	APIKey         string `json:"api_key"`
+	TotalRequests  int64  `json:"total_requests"`
+	FailedRequests int64  `json:"failed_requests"`
+	TokenStats
+	// EstimatedCost is the USD cost of the models with a configured price.
+	EstimatedCost float64                     `json:"estimated_cost"`
+	Models        map[string]ModelUsageReport `json:"models"`
+}
+
+// ModelUsageReport is the usage of one model by an API key.
+type ModelUsageReport struct {
+	TotalRequests  int64 `json:"total_requests"`
+	FailedRequests int64 `json:"failed_requests"`
+	TokenStats
+	EstimatedCost float64 `json:"estimated_cost"`
+	// Priced is false when no model price matches, leaving EstimatedCost at 0.
+	Priced bool `json:"priced"`
+}
+
+// KeyReport aggregates the requests made between from and to (either may be zero) per API
+// key and model, restricted to apiKey when it is not empty. Keys are sorted by estimated
+// cost, highest first.
+func (s *RequestStatistics) KeyReport(apiKey string, from, to time.Time) []KeyUsageReport {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := make([]KeyUsageReport, 0, len(s.apis))
+	for key, stats := range s.apis {
+		if apiKey != "" && key != apiKey {
+			continue
+		}
+		report := KeyUsageReport{APIKey: key, Models: make(map[string]ModelUsageReport)}
+		for modelName, modelStatsValue := range stats.Models {
+			var model ModelUsageReport
+			for _, detail := range modelStatsValue.Details {
+				if (!from.IsZero() && detail.Timestamp.Before(from)) || (!to.IsZero() && !detail.Timestamp.Before(to)) {
+					continue
+				}
+				model.TotalRequests++
+				if detail.Failed {
+					model.FailedRequests++
+				}
+				model.TokenStats = addTokenStats(model.TokenStats, detail.Tokens)
+			}
+			if model.TotalRequests == 0 {
+				continue
+			}
+			model.EstimatedCost, model.Priced = pricing.Estimate(modelName, coreusage.Detail(model.TokenStats))
+			report.TotalRequests += model.TotalRequests
+			report.FailedRequests += model.FailedRequests
+			report.TokenStats = addTokenStats(report.TokenStats, model.TokenStats)
+			report.EstimatedCost += model.EstimatedCost
+			report.Models[modelName] = model
+		}
+		if report.TotalRequests == 0 {
+			continue
+		}
+		report.EstimatedCost = pricing.Round(report.EstimatedCost)
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].EstimatedCost != reports[j].EstimatedCost {
+			return reports[i].EstimatedCost > reports[j].EstimatedCost
+		}
+		return reports[i].APIKey < reports[j].APIKey
+	})
+	return reports
+}
+
+func addTokenStats(a, b TokenStats) TokenStats {
+	return TokenStats{
+		InputTokens:     a.InputTokens + b.InputTokens,
+		OutputTokens:    a.OutputTokens + b.OutputTokens,
+		ReasoningTokens: a.ReasoningTokens + b.ReasoningTokens,
+		CachedTokens:    a.CachedTokens + b.CachedTokens,
+		TotalTokens:     a.TotalTokens + b.TotalTokens,
+	}
+}
@@ -0,0 +1,60 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestKeyReport(t *testing.T) {
+	pricing.Set([]config.ModelPrice{
+		{Model: "claude-*", Input: 3, Output: 15, CachedInput: 0.3},
+		{Model: "gpt-5", Input: 1, Output: 10},
+	})
+	defer pricing.Set(nil)
+	prevEnabled := StatisticsEnabled()
+	SetStatisticsEnabled(true)
+	defer SetStatisticsEnabled(prevEnabled)
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	ctx := context.Background()
+	stats.Record(ctx, coreusage.Record{APIKey: "key-a", Model: "claude-sonnet-4-5", RequestedAt: day,
+		Detail: coreusage.Detail{InputTokens: 1_000_000, CachedTokens: 500_000, OutputTokens: 100_000}})
+	// Reasoning is part of OpenAI completion tokens, so the total bounds the billed output.
+	stats.Record(ctx, coreusage.Record{APIKey: "key-a", Model: "gpt-5", RequestedAt: day, Failed: true,
+		Detail: coreusage.Detail{InputTokens: 1_000_000, OutputTokens: 200_000, ReasoningTokens: 150_000, TotalTokens: 1_200_000}})
+	stats.Record(ctx, coreusage.Record{APIKey: "key-a", Model: "local-model", RequestedAt: day,
+		Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 10}})
+	stats.Record(ctx, coreusage.Record{APIKey: "key-b", Model: "gpt-5", RequestedAt: day.Add(48 * time.Hour),
+		Detail: coreusage.Detail{InputTokens: 1_000_000}})
+
+	reports := stats.KeyReport("", time.Time{}, time.Time{})
+	if len(reports) != 2 || reports[0].APIKey != "key-a" || reports[1].APIKey != "key-b" {
+		t.Fatalf("reports = %+v", reports)
+	}
+	a := reports[0]
+	// claude: 0.5M * 3 + 0.5M * 0.3 + 0.1M * 15 = 3.15; gpt-5: 1M * 1 + 0.2M * 10 = 3.
+	if a.TotalRequests != 3 || a.FailedRequests != 1 || a.EstimatedCost != 6.15 {
+		t.Fatalf("key-a = %+v", a)
+	}
+	if m := a.Models["claude-sonnet-4-5"]; !m.Priced || m.EstimatedCost != 3.15 {
+		t.Fatalf("claude = %+v", m)
+	}
+	if m := a.Models["local-model"]; m.Priced || m.EstimatedCost != 0 || m.TotalTokens != 20 {
+		t.Fatalf("local-model = %+v", m)
+	}
+
+	reports = stats.KeyReport("key-b", time.Time{}, day.Add(24*time.Hour))
+	if len(reports) != 0 {
+		t.Fatalf("filtered reports = %+v, want none", reports)
+	}
+	reports = stats.KeyReport("key-b", day.Add(24*time.Hour), time.Time{})
+	if len(reports) != 1 || reports[0].EstimatedCost != 1 {
+		t.Fatalf("key-b reports = %+v", reports)
+	}
+}
@@ -0,0 +1,36 @@
+package usagedb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// Plugin forwards usage records to the bound store.
+type Plugin struct {
+	store atomic.Pointer[Store]
+}
+
+var (
+	sharedPlugin       = &Plugin{}
+	registerPluginOnce sync.Once
+)
+
+// Bind writes usage records to store through a single plugin registered with the default
+// usage manager. Binding nil stops writing; binding again replaces the previous store.
+func Bind(store *Store) {
+	sharedPlugin.store.Store(store)
+	registerPluginOnce.Do(func() {
+		coreusage.RegisterPlugin(sharedPlugin)
+	})
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p *Plugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	if p == nil {
+		return
+	}
+	p.store.Load().Enqueue(record)
+}
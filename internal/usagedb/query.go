@@ -0,0 +1,225 @@
+package usagedb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// Query filters the records returned by Records and aggregated by Summary. Zero fields do
+// not filter.
+type Query struct {
+	From     time.Time
+	To       time.Time
+	Provider string
+	Model    string
+	APIKey   string
+	AuthID   string
+	// FailedOnly keeps only failed requests.
+	FailedOnly bool
+	// Limit caps the records returned; 0 means 100 and values above 1000 are clamped.
+	Limit  int
+	Offset int
+}
+
+// Record is one stored usage record.
+type Record struct {
+	ID              int64     `json:"id"`
+	RequestedAt     time.Time `json:"requested_at"`
+	Provider        string    `json:"provider"`
+	Model           string    `json:"model"`
+	APIKey          string    `json:"api_key"`
+	AuthID          string    `json:"auth_id"`
+	AuthIndex       string    `json:"auth_index"`
+	Source          string    `json:"source"`
+	Failed          bool      `json:"failed"`
+	StatusCode      int       `json:"status_code"`
+	LatencyMs       int64     `json:"latency_ms"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	CachedTokens    int64     `json:"cached_tokens"`
+	TotalTokens     int64     `json:"total_tokens"`
+	// EstimatedCost is the USD cost from the model price table; omitted when unpriced.
+	EstimatedCost float64 `json:"estimated_cost,omitempty"`
+}
+
+func (r Record) detail() coreusage.Detail {
+	return coreusage.Detail{
+		InputTokens:     r.InputTokens,
+		OutputTokens:    r.OutputTokens,
+		ReasoningTokens: r.ReasoningTokens,
+		CachedTokens:    r.CachedTokens,
+		TotalTokens:     r.TotalTokens,
+	}
+}
+
+// SummaryRow aggregates the records sharing one group key.
+type SummaryRow struct {
+	Key             string  `json:"key"`
+	Requests        int64   `json:"requests"`
+	Failed          int64   `json:"failed"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	ReasoningTokens int64   `json:"reasoning_tokens"`
+	CachedTokens    int64   `json:"cached_tokens"`
+	TotalTokens     int64   `json:"total_tokens"`
+	AvgLatencyMs    float64 `json:"avg_latency_ms"`
+	// EstimatedCost is the USD cost of the group's tokens, reported when grouping by model.
+	EstimatedCost float64 `json:"estimated_cost,omitempty"`
+}
+
+// groupColumns maps the accepted Summary group names to their SQL expressions. Days are
+// computed from the millisecond timestamp so the expression works on every dialect.
+var groupColumns = map[string]string{
+	"provider": "provider",
+	"model":    "model",
+	"api_key":  "api_key",
+	"auth_id":  "auth_id",
+	"source":   "source",
+	"day":      "requested_at / 86400000",
+}
+
+// ValidGroup reports whether groupBy is accepted by Summary.
+func ValidGroup(groupBy string) bool {
+	_, ok := groupColumns[groupBy]
+	return ok
+}
+
+// where builds the WHERE clause for q and its arguments.
+func (s *Store) where(q Query) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, s.dialect.placeholder(len(args))))
+	}
+	if !q.From.IsZero() {
+		add("requested_at >= %s", q.From.UnixMilli())
+	}
+	if !q.To.IsZero() {
+		add("requested_at < %s", q.To.UnixMilli())
+	}
+	if q.Provider != "" {
+		add("provider = %s", q.Provider)
+	}
+	if q.Model != "" {
+		add("model = %s", q.Model)
+	}
+	if q.APIKey != "" {
+		add("api_key = %s", q.APIKey)
+	}
+	if q.AuthID != "" {
+		add("auth_id = %s", q.AuthID)
+	}
+	if q.FailedOnly {
+		conds = append(conds, "failed = 1")
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Records returns the records matching q, newest first.
+func (s *Store) Records(ctx context.Context, q Query) ([]Record, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	limit = min(limit, maxQueryLimit)
+	offset := max(q.Offset, 0)
+	where, args := s.where(q)
+	query := fmt.Sprintf(`SELECT id, requested_at, provider, model, api_key, auth_id, auth_index, source, failed, status_code, latency_ms, input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens FROM %s%s ORDER BY requested_at DESC, id DESC LIMIT %d OFFSET %d`, s.table, where, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	out := make([]Record, 0)
+	for rows.Next() {
+		var r Record
+		var requestedAt int64
+		var failed int
+		if err = rows.Scan(&r.ID, &requestedAt, &r.Provider, &r.Model, &r.APIKey, &r.AuthID, &r.AuthIndex, &r.Source, &failed, &r.StatusCode, &r.LatencyMs, &r.InputTokens, &r.OutputTokens, &r.ReasoningTokens, &r.CachedTokens, &r.TotalTokens); err != nil {
+			return nil, err
+		}
+		r.RequestedAt = time.UnixMilli(requestedAt).UTC()
+		r.Failed = failed != 0
+		r.EstimatedCost, _ = pricing.Estimate(r.Model, r.detail())
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Summary aggregates the records matching q by groupBy, one of provider, model, api_key,
+// auth_id, source or day. Rows are ordered by total tokens, largest first.
+func (s *Store) Summary(ctx context.Context, q Query, groupBy string) ([]SummaryRow, error) {
+	column, ok := groupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("usage database: unsupported group %q", groupBy)
+	}
+	where, args := s.where(q)
+	query := fmt.Sprintf(`SELECT %[1]s, COUNT(*), COALESCE(SUM(failed), 0), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0), COALESCE(SUM(reasoning_tokens), 0), COALESCE(SUM(cached_tokens), 0), COALESCE(SUM(total_tokens), 0), COALESCE(SUM(latency_ms), 0) FROM %[2]s%[3]s GROUP BY %[1]s ORDER BY 8 DESC`, column, s.table, where)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	out := make([]SummaryRow, 0)
+	for rows.Next() {
+		var r SummaryRow
+		var key any
+		var latency int64
+		if err = rows.Scan(&key, &r.Requests, &r.Failed, &r.InputTokens, &r.OutputTokens, &r.ReasoningTokens, &r.CachedTokens, &r.TotalTokens, &latency); err != nil {
+			return nil, err
+		}
+		r.Key = groupKey(groupBy, key)
+		if r.Requests > 0 {
+			r.AvgLatencyMs = float64(latency) / float64(r.Requests)
+		}
+		if groupBy == "model" {
+			r.EstimatedCost, _ = pricing.Estimate(r.Key, coreusage.Detail{
+				InputTokens:     r.InputTokens,
+				OutputTokens:    r.OutputTokens,
+				ReasoningTokens: r.ReasoningTokens,
+				CachedTokens:    r.CachedTokens,
+				TotalTokens:     r.TotalTokens,
+			})
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// groupKey renders a scanned group value; days are reported as YYYY-MM-DD in UTC.
+func groupKey(groupBy string, value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		value = string(v)
+	}
+	if groupBy != "day" {
+		return fmt.Sprint(value)
+	}
+	var day int64
+	switch v := value.(type) {
+	case int64:
+		day = v
+	case float64:
+		day = int64(v)
+	default:
+		_, _ = fmt.Sscan(fmt.Sprint(v), &day)
+	}
+	return time.UnixMilli(day * 86400000).UTC().Format("2006-01-02")
+}
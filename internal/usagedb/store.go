@@ -0,0 +1,325 @@
+// Package usagedb persists usage records to Postgres and answers queries over them,
+// so usage history survives restarts unlike the in-memory statistics.
+package usagedb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTable = "usage_records"
+	// queueSize bounds records waiting to be written; newer records are dropped when the
+	// database falls this far behind.
+	queueSize     = 4096
+	batchSize     = 256
+	flushInterval = time.Second
+	purgeInterval = time.Hour
+	writeTimeout  = 30 * time.Second
+)
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// dialect holds the SQL differences between the supported databases.
+type dialect struct {
+	driver     string
+	idColumn   string
+	positional bool
+}
+
+var dialects = map[string]dialect{
+	"postgres": {driver: "pgx", idColumn: "BIGSERIAL PRIMARY KEY", positional: true},
+}
+
+// placeholder returns the bind parameter for the n-th argument, counting from 1.
+func (d dialect) placeholder(n int) string {
+	if d.positional {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Store writes usage records to a database in the background and queries them.
+type Store struct {
+	db            *sql.DB
+	dialect       dialect
+	table         string
+	retentionDays int
+
+	queue chan coreusage.Record
+	flush chan chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+
+	mu      sync.Mutex
+	dropped int
+	closed  bool
+}
+
+// Open connects to the database named by cfg, creates the usage table when missing and
+// starts the background writer. Postgres is reached through pgx.
+func Open(ctx context.Context, cfg config.UsageDatabaseConfig) (*Store, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	if name == "postgresql" || name == "pgx" {
+		name = "postgres"
+	}
+	d, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("usage database: unsupported driver %q, want postgres", cfg.Driver)
+	}
+	if !slices.Contains(sql.Drivers(), d.driver) {
+		return nil, fmt.Errorf("usage database: no %s driver is linked into this build", name)
+	}
+	dsn := strings.TrimSpace(cfg.DSN)
+	if dsn == "" {
+		return nil, fmt.Errorf("usage database: dsn is required")
+	}
+	table := strings.TrimSpace(cfg.Table)
+	if table == "" {
+		table = defaultTable
+	}
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("usage database: invalid table name %q", table)
+	}
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("usage database: open: %w", err)
+	}
+	s := &Store{
+		db:            db,
+		dialect:       d,
+		table:         table,
+		retentionDays: cfg.RetentionDays,
+		queue:         make(chan coreusage.Record, queueSize),
+		flush:         make(chan chan struct{}),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if err = s.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *Store) ensureSchema(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id %s,
+	requested_at BIGINT NOT NULL,
+	provider TEXT NOT NULL,
+	model TEXT NOT NULL,
+	api_key TEXT NOT NULL,
+	auth_id TEXT NOT NULL,
+	auth_index TEXT NOT NULL,
+	source TEXT NOT NULL,
+	failed INTEGER NOT NULL,
+	status_code INTEGER NOT NULL,
+	latency_ms BIGINT NOT NULL,
+	input_tokens BIGINT NOT NULL,
+	output_tokens BIGINT NOT NULL,
+	reasoning_tokens BIGINT NOT NULL,
+	cached_tokens BIGINT NOT NULL,
+	total_tokens BIGINT NOT NULL
+)`, s.table, s.dialect.idColumn),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_requested_at_idx ON %s (requested_at)`, indexPrefix(s.table), s.table),
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("usage database: create schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// indexPrefix derives an index name from a possibly schema-qualified table name.
+func indexPrefix(table string) string {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		return table[i+1:]
+	}
+	return table
+}
+
+// Enqueue schedules a record for writing without blocking the caller.
+func (s *Store) Enqueue(record coreusage.Record) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- record:
+	default:
+		s.dropped++
+	}
+}
+
+// Flush waits until the records enqueued so far are written.
+func (s *Store) Flush(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case s.flush <- ack:
+	case <-s.done:
+		return
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-ack:
+	case <-ctx.Done():
+	}
+}
+
+// Close writes the pending records and closes the database.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.stop)
+	<-s.done
+	return s.db.Close()
+}
+
+func (s *Store) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+	s.purgeExpired(time.Now())
+
+	batch := make([]coreusage.Record, 0, batchSize)
+	write := func() {
+		if len(batch) > 0 {
+			if err := s.insert(batch); err != nil {
+				log.Warnf("usage database: failed to write %d records: %v", len(batch), err)
+			}
+			batch = batch[:0]
+		}
+		s.mu.Lock()
+		dropped := s.dropped
+		s.dropped = 0
+		s.mu.Unlock()
+		if dropped > 0 {
+			log.Warnf("usage database: dropped %d records while the write queue was full", dropped)
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case record := <-s.queue:
+				batch = append(batch, record)
+				if len(batch) >= batchSize {
+					write()
+				}
+			default:
+				write()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case record := <-s.queue:
+			batch = append(batch, record)
+			if len(batch) >= batchSize {
+				write()
+			}
+		case <-ticker.C:
+			write()
+		case ack := <-s.flush:
+			drain()
+			close(ack)
+		case now := <-purge.C:
+			s.purgeExpired(now)
+		case <-s.stop:
+			drain()
+			return
+		}
+	}
+}
+
+func (s *Store) insert(records []coreusage.Record) error {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	placeholders := make([]string, 15)
+	for i := range placeholders {
+		placeholders[i] = s.dialect.placeholder(i + 1)
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s (requested_at, provider, model, api_key, auth_id, auth_index, source, failed, status_code, latency_ms, input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens) VALUES (%s)`, s.table, strings.Join(placeholders, ", ")))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, record := range records {
+		requestedAt := record.RequestedAt
+		if requestedAt.IsZero() {
+			requestedAt = time.Now()
+		}
+		failed := 0
+		if record.Failed {
+			failed = 1
+		}
+		total := record.Detail.TotalTokens
+		if total == 0 {
+			total = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+		}
+		if _, err = stmt.ExecContext(ctx,
+			requestedAt.UnixMilli(), record.Provider, record.Model, record.APIKey, record.AuthID, record.AuthIndex, record.Source,
+			failed, record.StatusCode, record.Latency.Milliseconds(),
+			record.Detail.InputTokens, record.Detail.OutputTokens, record.Detail.ReasoningTokens, record.Detail.CachedTokens, total,
+		); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// purgeExpired deletes records older than the retention period, if one is configured.
+func (s *Store) purgeExpired(now time.Time) {
+	if s.retentionDays <= 0 {
+		return
+	}
+	cutoff := now.AddDate(0, 0, -s.retentionDays).UnixMilli()
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE requested_at < %s`, s.table, s.dialect.placeholder(1)), cutoff)
+	if err != nil {
+		log.Warnf("usage database: failed to purge expired records: %v", err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		log.Debugf("usage database: purged %d records older than %d days", n, s.retentionDays)
+	}
+}
@@ -0,0 +1,191 @@
+package usagedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// recordingDriver stands in for a database driver: it records every statement and answers
+// queries with canned rows.
+type recordingDriver struct {
+	mu    sync.Mutex
+	execs []recordedExec
+	rows  [][]driver.Value
+	query string
+	args  []driver.Value
+}
+
+type recordedExec struct {
+	query string
+	args  []driver.Value
+}
+
+var fakeDriver = &recordingDriver{}
+
+// The recording dialect binds "?" placeholders, which also covers the non-positional path.
+func init() {
+	sql.Register("usagedb-recording", fakeDriver)
+	dialects["recording"] = dialect{driver: "usagedb-recording", idColumn: "INTEGER PRIMARY KEY AUTOINCREMENT"}
+}
+
+func (d *recordingDriver) reset(rows [][]driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.execs, d.rows, d.query, d.args = nil, rows, "", nil
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{d: c.d, query: query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *recordingConn) Commit() error             { return nil }
+func (c *recordingConn) Rollback() error           { return nil }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.execs = append(s.d.execs, recordedExec{query: s.query, args: args})
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.query, s.d.args = s.query, args
+	return &recordingRows{rows: s.d.rows}, nil
+}
+
+type recordingRows struct {
+	rows [][]driver.Value
+}
+
+func (r *recordingRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+func (r *recordingRows) Close() error { return nil }
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestStoreWritesQueuedRecords(t *testing.T) {
+	fakeDriver.reset(nil)
+	store, err := Open(context.Background(), config.UsageDatabaseConfig{Driver: "recording", DSN: "usage.db"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	requestedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.Enqueue(coreusage.Record{
+		Provider: "claude", Model: "claude-sonnet-4-5", APIKey: "key-1", AuthID: "auth-1", Source: "a@example.com",
+		RequestedAt: requestedAt, Failed: true, StatusCode: 429, Latency: 1500 * time.Millisecond,
+		Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5},
+	})
+	store.Flush(context.Background())
+	if err = store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	store.Enqueue(coreusage.Record{Model: "after-close"})
+
+	fakeDriver.mu.Lock()
+	defer fakeDriver.mu.Unlock()
+	if len(fakeDriver.execs) != 3 {
+		t.Fatalf("got %d statements, want schema, index and one insert", len(fakeDriver.execs))
+	}
+	if !strings.Contains(fakeDriver.execs[0].query, "CREATE TABLE IF NOT EXISTS usage_records") || !strings.Contains(fakeDriver.execs[0].query, "AUTOINCREMENT") {
+		t.Fatalf("schema statement = %s", fakeDriver.execs[0].query)
+	}
+	insert := fakeDriver.execs[2]
+	if !strings.HasPrefix(insert.query, "INSERT INTO usage_records") || strings.Contains(insert.query, "$1") {
+		t.Fatalf("insert statement = %s", insert.query)
+	}
+	want := []driver.Value{requestedAt.UnixMilli(), "claude", "claude-sonnet-4-5", "key-1", "auth-1", "", "a@example.com", int64(1), int64(429), int64(1500), int64(10), int64(5), int64(0), int64(0), int64(15)}
+	for i := range want {
+		if insert.args[i] != want[i] {
+			t.Fatalf("insert arg %d = %v, want %v (all %v)", i, insert.args[i], want[i], insert.args)
+		}
+	}
+}
+
+func TestStoreQueries(t *testing.T) {
+	fakeDriver.reset(nil)
+	store, err := Open(context.Background(), config.UsageDatabaseConfig{Driver: "recording", DSN: "usage.db", Table: "usage_history"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	pricing.Set([]config.ModelPrice{{Model: "m", Input: 1, Output: 2}})
+	defer pricing.Set(nil)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fakeDriver.reset([][]driver.Value{{int64(7), at.UnixMilli(), "claude", "m", "k", "a", "0", "s", int64(0), int64(200), int64(80), int64(1), int64(2), int64(0), int64(0), int64(3)}})
+	records, err := store.Records(context.Background(), Query{From: at.Add(-time.Hour), Model: "m", Limit: 5000})
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 1 || records[0].ID != 7 || !records[0].RequestedAt.Equal(at) || records[0].Failed || records[0].TotalTokens != 3 || records[0].EstimatedCost != 0.000005 {
+		t.Fatalf("records = %+v", records)
+	}
+	if !strings.Contains(fakeDriver.query, "FROM usage_history WHERE requested_at >= ? AND model = ?") || !strings.Contains(fakeDriver.query, "LIMIT 1000 OFFSET 0") {
+		t.Fatalf("records query = %s", fakeDriver.query)
+	}
+	if len(fakeDriver.args) != 2 || fakeDriver.args[1] != "m" {
+		t.Fatalf("records args = %v", fakeDriver.args)
+	}
+
+	day := at.UnixMilli() / 86400000
+	fakeDriver.reset([][]driver.Value{{day, int64(4), int64(1), int64(10), int64(20), int64(0), int64(0), int64(30), int64(400)}})
+	rows, err := store.Summary(context.Background(), Query{}, "day")
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Key != "2026-03-01" || rows[0].Requests != 4 || rows[0].AvgLatencyMs != 100 {
+		t.Fatalf("summary = %+v", rows)
+	}
+	if _, err = store.Summary(context.Background(), Query{}, "requested_at; DROP TABLE x"); err == nil {
+		t.Fatal("Summary accepted an unknown group")
+	}
+}
+
+func TestOpenRejectsInvalidConfig(t *testing.T) {
+	for _, cfg := range []config.UsageDatabaseConfig{
+		{Driver: "mysql", DSN: "x"},
+		{Driver: "sqlite", DSN: "usage.db"},
+		{Driver: "recording"},
+		{Driver: "recording", DSN: "x", Table: "usage; DROP TABLE users"},
+	} {
+		if store, err := Open(context.Background(), cfg); err == nil {
+			_ = store.Close()
+			t.Fatalf("Open(%+v) succeeded", cfg)
+		}
+	}
+}
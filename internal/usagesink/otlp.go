@@ -0,0 +1,196 @@
+package usagesink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const defaultServiceName = "cli-proxy-api"
+
+// latencyBoundsMS are the bucket bounds of the latency histogram in milliseconds.
+var latencyBoundsMS = []float64{100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000}
+
+// tokenTypes are the token counters reported with their token.type attribute.
+var tokenTypes = []string{"input", "output", "reasoning", "cached"}
+
+// otlpSink posts request, token and latency metrics to an OTLP/HTTP collector in the JSON
+// encoding. Each send reports the records since the previous one as delta sums.
+type otlpSink struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+	// windowStart is where the current delta window began; only the worker goroutine uses it.
+	windowStart time.Time
+}
+
+func newOTLP(endpoint string, headers map[string]string, service string, client *http.Client) *otlpSink {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/metrics") {
+		endpoint += "/v1/metrics"
+	}
+	service = strings.TrimSpace(service)
+	if service == "" {
+		service = defaultServiceName
+	}
+	return &otlpSink{endpoint: endpoint, headers: headers, service: service, client: client, windowStart: time.Now()}
+}
+
+// otlpSeries accumulates the records of one provider, model and outcome.
+type otlpSeries struct {
+	provider, model string
+	failed          bool
+	requests        int64
+	tokens          map[string]int64
+	latency         []int64
+	latencyCount    int64
+	latencySum      float64
+}
+
+func (s *otlpSink) Send(ctx context.Context, records []coreusage.Record) error {
+	start, now := s.windowStart, time.Now()
+	s.windowStart = now
+	body, err := json.Marshal(s.encode(records, start, now))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// encode builds an OTLP ExportMetricsServiceRequest in its JSON mapping, where 64-bit
+// integers are decimal strings. Sums use delta temporality.
+func (s *otlpSink) encode(records []coreusage.Record, start, end time.Time) map[string]any {
+	type seriesKey struct {
+		provider, model string
+		failed          bool
+	}
+	bySeries := make(map[seriesKey]*otlpSeries)
+	for _, record := range records {
+		key := seriesKey{provider: record.Provider, model: record.Model, failed: record.Failed}
+		series := bySeries[key]
+		if series == nil {
+			series = &otlpSeries{provider: key.provider, model: key.model, failed: key.failed, tokens: make(map[string]int64), latency: make([]int64, len(latencyBoundsMS)+1)}
+			bySeries[key] = series
+		}
+		series.requests++
+		series.tokens["input"] += record.Detail.InputTokens
+		series.tokens["output"] += record.Detail.OutputTokens
+		series.tokens["reasoning"] += record.Detail.ReasoningTokens
+		series.tokens["cached"] += record.Detail.CachedTokens
+		if record.Latency > 0 {
+			ms := float64(record.Latency.Milliseconds())
+			series.latency[sort.SearchFloat64s(latencyBoundsMS, ms)]++
+			series.latencyCount++
+			series.latencySum += ms
+		}
+	}
+	ordered := make([]*otlpSeries, 0, len(bySeries))
+	for _, series := range bySeries {
+		ordered = append(ordered, series)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.provider != b.provider {
+			return a.provider < b.provider
+		}
+		if a.model != b.model {
+			return a.model < b.model
+		}
+		return !a.failed && b.failed
+	})
+
+	startNano := strconv.FormatInt(start.UnixNano(), 10)
+	endNano := strconv.FormatInt(end.UnixNano(), 10)
+	var requests, tokens, latency []map[string]any
+	for _, series := range ordered {
+		attrs := []map[string]any{
+			stringAttribute("provider", series.provider),
+			stringAttribute("model", series.model),
+			{"key": "failed", "value": map[string]any{"boolValue": series.failed}},
+		}
+		requests = append(requests, map[string]any{
+			"attributes": attrs, "startTimeUnixNano": startNano, "timeUnixNano": endNano,
+			"asInt": strconv.FormatInt(series.requests, 10),
+		})
+		for _, tokenType := range tokenTypes {
+			if series.tokens[tokenType] == 0 {
+				continue
+			}
+			tokens = append(tokens, map[string]any{
+				"attributes":        append(append([]map[string]any{}, attrs...), stringAttribute("token.type", tokenType)),
+				"startTimeUnixNano": startNano, "timeUnixNano": endNano,
+				"asInt": strconv.FormatInt(series.tokens[tokenType], 10),
+			})
+		}
+		if series.latencyCount > 0 {
+			buckets := make([]string, len(series.latency))
+			for i, count := range series.latency {
+				buckets[i] = strconv.FormatInt(count, 10)
+			}
+			latency = append(latency, map[string]any{
+				"attributes": attrs, "startTimeUnixNano": startNano, "timeUnixNano": endNano,
+				"count": strconv.FormatInt(series.latencyCount, 10), "sum": series.latencySum,
+				"bucketCounts": buckets, "explicitBounds": latencyBoundsMS,
+			})
+		}
+	}
+
+	metrics := []map[string]any{deltaSum("cliproxy.usage.requests", "Upstream requests", "{request}", requests)}
+	if len(tokens) > 0 {
+		metrics = append(metrics, deltaSum("cliproxy.usage.tokens", "Tokens used by upstream requests", "{token}", tokens))
+	}
+	if len(latency) > 0 {
+		metrics = append(metrics, map[string]any{
+			"name": "cliproxy.usage.latency", "description": "Upstream request latency", "unit": "ms",
+			"histogram": map[string]any{"aggregationTemporality": 1, "dataPoints": latency},
+		})
+	}
+	return map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource": map[string]any{"attributes": []map[string]any{stringAttribute("service.name", s.service)}},
+			"scopeMetrics": []any{map[string]any{
+				"scope":   map[string]any{"name": "github.com/router-for-me/CLIProxyAPI/v6/internal/usagesink"},
+				"metrics": metrics,
+			}},
+		}},
+	}
+}
+
+// deltaSum is a monotonic sum metric with delta temporality.
+func deltaSum(name, description, unit string, points []map[string]any) map[string]any {
+	return map[string]any{
+		"name": name, "description": description, "unit": unit,
+		"sum": map[string]any{"aggregationTemporality": 1, "isMonotonic": true, "dataPoints": points},
+	}
+}
+
+func stringAttribute(key, value string) map[string]any {
+	return map[string]any{"key": key, "value": map[string]any{"stringValue": value}}
+}
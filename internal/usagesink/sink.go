@@ -0,0 +1,184 @@
+// Package usagesink streams usage records to external observability systems: StatsD, an
+// OTLP/HTTP metrics collector or a webhook. Each configured sink batches records in the
+// background, so a slow or unreachable destination never delays requests.
+package usagesink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultInterval     = time.Second
+	defaultOTLPInterval = 10 * time.Second
+	// maxQueued bounds records waiting for one sink; newer records are dropped while the
+	// destination is unreachable.
+	maxQueued   = 8192
+	sendTimeout = 10 * time.Second
+)
+
+// Sink delivers a batch of usage records to an external system.
+type Sink interface {
+	Send(ctx context.Context, records []coreusage.Record) error
+}
+
+// New returns the sink described by cfg.
+func New(cfg config.UsageSinkConfig) (Sink, error) {
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("usage sink %q: endpoint is required", cfg.Type)
+	}
+	client := &http.Client{Timeout: sendTimeout}
+	switch strings.ToLower(strings.TrimSpace(cfg.Type)) {
+	case "statsd":
+		return newStatsD(endpoint, cfg.Prefix, cfg.Tags), nil
+	case "otlp":
+		return newOTLP(endpoint, cfg.Headers, cfg.ServiceName, client), nil
+	case "webhook":
+		return newWebhook(endpoint, cfg.Headers, client), nil
+	}
+	return nil, fmt.Errorf("usage sink: unsupported type %q, want statsd, otlp or webhook", cfg.Type)
+}
+
+// worker queues the records of one sink and sends them every interval.
+type worker struct {
+	name     string
+	sink     Sink
+	interval time.Duration
+
+	mu      sync.Mutex
+	queue   []coreusage.Record
+	dropped int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newWorker(name string, sink Sink, interval time.Duration) *worker {
+	w := &worker{name: name, sink: sink, interval: interval, stop: make(chan struct{}), done: make(chan struct{})}
+	go w.run()
+	return w
+}
+
+func (w *worker) enqueue(record coreusage.Record) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) >= maxQueued {
+		w.dropped++
+		return
+	}
+	w.queue = append(w.queue, record)
+}
+
+func (w *worker) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case <-w.stop:
+			w.flush()
+			return
+		}
+	}
+}
+
+func (w *worker) flush() {
+	w.mu.Lock()
+	records := w.queue
+	w.queue = nil
+	dropped := w.dropped
+	w.dropped = 0
+	w.mu.Unlock()
+	if dropped > 0 {
+		log.Warnf("usage sink %s: dropped %d records while the queue was full", w.name, dropped)
+	}
+	if len(records) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := w.sink.Send(ctx, records); err != nil {
+		log.Warnf("usage sink %s: failed to send %d records: %v", w.name, len(records), err)
+	}
+}
+
+// close sends the queued records and stops the worker.
+func (w *worker) close() {
+	close(w.stop)
+	<-w.done
+}
+
+// plugin fans usage records out to the configured sinks.
+type plugin struct {
+	workers atomic.Pointer[[]*worker]
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p *plugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	workers := p.workers.Load()
+	if workers == nil {
+		return
+	}
+	for _, w := range *workers {
+		w.enqueue(record)
+	}
+}
+
+var (
+	sharedPlugin       = &plugin{}
+	registerPluginOnce sync.Once
+	configureMu        sync.Mutex
+)
+
+// Configure replaces the running sinks with those in cfgs; it is called at startup and
+// when the sink settings change. Invalid entries are logged and skipped. Records queued for
+// the previous sinks are sent before they stop.
+func Configure(cfgs []config.UsageSinkConfig) {
+	configureMu.Lock()
+	defer configureMu.Unlock()
+	workers := make([]*worker, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		sink, err := New(cfg)
+		if err != nil {
+			log.Errorf("%v; usage records are not sent to it", err)
+			continue
+		}
+		interval := time.Duration(cfg.FlushIntervalSeconds) * time.Second
+		if interval <= 0 {
+			interval = defaultInterval
+			if _, ok := sink.(*otlpSink); ok {
+				interval = defaultOTLPInterval
+			}
+		}
+		name := strings.ToLower(strings.TrimSpace(cfg.Type)) + ":" + strings.TrimSpace(cfg.Endpoint)
+		workers = append(workers, newWorker(name, sink, interval))
+	}
+	if len(workers) > 0 {
+		registerPluginOnce.Do(func() {
+			coreusage.RegisterPlugin(sharedPlugin)
+		})
+	}
+	previous := sharedPlugin.workers.Swap(&workers)
+	if previous != nil {
+		for _, w := range *previous {
+			w.close()
+		}
+	}
+}
+
+// Close sends the queued records of every sink and stops them, e.g. before shutdown.
+func Close() {
+	Configure(nil)
+}
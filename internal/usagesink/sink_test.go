@@ -0,0 +1,143 @@
+package usagesink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+var testRecords = []coreusage.Record{
+	{Provider: "claude", Model: "claude-sonnet-4-5", APIKey: "sk-client-secret-key", AuthIndex: "a1", StatusCode: 200, Latency: 1200 * time.Millisecond, Detail: coreusage.Detail{InputTokens: 100, OutputTokens: 20, TotalTokens: 120}},
+	{Provider: "claude", Model: "claude-sonnet-4-5", StatusCode: 200, Latency: 300 * time.Millisecond, Detail: coreusage.Detail{InputTokens: 50, OutputTokens: 5, TotalTokens: 55}},
+	{Provider: "gemini", Model: "gemini-2.5-pro", Failed: true, StatusCode: 429},
+}
+
+func TestStatsDSinkSendsCountersAndTimings(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	for _, tags := range []bool{false, true} {
+		sink := newStatsD(conn.LocalAddr().String(), "", tags)
+		if err = sink.Send(context.Background(), testRecords); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		buf := make([]byte, maxDatagram)
+		n, _, errRead := conn.ReadFrom(buf)
+		if errRead != nil {
+			t.Fatalf("read: %v", errRead)
+		}
+		packet := string(buf[:n])
+		want := []string{
+			"cliproxy.claude.claude-sonnet-4-5.200.requests:2|c",
+			"cliproxy.claude.claude-sonnet-4-5.200.tokens.input:150|c",
+			"cliproxy.claude.claude-sonnet-4-5.200.latency:1200|ms",
+			"cliproxy.gemini.gemini-2_5-pro.429.failures:1|c",
+		}
+		if tags {
+			want = []string{
+				"cliproxy.requests:2|c|#provider:claude,model:claude-sonnet-4-5,status:200",
+				"cliproxy.tokens.input:150|c|#provider:claude,model:claude-sonnet-4-5,status:200",
+				"cliproxy.failures:1|c|#provider:gemini,model:gemini-2.5-pro,status:429",
+			}
+		}
+		for _, line := range want {
+			if !strings.Contains(packet, line+"\n") && !strings.HasSuffix(packet, line) {
+				t.Fatalf("packet misses %q:\n%s", line, packet)
+			}
+		}
+	}
+}
+
+func TestOTLPSinkPostsDeltaSums(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("X-Api-Key") != "collector-key" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sink, err := New(config.UsageSinkConfig{Type: "otlp", Endpoint: srv.URL, Headers: map[string]string{"X-Api-Key": "collector-key"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err = sink.Send(context.Background(), testRecords); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	metrics := gjson.GetBytes(body, "resourceMetrics.0.scopeMetrics.0.metrics")
+	requests := metrics.Get(`#(name=="cliproxy.usage.requests").sum`)
+	if requests.Get("aggregationTemporality").Int() != 1 || requests.Get("dataPoints.0.asInt").String() != "2" {
+		t.Fatalf("unexpected request sum: %s", requests.Raw)
+	}
+	if failed := requests.Get(`dataPoints.1.attributes.#(key=="failed").value.boolValue`); !failed.Bool() {
+		t.Fatalf("expected the gemini series to be failed: %s", requests.Raw)
+	}
+	inputTokens := metrics.Get(`#(name=="cliproxy.usage.tokens").sum.dataPoints.#(attributes.#(value.stringValue=="input")).asInt`)
+	if inputTokens.String() != "150" {
+		t.Fatalf("unexpected input tokens: %s", metrics.Raw)
+	}
+	latency := metrics.Get(`#(name=="cliproxy.usage.latency").histogram.dataPoints.0`)
+	if latency.Get("count").String() != "2" || latency.Get("bucketCounts.2").String() != "1" || latency.Get("bucketCounts.4").String() != "1" {
+		t.Fatalf("unexpected latency histogram: %s", latency.Raw)
+	}
+}
+
+func TestConfigureSendsQueuedRecordsToWebhook(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Records []map[string]any `json:"records"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		batches = append(batches, payload.Records)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	Configure([]config.UsageSinkConfig{
+		{Type: "webhook", Endpoint: srv.URL, FlushIntervalSeconds: 3600},
+		{Type: "kafka", Endpoint: "localhost:9092"},
+	})
+	workers := sharedPlugin.workers.Load()
+	if workers == nil || len(*workers) != 1 {
+		t.Fatal("the valid webhook sink must be running and the unsupported one skipped")
+	}
+	for _, record := range testRecords {
+		sharedPlugin.HandleUsage(context.Background(), record)
+	}
+	Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 1 || len(batches[0]) != len(testRecords) {
+		t.Fatalf("expected one batch with every record, got %v", batches)
+	}
+	first := batches[0][0]
+	if first["api_key"] == "sk-client-secret-key" || first["auth_index"] != "a1" || first["input_tokens"] != float64(100) || first["latency_ms"] != float64(1200) {
+		t.Fatalf("unexpected webhook record: %v", first)
+	}
+	if workers = sharedPlugin.workers.Load(); workers != nil && len(*workers) != 0 {
+		t.Fatal("Close must stop every sink")
+	}
+}
@@ -0,0 +1,149 @@
+package usagesink
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	defaultStatsDPrefix = "cliproxy"
+	// maxDatagram keeps StatsD packets below the common Ethernet MTU.
+	maxDatagram = 1432
+)
+
+// statsDSink sends counters and latency timings over UDP. Counters are summed per batch
+// before sending; timings are sent for every record.
+type statsDSink struct {
+	address string
+	prefix  string
+	tags    bool
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newStatsD(address, prefix string, tags bool) *statsDSink {
+	prefix = strings.Trim(strings.TrimSpace(prefix), ".")
+	if prefix == "" {
+		prefix = defaultStatsDPrefix
+	}
+	return &statsDSink{address: address, prefix: prefix, tags: tags}
+}
+
+// statsDKey groups the counters of one provider, model and status.
+type statsDKey struct {
+	provider, model, status string
+}
+
+func (s *statsDSink) Send(_ context.Context, records []coreusage.Record) error {
+	counters := make(map[statsDKey]map[string]int64)
+	var order []statsDKey
+	var lines []string
+	for _, record := range records {
+		key := statsDKey{provider: record.Provider, model: record.Model, status: statusLabel(record)}
+		values := counters[key]
+		if values == nil {
+			values = make(map[string]int64)
+			counters[key] = values
+			order = append(order, key)
+		}
+		values["requests"]++
+		if record.Failed {
+			values["failures"]++
+		}
+		values["tokens.input"] += record.Detail.InputTokens
+		values["tokens.output"] += record.Detail.OutputTokens
+		values["tokens.reasoning"] += record.Detail.ReasoningTokens
+		values["tokens.cached"] += record.Detail.CachedTokens
+		values["tokens.total"] += record.Detail.TotalTokens
+		if record.Latency > 0 {
+			lines = append(lines, s.line(key, "latency", strconv.FormatInt(record.Latency.Milliseconds(), 10), "ms"))
+		}
+	}
+	for _, key := range order {
+		for _, metric := range []string{"requests", "failures", "tokens.input", "tokens.output", "tokens.reasoning", "tokens.cached", "tokens.total"} {
+			if value := counters[key][metric]; value > 0 {
+				lines = append(lines, s.line(key, metric, strconv.FormatInt(value, 10), "c"))
+			}
+		}
+	}
+	return s.write(lines)
+}
+
+// line formats one metric, with the key as DogStatsD tags or as name segments.
+func (s *statsDSink) line(key statsDKey, metric, value, kind string) string {
+	if s.tags {
+		return fmt.Sprintf("%s.%s:%s|%s|#provider:%s,model:%s,status:%s", s.prefix, metric, value, kind, statsDTag(key.provider), statsDTag(key.model), key.status)
+	}
+	return fmt.Sprintf("%s.%s.%s.%s.%s:%s|%s", s.prefix, statsDSegment(key.provider), statsDSegment(key.model), key.status, metric, value, kind)
+}
+
+// write packs lines into datagrams of at most maxDatagram bytes.
+func (s *statsDSink) write(lines []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := net.Dial("udp", s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	var packet strings.Builder
+	send := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := s.conn.Write([]byte(packet.String()))
+		packet.Reset()
+		return err
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxDatagram {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	return send()
+}
+
+// statusLabel is the upstream status code, or "error" for failures without one.
+func statusLabel(record coreusage.Record) string {
+	if record.StatusCode > 0 {
+		return strconv.Itoa(record.StatusCode)
+	}
+	if record.Failed {
+		return "error"
+	}
+	return "ok"
+}
+
+var (
+	statsDTagReplacer     = strings.NewReplacer("|", "_", ",", "_", "#", "_", ":", "_", "@", "_", " ", "_", "\n", "_")
+	statsDSegmentReplacer = strings.NewReplacer(".", "_", "|", "_", ":", "_", "@", "_", "#", "_", " ", "_", "/", "_", "\n", "_")
+)
+
+func statsDTag(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return statsDTagReplacer.Replace(value)
+}
+
+func statsDSegment(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return statsDSegmentReplacer.Replace(value)
+}
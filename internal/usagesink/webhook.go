@@ -0,0 +1,87 @@
+package usagesink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// webhookSink posts each batch of records as JSON to a URL.
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newWebhook(url string, headers map[string]string, client *http.Client) *webhookSink {
+	return &webhookSink{url: url, headers: headers, client: client}
+}
+
+// webhookRecord is the JSON form of one usage record. Client API keys are masked and
+// credentials are identified by their index only.
+type webhookRecord struct {
+	RequestedAt     time.Time `json:"requested_at"`
+	Provider        string    `json:"provider"`
+	Model           string    `json:"model"`
+	APIKey          string    `json:"api_key,omitempty"`
+	AuthIndex       string    `json:"auth_index,omitempty"`
+	Failed          bool      `json:"failed"`
+	StatusCode      int       `json:"status_code,omitempty"`
+	LatencyMS       int64     `json:"latency_ms"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	CachedTokens    int64     `json:"cached_tokens"`
+	TotalTokens     int64     `json:"total_tokens"`
+}
+
+func (s *webhookSink) Send(ctx context.Context, records []coreusage.Record) error {
+	payload := make([]webhookRecord, 0, len(records))
+	for _, record := range records {
+		payload = append(payload, webhookRecord{
+			RequestedAt:     record.RequestedAt,
+			Provider:        record.Provider,
+			Model:           record.Model,
+			APIKey:          util.HideAPIKey(record.APIKey),
+			AuthIndex:       record.AuthIndex,
+			Failed:          record.Failed,
+			StatusCode:      record.StatusCode,
+			LatencyMS:       record.Latency.Milliseconds(),
+			InputTokens:     record.Detail.InputTokens,
+			OutputTokens:    record.Detail.OutputTokens,
+			ReasoningTokens: record.Detail.ReasoningTokens,
+			CachedTokens:    record.Detail.CachedTokens,
+			TotalTokens:     record.Detail.TotalTokens,
+		})
+	}
+	body, err := json.Marshal(map[string]any{"records": payload})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// compactionPrefix marks compaction items produced by the proxy, as opposed to the opaque
+// encrypted_content returned by OpenAI's own /responses/compact.
+const compactionPrefix = "cpa-compaction-v1:"
+
+// EncodeCompactionSummary wraps a conversation summary as the encrypted_content of a
+// Responses API compaction item.
+func EncodeCompactionSummary(summary string) string {
+	return compactionPrefix + base64.StdEncoding.EncodeToString([]byte(summary))
+}
+
+// DecodeCompactionSummary returns the summary held by a compaction item produced by
+// EncodeCompactionSummary. Content from other sources reports false.
+func DecodeCompactionSummary(content string) (string, bool) {
+	encoded, ok := strings.CutPrefix(content, compactionPrefix)
+	if !ok {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	return string(decoded), true
+}
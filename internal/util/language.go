@@ -0,0 +1,87 @@
+package util
+
+import "unicode"
+
+// Scripts reported by ScriptShares.
+const (
+	ScriptWestern  = "western"  // Latin letters, see IsWesternRune
+	ScriptCJK      = "cjk"      // Han, Hiragana, Katakana and Hangul
+	ScriptCyrillic = "cyrillic" // Cyrillic
+	ScriptArabic   = "arabic"   // Arabic
+	ScriptOther    = "other"    // any other non-western letter (Thai, Devanagari, Greek, ...)
+)
+
+// IsWesternRune reports whether c is ASCII or belongs to one of the Latin blocks.
+// Non-western characters (CJK, Arabic, ...) cost noticeably more tokens per character.
+func IsWesternRune(c rune) bool {
+	switch {
+	case c <= 0x024F: // ASCII, Latin-1 Supplement, Latin Extended-A and -B
+		return true
+	case c >= 0x1E00 && c <= 0x1EFF: // Latin Extended Additional
+		return true
+	case c >= 0x2C60 && c <= 0x2C7F: // Latin Extended-C
+		return true
+	case c >= 0xA720 && c <= 0xA7FF: // Latin Extended-D
+		return true
+	case c >= 0xAB30 && c <= 0xAB6F: // Latin Extended-E
+		return true
+	}
+	return false
+}
+
+// runeScript classifies a letter. Digits, punctuation, symbols and whitespace return ""
+// so that code and markup do not skew the result towards western text.
+func runeScript(c rune) string {
+	switch {
+	case unicode.In(c, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+		return ScriptCJK
+	case !unicode.IsLetter(c):
+		return ""
+	case IsWesternRune(c):
+		return ScriptWestern
+	case unicode.Is(unicode.Cyrillic, c):
+		return ScriptCyrillic
+	case unicode.Is(unicode.Arabic, c):
+		return ScriptArabic
+	default:
+		return ScriptOther
+	}
+}
+
+// ScriptShares returns the fraction of letters in text that belong to each script, looking
+// at no more than maxLetters letters (<= 0 means all). Text without letters yields nil.
+func ScriptShares(text string, maxLetters int) map[string]float64 {
+	counts := make(map[string]int, 5)
+	total := 0
+	for _, c := range text {
+		script := runeScript(c)
+		if script == "" {
+			continue
+		}
+		counts[script]++
+		total++
+		if maxLetters > 0 && total >= maxLetters {
+			break
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	shares := make(map[string]float64, len(counts))
+	for script, n := range counts {
+		shares[script] = float64(n) / float64(total)
+	}
+	return shares
+}
+
+// DominantScript returns the script with the largest share of letters in text, or "" when
+// text has no letters.
+func DominantScript(text string) string {
+	best, bestShare := "", 0.0
+	for script, share := range ScriptShares(text, 0) {
+		if share > bestShare || (share == bestShare && script < best) {
+			best, bestShare = script, share
+		}
+	}
+	return best
+}
@@ -0,0 +1,19 @@
+package util
+
+import "testing"
+
+func TestScriptShares(t *testing.T) {
+	shares := ScriptShares("Hello 世界, привет! 123", 0)
+	if shares[ScriptWestern] != 5.0/13 || shares[ScriptCJK] != 2.0/13 || shares[ScriptCyrillic] != 6.0/13 {
+		t.Fatalf("shares = %v", shares)
+	}
+	if ScriptShares("123 !?", 0) != nil {
+		t.Fatal("text without letters should have no shares")
+	}
+	if got := DominantScript("これは日本語の文章です。code()"); got != ScriptCJK {
+		t.Fatalf("dominant script = %q", got)
+	}
+	if !IsWesternRune('é') || IsWesternRune('中') {
+		t.Fatal("IsWesternRune misclassified a rune")
+	}
+}
@@ -0,0 +1,151 @@
+package util
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// logLevelState tracks the level derived from config, a runtime override set through the
+// management API, and targeted debug filters. Runtime changes are not persisted.
+var logLevelState struct {
+	mu             sync.Mutex
+	configLevel    log.Level
+	override       *log.Level
+	debugProviders map[string]struct{}
+	debugAuths     map[string]struct{}
+}
+
+// baseLogLevel is the level applied to entries that are not covered by a debug target.
+var baseLogLevel atomic.Uint32
+
+// debugTargetsActive reports whether any targeted debug filter is set.
+var debugTargetsActive atomic.Bool
+
+func init() {
+	logLevelState.configLevel = log.InfoLevel
+	baseLogLevel.Store(uint32(log.InfoLevel))
+}
+
+// LogLevelStatus describes the current runtime logging state.
+type LogLevelStatus struct {
+	ConfigLevel    string   `json:"config-level"`
+	Override       string   `json:"override,omitempty"`
+	EffectiveLevel string   `json:"effective-level"`
+	DebugProviders []string `json:"debug-providers"`
+	DebugAuths     []string `json:"debug-auths"`
+}
+
+// SetLogLevelOverride replaces the config-derived log level until cleared with nil.
+func SetLogLevelOverride(level *log.Level) {
+	logLevelState.mu.Lock()
+	defer logLevelState.mu.Unlock()
+	if level != nil {
+		copied := *level
+		level = &copied
+	}
+	logLevelState.override = level
+	applyLogLevelLocked()
+}
+
+// SetDebugTargets enables debug logging only for requests served by the given providers or
+// auth IDs. Empty slices clear the targets.
+func SetDebugTargets(providers, auths []string) {
+	logLevelState.mu.Lock()
+	defer logLevelState.mu.Unlock()
+	logLevelState.debugProviders = normalizeDebugTargets(providers)
+	logLevelState.debugAuths = normalizeDebugTargets(auths)
+	applyLogLevelLocked()
+}
+
+// DebugTargeted reports whether a request for provider or authID matches a debug target.
+func DebugTargeted(provider, authID string) bool {
+	if !debugTargetsActive.Load() {
+		return false
+	}
+	logLevelState.mu.Lock()
+	defer logLevelState.mu.Unlock()
+	if _, ok := logLevelState.debugProviders[strings.ToLower(strings.TrimSpace(provider))]; ok && provider != "" {
+		return true
+	}
+	_, ok := logLevelState.debugAuths[strings.ToLower(strings.TrimSpace(authID))]
+	return ok && authID != ""
+}
+
+// DebugTargetsActive reports whether targeted debug logging is enabled.
+func DebugTargetsActive() bool { return debugTargetsActive.Load() }
+
+// BaseLogLevel returns the level for entries outside any debug target.
+func BaseLogLevel() log.Level { return log.Level(baseLogLevel.Load()) }
+
+// CurrentLogLevelStatus returns a snapshot of the runtime logging state.
+func CurrentLogLevelStatus() LogLevelStatus {
+	logLevelState.mu.Lock()
+	defer logLevelState.mu.Unlock()
+	status := LogLevelStatus{
+		ConfigLevel:    logLevelState.configLevel.String(),
+		EffectiveLevel: log.GetLevel().String(),
+		DebugProviders: sortedDebugTargets(logLevelState.debugProviders),
+		DebugAuths:     sortedDebugTargets(logLevelState.debugAuths),
+	}
+	if logLevelState.override != nil {
+		status.Override = logLevelState.override.String()
+	}
+	return status
+}
+
+func setConfigLogLevel(level log.Level) {
+	logLevelState.mu.Lock()
+	defer logLevelState.mu.Unlock()
+	logLevelState.configLevel = level
+	applyLogLevelLocked()
+}
+
+// applyLogLevelLocked sets the logger to the base level, raised to debug while debug targets
+// exist so targeted entries reach the formatter, which filters the rest.
+func applyLogLevelLocked() {
+	base := logLevelState.configLevel
+	if logLevelState.override != nil {
+		base = *logLevelState.override
+	}
+	baseLogLevel.Store(uint32(base))
+	targets := len(logLevelState.debugProviders) > 0 || len(logLevelState.debugAuths) > 0
+	debugTargetsActive.Store(targets)
+
+	effective := base
+	if targets && effective < log.DebugLevel {
+		effective = log.DebugLevel
+	}
+	if current := log.GetLevel(); current != effective {
+		log.SetLevel(effective)
+		log.Infof("log level changed from %s to %s", current, effective)
+	}
+}
+
+func normalizeDebugTargets(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			out[value] = struct{}{}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func sortedDebugTargets(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for value := range set {
+		out = append(out, value)
+	}
+	sort.Strings(out)
+	return out
+}
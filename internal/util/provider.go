@@ -182,16 +182,17 @@ func HideAPIKey(apiKey string) string {
 func MaskAuthorizationHeader(value string) string {
 	parts := strings.SplitN(strings.TrimSpace(value), " ", 2)
 	if len(parts) < 2 {
-		return HideAPIKey(value)
+		return RedactSecret(value)
 	}
-	return parts[0] + " " + HideAPIKey(parts[1])
+	return parts[0] + " " + RedactSecret(parts[1])
 }
 
 // MaskSensitiveHeaderValue masks sensitive header values while preserving expected formats.
 //
 // Behavior by header key (case-insensitive):
 //   - "Authorization": Preserve the auth type prefix (e.g., "Bearer ") and mask only the credential part.
-//   - Headers containing "api-key": Mask the entire value using HideAPIKey.
+//   - Headers containing "api-key", "token", "secret", "cookie" or a configured extra name:
+//     Mask the entire value using RedactSecret.
 //   - Others: Return the original value unchanged.
 //
 // Parameters:
@@ -208,8 +209,9 @@ func MaskSensitiveHeaderValue(key, value string) string {
 	case strings.Contains(lowerKey, "api-key"),
 		strings.Contains(lowerKey, "apikey"),
 		strings.Contains(lowerKey, "token"),
-		strings.Contains(lowerKey, "secret"):
-		return HideAPIKey(value)
+		strings.Contains(lowerKey, "secret"),
+		isRedactedHeader(lowerKey):
+		return RedactSecret(value)
 	default:
 		return value
 	}
@@ -243,7 +245,7 @@ func MaskSensitiveQuery(raw string) string {
 		if err != nil {
 			decodedValue = valuePart
 		}
-		masked := HideAPIKey(strings.TrimSpace(decodedValue))
+		masked := RedactSecret(strings.TrimSpace(decodedValue))
 		parts[i] = keyPart + "=" + url.QueryEscape(masked)
 		changed = true
 	}
@@ -5,9 +5,11 @@ package util
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -15,7 +17,7 @@ import (
 )
 
 // SetProxy configures the provided HTTP client with proxy settings from the configuration.
-// It supports SOCKS5, HTTP, and HTTPS proxies. The function modifies the client's transport
+// It supports SOCKS5, HTTP, HTTPS, and Unix socket proxies. The function modifies the client's transport
 // to route requests through the configured proxy server.
 func SetProxy(cfg *config.SDKConfig, httpClient *http.Client) *http.Client {
 	var transport *http.Transport
@@ -23,7 +25,13 @@ func SetProxy(cfg *config.SDKConfig, httpClient *http.Client) *http.Client {
 	proxyURL, errParse := url.Parse(cfg.ProxyURL)
 	if errParse == nil {
 		// Handle different proxy schemes.
-		if proxyURL.Scheme == "socks5" {
+		if unixTransport, isUnix, errUnix := UnixProxyTransport(proxyURL); isUnix {
+			if errUnix != nil {
+				log.Errorf("create unix socket proxy transport failed: %v", errUnix)
+				return httpClient
+			}
+			transport = unixTransport
+		} else if proxyURL.Scheme == "socks5" {
 			// Configure SOCKS5 proxy with optional authentication.
 			var proxyAuth *proxy.Auth
 			if proxyURL.User != nil {
@@ -53,3 +61,55 @@ func SetProxy(cfg *config.SDKConfig, httpClient *http.Client) *http.Client {
 	}
 	return httpClient
 }
+
+// UnixProxyTransport builds a transport for proxies reached over a Unix domain socket.
+// "unix:///path.sock" speaks HTTP (CONNECT for TLS targets) and "socks5+unix:///path.sock"
+// speaks SOCKS5. On Linux "unix:@name" addresses an abstract-namespace socket. ok is false
+// when proxyURL uses any other scheme.
+func UnixProxyTransport(proxyURL *url.URL) (transport *http.Transport, ok bool, err error) {
+	if proxyURL == nil {
+		return nil, false, nil
+	}
+	scheme := strings.ToLower(proxyURL.Scheme)
+	if scheme != "unix" && scheme != "socks5+unix" {
+		return nil, false, nil
+	}
+	socketPath := proxyURL.Path
+	if socketPath == "" {
+		socketPath = proxyURL.Opaque
+	}
+	if socketPath == "" {
+		return nil, true, fmt.Errorf("proxy URL %q has no socket path", proxyURL.Redacted())
+	}
+
+	if scheme == "socks5+unix" {
+		var proxyAuth *proxy.Auth
+		if proxyURL.User != nil {
+			username := proxyURL.User.Username()
+			password, _ := proxyURL.User.Password()
+			proxyAuth = &proxy.Auth{User: username, Password: password}
+		}
+		dialer, errSOCKS5 := proxy.SOCKS5("unix", socketPath, proxyAuth, proxy.Direct)
+		if errSOCKS5 != nil {
+			return nil, true, fmt.Errorf("create SOCKS5 dialer failed: %w", errSOCKS5)
+		}
+		return &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if cd, okCtx := dialer.(proxy.ContextDialer); okCtx {
+					return cd.DialContext(ctx, network, addr)
+				}
+				return dialer.Dial(network, addr)
+			},
+		}, true, nil
+	}
+
+	// The transport dials the proxy host for every request; route that dial to the socket.
+	httpProxy := &url.URL{Scheme: "http", Host: "unix-proxy", User: proxyURL.User}
+	var d net.Dialer
+	return &http.Transport{
+		Proxy: http.ProxyURL(httpProxy),
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}, true, nil
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnixProxyTransportForwardsThroughSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "proxy.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	seen := make(chan string, 1)
+	go func() {
+		conn, errAccept := ln.Accept()
+		if errAccept != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		req, errRead := http.ReadRequest(bufio.NewReader(conn))
+		if errRead != nil {
+			return
+		}
+		seen <- req.RequestURI + " " + req.Header.Get("Proxy-Authorization")
+		_, _ = io.WriteString(conn, "HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+	}()
+
+	proxyURL, _ := url.Parse("unix://user:pass@" + sock)
+	transport, ok, err := UnixProxyTransport(proxyURL)
+	if !ok || err != nil {
+		t.Fatalf("expected unix transport, ok=%v err=%v", ok, err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get("http://upstream.example/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	got := <-seen
+	if !strings.HasPrefix(got, "http://upstream.example/v1/models Basic ") {
+		t.Fatalf("proxy saw %q", got)
+	}
+}
+
+func TestUnixProxyTransportSchemes(t *testing.T) {
+	for raw, want := range map[string]bool{
+		"unix:@tailscale":            true,
+		"socks5+unix:///run/s.sock":  true,
+		"socks5://127.0.0.1:1080":    false,
+		"http://proxy.internal:8080": false,
+	} {
+		u, _ := url.Parse(raw)
+		_, ok, err := UnixProxyTransport(u)
+		if ok != want || err != nil {
+			t.Errorf("%s: ok=%v err=%v", raw, ok, err)
+		}
+	}
+	u, _ := url.Parse("unix://")
+	if _, ok, err := UnixProxyTransport(u); !ok || err == nil {
+		t.Errorf("empty socket path should fail, ok=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,157 @@
+package util
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactedPlaceholder replaces secrets entirely when full redaction is enabled.
+const RedactedPlaceholder = "[REDACTED]"
+
+// defaultRedactedFields lists JSON and form field names whose values are treated as credentials.
+var defaultRedactedFields = []string{
+	"access_token", "accessToken",
+	"refresh_token", "refreshToken",
+	"id_token", "idToken",
+	"client_secret", "clientSecret",
+	"api_key", "apiKey",
+	"session_token", "sessionToken",
+	"password",
+}
+
+// defaultRedactedHeaders lists header names (case-insensitive substrings) masked in addition to
+// Authorization and api-key/token/secret style headers.
+var defaultRedactedHeaders = []string{"cookie"}
+
+// bearerAlternative matches bearer tokens in free text. Its two groups are the prefix kept
+// as is and the token.
+const bearerAlternative = `(?i:\b(bearer\s+))([A-Za-z0-9._~+/=-]{8,})`
+
+type redactionRules struct {
+	full    bool
+	headers []string
+	// pattern combines the JSON field, form field and bearer token alternatives so that a
+	// payload is scanned once. Each alternative contributes a prefix group and a secret
+	// group; the JSON alternative also keeps its closing quote in a third group.
+	pattern *regexp.Regexp
+	// needles are the field names; pattern cannot match unless one of them or a bearer
+	// prefix occurs in the data.
+	needles [][]byte
+}
+
+var activeRedaction atomic.Pointer[redactionRules]
+
+func init() {
+	SetLogRedaction(false, nil, nil)
+}
+
+// SetLogRedaction configures how credentials are masked in request logs and debug output.
+// When full is true secrets are replaced with RedactedPlaceholder instead of being partially shown.
+// Extra headers are matched as case-insensitive substrings; extra fields are exact JSON/form keys.
+func SetLogRedaction(full bool, extraHeaders, extraFields []string) {
+	headers := append([]string(nil), defaultRedactedHeaders...)
+	for _, header := range extraHeaders {
+		if header = strings.ToLower(strings.TrimSpace(header)); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	fields := append([]string(nil), defaultRedactedFields...)
+	for _, field := range extraFields {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	quoted := make([]string, 0, len(fields))
+	needles := make([][]byte, 0, len(fields)+2)
+	for _, field := range fields {
+		quoted = append(quoted, regexp.QuoteMeta(field))
+		needles = append(needles, []byte(field))
+	}
+	names := strings.Join(quoted, "|")
+	activeRedaction.Store(&redactionRules{
+		full:    full,
+		headers: headers,
+		pattern: regexp.MustCompile(`("(?:` + names + `)"\s*:\s*")((?:[^"\\]|\\.)*)(")` +
+			`|(\b(?:` + names + `)=)([^&\s"]+)` +
+			`|` + bearerAlternative),
+		needles: needles,
+	})
+}
+
+// RedactSecret masks a single credential according to the active redaction mode.
+func RedactSecret(value string) string {
+	if value == "" {
+		return value
+	}
+	if rules := activeRedaction.Load(); rules != nil && rules.full {
+		return RedactedPlaceholder
+	}
+	return HideAPIKey(value)
+}
+
+// isRedactedHeader reports whether a header matches one of the configured extra header names.
+func isRedactedHeader(lowerKey string) bool {
+	rules := activeRedaction.Load()
+	if rules == nil {
+		return false
+	}
+	for _, header := range rules.headers {
+		if strings.Contains(lowerKey, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactSensitiveText masks credential values embedded in JSON or form-encoded payloads
+// as well as bearer tokens appearing in free text. Data without any candidate field name
+// or bearer prefix is returned unchanged without running the pattern.
+func RedactSensitiveText(data []byte) []byte {
+	rules := activeRedaction.Load()
+	if rules == nil || len(data) == 0 || !rules.mayMatch(data) {
+		return data
+	}
+	matches := rules.pattern.FindAllSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	last := 0
+	for _, m := range matches {
+		// Find the alternative that matched: groups 1-3 (JSON), 4-5 (form), 6-7 (bearer).
+		prefix, secret, suffix := 2, 4, 6
+		switch {
+		case m[2] >= 0:
+		case m[8] >= 0:
+			prefix, secret, suffix = 8, 10, -1
+		default:
+			prefix, secret, suffix = 12, 14, -1
+		}
+		out = append(out, data[last:m[prefix]]...)
+		out = append(out, data[m[prefix]:m[prefix+1]]...)
+		out = append(out, RedactSecret(string(data[m[secret]:m[secret+1]]))...)
+		if suffix >= 0 {
+			out = append(out, data[m[suffix]:m[suffix+1]]...)
+		}
+		last = m[1]
+	}
+	return append(out, data[last:]...)
+}
+
+var bearerWord = []byte("bearer")
+
+func (r *redactionRules) mayMatch(data []byte) bool {
+	for _, needle := range r.needles {
+		if bytes.Contains(data, needle) {
+			return true
+		}
+	}
+	for i := 0; i+len(bearerWord) <= len(data); i++ {
+		if data[i]|0x20 == 'b' && bytes.EqualFold(data[i:i+len(bearerWord)], bearerWord) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package util
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Tool choice modes shared by all request formats.
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+// ToolChoice is a format-neutral view of a request's tool selection policy. It is parsed from
+// OpenAI Chat Completions, OpenAI Responses, Claude or Gemini requests and rendered back into
+// any of those formats so translators map tool_choice the same way in every direction.
+type ToolChoice struct {
+	// Mode is ToolChoiceAuto, ToolChoiceNone or ToolChoiceRequired.
+	Mode string
+	// Names restricts the functions the model may call. A single name with ToolChoiceRequired
+	// forces that function.
+	Names []string
+}
+
+// ParseToolChoice reads an OpenAI (Chat Completions or Responses) or Claude tool_choice value.
+// It reports false when the value is absent or selects something other than functions, such as
+// a built-in tool.
+func ParseToolChoice(choice gjson.Result) (ToolChoice, bool) {
+	switch choice.Type {
+	case gjson.String:
+		mode, ok := normalizeToolChoiceMode(choice.String())
+		return ToolChoice{Mode: mode}, ok
+	case gjson.JSON:
+		if !choice.IsObject() {
+			return ToolChoice{}, false
+		}
+	default:
+		return ToolChoice{}, false
+	}
+
+	switch typ := strings.ToLower(choice.Get("type").String()); typ {
+	case "function", "tool":
+		// Chat Completions nests the name under function, Responses and Claude do not.
+		name := choice.Get("function.name").String()
+		if name == "" {
+			name = choice.Get("name").String()
+		}
+		if name == "" {
+			return ToolChoice{Mode: ToolChoiceRequired}, true
+		}
+		return ToolChoice{Mode: ToolChoiceRequired, Names: []string{name}}, true
+	case "allowed_tools":
+		// Chat Completions nests the policy under allowed_tools, Responses does not.
+		policy := choice.Get("allowed_tools")
+		if !policy.Exists() {
+			policy = choice
+		}
+		mode, ok := normalizeToolChoiceMode(policy.Get("mode").String())
+		if !ok {
+			mode = ToolChoiceAuto
+		}
+		out := ToolChoice{Mode: mode}
+		policy.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+			name := tool.Get("function.name").String()
+			if name == "" {
+				name = tool.Get("name").String()
+			}
+			if name != "" {
+				out.Names = append(out.Names, name)
+			}
+			return true
+		})
+		return out, true
+	default:
+		mode, ok := normalizeToolChoiceMode(typ)
+		return ToolChoice{Mode: mode}, ok
+	}
+}
+
+// ParseGeminiToolConfig reads the function calling config of a Gemini request body, accepting
+// both camelCase and snake_case field names.
+func ParseGeminiToolConfig(request gjson.Result) (ToolChoice, bool) {
+	cfg := request.Get("toolConfig.functionCallingConfig")
+	if !cfg.Exists() {
+		cfg = request.Get("tool_config.function_calling_config")
+	}
+	if !cfg.Exists() {
+		return ToolChoice{}, false
+	}
+	var out ToolChoice
+	switch strings.ToUpper(cfg.Get("mode").String()) {
+	case "NONE":
+		out.Mode = ToolChoiceNone
+	case "ANY":
+		out.Mode = ToolChoiceRequired
+	case "AUTO", "VALIDATED", "":
+		out.Mode = ToolChoiceAuto
+	default:
+		return ToolChoice{}, false
+	}
+	names := cfg.Get("allowedFunctionNames")
+	if !names.Exists() {
+		names = cfg.Get("allowed_function_names")
+	}
+	for _, name := range names.Array() {
+		if n := name.String(); n != "" {
+			out.Names = append(out.Names, n)
+		}
+	}
+	return out, true
+}
+
+// OpenAI renders the choice as a Chat Completions tool_choice value.
+func (c ToolChoice) OpenAI() string {
+	if c.Mode == ToolChoiceNone || len(c.Names) == 0 {
+		return quoteMode(c.Mode)
+	}
+	if c.Mode == ToolChoiceRequired && len(c.Names) == 1 {
+		out, _ := sjson.Set(`{"type":"function","function":{"name":""}}`, "function.name", c.Names[0])
+		return out
+	}
+	out, _ := sjson.Set(`{"type":"allowed_tools","allowed_tools":{"mode":"","tools":[]}}`, "allowed_tools.mode", c.Mode)
+	for _, name := range c.Names {
+		tool, _ := sjson.Set(`{"type":"function","function":{"name":""}}`, "function.name", name)
+		out, _ = sjson.SetRaw(out, "allowed_tools.tools.-1", tool)
+	}
+	return out
+}
+
+// OpenAIResponses renders the choice as a Responses API tool_choice value.
+func (c ToolChoice) OpenAIResponses() string {
+	if c.Mode == ToolChoiceNone || len(c.Names) == 0 {
+		return quoteMode(c.Mode)
+	}
+	if c.Mode == ToolChoiceRequired && len(c.Names) == 1 {
+		out, _ := sjson.Set(`{"type":"function","name":""}`, "name", c.Names[0])
+		return out
+	}
+	out, _ := sjson.Set(`{"type":"allowed_tools","mode":"","tools":[]}`, "mode", c.Mode)
+	for _, name := range c.Names {
+		tool, _ := sjson.Set(`{"type":"function","name":""}`, "name", name)
+		out, _ = sjson.SetRaw(out, "tools.-1", tool)
+	}
+	return out
+}
+
+// Claude renders the choice as a Claude Messages tool_choice object. Claude cannot restrict the
+// model to a subset of several tools, so such choices fall back to the plain mode.
+func (c ToolChoice) Claude() string {
+	switch c.Mode {
+	case ToolChoiceNone:
+		return `{"type":"none"}`
+	case ToolChoiceRequired:
+		if len(c.Names) == 1 {
+			out, _ := sjson.Set(`{"type":"tool","name":""}`, "name", c.Names[0])
+			return out
+		}
+		return `{"type":"any"}`
+	default:
+		return `{"type":"auto"}`
+	}
+}
+
+// Gemini renders the choice as a Gemini toolConfig object. Allowed function names are only
+// honored by Gemini in ANY mode and are dropped otherwise.
+func (c ToolChoice) Gemini() string {
+	out := `{"functionCallingConfig":{"mode":"AUTO"}}`
+	switch c.Mode {
+	case ToolChoiceNone:
+		out, _ = sjson.Set(out, "functionCallingConfig.mode", "NONE")
+	case ToolChoiceRequired:
+		out, _ = sjson.Set(out, "functionCallingConfig.mode", "ANY")
+		if len(c.Names) > 0 {
+			out, _ = sjson.Set(out, "functionCallingConfig.allowedFunctionNames", c.Names)
+		}
+	}
+	return out
+}
+
+func normalizeToolChoiceMode(mode string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "auto":
+		return ToolChoiceAuto, true
+	case "none":
+		return ToolChoiceNone, true
+	case "required", "any":
+		return ToolChoiceRequired, true
+	default:
+		return "", false
+	}
+}
+
+func quoteMode(mode string) string {
+	if mode == "" {
+		mode = ToolChoiceAuto
+	}
+	return `"` + mode + `"`
+}
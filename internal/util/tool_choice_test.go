@@ -0,0 +1,105 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestParseToolChoice(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantOK    bool
+		wantMode  string
+		wantNames []string
+	}{
+		{"openai auto", `"auto"`, true, ToolChoiceAuto, nil},
+		{"openai none", `"none"`, true, ToolChoiceNone, nil},
+		{"openai required", `"required"`, true, ToolChoiceRequired, nil},
+		{"chat function", `{"type":"function","function":{"name":"get_weather"}}`, true, ToolChoiceRequired, []string{"get_weather"}},
+		{"responses function", `{"type":"function","name":"get_weather"}`, true, ToolChoiceRequired, []string{"get_weather"}},
+		{"chat allowed tools", `{"type":"allowed_tools","allowed_tools":{"mode":"required","tools":[{"type":"function","function":{"name":"a"}},{"type":"function","function":{"name":"b"}}]}}`, true, ToolChoiceRequired, []string{"a", "b"}},
+		{"responses allowed tools", `{"type":"allowed_tools","mode":"auto","tools":[{"type":"function","name":"a"}]}`, true, ToolChoiceAuto, []string{"a"}},
+		{"claude auto", `{"type":"auto"}`, true, ToolChoiceAuto, nil},
+		{"claude any", `{"type":"any"}`, true, ToolChoiceRequired, nil},
+		{"claude none", `{"type":"none"}`, true, ToolChoiceNone, nil},
+		{"claude tool", `{"type":"tool","name":"search"}`, true, ToolChoiceRequired, []string{"search"}},
+		{"built-in tool", `{"type":"web_search"}`, false, "", nil},
+		{"unknown string", `"sometimes"`, false, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseToolChoice(gjson.Parse(tt.input))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %t, want %t", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Mode != tt.wantMode || !equalStrings(got.Names, tt.wantNames) {
+				t.Fatalf("got %+v, want mode %q names %v", got, tt.wantMode, tt.wantNames)
+			}
+		})
+	}
+	if _, ok := ParseToolChoice(gjson.Result{}); ok {
+		t.Fatal("missing tool_choice should not parse")
+	}
+}
+
+func TestParseGeminiToolConfig(t *testing.T) {
+	got, ok := ParseGeminiToolConfig(gjson.Parse(`{"toolConfig":{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["search"]}}}`))
+	if !ok || got.Mode != ToolChoiceRequired || !equalStrings(got.Names, []string{"search"}) {
+		t.Fatalf("camelCase: got %+v ok=%t", got, ok)
+	}
+	got, ok = ParseGeminiToolConfig(gjson.Parse(`{"tool_config":{"function_calling_config":{"mode":"NONE"}}}`))
+	if !ok || got.Mode != ToolChoiceNone {
+		t.Fatalf("snake_case: got %+v ok=%t", got, ok)
+	}
+	got, ok = ParseGeminiToolConfig(gjson.Parse(`{"toolConfig":{"functionCallingConfig":{"mode":"VALIDATED"}}}`))
+	if !ok || got.Mode != ToolChoiceAuto {
+		t.Fatalf("validated: got %+v ok=%t", got, ok)
+	}
+	if _, ok = ParseGeminiToolConfig(gjson.Parse(`{}`)); ok {
+		t.Fatal("missing toolConfig should not parse")
+	}
+}
+
+func TestToolChoiceRender(t *testing.T) {
+	forced := ToolChoice{Mode: ToolChoiceRequired, Names: []string{"search"}}
+	subset := ToolChoice{Mode: ToolChoiceRequired, Names: []string{"a", "b"}}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"openai auto", ToolChoice{Mode: ToolChoiceAuto}.OpenAI(), `"auto"`},
+		{"openai forced", forced.OpenAI(), `{"type":"function","function":{"name":"search"}}`},
+		{"openai subset", subset.OpenAI(), `{"type":"allowed_tools","allowed_tools":{"mode":"required","tools":[{"type":"function","function":{"name":"a"}},{"type":"function","function":{"name":"b"}}]}}`},
+		{"responses none", ToolChoice{Mode: ToolChoiceNone, Names: []string{"a"}}.OpenAIResponses(), `"none"`},
+		{"responses forced", forced.OpenAIResponses(), `{"type":"function","name":"search"}`},
+		{"claude none", ToolChoice{Mode: ToolChoiceNone}.Claude(), `{"type":"none"}`},
+		{"claude forced", forced.Claude(), `{"type":"tool","name":"search"}`},
+		{"claude subset", subset.Claude(), `{"type":"any"}`},
+		{"gemini auto drops names", ToolChoice{Mode: ToolChoiceAuto, Names: []string{"a"}}.Gemini(), `{"functionCallingConfig":{"mode":"AUTO"}}`},
+		{"gemini forced", forced.Gemini(), `{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["search"]}}`},
+		{"gemini none", ToolChoice{Mode: ToolChoiceNone}.Gemini(), `{"functionCallingConfig":{"mode":"NONE"}}`},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
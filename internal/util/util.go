@@ -56,19 +56,13 @@ func SanitizeFunctionName(name string) string {
 
 // SetLogLevel configures the logrus log level based on the configuration.
 // It sets the log level to DebugLevel if debug mode is enabled, otherwise to InfoLevel.
+// A runtime override set through SetLogLevelOverride takes precedence until cleared.
 func SetLogLevel(cfg *config.Config) {
-	currentLevel := log.GetLevel()
-	var newLevel log.Level
+	newLevel := log.InfoLevel
 	if cfg.Debug {
 		newLevel = log.DebugLevel
-	} else {
-		newLevel = log.InfoLevel
-	}
-
-	if currentLevel != newLevel {
-		log.SetLevel(newLevel)
-		log.Infof("log level changed from %s to %s (debug=%t)", currentLevel, newLevel, cfg.Debug)
 	}
+	setConfigLogLevel(newLevel)
 }
 
 // ResolveAuthDir normalizes the auth directory path for consistent reuse throughout the app.
@@ -21,6 +21,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Port != newCfg.Port {
 		changes = append(changes, fmt.Sprintf("port: %d -> %d", oldCfg.Port, newCfg.Port))
 	}
+	if !reflect.DeepEqual(oldCfg.Listeners, newCfg.Listeners) {
+		changes = append(changes, "listeners: updated")
+	}
 	if oldCfg.AuthDir != newCfg.AuthDir {
 		changes = append(changes, fmt.Sprintf("auth-dir: %s -> %s", oldCfg.AuthDir, newCfg.AuthDir))
 	}
@@ -45,9 +48,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.RequestLog != newCfg.RequestLog {
 		changes = append(changes, fmt.Sprintf("request-log: %t -> %t", oldCfg.RequestLog, newCfg.RequestLog))
 	}
+	if !reflect.DeepEqual(oldCfg.RequestLogLevels, newCfg.RequestLogLevels) {
+		changes = append(changes, "request-log-levels: updated")
+	}
+	if oldCfg.RequestLogSampling != newCfg.RequestLogSampling {
+		changes = append(changes, fmt.Sprintf("request-log-sampling: %+v -> %+v", oldCfg.RequestLogSampling, newCfg.RequestLogSampling))
+	}
 	if oldCfg.LogsMaxTotalSizeMB != newCfg.LogsMaxTotalSizeMB {
 		changes = append(changes, fmt.Sprintf("logs-max-total-size-mb: %d -> %d", oldCfg.LogsMaxTotalSizeMB, newCfg.LogsMaxTotalSizeMB))
 	}
+	if !reflect.DeepEqual(oldCfg.LogRedaction, newCfg.LogRedaction) {
+		changes = append(changes, "log-redaction: updated")
+	}
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
@@ -66,6 +78,19 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ForceModelPrefix != newCfg.ForceModelPrefix {
 		changes = append(changes, fmt.Sprintf("force-model-prefix: %t -> %t", oldCfg.ForceModelPrefix, newCfg.ForceModelPrefix))
 	}
+	if oldCfg.SystemPromptDedup != newCfg.SystemPromptDedup {
+		changes = append(changes, fmt.Sprintf("system-prompt-dedup: enabled=%t ttl-minutes=%d", newCfg.SystemPromptDedup.Enabled, newCfg.SystemPromptDedup.TTLMinutes))
+	}
+	if !reflect.DeepEqual(oldCfg.Streaming.Coalesce, newCfg.Streaming.Coalesce) {
+		coalesce := newCfg.Streaming.Coalesce
+		changes = append(changes, fmt.Sprintf("streaming.coalesce: flush-interval-ms=%d flush-bytes=%d key-overrides=%d", coalesce.FlushIntervalMS, coalesce.FlushBytes, len(coalesce.Keys)))
+	}
+	if oldCfg.OutputBudget != newCfg.OutputBudget {
+		changes = append(changes, fmt.Sprintf("output-budget: enabled=%t max-tokens=%d ttl-minutes=%d", newCfg.OutputBudget.Enabled, newCfg.OutputBudget.MaxTokens, newCfg.OutputBudget.TTLMinutes))
+	}
+	if !reflect.DeepEqual(trimStrings(oldCfg.StrictKeys), trimStrings(newCfg.StrictKeys)) {
+		changes = append(changes, fmt.Sprintf("strict-keys count: %d -> %d", len(oldCfg.StrictKeys), len(newCfg.StrictKeys)))
+	}
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
@@ -78,9 +103,36 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-preview-model: %t -> %t", oldCfg.QuotaExceeded.SwitchPreviewModel, newCfg.QuotaExceeded.SwitchPreviewModel))
 	}
 
+	if !reflect.DeepEqual(oldCfg.LoadShedding, newCfg.LoadShedding) {
+		changes = append(changes, "load-shedding: updated")
+	}
+	if oldCfg.StandbyPrewarm != newCfg.StandbyPrewarm {
+		changes = append(changes, fmt.Sprintf("standby-prewarm: enabled=%t error-rate=%g min-requests=%d interval-seconds=%d", newCfg.StandbyPrewarm.Enabled, newCfg.StandbyPrewarm.ErrorRate, newCfg.StandbyPrewarm.MinRequests, newCfg.StandbyPrewarm.IntervalSeconds))
+	}
+	if !reflect.DeepEqual(oldCfg.AuthGC, newCfg.AuthGC) {
+		changes = append(changes, "auth-gc: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Backup, newCfg.Backup) {
+		changes = append(changes, "backup: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Timeouts, newCfg.Timeouts) {
+		changes = append(changes, "timeouts: updated")
+	}
+	if oldCfg.DNSCache != newCfg.DNSCache {
+		changes = append(changes, fmt.Sprintf("dns-cache: enabled=%t ttl-seconds=%d stale-seconds=%d", newCfg.DNSCache.Enabled, newCfg.DNSCache.TTLSeconds, newCfg.DNSCache.StaleSeconds))
+	}
+	if oldCfg.UpstreamRetry != newCfg.UpstreamRetry {
+		changes = append(changes, fmt.Sprintf("upstream-retry: max-attempts=%d initial-backoff-ms=%d max-backoff-ms=%d", newCfg.UpstreamRetry.MaxAttempts, newCfg.UpstreamRetry.InitialBackoffMs, newCfg.UpstreamRetry.MaxBackoffMs))
+	}
+	if !reflect.DeepEqual(oldCfg.UpstreamHosts, newCfg.UpstreamHosts) {
+		changes = append(changes, "upstream-hosts: updated")
+	}
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.Routing.DecisionLog != newCfg.Routing.DecisionLog {
+		changes = append(changes, fmt.Sprintf("routing.decision-log: enabled=%t sample-rate=%g buffer=%d", newCfg.Routing.DecisionLog.Enabled, newCfg.Routing.DecisionLog.SampleRate, newCfg.Routing.DecisionLog.Buffer))
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
@@ -168,6 +220,111 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Bedrock credentials (do not print key material)
+	if len(oldCfg.BedrockKey) != len(newCfg.BedrockKey) {
+		changes = append(changes, fmt.Sprintf("bedrock-api-key count: %d -> %d", len(oldCfg.BedrockKey), len(newCfg.BedrockKey)))
+	} else {
+		for i := range oldCfg.BedrockKey {
+			o := oldCfg.BedrockKey[i]
+			n := newCfg.BedrockKey[i]
+			if strings.TrimSpace(o.Region) != strings.TrimSpace(n.Region) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].region: %s -> %s", i, strings.TrimSpace(o.Region), strings.TrimSpace(n.Region)))
+			}
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.AccessKeyID != n.AccessKeyID || o.SecretAccessKey != n.SecretAccessKey || o.SessionToken != n.SessionToken || o.APIKey != n.APIKey {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].credentials: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].headers: updated", i))
+			}
+			if ComputeBedrockModelsHash(o.Models) != ComputeBedrockModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// Azure OpenAI credentials (do not print key material)
+	if len(oldCfg.AzureOpenAIKey) != len(newCfg.AzureOpenAIKey) {
+		changes = append(changes, fmt.Sprintf("azure-openai-api-key count: %d -> %d", len(oldCfg.AzureOpenAIKey), len(newCfg.AzureOpenAIKey)))
+	} else {
+		for i := range oldCfg.AzureOpenAIKey {
+			o := oldCfg.AzureOpenAIKey[i]
+			n := newCfg.AzureOpenAIKey[i]
+			if strings.TrimSpace(o.Endpoint) != strings.TrimSpace(n.Endpoint) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].endpoint: %s -> %s", i, strings.TrimSpace(o.Endpoint), strings.TrimSpace(n.Endpoint)))
+			}
+			if strings.TrimSpace(o.APIVersion) != strings.TrimSpace(n.APIVersion) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].api-version: %s -> %s", i, strings.TrimSpace(o.APIVersion), strings.TrimSpace(n.APIVersion)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.APIKey != n.APIKey || o.TenantID != n.TenantID || o.ClientID != n.ClientID || o.ClientSecret != n.ClientSecret || o.AuthorityHost != n.AuthorityHost {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].credentials: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].headers: updated", i))
+			}
+			if ComputeAzureOpenAIDeploymentsHash(o.Deployments) != ComputeAzureOpenAIDeploymentsHash(n.Deployments) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].deployments: updated (%d -> %d entries)", i, len(o.Deployments), len(n.Deployments)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// OpenRouter keys (do not print key material)
+	if len(oldCfg.OpenRouterKey) != len(newCfg.OpenRouterKey) {
+		changes = append(changes, fmt.Sprintf("openrouter-api-key count: %d -> %d", len(oldCfg.OpenRouterKey), len(newCfg.OpenRouterKey)))
+	} else {
+		for i := range oldCfg.OpenRouterKey {
+			o := oldCfg.OpenRouterKey[i]
+			n := newCfg.OpenRouterKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].api-key: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].headers: updated", i))
+			}
+			if ComputeOpenRouterModelsHash(o.Models) != ComputeOpenRouterModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
 	// Codex keys (do not print key material)
 	if len(oldCfg.CodexKey) != len(newCfg.CodexKey) {
 		changes = append(changes, fmt.Sprintf("codex-api-key count: %d -> %d", len(oldCfg.CodexKey), len(newCfg.CodexKey)))
@@ -56,6 +56,51 @@ func ComputeClaudeModelsHash(models []config.ClaudeModel) string {
 	return hashJoined(keys)
 }
 
+// ComputeBedrockModelsHash returns a stable hash for Bedrock model aliases.
+func ComputeBedrockModelsHash(models []config.BedrockModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeAzureOpenAIDeploymentsHash returns a stable hash for Azure OpenAI deployment mappings.
+func ComputeAzureOpenAIDeploymentsHash(deployments []config.AzureOpenAIDeployment) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, deployment := range deployments {
+			name := strings.TrimSpace(deployment.Name)
+			alias := strings.TrimSpace(deployment.GetAlias())
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeOpenRouterModelsHash returns a stable hash for OpenRouter model aliases.
+func ComputeOpenRouterModelsHash(models []config.OpenRouterModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
 // ComputeCodexModelsHash returns a stable hash for Codex model aliases.
 func ComputeCodexModelsHash(models []config.CodexModel) string {
 	keys := normalizeModelPairs(func(out func(key string)) {
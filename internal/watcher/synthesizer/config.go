@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
@@ -29,6 +30,12 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeGeminiKeys(ctx)...)
 	// Claude API Keys
 	out = append(out, s.synthesizeClaudeKeys(ctx)...)
+	// Bedrock credentials
+	out = append(out, s.synthesizeBedrockKeys(ctx)...)
+	// Azure OpenAI credentials
+	out = append(out, s.synthesizeAzureOpenAIKeys(ctx)...)
+	// OpenRouter API Keys
+	out = append(out, s.synthesizeOpenRouterKeys(ctx)...)
 	// Codex API Keys
 	out = append(out, s.synthesizeCodexKeys(ctx)...)
 	// OpenAI-compat
@@ -135,6 +142,166 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 	return out
 }
 
+// synthesizeBedrockKeys creates Auth entries for AWS Bedrock credentials.
+func (s *ConfigSynthesizer) synthesizeBedrockKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		bk := cfg.BedrockKey[i]
+		identity := strings.TrimSpace(bk.GetAPIKey())
+		region := strings.TrimSpace(bk.Region)
+		if identity == "" || region == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(bk.Prefix)
+		base := strings.TrimSpace(bk.BaseURL)
+		id, token := idGen.Next("bedrock:apikey", identity, region, base)
+		attrs := map[string]string{
+			"source":  fmt.Sprintf("config:bedrock[%s]", token),
+			"api_key": identity,
+			"region":  region,
+		}
+		if bk.APIKey == "" {
+			attrs["access_key_id"] = bk.AccessKeyID
+			attrs["secret_access_key"] = bk.SecretAccessKey
+			if bk.SessionToken != "" {
+				attrs["session_token"] = bk.SessionToken
+			}
+		}
+		if bk.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(bk.Priority)
+		}
+		if base != "" {
+			attrs["base_url"] = base
+		}
+		if hash := diff.ComputeBedrockModelsHash(bk.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(bk.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "bedrock",
+			Label:      "bedrock-" + region,
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(bk.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, bk.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeAzureOpenAIKeys creates Auth entries for Azure OpenAI resources.
+func (s *ConfigSynthesizer) synthesizeAzureOpenAIKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.AzureOpenAIKey))
+	for i := range cfg.AzureOpenAIKey {
+		ak := cfg.AzureOpenAIKey[i]
+		identity := strings.TrimSpace(ak.GetAPIKey())
+		endpoint := strings.TrimSpace(ak.Endpoint)
+		if identity == "" || endpoint == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(ak.Prefix)
+		id, token := idGen.Next("azure-openai:apikey", identity, endpoint)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:azure-openai[%s]", token),
+			"api_key":  identity,
+			"base_url": endpoint,
+		}
+		if ak.APIVersion != "" {
+			attrs["api_version"] = ak.APIVersion
+		}
+		if ak.APIKey == "" {
+			attrs["tenant_id"] = ak.TenantID
+			attrs["client_id"] = ak.ClientID
+			attrs["client_secret"] = ak.ClientSecret
+			if ak.AuthorityHost != "" {
+				attrs["authority_host"] = ak.AuthorityHost
+			}
+		}
+		if ak.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(ak.Priority)
+		}
+		if hash := diff.ComputeAzureOpenAIDeploymentsHash(ak.Deployments); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(ak.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "azure-openai",
+			Label:      "azure-openai",
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(ak.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, ak.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeOpenRouterKeys creates Auth entries for OpenRouter API keys.
+func (s *ConfigSynthesizer) synthesizeOpenRouterKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.OpenRouterKey))
+	for i := range cfg.OpenRouterKey {
+		rk := cfg.OpenRouterKey[i]
+		key := strings.TrimSpace(rk.APIKey)
+		if key == "" {
+			continue
+		}
+		base := strings.TrimSpace(rk.BaseURL)
+		if base == "" {
+			base = config.DefaultOpenRouterBaseURL
+		}
+		prefix := strings.TrimSpace(rk.Prefix)
+		id, token := idGen.Next("openrouter:apikey", key, base)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:openrouter[%s]", token),
+			"api_key":  key,
+			"base_url": base,
+		}
+		if rk.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(rk.Priority)
+		}
+		if hash := diff.ComputeOpenRouterModelsHash(rk.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(rk.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "openrouter",
+			Label:      "openrouter-apikey",
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(rk.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, rk.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
 // synthesizeCodexKeys creates Auth entries for Codex API keys.
 func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreauth.Auth {
 	cfg := ctx.Config
@@ -225,6 +225,128 @@ func TestConfigSynthesizer_ClaudeKeys_SkipsEmptyAndHeaders(t *testing.T) {
 	}
 }
 
+func TestConfigSynthesizer_BedrockKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			BedrockKey: []config.BedrockKey{
+				{
+					AccessKeyID:     "AKID",
+					SecretAccessKey: "secret",
+					SessionToken:    "token",
+					Region:          "us-east-1",
+					Models:          []config.BedrockModel{{Name: "anthropic.claude-3-haiku-20240307-v1:0", Alias: "haiku"}},
+				},
+				{APIKey: "bedrock-api-key", Region: "eu-west-1"},
+				{AccessKeyID: "AKID2", SecretAccessKey: "secret"}, // no region, should be skipped
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+
+	sigv4 := auths[0]
+	if sigv4.Provider != "bedrock" || sigv4.Label != "bedrock-us-east-1" {
+		t.Errorf("unexpected provider/label %s/%s", sigv4.Provider, sigv4.Label)
+	}
+	if sigv4.Attributes["api_key"] != "AKID" || sigv4.Attributes["secret_access_key"] != "secret" || sigv4.Attributes["session_token"] != "token" {
+		t.Errorf("unexpected sigv4 attributes %v", sigv4.Attributes)
+	}
+	if _, ok := sigv4.Attributes["models_hash"]; !ok {
+		t.Error("expected models_hash in attributes")
+	}
+
+	bearer := auths[1]
+	if bearer.Attributes["api_key"] != "bedrock-api-key" || bearer.Attributes["region"] != "eu-west-1" {
+		t.Errorf("unexpected api key attributes %v", bearer.Attributes)
+	}
+	if _, ok := bearer.Attributes["secret_access_key"]; ok {
+		t.Error("api key credential should not carry a secret access key")
+	}
+}
+
+func TestConfigSynthesizer_AzureOpenAIKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			AzureOpenAIKey: []config.AzureOpenAIKey{
+				{
+					APIKey:      "resource-key",
+					Endpoint:    "https://res.openai.azure.com",
+					APIVersion:  "2024-10-21",
+					Deployments: []config.AzureOpenAIDeployment{{Name: "my-gpt4o", Model: "gpt-4o"}},
+				},
+				{TenantID: "tenant", ClientID: "client", ClientSecret: "secret", Endpoint: "https://res2.openai.azure.com"},
+				{APIKey: "no-endpoint"}, // should be skipped
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+
+	keyed := auths[0]
+	if keyed.Provider != "azure-openai" || keyed.Attributes["api_key"] != "resource-key" || keyed.Attributes["api_version"] != "2024-10-21" {
+		t.Errorf("unexpected api key auth %s %v", keyed.Provider, keyed.Attributes)
+	}
+	if _, ok := keyed.Attributes["models_hash"]; !ok {
+		t.Error("expected models_hash in attributes")
+	}
+
+	app := auths[1]
+	if app.Attributes["api_key"] != "client" || app.Attributes["tenant_id"] != "tenant" || app.Attributes["client_secret"] != "secret" {
+		t.Errorf("unexpected azure ad attributes %v", app.Attributes)
+	}
+}
+
+func TestConfigSynthesizer_OpenRouterKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			OpenRouterKey: []config.OpenRouterKey{
+				{APIKey: "sk-or-1", Priority: 2, Models: []config.OpenRouterModel{{Name: "anthropic/claude-sonnet-4.5", Alias: "sonnet"}}},
+				{APIKey: "sk-or-2", BaseURL: "https://or.example.com/api/v1"},
+				{APIKey: ""}, // should be skipped
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+	first := auths[0]
+	if first.Provider != "openrouter" || first.Attributes["base_url"] != config.DefaultOpenRouterBaseURL || first.Attributes["priority"] != "2" {
+		t.Errorf("unexpected auth %s %v", first.Provider, first.Attributes)
+	}
+	if _, ok := first.Attributes["models_hash"]; !ok {
+		t.Error("expected models_hash in attributes")
+	}
+	if auths[1].Attributes["base_url"] != "https://or.example.com/api/v1" {
+		t.Errorf("base_url = %q", auths[1].Attributes["base_url"])
+	}
+}
+
 func TestConfigSynthesizer_CodexKeys(t *testing.T) {
 	synth := NewConfigSynthesizer()
 	ctx := &SynthesisContext{
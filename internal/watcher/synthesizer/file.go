@@ -90,6 +90,8 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 		status := coreauth.StatusActive
 		if disabled {
 			status = coreauth.StatusDisabled
+		} else if _, ok := metadata[coreauth.MetadataNeedsRelogin]; ok {
+			status = coreauth.StatusNeedsRelogin
 		}
 
 		a := &coreauth.Auth{
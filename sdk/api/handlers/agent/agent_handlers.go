@@ -0,0 +1,288 @@
+// Package agent provides the agent mode endpoint, where the proxy drives the tool-use loop
+// itself: it calls the model, runs the tool calls it asks for against tools registered on
+// the server, feeds the results back and returns only the final answer with a trace.
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultMaxTurns    = 8
+	defaultToolTimeout = 30 * time.Second
+)
+
+// Agent request statuses.
+const (
+	StatusCompleted = "completed"
+	// StatusMaxTurns means the model still asked for tools when the turn limit was reached.
+	StatusMaxTurns = "max_turns"
+)
+
+// AgentAPIHandler serves /v1/agent/completions on top of the OpenAI chat completions path.
+type AgentAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewAgentAPIHandler creates a new agent mode handler.
+func NewAgentAPIHandler(apiHandlers *handlers.BaseAPIHandler) *AgentAPIHandler {
+	return &AgentAPIHandler{BaseAPIHandler: apiHandlers}
+}
+
+// HandlerType returns the identifier for this handler implementation. Agent requests use
+// the OpenAI chat completions format.
+func (h *AgentAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *AgentAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// TraceEntry records one tool call made during an agent request.
+type TraceEntry struct {
+	Turn       int             `json:"turn"`
+	ToolCallID string          `json:"tool_call_id"`
+	Name       string          `json:"name"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Output     string          `json:"output,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMS int64           `json:"duration_ms"`
+}
+
+// Usage sums the token usage of every model call in an agent request.
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// Response is the body returned by the agent endpoint.
+type Response struct {
+	ID      string          `json:"id"`
+	Object  string          `json:"object"`
+	Created int64           `json:"created"`
+	Model   string          `json:"model"`
+	Status  string          `json:"status"`
+	Turns   int             `json:"turns"`
+	Message json.RawMessage `json:"message"`
+	Trace   []TraceEntry    `json:"trace"`
+	Usage   Usage           `json:"usage"`
+}
+
+// Completions handles POST /v1/agent/completions. The body is an OpenAI chat completion
+// request whose tools name registered server-side tools; their descriptions and schemas
+// are filled in from the registry. "max_turns" optionally lowers the configured turn limit.
+func (h *AgentAPIHandler) Completions(c *gin.Context) {
+	var cfg struct {
+		enabled     bool
+		maxTurns    int
+		toolTimeout time.Duration
+	}
+	if h.Cfg != nil {
+		cfg.enabled = h.Cfg.AgentMode.Enabled
+		cfg.maxTurns = h.Cfg.AgentMode.MaxTurns
+		cfg.toolTimeout = time.Duration(h.Cfg.AgentMode.ToolTimeoutSeconds) * time.Second
+	}
+	if !cfg.enabled {
+		writeError(c, http.StatusNotFound, "agent mode is disabled", "invalid_request_error")
+		return
+	}
+	if cfg.maxTurns <= 0 {
+		cfg.maxTurns = defaultMaxTurns
+	}
+	if cfg.toolTimeout <= 0 {
+		cfg.toolTimeout = defaultToolTimeout
+	}
+
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "invalid_request_error")
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) || !gjson.GetBytes(rawJSON, "messages").IsArray() {
+		writeError(c, http.StatusBadRequest, "Invalid request: messages array is required", "invalid_request_error")
+		return
+	}
+	if gjson.GetBytes(rawJSON, "stream").Bool() {
+		writeError(c, http.StatusBadRequest, "Invalid request: agent mode does not support streaming", "invalid_request_error")
+		return
+	}
+	if n := gjson.GetBytes(rawJSON, "max_turns").Int(); n > 0 && int(n) < cfg.maxTurns {
+		cfg.maxTurns = int(n)
+	}
+	body, errTools := prepareRequest(rawJSON)
+	if errTools != nil {
+		writeError(c, http.StatusBadRequest, "Invalid request: "+errTools.Error(), "invalid_request_error")
+		return
+	}
+
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	result, errMsg := h.run(cliCtx, modelName, body, cfg.maxTurns, cfg.toolTimeout)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+	cliCancel()
+}
+
+// run drives the tool loop until the model answers without tool calls or maxTurns is hit.
+func (h *AgentAPIHandler) run(ctx context.Context, modelName string, body []byte, maxTurns int, toolTimeout time.Duration) (*Response, *interfaces.ErrorMessage) {
+	result := &Response{
+		ID:      newResponseID(),
+		Object:  "agent.completion",
+		Created: time.Now().Unix(),
+		Model:   modelName,
+		Status:  StatusMaxTurns,
+		Trace:   []TraceEntry{},
+	}
+	for turn := 1; turn <= maxTurns; turn++ {
+		resp, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, body, "")
+		if errMsg != nil {
+			return nil, errMsg
+		}
+		result.Turns = turn
+		usage := gjson.GetBytes(resp, "usage")
+		result.Usage.PromptTokens += usage.Get("prompt_tokens").Int()
+		result.Usage.CompletionTokens += usage.Get("completion_tokens").Int()
+		result.Usage.TotalTokens += usage.Get("total_tokens").Int()
+
+		message := gjson.GetBytes(resp, "choices.0.message")
+		if !message.Exists() {
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errors.New("agent: upstream response has no message")}
+		}
+		result.Message = json.RawMessage(message.Raw)
+		toolCalls := message.Get("tool_calls").Array()
+		if len(toolCalls) == 0 {
+			result.Status = StatusCompleted
+			return result, nil
+		}
+
+		body, _ = sjson.SetRawBytes(body, "messages.-1", []byte(message.Raw))
+		for _, call := range toolCalls {
+			entry := runTool(ctx, call, turn, toolTimeout)
+			result.Trace = append(result.Trace, entry)
+			content := entry.Output
+			if entry.Error != "" {
+				content = "error: " + entry.Error
+			}
+			toolMessage, _ := json.Marshal(map[string]string{
+				"role":         "tool",
+				"tool_call_id": entry.ToolCallID,
+				"content":      content,
+			})
+			body, _ = sjson.SetRawBytes(body, "messages.-1", toolMessage)
+		}
+	}
+	return result, nil
+}
+
+// runTool executes one tool call of the model and records it in a trace entry.
+func runTool(ctx context.Context, call gjson.Result, turn int, timeout time.Duration) TraceEntry {
+	entry := TraceEntry{
+		Turn:       turn,
+		ToolCallID: call.Get("id").String(),
+		Name:       call.Get("function.name").String(),
+	}
+	arguments := call.Get("function.arguments").String()
+	if arguments == "" {
+		arguments = "{}"
+	}
+	if json.Valid([]byte(arguments)) {
+		entry.Arguments = json.RawMessage(arguments)
+	} else {
+		entry.Arguments, _ = json.Marshal(arguments)
+	}
+
+	tool, ok := LookupTool(entry.Name)
+	if !ok {
+		entry.Error = fmt.Sprintf("unknown tool %q", entry.Name)
+		return entry
+	}
+	toolCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	output, err := tool.Call(toolCtx, json.RawMessage(arguments))
+	entry.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Output = output
+	return entry
+}
+
+// prepareRequest replaces the declared tools with the registered definitions and strips the
+// agent-only fields so the body is a plain chat completion request.
+func prepareRequest(rawJSON []byte) ([]byte, error) {
+	body, _ := sjson.DeleteBytes(rawJSON, "max_turns")
+	body, _ = sjson.SetBytes(body, "stream", false)
+	declared := gjson.GetBytes(rawJSON, "tools")
+	if !declared.Exists() {
+		return body, nil
+	}
+	if !declared.IsArray() {
+		return nil, errors.New("tools must be an array")
+	}
+	definitions := make([]map[string]any, 0, len(declared.Array()))
+	for _, decl := range declared.Array() {
+		name := decl.Get("function.name").String()
+		if name == "" {
+			name = decl.Get("name").String()
+		}
+		tool, ok := LookupTool(name)
+		if !ok {
+			return nil, fmt.Errorf("tool %q is not registered on the server", name)
+		}
+		function := map[string]any{"name": tool.Name}
+		if tool.Description != "" {
+			function["description"] = tool.Description
+		}
+		if len(tool.Parameters) > 0 {
+			function["parameters"] = tool.Parameters
+		} else {
+			function["parameters"] = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		definitions = append(definitions, map[string]any{"type": "function", "function": function})
+	}
+	raw, err := json.Marshal(definitions)
+	if err != nil {
+		return nil, err
+	}
+	body, _ = sjson.SetRawBytes(body, "tools", raw)
+	return body, nil
+}
+
+func newResponseID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "agent-" + hex.EncodeToString(b[:])
+}
+
+func writeError(c *gin.Context, status int, message, errType string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
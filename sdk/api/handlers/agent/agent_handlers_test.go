@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// toolLoopExecutor asks for the echo tool on the first call and answers with the tool
+// result once it appears in the conversation.
+type toolLoopExecutor struct {
+	payloads [][]byte
+}
+
+func (e *toolLoopExecutor) Identifier() string { return "agent-test-provider" }
+
+func (e *toolLoopExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, req.Payload)
+	usage := `"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}`
+	last := gjson.GetBytes(req.Payload, "messages.@reverse.0")
+	if last.Get("role").String() == "tool" {
+		answer, _ := json.Marshal("tool said " + last.Get("content").String())
+		return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":` + string(answer) + `}}],` + usage + `}`)}, nil
+	}
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"echo","arguments":"{\"text\":\"pong\"}"}}]}}],` + usage + `}`)}, nil
+}
+
+func (e *toolLoopExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *toolLoopExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *toolLoopExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *toolLoopExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newAgentTestRouter(t *testing.T, cfg *sdkconfig.SDKConfig) (*gin.Engine, *toolLoopExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &toolLoopExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "agent-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "agent-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	if err := RegisterTool(Tool{
+		Name: "echo",
+		Call: func(_ context.Context, arguments json.RawMessage) (string, error) {
+			return gjson.GetBytes(arguments, "text").String(), nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { UnregisterTool("echo") })
+
+	h := NewAgentAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.POST("/v1/agent/completions", h.Completions)
+	return router, executor
+}
+
+func postAgent(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/agent/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestAgentCompletionsRunsToolLoop(t *testing.T) {
+	router, executor := newAgentTestRouter(t, &sdkconfig.SDKConfig{AgentMode: sdkconfig.AgentModeConfig{Enabled: true}})
+
+	resp := postAgent(router, `{"model":"agent-model","messages":[{"role":"user","content":"ping"}],"tools":[{"type":"function","function":{"name":"echo"}}]}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	out := resp.Body.Bytes()
+	if gjson.GetBytes(out, "status").String() != StatusCompleted || gjson.GetBytes(out, "turns").Int() != 2 {
+		t.Fatalf("response = %s", out)
+	}
+	if gjson.GetBytes(out, "message.content").String() != "tool said pong" {
+		t.Fatalf("final message = %s", gjson.GetBytes(out, "message").Raw)
+	}
+	if gjson.GetBytes(out, "trace.0.name").String() != "echo" || gjson.GetBytes(out, "trace.0.output").String() != "pong" || gjson.GetBytes(out, "trace.0.arguments.text").String() != "pong" {
+		t.Fatalf("trace = %s", gjson.GetBytes(out, "trace").Raw)
+	}
+	if gjson.GetBytes(out, "usage.total_tokens").Int() != 24 {
+		t.Fatalf("usage = %s", gjson.GetBytes(out, "usage").Raw)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("model calls = %d, want 2", len(executor.payloads))
+	}
+	if got := gjson.GetBytes(executor.payloads[0], "tools.0.function.parameters.type").String(); got != "object" {
+		t.Fatalf("tool definition was not expanded: %s", executor.payloads[0])
+	}
+	if got := gjson.GetBytes(executor.payloads[1], "messages.#").Int(); got != 3 {
+		t.Fatalf("second turn messages = %d, want 3", got)
+	}
+}
+
+func TestAgentCompletionsStopsAtMaxTurns(t *testing.T) {
+	router, executor := newAgentTestRouter(t, &sdkconfig.SDKConfig{AgentMode: sdkconfig.AgentModeConfig{Enabled: true}})
+
+	resp := postAgent(router, `{"model":"agent-model","max_turns":1,"messages":[{"role":"user","content":"ping"}],"tools":[{"type":"function","function":{"name":"echo"}}]}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if gjson.Get(resp.Body.String(), "status").String() != StatusMaxTurns || len(executor.payloads) != 1 {
+		t.Fatalf("response = %s, calls = %d", resp.Body.String(), len(executor.payloads))
+	}
+}
+
+func TestAgentCompletionsRejectsInvalidRequests(t *testing.T) {
+	router, executor := newAgentTestRouter(t, &sdkconfig.SDKConfig{AgentMode: sdkconfig.AgentModeConfig{Enabled: true}})
+
+	if resp := postAgent(router, `{"model":"agent-model","messages":[],"tools":[{"type":"function","function":{"name":"rm_rf"}}]}`); resp.Code != http.StatusBadRequest {
+		t.Fatalf("unregistered tool status = %d", resp.Code)
+	}
+	if resp := postAgent(router, `{"model":"agent-model","stream":true,"messages":[]}`); resp.Code != http.StatusBadRequest {
+		t.Fatalf("stream status = %d", resp.Code)
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("invalid requests reached the model %d times", len(executor.payloads))
+	}
+
+	disabled, _ := newAgentTestRouter(t, &sdkconfig.SDKConfig{})
+	if resp := postAgent(disabled, `{"model":"agent-model","messages":[]}`); resp.Code != http.StatusNotFound {
+		t.Fatalf("disabled status = %d", resp.Code)
+	}
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tool is a function the proxy runs on behalf of the model during an agent request.
+type Tool struct {
+	// Name is the function name the model calls.
+	Name string
+	// Description tells the model what the tool does.
+	Description string
+	// Parameters is the JSON schema of the arguments object. Nil means no arguments.
+	Parameters json.RawMessage
+	// Call runs the tool with the raw JSON arguments produced by the model. The returned
+	// string is sent back to the model as the tool result.
+	Call func(ctx context.Context, arguments json.RawMessage) (string, error)
+}
+
+var (
+	toolsMu sync.RWMutex
+	tools   = map[string]Tool{}
+)
+
+// RegisterTool makes a tool available to agent requests. Call it from an init function or
+// before the server starts. Registering a name twice replaces the earlier tool.
+func RegisterTool(tool Tool) error {
+	name := strings.TrimSpace(tool.Name)
+	if name == "" {
+		return fmt.Errorf("agent: tool name is required")
+	}
+	if tool.Call == nil {
+		return fmt.Errorf("agent: tool %s has no Call function", name)
+	}
+	if len(tool.Parameters) > 0 && !json.Valid(tool.Parameters) {
+		return fmt.Errorf("agent: tool %s has an invalid parameters schema", name)
+	}
+	tool.Name = name
+	toolsMu.Lock()
+	tools[name] = tool
+	toolsMu.Unlock()
+	return nil
+}
+
+// UnregisterTool removes a registered tool.
+func UnregisterTool(name string) {
+	toolsMu.Lock()
+	delete(tools, name)
+	toolsMu.Unlock()
+}
+
+// LookupTool returns the registered tool with the given name.
+func LookupTool(name string) (Tool, bool) {
+	toolsMu.RLock()
+	defer toolsMu.RUnlock()
+	tool, ok := tools[name]
+	return tool, ok
+}
+
+// RegisteredTools returns the names of all registered tools, sorted.
+func RegisteredTools() []string {
+	toolsMu.RLock()
+	defer toolsMu.RUnlock()
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	_ = RegisterTool(Tool{
+		Name:        "current_time",
+		Description: "Returns the current date and time in RFC 3339 format, in UTC or the given IANA time zone.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"timezone":{"type":"string","description":"IANA time zone, e.g. Europe/Berlin"}}}`),
+		Call: func(_ context.Context, arguments json.RawMessage) (string, error) {
+			var args struct {
+				Timezone string `json:"timezone"`
+			}
+			if len(arguments) > 0 {
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return "", fmt.Errorf("invalid arguments: %w", err)
+				}
+			}
+			loc := time.UTC
+			if args.Timezone != "" {
+				l, err := time.LoadLocation(args.Timezone)
+				if err != nil {
+					return "", fmt.Errorf("unknown time zone %q", args.Timezone)
+				}
+				loc = l
+			}
+			return time.Now().In(loc).Format(time.RFC3339), nil
+		},
+	})
+}
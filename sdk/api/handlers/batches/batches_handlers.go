@@ -0,0 +1,581 @@
+// Package batches emulates Anthropic's Message Batches API on /v1/messages/batches. A batch of
+// Messages requests is fanned out through the regular request pipeline with bounded
+// concurrency, so large offline workloads can drain the credential pool without a client
+// holding connections open. Results are persisted as requests finish and are retrieved as
+// JSONL once the batch has ended.
+package batches
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultMaxConcurrency = 4
+	defaultMaxRequests    = 10000
+	defaultResultTTL      = 720 * time.Hour
+	// batchExpiry matches Anthropic: requests not started within 24 hours expire.
+	batchExpiry      = 24 * time.Hour
+	defaultListLimit = 20
+	maxListLimit     = 1000
+)
+
+// Batch processing statuses.
+const (
+	StatusInProgress = "in_progress"
+	StatusCanceling  = "canceling"
+	StatusEnded      = "ended"
+)
+
+// Result types of individual batch requests.
+const (
+	ResultSucceeded = "succeeded"
+	ResultErrored   = "errored"
+	ResultCanceled  = "canceled"
+	ResultExpired   = "expired"
+)
+
+var customIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// RequestCounts tallies the requests of a batch by state.
+type RequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// add counts a finished request with the given result type.
+func (c *RequestCounts) add(resultType string) {
+	switch resultType {
+	case ResultSucceeded:
+		c.Succeeded++
+	case ResultErrored:
+		c.Errored++
+	case ResultCanceled:
+		c.Canceled++
+	case ResultExpired:
+		c.Expired++
+	}
+}
+
+// Batch is the message_batch object returned by the API.
+type Batch struct {
+	ID                string        `json:"id"`
+	Type              string        `json:"type"`
+	ProcessingStatus  string        `json:"processing_status"`
+	RequestCounts     RequestCounts `json:"request_counts"`
+	EndedAt           *time.Time    `json:"ended_at"`
+	CreatedAt         time.Time     `json:"created_at"`
+	ExpiresAt         time.Time     `json:"expires_at"`
+	ArchivedAt        *time.Time    `json:"archived_at"`
+	CancelInitiatedAt *time.Time    `json:"cancel_initiated_at"`
+	ResultsURL        *string       `json:"results_url"`
+}
+
+type batchRequest struct {
+	customID string
+	model    string
+	params   []byte
+}
+
+type batchState struct {
+	Batch
+
+	owners    []string
+	customIDs []string
+	// requests and ginCtx are only held while the batch is processing. ginCtx is a copy of the
+	// creating request's context, detached from its cancellation, so every request runs with
+	// the caller's key scope and usage attribution.
+	requests []batchRequest
+	ginCtx   *gin.Context
+}
+
+// MessageBatchesAPIHandler serves /v1/messages/batches and processes batches in the background.
+type MessageBatchesAPIHandler struct {
+	*handlers.BaseAPIHandler
+
+	dir string
+
+	mu      sync.Mutex
+	batches map[string]*batchState
+	loaded  bool
+	// slots bounds the batch requests in flight across all batches.
+	slots chan struct{}
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// NewMessageBatchesAPIHandler creates a Message Batches handler that stores batches in dir.
+func NewMessageBatchesAPIHandler(apiHandlers *handlers.BaseAPIHandler, dir string) *MessageBatchesAPIHandler {
+	return &MessageBatchesAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		dir:            dir,
+		batches:        make(map[string]*batchState),
+		now:            time.Now,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *MessageBatchesAPIHandler) HandlerType() string {
+	return Claude
+}
+
+// Models returns the Claude-compatible model metadata supported by this handler.
+func (h *MessageBatchesAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("claude")
+}
+
+// Create handles POST /v1/messages/batches. The body is {"requests": [{"custom_id", "params"}]}
+// where params is a non-streaming Messages request.
+func (h *MessageBatchesAPIHandler) Create(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "message batches are disabled", "invalid_request_error")
+		return
+	}
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "invalid_request_error")
+		return
+	}
+	requests, errMsg := h.parseRequests(rawJSON)
+	if errMsg != "" {
+		writeError(c, http.StatusBadRequest, "Invalid request: "+errMsg, "invalid_request_error")
+		return
+	}
+
+	now := h.now()
+	b := &batchState{
+		Batch: Batch{
+			ID:               newBatchID(),
+			Type:             "message_batch",
+			ProcessingStatus: StatusInProgress,
+			RequestCounts:    RequestCounts{Processing: len(requests)},
+			CreatedAt:        now,
+			ExpiresAt:        now.Add(batchExpiry),
+		},
+		owners:   logging.ClientKeyRefs(c),
+		requests: requests,
+		ginCtx:   c.Copy(),
+	}
+	for _, req := range requests {
+		b.customIDs = append(b.customIDs, req.customID)
+	}
+	if b.ginCtx.Request != nil {
+		b.ginCtx.Request = b.ginCtx.Request.WithContext(context.WithoutCancel(b.ginCtx.Request.Context()))
+	}
+
+	h.mu.Lock()
+	h.ensureLoadedLocked()
+	if err = h.saveLocked(b); err != nil {
+		h.mu.Unlock()
+		log.Errorf("message batches: save %s: %v", b.ID, err)
+		writeError(c, http.StatusInternalServerError, "batch storage is unavailable", "server_error")
+		return
+	}
+	h.batches[b.ID] = b
+	view := b.Batch
+	if h.slots == nil {
+		h.slots = make(chan struct{}, h.maxConcurrency())
+	}
+	h.mu.Unlock()
+
+	go h.process(b)
+	c.JSON(http.StatusOK, view)
+}
+
+// List handles GET /v1/messages/batches, newest first. "limit" (default 20, at most 1000) and
+// "after_id" page through the caller's batches.
+func (h *MessageBatchesAPIHandler) List(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "message batches are disabled", "invalid_request_error")
+		return
+	}
+	limit := defaultListLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxListLimit {
+			writeError(c, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit), "invalid_request_error")
+			return
+		}
+		limit = n
+	}
+	callers := logging.ClientKeyRefs(c)
+	h.mu.Lock()
+	h.ensureLoadedLocked()
+	h.pruneLocked()
+	all := make([]Batch, 0, len(h.batches))
+	for _, b := range h.batches {
+		if b.ownedBy(callers) {
+			all = append(all, b.Batch)
+		}
+	}
+	h.mu.Unlock()
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID > all[j].ID
+		}
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+	if afterID := c.Query("after_id"); afterID != "" {
+		idx := slices.IndexFunc(all, func(b Batch) bool { return b.ID == afterID })
+		all = all[idx+1:]
+	}
+	hasMore := len(all) > limit
+	if hasMore {
+		all = all[:limit]
+	}
+	resp := gin.H{"data": all, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(all) > 0 {
+		resp["first_id"] = all[0].ID
+		resp["last_id"] = all[len(all)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get handles GET /v1/messages/batches/:id.
+func (h *MessageBatchesAPIHandler) Get(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "message batches are disabled", "invalid_request_error")
+		return
+	}
+	h.mu.Lock()
+	h.ensureLoadedLocked()
+	h.pruneLocked()
+	b := h.lookupLocked(c)
+	var view Batch
+	if b != nil {
+		view = b.Batch
+	}
+	h.mu.Unlock()
+	if b == nil {
+		writeError(c, http.StatusNotFound, "batch not found", "not_found_error")
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// Results handles GET /v1/messages/batches/:id/results and streams one JSONL line per
+// request. Results are in completion order, not request order; match them by custom_id.
+func (h *MessageBatchesAPIHandler) Results(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "message batches are disabled", "invalid_request_error")
+		return
+	}
+	h.mu.Lock()
+	h.ensureLoadedLocked()
+	b := h.lookupLocked(c)
+	var ended bool
+	var data []byte
+	var err error
+	if b != nil && b.ProcessingStatus == StatusEnded {
+		ended = true
+		data, err = os.ReadFile(h.resultsPath(b.ID))
+		if os.IsNotExist(err) {
+			data, err = nil, nil
+		}
+	}
+	h.mu.Unlock()
+	switch {
+	case b == nil:
+		writeError(c, http.StatusNotFound, "batch not found", "not_found_error")
+	case !ended:
+		writeError(c, http.StatusBadRequest, "batch is still processing; results are available once it has ended", "invalid_request_error")
+	case err != nil:
+		log.Errorf("message batches: read results of %s: %v", b.ID, err)
+		writeError(c, http.StatusInternalServerError, "batch results are unavailable", "server_error")
+	default:
+		c.Data(http.StatusOK, "application/x-jsonl", data)
+	}
+}
+
+// Cancel handles POST /v1/messages/batches/:id/cancel. Requests that have not started are
+// canceled; requests in flight still finish and keep their results.
+func (h *MessageBatchesAPIHandler) Cancel(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "message batches are disabled", "invalid_request_error")
+		return
+	}
+	h.mu.Lock()
+	h.ensureLoadedLocked()
+	b := h.lookupLocked(c)
+	if b == nil {
+		h.mu.Unlock()
+		writeError(c, http.StatusNotFound, "batch not found", "not_found_error")
+		return
+	}
+	if b.ProcessingStatus == StatusInProgress {
+		now := h.now()
+		b.ProcessingStatus = StatusCanceling
+		b.CancelInitiatedAt = &now
+		if err := h.saveLocked(b); err != nil {
+			log.Warnf("message batches: save %s: %v", b.ID, err)
+		}
+	}
+	view := b.Batch
+	h.mu.Unlock()
+	c.JSON(http.StatusOK, view)
+}
+
+// Delete handles DELETE /v1/messages/batches/:id. Only ended batches can be deleted.
+func (h *MessageBatchesAPIHandler) Delete(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "message batches are disabled", "invalid_request_error")
+		return
+	}
+	h.mu.Lock()
+	h.ensureLoadedLocked()
+	b := h.lookupLocked(c)
+	if b == nil {
+		h.mu.Unlock()
+		writeError(c, http.StatusNotFound, "batch not found", "not_found_error")
+		return
+	}
+	if b.ProcessingStatus != StatusEnded {
+		h.mu.Unlock()
+		writeError(c, http.StatusBadRequest, "batch is still processing; cancel it and wait for it to end before deleting", "invalid_request_error")
+		return
+	}
+	delete(h.batches, b.ID)
+	h.removeLocked(b.ID)
+	h.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"id": b.ID, "type": "message_batch_deleted"})
+}
+
+// parseRequests validates the batch body and returns its requests, or a message describing
+// why the body is invalid.
+func (h *MessageBatchesAPIHandler) parseRequests(rawJSON []byte) ([]batchRequest, string) {
+	if !gjson.ValidBytes(rawJSON) {
+		return nil, "body must be a JSON object"
+	}
+	items := gjson.GetBytes(rawJSON, "requests")
+	if !items.IsArray() || len(items.Array()) == 0 {
+		return nil, "requests must be a non-empty array"
+	}
+	if n := len(items.Array()); n > h.maxRequests() {
+		return nil, fmt.Sprintf("a batch holds at most %d requests, got %d", h.maxRequests(), n)
+	}
+	seen := make(map[string]bool)
+	var out []batchRequest
+	for i, item := range items.Array() {
+		customID := item.Get("custom_id").String()
+		if !customIDPattern.MatchString(customID) {
+			return nil, fmt.Sprintf("requests[%d].custom_id must be 1-64 letters, digits, '-' or '_'", i)
+		}
+		if seen[customID] {
+			return nil, fmt.Sprintf("requests[%d].custom_id %q is not unique", i, customID)
+		}
+		seen[customID] = true
+		params := item.Get("params")
+		if !params.IsObject() {
+			return nil, fmt.Sprintf("requests[%d].params must be an object", i)
+		}
+		model := params.Get("model").String()
+		if model == "" {
+			return nil, fmt.Sprintf("requests[%d].params.model is required", i)
+		}
+		if params.Get("stream").Bool() {
+			return nil, fmt.Sprintf("requests[%d].params.stream is not supported in batches", i)
+		}
+		out = append(out, batchRequest{customID: customID, model: model, params: []byte(params.Raw)})
+	}
+	return out, ""
+}
+
+// process runs the requests of a batch, at most max-concurrency at a time across all batches,
+// and ends the batch once every request has a result.
+func (h *MessageBatchesAPIHandler) process(b *batchState) {
+	var wg sync.WaitGroup
+	for _, req := range b.requests {
+		h.slots <- struct{}{}
+		h.mu.Lock()
+		var skip string
+		switch {
+		case b.ProcessingStatus == StatusCanceling:
+			skip = ResultCanceled
+		case !h.now().Before(b.ExpiresAt):
+			skip = ResultExpired
+		}
+		if skip != "" {
+			h.recordLocked(b, resultLine(req.customID, skip, nil))
+			h.mu.Unlock()
+			<-h.slots
+			continue
+		}
+		h.mu.Unlock()
+		wg.Add(1)
+		go func(req batchRequest) {
+			defer wg.Done()
+			defer func() { <-h.slots }()
+			h.execute(b, req)
+		}(req)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	h.endLocked(b)
+	b.requests = nil
+	b.ginCtx = nil
+	if err := h.saveLocked(b); err != nil {
+		log.Warnf("message batches: save %s: %v", b.ID, err)
+	}
+	h.mu.Unlock()
+}
+
+// execute sends one batch request through the regular Messages pipeline and records its result.
+func (h *MessageBatchesAPIHandler) execute(b *batchState, req batchRequest) {
+	// Each request gets its own copy of the context, since the pipeline stores per-request
+	// values on it. The detached request context is the parent, so requests keep the batch's
+	// request ID and are not tied to the lifetime of the creating request.
+	ginCtx := b.ginCtx.Copy()
+	parent := context.Background()
+	if ginCtx.Request != nil {
+		parent = ginCtx.Request.Context()
+	}
+	ctx, cancel := h.GetContextWithCancel(h, ginCtx, parent)
+	resp, errMsg := h.ExecuteWithAuthManager(ctx, Claude, req.model, req.params, "")
+	var line []byte
+	switch {
+	case errMsg != nil:
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		message := "request failed"
+		if errMsg.Error != nil {
+			message = errMsg.Error.Error()
+		}
+		line = erroredLine(req.customID, status, message)
+		cancel(errMsg.Error)
+	case !json.Valid(resp):
+		line = erroredLine(req.customID, http.StatusBadGateway, "upstream returned an invalid message")
+		cancel(resp)
+	default:
+		line = resultLine(req.customID, ResultSucceeded, resp)
+		cancel(resp)
+	}
+	h.mu.Lock()
+	h.recordLocked(b, line)
+	h.mu.Unlock()
+}
+
+// recordLocked persists a result and moves the request out of the processing count.
+func (h *MessageBatchesAPIHandler) recordLocked(b *batchState, line []byte) {
+	if err := h.appendResultLocked(b.ID, line); err != nil {
+		log.Errorf("message batches: write result of %s: %v", b.ID, err)
+	}
+	b.RequestCounts.Processing--
+	b.RequestCounts.add(gjson.GetBytes(line, "result.type").String())
+}
+
+func (h *MessageBatchesAPIHandler) endLocked(b *batchState) {
+	now := h.now()
+	url := "/v1/messages/batches/" + b.ID + "/results"
+	b.ProcessingStatus = StatusEnded
+	b.RequestCounts.Processing = 0
+	b.EndedAt = &now
+	b.ResultsURL = &url
+}
+
+// ensureLoadedLocked reads the stored batches on first use.
+func (h *MessageBatchesAPIHandler) ensureLoadedLocked() {
+	if h.loaded {
+		return
+	}
+	h.loaded = true
+	h.loadLocked()
+}
+
+// pruneLocked drops ended batches, with their results, older than the result TTL.
+func (h *MessageBatchesAPIHandler) pruneLocked() {
+	cutoff := h.now().Add(-h.resultTTL())
+	for id, b := range h.batches {
+		if b.EndedAt != nil && b.EndedAt.Before(cutoff) {
+			delete(h.batches, id)
+			h.removeLocked(id)
+		}
+	}
+}
+
+func (h *MessageBatchesAPIHandler) lookupLocked(c *gin.Context) *batchState {
+	b := h.batches[c.Param("id")]
+	if b == nil || !b.ownedBy(logging.ClientKeyRefs(c)) {
+		return nil
+	}
+	return b
+}
+
+// ownedBy reports whether a caller with the given key references created the batch. Batches
+// created without client authentication are visible to every caller.
+func (b *batchState) ownedBy(callers []string) bool {
+	if len(b.owners) == 0 {
+		return true
+	}
+	for _, id := range callers {
+		if slices.Contains(b.owners, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *MessageBatchesAPIHandler) enabled() bool {
+	return h.Cfg != nil && h.Cfg.MessageBatches.Enabled
+}
+
+func (h *MessageBatchesAPIHandler) maxConcurrency() int {
+	if h.Cfg != nil && h.Cfg.MessageBatches.MaxConcurrency > 0 {
+		return h.Cfg.MessageBatches.MaxConcurrency
+	}
+	return defaultMaxConcurrency
+}
+
+func (h *MessageBatchesAPIHandler) maxRequests() int {
+	if h.Cfg != nil && h.Cfg.MessageBatches.MaxRequests > 0 {
+		return h.Cfg.MessageBatches.MaxRequests
+	}
+	return defaultMaxRequests
+}
+
+func (h *MessageBatchesAPIHandler) resultTTL() time.Duration {
+	if h.Cfg != nil && h.Cfg.MessageBatches.ResultTTLHours > 0 {
+		return time.Duration(h.Cfg.MessageBatches.ResultTTLHours) * time.Hour
+	}
+	return defaultResultTTL
+}
+
+func newBatchID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "msgbatch_" + hex.EncodeToString(b[:])
+}
+
+func writeError(c *gin.Context, status int, message, errType string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
@@ -0,0 +1,265 @@
+package batches
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type badRequestError struct{}
+
+func (badRequestError) Error() string   { return "prompt is too long" }
+func (badRequestError) StatusCode() int { return http.StatusBadRequest }
+
+// batchesTestExecutor answers every request with a Claude message, fails requests whose
+// payload contains "fail", and records the peak number of requests in flight. When gate is
+// set, each request announces itself on started and waits for gate.
+type batchesTestExecutor struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	started  chan struct{}
+	gate     chan struct{}
+}
+
+func (e *batchesTestExecutor) Identifier() string { return "batches-test-provider" }
+
+func (e *batchesTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.inFlight++
+	e.peak = max(e.peak, e.inFlight)
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.inFlight--
+		e.mu.Unlock()
+	}()
+	if e.gate != nil {
+		e.started <- struct{}{}
+		<-e.gate
+	} else {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if strings.Contains(string(req.Payload), "fail") {
+		return coreexecutor.Response{}, badRequestError{}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"ok"}]}`)}, nil
+}
+
+func (e *batchesTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *batchesTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *batchesTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *batchesTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+// newBatchesTestRouter serves the batches endpoints from dir; the X-Test-Key header stands in
+// for the authenticated client key.
+func newBatchesTestRouter(t *testing.T, dir string, executor *batchesTestExecutor, concurrency int) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "batches-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "batches-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	cfg := &sdkconfig.SDKConfig{MessageBatches: sdkconfig.MessageBatchesConfig{Enabled: true, MaxConcurrency: concurrency}}
+	h := NewMessageBatchesAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager), dir)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+		}
+	})
+	router.POST("/v1/messages/batches", h.Create)
+	router.GET("/v1/messages/batches", h.List)
+	router.GET("/v1/messages/batches/:id", h.Get)
+	router.GET("/v1/messages/batches/:id/results", h.Results)
+	router.POST("/v1/messages/batches/:id/cancel", h.Cancel)
+	router.DELETE("/v1/messages/batches/:id", h.Delete)
+	return router
+}
+
+func doBatches(router *gin.Engine, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-Test-Key", key)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func waitForEnded(t *testing.T, router *gin.Engine, id, key string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := doBatches(router, http.MethodGet, "/v1/messages/batches/"+id, key, "").Body.String()
+		if gjson.Get(body, "processing_status").String() == StatusEnded {
+			return body
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch %s did not end: %s", id, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// resultTypes maps custom_id to result type for a JSONL results body.
+func resultTypes(t *testing.T, body string) map[string]string {
+	t.Helper()
+	out := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		out[gjson.Get(scanner.Text(), "custom_id").String()] = gjson.Get(scanner.Text(), "result.type").String()
+	}
+	return out
+}
+
+func TestBatchRunsRequestsWithBoundedConcurrency(t *testing.T) {
+	executor := &batchesTestExecutor{}
+	router := newBatchesTestRouter(t, t.TempDir(), executor, 2)
+
+	body := `{"requests":[
+		{"custom_id":"a","params":{"model":"batches-model","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}},
+		{"custom_id":"b","params":{"model":"batches-model","max_tokens":16,"messages":[{"role":"user","content":"hello"}]}},
+		{"custom_id":"c","params":{"model":"batches-model","max_tokens":16,"messages":[{"role":"user","content":"fail"}]}},
+		{"custom_id":"d","params":{"model":"batches-model","max_tokens":16,"messages":[{"role":"user","content":"hey"}]}},
+		{"custom_id":"e","params":{"model":"batches-model","max_tokens":16,"messages":[{"role":"user","content":"yo"}]}}
+	]}`
+	resp := doBatches(router, http.MethodPost, "/v1/messages/batches", "key-a", body)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d: %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+	if gjson.Get(resp.Body.String(), "request_counts.processing").Int() != 5 {
+		t.Fatalf("created batch = %s", resp.Body.String())
+	}
+	if resp = doBatches(router, http.MethodGet, "/v1/messages/batches/"+id, "key-b", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("other key status = %d, want 404", resp.Code)
+	}
+
+	ended := waitForEnded(t, router, id, "key-a")
+	if gjson.Get(ended, "request_counts.succeeded").Int() != 4 || gjson.Get(ended, "request_counts.errored").Int() != 1 {
+		t.Fatalf("ended batch = %s", ended)
+	}
+	executor.mu.Lock()
+	peak := executor.peak
+	executor.mu.Unlock()
+	if peak > 2 {
+		t.Fatalf("peak concurrency = %d, want at most 2", peak)
+	}
+
+	results := doBatches(router, http.MethodGet, gjson.Get(ended, "results_url").String(), "key-a", "")
+	if results.Code != http.StatusOK {
+		t.Fatalf("results status = %d: %s", results.Code, results.Body.String())
+	}
+	types := resultTypes(t, results.Body.String())
+	if len(types) != 5 || types["a"] != ResultSucceeded || types["c"] != ResultErrored {
+		t.Fatalf("results = %s", results.Body.String())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(results.Body.String()), "\n") {
+		if gjson.Get(line, "custom_id").String() == "c" && gjson.Get(line, "result.error.error.type").String() != "invalid_request_error" {
+			t.Fatalf("errored result = %s", line)
+		}
+	}
+
+	if resp = doBatches(router, http.MethodDelete, "/v1/messages/batches/"+id, "key-a", ""); resp.Code != http.StatusOK {
+		t.Fatalf("delete status = %d: %s", resp.Code, resp.Body.String())
+	}
+	if resp = doBatches(router, http.MethodGet, "/v1/messages/batches/"+id, "key-a", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("deleted batch status = %d, want 404", resp.Code)
+	}
+}
+
+func TestBatchCancelSkipsUnstartedRequests(t *testing.T) {
+	executor := &batchesTestExecutor{started: make(chan struct{}), gate: make(chan struct{})}
+	router := newBatchesTestRouter(t, t.TempDir(), executor, 1)
+
+	body := `{"requests":[
+		{"custom_id":"a","params":{"model":"batches-model","messages":[{"role":"user","content":"1"}]}},
+		{"custom_id":"b","params":{"model":"batches-model","messages":[{"role":"user","content":"2"}]}},
+		{"custom_id":"c","params":{"model":"batches-model","messages":[{"role":"user","content":"3"}]}}
+	]}`
+	id := gjson.Get(doBatches(router, http.MethodPost, "/v1/messages/batches", "", body).Body.String(), "id").String()
+	<-executor.started
+	resp := doBatches(router, http.MethodPost, "/v1/messages/batches/"+id+"/cancel", "", "")
+	if gjson.Get(resp.Body.String(), "processing_status").String() != StatusCanceling {
+		t.Fatalf("cancel = %s", resp.Body.String())
+	}
+	close(executor.gate)
+
+	ended := waitForEnded(t, router, id, "")
+	if gjson.Get(ended, "request_counts.succeeded").Int() != 1 || gjson.Get(ended, "request_counts.canceled").Int() != 2 {
+		t.Fatalf("ended batch = %s", ended)
+	}
+}
+
+func TestBatchRecoveredAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	stored := `{"id":"msgbatch_restart","type":"message_batch","processing_status":"in_progress",` +
+		`"request_counts":{"processing":2},"created_at":"2026-01-01T00:00:00Z","expires_at":"2026-01-02T00:00:00Z",` +
+		`"custom_ids":["a","b"]}`
+	if err := os.WriteFile(filepath.Join(dir, "msgbatch_restart.json"), []byte(stored), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	done := `{"custom_id":"a","result":{"type":"succeeded","message":{"id":"msg_1"}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "msgbatch_restart.results.jsonl"), []byte(done), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	router := newBatchesTestRouter(t, dir, &batchesTestExecutor{}, 1)
+
+	batch := doBatches(router, http.MethodGet, "/v1/messages/batches/msgbatch_restart", "", "").Body.String()
+	if gjson.Get(batch, "processing_status").String() != StatusEnded ||
+		gjson.Get(batch, "request_counts.succeeded").Int() != 1 || gjson.Get(batch, "request_counts.errored").Int() != 1 {
+		t.Fatalf("recovered batch = %s", batch)
+	}
+	types := resultTypes(t, doBatches(router, http.MethodGet, "/v1/messages/batches/msgbatch_restart/results", "", "").Body.String())
+	if types["a"] != ResultSucceeded || types["b"] != ResultErrored {
+		t.Fatalf("recovered results = %v", types)
+	}
+}
+
+func TestBatchRejectsInvalidRequests(t *testing.T) {
+	router := newBatchesTestRouter(t, t.TempDir(), &batchesTestExecutor{}, 1)
+	for _, body := range []string{
+		`{"requests":[]}`,
+		`{"requests":[{"custom_id":"a b","params":{"model":"batches-model"}}]}`,
+		`{"requests":[{"custom_id":"a","params":{"model":"batches-model"}},{"custom_id":"a","params":{"model":"batches-model"}}]}`,
+		`{"requests":[{"custom_id":"a","params":{"model":"batches-model","stream":true}}]}`,
+	} {
+		if resp := doBatches(router, http.MethodPost, "/v1/messages/batches", "", body); resp.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.Code)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package batches
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// storedBatch is the on-disk form of a batch: the public batch object plus what is needed to
+// check ownership and to account for unfinished requests after a restart.
+type storedBatch struct {
+	Batch
+	Owners    []string `json:"owners,omitempty"`
+	CustomIDs []string `json:"custom_ids"`
+}
+
+// Each batch is stored as <id>.json, rewritten on every status change, and <id>.results.jsonl,
+// appended to as its requests finish.
+func (h *MessageBatchesAPIHandler) batchPath(id string) string {
+	return filepath.Join(h.dir, id+".json")
+}
+
+func (h *MessageBatchesAPIHandler) resultsPath(id string) string {
+	return filepath.Join(h.dir, id+".results.jsonl")
+}
+
+// saveLocked writes the batch metadata atomically.
+func (h *MessageBatchesAPIHandler) saveLocked(b *batchState) error {
+	if err := os.MkdirAll(h.dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(storedBatch{Batch: b.Batch, Owners: b.owners, CustomIDs: b.customIDs})
+	if err != nil {
+		return err
+	}
+	tmp := h.batchPath(b.ID) + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.batchPath(b.ID))
+}
+
+// appendResultLocked persists one result line.
+func (h *MessageBatchesAPIHandler) appendResultLocked(id string, line []byte) error {
+	f, err := os.OpenFile(h.resultsPath(id), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// removeLocked deletes the files of a batch.
+func (h *MessageBatchesAPIHandler) removeLocked(id string) {
+	for _, path := range []string{h.batchPath(id), h.resultsPath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("message batches: remove %s: %v", path, err)
+		}
+	}
+}
+
+// loadLocked reads the stored batches. Batches that were still processing when the proxy
+// stopped are ended: their unfinished requests are recorded as errored, or as canceled when a
+// cancel had been requested.
+func (h *MessageBatchesAPIHandler) loadLocked() {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("message batches: read %s: %v", h.dir, err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(h.dir, name))
+		if errRead != nil {
+			log.Warnf("message batches: read %s: %v", name, errRead)
+			continue
+		}
+		var stored storedBatch
+		if errParse := json.Unmarshal(data, &stored); errParse != nil || stored.ID == "" {
+			log.Warnf("message batches: skip %s: invalid batch file", name)
+			continue
+		}
+		b := &batchState{Batch: stored.Batch, owners: stored.Owners, customIDs: stored.CustomIDs}
+		h.batches[b.ID] = b
+		if b.ProcessingStatus != StatusEnded {
+			h.recoverLocked(b)
+		}
+	}
+}
+
+func (h *MessageBatchesAPIHandler) recoverLocked(b *batchState) {
+	done := make(map[string]bool, len(b.customIDs))
+	counts := RequestCounts{}
+	if data, err := os.ReadFile(h.resultsPath(b.ID)); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			done[gjson.GetBytes(line, "custom_id").String()] = true
+			counts.add(gjson.GetBytes(line, "result.type").String())
+		}
+	}
+	b.RequestCounts = counts
+	for _, customID := range b.customIDs {
+		if done[customID] {
+			continue
+		}
+		var line []byte
+		if b.ProcessingStatus == StatusCanceling {
+			line = resultLine(customID, ResultCanceled, nil)
+		} else {
+			line = erroredLine(customID, http.StatusInternalServerError, "batch processing was interrupted by a proxy restart")
+		}
+		if err := h.appendResultLocked(b.ID, line); err != nil {
+			log.Warnf("message batches: recover %s: %v", b.ID, err)
+		}
+		b.RequestCounts.add(gjson.GetBytes(line, "result.type").String())
+	}
+	h.endLocked(b)
+	if err := h.saveLocked(b); err != nil {
+		log.Warnf("message batches: save %s: %v", b.ID, err)
+	}
+}
+
+// resultLine builds a results JSONL line. payload is the message of a succeeded result or the
+// error of an errored one.
+func resultLine(customID, resultType string, payload []byte) []byte {
+	result := map[string]any{"type": resultType}
+	switch resultType {
+	case ResultSucceeded:
+		result["message"] = json.RawMessage(payload)
+	case ResultErrored:
+		result["error"] = json.RawMessage(payload)
+	}
+	line, _ := json.Marshal(map[string]any{"custom_id": customID, "result": result})
+	return line
+}
+
+func erroredLine(customID string, status int, message string) []byte {
+	payload, _ := json.Marshal(map[string]any{
+		"type":  "error",
+		"error": map[string]string{"type": anthropicErrorType(status), "message": message},
+	})
+	return resultLine(customID, ResultErrored, payload)
+}
+
+// anthropicErrorType maps an HTTP status to the error type the Anthropic API reports for it.
+func anthropicErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
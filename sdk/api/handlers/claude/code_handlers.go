@@ -128,7 +128,7 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 // Parameters:
 //   - c: The Gin context for the request.
 func (h *ClaudeCodeAPIHandler) ClaudeModels(c *gin.Context) {
-	models := h.Models()
+	models, stale := handlers.ListModels("claude")
 	firstID := ""
 	lastID := ""
 	if len(models) > 0 {
@@ -140,12 +140,12 @@ func (h *ClaudeCodeAPIHandler) ClaudeModels(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, handlers.AddModelListStaleness(gin.H{
 		"data":     models,
 		"has_more": false,
 		"first_id": firstID,
 		"last_id":  lastID,
-	})
+	}, stale))
 }
 
 // handleNonStreamingResponse handles non-streaming content generation requests for Claude models.
@@ -299,9 +299,28 @@ func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.
 			errorBytes, _ := json.Marshal(h.toClaudeError(errMsg))
 			_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", errorBytes)
 		},
+		IsBoundary: claudeEventBoundary,
 	})
 }
 
+// claudeBoundaryEvents are Claude stream event types forwarded without coalescing delay.
+var claudeBoundaryEvents = [][]byte{
+	[]byte(`"type":"content_block_start"`),
+	[]byte(`"type":"message_delta"`),
+	[]byte(`"type":"message_stop"`),
+}
+
+// claudeEventBoundary reports whether a Claude SSE chunk opens a content block (such as a
+// tool_use block) or finishes the message.
+func claudeEventBoundary(chunk []byte) bool {
+	for _, event := range claudeBoundaryEvents {
+		if bytes.Contains(chunk, event) {
+			return true
+		}
+	}
+	return false
+}
+
 type claudeErrorDetail struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
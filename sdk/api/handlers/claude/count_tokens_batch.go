@@ -0,0 +1,131 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// maxCountTokensBatch caps the number of payloads accepted in one batch call.
+	maxCountTokensBatch = 64
+	// countTokensBatchConcurrency bounds the upstream calls a single batch runs at once.
+	countTokensBatchConcurrency = 8
+)
+
+// countTokensBatchResult is one entry of a batch response. Exactly one of Result and
+// Error is set; Status carries the HTTP status the single-payload endpoint would return.
+type countTokensBatchResult struct {
+	Status int             `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// ClaudeCountTokensBatch handles POST /v1/messages/count_tokens/batch.
+// The body is {"requests": [<count_tokens payload>, ...]} and the response lists one
+// result per payload in request order. Upstreams have no batch API, so each distinct
+// payload is still sent as its own count_tokens call, at most
+// countTokensBatchConcurrency at a time; the batch saves client round trips, not
+// upstream requests. Duplicate payloads within a batch are counted once.
+func (h *ClaudeCodeAPIHandler) ClaudeCountTokensBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	requests := gjson.GetBytes(rawJSON, "requests")
+	if !requests.IsArray() || len(requests.Array()) == 0 {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Invalid request: requests must be a non-empty array",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	items := requests.Array()
+	if len(items) > maxCountTokensBatch {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: at most %d requests per batch", maxCountTokensBatch),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	alt := h.GetAlt(c)
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+
+	// Count each distinct payload once and fan the result out to its duplicates.
+	indexes := make(map[string][]int, len(items))
+	order := make([]string, 0, len(items))
+	for i, item := range items {
+		payload := strings.TrimSpace(item.Raw)
+		if _, seen := indexes[payload]; !seen {
+			order = append(order, payload)
+		}
+		indexes[payload] = append(indexes[payload], i)
+	}
+
+	results := make([]countTokensBatchResult, len(items))
+	sem := make(chan struct{}, countTokensBatchConcurrency)
+	var wg sync.WaitGroup
+	for _, payload := range order {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(payload string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := h.countTokensBatchItem(cliCtx, []byte(payload), alt)
+			for _, idx := range indexes[payload] {
+				results[idx] = result
+			}
+		}(payload)
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+	cliCancel()
+}
+
+func (h *ClaudeCodeAPIHandler) countTokensBatchItem(ctx context.Context, payload []byte, alt string) countTokensBatchResult {
+	if !gjson.ValidBytes(payload) || !gjson.ParseBytes(payload).IsObject() {
+		return countTokensBatchResult{
+			Status: http.StatusBadRequest,
+			Error:  handlers.BuildErrorResponseBody(http.StatusBadRequest, "request must be a JSON object"),
+		}
+	}
+	modelName := gjson.GetBytes(payload, "model").String()
+	resp, errMsg := h.ExecuteCountWithAuthManager(ctx, h.HandlerType(), modelName, payload, alt)
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		errText := ""
+		if errMsg.Error != nil {
+			errText = errMsg.Error.Error()
+		}
+		return countTokensBatchResult{Status: status, Error: handlers.BuildErrorResponseBody(status, errText)}
+	}
+	if !json.Valid(resp) {
+		return countTokensBatchResult{
+			Status: http.StatusBadGateway,
+			Error:  handlers.BuildErrorResponseBody(http.StatusBadGateway, "invalid count_tokens response"),
+		}
+	}
+	return countTokensBatchResult{Status: http.StatusOK, Result: resp}
+}
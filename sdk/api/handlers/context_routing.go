@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// ContextCompactedHeader reports how many conversation turns context routing dropped
+	// to fit the request into the largest context window.
+	ContextCompactedHeader = "X-CLIProxy-Context-Compacted"
+
+	defaultContextOutputReserve = 4096
+)
+
+// datedModelSuffix matches the release date that distinguishes dated model variants.
+var datedModelSuffix = regexp.MustCompile(`-(\d{8}|\d{4}-\d{2}-\d{2})$`)
+
+// modelVariant is an available model of the requested model's family and its context window.
+type modelVariant struct {
+	id     string
+	window int
+}
+
+// applyContextRouting returns the model and payload to use for a request whose estimated
+// prompt plus output reserve does not fit the requested model's context window: the dated
+// variant with the smallest window that fits, or, with compaction enabled, the variant with
+// the largest window and a payload trimmed of its oldest turns. Requests that fit, models
+// without a known window and models without variants are left alone.
+func (h *BaseAPIHandler) applyContextRouting(ctx context.Context, handlerType, modelName string, rawJSON []byte) (string, []byte) {
+	if h == nil || h.Cfg == nil || !h.Cfg.ContextRouting.Enabled || len(rawJSON) == 0 {
+		return modelName, rawJSON
+	}
+	if accessScopeError(ctx, modelName) != nil {
+		return modelName, rawJSON
+	}
+	suffix := thinking.ParseSuffix(modelName)
+	variants := contextVariants(ctx, suffix.ModelName)
+	current := -1
+	for i, variant := range variants {
+		if variant.id == suffix.ModelName {
+			current = i
+		}
+	}
+	if current < 0 || len(variants) < 2 {
+		return modelName, rawJSON
+	}
+	reserve := h.Cfg.ContextRouting.OutputReserveTokens
+	if reserve <= 0 {
+		reserve = defaultContextOutputReserve
+	}
+	if path := maxOutputTokensPath(handlerType, rawJSON); path != "" {
+		if requested := gjson.GetBytes(rawJSON, path).Int(); requested > 0 {
+			reserve = int(requested)
+		}
+	}
+	required := estimatePromptTokens(rawJSON) + reserve
+	if variants[current].window >= required {
+		return modelName, rawJSON
+	}
+
+	target := ""
+	for _, variant := range variants {
+		if variant.window >= required {
+			target = variant.id
+			break
+		}
+	}
+	if target == "" {
+		if !h.Cfg.ContextRouting.Compact {
+			log.Debugf("context routing: ~%d tokens fit no variant of %s", required, suffix.ModelName)
+			return modelName, rawJSON
+		}
+		largest := variants[len(variants)-1]
+		target = largest.id
+		var dropped int
+		rawJSON, dropped = compactConversation(handlerType, rawJSON, largest.window-reserve)
+		if dropped > 0 {
+			log.Infof("context routing: dropped %d oldest turns to fit %s (%d tokens)", dropped, target, largest.window)
+			if ctx != nil {
+				if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && !ginCtx.Writer.Written() {
+					ginCtx.Header(ContextCompactedHeader, strconv.Itoa(dropped))
+				}
+			}
+		}
+	}
+	if target == suffix.ModelName {
+		return modelName, rawJSON
+	}
+	log.Debugf("context routing: %s -> %s (~%d tokens)", suffix.ModelName, target, required)
+	if suffix.HasSuffix {
+		target += "(" + suffix.RawSuffix + ")"
+	}
+	return target, rawJSON
+}
+
+// contextVariants returns the available models of model's family that the caller may use and
+// whose context window is known, smallest window first and newest release first among equal
+// windows. A model served by several providers counts with its smallest window.
+func contextVariants(ctx context.Context, model string) []modelVariant {
+	family := datedModelSuffix.ReplaceAllString(model, "")
+	reg := registry.GetGlobalRegistry()
+	var variants []modelVariant
+	for _, info := range reg.GetAvailableModels("openai") {
+		id, _ := info["id"].(string)
+		if id == "" || datedModelSuffix.ReplaceAllString(id, "") != family || accessScopeError(ctx, id) != nil {
+			continue
+		}
+		window := 0
+		for _, provider := range reg.GetModelProviders(id) {
+			modelInfo := reg.GetModelInfo(id, provider)
+			if modelInfo == nil {
+				continue
+			}
+			limit := modelInfo.ContextLength
+			if limit <= 0 {
+				limit = modelInfo.InputTokenLimit
+			}
+			if limit > 0 && (window == 0 || limit < window) {
+				window = limit
+			}
+		}
+		if window > 0 {
+			variants = append(variants, modelVariant{id: id, window: window})
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		if variants[i].window != variants[j].window {
+			return variants[i].window < variants[j].window
+		}
+		return variants[i].id > variants[j].id
+	})
+	return variants
+}
+
+// estimatePromptTokens roughly estimates the tokens of a request from its text: four
+// characters of Latin script, or one character of other scripts, per token. Inline binary
+// data is skipped.
+func estimatePromptTokens(rawJSON []byte) int {
+	return textUnits(gjson.ParseBytes(rawJSON)) / 4
+}
+
+func textUnits(node gjson.Result) int {
+	switch {
+	case node.Type == gjson.String:
+		if strings.HasPrefix(node.Str, "data:") {
+			return 0
+		}
+		units := 0
+		for _, r := range node.Str {
+			if util.IsWesternRune(r) {
+				units++
+			} else {
+				units += 4
+			}
+		}
+		return units
+	case node.IsArray() || node.IsObject():
+		units := 0
+		node.ForEach(func(key, value gjson.Result) bool {
+			if key.Str != "data" {
+				units += textUnits(value)
+			}
+			return true
+		})
+		return units
+	}
+	return 0
+}
+
+// compactConversation drops the oldest turns of the conversation until its estimate fits
+// budget tokens. System turns are kept, and the remaining history always starts with a user
+// turn so no tool result loses its call.
+func compactConversation(handlerType string, rawJSON []byte, budget int) ([]byte, int) {
+	path := "messages"
+	switch handlerType {
+	case constant.OpenaiResponse:
+		path = "input"
+	case constant.Gemini:
+		path = "contents"
+	case constant.GeminiCLI:
+		path = "request.contents"
+	}
+	turns := gjson.GetBytes(rawJSON, path).Array()
+	if len(turns) < 2 {
+		return rawJSON, 0
+	}
+	pinned := func(turn gjson.Result) bool {
+		role := turn.Get("role").String()
+		return role == "system" || role == "developer"
+	}
+	opensConversation := func(turn gjson.Result) bool {
+		switch handlerType {
+		case constant.Claude:
+			return turn.Get("role").String() == "user" && !turn.Get(`content.#(type=="tool_result")`).Exists()
+		case constant.OpenaiResponse:
+			itemType := turn.Get("type").String()
+			return (itemType == "" || itemType == "message") && turn.Get("role").String() == "user"
+		case constant.Gemini, constant.GeminiCLI:
+			role := turn.Get("role").String()
+			return (role == "" || role == "user") && !turn.Get("parts.#(functionResponse)").Exists()
+		default:
+			return turn.Get("role").String() == "user"
+		}
+	}
+
+	// Cut at the earliest turn that can open the remaining history and brings the estimate
+	// within budget, or at the latest such turn when none does.
+	estimate := estimatePromptTokens(rawJSON)
+	cut, dropped := -1, 0
+	droppedTokens, droppedTurns := 0, 0
+	for i := 0; i < len(turns) && estimate > budget; i++ {
+		if pinned(turns[i]) {
+			continue
+		}
+		if droppedTurns > 0 && opensConversation(turns[i]) {
+			cut, dropped = i, droppedTurns
+			if estimate-droppedTokens <= budget {
+				break
+			}
+		}
+		droppedTokens += textUnits(turns[i]) / 4
+		droppedTurns++
+	}
+	if cut < 0 {
+		return rawJSON, 0
+	}
+	kept := make([]string, 0, len(turns)-dropped)
+	for i, turn := range turns {
+		if i >= cut || pinned(turn) {
+			kept = append(kept, turn.Raw)
+		}
+	}
+	out, err := sjson.SetRawBytes(rawJSON, path, []byte("["+strings.Join(kept, ",")+"]"))
+	if err != nil {
+		return rawJSON, 0
+	}
+	return out, dropped
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func registerContextVariants(t *testing.T) {
+	t.Helper()
+	registry.GetGlobalRegistry().RegisterClient("ctx-routing-auth", "codex", []*registry.ModelInfo{
+		{ID: "ctxtest-model", ContextLength: 1000},
+		{ID: "ctxtest-model-20250101", ContextLength: 2000},
+		{ID: "ctxtest-model-2025-03-01", InputTokenLimit: 8000},
+		{ID: "ctxtest-model-20250601", ContextLength: 50000},
+		{ID: "ctxtest-other", ContextLength: 1000000},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("ctx-routing-auth") })
+}
+
+// chatWithTokens builds an OpenAI chat request whose messages hold about tokens each.
+func chatWithTokens(maxTokens int, roles []string, tokens int) []byte {
+	messages := make([]string, len(roles))
+	for i, role := range roles {
+		messages[i] = fmt.Sprintf(`{"role":%q,"content":%q}`, role, strings.Repeat("word", tokens))
+	}
+	return []byte(fmt.Sprintf(`{"model":"ctxtest-model","max_tokens":%d,"messages":[%s]}`, maxTokens, strings.Join(messages, ",")))
+}
+
+func TestApplyContextRoutingPicksSmallestFittingVariant(t *testing.T) {
+	registerContextVariants(t)
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{ContextRouting: sdkconfig.ContextRoutingConfig{Enabled: true}}}
+	ctx := context.Background()
+
+	small := chatWithTokens(100, []string{"user"}, 100)
+	if model, _ := h.applyContextRouting(ctx, "openai", "ctxtest-model", small); model != "ctxtest-model" {
+		t.Errorf("fitting request routed to %s", model)
+	}
+	large := chatWithTokens(100, []string{"user"}, 3000)
+	if model, _ := h.applyContextRouting(ctx, "openai", "ctxtest-model", large); model != "ctxtest-model-2025-03-01" {
+		t.Errorf("large request routed to %s, want ctxtest-model-2025-03-01", model)
+	}
+	if model, _ := h.applyContextRouting(ctx, "openai", "ctxtest-model(high)", large); model != "ctxtest-model-2025-03-01(high)" {
+		t.Errorf("thinking suffix lost: %s", model)
+	}
+	// Without max_tokens the default output reserve of 4096 tokens applies.
+	noMax := []byte(fmt.Sprintf(`{"messages":[{"role":"user","content":%q}]}`, strings.Repeat("word", 5000)))
+	if model, _ := h.applyContextRouting(ctx, "openai", "ctxtest-model", noMax); model != "ctxtest-model-20250601" {
+		t.Errorf("request with output reserve routed to %s", model)
+	}
+	huge := chatWithTokens(100, []string{"user", "assistant", "user"}, 30000)
+	model, payload := h.applyContextRouting(ctx, "openai", "ctxtest-model", huge)
+	if model != "ctxtest-model" || string(payload) != string(huge) {
+		t.Errorf("request fitting no variant should be left alone without compaction, got %s", model)
+	}
+}
+
+func TestApplyContextRoutingCompacts(t *testing.T) {
+	registerContextVariants(t)
+	gin.SetMode(gin.TestMode)
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{ContextRouting: sdkconfig.ContextRoutingConfig{Enabled: true, Compact: true}}}
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	roles := []string{"system", "user", "assistant", "user", "assistant", "user", "assistant", "user"}
+	payload := chatWithTokens(100, roles, 10000)
+	payload = []byte(strings.Replace(string(payload), `"role":"system","content":"`+strings.Repeat("word", 10000), `"role":"system","content":"be brief`, 1))
+
+	model, out := h.applyContextRouting(ctx, "openai", "ctxtest-model", payload)
+	if model != "ctxtest-model-20250601" {
+		t.Fatalf("model = %s, want the largest variant", model)
+	}
+	if roles := gjson.GetBytes(out, "messages.#.role").Raw; roles != `["system","user","assistant","user"]` {
+		t.Errorf("remaining roles = %s", roles)
+	}
+	if got := recorder.Header().Get(ContextCompactedHeader); got != "4" {
+		t.Errorf("%s = %q, want 4", ContextCompactedHeader, got)
+	}
+}
+
+func TestCompactConversationKeepsToolResultsWithCalls(t *testing.T) {
+	payload := []byte(`{"messages":[
+		{"role":"user","content":"find it"},
+		{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"search","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"found"}]},
+		{"role":"assistant","content":"done"},
+		{"role":"user","content":"thanks"}
+	]}`)
+	out, dropped := compactConversation("claude", payload, 1)
+	if dropped != 4 || gjson.GetBytes(out, "messages.#").Int() != 1 || gjson.GetBytes(out, "messages.0.content").String() != "thanks" {
+		t.Fatalf("dropped %d, messages = %s", dropped, gjson.GetBytes(out, "messages").Raw)
+	}
+	// A conversation ending in a tool result cannot be cut without orphaning it.
+	trailing := []byte(`{"messages":[
+		{"role":"user","content":"find it"},
+		{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"search","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"found"}]}
+	]}`)
+	if _, dropped = compactConversation("claude", trailing, 1); dropped != 0 {
+		t.Fatalf("dropped %d turns of a single tool exchange", dropped)
+	}
+}
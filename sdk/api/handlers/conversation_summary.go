@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultConversationSummaryTTL = 24 * time.Hour
+	// conversationSummaryMax bounds remembered conversations; expired ones are purged first.
+	conversationSummaryMax = 8192
+	// conversationSummaryField is the extension field carrying the summary.
+	conversationSummaryField = "cliproxy_conversation"
+)
+
+// conversationTotals is the cumulative usage of a conversation as reported to clients.
+// Priced is false when some usage had no configured price, so CostUSD is a lower bound.
+type conversationTotals struct {
+	Requests        int64   `json:"requests"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	ReasoningTokens int64   `json:"reasoning_tokens"`
+	CachedTokens    int64   `json:"cached_tokens"`
+	TotalTokens     int64   `json:"total_tokens"`
+	CostUSD         float64 `json:"cost_usd"`
+	Priced          bool    `json:"priced"`
+}
+
+// conversationSummaryEntry is a conversation's totals and when they are forgotten.
+type conversationSummaryEntry struct {
+	totals conversationTotals
+	expire time.Time
+}
+
+// conversationSummaryTracker accumulates usage per conversation.
+type conversationSummaryTracker struct {
+	mu      sync.Mutex
+	entries map[string]conversationSummaryEntry
+	nowFn   func() time.Time
+}
+
+var conversationSummaries = &conversationSummaryTracker{entries: make(map[string]conversationSummaryEntry), nowFn: time.Now}
+
+// add charges one request's totals to conversation, extends its retention by ttl and
+// returns the conversation's new totals.
+func (t *conversationSummaryTracker) add(conversation string, request conversationTotals, ttl time.Duration) conversationTotals {
+	now := t.nowFn()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[conversation]
+	if ok && !now.Before(entry.expire) {
+		entry, ok = conversationSummaryEntry{}, false
+	}
+	if !ok {
+		if len(t.entries) >= conversationSummaryMax {
+			for key, e := range t.entries {
+				if !now.Before(e.expire) {
+					delete(t.entries, key)
+				}
+			}
+			if len(t.entries) >= conversationSummaryMax {
+				t.entries = make(map[string]conversationSummaryEntry)
+			}
+		}
+		entry.totals.Priced = true
+	}
+	totals := &entry.totals
+	totals.Requests += request.Requests
+	totals.InputTokens += request.InputTokens
+	totals.OutputTokens += request.OutputTokens
+	totals.ReasoningTokens += request.ReasoningTokens
+	totals.CachedTokens += request.CachedTokens
+	totals.TotalTokens += request.TotalTokens
+	totals.CostUSD += request.CostUSD
+	totals.Priced = totals.Priced && request.Priced
+	entry.expire = now.Add(ttl)
+	t.entries[conversation] = entry
+	return entry.totals
+}
+
+// conversationTally collects the usage records published while serving one request.
+type conversationTally struct {
+	conversation string
+	ttl          time.Duration
+
+	mu     sync.Mutex
+	totals conversationTotals
+}
+
+func (t *conversationTally) observe(record coreusage.Record) {
+	detail := record.Detail
+	cost, priced := pricing.Estimate(record.Model, detail)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totals.InputTokens += detail.InputTokens
+	t.totals.OutputTokens += detail.OutputTokens
+	t.totals.ReasoningTokens += detail.ReasoningTokens
+	t.totals.CachedTokens += detail.CachedTokens
+	t.totals.TotalTokens += detail.TotalTokens
+	t.totals.CostUSD += cost
+	if !priced && detail.TotalTokens+detail.InputTokens+detail.OutputTokens > 0 {
+		t.totals.Priced = false
+	}
+}
+
+// finish charges the request to its conversation and returns the summary JSON.
+func (t *conversationTally) finish() []byte {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	request := t.totals
+	t.mu.Unlock()
+	request.Requests = 1
+	out, err := json.Marshal(conversationSummaries.add(t.conversation, request, t.ttl))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// beginConversationSummary starts collecting the request's usage when conversation
+// summaries are enabled and the conversation can be identified.
+func (h *BaseAPIHandler) beginConversationSummary(ctx context.Context, handlerType, model string, rawJSON []byte) (context.Context, *conversationTally) {
+	if h == nil || h.Cfg == nil || !h.Cfg.ConversationSummary.Enabled || len(rawJSON) == 0 {
+		return ctx, nil
+	}
+	conversation, _ := conversationKey(ctx, handlerType, model, rawJSON)
+	if conversation == "" {
+		return ctx, nil
+	}
+	ttl := defaultConversationSummaryTTL
+	if minutes := h.Cfg.ConversationSummary.TTLMinutes; minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	tally := &conversationTally{conversation: conversation, ttl: ttl, totals: conversationTotals{Priced: true}}
+	return coreusage.WithObserver(ctx, tally.observe), tally
+}
+
+// appendConversationSummary adds the summary as a top-level field of a non-streaming response.
+func appendConversationSummary(out []byte, tally *conversationTally) []byte {
+	if tally == nil || !gjson.ValidBytes(out) || !gjson.ParseBytes(out).IsObject() {
+		return out
+	}
+	summary := tally.finish()
+	if summary == nil {
+		return out
+	}
+	if updated, err := sjson.SetRawBytes(out, conversationSummaryField, summary); err == nil {
+		return updated
+	}
+	return out
+}
+
+// conversationSummaryChunk returns the extra chunk ending a stream in the client's format,
+// or nil when the format has no place for one.
+func conversationSummaryChunk(handlerType, model, alt string, tally *conversationTally) []byte {
+	if tally == nil {
+		return nil
+	}
+	var template string
+	switch handlerType {
+	case constant.OpenAI:
+		template, _ = sjson.Set(`{"object":"chat.completion.chunk","choices":[]}`, "model", model)
+	case constant.OpenaiResponse:
+		template = `{"type":"cliproxy.conversation"}`
+	case constant.Claude:
+		template = `{"type":"cliproxy_conversation"}`
+	case constant.Gemini, constant.GeminiCLI:
+		if alt != "" {
+			return nil
+		}
+		template = `{}`
+	default:
+		return nil
+	}
+	summary := tally.finish()
+	if summary == nil {
+		return nil
+	}
+	payload, err := sjson.SetRaw(template, conversationSummaryField, string(summary))
+	if err != nil {
+		return nil
+	}
+	switch handlerType {
+	case constant.OpenaiResponse:
+		return []byte("event: cliproxy.conversation\ndata: " + payload)
+	case constant.Claude:
+		return []byte("event: cliproxy_conversation\ndata: " + payload + "\n\n")
+	}
+	return []byte(payload)
+}
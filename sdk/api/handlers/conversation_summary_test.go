@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestConversationSummaryAccumulatesAcrossTurns(t *testing.T) {
+	pricing.Set([]internalconfig.ModelPrice{{Model: "claude-sonnet-4-5", Input: 3, Output: 15}})
+	t.Cleanup(func() { pricing.Set(nil) })
+
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		ConversationSummary: sdkconfig.ConversationSummaryConfig{Enabled: true},
+	}}
+	turn := []byte(`{"model":"claude-sonnet-4-5","metadata":{"user_id":"summary-test-session"},"messages":[{"role":"user","content":"hi"}]}`)
+	record := coreusage.Record{Provider: "claude", Model: "claude-sonnet-4-5", Detail: coreusage.Detail{InputTokens: 1000, OutputTokens: 100, TotalTokens: 1100}}
+
+	ctx, tally := h.beginConversationSummary(context.Background(), "claude", "claude-sonnet-4-5", turn)
+	coreusage.PublishRecord(ctx, record)
+	out := appendConversationSummary([]byte(`{"type":"message"}`), tally)
+	if got := gjson.GetBytes(out, "cliproxy_conversation.total_tokens").Int(); got != 1100 {
+		t.Fatalf("total_tokens = %d, want 1100: %s", got, out)
+	}
+
+	ctx, tally = h.beginConversationSummary(context.Background(), "claude", "claude-sonnet-4-5", turn)
+	coreusage.PublishRecord(ctx, record)
+	chunk := string(conversationSummaryChunk("claude", "claude-sonnet-4-5", "", tally))
+	if !strings.HasPrefix(chunk, "event: cliproxy_conversation\ndata: ") || !strings.HasSuffix(chunk, "\n\n") {
+		t.Fatalf("unexpected claude chunk: %q", chunk)
+	}
+	summary := gjson.Get(strings.TrimSpace(strings.TrimPrefix(chunk, "event: cliproxy_conversation\ndata: ")), "cliproxy_conversation")
+	if summary.Get("requests").Int() != 2 || summary.Get("input_tokens").Int() != 2000 || !summary.Get("priced").Bool() {
+		t.Fatalf("unexpected summary: %s", summary.Raw)
+	}
+	if cost := summary.Get("cost_usd").Float(); cost < 0.00899 || cost > 0.00901 {
+		t.Fatalf("cost_usd = %v, want 0.009", cost)
+	}
+}
+
+func TestConversationSummaryChunkFormats(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		ConversationSummary: sdkconfig.ConversationSummaryConfig{Enabled: true},
+	}}
+	turn := []byte(`{"contents":[{"role":"user","parts":[{"text":"summary formats"}]}]}`)
+
+	ctx, tally := h.beginConversationSummary(context.Background(), "gemini", "gemini-2.5-pro", turn)
+	coreusage.PublishRecord(ctx, coreusage.Record{Model: "gemini-2.5-pro", Detail: coreusage.Detail{OutputTokens: 10, TotalTokens: 10}})
+	if chunk := conversationSummaryChunk("gemini", "gemini-2.5-pro", "json", tally); chunk != nil {
+		t.Fatalf("alt streams must not get a summary chunk: %s", chunk)
+	}
+	chunk := conversationSummaryChunk("gemini", "gemini-2.5-pro", "", tally)
+	if gjson.GetBytes(chunk, "cliproxy_conversation.output_tokens").Int() != 10 || gjson.GetBytes(chunk, "cliproxy_conversation.priced").Bool() {
+		t.Fatalf("unexpected gemini chunk: %s", chunk)
+	}
+
+	openAI := conversationSummaryChunk("openai", "gpt-5", "", &conversationTally{conversation: "summary-openai", ttl: defaultConversationSummaryTTL})
+	if gjson.GetBytes(openAI, "object").String() != "chat.completion.chunk" || gjson.GetBytes(openAI, "model").String() != "gpt-5" || !gjson.GetBytes(openAI, "cliproxy_conversation").Exists() {
+		t.Fatalf("unexpected openai chunk: %s", openAI)
+	}
+
+	disabled := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}
+	if _, tally = disabled.beginConversationSummary(context.Background(), "gemini", "gemini-2.5-pro", turn); tally != nil {
+		t.Fatal("summaries must be opt-in")
+	}
+}
@@ -226,5 +226,6 @@ func (h *GeminiCLIAPIHandler) forwardCLIStream(c *gin.Context, flusher http.Flus
 				_, _ = c.Writer.Write(body)
 			}
 		},
+		IsBoundary: geminiChunkBoundary,
 	})
 }
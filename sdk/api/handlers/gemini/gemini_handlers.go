@@ -6,6 +6,7 @@
 package gemini
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
@@ -48,7 +49,7 @@ func (h *GeminiAPIHandler) Models() []map[string]any {
 // GeminiModels handles the Gemini models listing endpoint.
 // It returns a JSON response containing available Gemini models and their specifications.
 func (h *GeminiAPIHandler) GeminiModels(c *gin.Context) {
-	rawModels := h.Models()
+	rawModels, stale := handlers.ListModels("gemini")
 	normalizedModels := make([]map[string]any, 0, len(rawModels))
 	defaultMethods := []string{"generateContent"}
 	for _, model := range rawModels {
@@ -72,9 +73,9 @@ func (h *GeminiAPIHandler) GeminiModels(c *gin.Context) {
 		}
 		normalizedModels = append(normalizedModels, normalizedModel)
 	}
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, handlers.AddModelListStaleness(gin.H{
 		"models": normalizedModels,
-	})
+	}, stale))
 }
 
 // GeminiGetHandler handles GET requests for specific Gemini model information.
@@ -334,5 +335,12 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 				_, _ = c.Writer.Write(body)
 			}
 		},
+		IsBoundary: geminiChunkBoundary,
 	})
 }
+
+// geminiChunkBoundary reports whether a Gemini stream chunk carries a function call or a
+// finish reason, which are forwarded without coalescing delay.
+func geminiChunkBoundary(chunk []byte) bool {
+	return bytes.Contains(chunk, []byte(`"functionCall"`)) || bytes.Contains(chunk, []byte(`"finishReason"`))
+}
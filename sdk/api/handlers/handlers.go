@@ -14,9 +14,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
@@ -241,6 +243,9 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil && tracing.SpanFromContext(parentCtx) == nil {
+		parentCtx = tracing.ContextWithSpan(parentCtx, tracing.SpanFromContext(requestCtx))
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
 		go func() {
@@ -254,7 +259,7 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 	newCtx = context.WithValue(newCtx, "gin", c)
 	newCtx = context.WithValue(newCtx, "handler", handler)
 	return newCtx, func(params ...interface{}) {
-		if h.Cfg.RequestLog && len(params) == 1 {
+		if len(params) == 1 && logging.ResolveRequestLogLevel(c, h.Cfg) == config.RequestLogLevelFull {
 			if existing, exists := c.Get("API_RESPONSE"); exists {
 				if existingBytes, ok := existing.([]byte); ok && len(bytes.TrimSpace(existingBytes)) > 0 {
 					switch params[0].(type) {
@@ -371,12 +376,47 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	rawJSON = h.normalizeRequest(handlerType, rawJSON)
+	overrides, rawJSON, errMsg := extractRequestOverrides(rawJSON)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	rawJSON, errMsg = h.applyPromptTemplate(ctx, handlerType, rawJSON)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	modelName = h.applyLanguageRouting(ctx, modelName, rawJSON)
+	modelName, rawJSON = h.applyContextRouting(ctx, handlerType, modelName, rawJSON)
+	if errMsg := accessScopeError(ctx, modelName); errMsg != nil {
+		return nil, errMsg
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	if providers, errMsg = overrides.narrowProviders(providers, modelName); errMsg != nil {
+		return nil, errMsg
+	}
+	if errMsg = h.strictParamsError(ctx, handlerType, providers, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
+	cacheKey := h.responseCacheKey(ctx, handlerType, normalizedModel, alt, false, rawJSON)
+	if cacheKey != "" {
+		if cached := responseCaches.get(cacheKey); cached != nil {
+			setResponseCacheHeader(ctx, "hit")
+			return cloneBytes(cached[0]), nil
+		}
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	ctx, cancel := overrides.apply(ctx, reqMeta)
+	defer cancel()
+	rawJSON = h.applySystemPromptDedup(ctx, handlerType, normalizedModel, rawJSON, reqMeta)
+	ctx, rawJSON, errMsg = h.applyOutputBudget(ctx, handlerType, normalizedModel, rawJSON)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	ctx, summary := h.beginConversationSummary(ctx, handlerType, normalizedModel, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -394,6 +434,13 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	opts.Metadata = reqMeta
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
 	if err != nil {
+		if errMsg := overrides.timeoutError(ctx); errMsg != nil {
+			return nil, errMsg
+		}
+		if cached := degradedFallback(cacheKey, err); cached != nil {
+			setResponseCacheHeader(ctx, "stale")
+			return cloneBytes(cached[0]), nil
+		}
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -408,18 +455,45 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	return resp.Payload, nil
+	out := h.outputPipelineFor(ctx).process(resp.Payload, true)
+	if cacheKey != "" {
+		ttl, staleFor, maxBytes := h.responseCacheLimits()
+		responseCaches.put(cacheKey, [][]byte{cloneBytes(out)}, ttl, staleFor, maxBytes)
+		setResponseCacheHeader(ctx, "miss")
+	}
+	return appendConversationSummary(out, summary), nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	rawJSON = h.normalizeRequest(handlerType, rawJSON)
+	overrides, rawJSON, errMsg := extractRequestOverrides(rawJSON)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	rawJSON, errMsg = h.applyPromptTemplate(ctx, handlerType, rawJSON)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	modelName = h.applyLanguageRouting(ctx, modelName, rawJSON)
+	if errMsg := accessScopeError(ctx, modelName); errMsg != nil {
+		return nil, errMsg
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	if providers, errMsg = overrides.narrowProviders(providers, modelName); errMsg != nil {
+		return nil, errMsg
+	}
+	if errMsg = h.strictParamsError(ctx, handlerType, providers, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	ctx, cancel := overrides.apply(ctx, reqMeta)
+	defer cancel()
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -437,6 +511,9 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	opts.Metadata = reqMeta
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
 	if err != nil {
+		if errMsg := overrides.timeoutError(ctx); errMsg != nil {
+			return nil, errMsg
+		}
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -457,15 +534,54 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	rawJSON = h.normalizeRequest(handlerType, rawJSON)
+	overrides, rawJSON, errMsg := extractRequestOverrides(rawJSON)
+	if errMsg == nil {
+		rawJSON, errMsg = h.applyPromptTemplate(ctx, handlerType, rawJSON)
+	}
+	var providers []string
+	var normalizedModel string
+	if errMsg == nil {
+		modelName = h.applyLanguageRouting(ctx, modelName, rawJSON)
+		modelName, rawJSON = h.applyContextRouting(ctx, handlerType, modelName, rawJSON)
+		providers, normalizedModel, errMsg = h.getRequestDetails(modelName)
+		if scopeErr := accessScopeError(ctx, modelName); scopeErr != nil {
+			errMsg = scopeErr
+		}
+	}
+	if errMsg == nil {
+		providers, errMsg = overrides.narrowProviders(providers, modelName)
+	}
+	if errMsg == nil {
+		errMsg = h.strictParamsError(ctx, handlerType, providers, rawJSON)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
 		close(errChan)
 		return nil, errChan
 	}
+	cacheKey := h.responseCacheKey(ctx, handlerType, normalizedModel, alt, true, rawJSON)
+	if cacheKey != "" {
+		if cached := responseCaches.get(cacheKey); cached != nil {
+			setResponseCacheHeader(ctx, "hit")
+			return replayResponseStream(cached)
+		}
+		setResponseCacheHeader(ctx, "miss")
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	ctx, cancel := overrides.apply(ctx, reqMeta)
+	rawJSON = h.applySystemPromptDedup(ctx, handlerType, normalizedModel, rawJSON, reqMeta)
+	ctx, rawJSON, errMsg = h.applyOutputBudget(ctx, handlerType, normalizedModel, rawJSON)
+	if errMsg != nil {
+		cancel()
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
+	ctx, summary := h.beginConversationSummary(ctx, handlerType, normalizedModel, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -483,7 +599,17 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	opts.Metadata = reqMeta
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
+		defer cancel()
 		errChan := make(chan *interfaces.ErrorMessage, 1)
+		if errMsg := overrides.timeoutError(ctx); errMsg != nil {
+			errChan <- errMsg
+			close(errChan)
+			return nil, errChan
+		}
+		if cached := degradedFallback(cacheKey, err); cached != nil {
+			setResponseCacheHeader(ctx, "stale")
+			return replayResponseStream(cached)
+		}
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -500,14 +626,21 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, errChan
 	}
+	output := h.outputPipelineFor(ctx)
+	recorder := h.newResponseStreamRecorder(cacheKey)
+	live := h.newLiveStream(ctx, handlerType, modelName)
+	tracked := h.trackStream(ctx, handlerType, modelName, cancel)
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
+		defer tracked.done()
+		defer cancel()
+		defer live.close()
 		defer close(dataChan)
 		defer close(errChan)
 		sentPayload := false
 		bootstrapRetries := 0
-		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		maxBootstrapRetries := overrides.bootstrapRetries(StreamingBootstrapRetries(h.Cfg))
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
 			if ctx == nil {
@@ -557,13 +690,24 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				if ctx != nil {
 					select {
 					case <-ctx.Done():
+						if errMsg := overrides.timeoutError(ctx); errMsg != nil {
+							errChan <- errMsg
+						}
 						return
 					case chunk, ok = <-chunks:
 					}
 				} else {
 					chunk, ok = <-chunks
 				}
+				tracked.touch()
 				if !ok {
+					recorder.commit()
+					// The summary is sent after recording so cached replays never repeat stale totals.
+					if sentPayload {
+						if final := conversationSummaryChunk(handlerType, modelName, alt, summary); final != nil {
+							_ = sendData(final)
+						}
+					}
 					return
 				}
 				if chunk.Err != nil {
@@ -599,9 +743,13 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				}
 				if len(chunk.Payload) > 0 {
 					sentPayload = true
-					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
+					processed := output.process(cloneBytes(chunk.Payload), false)
+					if okSendData := sendData(processed); !okSendData {
 						return
 					}
+					tracked.touch()
+					recorder.add(processed)
+					live.publish(processed)
 				}
 			}
 		}
@@ -609,6 +757,36 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	return dataChan, errChan
 }
 
+// accessScopeError rejects requests whose credential is restricted to a model allow-list
+// (scoped managed tokens) that does not include the requested model.
+func accessScopeError(ctx context.Context, modelName string) *interfaces.ErrorMessage {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	raw, exists := ginCtx.Get("accessMetadata")
+	if !exists {
+		return nil
+	}
+	metadata, ok := raw.(map[string]string)
+	if !ok {
+		return nil
+	}
+	allowed := strings.TrimSpace(metadata[managedkeys.MetadataAllowedModels])
+	if allowed == "" {
+		return nil
+	}
+	list := strings.Split(allowed, ",")
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	if managedkeys.ModelAllowed(list, modelName) || managedkeys.ModelAllowed(list, baseModel) {
+		return nil
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("model %s is not permitted for this API key", modelName)}
+}
+
 func statusFromError(err error) int {
 	if err == nil {
 		return 0
@@ -717,7 +895,7 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 }
 
 func (h *BaseAPIHandler) LoggingAPIResponseError(ctx context.Context, err *interfaces.ErrorMessage) {
-	if h.Cfg.RequestLog {
+	if h.Cfg.RequestLogActive() {
 		if ginContext, ok := ctx.Value("gin").(*gin.Context); ok {
 			if apiResponseErrors, isExist := ginContext.Get("API_RESPONSE_ERROR"); isExist {
 				if slicesAPIResponseError, isOk := apiResponseErrors.([]*interfaces.ErrorMessage); isOk {
@@ -0,0 +1,618 @@
+// Package jobs provides deferred request execution: a request is queued with POST /v1/jobs
+// and runs once its start time has come and, optionally, once the remaining quota of a model
+// has recovered past a threshold. Results are kept for retrieval by job ID and can be posted
+// to a webhook, so bulk work can be pushed into quota valleys without a client waiting on it.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultMaxPending    = 100
+	defaultMaxWait       = 24 * time.Hour
+	defaultResultTTL     = 24 * time.Hour
+	defaultCheckInterval = 30 * time.Second
+	webhookTimeout       = 10 * time.Second
+)
+
+// Job statuses.
+const (
+	StatusScheduled = "scheduled"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	// StatusExpired means the start time or quota condition was not reached within the wait limit.
+	StatusExpired = "expired"
+)
+
+// jobEndpoints maps the endpoints a job may target to their request format.
+var jobEndpoints = map[string]string{
+	"/v1/chat/completions": OpenAI,
+	"/v1/messages":         Claude,
+	"/v1/responses":        OpenaiResponse,
+	"/v1/embeddings":       OpenAIEmbedding,
+}
+
+// QuotaCondition holds a job back until the remaining quota of Model, averaged over the
+// credentials serving it, is at least MinRemainingPercent.
+type QuotaCondition struct {
+	Model               string  `json:"model"`
+	MinRemainingPercent float64 `json:"min_remaining_percent"`
+}
+
+// Job is a queued request and, once it has run, its result.
+type Job struct {
+	ID            string          `json:"id"`
+	Object        string          `json:"object"`
+	Status        string          `json:"status"`
+	Endpoint      string          `json:"endpoint"`
+	Model         string          `json:"model"`
+	CreatedAt     time.Time       `json:"created_at"`
+	RunAt         *time.Time      `json:"run_at,omitempty"`
+	WhenQuota     *QuotaCondition `json:"when_quota,omitempty"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	StartedAt     *time.Time      `json:"started_at,omitempty"`
+	FinishedAt    *time.Time      `json:"finished_at,omitempty"`
+	StatusCode    int             `json:"status_code,omitempty"`
+	Result        json.RawMessage `json:"result,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	WebhookURL    string          `json:"webhook_url,omitempty"`
+	WebhookStatus string          `json:"webhook_status,omitempty"`
+	// Requeue reschedules the job to the pool's reset time when it fails because every
+	// credential for the model is exhausted.
+	Requeue  bool `json:"requeue,omitempty"`
+	Requeues int  `json:"requeues,omitempty"`
+
+	handlerType string
+	body        []byte
+	owners      []string
+	// ginCtx is a copy of the enqueuing request's context, detached from its cancellation,
+	// so the job runs with the caller's key scope and usage attribution.
+	ginCtx *gin.Context
+}
+
+// JobsAPIHandler serves /v1/jobs and runs the queued jobs in the background.
+type JobsAPIHandler struct {
+	*handlers.BaseAPIHandler
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	started bool
+	wake    chan struct{}
+
+	// now and quotaPercent are replaced in tests.
+	now          func() time.Time
+	quotaPercent func(model string) (float64, bool)
+	httpClient   *http.Client
+}
+
+// NewJobsAPIHandler creates a new scheduled jobs handler.
+func NewJobsAPIHandler(apiHandlers *handlers.BaseAPIHandler) *JobsAPIHandler {
+	h := &JobsAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		jobs:           make(map[string]*Job),
+		wake:           make(chan struct{}, 1),
+		now:            time.Now,
+		httpClient:     &http.Client{Timeout: webhookTimeout},
+	}
+	h.quotaPercent = h.poolQuotaPercent
+	return h
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *JobsAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *JobsAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// Create handles POST /v1/jobs. The body names the target endpoint and carries the request
+// under "body"; "run_at" (RFC 3339) or "delay_seconds" set the earliest start, "when_quota"
+// holds the job until a model's quota has recovered and "webhook_url" receives the finished job.
+func (h *JobsAPIHandler) Create(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "scheduled jobs are disabled", "invalid_request_error")
+		return
+	}
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "invalid_request_error")
+		return
+	}
+	job, errMsg := h.parseJob(rawJSON)
+	if errMsg != "" {
+		writeError(c, http.StatusBadRequest, "Invalid request: "+errMsg, "invalid_request_error")
+		return
+	}
+	job.owners = logging.ClientKeyIdentifiers(c)
+	job.ginCtx = c.Copy()
+	if job.ginCtx.Request != nil {
+		job.ginCtx.Request = job.ginCtx.Request.WithContext(context.WithoutCancel(job.ginCtx.Request.Context()))
+	}
+
+	h.mu.Lock()
+	pending := 0
+	for _, existing := range h.jobs {
+		if existing.Status == StatusScheduled || existing.Status == StatusRunning {
+			pending++
+		}
+	}
+	if pending >= h.maxPending() {
+		h.mu.Unlock()
+		writeError(c, http.StatusTooManyRequests, "too many pending jobs", "rate_limit_error")
+		return
+	}
+	h.jobs[job.ID] = job
+	view := *job
+	if !h.started {
+		h.started = true
+		go h.loop()
+	}
+	h.mu.Unlock()
+
+	h.signal()
+	c.JSON(http.StatusAccepted, view)
+}
+
+// List handles GET /v1/jobs and returns the caller's jobs, newest first, without results.
+func (h *JobsAPIHandler) List(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "scheduled jobs are disabled", "invalid_request_error")
+		return
+	}
+	callers := logging.ClientKeyIdentifiers(c)
+	h.mu.Lock()
+	h.pruneLocked()
+	out := make([]Job, 0, len(h.jobs))
+	for _, job := range h.jobs {
+		if job.ownedBy(callers) {
+			view := *job
+			view.Result = nil
+			out = append(out, view)
+		}
+	}
+	h.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": out})
+}
+
+// Get handles GET /v1/jobs/:id.
+func (h *JobsAPIHandler) Get(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "scheduled jobs are disabled", "invalid_request_error")
+		return
+	}
+	h.mu.Lock()
+	h.pruneLocked()
+	job := h.lookupLocked(c)
+	var view Job
+	if job != nil {
+		view = *job
+	}
+	h.mu.Unlock()
+	if job == nil {
+		writeError(c, http.StatusNotFound, "job not found", "invalid_request_error")
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// Cancel handles DELETE /v1/jobs/:id. A job that has not started is cancelled; a finished
+// job is removed together with its result. Running jobs cannot be cancelled.
+func (h *JobsAPIHandler) Cancel(c *gin.Context) {
+	if !h.enabled() {
+		writeError(c, http.StatusNotFound, "scheduled jobs are disabled", "invalid_request_error")
+		return
+	}
+	h.mu.Lock()
+	job := h.lookupLocked(c)
+	if job == nil {
+		h.mu.Unlock()
+		writeError(c, http.StatusNotFound, "job not found", "invalid_request_error")
+		return
+	}
+	switch job.Status {
+	case StatusRunning:
+		h.mu.Unlock()
+		writeError(c, http.StatusConflict, "job is running", "invalid_request_error")
+		return
+	case StatusScheduled:
+		now := h.now()
+		job.Status = StatusCancelled
+		job.FinishedAt = &now
+		job.body = nil
+		job.ginCtx = nil
+	default:
+		delete(h.jobs, job.ID)
+	}
+	view := *job
+	h.mu.Unlock()
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *JobsAPIHandler) lookupLocked(c *gin.Context) *Job {
+	job := h.jobs[c.Param("id")]
+	if job == nil || !job.ownedBy(logging.ClientKeyIdentifiers(c)) {
+		return nil
+	}
+	return job
+}
+
+// ownedBy reports whether a caller with the given key identifiers enqueued the job. Jobs
+// created without client authentication are visible to every caller.
+func (j *Job) ownedBy(callers []string) bool {
+	if len(j.owners) == 0 {
+		return true
+	}
+	for _, id := range callers {
+		if slices.Contains(j.owners, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseJob validates a job request and returns the scheduled job, or a message describing
+// why the request is invalid.
+func (h *JobsAPIHandler) parseJob(rawJSON []byte) (*Job, string) {
+	if !gjson.ValidBytes(rawJSON) {
+		return nil, "body must be a JSON object"
+	}
+	root := gjson.ParseBytes(rawJSON)
+	endpoint := root.Get("endpoint").String()
+	if endpoint == "" {
+		endpoint = "/v1/chat/completions"
+	}
+	handlerType, ok := jobEndpoints[endpoint]
+	if !ok {
+		return nil, fmt.Sprintf("unsupported endpoint %q", endpoint)
+	}
+	body := root.Get("body")
+	if !body.IsObject() {
+		return nil, "body must be an object holding the request"
+	}
+	model := body.Get("model").String()
+	if model == "" {
+		return nil, "body.model is required"
+	}
+	if body.Get("stream").Bool() {
+		return nil, "streaming requests cannot be scheduled"
+	}
+
+	now := h.now()
+	job := &Job{
+		ID:          newJobID(),
+		Object:      "job",
+		Status:      StatusScheduled,
+		Endpoint:    endpoint,
+		Model:       model,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(h.maxWait()),
+		handlerType: handlerType,
+		body:        []byte(body.Raw),
+	}
+	if v := root.Get("run_at"); v.Exists() {
+		runAt, err := time.Parse(time.RFC3339, v.String())
+		if err != nil {
+			return nil, "run_at must be an RFC 3339 timestamp"
+		}
+		job.RunAt = &runAt
+	} else if v := root.Get("delay_seconds"); v.Exists() {
+		if v.Type != gjson.Number || v.Int() < 0 {
+			return nil, "delay_seconds must be a non-negative number"
+		}
+		runAt := now.Add(time.Duration(v.Int()) * time.Second)
+		job.RunAt = &runAt
+	}
+	if job.RunAt != nil && job.RunAt.After(job.ExpiresAt) {
+		return nil, fmt.Sprintf("run_at is further away than the %s wait limit", h.maxWait())
+	}
+	if v := root.Get("when_quota"); v.Exists() {
+		if !v.IsObject() {
+			return nil, "when_quota must be an object"
+		}
+		cond := &QuotaCondition{
+			Model:               v.Get("model").String(),
+			MinRemainingPercent: v.Get("min_remaining_percent").Float(),
+		}
+		if cond.Model == "" {
+			cond.Model = model
+		}
+		if cond.MinRemainingPercent <= 0 || cond.MinRemainingPercent > 100 {
+			return nil, "when_quota.min_remaining_percent must be between 0 and 100"
+		}
+		job.WhenQuota = cond
+	}
+	job.Requeue = h.Cfg != nil && h.Cfg.ScheduledJobs.RequeueExhausted
+	if v := root.Get("requeue"); v.Exists() {
+		if !v.IsBool() {
+			return nil, "requeue must be a boolean"
+		}
+		job.Requeue = v.Bool()
+	}
+	if v := root.Get("webhook_url"); v.Exists() {
+		if errMsg := h.checkWebhook(v.String()); errMsg != "" {
+			return nil, errMsg
+		}
+		job.WebhookURL = v.String()
+	}
+	return job, ""
+}
+
+func (h *JobsAPIHandler) checkWebhook(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "webhook_url must be an http or https URL"
+	}
+	var hosts []string
+	if h.Cfg != nil {
+		hosts = h.Cfg.ScheduledJobs.WebhookHosts
+	}
+	for _, host := range hosts {
+		if strings.EqualFold(strings.TrimSpace(host), u.Hostname()) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("webhook host %q is not allowed", u.Hostname())
+}
+
+// loop checks the queue every check interval, or sooner when a job is added.
+func (h *JobsAPIHandler) loop() {
+	ticker := time.NewTicker(h.checkInterval())
+	defer ticker.Stop()
+	for {
+		h.runDue()
+		select {
+		case <-ticker.C:
+		case <-h.wake:
+		}
+	}
+}
+
+func (h *JobsAPIHandler) signal() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runDue starts every job whose start time has come and whose quota condition holds, and
+// expires jobs that waited too long. Jobs run concurrently.
+func (h *JobsAPIHandler) runDue() {
+	now := h.now()
+	var due []*Job
+	h.mu.Lock()
+	h.pruneLocked()
+	for _, job := range h.jobs {
+		if job.Status != StatusScheduled {
+			continue
+		}
+		if !now.Before(job.ExpiresAt) {
+			h.finishLocked(job, StatusExpired, 0, nil, "start time or quota condition not reached before the job expired")
+			go h.deliver(job.ID)
+			continue
+		}
+		if job.RunAt != nil && now.Before(*job.RunAt) {
+			continue
+		}
+		if cond := job.WhenQuota; cond != nil {
+			if percent, ok := h.quotaPercent(cond.Model); !ok || percent < cond.MinRemainingPercent {
+				continue
+			}
+		}
+		started := now
+		job.Status = StatusRunning
+		job.StartedAt = &started
+		due = append(due, job)
+	}
+	h.mu.Unlock()
+
+	for _, job := range due {
+		go h.execute(job)
+	}
+}
+
+// execute runs a job through the regular request pipeline and records the outcome.
+func (h *JobsAPIHandler) execute(job *Job) {
+	// The detached request context is the parent, so the job keeps its request ID and is not
+	// tied to the lifetime of the enqueuing request.
+	parent := context.Background()
+	if job.ginCtx != nil && job.ginCtx.Request != nil {
+		parent = job.ginCtx.Request.Context()
+	}
+	ctx, cancel := h.GetContextWithCancel(h, job.ginCtx, parent)
+	resp, errMsg := h.ExecuteWithAuthManager(ctx, job.handlerType, job.Model, job.body, "")
+	h.mu.Lock()
+	if errMsg != nil && h.requeueLocked(job, errMsg) {
+		h.mu.Unlock()
+		cancel(errMsg.Error)
+		h.deliver(job.ID)
+		return
+	}
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		message := "request failed"
+		if errMsg.Error != nil {
+			message = errMsg.Error.Error()
+		}
+		h.finishLocked(job, StatusFailed, status, nil, message)
+	} else {
+		h.finishLocked(job, StatusCompleted, http.StatusOK, resp, "")
+	}
+	h.mu.Unlock()
+	if errMsg != nil {
+		cancel(errMsg.Error)
+	} else {
+		cancel(resp)
+	}
+	h.deliver(job.ID)
+}
+
+func (h *JobsAPIHandler) finishLocked(job *Job, status string, statusCode int, result []byte, errText string) {
+	now := h.now()
+	job.Status = status
+	job.StatusCode = statusCode
+	job.FinishedAt = &now
+	job.Error = errText
+	if len(result) > 0 {
+		if json.Valid(result) {
+			job.Result = json.RawMessage(result)
+		} else {
+			job.Result, _ = json.Marshal(string(result))
+		}
+	}
+	job.body = nil
+	job.ginCtx = nil
+}
+
+// deliver posts the finished job to its webhook, if any, and records the outcome.
+func (h *JobsAPIHandler) deliver(id string) {
+	h.mu.Lock()
+	job := h.jobs[id]
+	if job == nil || job.WebhookURL == "" {
+		h.mu.Unlock()
+		return
+	}
+	view := *job
+	h.mu.Unlock()
+
+	status := "delivered"
+	payload, err := json.Marshal(view)
+	if err == nil {
+		var req *http.Request
+		req, err = http.NewRequest(http.MethodPost, view.WebhookURL, bytes.NewReader(payload))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			var resp *http.Response
+			resp, err = h.httpClient.Do(req)
+			if err == nil {
+				_ = resp.Body.Close()
+				if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+					err = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+				}
+			}
+		}
+	}
+	if err != nil {
+		log.Warnf("scheduled jobs: deliver %s to webhook failed: %v", id, err)
+		status = "failed: " + err.Error()
+	}
+	h.mu.Lock()
+	if job := h.jobs[id]; job != nil {
+		job.WebhookStatus = status
+	}
+	h.mu.Unlock()
+}
+
+// pruneLocked drops finished jobs older than the result TTL.
+func (h *JobsAPIHandler) pruneLocked() {
+	cutoff := h.now().Add(-h.resultTTL())
+	for id, job := range h.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(h.jobs, id)
+		}
+	}
+}
+
+// poolQuotaPercent averages the remaining quota reported for model over the enabled
+// credentials serving it, as GET /v1/limits does.
+func (h *JobsAPIHandler) poolQuotaPercent(model string) (float64, bool) {
+	if h.AuthManager == nil {
+		return 0, false
+	}
+	reg := registry.GetGlobalRegistry()
+	sum, n := 0.0, 0
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		if !reg.ClientSupportsModel(auth.ID, model) {
+			continue
+		}
+		if percent, ok := quota.GetPercentFromMetadata(auth.Metadata, model); ok {
+			sum += percent
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+func (h *JobsAPIHandler) enabled() bool {
+	return h.Cfg != nil && h.Cfg.ScheduledJobs.Enabled
+}
+
+func (h *JobsAPIHandler) maxPending() int {
+	if h.Cfg != nil && h.Cfg.ScheduledJobs.MaxPending > 0 {
+		return h.Cfg.ScheduledJobs.MaxPending
+	}
+	return defaultMaxPending
+}
+
+func (h *JobsAPIHandler) maxWait() time.Duration {
+	if h.Cfg != nil && h.Cfg.ScheduledJobs.MaxWaitMinutes > 0 {
+		return time.Duration(h.Cfg.ScheduledJobs.MaxWaitMinutes) * time.Minute
+	}
+	return defaultMaxWait
+}
+
+func (h *JobsAPIHandler) resultTTL() time.Duration {
+	if h.Cfg != nil && h.Cfg.ScheduledJobs.ResultTTLMinutes > 0 {
+		return time.Duration(h.Cfg.ScheduledJobs.ResultTTLMinutes) * time.Minute
+	}
+	return defaultResultTTL
+}
+
+func (h *JobsAPIHandler) checkInterval() time.Duration {
+	if h.Cfg != nil && h.Cfg.ScheduledJobs.CheckIntervalSeconds > 0 {
+		return time.Duration(h.Cfg.ScheduledJobs.CheckIntervalSeconds) * time.Second
+	}
+	return defaultCheckInterval
+}
+
+func newJobID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "job-" + hex.EncodeToString(b[:])
+}
+
+func writeError(c *gin.Context, status int, message, errType string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
@@ -0,0 +1,320 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type jobsTestExecutor struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *jobsTestExecutor) Identifier() string { return "jobs-test-provider" }
+
+func (e *jobsTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`)}, nil
+}
+
+func (e *jobsTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *jobsTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *jobsTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *jobsTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *jobsTestExecutor) callCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+// newJobsTestRouter serves the jobs endpoints; the X-Test-Key header stands in for the
+// authenticated client key.
+func newJobsTestRouter(t *testing.T, cfg *sdkconfig.SDKConfig) (*gin.Engine, *JobsAPIHandler, *jobsTestExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &jobsTestExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "jobs-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "jobs-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewJobsAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+		}
+	})
+	router.POST("/v1/jobs", h.Create)
+	router.GET("/v1/jobs", h.List)
+	router.GET("/v1/jobs/:id", h.Get)
+	router.DELETE("/v1/jobs/:id", h.Cancel)
+	return router, h, executor
+}
+
+func doJobs(router *gin.Engine, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-Test-Key", key)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+// waitForStatus polls the job until it reaches status or the deadline passes.
+func waitForStatus(t *testing.T, router *gin.Engine, id, key, status string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := doJobs(router, http.MethodGet, "/v1/jobs/"+id, key, "").Body.String()
+		if gjson.Get(body, "status").String() == status {
+			return body
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not reach %s: %s", id, status, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func enabledJobsConfig() *sdkconfig.SDKConfig {
+	return &sdkconfig.SDKConfig{ScheduledJobs: sdkconfig.ScheduledJobsConfig{Enabled: true, CheckIntervalSeconds: 1}}
+}
+
+func TestJobRunsAndKeepsResult(t *testing.T) {
+	router, _, executor := newJobsTestRouter(t, enabledJobsConfig())
+
+	resp := doJobs(router, http.MethodPost, "/v1/jobs", "key-a", `{"body":{"model":"jobs-model","messages":[{"role":"user","content":"hi"}]}}`)
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+	if gjson.Get(resp.Body.String(), "endpoint").String() != "/v1/chat/completions" {
+		t.Fatalf("default endpoint not applied: %s", resp.Body.String())
+	}
+
+	body := waitForStatus(t, router, id, "key-a", StatusCompleted)
+	if gjson.Get(body, "result.choices.0.message.content").String() != "done" || gjson.Get(body, "status_code").Int() != http.StatusOK {
+		t.Fatalf("unexpected job %s", body)
+	}
+	if executor.callCount() != 1 {
+		t.Fatalf("executor calls = %d, want 1", executor.callCount())
+	}
+
+	if resp := doJobs(router, http.MethodGet, "/v1/jobs/"+id, "key-b", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("other key status = %d", resp.Code)
+	}
+	list := doJobs(router, http.MethodGet, "/v1/jobs", "key-b", "").Body.String()
+	if gjson.Get(list, "data.#").Int() != 0 {
+		t.Fatalf("other key sees jobs: %s", list)
+	}
+	if resp := doJobs(router, http.MethodDelete, "/v1/jobs/"+id, "key-a", ""); resp.Code != http.StatusOK {
+		t.Fatalf("delete status = %d", resp.Code)
+	}
+	if resp := doJobs(router, http.MethodGet, "/v1/jobs/"+id, "key-a", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("deleted job status = %d", resp.Code)
+	}
+}
+
+func TestJobWaitsForQuotaAndStartTime(t *testing.T) {
+	router, h, executor := newJobsTestRouter(t, enabledJobsConfig())
+	var remaining atomic.Int64
+	remaining.Store(10)
+	h.quotaPercent = func(model string) (float64, bool) {
+		return float64(remaining.Load()), model == "jobs-model"
+	}
+
+	resp := doJobs(router, http.MethodPost, "/v1/jobs", "", `{"body":{"model":"jobs-model","messages":[]},"when_quota":{"min_remaining_percent":50}}`)
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+	h.runDue()
+	if got := gjson.Get(doJobs(router, http.MethodGet, "/v1/jobs/"+id, "", "").Body.String(), "status").String(); got != StatusScheduled {
+		t.Fatalf("job started below the quota threshold: %s", got)
+	}
+
+	remaining.Store(80)
+	h.runDue()
+	waitForStatus(t, router, id, "", StatusCompleted)
+
+	resp = doJobs(router, http.MethodPost, "/v1/jobs", "", `{"body":{"model":"jobs-model","messages":[]},"delay_seconds":3600}`)
+	later := gjson.Get(resp.Body.String(), "id").String()
+	h.runDue()
+	if got := gjson.Get(doJobs(router, http.MethodGet, "/v1/jobs/"+later, "", "").Body.String(), "status").String(); got != StatusScheduled {
+		t.Fatalf("delayed job status = %s", got)
+	}
+	cancelled := doJobs(router, http.MethodDelete, "/v1/jobs/"+later, "", "")
+	if gjson.Get(cancelled.Body.String(), "status").String() != StatusCancelled {
+		t.Fatalf("cancel = %s", cancelled.Body.String())
+	}
+	if executor.callCount() != 1 {
+		t.Fatalf("executor calls = %d, want 1", executor.callCount())
+	}
+}
+
+func TestJobDeliversToWebhook(t *testing.T) {
+	delivered := make(chan string, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		delivered <- string(body)
+	}))
+	defer hook.Close()
+
+	cfg := enabledJobsConfig()
+	cfg.ScheduledJobs.WebhookHosts = []string{"127.0.0.1"}
+	router, _, _ := newJobsTestRouter(t, cfg)
+
+	resp := doJobs(router, http.MethodPost, "/v1/jobs", "", `{"body":{"model":"jobs-model","messages":[]},"webhook_url":"`+hook.URL+`/done"}`)
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	select {
+	case body := <-delivered:
+		if gjson.Get(body, "status").String() != StatusCompleted || gjson.Get(body, "result.choices.0.message.content").String() != "done" {
+			t.Fatalf("webhook payload = %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+	waitForStatus(t, router, gjson.Get(resp.Body.String(), "id").String(), "", StatusCompleted)
+}
+
+func TestJobRejectsInvalidRequests(t *testing.T) {
+	router, _, executor := newJobsTestRouter(t, enabledJobsConfig())
+
+	for _, body := range []string{
+		`{"body":{"messages":[]}}`,
+		`{"endpoint":"/v1/images","body":{"model":"jobs-model"}}`,
+		`{"body":{"model":"jobs-model","stream":true}}`,
+		`{"body":{"model":"jobs-model"},"run_at":"tomorrow"}`,
+		`{"body":{"model":"jobs-model"},"delay_seconds":999999}`,
+		`{"body":{"model":"jobs-model"},"when_quota":{"min_remaining_percent":150}}`,
+		`{"body":{"model":"jobs-model"},"webhook_url":"https://evil.example.com/hook"}`,
+	} {
+		if resp := doJobs(router, http.MethodPost, "/v1/jobs", "", body); resp.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d", body, resp.Code)
+		}
+	}
+	if executor.callCount() != 0 {
+		t.Fatalf("invalid jobs reached the model %d times", executor.callCount())
+	}
+
+	disabled, _, _ := newJobsTestRouter(t, &sdkconfig.SDKConfig{})
+	if resp := doJobs(disabled, http.MethodPost, "/v1/jobs", "", `{"body":{"model":"jobs-model"}}`); resp.Code != http.StatusNotFound {
+		t.Fatalf("disabled status = %d", resp.Code)
+	}
+}
+
+type exhaustedError struct{}
+
+func (exhaustedError) Error() string   { return `{"error":{"message":"quota exhausted"}}` }
+func (exhaustedError) StatusCode() int { return http.StatusTooManyRequests }
+
+type exhaustedExecutor struct{ jobsTestExecutor }
+
+func (e *exhaustedExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	return coreexecutor.Response{}, exhaustedError{}
+}
+
+func TestJobRequeuedAfterPoolExhaustion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &exhaustedExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "jobs-exhausted-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "jobs-exhausted-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	notifications := make(chan string, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		notifications <- string(body)
+	}))
+	defer hook.Close()
+
+	cfg := enabledJobsConfig()
+	cfg.ScheduledJobs.RequeueExhausted = true
+	cfg.ScheduledJobs.MaxRequeues = 1
+	cfg.ScheduledJobs.WebhookHosts = []string{"127.0.0.1"}
+	h := NewJobsAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+	var offset atomic.Int64
+	h.now = func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }
+	router := gin.New()
+	router.POST("/v1/jobs", h.Create)
+	router.GET("/v1/jobs/:id", h.Get)
+
+	resp := doJobs(router, http.MethodPost, "/v1/jobs", "", `{"body":{"model":"jobs-exhausted-model","messages":[]},"webhook_url":"`+hook.URL+`"}`)
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+
+	var notice string
+	select {
+	case notice = <-notifications:
+	case <-time.After(5 * time.Second):
+		t.Fatal("requeue was not notified")
+	}
+	if gjson.Get(notice, "status").String() != StatusScheduled || gjson.Get(notice, "requeues").Int() != 1 || gjson.Get(notice, "status_code").Int() != http.StatusTooManyRequests {
+		t.Fatalf("requeue notification = %s", notice)
+	}
+	runAt, err := time.Parse(time.RFC3339Nano, gjson.Get(notice, "run_at").String())
+	if err != nil || !runAt.After(time.Now()) {
+		t.Fatalf("run_at = %q, err = %v", gjson.Get(notice, "run_at").String(), err)
+	}
+
+	offset.Store(int64(time.Until(runAt) + time.Second))
+	h.runDue()
+	select {
+	case notice = <-notifications:
+	case <-time.After(5 * time.Second):
+		t.Fatal("final failure was not notified")
+	}
+	if gjson.Get(notice, "status").String() != StatusFailed || gjson.Get(notice, "requeues").Int() != 1 {
+		t.Fatalf("final notification = %s", notice)
+	}
+	waitForStatus(t, router, id, "", StatusFailed)
+}
@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxRequeues = 3
+	// requeueFallbackDelay is used when neither the error nor the pool reveals a reset time.
+	requeueFallbackDelay = time.Hour
+	// requeueMargin lets the credentials recover before the job is retried.
+	requeueMargin = 30 * time.Second
+)
+
+// requeueLocked reschedules a job that failed because the credential pool for its model is
+// exhausted. It reports whether the job was requeued; the caller notifies the webhook.
+func (h *JobsAPIHandler) requeueLocked(job *Job, errMsg *interfaces.ErrorMessage) bool {
+	if !job.Requeue || errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	if job.Requeues >= h.maxRequeues() {
+		return false
+	}
+	now := h.now()
+	resetAt, ok := h.poolResetTime(job.Model, now)
+	if !ok {
+		resetAt, ok = retryAfter(errMsg, now)
+	}
+	if !ok {
+		resetAt = now.Add(requeueFallbackDelay)
+	}
+	runAt := resetAt.Add(requeueMargin)
+	job.Status = StatusScheduled
+	job.Requeues++
+	job.RunAt = &runAt
+	job.StartedAt = nil
+	job.StatusCode = errMsg.StatusCode
+	if errMsg.Error != nil {
+		job.Error = errMsg.Error.Error()
+	}
+	if expires := runAt.Add(h.maxWait()); expires.After(job.ExpiresAt) {
+		job.ExpiresAt = expires
+	}
+	log.Debugf("scheduled jobs: %s requeued to %s after pool exhaustion (%d/%d)", job.ID, runAt.Format(time.RFC3339), job.Requeues, h.maxRequeues())
+	return true
+}
+
+// poolResetTime returns the earliest time a credential serving model is expected to recover:
+// the end of its cooldown, or the reset time of the quota group the model belongs to.
+func (h *JobsAPIHandler) poolResetTime(model string, now time.Time) (time.Time, bool) {
+	if h.AuthManager == nil {
+		return time.Time{}, false
+	}
+	reg := registry.GetGlobalRegistry()
+	var earliest time.Time
+	consider := func(t time.Time) {
+		if t.After(now) && (earliest.IsZero() || t.Before(earliest)) {
+			earliest = t
+		}
+	}
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		if !reg.ClientSupportsModel(auth.ID, model) {
+			continue
+		}
+		if state := auth.ModelStates[model]; state != nil && state.Unavailable {
+			consider(state.NextRetryAfter)
+		}
+		if auth.Unavailable {
+			consider(auth.NextRetryAfter)
+		}
+		if entry, ok := quota.GetModelQuotaFromMetadata(auth.Metadata, model); ok && entry.Percent <= 0 {
+			consider(entry.ResetTime)
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// retryAfter reads the Retry-After seconds the auth manager attaches to cooldown errors.
+func retryAfter(errMsg *interfaces.ErrorMessage, now time.Time) (time.Time, bool) {
+	if errMsg.Addon == nil {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(errMsg.Addon.Get("Retry-After")))
+	if err != nil || seconds < 0 {
+		return time.Time{}, false
+	}
+	return now.Add(time.Duration(seconds) * time.Second), true
+}
+
+func (h *JobsAPIHandler) maxRequeues() int {
+	if h.Cfg != nil && h.Cfg.ScheduledJobs.MaxRequeues > 0 {
+		return h.Cfg.ScheduledJobs.MaxRequeues
+	}
+	return defaultMaxRequeues
+}
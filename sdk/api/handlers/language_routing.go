@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/managedkeys"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultLanguageRouteMinShare = 0.5
+	// languageRoutingSampleLetters bounds how much of a long prompt is classified.
+	languageRoutingSampleLetters = 20000
+	scriptNonWestern             = "non-western"
+)
+
+// languagePromptRoots are the request fields holding the conversation in the supported formats.
+// System prompts are left out: a long fixed system prompt would otherwise decide the language
+// of every request.
+var languagePromptRoots = []string{"messages", "contents", "input", "prompt"}
+
+// applyLanguageRouting returns the model the request should go to according to the
+// language routing rules, or modelName when no rule matches. Requests for a model the
+// caller's key may not use are left alone so they still fail the scope check, and targets
+// that have no provider or are outside the key's scope are skipped.
+func (h *BaseAPIHandler) applyLanguageRouting(ctx context.Context, modelName string, rawJSON []byte) string {
+	if h == nil || h.Cfg == nil || len(h.Cfg.LanguageRouting) == 0 || len(rawJSON) == 0 {
+		return modelName
+	}
+	if accessScopeError(ctx, modelName) != nil {
+		return modelName
+	}
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	var shares map[string]float64
+	for _, route := range h.Cfg.LanguageRouting {
+		target := strings.TrimSpace(route.Target)
+		if target == "" || strings.EqualFold(target, baseModel) {
+			continue
+		}
+		if len(route.Models) > 0 && !managedkeys.ModelAllowed(route.Models, baseModel) {
+			continue
+		}
+		if shares == nil {
+			shares = util.ScriptShares(promptText(rawJSON), languageRoutingSampleLetters)
+			if shares == nil {
+				return modelName
+			}
+		}
+		minShare := route.MinShare
+		if minShare <= 0 {
+			minShare = defaultLanguageRouteMinShare
+		}
+		share := scriptShare(shares, strings.ToLower(strings.TrimSpace(route.Script)))
+		if share < minShare {
+			continue
+		}
+		if len(util.GetProviderName(thinking.ParseSuffix(target).ModelName)) == 0 || accessScopeError(ctx, target) != nil {
+			continue
+		}
+		log.Debugf("language routing: %s -> %s (%s share %.2f)", modelName, target, route.Script, share)
+		return target
+	}
+	return modelName
+}
+
+func scriptShare(shares map[string]float64, script string) float64 {
+	if script == scriptNonWestern {
+		return 1 - shares[util.ScriptWestern]
+	}
+	return shares[script]
+}
+
+// promptText concatenates the text users wrote in a request of any supported format. Turns of
+// other roles and tool results are skipped.
+func promptText(rawJSON []byte) string {
+	var b strings.Builder
+	for _, root := range languagePromptRoots {
+		collectPromptText(gjson.GetBytes(rawJSON, root), &b)
+	}
+	return b.String()
+}
+
+func collectPromptText(node gjson.Result, b *strings.Builder) {
+	switch {
+	case node.Type == gjson.String:
+		b.WriteString(node.String())
+		b.WriteByte('\n')
+	case node.IsArray():
+		for _, item := range node.Array() {
+			collectPromptText(item, b)
+		}
+	case node.IsObject():
+		if role := node.Get("role").String(); role != "" && role != "user" {
+			return
+		}
+		if node.Get("type").String() == "tool_result" || node.Get("functionResponse").Exists() {
+			return
+		}
+		for _, key := range []string{"text", "content", "parts"} {
+			if child := node.Get(key); child.Exists() {
+				collectPromptText(child, b)
+			}
+		}
+	}
+}
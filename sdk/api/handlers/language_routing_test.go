@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestApplyLanguageRouting(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("lang-route-auth", "lang-test", []*registry.ModelInfo{{ID: "cjk-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("lang-route-auth") })
+
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{LanguageRouting: []sdkconfig.LanguageRoute{
+		{Script: "cyrillic", Target: "missing-model"},
+		{Script: "cjk", MinShare: 0.6, Models: []string{"claude-*"}, Target: "cjk-model"},
+	}}}
+	ctx := context.Background()
+
+	cjk := []byte(`{"system":"You are a helpful assistant that always answers carefully.","messages":[{"role":"user","content":[{"type":"text","text":"请帮我把这段代码翻译成中文注释 func main() {}"}]},{"role":"assistant","content":"Sure, here is the translation in English words."}]}`)
+	if got := h.applyLanguageRouting(ctx, "claude-sonnet-4-5", cjk); got != "cjk-model" {
+		t.Fatalf("CJK prompt routed to %q, want cjk-model", got)
+	}
+	if got := h.applyLanguageRouting(ctx, "gpt-5", cjk); got != "gpt-5" {
+		t.Fatalf("route limited to claude-* applied to gpt-5: %q", got)
+	}
+
+	english := []byte(`{"contents":[{"role":"user","parts":[{"text":"Please translate 你好 into English"}]}]}`)
+	if got := h.applyLanguageRouting(ctx, "claude-sonnet-4-5", english); got != "claude-sonnet-4-5" {
+		t.Fatalf("western prompt routed to %q", got)
+	}
+
+	russian := []byte(`{"messages":[{"role":"user","content":"Привет, как дела?"}]}`)
+	if got := h.applyLanguageRouting(ctx, "claude-sonnet-4-5", russian); got != "claude-sonnet-4-5" {
+		t.Fatalf("route to a model without providers should be skipped, got %q", got)
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// ListModels returns the models to advertise for handlerType. Right after startup it may
+// answer from the persisted model-list cache; stale is true in that case.
+func ListModels(handlerType string) (models []map[string]any, stale bool) {
+	return registry.GetModelListCache().Models(handlerType)
+}
+
+// AddModelListStaleness adds the staleness flag to a model list response body, along with
+// the time the cached list was saved when it is stale.
+func AddModelListStaleness(body gin.H, stale bool) gin.H {
+	body["stale"] = stale
+	if stale {
+		if savedAt := registry.GetModelListCache().SavedAt(); !savedAt.IsZero() {
+			body["cached_at"] = savedAt.UTC().Format(time.RFC3339)
+		}
+	}
+	return body
+}
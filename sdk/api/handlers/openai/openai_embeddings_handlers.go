@@ -0,0 +1,75 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// Embeddings handles the /v1/embeddings endpoint.
+// Requests keep the OpenAI embeddings format; executors translate them for Gemini
+// embedding models and forward them unchanged to OpenAI-compatible upstreams.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Embeddings(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	// If data retrieval fails, return a 400 Bad Request error.
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	if msg := validateEmbeddingsRequest(rawJSON); msg != "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: msg,
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, OpenAIEmbedding, modelName, rawJSON, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// validateEmbeddingsRequest returns a message describing why the request cannot be served,
+// or "" when it is valid. input may be a string or an array of strings or token arrays.
+func validateEmbeddingsRequest(rawJSON []byte) string {
+	if !gjson.ValidBytes(rawJSON) {
+		return "Invalid request: body must be a JSON object"
+	}
+	if gjson.GetBytes(rawJSON, "model").String() == "" {
+		return "model is required"
+	}
+	input := gjson.GetBytes(rawJSON, "input")
+	switch {
+	case input.Type == gjson.String && input.String() != "":
+	case input.IsArray() && len(input.Array()) > 0:
+	default:
+		return "input must be a non-empty string or array"
+	}
+	if format := gjson.GetBytes(rawJSON, "encoding_format").String(); format != "" && format != "float" && format != "base64" {
+		return "encoding_format must be float or base64"
+	}
+	return ""
+}
@@ -0,0 +1,25 @@
+package openai
+
+import "testing"
+
+func TestValidateEmbeddingsRequest(t *testing.T) {
+	cases := []struct {
+		body  string
+		valid bool
+	}{
+		{`{"model":"gemini-embedding-001","input":"hello"}`, true},
+		{`{"model":"gemini-embedding-001","input":["a","b"],"encoding_format":"base64"}`, true},
+		{`{"model":"text-embedding-3-small","input":[[1,2,3]]}`, true},
+		{`{"input":"hello"}`, false},
+		{`{"model":"gemini-embedding-001","input":""}`, false},
+		{`{"model":"gemini-embedding-001","input":[]}`, false},
+		{`{"model":"gemini-embedding-001"}`, false},
+		{`{"model":"gemini-embedding-001","input":"a","encoding_format":"int8"}`, false},
+		{`not json`, false},
+	}
+	for _, tc := range cases {
+		if msg := validateEmbeddingsRequest([]byte(tc.body)); (msg == "") != tc.valid {
+			t.Errorf("%s: valid=%v, message %q", tc.body, tc.valid, msg)
+		}
+	}
+}
@@ -60,7 +60,7 @@ func (h *OpenAIAPIHandler) Models() []map[string]any {
 // and specifications in OpenAI-compatible format.
 func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 	// Get all available models
-	allModels := h.Models()
+	allModels, stale := handlers.ListModels("openai")
 
 	// Filter to only include the 4 required fields: id, object, created, owned_by
 	filteredModels := make([]map[string]any, len(allModels))
@@ -83,10 +83,10 @@ func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 		filteredModels[i] = filteredModel
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, handlers.AddModelListStaleness(gin.H{
 		"object": "list",
 		"data":   filteredModels,
-	})
+	}, stale))
 }
 
 // ChatCompletions handles the /v1/chat/completions endpoint.
@@ -668,5 +668,7 @@ func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flush
 		WriteDone: func() {
 			_, _ = fmt.Fprint(c.Writer, "data: [DONE]\n\n")
 		},
+		IsBoundary:  chatChunkBoundary,
+		MergeChunks: mergeChatChunks,
 	})
 }
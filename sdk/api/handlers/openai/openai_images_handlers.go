@@ -0,0 +1,213 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// defaultImageModel serves image requests that do not name a model.
+	defaultImageModel = "gemini-3-pro-image"
+	// maxImagesPerRequest caps n; each image is a separate upstream generation.
+	maxImagesPerRequest = 4
+)
+
+// imageAspectRatios maps the OpenAI size values to Gemini aspect ratios.
+var imageAspectRatios = map[string]string{
+	"256x256":   "1:1",
+	"512x512":   "1:1",
+	"1024x1024": "1:1",
+	"1536x1024": "3:2",
+	"1024x1536": "2:3",
+	"1792x1024": "16:9",
+	"1024x1792": "9:16",
+}
+
+// imageGenerationRequest is the validated form of an OpenAI image generation request.
+type imageGenerationRequest struct {
+	model          string
+	prompt         string
+	n              int
+	responseFormat string
+	aspectRatio    string
+	imageSize      string
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+// The OpenAI image request is sent to the model as a Gemini generateContent request asking
+// for image output, and the returned inlineData parts are returned as b64_json or data URLs.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	// If data retrieval fails, return a 400 Bad Request error.
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	req, msg := parseImageGenerationRequest(rawJSON)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: msg,
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	payload := buildGeminiImageRequest(req)
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	data := make([]gin.H, 0, req.n)
+	var inputTokens, outputTokens int64
+	for len(data) < req.n {
+		resp, errMsg := h.ExecuteWithAuthManager(cliCtx, Gemini, req.model, payload, "")
+		if errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		images, revisedPrompt := geminiImageParts(resp)
+		if len(images) == 0 {
+			c.JSON(http.StatusBadGateway, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: "upstream returned no image",
+					Type:    "server_error",
+				},
+			})
+			cliCancel()
+			return
+		}
+		for _, image := range images {
+			if len(data) == req.n {
+				break
+			}
+			item := gin.H{}
+			if req.responseFormat == "url" {
+				item["url"] = "data:" + image.mimeType + ";base64," + image.data
+			} else {
+				item["b64_json"] = image.data
+			}
+			if revisedPrompt != "" {
+				item["revised_prompt"] = revisedPrompt
+			}
+			data = append(data, item)
+		}
+		usage := gjson.GetBytes(resp, "usageMetadata")
+		inputTokens += usage.Get("promptTokenCount").Int()
+		outputTokens += usage.Get("candidatesTokenCount").Int()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"created": time.Now().Unix(),
+		"data":    data,
+		"usage": gin.H{
+			"input_tokens":  inputTokens,
+			"output_tokens": outputTokens,
+			"total_tokens":  inputTokens + outputTokens,
+		},
+	})
+	cliCancel()
+}
+
+// parseImageGenerationRequest validates an OpenAI image generation request. It returns a
+// message describing why the request cannot be served, or "" when it is valid.
+func parseImageGenerationRequest(rawJSON []byte) (imageGenerationRequest, string) {
+	req := imageGenerationRequest{model: defaultImageModel, n: 1, responseFormat: "b64_json", aspectRatio: "1:1"}
+	if !gjson.ValidBytes(rawJSON) {
+		return req, "Invalid request: body must be a JSON object"
+	}
+	root := gjson.ParseBytes(rawJSON)
+	if model := strings.TrimSpace(root.Get("model").String()); model != "" {
+		req.model = model
+	}
+	req.prompt = strings.TrimSpace(root.Get("prompt").String())
+	if req.prompt == "" {
+		return req, "prompt is required"
+	}
+	if n := root.Get("n"); n.Exists() {
+		req.n = int(n.Int())
+		if req.n < 1 || req.n > maxImagesPerRequest {
+			return req, fmt.Sprintf("n must be between 1 and %d", maxImagesPerRequest)
+		}
+	}
+	switch format := root.Get("response_format").String(); format {
+	case "", "b64_json":
+	case "url":
+		req.responseFormat = format
+	default:
+		return req, "response_format must be b64_json or url"
+	}
+	if size := root.Get("size").String(); size != "" && size != "auto" {
+		ratio, ok := imageAspectRatios[size]
+		if !ok {
+			return req, fmt.Sprintf("unsupported size %q", size)
+		}
+		req.aspectRatio = ratio
+	}
+	switch strings.ToLower(root.Get("quality").String()) {
+	case "hd", "high":
+		req.imageSize = "2K"
+	}
+	return req, ""
+}
+
+// buildGeminiImageRequest builds the generateContent request for one generation.
+func buildGeminiImageRequest(req imageGenerationRequest) []byte {
+	payload := []byte(`{"contents":[{"role":"user","parts":[{"text":""}]}],"generationConfig":{"responseModalities":["TEXT","IMAGE"]}}`)
+	payload, _ = sjson.SetBytes(payload, "contents.0.parts.0.text", req.prompt)
+	payload, _ = sjson.SetBytes(payload, "generationConfig.imageConfig.aspectRatio", req.aspectRatio)
+	if req.imageSize != "" {
+		payload, _ = sjson.SetBytes(payload, "generationConfig.imageConfig.imageSize", req.imageSize)
+	}
+	return payload
+}
+
+type generatedImage struct {
+	mimeType string
+	data     string
+}
+
+// geminiImageParts returns the inline images of a generateContent response and the text the
+// model returned alongside them.
+func geminiImageParts(resp []byte) ([]generatedImage, string) {
+	var images []generatedImage
+	var text strings.Builder
+	gjson.GetBytes(resp, "candidates").ForEach(func(_, candidate gjson.Result) bool {
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			if data := inline.Get("data").String(); data != "" {
+				mimeType := inline.Get("mimeType").String()
+				if mimeType == "" {
+					mimeType = inline.Get("mime_type").String()
+				}
+				if mimeType == "" {
+					mimeType = "image/png"
+				}
+				images = append(images, generatedImage{mimeType: mimeType, data: data})
+			} else if !part.Get("thought").Bool() {
+				text.WriteString(part.Get("text").String())
+			}
+			return true
+		})
+		return true
+	})
+	return images, strings.TrimSpace(text.String())
+}
@@ -0,0 +1,113 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// imageExecutor answers every generateContent request with one inline PNG.
+type imageExecutor struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *imageExecutor) Identifier() string { return "images-test-provider" }
+
+func (e *imageExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.mu.Unlock()
+	return coreexecutor.Response{Payload: []byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"A red fox.","thought":true},{"text":"A red fox in snow."},{"inlineData":{"mimeType":"image/png","data":"iVBORw0K"}}]}}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":1290}}`)}, nil
+}
+
+func (e *imageExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *imageExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *imageExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *imageExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestParseImageGenerationRequest(t *testing.T) {
+	cases := []struct {
+		body  string
+		valid bool
+	}{
+		{`{"prompt":"a fox"}`, true},
+		{`{"model":"gemini-3-pro-image","prompt":"a fox","n":2,"size":"1792x1024","response_format":"url"}`, true},
+		{`{"prompt":"a fox","size":"auto","quality":"hd"}`, true},
+		{`{"model":"gemini-3-pro-image"}`, false},
+		{`{"prompt":"a fox","n":0}`, false},
+		{`{"prompt":"a fox","n":5}`, false},
+		{`{"prompt":"a fox","size":"640x480"}`, false},
+		{`{"prompt":"a fox","response_format":"png"}`, false},
+		{`not json`, false},
+	}
+	for _, tc := range cases {
+		if _, msg := parseImageGenerationRequest([]byte(tc.body)); (msg == "") != tc.valid {
+			t.Errorf("%s: valid=%v, message %q", tc.body, tc.valid, msg)
+		}
+	}
+}
+
+func TestImageGenerationsReturnsInlineImages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &imageExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "images-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: defaultImageModel}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/images/generations", h.ImageGenerations)
+
+	body := `{"prompt":"a red fox","n":2,"size":"1792x1024","quality":"hd","response_format":"url"}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	resp := gjson.Parse(rec.Body.String())
+	if len(resp.Get("data").Array()) != 2 || resp.Get("data.0.url").String() != "data:image/png;base64,iVBORw0K" ||
+		resp.Get("data.0.revised_prompt").String() != "A red fox in snow." || resp.Get("usage.total_tokens").Int() != 2594 {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if len(executor.payloads) != 2 {
+		t.Fatalf("upstream generations = %d, want 2", len(executor.payloads))
+	}
+	first := executor.payloads[0]
+	if gjson.Get(first, "contents.0.parts.0.text").String() != "a red fox" ||
+		gjson.Get(first, "generationConfig.imageConfig.aspectRatio").String() != "16:9" ||
+		gjson.Get(first, "generationConfig.imageConfig.imageSize").String() != "2K" {
+		t.Fatalf("upstream request = %s", first)
+	}
+}
@@ -0,0 +1,565 @@
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// realtimeMaxMessageBytes bounds a single client event.
+const realtimeMaxMessageBytes = 16 << 20
+
+var realtimeUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// Clients authenticate with the Authorization header, which browsers cannot attach to
+	// cross-origin websocket requests, so the origin does not need to be checked.
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// Realtime handles GET /v1/realtime, a websocket endpoint speaking the text subset of the
+// OpenAI Realtime API (beta event names). The proxy keeps the conversation; each
+// response.create sends it as a streaming chat completions request through the credential
+// pool and converts the stream chunks back into realtime events. Audio is not supported.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Realtime(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "model query parameter is required",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	conn, err := realtimeUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Debugf("realtime: websocket upgrade failed: %v", err)
+		return
+	}
+	conn.SetReadLimit(realtimeMaxMessageBytes)
+	s := &realtimeSession{
+		h:       h,
+		c:       c,
+		conn:    conn,
+		session: newRealtimeSessionObject(model),
+	}
+	s.run()
+}
+
+// realtimeSession is the state of one websocket connection.
+type realtimeSession struct {
+	h    *OpenAIAPIHandler
+	c    *gin.Context
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	session string
+	items   []realtimeItem
+	// cancel stops the response in progress, if any.
+	cancel context.CancelFunc
+	active bool
+	wg     sync.WaitGroup
+}
+
+// realtimeItem is a conversation item. Text content is kept as parts; function calls and
+// their outputs keep their call fields.
+type realtimeItem struct {
+	ID        string            `json:"id"`
+	Object    string            `json:"object"`
+	Type      string            `json:"type"`
+	Status    string            `json:"status,omitempty"`
+	Role      string            `json:"role,omitempty"`
+	Content   []realtimeContent `json:"content,omitempty"`
+	CallID    string            `json:"call_id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Arguments string            `json:"arguments,omitempty"`
+	Output    string            `json:"output,omitempty"`
+}
+
+type realtimeContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func newRealtimeSessionObject(model string) string {
+	out := `{"id":"","object":"realtime.session","model":"","modalities":["text"],"instructions":"","tools":[],"tool_choice":"auto","max_response_output_tokens":"inf"}`
+	out, _ = sjson.Set(out, "id", "sess_"+realtimeID())
+	out, _ = sjson.Set(out, "model", model)
+	return out
+}
+
+func (s *realtimeSession) run() {
+	defer func() {
+		s.mu.Lock()
+		if s.cancel != nil {
+			s.cancel()
+		}
+		s.mu.Unlock()
+		s.wg.Wait()
+		_ = s.conn.Close()
+	}()
+	s.mu.Lock()
+	session := s.session
+	s.mu.Unlock()
+	s.send("session.created", map[string]any{"session": json.RawMessage(session)})
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debugf("realtime: read failed: %v", err)
+			}
+			return
+		}
+		s.handleEvent(data)
+	}
+}
+
+func (s *realtimeSession) handleEvent(data []byte) {
+	if !gjson.ValidBytes(data) {
+		s.sendError("invalid_request_error", "invalid_json", "event must be a JSON object", "")
+		return
+	}
+	event := gjson.ParseBytes(data)
+	eventID := event.Get("event_id").String()
+	switch event.Get("type").String() {
+	case "session.update":
+		s.updateSession(event.Get("session"), eventID)
+	case "conversation.item.create":
+		s.createItem(event, eventID)
+	case "conversation.item.delete":
+		s.deleteItem(event.Get("item_id").String(), eventID)
+	case "response.create":
+		s.createResponse(event.Get("response"), eventID)
+	case "response.cancel":
+		s.mu.Lock()
+		if s.cancel != nil {
+			s.cancel()
+		}
+		s.mu.Unlock()
+	case "input_audio_buffer.append", "input_audio_buffer.commit", "input_audio_buffer.clear":
+		s.sendError("invalid_request_error", "audio_not_supported", "audio input is not supported by this proxy; send text items", eventID)
+	default:
+		s.sendError("invalid_request_error", "unknown_event", "unsupported event type "+event.Get("type").String(), eventID)
+	}
+}
+
+// updateSession merges the given fields into the session. Only text output is produced, so
+// modalities always stay ["text"].
+func (s *realtimeSession) updateSession(update gjson.Result, eventID string) {
+	if !update.IsObject() {
+		s.sendError("invalid_request_error", "invalid_value", "session must be an object", eventID)
+		return
+	}
+	s.mu.Lock()
+	update.ForEach(func(key, value gjson.Result) bool {
+		switch key.String() {
+		case "id", "object", "modalities":
+		default:
+			s.session, _ = sjson.SetRaw(s.session, key.String(), value.Raw)
+		}
+		return true
+	})
+	session := s.session
+	s.mu.Unlock()
+	s.send("session.updated", map[string]any{"session": json.RawMessage(session)})
+}
+
+func (s *realtimeSession) createItem(event gjson.Result, eventID string) {
+	raw := event.Get("item")
+	item := realtimeItem{ID: raw.Get("id").String(), Object: "realtime.item", Type: raw.Get("type").String(), Status: "completed"}
+	if item.ID == "" {
+		item.ID = "item_" + realtimeID()
+	}
+	switch item.Type {
+	case "message":
+		item.Role = raw.Get("role").String()
+		if item.Role != "user" && item.Role != "assistant" && item.Role != "system" {
+			s.sendError("invalid_request_error", "invalid_value", "item.role must be user, assistant or system", eventID)
+			return
+		}
+		for _, part := range raw.Get("content").Array() {
+			switch part.Get("type").String() {
+			case "input_text", "text":
+				item.Content = append(item.Content, realtimeContent{Type: part.Get("type").String(), Text: part.Get("text").String()})
+			default:
+				s.sendError("invalid_request_error", "audio_not_supported", "only text content is supported", eventID)
+				return
+			}
+		}
+	case "function_call":
+		item.CallID = raw.Get("call_id").String()
+		item.Name = raw.Get("name").String()
+		item.Arguments = raw.Get("arguments").String()
+	case "function_call_output":
+		item.CallID = raw.Get("call_id").String()
+		item.Output = raw.Get("output").String()
+		if item.CallID == "" {
+			s.sendError("invalid_request_error", "invalid_value", "item.call_id is required", eventID)
+			return
+		}
+	default:
+		s.sendError("invalid_request_error", "invalid_value", "item.type must be message, function_call or function_call_output", eventID)
+		return
+	}
+
+	s.mu.Lock()
+	previous := s.insertItemLocked(item, event.Get("previous_item_id"))
+	s.mu.Unlock()
+	s.send("conversation.item.created", map[string]any{"previous_item_id": previous, "item": item})
+}
+
+// insertItemLocked adds item after previousItemID, or at the end when it is absent or not
+// found, and returns the ID of the item before it.
+func (s *realtimeSession) insertItemLocked(item realtimeItem, previousItemID gjson.Result) any {
+	idx := len(s.items)
+	if previousItemID.Exists() {
+		if previousItemID.String() == "root" {
+			idx = 0
+		}
+		for i, existing := range s.items {
+			if existing.ID == previousItemID.String() {
+				idx = i + 1
+				break
+			}
+		}
+	}
+	s.items = append(s.items, realtimeItem{})
+	copy(s.items[idx+1:], s.items[idx:])
+	s.items[idx] = item
+	if idx == 0 {
+		return nil
+	}
+	return s.items[idx-1].ID
+}
+
+func (s *realtimeSession) deleteItem(id, eventID string) {
+	s.mu.Lock()
+	found := false
+	for i, item := range s.items {
+		if item.ID == id {
+			s.items = append(s.items[:i], s.items[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		s.sendError("invalid_request_error", "item_not_found", "item "+id+" not found", eventID)
+		return
+	}
+	s.send("conversation.item.deleted", map[string]any{"item_id": id})
+}
+
+// createResponse starts streaming a response to the conversation so far. Fields of the
+// response object (instructions, tools, tool_choice, temperature,
+// max_response_output_tokens) override the session for this response only.
+func (s *realtimeSession) createResponse(overrides gjson.Result, eventID string) {
+	s.mu.Lock()
+	if s.active {
+		s.mu.Unlock()
+		s.sendError("invalid_request_error", "conversation_already_has_active_response", "a response is already in progress", eventID)
+		return
+	}
+	settings := s.session
+	if overrides.IsObject() {
+		overrides.ForEach(func(key, value gjson.Result) bool {
+			settings, _ = sjson.SetRaw(settings, key.String(), value.Raw)
+			return true
+		})
+	}
+	payload := buildRealtimeChatRequest(settings, s.items)
+	parent := context.Background()
+	if s.c.Request != nil {
+		parent = s.c.Request.Context()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	s.cancel = cancel
+	s.active = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		s.streamResponse(ctx, gjson.Get(settings, "model").String(), payload)
+		s.mu.Lock()
+		s.active = false
+		s.cancel = nil
+		s.mu.Unlock()
+	}()
+}
+
+// buildRealtimeChatRequest converts the session settings and conversation into a streaming
+// chat completions request.
+func buildRealtimeChatRequest(settings string, items []realtimeItem) []byte {
+	out := []byte(`{"model":"","stream":true,"stream_options":{"include_usage":true},"messages":[]}`)
+	out, _ = sjson.SetBytes(out, "model", gjson.Get(settings, "model").String())
+	if instructions := gjson.Get(settings, "instructions").String(); instructions != "" {
+		out, _ = sjson.SetRawBytes(out, "messages.-1", mustMarshal(map[string]string{"role": "system", "content": instructions}))
+	}
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+		switch item.Type {
+		case "message":
+			var text strings.Builder
+			for _, part := range item.Content {
+				text.WriteString(part.Text)
+			}
+			out, _ = sjson.SetRawBytes(out, "messages.-1", mustMarshal(map[string]string{"role": item.Role, "content": text.String()}))
+		case "function_call":
+			// Consecutive calls belong to one assistant turn.
+			var calls []map[string]any
+			for ; i < len(items) && items[i].Type == "function_call"; i++ {
+				calls = append(calls, map[string]any{
+					"id":       items[i].CallID,
+					"type":     "function",
+					"function": map[string]string{"name": items[i].Name, "arguments": items[i].Arguments},
+				})
+			}
+			i--
+			out, _ = sjson.SetRawBytes(out, "messages.-1", mustMarshal(map[string]any{"role": "assistant", "content": nil, "tool_calls": calls}))
+		case "function_call_output":
+			out, _ = sjson.SetRawBytes(out, "messages.-1", mustMarshal(map[string]string{"role": "tool", "tool_call_id": item.CallID, "content": item.Output}))
+		}
+	}
+	if tools := gjson.Get(settings, "tools").Array(); len(tools) > 0 {
+		for _, tool := range tools {
+			fn := map[string]any{"name": tool.Get("name").String()}
+			if desc := tool.Get("description"); desc.Exists() {
+				fn["description"] = desc.String()
+			}
+			if params := tool.Get("parameters"); params.Exists() {
+				fn["parameters"] = json.RawMessage(params.Raw)
+			}
+			out, _ = sjson.SetRawBytes(out, "tools.-1", mustMarshal(map[string]any{"type": "function", "function": fn}))
+		}
+		switch choice := gjson.Get(settings, "tool_choice"); {
+		case choice.Type == gjson.String && choice.String() != "":
+			out, _ = sjson.SetBytes(out, "tool_choice", choice.String())
+		case choice.IsObject():
+			out, _ = sjson.SetRawBytes(out, "tool_choice", mustMarshal(map[string]any{"type": "function", "function": map[string]string{"name": choice.Get("name").String()}}))
+		}
+	}
+	if temperature := gjson.Get(settings, "temperature"); temperature.Type == gjson.Number {
+		out, _ = sjson.SetBytes(out, "temperature", temperature.Float())
+	}
+	if maxTokens := gjson.Get(settings, "max_response_output_tokens"); maxTokens.Type == gjson.Number {
+		out, _ = sjson.SetBytes(out, "max_tokens", maxTokens.Int())
+	}
+	return out
+}
+
+// realtimeStream accumulates the chat completion stream of one response.
+type realtimeStream struct {
+	responseID string
+	text       *realtimeItem
+	textIndex  int
+	calls      map[int64]*realtimeItem
+	callIndex  map[int64]int
+	output     []*realtimeItem
+	usage      json.RawMessage
+}
+
+func (s *realtimeSession) streamResponse(ctx context.Context, model string, payload []byte) {
+	st := &realtimeStream{responseID: "resp_" + realtimeID(), calls: make(map[int64]*realtimeItem), callIndex: make(map[int64]int)}
+	s.send("response.created", map[string]any{"response": st.responseObject("in_progress", nil)})
+
+	// The gin context is shared with the websocket reader, so the pipeline gets its own copy,
+	// bound to the response context so response.cancel stops the upstream request.
+	ginCtx := s.c.Copy()
+	if ginCtx.Request != nil {
+		ginCtx.Request = ginCtx.Request.WithContext(ctx)
+	}
+	cliCtx, cliCancel := s.h.GetContextWithCancel(s.h, ginCtx, ctx)
+	dataChan, errChan := s.h.ExecuteStreamWithAuthManager(cliCtx, OpenAI, model, payload, "")
+	var errMsg *interfaces.ErrorMessage
+	for dataChan != nil || errChan != nil {
+		select {
+		case chunk, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+				continue
+			}
+			s.handleChunk(st, chunk)
+		case msg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			if msg != nil {
+				errMsg = msg
+			}
+		}
+	}
+	status := "completed"
+	var details any
+	switch {
+	case ctx.Err() != nil:
+		status = "cancelled"
+		details = map[string]string{"type": "cancelled", "reason": "client_cancelled"}
+		cliCancel(ctx.Err())
+	case errMsg != nil:
+		status = "failed"
+		message := "upstream request failed"
+		if errMsg.Error != nil {
+			message = errMsg.Error.Error()
+		}
+		details = map[string]any{"type": "failed", "error": map[string]string{"type": "server_error", "message": message}}
+		s.sendError("server_error", "upstream_error", message, "")
+		cliCancel(errMsg.Error)
+	default:
+		cliCancel()
+	}
+	s.finishResponse(st, status, details)
+}
+
+// handleChunk converts one chat.completion.chunk into realtime events.
+func (s *realtimeSession) handleChunk(st *realtimeStream, chunk []byte) {
+	if !gjson.ValidBytes(chunk) {
+		return
+	}
+	if usage := gjson.GetBytes(chunk, "usage"); usage.IsObject() {
+		st.usage = realtimeUsage(usage)
+	}
+	delta := gjson.GetBytes(chunk, "choices.0.delta")
+	if text := delta.Get("content").String(); text != "" {
+		if st.text == nil {
+			st.text = &realtimeItem{ID: "item_" + realtimeID(), Object: "realtime.item", Type: "message", Status: "in_progress", Role: "assistant", Content: []realtimeContent{{Type: "text"}}}
+			st.textIndex = len(st.output)
+			st.output = append(st.output, st.text)
+			s.send("response.output_item.added", map[string]any{"response_id": st.responseID, "output_index": st.textIndex, "item": realtimeItem{ID: st.text.ID, Object: "realtime.item", Type: "message", Status: "in_progress", Role: "assistant", Content: []realtimeContent{}}})
+			s.send("response.content_part.added", map[string]any{"response_id": st.responseID, "item_id": st.text.ID, "output_index": st.textIndex, "content_index": 0, "part": realtimeContent{Type: "text"}})
+		}
+		st.text.Content[0].Text += text
+		s.send("response.text.delta", map[string]any{"response_id": st.responseID, "item_id": st.text.ID, "output_index": st.textIndex, "content_index": 0, "delta": text})
+	}
+	for _, call := range delta.Get("tool_calls").Array() {
+		index := call.Get("index").Int()
+		item := st.calls[index]
+		if item == nil {
+			item = &realtimeItem{ID: "item_" + realtimeID(), Object: "realtime.item", Type: "function_call", Status: "in_progress", CallID: call.Get("id").String(), Name: call.Get("function.name").String()}
+			if item.CallID == "" {
+				item.CallID = "call_" + realtimeID()
+			}
+			st.calls[index] = item
+			st.callIndex[index] = len(st.output)
+			st.output = append(st.output, item)
+			s.send("response.output_item.added", map[string]any{"response_id": st.responseID, "output_index": st.callIndex[index], "item": *item})
+		}
+		if args := call.Get("function.arguments").String(); args != "" {
+			item.Arguments += args
+			s.send("response.function_call_arguments.delta", map[string]any{"response_id": st.responseID, "item_id": item.ID, "output_index": st.callIndex[index], "call_id": item.CallID, "delta": args})
+		}
+	}
+}
+
+// finishResponse closes the open output items, adds them to the conversation and sends
+// response.done.
+func (s *realtimeSession) finishResponse(st *realtimeStream, status string, details any) {
+	itemStatus := "completed"
+	if status != "completed" {
+		itemStatus = "incomplete"
+	}
+	for index, item := range st.output {
+		item.Status = itemStatus
+		switch item.Type {
+		case "message":
+			text := item.Content[0].Text
+			s.send("response.text.done", map[string]any{"response_id": st.responseID, "item_id": item.ID, "output_index": index, "content_index": 0, "text": text})
+			s.send("response.content_part.done", map[string]any{"response_id": st.responseID, "item_id": item.ID, "output_index": index, "content_index": 0, "part": item.Content[0]})
+		case "function_call":
+			s.send("response.function_call_arguments.done", map[string]any{"response_id": st.responseID, "item_id": item.ID, "output_index": index, "call_id": item.CallID, "name": item.Name, "arguments": item.Arguments})
+		}
+		s.send("response.output_item.done", map[string]any{"response_id": st.responseID, "output_index": index, "item": *item})
+	}
+	s.mu.Lock()
+	for _, item := range st.output {
+		s.items = append(s.items, *item)
+	}
+	s.mu.Unlock()
+	s.send("response.done", map[string]any{"response": st.responseObject(status, details)})
+}
+
+func (st *realtimeStream) responseObject(status string, details any) map[string]any {
+	output := make([]realtimeItem, 0, len(st.output))
+	for _, item := range st.output {
+		output = append(output, *item)
+	}
+	resp := map[string]any{
+		"id":             st.responseID,
+		"object":         "realtime.response",
+		"status":         status,
+		"status_details": details,
+		"output":         output,
+		"usage":          nil,
+	}
+	if st.usage != nil {
+		resp["usage"] = st.usage
+	}
+	return resp
+}
+
+// realtimeUsage converts chat completion usage to the realtime usage object.
+func realtimeUsage(usage gjson.Result) json.RawMessage {
+	return mustMarshal(map[string]any{
+		"total_tokens":  usage.Get("total_tokens").Int(),
+		"input_tokens":  usage.Get("prompt_tokens").Int(),
+		"output_tokens": usage.Get("completion_tokens").Int(),
+		"input_token_details": map[string]int64{
+			"text_tokens":   usage.Get("prompt_tokens").Int(),
+			"cached_tokens": usage.Get("prompt_tokens_details.cached_tokens").Int(),
+		},
+		"output_token_details": map[string]int64{"text_tokens": usage.Get("completion_tokens").Int()},
+	})
+}
+
+// send writes a server event. Writes from the reader and the response goroutine are
+// serialized.
+func (s *realtimeSession) send(eventType string, fields map[string]any) {
+	fields["type"] = eventType
+	fields["event_id"] = "event_" + realtimeID()
+	data := mustMarshal(fields)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		log.Debugf("realtime: write %s failed: %v", eventType, err)
+	}
+}
+
+func (s *realtimeSession) sendError(errType, code, message, eventID string) {
+	detail := map[string]any{"type": errType, "code": code, "message": message, "event_id": nil}
+	if eventID != "" {
+		detail["event_id"] = eventID
+	}
+	s.send("error", map[string]any{"error": detail})
+}
+
+func mustMarshal(v any) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+func realtimeID() string {
+	var b [10]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
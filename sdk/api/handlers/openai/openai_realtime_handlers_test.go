@@ -0,0 +1,179 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// realtimeStreamExecutor streams a text answer, or a tool call when the request offers tools
+// and has no tool result yet.
+type realtimeStreamExecutor struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *realtimeStreamExecutor) Identifier() string { return "realtime-test-provider" }
+
+func (e *realtimeStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *realtimeStreamExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.mu.Unlock()
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14}}`,
+	}
+	if gjson.GetBytes(req.Payload, "tools").Exists() && !strings.Contains(string(req.Payload), `"role":"tool"`) {
+		chunks = []string{
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_time","arguments":"{\"tz\":"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"UTC\"}"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		}
+	}
+	out := make(chan coreexecutor.StreamChunk, len(chunks))
+	for _, chunk := range chunks {
+		out <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+	}
+	close(out)
+	return out, nil
+}
+
+func (e *realtimeStreamExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *realtimeStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *realtimeStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+// readUntil reads server events until one of type eventType arrives and returns all of them.
+func readUntil(t *testing.T, conn *websocket.Conn, eventType string) []gjson.Result {
+	t.Helper()
+	var events []gjson.Result
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read while waiting for %s: %v", eventType, err)
+		}
+		event := gjson.ParseBytes(data)
+		events = append(events, event)
+		if event.Get("type").String() == eventType {
+			return events
+		}
+	}
+}
+
+func TestRealtimeBridgesTextAndToolCalls(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &realtimeStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "realtime-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "realtime-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.GET("/v1/realtime", h.Realtime)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v1/realtime?model=realtime-model", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	readUntil(t, conn, "session.created")
+
+	send := func(event string) {
+		if errWrite := conn.WriteMessage(websocket.TextMessage, []byte(event)); errWrite != nil {
+			t.Fatalf("write: %v", errWrite)
+		}
+	}
+	send(`{"type":"session.update","session":{"instructions":"Be brief.","modalities":["text","audio"]}}`)
+	if updated := readUntil(t, conn, "session.updated"); updated[len(updated)-1].Get("session.modalities").Raw != `["text"]` {
+		t.Fatalf("session.updated = %s", updated[len(updated)-1].Raw)
+	}
+	send(`{"type":"conversation.item.create","item":{"type":"message","role":"user","content":[{"type":"input_text","text":"Hi"}]}}`)
+	readUntil(t, conn, "conversation.item.created")
+	send(`{"type":"response.create"}`)
+	events := readUntil(t, conn, "response.done")
+	var text strings.Builder
+	for _, event := range events {
+		if event.Get("type").String() == "response.text.delta" {
+			text.WriteString(event.Get("delta").String())
+		}
+	}
+	done := events[len(events)-1]
+	if text.String() != "Hello" || done.Get("response.status").String() != "completed" ||
+		done.Get("response.output.0.content.0.text").String() != "Hello" || done.Get("response.usage.total_tokens").Int() != 14 {
+		t.Fatalf("text = %q, response.done = %s", text.String(), done.Raw)
+	}
+	executor.mu.Lock()
+	first := executor.payloads[0]
+	executor.mu.Unlock()
+	if gjson.Get(first, "messages.0.content").String() != "Be brief." || gjson.Get(first, "messages.1.content").String() != "Hi" || !gjson.Get(first, "stream").Bool() {
+		t.Fatalf("first upstream request = %s", first)
+	}
+
+	send(`{"type":"response.create","response":{"tools":[{"type":"function","name":"get_time","parameters":{"type":"object"}}]}}`)
+	events = readUntil(t, conn, "response.done")
+	var args gjson.Result
+	for _, event := range events {
+		if event.Get("type").String() == "response.function_call_arguments.done" {
+			args = event
+		}
+	}
+	if args.Get("name").String() != "get_time" || args.Get("arguments").String() != `{"tz":"UTC"}` || args.Get("call_id").String() != "call_1" {
+		t.Fatalf("function call events = %v", events)
+	}
+	executor.mu.Lock()
+	second := executor.payloads[1]
+	executor.mu.Unlock()
+	if gjson.Get(second, "tools.0.function.name").String() != "get_time" || gjson.Get(second, "messages.2.role").String() != "assistant" {
+		t.Fatalf("second upstream request = %s", second)
+	}
+
+	send(`{"type":"conversation.item.create","item":{"type":"function_call_output","call_id":"call_1","output":"12:00"}}`)
+	readUntil(t, conn, "conversation.item.created")
+	send(`{"type":"response.create"}`)
+	readUntil(t, conn, "response.done")
+	executor.mu.Lock()
+	third := executor.payloads[2]
+	executor.mu.Unlock()
+	if gjson.Get(third, "messages.3.tool_calls.0.id").String() != "call_1" || gjson.Get(third, "messages.4.tool_call_id").String() != "call_1" {
+		t.Fatalf("third upstream request = %s", third)
+	}
+
+	send(`{"type":"input_audio_buffer.append","audio":""}`)
+	if errEvents := readUntil(t, conn, "error"); errEvents[len(errEvents)-1].Get("error.code").String() != "audio_not_supported" {
+		t.Fatalf("audio error = %s", errEvents[len(errEvents)-1].Raw)
+	}
+}
@@ -57,10 +57,11 @@ func (h *OpenAIResponsesAPIHandler) Models() []map[string]any {
 // It returns a list of available AI models with their capabilities
 // and specifications in OpenAIResponses-compatible format.
 func (h *OpenAIResponsesAPIHandler) OpenAIResponsesModels(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	models, stale := handlers.ListModels("openai")
+	c.JSON(http.StatusOK, handlers.AddModelListStaleness(gin.H{
 		"object": "list",
-		"data":   h.Models(),
-	})
+		"data":   models,
+	}, stale))
 }
 
 // Responses handles the /v1/responses endpoint.
@@ -267,5 +268,6 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flush
 		WriteDone: func() {
 			_, _ = c.Writer.Write([]byte("\n"))
 		},
+		IsBoundary: responsesEventBoundary,
 	})
 }
@@ -0,0 +1,151 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxTranscriptionBytes matches the upload limit of OpenAI's transcription endpoint.
+const maxTranscriptionBytes = 25 << 20
+
+// audioMimeTypes covers the formats OpenAI accepts, for uploads sent without a usable type.
+var audioMimeTypes = map[string]string{
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".mp4":  "audio/mp4",
+	".mpeg": "audio/mpeg",
+	".mpga": "audio/mpeg",
+	".oga":  "audio/ogg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".webm": "audio/webm",
+}
+
+// AudioTranscriptions handles the /v1/audio/transcriptions endpoint.
+// The multipart upload is converted to a JSON request carrying the audio as base64; executors
+// send it inline to Gemini models or re-upload it to OpenAI-compatible upstreams. The result
+// is returned as json, verbose_json or text.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) AudioTranscriptions(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxTranscriptionBytes+1<<20)
+	rawJSON, msg := buildTranscriptionRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: msg,
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, OpenAITranscription, modelName, rawJSON, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	switch format := gjson.GetBytes(rawJSON, "response_format").String(); format {
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(gjson.GetBytes(resp, "text").String()))
+	case "verbose_json":
+		if !gjson.GetBytes(resp, "task").Exists() {
+			resp, _ = sjson.SetBytes(resp, "task", "transcribe")
+		}
+		if language := gjson.GetBytes(rawJSON, "language").String(); language != "" && !gjson.GetBytes(resp, "language").Exists() {
+			resp, _ = sjson.SetBytes(resp, "language", language)
+		}
+		c.Data(http.StatusOK, "application/json", resp)
+	default:
+		c.Data(http.StatusOK, "application/json", resp)
+	}
+	cliCancel()
+}
+
+// buildTranscriptionRequest reads the multipart upload into the JSON form executors expect.
+// It returns a message describing why the request cannot be served, or "" when it is valid.
+func buildTranscriptionRequest(c *gin.Context) ([]byte, string) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Sprintf("file is required: %v", err)
+	}
+	if header.Size > maxTranscriptionBytes {
+		return nil, fmt.Sprintf("file exceeds %d MB", maxTranscriptionBytes>>20)
+	}
+	model := strings.TrimSpace(c.PostForm("model"))
+	if model == "" {
+		return nil, "model is required"
+	}
+	format := c.PostForm("response_format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "text", "verbose_json":
+	case "srt", "vtt":
+		return nil, "response_format " + format + " is not supported; use json, verbose_json or text"
+	default:
+		return nil, "response_format must be json, verbose_json or text"
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Sprintf("Invalid request: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	audio, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Sprintf("Invalid request: %v", err)
+	}
+	if len(audio) == 0 {
+		return nil, "file is empty"
+	}
+
+	out := []byte(`{"model":"","file":{"name":"","mime_type":"","data":""},"response_format":""}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "file.name", header.Filename)
+	out, _ = sjson.SetBytes(out, "file.mime_type", audioMimeType(header.Filename, header.Header.Get("Content-Type")))
+	out, _ = sjson.SetBytes(out, "file.data", base64.StdEncoding.EncodeToString(audio))
+	out, _ = sjson.SetBytes(out, "response_format", format)
+	for _, key := range []string{"language", "prompt"} {
+		if value := strings.TrimSpace(c.PostForm(key)); value != "" {
+			out, _ = sjson.SetBytes(out, key, value)
+		}
+	}
+	if raw := strings.TrimSpace(c.PostForm("temperature")); raw != "" {
+		temperature, errParse := strconv.ParseFloat(raw, 64)
+		if errParse != nil || temperature < 0 || temperature > 1 {
+			return nil, "temperature must be a number between 0 and 1"
+		}
+		out, _ = sjson.SetBytes(out, "temperature", temperature)
+	}
+	return out, ""
+}
+
+// audioMimeType prefers the part's declared audio type and falls back to the file extension.
+func audioMimeType(filename, declared string) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && (strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/")) {
+		return mediaType
+	}
+	if mimeType, ok := audioMimeTypes[strings.ToLower(filepath.Ext(filename))]; ok {
+		return mimeType
+	}
+	return "audio/mpeg"
+}
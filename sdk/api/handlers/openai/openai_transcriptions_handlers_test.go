@@ -0,0 +1,128 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// transcriptionExecutor records the JSON form of each upload and returns a fixed transcript.
+type transcriptionExecutor struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *transcriptionExecutor) Identifier() string { return "transcriptions-test-provider" }
+
+func (e *transcriptionExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.mu.Unlock()
+	return coreexecutor.Response{Payload: []byte(`{"text":"Hello there.","usage":{"type":"tokens","input_tokens":40,"output_tokens":3,"total_tokens":43}}`)}, nil
+}
+
+func (e *transcriptionExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *transcriptionExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *transcriptionExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *transcriptionExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func transcriptionUploadRequest(t *testing.T, fields map[string]string, audio []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range fields {
+		_ = form.WriteField(key, value)
+	}
+	if audio != nil {
+		part, err := form.CreateFormFile("file", "clip.wav")
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		_, _ = part.Write(audio)
+	}
+	_ = form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestAudioTranscriptionsConvertsUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &transcriptionExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "transcriptions-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "transcribe-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/audio/transcriptions", h.AudioTranscriptions)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, transcriptionUploadRequest(t, map[string]string{"model": "transcribe-model", "language": "en", "temperature": "0.1", "response_format": "verbose_json"}, []byte("RIFF")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if gjson.Get(rec.Body.String(), "text").String() != "Hello there." || gjson.Get(rec.Body.String(), "task").String() != "transcribe" ||
+		gjson.Get(rec.Body.String(), "language").String() != "en" {
+		t.Fatalf("verbose_json response = %s", rec.Body.String())
+	}
+	executor.mu.Lock()
+	payload := executor.payloads[0]
+	executor.mu.Unlock()
+	if gjson.Get(payload, "file.data").String() != "UklGRg==" || gjson.Get(payload, "file.mime_type").String() != "audio/wav" ||
+		gjson.Get(payload, "temperature").Float() != 0.1 {
+		t.Fatalf("upstream request = %s", payload)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, transcriptionUploadRequest(t, map[string]string{"model": "transcribe-model", "response_format": "text"}, []byte("RIFF")))
+	if rec.Code != http.StatusOK || rec.Body.String() != "Hello there." {
+		t.Fatalf("text response = %d %q", rec.Code, rec.Body.String())
+	}
+
+	for _, fields := range []map[string]string{
+		{"response_format": "json"},
+		{"model": "transcribe-model", "response_format": "srt"},
+		{"model": "transcribe-model", "temperature": "2"},
+	} {
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, transcriptionUploadRequest(t, fields, []byte("RIFF")))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("fields %v: status = %d, want 400", fields, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, transcriptionUploadRequest(t, map[string]string{"model": "transcribe-model"}, nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing file: status = %d, want 400", rec.Code)
+	}
+}
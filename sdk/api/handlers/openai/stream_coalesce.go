@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"bytes"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// chatChunkBoundary reports whether a Chat Completions chunk starts a tool call, finishes a
+// choice or carries usage, all of which are forwarded without coalescing delay.
+func chatChunkBoundary(chunk []byte) bool {
+	if !gjson.ValidBytes(chunk) {
+		return false
+	}
+	root := gjson.ParseBytes(chunk)
+	if usage := root.Get("usage"); usage.Exists() && usage.Type != gjson.Null {
+		return true
+	}
+	boundary := false
+	root.Get("choices").ForEach(func(_, choice gjson.Result) bool {
+		if reason := choice.Get("finish_reason"); reason.Exists() && reason.Type != gjson.Null && reason.String() != "" {
+			boundary = true
+			return false
+		}
+		choice.Get("delta.tool_calls").ForEach(func(_, call gjson.Result) bool {
+			if call.Get("id").String() != "" || call.Get("function.name").String() != "" {
+				boundary = true
+			}
+			return !boundary
+		})
+		return !boundary
+	})
+	return boundary
+}
+
+// mergeableChatDeltaFields are the delta fields whose text can be concatenated across chunks.
+var mergeableChatDeltaFields = []string{"content", "reasoning_content"}
+
+// mergeChatChunks concatenates the text deltas of two consecutive Chat Completions chunks.
+// Only single-choice chunks carrying the same set of plain text delta fields are merged, so
+// role changes, tool calls and finish reasons keep their own chunks.
+func mergeChatChunks(pending, next []byte) ([]byte, bool) {
+	fields, ok := chatTextDeltaFields(pending)
+	if !ok {
+		return nil, false
+	}
+	nextFields, ok := chatTextDeltaFields(next)
+	if !ok || fields != nextFields {
+		return nil, false
+	}
+	if gjson.GetBytes(pending, "id").String() != gjson.GetBytes(next, "id").String() {
+		return nil, false
+	}
+	merged := pending
+	for _, field := range mergeableChatDeltaFields {
+		path := "choices.0.delta." + field
+		if !gjson.GetBytes(next, path).Exists() {
+			continue
+		}
+		text := gjson.GetBytes(pending, path).String() + gjson.GetBytes(next, path).String()
+		var err error
+		if merged, err = sjson.SetBytes(merged, path, text); err != nil {
+			return nil, false
+		}
+	}
+	return merged, true
+}
+
+// chatTextDeltaFields returns a bitmask of the text fields in a mergeable chunk's delta and
+// reports false when the chunk carries anything else.
+func chatTextDeltaFields(chunk []byte) (int, bool) {
+	if !gjson.ValidBytes(chunk) {
+		return 0, false
+	}
+	root := gjson.ParseBytes(chunk)
+	if root.Get("object").String() != "chat.completion.chunk" {
+		return 0, false
+	}
+	if usage := root.Get("usage"); usage.Exists() && usage.Type != gjson.Null {
+		return 0, false
+	}
+	choices := root.Get("choices").Array()
+	if len(choices) != 1 || choices[0].Get("index").Int() != 0 {
+		return 0, false
+	}
+	if reason := choices[0].Get("finish_reason"); reason.Exists() && reason.Type != gjson.Null {
+		return 0, false
+	}
+	mask := 0
+	ok := true
+	choices[0].Get("delta").ForEach(func(key, value gjson.Result) bool {
+		for i, field := range mergeableChatDeltaFields {
+			if key.String() == field && value.Type == gjson.String {
+				mask |= 1 << i
+				return true
+			}
+		}
+		ok = false
+		return false
+	})
+	return mask, ok && mask != 0
+}
+
+// responsesBoundaryEvents are Responses API stream events forwarded without coalescing delay.
+var responsesBoundaryEvents = [][]byte{
+	[]byte("response.output_item.added"),
+	[]byte("response.output_item.done"),
+	[]byte("response.completed"),
+	[]byte("response.failed"),
+	[]byte("response.incomplete"),
+}
+
+// responsesEventBoundary reports whether a Responses API SSE chunk opens or closes an output
+// item (such as a function call) or ends the response.
+func responsesEventBoundary(chunk []byte) bool {
+	line, _, _ := bytes.Cut(chunk, []byte("\n"))
+	name, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("event:"))
+	if !ok {
+		return false
+	}
+	name = bytes.TrimSpace(name)
+	for _, event := range responsesBoundaryEvents {
+		if bytes.Equal(name, event) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestMergeChatChunksConcatenatesContent(t *testing.T) {
+	a := []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hel"},"finish_reason":null}]}`)
+	b := []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":null}]}`)
+
+	merged, ok := mergeChatChunks(a, b)
+	if !ok {
+		t.Fatal("expected chunks to merge")
+	}
+	if got := gjson.GetBytes(merged, "choices.0.delta.content").String(); got != "Hello" {
+		t.Fatalf("merged content = %q, want Hello", got)
+	}
+}
+
+func TestMergeChatChunksKeepsStructuralChunksApart(t *testing.T) {
+	text := []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`)
+	cases := map[string][]byte{
+		"reasoning":  []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"reasoning_content":"hmm"},"finish_reason":null}]}`),
+		"role":       []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"x"},"finish_reason":null}]}`),
+		"tool call":  []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"f","arguments":""}}]},"finish_reason":null}]}`),
+		"finish":     []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"!"},"finish_reason":"stop"}]}`),
+		"other id":   []byte(`{"id":"c2","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"x"},"finish_reason":null}]}`),
+		"completion": []byte(`{"id":"c1","object":"text_completion","choices":[{"index":0,"text":"x","finish_reason":null}]}`),
+	}
+	for name, next := range cases {
+		if _, ok := mergeChatChunks(text, next); ok {
+			t.Errorf("%s: chunks should not merge", name)
+		}
+	}
+}
+
+func TestChatChunkBoundary(t *testing.T) {
+	cases := []struct {
+		chunk string
+		want  bool
+	}{
+		{`{"choices":[{"index":0,"delta":{"content":"x"},"finish_reason":null}]}`, false},
+		{`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"f"}}]},"finish_reason":null}]}`, true},
+		{`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"a\""}}]},"finish_reason":null}]}`, false},
+		{`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`, true},
+		{`{"choices":[],"usage":{"total_tokens":3}}`, true},
+	}
+	for _, tc := range cases {
+		if got := chatChunkBoundary([]byte(tc.chunk)); got != tc.want {
+			t.Errorf("chatChunkBoundary(%s) = %v, want %v", tc.chunk, got, tc.want)
+		}
+	}
+}
+
+func TestResponsesEventBoundary(t *testing.T) {
+	if !responsesEventBoundary([]byte("event: response.output_item.added\ndata: {}")) {
+		t.Error("output_item.added should be a boundary")
+	}
+	if responsesEventBoundary([]byte("event: response.output_text.delta\ndata: {}")) {
+		t.Error("output_text.delta should not be a boundary")
+	}
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultOutputBudgetTTL = 24 * time.Hour
+	// claudeMinThinkingBudget is the smallest thinking budget Claude accepts.
+	claudeMinThinkingBudget = 1024
+	// outputBudgetTrackerMax bounds remembered conversations; expired ones are purged first.
+	outputBudgetTrackerMax = 8192
+)
+
+func init() {
+	coreusage.RegisterPlugin(outputBudgetPlugin{})
+}
+
+type outputBudgetContextKey struct{}
+
+// outputBudgetEntry is a conversation's output token spend.
+type outputBudgetEntry struct {
+	used   int64
+	ttl    time.Duration
+	expire time.Time
+}
+
+// outputBudgetTracker accumulates output tokens per conversation.
+type outputBudgetTracker struct {
+	mu    sync.Mutex
+	spent map[string]outputBudgetEntry
+	nowFn func() time.Time
+}
+
+var outputBudgets = &outputBudgetTracker{spent: make(map[string]outputBudgetEntry), nowFn: time.Now}
+
+// used returns the tokens conversation has spent and extends its retention by ttl.
+func (t *outputBudgetTracker) used(conversation string, ttl time.Duration) int64 {
+	now := t.nowFn()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.spent[conversation]
+	if ok && !now.Before(entry.expire) {
+		entry, ok = outputBudgetEntry{}, false
+	}
+	if !ok && len(t.spent) >= outputBudgetTrackerMax {
+		for key, e := range t.spent {
+			if !now.Before(e.expire) {
+				delete(t.spent, key)
+			}
+		}
+		if len(t.spent) >= outputBudgetTrackerMax {
+			t.spent = make(map[string]outputBudgetEntry)
+		}
+	}
+	entry.ttl = ttl
+	entry.expire = now.Add(ttl)
+	t.spent[conversation] = entry
+	return entry.used
+}
+
+// add records tokens spent by conversation. Unknown or expired conversations are ignored.
+func (t *outputBudgetTracker) add(conversation string, tokens int64) {
+	if tokens <= 0 {
+		return
+	}
+	now := t.nowFn()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.spent[conversation]
+	if !ok || !now.Before(entry.expire) {
+		return
+	}
+	entry.used += tokens
+	entry.expire = now.Add(entry.ttl)
+	t.spent[conversation] = entry
+}
+
+// outputBudgetPlugin charges reported output tokens to the conversation tagged on the
+// request context by applyOutputBudget.
+type outputBudgetPlugin struct{}
+
+// HandleUsage implements coreusage.Plugin.
+func (outputBudgetPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if ctx == nil {
+		return
+	}
+	conversation, _ := ctx.Value(outputBudgetContextKey{}).(string)
+	if conversation == "" {
+		return
+	}
+	tokens := record.Detail.OutputTokens
+	if geminiFamilyProviders.has(record.Provider) {
+		// Gemini reports thinking tokens separately from candidate tokens but bills both as output.
+		tokens += record.Detail.ReasoningTokens
+	}
+	outputBudgets.add(conversation, tokens)
+}
+
+// applyOutputBudget enforces the per-conversation output token budget. It caps the request's
+// max output tokens to what is left of the budget and tags ctx so reported usage is charged
+// to the conversation. Thinking budgets are lowered to fit under the new cap. Requests of
+// conversations that exhausted their budget are rejected with a 429 until the conversation
+// has been idle for the budget's TTL.
+func (h *BaseAPIHandler) applyOutputBudget(ctx context.Context, handlerType, model string, rawJSON []byte) (context.Context, []byte, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || !h.Cfg.OutputBudget.Enabled || h.Cfg.OutputBudget.MaxTokens <= 0 || len(rawJSON) == 0 {
+		return ctx, rawJSON, nil
+	}
+	path := maxOutputTokensPath(handlerType, rawJSON)
+	if path == "" {
+		return ctx, rawJSON, nil
+	}
+	conversation, _ := conversationKey(ctx, handlerType, model, rawJSON)
+	if conversation == "" {
+		return ctx, rawJSON, nil
+	}
+	ttl := defaultOutputBudgetTTL
+	if minutes := h.Cfg.OutputBudget.TTLMinutes; minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	limit := h.Cfg.OutputBudget.MaxTokens
+	used := outputBudgets.used(conversation, ttl)
+	remaining := limit - used
+	if remaining <= 0 {
+		body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{
+			Message: fmt.Sprintf("conversation output token budget exhausted: used %d of %d tokens", used, limit),
+			Type:    "output_budget_exceeded",
+			Code:    "output_budget_exhausted",
+		}})
+		headers := make(http.Header)
+		headers.Set("Retry-After", strconv.FormatInt(int64(ttl/time.Second), 10))
+		return ctx, rawJSON, &interfaces.ErrorMessage{
+			StatusCode: http.StatusTooManyRequests,
+			Error:      errors.New(string(body)),
+			Addon:      headers,
+		}
+	}
+	if current := gjson.GetBytes(rawJSON, path); !current.Exists() || current.Int() <= 0 || current.Int() > remaining {
+		if updated, err := sjson.SetBytes(rawJSON, path, remaining); err == nil {
+			rawJSON = clampThinkingBudget(handlerType, updated, remaining)
+		}
+	}
+	return context.WithValue(ctx, outputBudgetContextKey{}, conversation), rawJSON, nil
+}
+
+// clampThinkingBudget keeps the request's thinking budget below maxTokens; Claude rejects
+// budgets that are not, and Gemini would spend the whole cap thinking. Claude thinking is
+// dropped when the cap leaves no room for its minimum budget.
+func clampThinkingBudget(handlerType string, rawJSON []byte, maxTokens int64) []byte {
+	var path string
+	switch handlerType {
+	case constant.Claude:
+		path = "thinking.budget_tokens"
+	case constant.Gemini:
+		path = "generationConfig.thinkingConfig.thinkingBudget"
+	case constant.GeminiCLI:
+		path = "request.generationConfig.thinkingConfig.thinkingBudget"
+	default:
+		return rawJSON
+	}
+	budget := gjson.GetBytes(rawJSON, path)
+	if !budget.Exists() || budget.Int() < maxTokens {
+		return rawJSON
+	}
+	var (
+		updated []byte
+		err     error
+	)
+	switch {
+	case handlerType == constant.Claude && maxTokens-1 < claudeMinThinkingBudget:
+		updated, err = sjson.DeleteBytes(rawJSON, "thinking")
+	case maxTokens <= 1:
+		updated, err = sjson.DeleteBytes(rawJSON, path)
+	default:
+		updated, err = sjson.SetBytes(rawJSON, path, maxTokens-1)
+	}
+	if err != nil {
+		return rawJSON
+	}
+	return updated
+}
+
+// maxOutputTokensPath returns the request field limiting output tokens for handlerType.
+func maxOutputTokensPath(handlerType string, rawJSON []byte) string {
+	switch handlerType {
+	case constant.OpenAI:
+		if gjson.GetBytes(rawJSON, "max_completion_tokens").Exists() {
+			return "max_completion_tokens"
+		}
+		return "max_tokens"
+	case constant.OpenaiResponse:
+		return "max_output_tokens"
+	case constant.Claude:
+		return "max_tokens"
+	case constant.Gemini:
+		return "generationConfig.maxOutputTokens"
+	case constant.GeminiCLI:
+		return "request.generationConfig.maxOutputTokens"
+	}
+	return ""
+}
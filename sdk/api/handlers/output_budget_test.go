@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyOutputBudgetCapsAndExhausts(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		OutputBudget: sdkconfig.OutputBudgetConfig{Enabled: true, MaxTokens: 1000},
+	}}
+	turn := []byte(`{"model":"claude-sonnet-4-5","max_tokens":4096,"metadata":{"user_id":"budget-test-session"},"messages":[{"role":"user","content":"hi"}]}`)
+
+	ctx, out, errMsg := h.applyOutputBudget(context.Background(), "claude", "claude-sonnet-4-5", turn)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 1000 {
+		t.Fatalf("max_tokens = %d, want 1000", got)
+	}
+
+	outputBudgetPlugin{}.HandleUsage(ctx, coreusage.Record{Provider: "claude", Detail: coreusage.Detail{OutputTokens: 900}})
+
+	ctx, out, errMsg = h.applyOutputBudget(context.Background(), "claude", "claude-sonnet-4-5", turn)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 100 {
+		t.Fatalf("max_tokens = %d, want 100", got)
+	}
+
+	outputBudgetPlugin{}.HandleUsage(ctx, coreusage.Record{Provider: "claude", Detail: coreusage.Detail{OutputTokens: 100}})
+
+	_, _, errMsg = h.applyOutputBudget(context.Background(), "claude", "claude-sonnet-4-5", turn)
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected exhausted budget to be rejected, got %+v", errMsg)
+	}
+	body := BuildErrorResponseBody(errMsg.StatusCode, errMsg.Error.Error())
+	if got := gjson.GetBytes(body, "error.type").String(); got != "output_budget_exceeded" {
+		t.Fatalf("error type = %q, want output_budget_exceeded: %s", got, body)
+	}
+	if errMsg.Addon.Get("Retry-After") == "" {
+		t.Fatal("exhausted budget must say when it resets")
+	}
+}
+
+func TestApplyOutputBudgetClampsThinkingBudget(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		OutputBudget: sdkconfig.OutputBudgetConfig{Enabled: true, MaxTokens: 2000},
+	}}
+	claude := []byte(`{"max_tokens":16000,"thinking":{"type":"enabled","budget_tokens":8000},"metadata":{"user_id":"budget-thinking-session"},"messages":[{"role":"user","content":"hi"}]}`)
+	ctx, out, _ := h.applyOutputBudget(context.Background(), "claude", "claude-sonnet-4-5", claude)
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 1999 {
+		t.Fatalf("budget_tokens = %d, want 1999 below max_tokens 2000", got)
+	}
+
+	outputBudgetPlugin{}.HandleUsage(ctx, coreusage.Record{Provider: "claude", Detail: coreusage.Detail{OutputTokens: 1500}})
+	_, out, _ = h.applyOutputBudget(context.Background(), "claude", "claude-sonnet-4-5", claude)
+	if gjson.GetBytes(out, "max_tokens").Int() != 500 || gjson.GetBytes(out, "thinking").Exists() {
+		t.Fatalf("thinking must be dropped when the cap is below its minimum budget: %s", out)
+	}
+
+	gemini := []byte(`{"generationConfig":{"thinkingConfig":{"thinkingBudget":4096}},"contents":[{"role":"user","parts":[{"text":"budget thinking gemini"}]}]}`)
+	_, out, _ = h.applyOutputBudget(context.Background(), "gemini", "gemini-2.5-pro", gemini)
+	if got := gjson.GetBytes(out, "generationConfig.thinkingConfig.thinkingBudget").Int(); got != 1999 {
+		t.Fatalf("thinkingBudget = %d, want 1999", got)
+	}
+}
+
+func TestApplyOutputBudgetKeepsSmallerLimitAndCountsGeminiThoughts(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		OutputBudget: sdkconfig.OutputBudgetConfig{Enabled: true, MaxTokens: 1000},
+	}}
+	turn := []byte(`{"generationConfig":{"maxOutputTokens":200},"contents":[{"role":"user","parts":[{"text":"budget gemini"}]}]}`)
+
+	ctx, out, errMsg := h.applyOutputBudget(context.Background(), "gemini", "gemini-2.5-pro", turn)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.maxOutputTokens").Int(); got != 200 {
+		t.Fatalf("maxOutputTokens = %d, want 200", got)
+	}
+
+	outputBudgetPlugin{}.HandleUsage(ctx, coreusage.Record{Provider: "gemini", Detail: coreusage.Detail{OutputTokens: 300, ReasoningTokens: 500}})
+
+	_, out, _ = h.applyOutputBudget(context.Background(), "gemini", "gemini-2.5-pro", turn)
+	if got := gjson.GetBytes(out, "generationConfig.maxOutputTokens").Int(); got != 200 {
+		t.Fatalf("maxOutputTokens = %d, want 200", got)
+	}
+	_, out, _ = h.applyOutputBudget(context.Background(), "gemini", "gemini-2.5-pro", []byte(`{"contents":[{"role":"user","parts":[{"text":"budget gemini"}]}]}`))
+	if got := gjson.GetBytes(out, "generationConfig.maxOutputTokens").Int(); got != 200 {
+		t.Fatalf("maxOutputTokens = %d, want remaining 200", got)
+	}
+}
+
+func TestApplyOutputBudgetDisabled(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}
+	payload := []byte(`{"max_tokens":4096,"messages":[{"role":"user","content":"hi"}]}`)
+	ctx, out, errMsg := h.applyOutputBudget(context.Background(), "claude", "claude-sonnet-4-5", payload)
+	if errMsg != nil || string(out) != string(payload) {
+		t.Fatalf("disabled budget must leave the request untouched: %s", out)
+	}
+	if ctx.Value(outputBudgetContextKey{}) != nil {
+		t.Fatal("disabled budget must not tag the context")
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// googleURLPattern matches google.com links, including redirect and grounding URLs.
+var googleURLPattern = regexp.MustCompile(`https?://[a-zA-Z0-9.-]*google\.com[^\s)\]"]*`)
+
+// outputPipeline is a compiled list of output post-processing rules.
+type outputPipeline struct {
+	steps       []func(string) string
+	closeFences bool
+}
+
+// outputPipelines caches compiled pipelines by their rule set, so regular expressions are
+// compiled once per configuration rather than per request.
+var outputPipelines sync.Map // string -> *outputPipeline
+
+// outputPipelineFor resolves and compiles the post-processing rules of the calling client key.
+// It returns nil when no rules apply.
+func (h *BaseAPIHandler) outputPipelineFor(ctx context.Context) *outputPipeline {
+	if h == nil || h.Cfg == nil {
+		return nil
+	}
+	var ginCtx *gin.Context
+	if ctx != nil {
+		ginCtx, _ = ctx.Value("gin").(*gin.Context)
+	}
+	rules := h.Cfg.OutputRulesFor(logging.ClientKeyIdentifiers(ginCtx)...)
+	if len(rules) == 0 {
+		return nil
+	}
+	signature, err := json.Marshal(rules)
+	if err != nil {
+		return nil
+	}
+	if cached, ok := outputPipelines.Load(string(signature)); ok {
+		return cached.(*outputPipeline)
+	}
+	pipeline := compileOutputRules(rules)
+	outputPipelines.Store(string(signature), pipeline)
+	return pipeline
+}
+
+func compileOutputRules(rules []config.OutputRule) *outputPipeline {
+	p := &outputPipeline{}
+	for i, rule := range rules {
+		switch strings.ToLower(strings.TrimSpace(rule.Type)) {
+		case config.OutputRuleRegex:
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil || rule.Pattern == "" {
+				log.Warnf("output-postprocess: rule %d: invalid pattern %q: %v", i, rule.Pattern, err)
+				continue
+			}
+			replace := rule.Replace
+			p.steps = append(p.steps, func(s string) string { return re.ReplaceAllString(s, replace) })
+		case config.OutputRuleBan:
+			words := make([]string, 0, len(rule.Words))
+			for _, word := range rule.Words {
+				if word != "" {
+					words = append(words, regexp.QuoteMeta(word))
+				}
+			}
+			if len(words) == 0 {
+				continue
+			}
+			re := regexp.MustCompile(`(?i)(?:` + strings.Join(words, "|") + `)`)
+			replace := rule.Replace
+			p.steps = append(p.steps, func(s string) string { return re.ReplaceAllLiteralString(s, replace) })
+		case config.OutputRuleStripGoogleURLs:
+			p.steps = append(p.steps, func(s string) string { return googleURLPattern.ReplaceAllString(s, "") })
+		case config.OutputRuleCloseCodeFences:
+			p.closeFences = true
+		default:
+			log.Warnf("output-postprocess: rule %d: unknown type %q", i, rule.Type)
+		}
+	}
+	return p
+}
+
+func (p *outputPipeline) apply(text string, final bool) string {
+	for _, step := range p.steps {
+		text = step(text)
+	}
+	if final && p.closeFences && strings.Count(text, "```")%2 == 1 {
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		text += "```"
+	}
+	return text
+}
+
+// process rewrites the text fields of a response payload. final is true for complete
+// (non-streamed) responses. Payloads may be a JSON document or SSE text with "data:" lines.
+func (p *outputPipeline) process(payload []byte, final bool) []byte {
+	if p == nil || len(payload) == 0 {
+		return payload
+	}
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return p.processJSON(payload, final)
+	}
+	lines := bytes.Split(payload, []byte("\n"))
+	changed := false
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if len(data) == 0 || data[0] != '{' {
+			continue
+		}
+		if out := p.processJSON(data, final); !bytes.Equal(out, data) {
+			lines[i] = append([]byte("data: "), out...)
+			changed = true
+		}
+	}
+	if !changed {
+		return payload
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// processJSON rewrites the string values of "text" and "content" fields, and the string
+// "delta" of OpenAI Responses text events, which together cover the text output of every
+// supported response format.
+func (p *outputPipeline) processJSON(doc []byte, final bool) []byte {
+	if !gjson.ValidBytes(doc) {
+		return doc
+	}
+	var paths []string
+	collectOutputTextPaths(gjson.ParseBytes(doc), "", &paths)
+	for _, path := range paths {
+		original := gjson.GetBytes(doc, path).String()
+		if updated := p.apply(original, final); updated != original {
+			if out, err := sjson.SetBytes(doc, path, updated); err == nil {
+				doc = out
+			}
+		}
+	}
+	return doc
+}
+
+func collectOutputTextPaths(node gjson.Result, path string, out *[]string) {
+	if node.IsArray() {
+		for i, item := range node.Array() {
+			collectOutputTextPaths(item, joinOutputPath(path, strconv.Itoa(i)), out)
+		}
+		return
+	}
+	if !node.IsObject() {
+		return
+	}
+	node.ForEach(func(key, value gjson.Result) bool {
+		name := key.String()
+		child := joinOutputPath(path, escapeOutputPathKey(name))
+		switch {
+		case value.Type == gjson.String && (name == "text" || name == "content"):
+			*out = append(*out, child)
+		case value.Type == gjson.String && name == "delta" && strings.HasSuffix(node.Get("type").String(), "text.delta"):
+			*out = append(*out, child)
+		case outputToolArgumentKeys[name]:
+			// Tool call arguments are data for the client, not model text.
+		case value.IsObject() || value.IsArray():
+			collectOutputTextPaths(value, child, out)
+		}
+		return true
+	})
+}
+
+// outputToolArgumentKeys name the fields holding tool call arguments in each format.
+var outputToolArgumentKeys = map[string]bool{
+	"input":         true, // Claude tool_use
+	"args":          true, // Gemini functionCall
+	"tool_calls":    true, // OpenAI chat completions
+	"function_call": true,
+}
+
+func joinOutputPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+var outputPathEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`, "@", `\@`)
+
+func escapeOutputPathKey(key string) string {
+	return outputPathEscaper.Replace(key)
+}
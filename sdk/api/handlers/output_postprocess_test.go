@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestOutputPipelineRewritesResponseText(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{OutputPostProcess: sdkconfig.OutputPostProcessConfig{
+		Rules: []sdkconfig.OutputRule{
+			{Type: sdkconfig.OutputRuleStripGoogleURLs},
+			{Type: sdkconfig.OutputRuleBan, Words: []string{"As an AI"}, Replace: "[removed]"},
+			{Type: sdkconfig.OutputRuleRegex, Pattern: `v(\d+)`, Replace: "version $1"},
+			{Type: sdkconfig.OutputRuleCloseCodeFences},
+		},
+		Keys: map[string][]sdkconfig.OutputRule{"raw-key": {}},
+	}}}
+
+	p := h.outputPipelineFor(strictTestContext("client-key"))
+	if p == nil {
+		t.Fatal("expected default rules to apply")
+	}
+
+	claude := []byte(`{"content":[{"type":"text","text":"as an ai, see https://www.google.com/search?q=x v2\n` + "```go\\nfmt.Println()" + `"},{"type":"tool_use","input":{"content":"as an ai v2"}}]}`)
+	out := p.process(claude, true)
+	if got := gjson.GetBytes(out, "content.0.text").String(); got != "[removed], see  version 2\n```go\nfmt.Println()\n```" {
+		t.Fatalf("text = %q", got)
+	}
+	if got := gjson.GetBytes(out, "content.1.input.content").String(); got != "as an ai v2" {
+		t.Fatalf("tool input must not be rewritten: %q", got)
+	}
+
+	stream := []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"```v1\"}}\n\n")
+	out = p.process(stream, false)
+	if got := gjson.Get(string(out[len("event: content_block_delta\ndata: "):]), "delta.text").String(); got != "```version 1" {
+		t.Fatalf("stream text = %q (fences are only closed in complete responses)", got)
+	}
+
+	responses := []byte(`{"type":"response.function_call_arguments.delta","delta":"v1"}`)
+	if out = p.process(responses, false); string(out) != string(responses) {
+		t.Fatalf("function call arguments must not be rewritten: %s", out)
+	}
+
+	if h.outputPipelineFor(strictTestContext("raw-key")) != nil {
+		t.Fatal("an empty per-key rule set should disable post-processing")
+	}
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// promptTemplatePlaceholder matches {{name}} placeholders, allowing spaces inside the braces.
+var promptTemplatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// applyPromptTemplate expands the "template" extension field of a request: the template's
+// system prompt is prepended to the request's and its messages are inserted before the
+// request's messages. The "template" and "variables" fields are removed from the body.
+func (h *BaseAPIHandler) applyPromptTemplate(ctx context.Context, handlerType string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	ref := gjson.GetBytes(rawJSON, "template")
+	if !ref.Exists() {
+		return rawJSON, nil
+	}
+	if ref.Type != gjson.String || strings.TrimSpace(ref.String()) == "" {
+		return nil, promptTemplateError(http.StatusBadRequest, "template must be the name of a prompt template")
+	}
+	var templates []config.PromptTemplate
+	if h != nil && h.Cfg != nil {
+		templates = h.Cfg.PromptTemplates
+	}
+	tpl, errMsg := resolvePromptTemplate(templates, strings.TrimSpace(ref.String()))
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if !promptTemplateAllowed(ctx, tpl) {
+		return nil, promptTemplateError(http.StatusForbidden, fmt.Sprintf("template %s is not permitted for this API key", tpl.Name))
+	}
+
+	variables := gjson.GetBytes(rawJSON, "variables")
+	if variables.Exists() && !variables.IsObject() {
+		return nil, promptTemplateError(http.StatusBadRequest, "variables must be an object")
+	}
+	values := make(map[string]string, len(tpl.Variables))
+	for name, value := range tpl.Variables {
+		values[name] = value
+	}
+	variables.ForEach(func(key, value gjson.Result) bool {
+		values[key.String()] = value.String()
+		return true
+	})
+	var missing []string
+	render := func(text string) string {
+		return promptTemplatePlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name := promptTemplatePlaceholder.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	}
+	system := render(tpl.System)
+	messages := make([]config.PromptTemplateMessage, 0, len(tpl.Messages))
+	for _, msg := range tpl.Messages {
+		messages = append(messages, config.PromptTemplateMessage{Role: msg.Role, Content: render(msg.Content)})
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, promptTemplateError(http.StatusBadRequest, fmt.Sprintf("template %s: missing variables: %s", tpl.Name, strings.Join(slices.Compact(missing), ", ")))
+	}
+
+	payload, _ := sjson.DeleteBytes(rawJSON, "template")
+	payload, _ = sjson.DeleteBytes(payload, "variables")
+	out, err := insertPromptTemplate(handlerType, payload, system, messages)
+	if err != nil {
+		return nil, promptTemplateError(http.StatusBadRequest, err.Error())
+	}
+	return out, nil
+}
+
+// resolvePromptTemplate finds the template named by ref, "name" or "name@version". Without a
+// version the highest one is used.
+func resolvePromptTemplate(templates []config.PromptTemplate, ref string) (*config.PromptTemplate, *interfaces.ErrorMessage) {
+	name, version := ref, 0
+	if idx := strings.LastIndex(ref, "@"); idx > 0 {
+		v, err := strconv.Atoi(ref[idx+1:])
+		if err != nil || v <= 0 {
+			return nil, promptTemplateError(http.StatusBadRequest, fmt.Sprintf("invalid template version in %q", ref))
+		}
+		name, version = ref[:idx], v
+	}
+	var found *config.PromptTemplate
+	for i := range templates {
+		tpl := &templates[i]
+		if !strings.EqualFold(strings.TrimSpace(tpl.Name), name) {
+			continue
+		}
+		v := promptTemplateVersion(tpl)
+		if version > 0 {
+			if v == version {
+				return tpl, nil
+			}
+			continue
+		}
+		if found == nil || v > promptTemplateVersion(found) {
+			found = tpl
+		}
+	}
+	if found == nil {
+		return nil, promptTemplateError(http.StatusNotFound, fmt.Sprintf("unknown prompt template %q", ref))
+	}
+	return found, nil
+}
+
+func promptTemplateVersion(tpl *config.PromptTemplate) int {
+	if tpl.Version <= 0 {
+		return 1
+	}
+	return tpl.Version
+}
+
+func promptTemplateAllowed(ctx context.Context, tpl *config.PromptTemplate) bool {
+	if len(tpl.AllowedKeys) == 0 {
+		return true
+	}
+	var ginCtx *gin.Context
+	if ctx != nil {
+		ginCtx, _ = ctx.Value("gin").(*gin.Context)
+	}
+	for _, id := range logging.ClientKeyIdentifiers(ginCtx) {
+		for _, key := range tpl.AllowedKeys {
+			if id == strings.TrimSpace(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insertPromptTemplate adds the rendered template to a request in the handler's format.
+func insertPromptTemplate(handlerType string, payload []byte, system string, messages []config.PromptTemplateMessage) ([]byte, error) {
+	var err error
+	switch handlerType {
+	case constant.OpenAI:
+		turns := make([]any, 0, len(messages)+1)
+		if system != "" {
+			turns = append(turns, map[string]string{"role": "system", "content": system})
+		}
+		for _, msg := range messages {
+			turns = append(turns, map[string]string{"role": promptTemplateRole(msg.Role, "assistant"), "content": msg.Content})
+		}
+		return prependJSONArray(payload, "messages", turns)
+	case constant.OpenaiResponse:
+		if system != "" {
+			payload, err = sjson.SetBytes(payload, "instructions", joinSystemText(system, gjson.GetBytes(payload, "instructions").String()))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if input := gjson.GetBytes(payload, "input"); input.Type == gjson.String {
+			payload, _ = sjson.SetBytes(payload, "input", []map[string]string{{"role": "user", "content": input.String()}})
+		}
+		turns := make([]any, 0, len(messages))
+		for _, msg := range messages {
+			turns = append(turns, map[string]string{"role": promptTemplateRole(msg.Role, "assistant"), "content": msg.Content})
+		}
+		return prependJSONArray(payload, "input", turns)
+	case constant.Claude:
+		if system != "" {
+			existing := gjson.GetBytes(payload, "system")
+			if existing.IsArray() {
+				payload, err = prependJSONArray(payload, "system", []any{map[string]string{"type": "text", "text": system}})
+			} else {
+				payload, err = sjson.SetBytes(payload, "system", joinSystemText(system, existing.String()))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		turns := make([]any, 0, len(messages))
+		for _, msg := range messages {
+			turns = append(turns, map[string]string{"role": promptTemplateRole(msg.Role, "assistant"), "content": msg.Content})
+		}
+		return prependJSONArray(payload, "messages", turns)
+	case constant.Gemini, constant.GeminiCLI:
+		root := ""
+		if handlerType == constant.GeminiCLI {
+			root = "request."
+		}
+		if system != "" {
+			payload, err = prependJSONArray(payload, root+"systemInstruction.parts", []any{map[string]string{"text": system}})
+			if err != nil {
+				return nil, err
+			}
+		}
+		turns := make([]any, 0, len(messages))
+		for _, msg := range messages {
+			turns = append(turns, map[string]any{
+				"role":  promptTemplateRole(msg.Role, "model"),
+				"parts": []map[string]string{{"text": msg.Content}},
+			})
+		}
+		return prependJSONArray(payload, root+"contents", turns)
+	}
+	return nil, fmt.Errorf("prompt templates are not supported for %s requests", handlerType)
+}
+
+// promptTemplateRole maps a template role to the format's user or assistant role name.
+func promptTemplateRole(role, assistant string) string {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "assistant", "model":
+		return assistant
+	}
+	return "user"
+}
+
+func joinSystemText(template, existing string) string {
+	if existing == "" {
+		return template
+	}
+	return template + "\n\n" + existing
+}
+
+// prependJSONArray inserts items before the elements of the array at path, creating it when absent.
+func prependJSONArray(payload []byte, path string, items []any) ([]byte, error) {
+	if len(items) == 0 {
+		return payload, nil
+	}
+	raw := make([]string, 0, len(items))
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, string(b))
+	}
+	for _, item := range gjson.GetBytes(payload, path).Array() {
+		raw = append(raw, item.Raw)
+	}
+	return sjson.SetRawBytes(payload, path, []byte("["+strings.Join(raw, ",")+"]"))
+}
+
+func promptTemplateError(status int, message string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: status, Error: errors.New(message)}
+}
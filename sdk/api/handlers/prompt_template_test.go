@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func promptTemplateTestHandler() *BaseAPIHandler {
+	return &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{PromptTemplates: []sdkconfig.PromptTemplate{
+		{Name: "review", System: "Review {{language}} code."},
+		{
+			Name:      "review",
+			Version:   2,
+			System:    "Review {{ language }} code for {{focus}}.",
+			Messages:  []sdkconfig.PromptTemplateMessage{{Role: "user", Content: "Here is the diff:"}, {Role: "assistant", Content: "Send it."}},
+			Variables: map[string]string{"focus": "bugs"},
+		},
+		{Name: "internal", System: "secret", AllowedKeys: []string{"team-key"}},
+	}}}
+}
+
+func TestApplyPromptTemplateOpenAI(t *testing.T) {
+	h := promptTemplateTestHandler()
+	payload := []byte(`{"model":"gpt-5","template":"review","variables":{"language":"Go"},"messages":[{"role":"user","content":"diff"}]}`)
+
+	out, errMsg := h.applyPromptTemplate(strictTestContext("any-key"), "openai", payload)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if gjson.GetBytes(out, "template").Exists() || gjson.GetBytes(out, "variables").Exists() {
+		t.Fatalf("extension fields were not removed: %s", out)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages, got %s", out)
+	}
+	if messages[0].Get("role").String() != "system" || messages[0].Get("content").String() != "Review Go code for bugs." {
+		t.Fatalf("unexpected system message %s", messages[0].Raw)
+	}
+	if messages[2].Get("role").String() != "assistant" || messages[3].Get("content").String() != "diff" {
+		t.Fatalf("unexpected message order %s", out)
+	}
+
+	out, errMsg = h.applyPromptTemplate(strictTestContext("any-key"), "openai", []byte(`{"template":"review@1","variables":{"language":"Rust"},"messages":[]}`))
+	if errMsg != nil || gjson.GetBytes(out, "messages.0.content").String() != "Review Rust code." {
+		t.Fatalf("pinned version not applied: %s %v", out, errMsg)
+	}
+}
+
+func TestApplyPromptTemplateFormats(t *testing.T) {
+	h := promptTemplateTestHandler()
+	vars := `"template":"review","variables":{"language":"Go","focus":"style"}`
+
+	out, errMsg := h.applyPromptTemplate(strictTestContext("k"), "claude", []byte(`{`+vars+`,"system":"Be brief.","messages":[{"role":"user","content":"x"}]}`))
+	if errMsg != nil || gjson.GetBytes(out, "system").String() != "Review Go code for style.\n\nBe brief." || gjson.GetBytes(out, "messages.#").Int() != 3 {
+		t.Fatalf("claude: %s %v", out, errMsg)
+	}
+
+	out, errMsg = h.applyPromptTemplate(strictTestContext("k"), "gemini", []byte(`{`+vars+`,"contents":[{"role":"user","parts":[{"text":"x"}]}]}`))
+	if errMsg != nil || gjson.GetBytes(out, "systemInstruction.parts.0.text").String() != "Review Go code for style." || gjson.GetBytes(out, "contents.1.role").String() != "model" {
+		t.Fatalf("gemini: %s %v", out, errMsg)
+	}
+
+	out, errMsg = h.applyPromptTemplate(strictTestContext("k"), "openai-response", []byte(`{`+vars+`,"input":"x"}`))
+	if errMsg != nil || gjson.GetBytes(out, "instructions").String() != "Review Go code for style." || gjson.GetBytes(out, "input.2.content").String() != "x" {
+		t.Fatalf("responses: %s %v", out, errMsg)
+	}
+}
+
+func TestApplyPromptTemplateErrors(t *testing.T) {
+	h := promptTemplateTestHandler()
+	cases := []struct {
+		name    string
+		payload string
+		key     string
+		status  int
+		message string
+	}{
+		{"missing variable", `{"template":"review","messages":[]}`, "k", http.StatusBadRequest, "missing variables: language"},
+		{"unknown template", `{"template":"nope","messages":[]}`, "k", http.StatusNotFound, "unknown prompt template"},
+		{"unknown version", `{"template":"review@3","messages":[]}`, "k", http.StatusNotFound, "unknown prompt template"},
+		{"access control", `{"template":"internal","messages":[]}`, "other-key", http.StatusForbidden, "not permitted"},
+	}
+	for _, tc := range cases {
+		_, errMsg := h.applyPromptTemplate(strictTestContext(tc.key), "openai", []byte(tc.payload))
+		if errMsg == nil || errMsg.StatusCode != tc.status || !strings.Contains(errMsg.Error.Error(), tc.message) {
+			t.Errorf("%s: got %+v", tc.name, errMsg)
+		}
+	}
+	if _, errMsg := h.applyPromptTemplate(strictTestContext("team-key"), "openai", []byte(`{"template":"internal","messages":[]}`)); errMsg != nil {
+		t.Errorf("allowed key rejected: %v", errMsg.Error)
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Request normalization quirks, used as metric labels.
+const (
+	quirkWrapContent    = "wrap-content"
+	quirkDefaultRoles   = "default-roles"
+	quirkCoerceBooleans = "coerce-booleans"
+)
+
+// requestBooleanFields are the boolean fields of each request format repaired by
+// coerce-booleans.
+var requestBooleanFields = map[string][]string{
+	constant.OpenAI:         {"stream", "logprobs", "echo", "store", "parallel_tool_calls", "stream_options.include_usage"},
+	constant.OpenaiResponse: {"stream", "store", "background", "parallel_tool_calls"},
+	constant.Claude:         {"stream", "tool_choice.disable_parallel_tool_use"},
+	constant.Gemini:         {"generationConfig.responseLogprobs", "generationConfig.thinkingConfig.includeThoughts"},
+	constant.GeminiCLI:      {"request.generationConfig.responseLogprobs", "request.generationConfig.thinkingConfig.includeThoughts"},
+}
+
+// normalizeRequest applies the enabled request-normalization repairs to rawJSON and counts
+// each repaired quirk once per request. Invalid JSON is left for the handlers to reject.
+func (h *BaseAPIHandler) normalizeRequest(handlerType string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil || len(rawJSON) == 0 {
+		return rawJSON
+	}
+	cfg := h.Cfg.RequestNormalization
+	if (!cfg.WrapContent && !cfg.DefaultRoles && !cfg.CoerceBooleans) || !gjson.ValidBytes(rawJSON) {
+		return rawJSON
+	}
+	repairs := []struct {
+		quirk   string
+		enabled bool
+		repair  func(string, []byte) ([]byte, bool)
+	}{
+		{quirkCoerceBooleans, cfg.CoerceBooleans, coerceRequestBooleans},
+		{quirkWrapContent, cfg.WrapContent, wrapRequestContent},
+		{quirkDefaultRoles, cfg.DefaultRoles, defaultRequestRoles},
+	}
+	for _, r := range repairs {
+		if !r.enabled {
+			continue
+		}
+		repaired, changed := r.repair(handlerType, rawJSON)
+		if !changed {
+			continue
+		}
+		rawJSON = repaired
+		metrics.Default().ObserveNormalization(handlerType, r.quirk)
+		log.Debugf("request normalization: repaired %s in %s request", r.quirk, handlerType)
+	}
+	return rawJSON
+}
+
+// coerceRequestBooleans turns 0/1 and "true"/"false" in the format's boolean fields into
+// JSON booleans.
+func coerceRequestBooleans(handlerType string, rawJSON []byte) ([]byte, bool) {
+	changed := false
+	for _, path := range requestBooleanFields[handlerType] {
+		value := gjson.GetBytes(rawJSON, path)
+		var b bool
+		switch {
+		case value.Type == gjson.Number && (value.Raw == "0" || value.Raw == "1"):
+			b = value.Raw == "1"
+		case value.Type == gjson.String && strings.EqualFold(value.Str, "true"):
+			b = true
+		case value.Type == gjson.String && strings.EqualFold(value.Str, "false"):
+			b = false
+		default:
+			continue
+		}
+		if out, err := sjson.SetBytes(rawJSON, path, b); err == nil {
+			rawJSON, changed = out, true
+		}
+	}
+	return rawJSON, changed
+}
+
+// wrapRequestContent wraps single content values into the arrays the format expects.
+func wrapRequestContent(handlerType string, rawJSON []byte) ([]byte, bool) {
+	e := &jsonEditor{raw: rawJSON}
+	switch handlerType {
+	case constant.OpenAI:
+		for i, msg := range gjson.GetBytes(rawJSON, "messages").Array() {
+			e.wrapParts(fmt.Sprintf("messages.%d.content", i), msg.Get("content"), false, textPart("text"))
+		}
+	case constant.Claude:
+		e.wrapParts("system", gjson.GetBytes(rawJSON, "system"), false, textPart("text"))
+		for i, msg := range gjson.GetBytes(rawJSON, "messages").Array() {
+			e.wrapParts(fmt.Sprintf("messages.%d.content", i), msg.Get("content"), false, textPart("text"))
+		}
+	case constant.OpenaiResponse:
+		if input := gjson.GetBytes(rawJSON, "input"); input.IsObject() {
+			e.setRaw("input", "["+input.Raw+"]")
+		}
+		for i, item := range gjson.GetBytes(e.raw, "input").Array() {
+			partType := "input_text"
+			if item.Get("role").String() == "assistant" {
+				partType = "output_text"
+			}
+			e.wrapParts(fmt.Sprintf("input.%d.content", i), item.Get("content"), false, textPart(partType))
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		prefix := ""
+		if handlerType == constant.GeminiCLI {
+			prefix = "request."
+		}
+		geminiText := func(text string) string {
+			part, _ := sjson.Set(`{}`, "text", text)
+			return part
+		}
+		contents := gjson.GetBytes(rawJSON, prefix+"contents")
+		switch {
+		case contents.Type == gjson.String:
+			content, _ := sjson.SetRaw(`{"role":"user"}`, "parts", "["+geminiText(contents.Str)+"]")
+			e.setRaw(prefix+"contents", "["+content+"]")
+		case contents.IsObject():
+			e.setRaw(prefix+"contents", "["+contents.Raw+"]")
+		}
+		for i, content := range gjson.GetBytes(e.raw, prefix+"contents").Array() {
+			e.wrapParts(fmt.Sprintf("%scontents.%d.parts", prefix, i), content.Get("parts"), true, geminiText)
+		}
+		if system := gjson.GetBytes(e.raw, prefix+"systemInstruction"); system.Type == gjson.String {
+			e.setRaw(prefix+"systemInstruction", `{"parts":[`+geminiText(system.Str)+`]}`)
+		} else if system.IsObject() {
+			e.wrapParts(prefix+"systemInstruction.parts", system.Get("parts"), true, geminiText)
+		}
+	}
+	return e.raw, e.changed
+}
+
+// defaultRequestRoles assigns roles to messages that lack one.
+func defaultRequestRoles(handlerType string, rawJSON []byte) ([]byte, bool) {
+	e := &jsonEditor{raw: rawJSON}
+	hasRole := func(msg gjson.Result) bool { return strings.TrimSpace(msg.Get("role").String()) != "" }
+	switch handlerType {
+	case constant.OpenAI:
+		for i, msg := range gjson.GetBytes(rawJSON, "messages").Array() {
+			if !msg.IsObject() || hasRole(msg) {
+				continue
+			}
+			role := "user"
+			if msg.Get("tool_call_id").Exists() {
+				role = "tool"
+			} else if msg.Get("tool_calls").Exists() {
+				role = "assistant"
+			}
+			e.set(fmt.Sprintf("messages.%d.role", i), role)
+		}
+	case constant.Claude:
+		for i, msg := range gjson.GetBytes(rawJSON, "messages").Array() {
+			if !msg.IsObject() || hasRole(msg) {
+				continue
+			}
+			role := "user"
+			if msg.Get(`content.#(type=="tool_use")`).Exists() {
+				role = "assistant"
+			}
+			e.set(fmt.Sprintf("messages.%d.role", i), role)
+		}
+	case constant.OpenaiResponse:
+		for i, item := range gjson.GetBytes(rawJSON, "input").Array() {
+			itemType := item.Get("type").String()
+			if !item.IsObject() || hasRole(item) || (itemType != "" && itemType != "message") || !item.Get("content").Exists() {
+				continue
+			}
+			role := "user"
+			if item.Get(`content.#(type=="output_text")`).Exists() {
+				role = "assistant"
+			}
+			e.set(fmt.Sprintf("input.%d.role", i), role)
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		prefix := ""
+		if handlerType == constant.GeminiCLI {
+			prefix = "request."
+		}
+		for i, content := range gjson.GetBytes(rawJSON, prefix+"contents").Array() {
+			if !content.IsObject() || hasRole(content) {
+				continue
+			}
+			role := "user"
+			if content.Get("parts.#(functionCall)").Exists() {
+				role = "model"
+			}
+			e.set(fmt.Sprintf("%scontents.%d.role", prefix, i), role)
+		}
+	}
+	return e.raw, e.changed
+}
+
+// textPart returns a builder of text parts of the given type.
+func textPart(partType string) func(string) string {
+	return func(text string) string {
+		part, _ := sjson.Set(`{"type":""}`, "type", partType)
+		part, _ = sjson.Set(part, "text", text)
+		return part
+	}
+}
+
+// jsonEditor applies edits to a JSON document and tracks whether any succeeded.
+type jsonEditor struct {
+	raw     []byte
+	changed bool
+}
+
+func (e *jsonEditor) set(path string, value any) {
+	if out, err := sjson.SetBytes(e.raw, path, value); err == nil {
+		e.raw, e.changed = out, true
+	}
+}
+
+func (e *jsonEditor) setRaw(path, value string) {
+	if out, err := sjson.SetRawBytes(e.raw, path, []byte(value)); err == nil {
+		e.raw, e.changed = out, true
+	}
+}
+
+// wrapParts wraps a single object at path into an array and turns bare strings inside the
+// array into parts built by part. A lone string is wrapped too when wrapString is set; the
+// chat formats accept string content as is.
+func (e *jsonEditor) wrapParts(path string, value gjson.Result, wrapString bool, part func(string) string) {
+	switch {
+	case value.IsObject():
+		e.setRaw(path, "["+value.Raw+"]")
+	case value.Type == gjson.String && wrapString:
+		e.setRaw(path, "["+part(value.Str)+"]")
+	case value.IsArray():
+		for i, item := range value.Array() {
+			if item.Type == gjson.String {
+				e.setRaw(fmt.Sprintf("%s.%d", path, i), part(item.Str))
+			}
+		}
+	}
+}
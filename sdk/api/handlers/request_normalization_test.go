@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func normalizationHandler(cfg sdkconfig.RequestNormalizationConfig) *BaseAPIHandler {
+	return &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{RequestNormalization: cfg}}
+}
+
+func TestNormalizeRequestOpenAI(t *testing.T) {
+	h := normalizationHandler(sdkconfig.RequestNormalizationConfig{WrapContent: true, DefaultRoles: true, CoerceBooleans: true})
+	in := []byte(`{"model":"gpt-5","stream":1,"logprobs":"false","messages":[
+		{"content":"hi"},
+		{"tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},
+		{"tool_call_id":"c1","content":"42"},
+		{"role":"user","content":{"type":"text","text":"one"}},
+		{"role":"user","content":["two",{"type":"text","text":"three"}]}
+	]}`)
+	out := gjson.ParseBytes(h.normalizeRequest("openai", in))
+
+	if out.Get("stream").Raw != "true" || out.Get("logprobs").Raw != "false" {
+		t.Errorf("booleans = %s, %s", out.Get("stream").Raw, out.Get("logprobs").Raw)
+	}
+	if roles := out.Get("messages.#.role").Raw; roles != `["user","assistant","tool","user","user"]` {
+		t.Errorf("roles = %s", roles)
+	}
+	if got := out.Get("messages.0.content").Raw; got != `"hi"` {
+		t.Errorf("string content should stay as is, got %s", got)
+	}
+	if got := out.Get("messages.3.content.0.text").String(); got != "one" {
+		t.Errorf("object content not wrapped: %s", out.Get("messages.3.content").Raw)
+	}
+	if got := out.Get("messages.4.content.0").Raw; got != `{"type":"text","text":"two"}` {
+		t.Errorf("bare string part = %s", got)
+	}
+}
+
+func TestNormalizeRequestGemini(t *testing.T) {
+	h := normalizationHandler(sdkconfig.RequestNormalizationConfig{WrapContent: true, DefaultRoles: true})
+	out := gjson.ParseBytes(h.normalizeRequest("gemini", []byte(`{"contents":"hello","systemInstruction":"be brief"}`)))
+	if got := out.Get("contents").Raw; got != `[{"role":"user","parts":[{"text":"hello"}]}]` {
+		t.Errorf("contents = %s", got)
+	}
+	if got := out.Get("systemInstruction").Raw; got != `{"parts":[{"text":"be brief"}]}` {
+		t.Errorf("systemInstruction = %s", got)
+	}
+
+	out = gjson.ParseBytes(h.normalizeRequest("gemini-cli", []byte(`{"request":{"contents":[{"parts":{"text":"q"}},{"parts":[{"functionCall":{"name":"f","args":{}}}]},{"parts":"a"}]}}`)))
+	if roles := out.Get("request.contents.#.role").Raw; roles != `["user","model","user"]` {
+		t.Errorf("roles = %s", roles)
+	}
+	if got := out.Get("request.contents.0.parts").Raw; got != `[{"text":"q"}]` {
+		t.Errorf("object parts = %s", got)
+	}
+	if got := out.Get("request.contents.2.parts").Raw; got != `[{"text":"a"}]` {
+		t.Errorf("string parts = %s", got)
+	}
+}
+
+func TestNormalizeRequestTogglesAndCounters(t *testing.T) {
+	in := []byte(`{"stream":"TRUE","messages":[{"content":[{"type":"tool_use","id":"t","name":"f","input":{}}]}]}`)
+
+	h := normalizationHandler(sdkconfig.RequestNormalizationConfig{DefaultRoles: true})
+	out := gjson.ParseBytes(h.normalizeRequest("claude", in))
+	if out.Get("stream").Raw != `"TRUE"` || out.Get("messages.0.role").String() != "assistant" {
+		t.Errorf("only default-roles should apply: %s", out.Raw)
+	}
+
+	h = normalizationHandler(sdkconfig.RequestNormalizationConfig{})
+	if got := h.normalizeRequest("claude", in); !bytes.Equal(got, in) {
+		t.Errorf("disabled normalization changed the request: %s", got)
+	}
+
+	var text strings.Builder
+	if err := metrics.Default().Write(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), `cliproxy_request_normalizations_total{format="claude",quirk="default-roles"}`) {
+		t.Errorf("missing normalization counter in:\n%s", text.String())
+	}
+}
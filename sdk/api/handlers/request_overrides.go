@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// requestOverridesField is the request body object through which a client tunes the
+// execution of a single call. It is removed before the request is translated.
+const requestOverridesField = "cliproxy"
+
+// requestOverrides are the per-request execution settings. They can only narrow what the
+// server would otherwise do: fewer retries, fewer providers, a shorter deadline.
+type requestOverrides struct {
+	// retries caps the upstream attempts after the first one; -1 keeps the server behaviour.
+	retries int
+	// providers restricts routing to these providers when not empty.
+	providers []string
+	// timeout bounds the whole request when positive.
+	timeout time.Duration
+}
+
+// extractRequestOverrides validates and removes the "cliproxy" object from rawJSON.
+func extractRequestOverrides(rawJSON []byte) (requestOverrides, []byte, *interfaces.ErrorMessage) {
+	overrides := requestOverrides{retries: -1}
+	value := gjson.GetBytes(rawJSON, requestOverridesField)
+	if !value.Exists() {
+		return overrides, rawJSON, nil
+	}
+	invalid := func(format string, args ...any) (requestOverrides, []byte, *interfaces.ErrorMessage) {
+		err := fmt.Errorf("invalid %s: %s", requestOverridesField, fmt.Sprintf(format, args...))
+		return overrides, rawJSON, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: err}
+	}
+	if !value.IsObject() {
+		return invalid("must be an object")
+	}
+	var body struct {
+		Retries   *int     `json:"retries"`
+		Providers []string `json:"providers"`
+		TimeoutMs *int64   `json:"timeout_ms"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value.Raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		return invalid("%v", err)
+	}
+	if body.Retries != nil {
+		if *body.Retries < 0 {
+			return invalid("retries must not be negative")
+		}
+		overrides.retries = *body.Retries
+	}
+	for _, provider := range body.Providers {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" {
+			return invalid("providers must not contain empty names")
+		}
+		overrides.providers = append(overrides.providers, provider)
+	}
+	if body.TimeoutMs != nil {
+		if *body.TimeoutMs <= 0 {
+			return invalid("timeout_ms must be positive")
+		}
+		overrides.timeout = time.Duration(*body.TimeoutMs) * time.Millisecond
+	}
+	stripped, err := sjson.DeleteBytes(rawJSON, requestOverridesField)
+	if err != nil {
+		return invalid("%v", err)
+	}
+	return overrides, stripped, nil
+}
+
+// narrowProviders keeps the providers of model that the client allowed.
+func (o requestOverrides) narrowProviders(providers []string, model string) ([]string, *interfaces.ErrorMessage) {
+	if len(o.providers) == 0 {
+		return providers, nil
+	}
+	narrowed := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		for _, allowed := range o.providers {
+			if strings.EqualFold(provider, allowed) {
+				narrowed = append(narrowed, provider)
+				break
+			}
+		}
+	}
+	if len(narrowed) == 0 {
+		err := fmt.Errorf("invalid %s: model %s is not served by providers %s", requestOverridesField, model, strings.Join(o.providers, ", "))
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: err}
+	}
+	return narrowed, nil
+}
+
+// apply records the retry cap in the execution metadata and bounds ctx by the requested
+// timeout. The returned cancel function must be called once the request is done.
+func (o requestOverrides) apply(ctx context.Context, meta map[string]any) (context.Context, context.CancelFunc) {
+	if o.retries >= 0 {
+		meta[coreexecutor.MaxRetriesMetadataKey] = o.retries
+	}
+	if o.timeout <= 0 || ctx == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// timeoutError reports the requested timeout as a gateway timeout when it ended ctx.
+func (o requestOverrides) timeoutError(ctx context.Context) *interfaces.ErrorMessage {
+	if o.timeout <= 0 || ctx == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	err := fmt.Errorf("request exceeded %s.timeout_ms of %d", requestOverridesField, o.timeout.Milliseconds())
+	return &interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: err}
+}
+
+// bootstrapRetries caps the stream bootstrap retries at the requested retry count.
+func (o requestOverrides) bootstrapRetries(configured int) int {
+	if o.retries >= 0 && o.retries < configured {
+		return o.retries
+	}
+	return configured
+}
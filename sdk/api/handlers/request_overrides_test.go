@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+func TestExtractRequestOverrides(t *testing.T) {
+	payload := []byte(`{"model":"gemini-2.5-pro","cliproxy":{"retries":0,"providers":[" Antigravity "],"timeout_ms":20000},"messages":[]}`)
+	overrides, stripped, errMsg := extractRequestOverrides(payload)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if gjson.GetBytes(stripped, "cliproxy").Exists() || !gjson.GetBytes(stripped, "messages").Exists() {
+		t.Fatalf("stripped payload = %s", stripped)
+	}
+	if overrides.retries != 0 || overrides.timeout != 20*time.Second || len(overrides.providers) != 1 || overrides.providers[0] != "antigravity" {
+		t.Fatalf("overrides = %+v", overrides)
+	}
+
+	providers, errMsg := overrides.narrowProviders([]string{"gemini-cli", "antigravity"}, "gemini-2.5-pro")
+	if errMsg != nil || len(providers) != 1 || providers[0] != "antigravity" {
+		t.Fatalf("narrowProviders = %v, %v", providers, errMsg)
+	}
+	if _, errMsg = overrides.narrowProviders([]string{"gemini-cli"}, "gemini-2.5-pro"); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a provider that does not serve the model, got %+v", errMsg)
+	}
+
+	meta := map[string]any{}
+	ctx, cancel := overrides.apply(context.Background(), meta)
+	defer cancel()
+	if meta[coreexecutor.MaxRetriesMetadataKey] != 0 {
+		t.Fatalf("metadata = %v", meta)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		t.Fatal("timeout_ms did not set a deadline")
+	}
+	if got := overrides.bootstrapRetries(2); got != 0 {
+		t.Fatalf("bootstrap retries = %d, want 0", got)
+	}
+}
+
+func TestExtractRequestOverridesDefaultsAndValidation(t *testing.T) {
+	payload := []byte(`{"model":"m"}`)
+	overrides, stripped, errMsg := extractRequestOverrides(payload)
+	if errMsg != nil || string(stripped) != string(payload) || overrides.retries != -1 {
+		t.Fatalf("absent extension: %+v, %s, %v", overrides, stripped, errMsg)
+	}
+	meta := map[string]any{}
+	ctx, cancel := overrides.apply(context.Background(), meta)
+	defer cancel()
+	if _, ok := meta[coreexecutor.MaxRetriesMetadataKey]; ok {
+		t.Fatalf("metadata = %v, want no retry cap", meta)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("deadline set without timeout_ms")
+	}
+
+	for _, body := range []string{
+		`{"cliproxy":"fast"}`,
+		`{"cliproxy":{"retries":-1}}`,
+		`{"cliproxy":{"timeout_ms":0}}`,
+		`{"cliproxy":{"providers":[""]}}`,
+		`{"cliproxy":{"retry":1}}`,
+	} {
+		if _, _, errMsg = extractRequestOverrides([]byte(body)); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %+v", body, errMsg)
+		}
+	}
+}
+
+func TestRequestOverridesTimeoutError(t *testing.T) {
+	overrides := requestOverrides{retries: -1, timeout: time.Millisecond}
+	ctx, cancel := overrides.apply(context.Background(), map[string]any{})
+	defer cancel()
+	<-ctx.Done()
+	if errMsg := overrides.timeoutError(ctx); errMsg == nil || errMsg.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("timeoutError = %+v, want 504", errMsg)
+	}
+	if errMsg := (requestOverrides{retries: -1}).timeoutError(ctx); errMsg != nil {
+		t.Fatalf("timeoutError without timeout_ms = %+v", errMsg)
+	}
+}
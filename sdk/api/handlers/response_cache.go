@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	// ResponseCacheHeader reports whether a response was served from the response cache
+	// ("hit"), produced by the upstream and stored ("miss"), or served past its TTL because
+	// load shedding rejected the request ("stale").
+	ResponseCacheHeader = "X-CLIProxy-Cache"
+
+	defaultResponseCacheTTL    = 5 * time.Minute
+	defaultResponseCacheSizeMB = 64
+)
+
+// responseCacheEntry is a cached response: the body of a non-streaming response, or the
+// chunks of a completed stream in the order they were sent. Entries are served until expire
+// and kept until keep to answer requests rejected by load shedding.
+type responseCacheEntry struct {
+	key    string
+	chunks [][]byte
+	size   int
+	expire time.Time
+	keep   time.Time
+}
+
+// responseCache is a size-bounded LRU of responses.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	size    int
+	nowFn   func() time.Time
+}
+
+var responseCaches = newResponseCache()
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]*list.Element), order: list.New(), nowFn: time.Now}
+}
+
+// get returns the chunks cached under key, or nil when there are none or they expired.
+func (c *responseCache) get(key string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*responseCacheEntry)
+	now := c.nowFn()
+	if !now.Before(entry.keep) {
+		c.remove(elem)
+		return nil
+	}
+	if !now.Before(entry.expire) {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return entry.chunks
+}
+
+// getStale returns the chunks cached under key, including expired ones that are still kept.
+func (c *responseCache) getStale(key string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*responseCacheEntry)
+	if !c.nowFn().Before(entry.keep) {
+		c.remove(elem)
+		return nil
+	}
+	return entry.chunks
+}
+
+// put stores chunks under key for ttl, keeps them stale for another staleFor, and evicts the
+// least recently used entries until the cache holds at most maxBytes. Responses larger than
+// maxBytes are not cached.
+func (c *responseCache) put(key string, chunks [][]byte, ttl, staleFor time.Duration, maxBytes int) {
+	size := 0
+	for _, chunk := range chunks {
+		size += len(chunk)
+	}
+	if size == 0 || size > maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	expire := c.nowFn().Add(ttl)
+	entry := &responseCacheEntry{key: key, chunks: chunks, size: size, expire: expire, keep: expire.Add(max(staleFor, 0))}
+	c.entries[key] = c.order.PushFront(entry)
+	c.size += size
+	for c.size > maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *responseCache) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*responseCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// responseCacheLimits returns the configured entry lifetime, how long expired entries are
+// kept for degraded providers, and the memory bound.
+func (h *BaseAPIHandler) responseCacheLimits() (time.Duration, time.Duration, int) {
+	ttl := defaultResponseCacheTTL
+	if seconds := h.Cfg.ResponseCache.TTLSeconds; seconds > 0 {
+		ttl = time.Duration(seconds) * time.Second
+	}
+	staleFor := time.Duration(max(h.Cfg.ResponseCache.StaleIfDegradedSeconds, 0)) * time.Second
+	sizeMB := h.Cfg.ResponseCache.MaxSizeMB
+	if sizeMB <= 0 {
+		sizeMB = defaultResponseCacheSizeMB
+	}
+	return ttl, staleFor, sizeMB << 20
+}
+
+// degradedFallback returns the response cached under key, fresh or stale, when err is load
+// shedding turning the request away; otherwise nil.
+func degradedFallback(key string, err error) [][]byte {
+	var authErr *coreauth.Error
+	if key == "" || !errors.As(err, &authErr) || authErr.Code != "provider_degraded" {
+		return nil
+	}
+	return responseCaches.getStale(key)
+}
+
+// responseCacheKey identifies a request for the response cache by client credential,
+// endpoint format, model, stream mode and request body with object keys sorted and
+// whitespace removed. It returns "" when the cache is disabled or the client asked to
+// bypass it with Cache-Control: no-cache or no-store.
+func (h *BaseAPIHandler) responseCacheKey(ctx context.Context, handlerType, model, alt string, stream bool, rawJSON []byte) string {
+	if h == nil || h.Cfg == nil || !h.Cfg.ResponseCache.Enabled || len(rawJSON) == 0 {
+		return ""
+	}
+	var principal string
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			if ginCtx.Request != nil {
+				control := strings.ToLower(ginCtx.GetHeader("Cache-Control"))
+				if strings.Contains(control, "no-cache") || strings.Contains(control, "no-store") {
+					return ""
+				}
+			}
+			if v, exists := ginCtx.Get("apiKey"); exists {
+				principal, _ = v.(string)
+			}
+		}
+	}
+	body := rawJSON
+	decoder := json.NewDecoder(bytes.NewReader(rawJSON))
+	decoder.UseNumber()
+	var parsed any
+	if err := decoder.Decode(&parsed); err == nil {
+		if normalized, errMarshal := json.Marshal(parsed); errMarshal == nil {
+			body = normalized
+		}
+	}
+	mode := "nonstream"
+	if stream {
+		mode = "stream"
+	}
+	hash := sha256.New()
+	for _, part := range []string{principal, handlerType, model, alt, mode} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// setResponseCacheHeader reports the cache outcome on the client response.
+func setResponseCacheHeader(ctx context.Context, value string) {
+	if ctx == nil {
+		return
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && !ginCtx.Writer.Written() {
+		ginCtx.Header(ResponseCacheHeader, value)
+	}
+}
+
+// replayResponseStream sends cached stream chunks the way ExecuteStreamWithAuthManager
+// sends upstream ones.
+func replayResponseStream(chunks [][]byte) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	dataChan := make(chan []byte, len(chunks))
+	for _, chunk := range chunks {
+		dataChan <- cloneBytes(chunk)
+	}
+	close(dataChan)
+	errChan := make(chan *interfaces.ErrorMessage)
+	close(errChan)
+	return dataChan, errChan
+}
+
+// responseStreamRecorder collects the chunks sent to a streaming client so a stream that
+// completes can be stored in the response cache.
+type responseStreamRecorder struct {
+	key      string
+	ttl      time.Duration
+	staleFor time.Duration
+	maxBytes int
+	size     int
+	chunks   [][]byte
+}
+
+// newResponseStreamRecorder returns nil when key is empty; a nil recorder ignores all calls.
+func (h *BaseAPIHandler) newResponseStreamRecorder(key string) *responseStreamRecorder {
+	if key == "" {
+		return nil
+	}
+	ttl, staleFor, maxBytes := h.responseCacheLimits()
+	return &responseStreamRecorder{key: key, ttl: ttl, staleFor: staleFor, maxBytes: maxBytes}
+}
+
+// add records a sent chunk. Streams that outgrow the cache are no longer recorded.
+func (r *responseStreamRecorder) add(chunk []byte) {
+	if r == nil || r.key == "" || len(chunk) == 0 {
+		return
+	}
+	r.size += len(chunk)
+	if r.size > r.maxBytes {
+		r.key, r.chunks = "", nil
+		return
+	}
+	r.chunks = append(r.chunks, cloneBytes(chunk))
+}
+
+// commit stores the recorded stream once the upstream finished it without error.
+func (r *responseStreamRecorder) commit() {
+	if r == nil || r.key == "" {
+		return
+	}
+	responseCaches.put(r.key, r.chunks, r.ttl, r.staleFor, r.maxBytes)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestResponseCacheEvictsAndExpires(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := newResponseCache()
+	cache.nowFn = func() time.Time { return now }
+
+	cache.put("a", [][]byte{[]byte("aaaa")}, time.Minute, 0, 10)
+	cache.put("b", [][]byte{[]byte("bb"), []byte("bb")}, time.Minute, 0, 10)
+	if got := cache.get("a"); len(got) != 1 || string(got[0]) != "aaaa" {
+		t.Fatalf("get(a) = %q", got)
+	}
+	// "b" is now the least recently used entry and makes room for "c".
+	cache.put("c", [][]byte{[]byte("cccc")}, time.Minute, 0, 10)
+	if cache.get("b") != nil {
+		t.Fatal("expected b to be evicted")
+	}
+	if cache.get("a") == nil || cache.get("c") == nil {
+		t.Fatal("expected a and c to stay cached")
+	}
+	cache.put("big", [][]byte{[]byte("0123456789a")}, time.Minute, 0, 10)
+	if cache.get("big") != nil || cache.size != 8 {
+		t.Fatalf("oversized response was cached, size = %d", cache.size)
+	}
+
+	now = now.Add(time.Minute)
+	if cache.get("a") != nil || cache.size != 4 {
+		t.Fatalf("expected a to expire, size = %d", cache.size)
+	}
+
+	cache.put("stale", [][]byte{[]byte("ss")}, time.Minute, time.Minute, 10)
+	now = now.Add(time.Minute)
+	if cache.get("stale") != nil {
+		t.Fatal("expired entry served as fresh")
+	}
+	if got := cache.getStale("stale"); len(got) != 1 || string(got[0]) != "ss" {
+		t.Fatalf("getStale = %q, want the kept entry", got)
+	}
+	now = now.Add(time.Minute)
+	if cache.getStale("stale") != nil {
+		t.Fatal("entry served past its stale window")
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{ResponseCache: sdkconfig.ResponseCacheConfig{Enabled: true}}}
+	keyFor := func(apiKey, cacheControl string, stream bool, body string) string {
+		ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginCtx.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+		if cacheControl != "" {
+			ginCtx.Request.Header.Set("Cache-Control", cacheControl)
+		}
+		ginCtx.Set("apiKey", apiKey)
+		ctx := context.WithValue(context.Background(), "gin", ginCtx)
+		return handler.responseCacheKey(ctx, "openai", "gpt-5", "", stream, []byte(body))
+	}
+
+	base := keyFor("k1", "", false, `{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.5}`)
+	if base == "" {
+		t.Fatal("expected a cache key")
+	}
+	reordered := keyFor("k1", "", false, "{\n  \"temperature\": 0.5,\n  \"messages\": [{\"content\":\"hi\",\"role\":\"user\"}], \"model\": \"gpt-5\"}")
+	if reordered != base {
+		t.Fatal("expected key order and whitespace to be ignored")
+	}
+	if keyFor("k2", "", false, `{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.5}`) == base {
+		t.Fatal("expected keys of different clients to differ")
+	}
+	if keyFor("k1", "", true, `{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.5}`) == base {
+		t.Fatal("expected stream and non-stream keys to differ")
+	}
+	if keyFor("k1", "", false, `{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"temperature":0.50001}`) == base {
+		t.Fatal("expected different parameters to change the key")
+	}
+	if keyFor("k1", "no-cache", false, `{"model":"gpt-5"}`) != "" {
+		t.Fatal("expected Cache-Control: no-cache to bypass the cache")
+	}
+	handler.Cfg.ResponseCache.Enabled = false
+	if keyFor("k1", "", false, `{"model":"gpt-5"}`) != "" {
+		t.Fatal("expected no key with the cache disabled")
+	}
+}
+
+func TestExecuteStreamWithAuthManager_ReplaysCachedStream(t *testing.T) {
+	prev := responseCaches
+	responseCaches = newResponseCache()
+	t.Cleanup(func() { responseCaches = prev })
+
+	executor := &countingStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "cache-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ResponseCache: sdkconfig.ResponseCacheConfig{Enabled: true},
+	}, manager)
+	stream := func() string {
+		dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "test-model", []byte(`{"model":"test-model","stream":true}`), "")
+		var got []byte
+		for chunk := range dataChan {
+			got = append(got, chunk...)
+		}
+		for msg := range errChan {
+			if msg != nil {
+				t.Fatalf("unexpected error: %+v", msg)
+			}
+		}
+		return string(got)
+	}
+
+	if got := stream(); got != "data: a\n\ndata: b\n\n" {
+		t.Fatalf("first stream = %q", got)
+	}
+	if got := stream(); got != "data: a\n\ndata: b\n\n" {
+		t.Fatalf("replayed stream = %q", got)
+	}
+	if executor.Calls() != 1 {
+		t.Fatalf("expected 1 upstream stream, got %d", executor.Calls())
+	}
+}
+
+func TestExecuteStreamWithAuthManager_ServesStaleResponseWhileShedding(t *testing.T) {
+	prev := responseCaches
+	responseCaches = newResponseCache()
+	t.Cleanup(func() { responseCaches = prev })
+	now := time.Now()
+	responseCaches.nowFn = func() time.Time { return now }
+
+	executor := &countingStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{LoadShedding: internalconfig.LoadSheddingConfig{Enabled: true, MinRequests: 1}})
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "stale-cache-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "stale-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ResponseCache: sdkconfig.ResponseCacheConfig{Enabled: true, TTLSeconds: 60, StaleIfDegradedSeconds: 600},
+	}, manager)
+	stream := func() (string, *interfaces.ErrorMessage) {
+		dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "stale-model", []byte(`{"model":"stale-model","stream":true}`), "")
+		var got []byte
+		for chunk := range dataChan {
+			got = append(got, chunk...)
+		}
+		var errMsg *interfaces.ErrorMessage
+		for msg := range errChan {
+			if msg != nil {
+				errMsg = msg
+			}
+		}
+		return string(got), errMsg
+	}
+
+	if got, errMsg := stream(); errMsg != nil || got != "data: a\n\ndata: b\n\n" {
+		t.Fatalf("first stream = %q, %+v", got, errMsg)
+	}
+	now = now.Add(5 * time.Minute)
+	manager.MarkResult(context.Background(), coreauth.Result{AuthID: auth.ID, Provider: "codex", Model: "stale-model", Error: &coreauth.Error{HTTPStatus: http.StatusInternalServerError}})
+
+	if got, errMsg := stream(); errMsg != nil || got != "data: a\n\ndata: b\n\n" {
+		t.Fatalf("stream while shedding = %q, %+v; want the stale response", got, errMsg)
+	}
+	if executor.Calls() != 1 {
+		t.Fatalf("expected 1 upstream stream, got %d", executor.Calls())
+	}
+}
+
+// countingStreamExecutor streams two SSE events per call.
+type countingStreamExecutor struct {
+	failOnceStreamExecutor
+}
+
+func (e *countingStreamExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	ch := make(chan coreexecutor.StreamChunk, 2)
+	ch <- coreexecutor.StreamChunk{Payload: []byte("data: a\n\n")}
+	ch <- coreexecutor.StreamChunk{Payload: []byte("data: b\n\n")}
+	close(ch)
+	return ch, nil
+}
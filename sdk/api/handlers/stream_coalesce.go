@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// streamCoalesceSettings resolves the chunk coalescing settings for the calling client key.
+func (h *BaseAPIHandler) streamCoalesceSettings(c *gin.Context) config.StreamCoalesceSettings {
+	if h == nil || h.Cfg == nil {
+		return config.StreamCoalesceSettings{}
+	}
+	return h.Cfg.StreamCoalesceFor(logging.ClientKeyIdentifiers(c)...)
+}
+
+// chunkCoalescer buffers streaming chunks between flushes. Chunks are written as they arrive
+// unless they can be merged into the held-back chunk; the caller flushes whenever add reports
+// a flush is due or the timer fires.
+type chunkCoalescer struct {
+	settings config.StreamCoalesceSettings
+	write    func([]byte)
+	merge    func(pending, next []byte) ([]byte, bool)
+	boundary func([]byte) bool
+
+	pending  []byte
+	buffered int
+	timer    *time.Timer
+	timerC   <-chan time.Time
+}
+
+func newChunkCoalescer(settings config.StreamCoalesceSettings, write func([]byte), opts StreamForwardOptions) *chunkCoalescer {
+	return &chunkCoalescer{
+		settings: settings,
+		write:    write,
+		merge:    opts.MergeChunks,
+		boundary: opts.IsBoundary,
+	}
+}
+
+// add buffers chunk and reports whether the caller should drain and flush now. Boundary
+// chunks (for example tool-call starts) are never merged and always flushed immediately.
+func (co *chunkCoalescer) add(chunk []byte) bool {
+	if !co.settings.Enabled() {
+		co.write(chunk)
+		return true
+	}
+	if co.boundary != nil && co.boundary(chunk) {
+		co.writePending()
+		co.write(chunk)
+		return true
+	}
+	if co.merge == nil {
+		co.write(chunk)
+		co.buffered += len(chunk)
+	} else if co.pending == nil {
+		co.pending = chunk
+	} else if merged, ok := co.merge(co.pending, chunk); ok {
+		co.pending = merged
+	} else {
+		co.writePending()
+		co.pending = chunk
+	}
+	if co.settings.FlushBytes > 0 && co.buffered+len(co.pending) >= co.settings.FlushBytes {
+		return true
+	}
+	if co.settings.FlushIntervalMS <= 0 {
+		return false
+	}
+	if co.timerC == nil {
+		interval := time.Duration(co.settings.FlushIntervalMS) * time.Millisecond
+		if co.timer == nil {
+			co.timer = time.NewTimer(interval)
+		} else {
+			co.timer.Reset(interval)
+		}
+		co.timerC = co.timer.C
+	}
+	return false
+}
+
+// drain writes any held-back chunk and resets the flush window. The caller flushes afterwards.
+func (co *chunkCoalescer) drain() {
+	co.writePending()
+	co.buffered = 0
+	if co.timerC != nil {
+		if !co.timer.Stop() {
+			select {
+			case <-co.timer.C:
+			default:
+			}
+		}
+		co.timerC = nil
+	}
+}
+
+func (co *chunkCoalescer) writePending() {
+	if co.pending == nil {
+		return
+	}
+	co.write(co.pending)
+	co.buffered += len(co.pending)
+	co.pending = nil
+}
+
+func (co *chunkCoalescer) stop() {
+	if co.timer != nil {
+		co.timer.Stop()
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type countingFlusher struct {
+	flushes int
+}
+
+func (f *countingFlusher) Flush() { f.flushes++ }
+
+func forwardTestStream(t *testing.T, cfg *sdkconfig.SDKConfig, apiKey string, chunks []string, opts StreamForwardOptions) (string, int) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	if apiKey != "" {
+		c.Set("apiKey", apiKey)
+	}
+
+	data := make(chan []byte, len(chunks))
+	for _, chunk := range chunks {
+		data <- []byte(chunk)
+	}
+	close(data)
+	errs := make(chan *interfaces.ErrorMessage)
+
+	var written []string
+	opts.WriteChunk = func(chunk []byte) { written = append(written, string(chunk)) }
+	flusher := &countingFlusher{}
+	h := &BaseAPIHandler{Cfg: cfg}
+	h.ForwardStream(c, flusher, func(error) {}, data, errs, opts)
+	return strings.Join(written, "|"), flusher.flushes
+}
+
+func TestForwardStreamCoalescesByBytes(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{}
+	cfg.Streaming.Coalesce.FlushBytes = 10
+
+	out, flushes := forwardTestStream(t, cfg, "", []string{"aaaa", "bbbb", "cccc", "dd"}, StreamForwardOptions{})
+	if out != "aaaa|bbbb|cccc|dd" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	// One flush once 12 bytes are buffered, one when the stream ends.
+	if flushes != 2 {
+		t.Fatalf("flushes = %d, want 2", flushes)
+	}
+}
+
+func TestForwardStreamMergesAndRespectsBoundaries(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{}
+	cfg.Streaming.Coalesce.FlushIntervalMS = 60000
+
+	opts := StreamForwardOptions{
+		IsBoundary: func(chunk []byte) bool { return string(chunk) == "TOOL" },
+		MergeChunks: func(pending, next []byte) ([]byte, bool) {
+			return append(append([]byte(nil), pending...), next...), true
+		},
+	}
+	out, flushes := forwardTestStream(t, cfg, "", []string{"a", "b", "TOOL", "c", "d"}, opts)
+	if out != "ab|TOOL|cd" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if flushes != 2 {
+		t.Fatalf("flushes = %d, want 2", flushes)
+	}
+}
+
+func TestForwardStreamKeyOverrideDisablesCoalescing(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{}
+	cfg.Streaming.Coalesce.FlushBytes = 1024
+	cfg.Streaming.Coalesce.Keys = map[string]sdkconfig.StreamCoalesceSettings{"live-key": {}}
+
+	out, flushes := forwardTestStream(t, cfg, "live-key", []string{"a", "b", "c"}, StreamForwardOptions{})
+	if out != "a|b|c" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	// One flush per chunk plus the final flush.
+	if flushes != 4 {
+		t.Fatalf("flushes = %d, want 4", flushes)
+	}
+}
+
+func TestChunkCoalescerFlushesOnTimer(t *testing.T) {
+	var written []string
+	co := newChunkCoalescer(sdkconfig.StreamCoalesceSettings{FlushIntervalMS: 1}, func(chunk []byte) {
+		written = append(written, string(chunk))
+	}, StreamForwardOptions{})
+	defer co.stop()
+
+	if co.add([]byte("a")) {
+		t.Fatal("add should not request an immediate flush")
+	}
+	select {
+	case <-co.timerC:
+	case <-time.After(time.Second):
+		t.Fatal("flush timer did not fire")
+	}
+	co.drain()
+	if co.timerC != nil {
+		t.Fatal("drain should reset the flush timer")
+	}
+	if strings.Join(written, "|") != "a" {
+		t.Fatalf("unexpected output %v", written)
+	}
+}
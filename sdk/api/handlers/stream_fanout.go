@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// StreamIDHeader carries the ID under which a streaming response can be followed through
+	// the management API while it is live.
+	StreamIDHeader = "X-CLIProxy-Stream-ID"
+
+	// streamHistoryLimit bounds the chunks a live stream keeps for late subscribers.
+	streamHistoryLimit = 4 << 20
+	// streamSubscriberBuffer is the number of chunks a subscriber may fall behind before it
+	// is disconnected; a slow subscriber never holds up the client.
+	streamSubscriberBuffer = 256
+)
+
+// LiveStreamInfo describes a streaming response in flight.
+type LiveStreamInfo struct {
+	ID          string    `json:"id"`
+	ResponseID  string    `json:"response_id,omitempty"`
+	Model       string    `json:"model"`
+	Format      string    `json:"format"`
+	StartedAt   time.Time `json:"started_at"`
+	Subscribers int       `json:"subscribers"`
+}
+
+// liveStream copies the chunks of one streaming response to its subscribers.
+type liveStream struct {
+	mu          sync.Mutex
+	info        LiveStreamInfo
+	history     [][]byte
+	historySize int
+	truncated   bool
+	subscribers map[chan []byte]struct{}
+	closed      bool
+}
+
+// streamHub indexes live streams by stream ID and upstream response ID.
+type streamHub struct {
+	mu      sync.RWMutex
+	streams map[string]*liveStream
+}
+
+var liveStreams = &streamHub{streams: make(map[string]*liveStream)}
+
+// newLiveStream registers the streaming response of the request in ctx and advertises its
+// ID to the client. It returns nil when stream fan-out is disabled and in usage
+// aggregation-only mode, where payloads must not leave the request.
+func (h *BaseAPIHandler) newLiveStream(ctx context.Context, handlerType, modelName string) *liveStream {
+	if h == nil || h.Cfg == nil || !h.Cfg.StreamFanout || coreusage.AggregateOnly() {
+		return nil
+	}
+	var ginCtx *gin.Context
+	if ctx != nil {
+		ginCtx, _ = ctx.Value("gin").(*gin.Context)
+	}
+	id := logging.GetGinRequestID(ginCtx)
+	if id == "" {
+		id = logging.GenerateRequestID()
+	}
+	stream := &liveStream{
+		info:        LiveStreamInfo{ID: id, Model: modelName, Format: handlerType, StartedAt: time.Now()},
+		subscribers: make(map[chan []byte]struct{}),
+	}
+	liveStreams.mu.Lock()
+	liveStreams.streams[id] = stream
+	liveStreams.mu.Unlock()
+	if ginCtx != nil && !ginCtx.Writer.Written() {
+		ginCtx.Header(StreamIDHeader, id)
+	}
+	return stream
+}
+
+// publish copies a chunk sent to the client to the subscribers. The first upstream response
+// ID found in the chunks becomes a second lookup key of the stream.
+func (s *liveStream) publish(chunk []byte) {
+	if s == nil || len(chunk) == 0 {
+		return
+	}
+	chunk = cloneBytes(chunk)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.info.ResponseID == "" {
+		if responseID := streamResponseID(chunk); responseID != "" {
+			s.info.ResponseID = responseID
+			liveStreams.mu.Lock()
+			if _, taken := liveStreams.streams[responseID]; !taken {
+				liveStreams.streams[responseID] = s
+			}
+			liveStreams.mu.Unlock()
+		}
+	}
+	if !s.truncated {
+		s.historySize += len(chunk)
+		if s.historySize > streamHistoryLimit {
+			s.history, s.truncated = nil, true
+		} else {
+			s.history = append(s.history, chunk)
+		}
+	}
+	for sub := range s.subscribers {
+		select {
+		case sub <- chunk:
+		default:
+			log.Debugf("stream fan-out: dropping slow subscriber of %s", s.info.ID)
+			delete(s.subscribers, sub)
+			close(sub)
+		}
+	}
+}
+
+// close ends the stream for its subscribers and forgets it.
+func (s *liveStream) close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.closed = true
+	for sub := range s.subscribers {
+		close(sub)
+	}
+	s.subscribers, s.history = nil, nil
+	s.mu.Unlock()
+
+	liveStreams.mu.Lock()
+	for _, key := range []string{s.info.ID, s.info.ResponseID} {
+		if key != "" && liveStreams.streams[key] == s {
+			delete(liveStreams.streams, key)
+		}
+	}
+	liveStreams.mu.Unlock()
+}
+
+// SubscribeStream follows the live stream with the given stream or response ID. The channel
+// first replays the chunks sent so far, unless they outgrew the history limit (truncated),
+// then receives each new chunk and is closed when the stream ends or the subscriber falls
+// too far behind. cancel unsubscribes. ok is false when no such stream is live.
+func SubscribeStream(id string) (chunks <-chan []byte, truncated bool, cancel func(), ok bool) {
+	liveStreams.mu.RLock()
+	s := liveStreams.streams[id]
+	liveStreams.mu.RUnlock()
+	if s == nil {
+		return nil, false, nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, false, nil, false
+	}
+	sub := make(chan []byte, len(s.history)+streamSubscriberBuffer)
+	for _, chunk := range s.history {
+		sub <- chunk
+	}
+	s.subscribers[sub] = struct{}{}
+	cancel = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, subscribed := s.subscribers[sub]; subscribed {
+			delete(s.subscribers, sub)
+			close(sub)
+		}
+	}
+	return sub, s.truncated, cancel, true
+}
+
+// LiveStreams lists the streaming responses in flight, oldest first.
+func LiveStreams() []LiveStreamInfo {
+	liveStreams.mu.RLock()
+	seen := make(map[*liveStream]struct{}, len(liveStreams.streams))
+	for _, s := range liveStreams.streams {
+		seen[s] = struct{}{}
+	}
+	liveStreams.mu.RUnlock()
+	out := make([]LiveStreamInfo, 0, len(seen))
+	for s := range seen {
+		s.mu.Lock()
+		info := s.info
+		info.Subscribers = len(s.subscribers)
+		s.mu.Unlock()
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+// streamResponseID returns the upstream response ID carried by a stream chunk of any of the
+// supported formats, or "" when the chunk has none.
+func streamResponseID(chunk []byte) string {
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace(line), []byte("data:")))
+		if len(line) == 0 || line[0] != '{' || !gjson.ValidBytes(line) {
+			continue
+		}
+		for _, path := range []string{"id", "message.id", "response.id", "responseId"} {
+			if id := gjson.GetBytes(line, path).String(); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
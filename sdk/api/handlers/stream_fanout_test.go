@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestLiveStreamFanOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	logging.SetGinRequestID(ginCtx, "req-1")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	if stream := (&BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}).newLiveStream(ctx, "claude", "m"); stream != nil {
+		t.Fatal("fan-out registered a stream while disabled")
+	}
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{StreamFanout: true}}
+	stream := h.newLiveStream(ctx, "claude", "claude-sonnet-4")
+	if got := recorder.Header().Get(StreamIDHeader); got != "req-1" {
+		t.Fatalf("%s = %q", StreamIDHeader, got)
+	}
+
+	stream.publish([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n"))
+	chunks, truncated, cancel, ok := SubscribeStream("msg_1")
+	if !ok || truncated {
+		t.Fatalf("SubscribeStream(msg_1) ok=%v truncated=%v", ok, truncated)
+	}
+	defer cancel()
+	stream.publish([]byte("data: {\"type\":\"message_stop\"}\n\n"))
+
+	if first := <-chunks; streamResponseID(first) != "msg_1" {
+		t.Errorf("history not replayed first: %q", first)
+	}
+	if second := <-chunks; string(second) != "data: {\"type\":\"message_stop\"}\n\n" {
+		t.Errorf("live chunk = %q", second)
+	}
+	if streams := LiveStreams(); len(streams) != 1 || streams[0].ResponseID != "msg_1" || streams[0].Subscribers != 1 {
+		t.Errorf("LiveStreams() = %+v", streams)
+	}
+
+	stream.close()
+	if _, open := <-chunks; open {
+		t.Error("subscriber channel not closed at the end of the stream")
+	}
+	if _, _, _, ok := SubscribeStream("req-1"); ok {
+		t.Error("finished stream still subscribable")
+	}
+}
+
+func TestStreamResponseID(t *testing.T) {
+	cases := map[string]string{
+		`{"id":"chatcmpl-1","object":"chat.completion.chunk"}`:                  "chatcmpl-1",
+		"event: response.created\ndata: {\"response\":{\"id\":\"resp_1\"}}\n\n": "resp_1",
+		`data: {"candidates":[],"responseId":"gem-1"}`:                          "gem-1",
+		"data: [DONE]\n\n": "",
+	}
+	for chunk, want := range cases {
+		if got := streamResponseID([]byte(chunk)); got != want {
+			t.Errorf("streamResponseID(%q) = %q, want %q", chunk, got, want)
+		}
+	}
+}
@@ -27,6 +27,14 @@ type StreamForwardOptions struct {
 	// WriteKeepAlive optionally writes a keep-alive heartbeat. It should not flush.
 	// When nil, a standard SSE comment heartbeat is used.
 	WriteKeepAlive func()
+
+	// IsBoundary optionally marks chunks that must reach the client without delay when chunk
+	// coalescing is enabled, such as the start of a tool call. Buffered chunks are flushed first.
+	IsBoundary func(chunk []byte) bool
+
+	// MergeChunks optionally combines two consecutive chunks into one when chunk coalescing is
+	// enabled. It reports false when the chunks cannot be merged.
+	MergeChunks func(pending, next []byte) ([]byte, bool)
 }
 
 func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, opts StreamForwardOptions) {
@@ -61,6 +69,9 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 		keepAliveC = keepAlive.C
 	}
 
+	coalescer := newChunkCoalescer(h.streamCoalesceSettings(c), writeChunk, opts)
+	defer coalescer.stop()
+
 	var terminalErr *interfaces.ErrorMessage
 	for {
 		select {
@@ -69,6 +80,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 			return
 		case chunk, ok := <-data:
 			if !ok {
+				coalescer.drain()
 				// Prefer surfacing a terminal error if one is pending.
 				if terminalErr == nil {
 					select {
@@ -94,12 +106,18 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 				cancel(nil)
 				return
 			}
-			writeChunk(chunk)
+			if coalescer.add(chunk) {
+				coalescer.drain()
+				flusher.Flush()
+			}
+		case <-coalescer.timerC:
+			coalescer.drain()
 			flusher.Flush()
 		case errMsg, ok := <-errs:
 			if !ok {
 				continue
 			}
+			coalescer.drain()
 			if errMsg != nil {
 				terminalErr = errMsg
 				if opts.WriteTerminalError != nil {
@@ -114,6 +132,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 			cancel(execErr)
 			return
 		case <-keepAliveC:
+			coalescer.drain()
 			writeKeepAlive()
 			flusher.Flush()
 		}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLeakWatchdogInterval = 30 * time.Second
+	defaultLeakWatchdogGrace    = 30 * time.Second
+	defaultLeakWatchdogIdle     = 10 * time.Minute
+)
+
+// Leak reasons reported by the watchdog.
+const (
+	// leakClientGone is a stream still running after its client disconnected.
+	leakClientGone = "client-gone"
+	// leakIdle is a stream without upstream or client progress.
+	leakIdle = "idle"
+	// leakUnresponsive is a stream still running after it was force-closed, typically a
+	// goroutine blocked on a channel that ignores its context.
+	leakUnresponsive = "unresponsive"
+)
+
+// leakWatchdogSettings are the resolved watchdog settings.
+type leakWatchdogSettings struct {
+	interval   time.Duration
+	grace      time.Duration
+	idle       time.Duration
+	forceClose bool
+}
+
+func resolveLeakWatchdog(cfg *config.SDKConfig) (leakWatchdogSettings, bool) {
+	if cfg == nil || !cfg.Streaming.LeakWatchdog.Enabled {
+		return leakWatchdogSettings{}, false
+	}
+	raw := cfg.Streaming.LeakWatchdog
+	settings := leakWatchdogSettings{
+		interval:   defaultLeakWatchdogInterval,
+		grace:      defaultLeakWatchdogGrace,
+		idle:       defaultLeakWatchdogIdle,
+		forceClose: raw.ForceClose,
+	}
+	if raw.IntervalSeconds > 0 {
+		settings.interval = time.Duration(raw.IntervalSeconds) * time.Second
+	}
+	if raw.GraceSeconds > 0 {
+		settings.grace = time.Duration(raw.GraceSeconds) * time.Second
+	}
+	if raw.IdleSeconds > 0 {
+		settings.idle = time.Duration(raw.IdleSeconds) * time.Second
+	}
+	return settings, true
+}
+
+// trackedStream is the watchdog's registration of one stream pipeline.
+type trackedStream struct {
+	requestID string
+	model     string
+	format    string
+	started   time.Time
+	// client is the inbound request's context; it is done once the client disconnected.
+	client context.Context
+	cancel context.CancelFunc
+	// lastActivity is the UnixNano of the last chunk received from the upstream or handed
+	// to the client.
+	lastActivity atomic.Int64
+
+	// The fields below are only used by the watchdog loop.
+	clientGoneAt time.Time
+	leaked       bool
+	closedAt     time.Time
+	unresponsive bool
+}
+
+// touch records progress of the stream. It is safe on a nil stream.
+func (s *trackedStream) touch() {
+	if s != nil {
+		s.lastActivity.Store(time.Now().UnixNano())
+	}
+}
+
+// done unregisters the stream once its forwarding goroutine exits. It is safe on a nil stream.
+func (s *trackedStream) done() {
+	if s != nil {
+		streamWatchdog.unregister(s)
+	}
+}
+
+// leakWatchdog compares registered stream pipelines against their clients and progress,
+// logging and optionally cancelling the ones that leaked.
+type leakWatchdog struct {
+	mu       sync.Mutex
+	streams  map[*trackedStream]struct{}
+	settings atomic.Pointer[leakWatchdogSettings]
+	start    sync.Once
+}
+
+var streamWatchdog = &leakWatchdog{streams: make(map[*trackedStream]struct{})}
+
+// ActiveStreams returns the number of stream pipelines registered with the leak watchdog. It
+// is zero while the watchdog is disabled.
+func ActiveStreams() int {
+	streamWatchdog.mu.Lock()
+	defer streamWatchdog.mu.Unlock()
+	return len(streamWatchdog.streams)
+}
+
+// trackStream registers the stream pipeline of the request in ctx with the leak watchdog and
+// starts the watchdog on first use. cancel stops the pipeline when the stream is force-closed.
+// It returns nil when the watchdog is disabled.
+func (h *BaseAPIHandler) trackStream(ctx context.Context, handlerType, modelName string, cancel context.CancelFunc) *trackedStream {
+	if h == nil {
+		return nil
+	}
+	settings, ok := resolveLeakWatchdog(h.Cfg)
+	if !ok {
+		return nil
+	}
+	stream := &trackedStream{model: modelName, format: handlerType, started: time.Now(), cancel: cancel}
+	if ctx != nil {
+		stream.requestID = logging.GetRequestID(ctx)
+		if ginCtx, okGin := ctx.Value("gin").(*gin.Context); okGin && ginCtx != nil && ginCtx.Request != nil {
+			stream.client = ginCtx.Request.Context()
+		}
+	}
+	stream.touch()
+	streamWatchdog.register(stream, settings)
+	return stream
+}
+
+func (w *leakWatchdog) register(stream *trackedStream, settings leakWatchdogSettings) {
+	w.settings.Store(&settings)
+	w.mu.Lock()
+	w.streams[stream] = struct{}{}
+	w.mu.Unlock()
+	w.start.Do(func() { go w.run() })
+}
+
+func (w *leakWatchdog) unregister(stream *trackedStream) {
+	w.mu.Lock()
+	delete(w.streams, stream)
+	w.mu.Unlock()
+}
+
+// run checks the registered streams at the configured interval. The settings of the most
+// recent registration apply, so configuration reloads take effect with the next stream.
+func (w *leakWatchdog) run() {
+	interval := w.settings.Load().interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		settings := *w.settings.Load()
+		w.check(time.Now(), settings)
+		if settings.interval != interval {
+			interval = settings.interval
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// check flags the streams that leaked by now. It returns the number of newly flagged streams.
+func (w *leakWatchdog) check(now time.Time, settings leakWatchdogSettings) int {
+	w.mu.Lock()
+	streams := make([]*trackedStream, 0, len(w.streams))
+	for stream := range w.streams {
+		streams = append(streams, stream)
+	}
+	w.mu.Unlock()
+
+	flagged := 0
+	for _, stream := range streams {
+		if stream.unresponsive {
+			continue
+		}
+		if stream.leaked {
+			if !stream.closedAt.IsZero() && now.Sub(stream.closedAt) >= settings.grace {
+				stream.unresponsive = true
+				flagged++
+				w.report(stream, leakUnresponsive, "logged", now)
+			}
+			continue
+		}
+		reason := ""
+		if stream.client != nil && stream.client.Err() != nil {
+			if stream.clientGoneAt.IsZero() {
+				stream.clientGoneAt = now
+			}
+			if now.Sub(stream.clientGoneAt) >= settings.grace {
+				reason = leakClientGone
+			}
+		}
+		if reason == "" && now.Sub(time.Unix(0, stream.lastActivity.Load())) >= settings.idle {
+			reason = leakIdle
+		}
+		if reason == "" {
+			continue
+		}
+		stream.leaked = true
+		flagged++
+		action := "logged"
+		if settings.forceClose && stream.cancel != nil {
+			action = "closed"
+			stream.closedAt = now
+			stream.cancel()
+		}
+		w.report(stream, reason, action, now)
+	}
+	if flagged > 0 {
+		log.Warnf("stream watchdog: %d active streams, %d goroutines", len(streams), runtime.NumGoroutine())
+	}
+	return flagged
+}
+
+func (w *leakWatchdog) report(stream *trackedStream, reason, action string, now time.Time) {
+	metrics.Default().ObserveStreamLeak(reason, action)
+	log.WithFields(log.Fields{
+		"request_id":    stream.requestID,
+		"model":         stream.model,
+		"format":        stream.format,
+		"age":           now.Sub(stream.started).Round(time.Second).String(),
+		"last_activity": now.Sub(time.Unix(0, stream.lastActivity.Load())).Round(time.Second).String(),
+		"reason":        reason,
+		"action":        action,
+	}).Warn("stream watchdog: leaked stream")
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLeakWatchdogFlagsStreamsOutlivingTheirClient(t *testing.T) {
+	w := &leakWatchdog{streams: make(map[*trackedStream]struct{})}
+	settings := leakWatchdogSettings{interval: time.Second, grace: 30 * time.Second, idle: 10 * time.Minute, forceClose: true}
+
+	client, disconnect := context.WithCancel(context.Background())
+	pipeline, cancelPipeline := context.WithCancel(context.Background())
+	defer cancelPipeline()
+	stream := &trackedStream{model: "gemini-2.5-pro", format: "openai", started: time.Now(), client: client, cancel: cancelPipeline}
+	stream.touch()
+	w.streams[stream] = struct{}{}
+
+	now := time.Now()
+	if flagged := w.check(now, settings); flagged != 0 {
+		t.Fatalf("flagged %d streams with a connected client", flagged)
+	}
+	disconnect()
+	if flagged := w.check(now.Add(time.Second), settings); flagged != 0 {
+		t.Fatalf("flagged %d streams within the grace period", flagged)
+	}
+	if flagged := w.check(now.Add(40*time.Second), settings); flagged != 1 {
+		t.Fatalf("flagged %d streams after the grace period, want 1", flagged)
+	}
+	if pipeline.Err() == nil {
+		t.Fatal("leaked stream was not force-closed")
+	}
+	if flagged := w.check(now.Add(50*time.Second), settings); flagged != 0 {
+		t.Fatalf("flagged a leaked stream again before its close grace expired")
+	}
+	if flagged := w.check(now.Add(80*time.Second), settings); flagged != 1 || !stream.unresponsive {
+		t.Fatalf("stream still running after force-close was not reported as unresponsive")
+	}
+	if flagged := w.check(now.Add(200*time.Second), settings); flagged != 0 {
+		t.Fatalf("unresponsive stream reported twice")
+	}
+}
+
+func TestLeakWatchdogFlagsIdleStreamsWithoutClosing(t *testing.T) {
+	w := &leakWatchdog{streams: make(map[*trackedStream]struct{})}
+	settings := leakWatchdogSettings{interval: time.Second, grace: 30 * time.Second, idle: time.Minute}
+
+	pipeline, cancelPipeline := context.WithCancel(context.Background())
+	defer cancelPipeline()
+	stream := &trackedStream{model: "claude-sonnet-4", format: "claude", started: time.Now(), cancel: cancelPipeline}
+	stream.touch()
+	w.streams[stream] = struct{}{}
+
+	if flagged := w.check(time.Now().Add(30*time.Second), settings); flagged != 0 {
+		t.Fatalf("flagged %d streams before the idle timeout", flagged)
+	}
+	if flagged := w.check(time.Now().Add(2*time.Minute), settings); flagged != 1 {
+		t.Fatalf("flagged %d idle streams, want 1", flagged)
+	}
+	if pipeline.Err() != nil {
+		t.Fatal("stream was closed although force-close is off")
+	}
+	stream.done()
+}
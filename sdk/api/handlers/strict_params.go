@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/tidwall/gjson"
+)
+
+type providerSet map[string]bool
+
+func (s providerSet) has(provider string) bool { return s[provider] }
+
+// geminiFamilyProviders speak the Gemini generateContent format upstream.
+var geminiFamilyProviders = providerSet{
+	"gemini":      true,
+	"gemini-cli":  true,
+	"vertex":      true,
+	"aistudio":    true,
+	"antigravity": true,
+}
+
+// openAIFormatProvider reports whether provider receives OpenAI Chat Completions payloads,
+// which covers OpenAI-compatible upstreams, Qwen and iFlow.
+func openAIFormatProvider(provider string) bool {
+	return !geminiFamilyProviders[provider] && provider != "claude" && provider != "codex"
+}
+
+func geminiOrOpenAIProvider(provider string) bool {
+	return geminiFamilyProviders[provider] || openAIFormatProvider(provider)
+}
+
+// strictParam is a request field that some providers cannot honor after translation.
+type strictParam struct {
+	name    string
+	present func(root gjson.Result) bool
+	honored func(provider string) bool
+}
+
+func nonZero(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool { return root.Get(path).Float() != 0 }
+}
+
+func greaterThanOne(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool { return root.Get(path).Int() > 1 }
+}
+
+func isTrue(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool { return root.Get(path).Bool() }
+}
+
+func exists(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool { return root.Get(path).Exists() }
+}
+
+func nonEmptyObject(path string) func(gjson.Result) bool {
+	return func(root gjson.Result) bool {
+		value := root.Get(path)
+		return value.IsObject() && len(value.Map()) > 0
+	}
+}
+
+func geminiStrictParams(prefix string) []strictParam {
+	return []strictParam{
+		{prefix + "generationConfig.candidateCount", greaterThanOne(prefix + "generationConfig.candidateCount"), geminiOrOpenAIProvider},
+		{prefix + "generationConfig.presencePenalty", nonZero(prefix + "generationConfig.presencePenalty"), geminiFamilyProviders.has},
+		{prefix + "generationConfig.frequencyPenalty", nonZero(prefix + "generationConfig.frequencyPenalty"), geminiFamilyProviders.has},
+		{prefix + "generationConfig.responseLogprobs", isTrue(prefix + "generationConfig.responseLogprobs"), geminiFamilyProviders.has},
+		{prefix + "generationConfig.logprobs", exists(prefix + "generationConfig.logprobs"), geminiFamilyProviders.has},
+	}
+}
+
+// strictParamsBySource lists the checked fields per inbound request format.
+var strictParamsBySource = map[string][]strictParam{
+	constant.OpenAI: {
+		{"n", greaterThanOne("n"), geminiOrOpenAIProvider},
+		{"logprobs", isTrue("logprobs"), openAIFormatProvider},
+		{"top_logprobs", exists("top_logprobs"), openAIFormatProvider},
+		{"logit_bias", nonEmptyObject("logit_bias"), openAIFormatProvider},
+		{"presence_penalty", nonZero("presence_penalty"), openAIFormatProvider},
+		{"frequency_penalty", nonZero("frequency_penalty"), openAIFormatProvider},
+	},
+	constant.OpenaiResponse: {
+		{"top_logprobs", exists("top_logprobs"), func(provider string) bool { return provider == "codex" }},
+	},
+	constant.Gemini:    geminiStrictParams(""),
+	constant.GeminiCLI: geminiStrictParams("request."),
+}
+
+// strictParamsError rejects requests from strict keys that carry parameters one of the
+// candidate providers would silently drop. The error lists each offending field together
+// with the providers that cannot honor it.
+func (h *BaseAPIHandler) strictParamsError(ctx context.Context, handlerType string, providers []string, rawJSON []byte) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil || len(h.Cfg.StrictKeys) == 0 || len(rawJSON) == 0 || !strictKey(ctx, h.Cfg.StrictKeys) {
+		return nil
+	}
+	params := strictParamsBySource[handlerType]
+	if len(params) == 0 {
+		return nil
+	}
+	root := gjson.ParseBytes(rawJSON)
+	var offending []string
+	for _, param := range params {
+		if !param.present(root) {
+			continue
+		}
+		var unsupported []string
+		for _, provider := range providers {
+			if !param.honored(provider) {
+				unsupported = append(unsupported, provider)
+			}
+		}
+		if len(unsupported) > 0 {
+			offending = append(offending, fmt.Sprintf("%s (%s)", param.name, strings.Join(unsupported, ", ")))
+		}
+	}
+	if len(offending) == 0 {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("strict mode: parameters not supported by the target provider: %s", strings.Join(offending, "; ")),
+	}
+}
+
+func strictKey(ctx context.Context, keys []string) bool {
+	if ctx == nil {
+		return false
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	for _, id := range logging.ClientKeyIdentifiers(ginCtx) {
+		for _, key := range keys {
+			if id == strings.TrimSpace(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
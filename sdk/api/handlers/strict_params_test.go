@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func strictTestContext(apiKey string) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func TestStrictParamsErrorListsUnsupportedFields(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{StrictKeys: []string{"eval-key"}}}
+	payload := []byte(`{"model":"claude-sonnet-4-5","n":2,"logprobs":true,"presence_penalty":0,"frequency_penalty":0.5,"messages":[]}`)
+
+	errMsg := h.strictParamsError(strictTestContext("eval-key"), "openai", []string{"claude"}, payload)
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %+v", errMsg)
+	}
+	msg := errMsg.Error.Error()
+	for _, field := range []string{"n (claude)", "logprobs (claude)", "frequency_penalty (claude)"} {
+		if !strings.Contains(msg, field) {
+			t.Errorf("error %q does not mention %s", msg, field)
+		}
+	}
+	if strings.Contains(msg, "presence_penalty") {
+		t.Errorf("zero presence_penalty should not be reported: %q", msg)
+	}
+
+	if errMsg = h.strictParamsError(strictTestContext("other-key"), "openai", []string{"claude"}, payload); errMsg != nil {
+		t.Fatalf("non-strict key should pass, got %v", errMsg.Error)
+	}
+}
+
+func TestStrictParamsErrorAllowsHonoredFields(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{StrictKeys: []string{"eval-key"}}}
+	ctx := strictTestContext("eval-key")
+
+	if errMsg := h.strictParamsError(ctx, "openai", []string{"gemini"}, []byte(`{"n":3}`)); errMsg != nil {
+		t.Fatalf("gemini honors n, got %v", errMsg.Error)
+	}
+	if errMsg := h.strictParamsError(ctx, "openai", []string{"openrouter"}, []byte(`{"logprobs":true,"top_logprobs":5}`)); errMsg != nil {
+		t.Fatalf("OpenAI-compatible providers honor logprobs, got %v", errMsg.Error)
+	}
+	errMsg := h.strictParamsError(ctx, "gemini", []string{"gemini", "claude"}, []byte(`{"generationConfig":{"candidateCount":2}}`))
+	if errMsg == nil || !strings.Contains(errMsg.Error.Error(), "generationConfig.candidateCount (claude)") {
+		t.Fatalf("mixed providers should report the provider that drops the field, got %+v", errMsg)
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultSystemPromptTTL = time.Hour
+	// systemPromptTrackerMax bounds remembered conversations; the least recently seen are
+	// evicted first.
+	systemPromptTrackerMax = 8192
+)
+
+type systemPromptSeen struct {
+	conversation string
+	hash         string
+	expire       time.Time
+}
+
+// systemPromptTracker remembers the last system prompt hash per conversation.
+type systemPromptTracker struct {
+	mu    sync.Mutex
+	seen  map[string]*list.Element
+	order *list.List // of *systemPromptSeen, least recently seen first
+	nowFn func() time.Time
+}
+
+var systemPrompts = newSystemPromptTracker()
+
+func newSystemPromptTracker() *systemPromptTracker {
+	return &systemPromptTracker{seen: make(map[string]*list.Element), order: list.New(), nowFn: time.Now}
+}
+
+// observe records hash for conversation and reports whether it matches the previous request.
+func (t *systemPromptTracker) observe(conversation, hash string, ttl time.Duration) bool {
+	now := t.nowFn()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if elem, ok := t.seen[conversation]; ok {
+		prev := elem.Value.(*systemPromptSeen)
+		repeat := prev.hash == hash && now.Before(prev.expire)
+		prev.hash, prev.expire = hash, now.Add(ttl)
+		t.order.MoveToBack(elem)
+		return repeat
+	}
+	for t.order.Len() >= systemPromptTrackerMax {
+		oldest := t.order.Front()
+		delete(t.seen, oldest.Value.(*systemPromptSeen).conversation)
+		t.order.Remove(oldest)
+	}
+	t.seen[conversation] = t.order.PushBack(&systemPromptSeen{conversation: conversation, hash: hash, expire: now.Add(ttl)})
+	return false
+}
+
+// applySystemPromptDedup collapses consecutive identical system prompt blocks in rawJSON and,
+// when the conversation resends the system prompt of its previous request, flags the
+// execution metadata so executors can use provider prompt caching.
+func (h *BaseAPIHandler) applySystemPromptDedup(ctx context.Context, handlerType, model string, rawJSON []byte, meta map[string]any) []byte {
+	if h == nil || h.Cfg == nil || !h.Cfg.SystemPromptDedup.Enabled || len(rawJSON) == 0 {
+		return rawJSON
+	}
+	payload := collapseSystemPrompt(handlerType, rawJSON)
+	hash := systemPromptHash(handlerType, payload)
+	if hash == "" {
+		return payload
+	}
+	conversation, session := conversationKey(ctx, handlerType, model, payload)
+	if conversation == "" {
+		return payload
+	}
+	ttl := defaultSystemPromptTTL
+	if minutes := h.Cfg.SystemPromptDedup.TTLMinutes; minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	// Only a client session marker is stable enough to pin an upstream cache key; the
+	// first-turn fallback can merge unrelated conversations that open the same way.
+	if session {
+		meta[coreexecutor.ConversationKeyMetadataKey] = conversation
+	}
+	if systemPrompts.observe(conversation, hash, ttl) {
+		meta[coreexecutor.SystemPromptRepeatMetadataKey] = true
+	}
+	return payload
+}
+
+// collapseSystemPrompt drops system blocks that repeat the block right before them.
+func collapseSystemPrompt(handlerType string, payload []byte) []byte {
+	switch handlerType {
+	case constant.Claude:
+		return collapseArray(payload, "system", func(prev, cur gjson.Result) bool {
+			return cur.Get("type").String() == "text" && !cur.Get("cache_control").Exists() &&
+				prev.Get("type").String() == "text" && prev.Get("text").String() == cur.Get("text").String()
+		})
+	case constant.OpenAI:
+		return collapseArray(payload, "messages", sameSystemMessage)
+	case constant.OpenaiResponse:
+		return collapseArray(payload, "input", sameSystemMessage)
+	case constant.Gemini:
+		return collapseArray(payload, "systemInstruction.parts", sameTextPart)
+	case constant.GeminiCLI:
+		return collapseArray(payload, "request.systemInstruction.parts", sameTextPart)
+	}
+	return payload
+}
+
+func sameSystemMessage(prev, cur gjson.Result) bool {
+	role := cur.Get("role").String()
+	if role != "system" && role != "developer" {
+		return false
+	}
+	return prev.Get("role").String() == role && prev.Get("content").Raw == cur.Get("content").Raw
+}
+
+func sameTextPart(prev, cur gjson.Result) bool {
+	text := cur.Get("text")
+	return text.Exists() && len(cur.Map()) == 1 && prev.Get("text").String() == text.String() && len(prev.Map()) == 1
+}
+
+func collapseArray(payload []byte, path string, duplicate func(prev, cur gjson.Result) bool) []byte {
+	arr := gjson.GetBytes(payload, path)
+	if !arr.IsArray() {
+		return payload
+	}
+	items := arr.Array()
+	kept := make([]string, 0, len(items))
+	for i, item := range items {
+		if i > 0 && duplicate(items[i-1], item) {
+			continue
+		}
+		kept = append(kept, item.Raw)
+	}
+	if len(kept) == len(items) {
+		return payload
+	}
+	out, err := sjson.SetRawBytes(payload, path, []byte("["+strings.Join(kept, ",")+"]"))
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+// systemPromptHash fingerprints the system prompt of a request, or returns "" when it has none.
+func systemPromptHash(handlerType string, payload []byte) string {
+	var parts []string
+	switch handlerType {
+	case constant.Claude:
+		parts = append(parts, gjson.GetBytes(payload, "system").Raw)
+	case constant.OpenAI, constant.OpenaiResponse:
+		field := "messages"
+		if handlerType == constant.OpenaiResponse {
+			field = "input"
+			parts = append(parts, gjson.GetBytes(payload, "instructions").Raw)
+		}
+		for _, msg := range gjson.GetBytes(payload, field).Array() {
+			if role := msg.Get("role").String(); role == "system" || role == "developer" {
+				parts = append(parts, msg.Get("content").Raw)
+			}
+		}
+	case constant.Gemini:
+		parts = append(parts, gjson.GetBytes(payload, "systemInstruction").Raw)
+	case constant.GeminiCLI:
+		parts = append(parts, gjson.GetBytes(payload, "request.systemInstruction").Raw)
+	}
+	joined := strings.Join(parts, "\x00")
+	if strings.Trim(joined, "\x00") == "" {
+		return ""
+	}
+	return shortHash(joined)
+}
+
+// conversationKey identifies a conversation by client credential, model and the client's
+// session marker, falling back to the first user turn which stays fixed across turns.
+// session reports whether the key came from a client session marker.
+func conversationKey(ctx context.Context, handlerType, model string, payload []byte) (key string, session bool) {
+	var marker string
+	switch handlerType {
+	case constant.Claude:
+		marker = gjson.GetBytes(payload, "metadata.user_id").String()
+	case constant.OpenAI, constant.OpenaiResponse:
+		marker = gjson.GetBytes(payload, "prompt_cache_key").String()
+		if marker == "" {
+			marker = gjson.GetBytes(payload, "user").String()
+		}
+	}
+	session = marker != ""
+	if !session {
+		marker = firstUserTurn(handlerType, payload)
+	}
+	if marker == "" {
+		return "", false
+	}
+	var principal string
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			principal, _ = v.(string)
+		}
+	}
+	return shortHash(principal + "\x00" + model + "\x00" + marker), session
+}
+
+func firstUserTurn(handlerType string, payload []byte) string {
+	field := "messages"
+	switch handlerType {
+	case constant.OpenaiResponse:
+		field = "input"
+	case constant.Gemini:
+		field = "contents"
+	case constant.GeminiCLI:
+		field = "request.contents"
+	}
+	for _, msg := range gjson.GetBytes(payload, field).Array() {
+		if msg.Get("role").String() == "user" {
+			if content := msg.Get("content"); content.Exists() {
+				return content.Raw
+			}
+			return msg.Get("parts").Raw
+		}
+	}
+	return ""
+}
+
+func shortHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:12])
+}
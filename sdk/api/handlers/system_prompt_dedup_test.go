@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplySystemPromptDedupCollapsesAndFlagsRepeats(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		SystemPromptDedup: sdkconfig.SystemPromptDedupConfig{Enabled: true},
+	}}
+	turn1 := []byte(`{"model":"claude-sonnet-4-5","metadata":{"user_id":"dedup-test-session"},` +
+		`"system":[{"type":"text","text":"You are helpful."},{"type":"text","text":"You are helpful."},{"type":"text","text":"Be brief."}],` +
+		`"messages":[{"role":"user","content":"hi"}]}`)
+
+	meta := map[string]any{}
+	out := h.applySystemPromptDedup(context.Background(), "claude", "claude-sonnet-4-5", turn1, meta)
+	if n := gjson.GetBytes(out, "system.#").Int(); n != 2 {
+		t.Fatalf("expected duplicate system block to be dropped, got %d blocks: %s", n, out)
+	}
+	if meta[coreexecutor.ConversationKeyMetadataKey] == nil {
+		t.Fatal("conversation key should be set")
+	}
+	if meta[coreexecutor.SystemPromptRepeatMetadataKey] != nil {
+		t.Fatal("first request must not be flagged as a repeat")
+	}
+
+	turn2 := []byte(`{"model":"claude-sonnet-4-5","metadata":{"user_id":"dedup-test-session"},` +
+		`"system":[{"type":"text","text":"You are helpful."},{"type":"text","text":"Be brief."}],` +
+		`"messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"more"}]}`)
+	meta = map[string]any{}
+	h.applySystemPromptDedup(context.Background(), "claude", "claude-sonnet-4-5", turn2, meta)
+	if meta[coreexecutor.SystemPromptRepeatMetadataKey] != true {
+		t.Fatal("unchanged system prompt should be flagged as a repeat")
+	}
+
+	turn3 := []byte(`{"model":"claude-sonnet-4-5","metadata":{"user_id":"dedup-test-session"},"system":"Changed.","messages":[]}`)
+	meta = map[string]any{}
+	h.applySystemPromptDedup(context.Background(), "claude", "claude-sonnet-4-5", turn3, meta)
+	if meta[coreexecutor.SystemPromptRepeatMetadataKey] != nil {
+		t.Fatal("changed system prompt must not be flagged")
+	}
+}
+
+func TestCollapseSystemPromptOpenAIMessages(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"system","content":"S"},{"role":"system","content":"S"},{"role":"user","content":"u"},{"role":"user","content":"u"}]}`)
+	out := collapseSystemPrompt("openai", payload)
+	if n := gjson.GetBytes(out, "messages.#").Int(); n != 3 {
+		t.Fatalf("expected only the repeated system message to be dropped, got %s", out)
+	}
+}
+
+func TestApplySystemPromptDedupDisabled(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}
+	payload := []byte(`{"system":[{"type":"text","text":"a"},{"type":"text","text":"a"}]}`)
+	meta := map[string]any{}
+	if out := h.applySystemPromptDedup(context.Background(), "claude", "m", payload, meta); string(out) != string(payload) || len(meta) != 0 {
+		t.Fatalf("disabled dedup must not touch the request: %s %v", out, meta)
+	}
+}
+
+func TestApplySystemPromptDedupWithoutSessionMarkerKeepsCacheKeyUnset(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		SystemPromptDedup: sdkconfig.SystemPromptDedupConfig{Enabled: true},
+	}}
+	payload := []byte(`{"input":[{"role":"developer","content":"Rules."},{"role":"user","content":"no-marker-turn"}]}`)
+	for i := 0; i < 2; i++ {
+		meta := map[string]any{}
+		h.applySystemPromptDedup(context.Background(), "openai-response", "gpt-5", payload, meta)
+		if _, ok := meta[coreexecutor.ConversationKeyMetadataKey]; ok {
+			t.Fatal("conversation key must only be published for a client session marker")
+		}
+		if repeat := meta[coreexecutor.SystemPromptRepeatMetadataKey] == true; repeat != (i == 1) {
+			t.Fatalf("request %d: repeat flag = %v", i, repeat)
+		}
+	}
+}
+
+func TestSystemPromptTrackerEvictsLeastRecentlySeen(t *testing.T) {
+	tracker := newSystemPromptTracker()
+	for i := 0; i < systemPromptTrackerMax; i++ {
+		tracker.observe(strconv.Itoa(i), "h", time.Hour)
+	}
+	// Touch the oldest entry so the next insert evicts "1" instead.
+	tracker.observe("0", "h", time.Hour)
+	tracker.observe("new", "h", time.Hour)
+
+	if len(tracker.seen) != systemPromptTrackerMax || tracker.order.Len() != systemPromptTrackerMax {
+		t.Fatalf("tracker size = %d/%d, want %d", len(tracker.seen), tracker.order.Len(), systemPromptTrackerMax)
+	}
+	if _, ok := tracker.seen["1"]; ok {
+		t.Fatal("least recently seen conversation should be evicted")
+	}
+	if !tracker.observe("0", "h", time.Hour) {
+		t.Fatal("recently seen conversation must survive eviction")
+	}
+}
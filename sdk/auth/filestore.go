@@ -219,6 +219,8 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 	status := cliproxyauth.StatusActive
 	if disabled {
 		status = cliproxyauth.StatusDisabled
+	} else if _, ok := metadata[cliproxyauth.MetadataNeedsRelogin]; ok {
+		status = cliproxyauth.StatusNeedsRelogin
 	}
 	auth := &cliproxyauth.Auth{
 		ID:               id,
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ChatMessage is a Chat Completions message. Content is a string or a list of content parts.
+type ChatMessage struct {
+	Role             string     `json:"role"`
+	Content          any        `json:"content,omitempty"`
+	Name             string     `json:"name,omitempty"`
+	ReasoningContent string     `json:"reasoning_content,omitempty"`
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID       string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a function call requested by the model.
+type ToolCall struct {
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall names a function and carries its JSON-encoded arguments.
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// Tool declares a function the model may call.
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes a callable function with a JSON schema for its parameters.
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ChatCompletionRequest is a POST /v1/chat/completions body. Use Client.Do with a custom
+// body for fields not covered here.
+type ChatCompletionRequest struct {
+	Model           string          `json:"model"`
+	Messages        []ChatMessage   `json:"messages"`
+	Stream          bool            `json:"stream,omitempty"`
+	MaxTokens       *int            `json:"max_tokens,omitempty"`
+	Temperature     *float64        `json:"temperature,omitempty"`
+	TopP            *float64        `json:"top_p,omitempty"`
+	Stop            []string        `json:"stop,omitempty"`
+	Tools           []Tool          `json:"tools,omitempty"`
+	ToolChoice      any             `json:"tool_choice,omitempty"`
+	ReasoningEffort string          `json:"reasoning_effort,omitempty"`
+	ResponseFormat  json.RawMessage `json:"response_format,omitempty"`
+	User            string          `json:"user,omitempty"`
+}
+
+// Usage reports token consumption.
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// ChatCompletion is a non-streaming chat completion response.
+type ChatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   *Usage       `json:"usage,omitempty"`
+}
+
+// ChatChoice is one completion alternative.
+type ChatChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ChatCompletionChunk is a single streaming chat completion event.
+type ChatCompletionChunk struct {
+	ID      string            `json:"id"`
+	Object  string            `json:"object"`
+	Created int64             `json:"created"`
+	Model   string            `json:"model"`
+	Choices []ChatChunkChoice `json:"choices"`
+	Usage   *Usage            `json:"usage,omitempty"`
+}
+
+// ChatChunkChoice is the incremental update of one choice.
+type ChatChunkChoice struct {
+	Index        int       `json:"index"`
+	Delta        ChatDelta `json:"delta"`
+	FinishReason *string   `json:"finish_reason"`
+}
+
+// ChatDelta is the content added by a chunk.
+type ChatDelta struct {
+	Role             string     `json:"role,omitempty"`
+	Content          string     `json:"content,omitempty"`
+	ReasoningContent string     `json:"reasoning_content,omitempty"`
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// Text returns the first choice's message content when it is a plain string.
+func (c *ChatCompletion) Text() string {
+	if c == nil || len(c.Choices) == 0 {
+		return ""
+	}
+	text, _ := c.Choices[0].Message.Content.(string)
+	return text
+}
+
+// CreateChatCompletion sends a non-streaming chat completion request.
+func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletion, error) {
+	if req.Stream {
+		return nil, errors.New("client: use StreamChatCompletion for streaming requests")
+	}
+	var out ChatCompletion
+	if err := c.Do(ctx, http.MethodPost, "/v1/chat/completions", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StreamChatCompletion sends a streaming chat completion request. The caller must Close the
+// returned stream.
+func (c *Client) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionStream, error) {
+	req.Stream = true
+	events, err := c.Stream(ctx, "/v1/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatCompletionStream{events: events}, nil
+}
@@ -0,0 +1,221 @@
+// Package client provides a typed Go client for a running CLIProxyAPI instance.
+//
+// It covers the OpenAI-compatible inference endpoints, model listing, the caller's limits
+// and the management API, so Go services orchestrating the proxy do not have to hand-roll
+// HTTP requests or SSE parsing.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// managementPrefix is the path prefix of the management API.
+const managementPrefix = "/v0/management"
+
+// Client talks to a CLIProxyAPI server. It is safe for concurrent use.
+type Client struct {
+	baseURL       *url.URL
+	apiKey        string
+	managementKey string
+	httpClient    *http.Client
+	headers       http.Header
+}
+
+// Option customises a Client.
+type Option func(*Client)
+
+// WithAPIKey sets the client API key sent with inference, model and limits requests.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = strings.TrimSpace(key) }
+}
+
+// WithManagementKey sets the secret sent with management API requests.
+func WithManagementKey(key string) Option {
+	return func(c *Client) { c.managementKey = strings.TrimSpace(key) }
+}
+
+// WithHTTPClient replaces the HTTP client used for requests. Streaming requests should not
+// use a client with an overall timeout; cancel the request context instead.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.headers.Add(key, value) }
+}
+
+// New returns a client for the proxy at baseURL, e.g. "http://127.0.0.1:8317".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, fmt.Errorf("client: base URL must be an absolute http(s) URL, got %q", baseURL)
+	}
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: http.DefaultClient,
+		headers:    make(http.Header),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	return c, nil
+}
+
+// APIError is returned when the proxy answers with a non-2xx status.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Type is the error type reported by the proxy, if any.
+	Type string
+	// Message is the error message reported by the proxy, or the status text.
+	Message string
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("proxy returned %d (%s): %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("proxy returned %d: %s", e.StatusCode, e.Message)
+}
+
+// IsStatus reports whether err is an APIError with the given HTTP status.
+func IsStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// Do sends a JSON request to path on the inference API and decodes the JSON response into
+// out. body and out may be nil. It is the escape hatch for endpoints without a typed method.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	return c.doJSON(ctx, method, path, c.apiKey, body, out)
+}
+
+// DoManagement is Do for management API paths relative to /v0/management.
+func (c *Client) DoManagement(ctx context.Context, method, path string, body, out any) error {
+	return c.doJSON(ctx, method, managementPrefix+"/"+strings.TrimLeft(path, "/"), c.managementKey, body, out)
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path, key string, body, out any) error {
+	resp, err := c.send(ctx, method, path, key, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if raw, ok := out.(*json.RawMessage); ok {
+		data, errRead := io.ReadAll(resp.Body)
+		if errRead != nil {
+			return fmt.Errorf("client: read response: %w", errRead)
+		}
+		*raw = data
+		return nil
+	}
+	if errDecode := json.NewDecoder(resp.Body).Decode(out); errDecode != nil {
+		return fmt.Errorf("client: decode response: %w", errDecode)
+	}
+	return nil
+}
+
+// send performs the request and returns the response when its status is 2xx. Otherwise the
+// body is consumed and an *APIError is returned.
+func (c *Client) send(ctx context.Context, method, path, key string, body any, accept string) (*http.Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("client: encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	endpoint := *c.baseURL
+	rel, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid path %q: %w", path, err)
+	}
+	endpoint.Path = c.baseURL.Path + "/" + strings.TrimLeft(rel.Path, "/")
+	endpoint.RawQuery = rel.RawQuery
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("client: build request: %w", err)
+	}
+	for name, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", accept)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(resp.Body)
+	return nil, newAPIError(resp.StatusCode, data)
+}
+
+// newAPIError parses both the OpenAI-style {"error":{"message","type"}} and the management
+// {"error":"..."} error bodies.
+func newAPIError(status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status, Body: body}
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && len(payload.Error) > 0 {
+		var detail struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		}
+		var text string
+		if json.Unmarshal(payload.Error, &text) == nil {
+			apiErr.Message = text
+		} else if json.Unmarshal(payload.Error, &detail) == nil {
+			apiErr.Message = detail.Message
+			apiErr.Type = detail.Type
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+	return apiErr
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateChatCompletion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "gpt-5" || req.Stream {
+			t.Errorf("unexpected body %+v", req)
+		}
+		_, _ = io.WriteString(w, `{"id":"c1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithAPIKey("sk-test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.CreateChatCompletion(context.Background(), ChatCompletionRequest{
+		Model:    "gpt-5",
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "hello" || resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
+
+func TestStreamChatCompletion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("stream flag not set")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"Hel", "lo"} {
+			_, _ = fmt.Fprintf(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q},\"finish_reason\":null}]}\n\n", part)
+		}
+		_, _ = io.WriteString(w, ": keep-alive\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL)
+	stream, err := c.StreamChatCompletion(context.Background(), ChatCompletionRequest{Model: "gpt-5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = stream.Close() }()
+	text, err := stream.Collect()
+	if err != nil || text != "Hello" {
+		t.Fatalf("Collect() = %q, %v", text, err)
+	}
+}
+
+func TestEventStreamNamedEvents(t *testing.T) {
+	body := io.NopCloser(strings.NewReader("event: message_start\ndata: {\"a\":1}\n\nevent: ping\ndata: {}\ndata: {}\n\n"))
+	stream := newEventStream(body)
+	first, err := stream.Next()
+	if err != nil || first.Name != "message_start" || string(first.Data) != `{"a":1}` {
+		t.Fatalf("unexpected first event %+v, %v", first, err)
+	}
+	second, err := stream.Next()
+	if err != nil || second.Name != "ping" || string(second.Data) != "{}\n{}" {
+		t.Fatalf("unexpected second event %+v, %v", second, err)
+	}
+	if _, err = stream.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestErrorsAndManagementAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":{"message":"Invalid API key","type":"authentication_error"}}`)
+		case "/v0/management/auth-files":
+			if r.Header.Get("Authorization") != "Bearer mgmt" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = io.WriteString(w, `{"error":"invalid management key"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"files":[{"id":"a.json","name":"a.json","provider":"claude","disabled":false,"extra":1}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL+"/", WithManagementKey("mgmt"))
+	_, err := c.ListModels(context.Background())
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Type != "authentication_error" || apiErr.Message != "Invalid API key" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+
+	files, err := c.ListAuthFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Provider != "claude" || len(files[0].Raw) == 0 {
+		t.Fatalf("unexpected files %+v", files)
+	}
+
+	noKey, _ := New(srv.URL)
+	_, err = noKey.ListAuthFiles(context.Background())
+	if !IsStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("expected 401, got %v", err)
+	}
+	if apiErr, _ := err.(*APIError); apiErr == nil || apiErr.Message != "invalid management key" {
+		t.Fatalf("unexpected management error %#v", err)
+	}
+}
+
+func TestNewRejectsRelativeURL(t *testing.T) {
+	if _, err := New("localhost:8317"); err == nil {
+		t.Fatal("expected error for URL without scheme")
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// AuthFile is an entry of GET /v0/management/auth-files. Fields the proxy reports beyond
+// these are kept in Raw.
+type AuthFile struct {
+	ID            string     `json:"id"`
+	AuthIndex     string     `json:"auth_index,omitempty"`
+	Name          string     `json:"name"`
+	Provider      string     `json:"provider"`
+	Label         string     `json:"label,omitempty"`
+	Email         string     `json:"email,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	StatusMessage string     `json:"status_message,omitempty"`
+	Disabled      bool       `json:"disabled"`
+	Unavailable   bool       `json:"unavailable"`
+	RuntimeOnly   bool       `json:"runtime_only"`
+	LastRefresh   *time.Time `json:"last_refresh,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON keeps the full entry in Raw alongside the typed fields.
+func (a *AuthFile) UnmarshalJSON(data []byte) error {
+	type plain AuthFile
+	if err := json.Unmarshal(data, (*plain)(a)); err != nil {
+		return err
+	}
+	a.Raw = append(a.Raw[:0], data...)
+	return nil
+}
+
+// LoadSheddingProvider is the load shedding state of one provider.
+type LoadSheddingProvider struct {
+	Provider  string     `json:"provider"`
+	Shedding  bool       `json:"shedding"`
+	Since     *time.Time `json:"since,omitempty"`
+	Requests  int        `json:"requests"`
+	Failures  int        `json:"failures"`
+	ErrorRate float64    `json:"error_rate"`
+}
+
+// LoadSheddingEvent records a provider entering or leaving essential-only mode.
+type LoadSheddingEvent struct {
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider"`
+	Shedding  bool      `json:"shedding"`
+	ErrorRate float64   `json:"error_rate"`
+	Requests  int       `json:"requests"`
+}
+
+// LoadShedding is the GET /v0/management/load-shedding response.
+type LoadShedding struct {
+	Enabled   bool                   `json:"enabled"`
+	Providers []LoadSheddingProvider `json:"providers"`
+	Events    []LoadSheddingEvent    `json:"events"`
+}
+
+// GetConfig returns the server configuration as JSON.
+func (c *Client) GetConfig(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	if err := c.DoManagement(ctx, http.MethodGet, "/config", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetUsage returns the usage statistics snapshot as JSON.
+func (c *Client) GetUsage(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	if err := c.DoManagement(ctx, http.MethodGet, "/usage", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListAuthFiles returns the credentials known to the proxy.
+func (c *Client) ListAuthFiles(ctx context.Context) ([]AuthFile, error) {
+	var out struct {
+		Files []AuthFile `json:"files"`
+	}
+	if err := c.DoManagement(ctx, http.MethodGet, "/auth-files", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Files, nil
+}
+
+// SetAuthFileDisabled enables or disables a credential by file name or ID.
+func (c *Client) SetAuthFileDisabled(ctx context.Context, name string, disabled bool) error {
+	body := struct {
+		Name     string `json:"name"`
+		Disabled bool   `json:"disabled"`
+	}{Name: name, Disabled: disabled}
+	return c.DoManagement(ctx, http.MethodPatch, "/auth-files/status", body, nil)
+}
+
+// GetLoadShedding returns per-provider error rates and load shedding transitions.
+func (c *Client) GetLoadShedding(ctx context.Context) (*LoadShedding, error) {
+	var out LoadShedding
+	if err := c.DoManagement(ctx, http.MethodGet, "/load-shedding", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
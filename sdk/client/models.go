@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Model is an entry of GET /v1/models.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created,omitempty"`
+	OwnedBy string `json:"owned_by,omitempty"`
+}
+
+// ListModels returns the models available to the configured API key.
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	var out struct {
+		Data []Model `json:"data"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/v1/models", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Data, nil
+}
+
+// KeyLimits describes the calling API key and its remaining budget.
+type KeyLimits struct {
+	Type              string     `json:"type"`
+	ID                string     `json:"id,omitempty"`
+	Prefix            string     `json:"prefix,omitempty"`
+	Description       string     `json:"description,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	Models            []string   `json:"models,omitempty"`
+	Requests          int64      `json:"requests"`
+	Tokens            int64      `json:"tokens"`
+	MaxRequests       int64      `json:"max_requests,omitempty"`
+	MaxTokens         int64      `json:"max_tokens,omitempty"`
+	RemainingRequests *int64     `json:"remaining_requests,omitempty"`
+	RemainingTokens   *int64     `json:"remaining_tokens,omitempty"`
+}
+
+// ModelLimits summarises credential pool availability and quota for one model.
+type ModelLimits struct {
+	ID             string     `json:"id"`
+	Credentials    int        `json:"credentials"`
+	Ready          int        `json:"ready"`
+	Cooling        int        `json:"cooling"`
+	RateLimited    int        `json:"rate_limited"`
+	NextRecoverAt  *time.Time `json:"next_recover_at,omitempty"`
+	RateLimitReset *time.Time `json:"rate_limit_reset_at,omitempty"`
+	QuotaRemaining *float64   `json:"quota_remaining_percent,omitempty"`
+}
+
+// RateLimits summarises the caller's request and token caps and upstream rate limiting.
+type RateLimits struct {
+	LimitRequests     int64      `json:"limit_requests,omitempty"`
+	RemainingRequests *int64     `json:"remaining_requests,omitempty"`
+	LimitTokens       int64      `json:"limit_tokens,omitempty"`
+	RemainingTokens   *int64     `json:"remaining_tokens,omitempty"`
+	RateLimitedModels int        `json:"rate_limited_models"`
+	ResetAt           *time.Time `json:"reset_at,omitempty"`
+}
+
+// Limits is the GET /v1/limits response.
+type Limits struct {
+	Key        KeyLimits     `json:"key"`
+	RateLimits RateLimits    `json:"rate_limits"`
+	Models     []ModelLimits `json:"models"`
+	StatusLine string        `json:"status_line"`
+}
+
+// Limits returns the caller's remaining key budget and the quota and availability of every
+// model it may use.
+func (c *Client) Limits(ctx context.Context) (*Limits, error) {
+	var out Limits
+	if err := c.Do(ctx, http.MethodGet, "/v1/limits", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxEventSize bounds a single SSE line; image and tool payloads can be large.
+const maxEventSize = 16 << 20
+
+// Event is a single server-sent event.
+type Event struct {
+	// Name is the event field, empty for unnamed events.
+	Name string
+	// Data is the event payload with multi-line data joined by newlines.
+	Data []byte
+}
+
+// EventStream reads server-sent events from a streaming response. Call Close when done.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// Stream sends a JSON request to path on the inference API and returns the SSE response.
+// It is the streaming counterpart of Do, e.g. for /v1/messages or /v1/responses.
+func (c *Client) Stream(ctx context.Context, path string, body any) (*EventStream, error) {
+	resp, err := c.send(ctx, http.MethodPost, path, c.apiKey, body, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+	return newEventStream(resp.Body), nil
+}
+
+func newEventStream(body io.ReadCloser) *EventStream {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	return &EventStream{body: body, scanner: scanner}
+}
+
+// Next returns the next event, skipping comments such as keep-alives. It returns io.EOF when
+// the stream ends.
+func (s *EventStream) Next() (Event, error) {
+	var (
+		event   Event
+		data    [][]byte
+		hasData bool
+	)
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		if len(line) == 0 {
+			if hasData || event.Name != "" {
+				event.Data = bytes.Join(data, []byte("\n"))
+				return event, nil
+			}
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			event.Name = string(value)
+		case "data":
+			data = append(data, bytes.Clone(value))
+			hasData = true
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	if hasData || event.Name != "" {
+		event.Data = bytes.Join(data, []byte("\n"))
+		return event, nil
+	}
+	return Event{}, io.EOF
+}
+
+// Close releases the underlying response body.
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// ChatCompletionStream yields chat completion chunks from a streaming request.
+type ChatCompletionStream struct {
+	events *EventStream
+}
+
+// Recv returns the next chunk. It returns io.EOF after the terminal [DONE] event. An error
+// event sent after the stream started is returned as an *APIError.
+func (s *ChatCompletionStream) Recv() (*ChatCompletionChunk, error) {
+	for {
+		event, err := s.events.Next()
+		if err != nil {
+			return nil, err
+		}
+		data := bytes.TrimSpace(event.Data)
+		if len(data) == 0 {
+			continue
+		}
+		if string(data) == "[DONE]" {
+			return nil, io.EOF
+		}
+		if event.Name == "error" || bytes.HasPrefix(data, []byte(`{"error"`)) {
+			return nil, newAPIError(http.StatusInternalServerError, data)
+		}
+		var chunk ChatCompletionChunk
+		if errDecode := json.Unmarshal(data, &chunk); errDecode != nil {
+			return nil, errDecode
+		}
+		return &chunk, nil
+	}
+}
+
+// Collect drains the stream and returns the concatenated content of the first choice.
+func (s *ChatCompletionStream) Collect() (string, error) {
+	var sb strings.Builder
+	for {
+		chunk, err := s.Recv()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		if len(chunk.Choices) > 0 {
+			sb.WriteString(chunk.Choices[0].Delta.Content)
+		}
+	}
+}
+
+// Close releases the underlying response body.
+func (s *ChatCompletionStream) Close() error {
+	return s.events.Close()
+}
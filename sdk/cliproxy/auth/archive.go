@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MetadataArchivedAt stores when an auth was archived by the stale auth sweep.
+	MetadataArchivedAt = "archived_at"
+	// MetadataArchivedReason stores why an auth was archived.
+	MetadataArchivedReason = "archived_reason"
+	// MetadataLastUsedAt persists LastUsedAt so staleness survives restarts.
+	MetadataLastUsedAt = "last_used_at"
+	// MetadataFailingSince persists FailingSince so failure streaks survive restarts.
+	MetadataFailingSince = "failing_since"
+
+	defaultAuthGCInterval = time.Hour
+	// activityPersistGranularity limits how often usage timestamps are written back to the store.
+	activityPersistGranularity = 24 * time.Hour
+)
+
+// GCReport summarises one stale auth sweep.
+type GCReport struct {
+	Archived []string `json:"archived"`
+	Deleted  []string `json:"deleted"`
+}
+
+// ArchivedAt reports when the auth was archived by the stale auth sweep.
+func (a *Auth) ArchivedAt() (time.Time, bool) {
+	if a == nil || !a.Disabled {
+		return time.Time{}, false
+	}
+	return lookupMetadataTime(a.Metadata, MetadataArchivedAt)
+}
+
+// ClearArchive removes archive markers and restarts the staleness clock, so a restored
+// auth is not archived again by the next sweep.
+func (a *Auth) ClearArchive(now time.Time) {
+	if a == nil {
+		return
+	}
+	if a.Metadata != nil {
+		delete(a.Metadata, MetadataArchivedAt)
+		delete(a.Metadata, MetadataArchivedReason)
+		delete(a.Metadata, MetadataFailingSince)
+		a.Metadata[MetadataLastUsedAt] = now.UTC().Format(time.RFC3339)
+	}
+	a.LastUsedAt = now
+	a.FailingSince = time.Time{}
+}
+
+// authLastUsed returns the most recent known successful use, or when tracking started.
+func authLastUsed(a *Auth) time.Time {
+	last := a.LastUsedAt
+	if persisted, ok := lookupMetadataTime(a.Metadata, MetadataLastUsedAt); ok && persisted.After(last) {
+		last = persisted
+	}
+	return last
+}
+
+// authFailingSince returns the start of the current failure streak, if any.
+func authFailingSince(a *Auth) time.Time {
+	if !a.FailingSince.IsZero() {
+		return a.FailingSince
+	}
+	if !a.LastUsedAt.IsZero() {
+		// Succeeded since the last restart: any persisted streak is over.
+		return time.Time{}
+	}
+	since, _ := lookupMetadataTime(a.Metadata, MetadataFailingSince)
+	return since
+}
+
+// syncActivityMetadata copies usage timestamps into metadata at day granularity. Auths
+// never seen in use are stamped with now, so enabling the sweep does not archive every
+// auth whose file predates usage tracking. It reports whether metadata changed.
+func syncActivityMetadata(a *Auth, now time.Time) bool {
+	if a.Metadata == nil {
+		return false
+	}
+	changed := false
+	persisted, ok := lookupMetadataTime(a.Metadata, MetadataLastUsedAt)
+	switch {
+	case !a.LastUsedAt.IsZero() && (!ok || a.LastUsedAt.Sub(persisted) >= activityPersistGranularity):
+		a.Metadata[MetadataLastUsedAt] = a.LastUsedAt.UTC().Format(time.RFC3339)
+		changed = true
+	case a.LastUsedAt.IsZero() && !ok:
+		a.Metadata[MetadataLastUsedAt] = now.UTC().Format(time.RFC3339)
+		changed = true
+	}
+	since := authFailingSince(a)
+	_, hasSince := a.Metadata[MetadataFailingSince]
+	switch {
+	case since.IsZero() && hasSince:
+		delete(a.Metadata, MetadataFailingSince)
+		changed = true
+	case !since.IsZero() && !hasSince:
+		a.Metadata[MetadataFailingSince] = since.UTC().Format(time.RFC3339)
+		changed = true
+	}
+	return changed
+}
+
+// staleReason returns a non-empty reason when the auth should be archived under cfg.
+func staleReason(a *Auth, cfg internalconfig.AuthGCConfig, now time.Time) string {
+	if cfg.FailingDays > 0 {
+		if since := authFailingSince(a); !since.IsZero() && now.Sub(since) >= days(cfg.FailingDays) {
+			return fmt.Sprintf("failing for %d days", cfg.FailingDays)
+		}
+	}
+	if cfg.UnusedDays > 0 {
+		if last := authLastUsed(a); !last.IsZero() && now.Sub(last) >= days(cfg.UnusedDays) {
+			return fmt.Sprintf("unused for %d days", cfg.UnusedDays)
+		}
+	}
+	return ""
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+func isRuntimeOnly(a *Auth) bool {
+	return a.Attributes != nil && strings.EqualFold(strings.TrimSpace(a.Attributes["runtime_only"]), "true")
+}
+
+// SweepStaleAuths archives auths that have been unused or failing beyond the configured
+// thresholds and deletes archived auths past the retention period. Config-backed API key
+// auths and runtime-only auths are never touched.
+func (m *Manager) SweepStaleAuths(ctx context.Context, now time.Time) GCReport {
+	report := GCReport{Archived: []string{}, Deleted: []string{}}
+	if m == nil {
+		return report
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return report
+	}
+	gc := cfg.AuthGC
+
+	var toPersist []*Auth
+	var toDelete []string
+	m.mu.Lock()
+	for id, a := range m.auths {
+		if a == nil || a.Metadata == nil || isRuntimeOnly(a) {
+			continue
+		}
+		if archivedAt, ok := a.ArchivedAt(); ok {
+			if gc.DeleteAfterDays > 0 && now.Sub(archivedAt) >= days(gc.DeleteAfterDays) {
+				toDelete = append(toDelete, id)
+			}
+			continue
+		}
+		if a.Disabled {
+			continue
+		}
+		synced := syncActivityMetadata(a, now)
+		if reason := staleReason(a, gc, now); reason != "" {
+			a.Disabled = true
+			a.Status = StatusDisabled
+			a.StatusMessage = "archived: " + reason
+			a.Metadata[MetadataArchivedAt] = now.UTC().Format(time.RFC3339)
+			a.Metadata[MetadataArchivedReason] = reason
+			a.UpdatedAt = now
+			toPersist = append(toPersist, a.Clone())
+			report.Archived = append(report.Archived, id)
+			continue
+		}
+		if synced {
+			toPersist = append(toPersist, a.Clone())
+		}
+	}
+	store := m.store
+	m.mu.Unlock()
+
+	for _, a := range toPersist {
+		if err := m.persist(ctx, a); err != nil {
+			log.Warnf("auth gc: failed to persist %s: %v", a.ID, err)
+		}
+		if a.Disabled {
+			registry.GetGlobalRegistry().UnregisterClient(a.ID)
+			log.Infof("auth gc: archived %s (%s)", a.ID, a.StatusMessage)
+			m.hook.OnAuthUpdated(ctx, a.Clone())
+		}
+	}
+	// Archived auths leave the manager only once the store has dropped them; a failed
+	// delete keeps them listed so the next sweep retries instead of resurrecting them on reload.
+	for _, id := range toDelete {
+		if store != nil {
+			if err := store.Delete(ctx, id); err != nil {
+				log.Warnf("auth gc: failed to delete archived auth %s: %v", id, err)
+				continue
+			}
+		}
+		m.mu.Lock()
+		delete(m.auths, id)
+		m.mu.Unlock()
+		registry.GetGlobalRegistry().UnregisterClient(id)
+		log.Infof("auth gc: deleted archived auth %s", id)
+		report.Deleted = append(report.Deleted, id)
+	}
+	return report
+}
+
+// StartAuthGC launches the periodic stale auth sweep. The sweep is a no-op while
+// auth-gc is disabled, so the loop can be started unconditionally and follows config reloads.
+func (m *Manager) StartAuthGC(parent context.Context) {
+	if m == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.mu.Lock()
+	if m.gcCancel != nil {
+		m.gcCancel()
+	}
+	m.gcCancel = cancel
+	m.mu.Unlock()
+	go func() {
+		for {
+			interval := defaultAuthGCInterval
+			if cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config); cfg != nil {
+				if cfg.AuthGC.IntervalMinutes > 0 {
+					interval = time.Duration(cfg.AuthGC.IntervalMinutes) * time.Minute
+				}
+				if cfg.AuthGC.Enabled {
+					m.SweepStaleAuths(ctx, time.Now())
+				}
+			}
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// StopAuthGC cancels the stale auth sweep loop, if running.
+func (m *Manager) StopAuthGC() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.gcCancel != nil {
+		m.gcCancel()
+		m.gcCancel = nil
+	}
+}
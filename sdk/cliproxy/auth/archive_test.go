@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+type deletingStore struct {
+	countingStore
+	deleted   []string
+	deleteErr error
+}
+
+func (s *deletingStore) Delete(_ context.Context, id string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func TestSweepStaleAuthsArchivesAndDeletes(t *testing.T) {
+	store := &deletingStore{}
+	mgr := NewManager(store, nil, nil)
+	mgr.SetConfig(&internalconfig.Config{AuthGC: internalconfig.AuthGCConfig{
+		Enabled:         true,
+		UnusedDays:      30,
+		FailingDays:     3,
+		DeleteAfterDays: 7,
+	}})
+	now := time.Now()
+	ctx := context.Background()
+	stamp := func(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+
+	_, _ = mgr.Register(ctx, &Auth{ID: "fresh", Provider: "codex", Metadata: map[string]any{}})
+	_, _ = mgr.Register(ctx, &Auth{ID: "idle", Provider: "codex", Metadata: map[string]any{
+		MetadataLastUsedAt: stamp(now.Add(-31 * 24 * time.Hour)),
+	}})
+	_, _ = mgr.Register(ctx, &Auth{ID: "failing", Provider: "codex", Metadata: map[string]any{
+		MetadataLastUsedAt:   stamp(now.Add(-5 * 24 * time.Hour)),
+		MetadataFailingSince: stamp(now.Add(-4 * 24 * time.Hour)),
+	}})
+	_, _ = mgr.Register(ctx, &Auth{ID: "expired", Provider: "codex", Disabled: true, Status: StatusDisabled, Metadata: map[string]any{
+		MetadataArchivedAt: stamp(now.Add(-8 * 24 * time.Hour)),
+	}})
+	_, _ = mgr.Register(ctx, &Auth{ID: "manual", Provider: "codex", Disabled: true, Status: StatusDisabled, Metadata: map[string]any{
+		MetadataLastUsedAt: stamp(now.Add(-90 * 24 * time.Hour)),
+	}})
+
+	report := mgr.SweepStaleAuths(ctx, now)
+
+	archived := map[string]bool{}
+	for _, id := range report.Archived {
+		archived[id] = true
+	}
+	if len(archived) != 2 || !archived["idle"] || !archived["failing"] {
+		t.Fatalf("archived = %v, want idle and failing", report.Archived)
+	}
+	if len(report.Deleted) != 1 || report.Deleted[0] != "expired" || len(store.deleted) != 1 {
+		t.Fatalf("deleted = %v (store %v), want [expired]", report.Deleted, store.deleted)
+	}
+	if _, ok := mgr.GetByID("expired"); ok {
+		t.Fatal("expected deleted auth to be removed from manager")
+	}
+
+	idle, _ := mgr.GetByID("idle")
+	if !idle.Disabled || idle.Status != StatusDisabled {
+		t.Fatalf("idle auth not disabled: %+v", idle)
+	}
+	if _, ok := idle.ArchivedAt(); !ok {
+		t.Fatal("expected archived_at on idle auth")
+	}
+	fresh, _ := mgr.GetByID("fresh")
+	if fresh.Disabled {
+		t.Fatal("fresh auth should stay active")
+	}
+	if _, ok := fresh.Metadata[MetadataLastUsedAt]; !ok {
+		t.Fatal("expected tracking start to be stamped on fresh auth")
+	}
+	if manual, _ := mgr.GetByID("manual"); manual == nil {
+		t.Fatal("manually disabled auth must not be deleted")
+	}
+
+	idle.Disabled = false
+	idle.Status = StatusActive
+	idle.ClearArchive(now)
+	_, _ = mgr.Update(ctx, idle)
+	if report = mgr.SweepStaleAuths(ctx, now.Add(time.Hour)); len(report.Archived) != 0 {
+		t.Fatalf("restored auth re-archived: %v", report.Archived)
+	}
+}
+
+func TestSweepStaleAuthsKeepsAuthWhenStoreDeleteFails(t *testing.T) {
+	store := &deletingStore{deleteErr: errors.New("disk full")}
+	mgr := NewManager(store, nil, nil)
+	mgr.SetConfig(&internalconfig.Config{AuthGC: internalconfig.AuthGCConfig{Enabled: true, DeleteAfterDays: 7}})
+	now := time.Now()
+	ctx := context.Background()
+	_, _ = mgr.Register(ctx, &Auth{ID: "expired", Provider: "codex", Disabled: true, Status: StatusDisabled, Metadata: map[string]any{
+		MetadataArchivedAt: now.Add(-8 * 24 * time.Hour).UTC().Format(time.RFC3339),
+	}})
+
+	if report := mgr.SweepStaleAuths(ctx, now); len(report.Deleted) != 0 {
+		t.Fatalf("deleted = %v, want none while the store fails", report.Deleted)
+	}
+	if _, ok := mgr.GetByID("expired"); !ok {
+		t.Fatal("auth must stay in the manager when the store delete fails")
+	}
+
+	store.deleteErr = nil
+	if report := mgr.SweepStaleAuths(ctx, now); len(report.Deleted) != 1 || report.Deleted[0] != "expired" {
+		t.Fatalf("deleted = %v, want the retried auth", report.Deleted)
+	}
+	if _, ok := mgr.GetByID("expired"); ok {
+		t.Fatal("expected auth to be removed once the store delete succeeds")
+	}
+}
+
+func TestMarkResultTracksUsageAndFailureStreak(t *testing.T) {
+	mgr := NewManager(nil, nil, nil)
+	ctx := context.Background()
+	_, _ = mgr.Register(ctx, &Auth{ID: "a", Provider: "codex"})
+
+	mgr.MarkResult(ctx, Result{AuthID: "a", Provider: "codex", Error: &Error{Message: "quota", HTTPStatus: 429}})
+	mgr.MarkResult(ctx, Result{AuthID: "a", Provider: "codex", Error: &Error{Message: "bad request", HTTPStatus: 400}})
+	auth, _ := mgr.GetByID("a")
+	if !auth.FailingSince.IsZero() {
+		t.Fatal("quota and request errors must not start a failure streak")
+	}
+
+	mgr.MarkResult(ctx, Result{AuthID: "a", Provider: "codex", Error: &Error{Message: "unauthorized", HTTPStatus: 401}})
+	auth, _ = mgr.GetByID("a")
+	if auth.FailingSince.IsZero() {
+		t.Fatal("expected failure streak to start")
+	}
+	first := auth.FailingSince
+	mgr.MarkResult(ctx, Result{AuthID: "a", Provider: "codex", Error: &Error{Message: "forbidden", HTTPStatus: 403}})
+	if auth, _ = mgr.GetByID("a"); !auth.FailingSince.Equal(first) {
+		t.Fatal("failure streak start should not move on repeated failures")
+	}
+
+	mgr.MarkResult(ctx, Result{AuthID: "a", Provider: "codex", Success: true})
+	auth, _ = mgr.GetByID("a")
+	if auth.LastUsedAt.IsZero() || !auth.FailingSince.IsZero() {
+		t.Fatalf("success should record use and end streak: %+v", auth)
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AvailabilityWindow is the granularity of availability tracking. A window with traffic
+// counts as failed when most of its upstream requests failed.
+const AvailabilityWindow = 5 * time.Minute
+
+// availabilityWindows covers the longest reported period, 30 days.
+const availabilityWindows = int(30 * 24 * time.Hour / AvailabilityWindow)
+
+// availabilityPeriods are the periods of an availability report, shortest first.
+var availabilityPeriods = []struct {
+	name   string
+	length time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// ProviderAvailability reports how reliably a provider served requests over recent periods.
+type ProviderAvailability struct {
+	Provider string                        `json:"provider"`
+	Periods  map[string]AvailabilityPeriod `json:"periods"`
+}
+
+// AvailabilityPeriod summarises a provider's windows within one period. Availability is the
+// percentage of windows with traffic that did not fail, and nil without traffic.
+type AvailabilityPeriod struct {
+	Availability  *float64 `json:"availability"`
+	Windows       int      `json:"windows"`
+	FailedWindows int      `json:"failed_windows"`
+	Requests      int      `json:"requests"`
+	Failures      int      `json:"failures"`
+}
+
+type availabilityBucket struct {
+	slot     int64
+	requests int
+	failures int
+}
+
+func (b availabilityBucket) failed() bool {
+	return b.requests > 0 && b.failures*2 > b.requests
+}
+
+// availabilityTracker keeps per-provider request outcomes in fixed windows for 30 days.
+type availabilityTracker struct {
+	mu        sync.Mutex
+	since     time.Time
+	providers map[string]*[availabilityWindows]availabilityBucket
+}
+
+func (t *availabilityTracker) record(provider string, failed bool, now time.Time) {
+	slot := now.UnixNano() / int64(AvailabilityWindow)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.providers == nil {
+		t.providers = make(map[string]*[availabilityWindows]availabilityBucket)
+		t.since = now
+	}
+	buckets := t.providers[provider]
+	if buckets == nil {
+		buckets = new([availabilityWindows]availabilityBucket)
+		t.providers[provider] = buckets
+	}
+	b := &buckets[slot%int64(availabilityWindows)]
+	if b.slot != slot {
+		*b = availabilityBucket{slot: slot}
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+}
+
+func (t *availabilityTracker) report(now time.Time) ([]ProviderAvailability, time.Time) {
+	current := now.UnixNano() / int64(AvailabilityWindow)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]ProviderAvailability, 0, len(t.providers))
+	for provider, buckets := range t.providers {
+		entry := ProviderAvailability{Provider: provider, Periods: make(map[string]AvailabilityPeriod, len(availabilityPeriods))}
+		for _, period := range availabilityPeriods {
+			oldest := current - int64(period.length/AvailabilityWindow)
+			var p AvailabilityPeriod
+			for _, b := range buckets {
+				if b.slot <= oldest || b.slot > current || b.requests == 0 {
+					continue
+				}
+				p.Windows++
+				p.Requests += b.requests
+				p.Failures += b.failures
+				if b.failed() {
+					p.FailedWindows++
+				}
+			}
+			if p.Windows > 0 {
+				availability := float64(p.Windows-p.FailedWindows) * 100 / float64(p.Windows)
+				p.Availability = &availability
+			}
+			entry.Periods[period.name] = p
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out, t.since
+}
+
+// recordAvailability feeds an execution result into the provider availability windows.
+// Only upstream failures (5xx and network errors) count against a provider.
+func (m *Manager) recordAvailability(result Result) {
+	if result.Provider == "" {
+		return
+	}
+	m.availability.record(strings.ToLower(result.Provider), upstreamFailure(result), time.Now())
+}
+
+// AvailabilityReport returns the availability of each provider over the last 24 hours, 7
+// days and 30 days, and when tracking started. Tracking is in memory and restarts with the
+// process.
+func (m *Manager) AvailabilityReport() ([]ProviderAvailability, time.Time) {
+	return m.availability.report(time.Now())
+}
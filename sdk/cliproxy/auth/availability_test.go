@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAvailabilityReportCountsFailedWindows(t *testing.T) {
+	var tracker availabilityTracker
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	// Two days ago: one healthy window.
+	tracker.record("claude", false, now.Add(-48*time.Hour))
+	// Within the last day: a healthy window and a window where most requests failed.
+	tracker.record("claude", false, now.Add(-time.Hour))
+	tracker.record("claude", true, now.Add(-time.Hour))
+	tracker.record("claude", true, now.Add(-10*time.Minute))
+	tracker.record("claude", true, now.Add(-10*time.Minute))
+	tracker.record("claude", false, now.Add(-10*time.Minute))
+
+	report, since := tracker.report(now)
+	if len(report) != 1 || !since.Equal(now.Add(-48*time.Hour)) {
+		t.Fatalf("report = %+v since %s", report, since)
+	}
+	day := report[0].Periods["24h"]
+	if day.Windows != 2 || day.FailedWindows != 1 || day.Requests != 5 || day.Failures != 3 || day.Availability == nil || *day.Availability != 50 {
+		t.Fatalf("24h = %+v", day)
+	}
+	week := report[0].Periods["7d"]
+	if week.Windows != 3 || week.FailedWindows != 1 || week.Availability == nil || *week.Availability < 66.6 || *week.Availability > 66.7 {
+		t.Fatalf("7d = %+v", week)
+	}
+
+	later, _ := tracker.report(now.Add(31 * 24 * time.Hour))
+	if month := later[0].Periods["30d"]; month.Windows != 0 || month.Availability != nil {
+		t.Fatalf("30d after the data aged out = %+v", month)
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	// clockDriftTolerance is the offset below which a provider's clock counts as aligned; Date
+	// headers only have a resolution of one second and the network adds latency.
+	clockDriftTolerance = 2 * time.Second
+	// clockDriftWeight is the weight of a new observation in the smoothed offset.
+	clockDriftWeight = 0.25
+)
+
+var clockDrift = struct {
+	mu      sync.RWMutex
+	offsets map[string]time.Duration
+}{offsets: make(map[string]time.Duration)}
+
+// ObserveServerDate records the Date header of a response from provider to a request sent
+// at sent and answered at received. The server is assumed to have stamped the response
+// halfway through the round trip.
+func ObserveServerDate(provider, date string, sent, received time.Time) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" || date == "" {
+		return
+	}
+	serverTime, errParse := http.ParseTime(date)
+	if errParse != nil {
+		return
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := serverTime.Sub(local)
+	clockDrift.mu.Lock()
+	defer clockDrift.mu.Unlock()
+	if previous, ok := clockDrift.offsets[provider]; ok {
+		offset = previous + time.Duration(float64(offset-previous)*clockDriftWeight)
+	}
+	clockDrift.offsets[provider] = offset
+}
+
+// ClockDrift returns how far the clock of provider's servers is ahead of the local clock, as
+// observed from response Date headers. It is zero while the clocks agree within tolerance or
+// no response was observed.
+func ClockDrift(provider string) time.Duration {
+	clockDrift.mu.RLock()
+	offset := clockDrift.offsets[strings.ToLower(strings.TrimSpace(provider))]
+	clockDrift.mu.RUnlock()
+	if offset > -clockDriftTolerance && offset < clockDriftTolerance {
+		return 0
+	}
+	return offset
+}
+
+// RefreshSkew returns how long before expiry a token of provider is refreshed: the
+// configured skew, or fallback when none is configured, widened by the detected clock drift
+// when drift detection is enabled. Widening by the drift keeps tokens fresh whether their
+// expiry was computed on the local clock or stamped by the provider.
+func RefreshSkew(cfg *internalconfig.Config, provider string, fallback time.Duration) time.Duration {
+	if cfg == nil {
+		return fallback
+	}
+	skew := fallback
+	if seconds := cfg.RefreshLimits.For(provider).SkewSeconds; seconds > 0 {
+		skew = time.Duration(seconds) * time.Second
+	}
+	if cfg.RefreshLimits.DetectClockDrift {
+		drift := ClockDrift(provider)
+		if drift < 0 {
+			drift = -drift
+		}
+		skew += drift
+	}
+	return skew
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestClockDriftWidensRefreshSkew(t *testing.T) {
+	sent := time.Now()
+	received := sent.Add(200 * time.Millisecond)
+	ObserveServerDate("drift-ahead", sent.Add(90*time.Second).UTC().Format(http.TimeFormat), sent, received)
+	ObserveServerDate("drift-aligned", sent.UTC().Format(http.TimeFormat), sent, received)
+
+	if drift := ClockDrift("drift-ahead"); drift < 88*time.Second || drift > 91*time.Second {
+		t.Fatalf("ClockDrift = %s, want about 90s", drift)
+	}
+	if drift := ClockDrift("drift-aligned"); drift != 0 {
+		t.Fatalf("ClockDrift of an aligned provider = %s", drift)
+	}
+
+	cfg := &internalconfig.Config{RefreshLimits: internalconfig.RefreshLimitsConfig{
+		Providers: map[string]internalconfig.RefreshLimitSet{"drift-ahead": {SkewSeconds: 600}},
+	}}
+	if skew := RefreshSkew(cfg, "drift-ahead", time.Hour); skew != 10*time.Minute {
+		t.Fatalf("RefreshSkew without drift detection = %s, want the configured skew", skew)
+	}
+	if skew := RefreshSkew(cfg, "drift-aligned", time.Hour); skew != time.Hour {
+		t.Fatalf("RefreshSkew without configured skew = %s, want the fallback", skew)
+	}
+	cfg.RefreshLimits.DetectClockDrift = true
+	if skew := RefreshSkew(cfg, "drift-ahead", time.Hour); skew < 11*time.Minute+28*time.Second || skew > 11*time.Minute+31*time.Second {
+		t.Fatalf("RefreshSkew with drift detection = %s, want the skew plus the drift", skew)
+	}
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// clusterRefreshLockTTL bounds how long one instance may hold the refresh lock of an auth.
+	clusterRefreshLockTTL = 2 * time.Minute
+	// clusterRefreshMarkerTTL is how long the marker of an auth's last refresh is kept.
+	clusterRefreshMarkerTTL = 30 * 24 * time.Hour
+	// clusterOffsetLeaseSize is how many round-robin positions an instance takes from the
+	// shared counter at once and hands out locally.
+	clusterOffsetLeaseSize = 100
+	// clusterOffsetBackoff is how long round-robin stays node-local after the coordinator
+	// failed to lease positions.
+	clusterOffsetBackoff = 30 * time.Second
+)
+
+// Coordinator shares credential usage between the proxy instances of a cluster, so
+// independent replicas holding the same credentials do not burn them twice.
+type Coordinator interface {
+	// LeaseOffsets adds n to the shared counter named key and returns its new value, leasing
+	// the n positions up to it to the caller. It must not be retried on failure, and should
+	// fail fast when the shared store is slow.
+	LeaseOffsets(key string, n int64) (int64, error)
+	// TryLock acquires the lock named key for ttl. ok is false while another instance holds
+	// it; unlock releases a lock that was acquired.
+	TryLock(key string, ttl time.Duration) (unlock func(), ok bool, err error)
+	// Get returns the shared value named key; ok is false when it is not set.
+	Get(key string) (value string, ok bool, err error)
+	// Set stores the shared value named key for ttl.
+	Set(key, value string, ttl time.Duration) error
+}
+
+type coordinatorHolder struct{ Coordinator }
+
+var clusterCoordinator atomic.Pointer[coordinatorHolder]
+
+// SetCoordinator makes round-robin selection and token refreshes coordinate through c.
+// A nil c returns to node-local coordination.
+func SetCoordinator(c Coordinator) {
+	resetClusterOffsets()
+	if c == nil {
+		clusterCoordinator.Store(nil)
+		return
+	}
+	clusterCoordinator.Store(&coordinatorHolder{c})
+}
+
+func currentCoordinator() Coordinator {
+	if holder := clusterCoordinator.Load(); holder != nil {
+		return holder.Coordinator
+	}
+	return nil
+}
+
+// offsetLease is a range of shared round-robin positions leased to this instance.
+type offsetLease struct {
+	next, end int64
+}
+
+// clusterOffsets hands out leased round-robin positions. Only one lease per key is requested
+// at a time; picks made meanwhile, and for clusterOffsetBackoff after a failed lease, use the
+// selector's local cursor, so a slow or unreachable coordinator never holds up selection.
+var clusterOffsets = struct {
+	mu        sync.Mutex
+	leases    map[string]*offsetLease
+	leasing   map[string]bool
+	openUntil time.Time
+}{leases: make(map[string]*offsetLease), leasing: make(map[string]bool)}
+
+// clusterOffset returns the next shared round-robin position for key, or false when the
+// proxy is not clustered or no shared position is at hand.
+func clusterOffset(key string) (int, bool) {
+	c := currentCoordinator()
+	if c == nil {
+		return 0, false
+	}
+	now := time.Now()
+	clusterOffsets.mu.Lock()
+	if lease := clusterOffsets.leases[key]; lease != nil && lease.next <= lease.end {
+		n := lease.next
+		lease.next++
+		clusterOffsets.mu.Unlock()
+		return offsetIndex(n), true
+	}
+	if clusterOffsets.leasing[key] || now.Before(clusterOffsets.openUntil) {
+		clusterOffsets.mu.Unlock()
+		return 0, false
+	}
+	clusterOffsets.leasing[key] = true
+	clusterOffsets.mu.Unlock()
+
+	end, err := c.LeaseOffsets("rr:"+key, clusterOffsetLeaseSize)
+
+	clusterOffsets.mu.Lock()
+	defer clusterOffsets.mu.Unlock()
+	delete(clusterOffsets.leasing, key)
+	if err != nil {
+		clusterOffsets.openUntil = time.Now().Add(clusterOffsetBackoff)
+		log.Debugf("cluster: shared round-robin offsets unavailable, using local offsets for %s: %v", clusterOffsetBackoff, err)
+		return 0, false
+	}
+	start := end - clusterOffsetLeaseSize + 1
+	clusterOffsets.leases[key] = &offsetLease{next: start + 1, end: end}
+	return offsetIndex(start), true
+}
+
+// resetClusterOffsets drops leased positions, e.g. when the coordinator changes.
+func resetClusterOffsets() {
+	clusterOffsets.mu.Lock()
+	clusterOffsets.leases = make(map[string]*offsetLease)
+	clusterOffsets.leasing = make(map[string]bool)
+	clusterOffsets.openUntil = time.Time{}
+	clusterOffsets.mu.Unlock()
+}
+
+func offsetIndex(n int64) int {
+	if n < 1 {
+		n = 1
+	}
+	return int((n - 1) % 2_147_483_640)
+}
+
+// acquireRefreshLock claims the refresh of an auth for this instance. It returns false when
+// another instance of the cluster is refreshing it; an unreachable coordinator does not
+// block refreshes.
+func acquireRefreshLock(authID string) (func(), bool) {
+	c := currentCoordinator()
+	if c == nil {
+		return func() {}, true
+	}
+	unlock, ok, err := c.TryLock("refresh:"+authID, clusterRefreshLockTTL)
+	if err != nil {
+		log.Debugf("cluster: refresh lock unavailable for %s, refreshing anyway: %v", authID, err)
+		return func() {}, true
+	}
+	if !ok {
+		return nil, false
+	}
+	return unlock, true
+}
+
+// publishRefreshMarker records that this instance just refreshed authID, so instances that
+// refresh it next reload the new token instead of spending their stale refresh token. It
+// returns the marker, or "" when the proxy is not clustered or the marker was not stored.
+func publishRefreshMarker(authID string) string {
+	c := currentCoordinator()
+	if c == nil {
+		return ""
+	}
+	marker := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+	if err := c.Set("refreshed:"+authID, marker, clusterRefreshMarkerTTL); err != nil {
+		log.Debugf("cluster: failed to record refresh of %s: %v", authID, err)
+		return ""
+	}
+	return marker
+}
+
+// peerRefreshMarker returns the marker of the last refresh of authID by any instance, or ""
+// when there is none or it cannot be read.
+func peerRefreshMarker(authID string) string {
+	c := currentCoordinator()
+	if c == nil {
+		return ""
+	}
+	marker, ok, err := c.Get("refreshed:" + authID)
+	if err != nil {
+		log.Debugf("cluster: refresh marker of %s unavailable: %v", authID, err)
+		return ""
+	}
+	if !ok {
+		return ""
+	}
+	return marker
+}
+
+func (m *Manager) setRefreshMarker(authID, marker string) {
+	m.mu.Lock()
+	if m.refreshMarkers == nil {
+		m.refreshMarkers = make(map[string]string)
+	}
+	m.refreshMarkers[authID] = marker
+	m.mu.Unlock()
+}
+
+// reloadPeerRefresh reloads auth from the store when another instance refreshed it since
+// this instance last refreshed or loaded it, and reports whether it did. The caller holds
+// the cluster refresh lock of auth.
+func (m *Manager) reloadPeerRefresh(ctx context.Context, auth *Auth) bool {
+	marker := peerRefreshMarker(auth.ID)
+	if marker == "" {
+		return false
+	}
+	m.mu.RLock()
+	known := m.refreshMarkers[auth.ID]
+	store := m.store
+	m.mu.RUnlock()
+	if marker == known || store == nil {
+		return false
+	}
+	items, err := store.List(ctx)
+	if err != nil {
+		log.Warnf("cluster: failed to reload %s refreshed by another instance: %v", auth.ID, err)
+		return false
+	}
+	for _, stored := range items {
+		if stored == nil || stored.ID != auth.ID {
+			continue
+		}
+		reloaded := auth.Clone()
+		reloaded.Metadata = stored.Metadata
+		reloaded.Attributes = stored.Attributes
+		if stored.Runtime != nil {
+			reloaded.Runtime = stored.Runtime
+		}
+		now := time.Now()
+		reloaded.LastRefreshedAt = now
+		reloaded.NextRefreshAfter = time.Time{}
+		reloaded.LastError = nil
+		reloaded.UpdatedAt = now
+		// The store already holds the peer's token; writing it back is unnecessary.
+		_, _ = m.Update(WithSkipPersist(ctx), reloaded)
+		m.setRefreshMarker(auth.ID, marker)
+		log.Debugf("cluster: reloaded %s, %s refreshed by another instance", auth.Provider, auth.ID)
+		return true
+	}
+	return false
+}
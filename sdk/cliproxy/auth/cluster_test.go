@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type memoryCoordinator struct {
+	mu      sync.Mutex
+	offsets map[string]int64
+	locks   map[string]bool
+	values  map[string]string
+	leases  int
+	err     error
+}
+
+func (c *memoryCoordinator) LeaseOffsets(key string, n int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leases++
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.offsets[key] += n
+	return c.offsets[key], nil
+}
+
+func (c *memoryCoordinator) TryLock(key string, _ time.Duration) (func(), bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.locks[key] {
+		return nil, false, nil
+	}
+	c.locks[key] = true
+	return func() {
+		c.mu.Lock()
+		delete(c.locks, key)
+		c.mu.Unlock()
+	}, true, nil
+}
+
+func (c *memoryCoordinator) Get(key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	return value, ok, nil
+}
+
+func (c *memoryCoordinator) Set(key, value string, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]string)
+	}
+	c.values[key] = value
+	return nil
+}
+
+// sharedTokenStore is a token store shared by several managers.
+type sharedTokenStore struct {
+	mu    sync.Mutex
+	auths map[string]*Auth
+}
+
+func (s *sharedTokenStore) List(context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Auth, 0, len(s.auths))
+	for _, auth := range s.auths {
+		out = append(out, auth.Clone())
+	}
+	return out, nil
+}
+
+func (s *sharedTokenStore) Save(_ context.Context, auth *Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths[auth.ID] = auth.Clone()
+	return auth.ID, nil
+}
+
+func (s *sharedTokenStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.auths, id)
+	return nil
+}
+
+// rotatingRefreshExecutor rotates the refresh token like OAuth providers do.
+type rotatingRefreshExecutor struct {
+	refreshCountingExecutor
+	refreshes int
+}
+
+func (e *rotatingRefreshExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.refreshes++
+	updated := auth.Clone()
+	updated.Metadata["refresh_token"] = fmt.Sprintf("token-%d", e.refreshes+1)
+	return updated, nil
+}
+
+func TestRefreshReloadsTokenRefreshedByPeer(t *testing.T) {
+	SetCoordinator(&memoryCoordinator{offsets: make(map[string]int64), locks: make(map[string]bool)})
+	defer SetCoordinator(nil)
+
+	ctx := context.Background()
+	store := &sharedTokenStore{auths: make(map[string]*Auth)}
+	exec := &rotatingRefreshExecutor{}
+	replicas := []*Manager{NewManager(store, nil, nil), NewManager(store, nil, nil)}
+	for _, m := range replicas {
+		m.RegisterExecutor(exec)
+		_, _ = m.Register(ctx, &Auth{ID: "a", Provider: "antigravity", Metadata: map[string]any{"refresh_token": "token-1"}})
+	}
+
+	replicas[0].refreshAuth(ctx, "a")
+	replicas[1].refreshAuth(ctx, "a")
+	if exec.refreshes != 1 {
+		t.Fatalf("a peer's refresh must be reloaded, not repeated; got %d refreshes", exec.refreshes)
+	}
+	reloaded, _ := replicas[1].GetByID("a")
+	if got := reloaded.Metadata["refresh_token"]; got != "token-2" {
+		t.Fatalf("replica kept refresh token %v, want token-2", got)
+	}
+
+	replicas[1].refreshAuth(ctx, "a")
+	if exec.refreshes != 2 {
+		t.Fatalf("an auth nobody else refreshed must be refreshed, got %d refreshes", exec.refreshes)
+	}
+}
+
+func TestRoundRobinRotatesAcrossClusterInstances(t *testing.T) {
+	coordinator := &memoryCoordinator{offsets: make(map[string]int64), locks: make(map[string]bool)}
+	SetCoordinator(coordinator)
+	defer SetCoordinator(nil)
+
+	auths := []*Auth{{ID: "a", Provider: "gemini"}, {ID: "b", Provider: "gemini"}, {ID: "c", Provider: "gemini"}}
+	replicas := []*RoundRobinSelector{{}, {}}
+	var picked []string
+	for i := 0; i < 4; i++ {
+		auth, err := replicas[i%2].Pick(context.Background(), "gemini", "gemini-2.5-pro", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked = append(picked, auth.ID)
+	}
+	if got := picked[0] + picked[1] + picked[2] + picked[3]; got != "abca" {
+		t.Fatalf("instances did not share the rotation: %v", picked)
+	}
+
+	unlock, ok := acquireRefreshLock("a")
+	if !ok {
+		t.Fatal("refresh lock not acquired")
+	}
+	if _, ok = acquireRefreshLock("a"); ok {
+		t.Fatal("a second refresh of the same auth was allowed")
+	}
+	unlock()
+	if _, ok = acquireRefreshLock("a"); !ok {
+		t.Fatal("refresh lock was not released")
+	}
+}
+
+func TestRoundRobinLeasesSharedOffsetsAndFallsBackLocally(t *testing.T) {
+	coordinator := &memoryCoordinator{offsets: make(map[string]int64), locks: make(map[string]bool)}
+	SetCoordinator(coordinator)
+	defer SetCoordinator(nil)
+
+	auths := []*Auth{{ID: "a", Provider: "gemini"}, {ID: "b", Provider: "gemini"}}
+	selector := &RoundRobinSelector{}
+	for i := 0; i < clusterOffsetLeaseSize+1; i++ {
+		if _, err := selector.Pick(context.Background(), "gemini", "gemini-2.5-pro", cliproxyexecutor.Options{}, auths); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if coordinator.leases != 2 || coordinator.offsets["rr:gemini:gemini-2.5-pro"] != 2*clusterOffsetLeaseSize {
+		t.Fatalf("expected two leases of %d positions, got %d leases and counter %d", clusterOffsetLeaseSize, coordinator.leases, coordinator.offsets["rr:gemini:gemini-2.5-pro"])
+	}
+
+	coordinator.err = errors.New("redis unreachable")
+	resetClusterOffsets()
+	var picked []string
+	for i := 0; i < 4; i++ {
+		auth, err := selector.Pick(context.Background(), "gemini", "gemini-2.5-flash", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked = append(picked, auth.ID)
+	}
+	if coordinator.leases != 3 {
+		t.Fatalf("a failed lease must not be retried while backing off, got %d lease calls", coordinator.leases)
+	}
+	if got := picked[0] + picked[1] + picked[2] + picked[3]; got != "abab" {
+		t.Fatalf("local rotation expected while the coordinator is down, got %v", picked)
+	}
+}
@@ -19,8 +19,10 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -176,11 +178,31 @@ type Manager struct {
 	// It is initialized in NewManager; never Load() before first Store().
 	runtimeConfig atomic.Value
 
+	// selectionLog keeps sampled selector decisions when routing.decision-log is enabled.
+	selectionLog selectionLog
+
+	// shedder tracks provider error rates for load-shedding.
+	shedder loadShedder
+	// standby remembers when standby providers were last pre-warmed.
+	standby standbyPrewarm
+	// availability records per-provider outcomes for availability reports.
+	availability availabilityTracker
+	// sharedStates is the quota store model states are exchanged through with other
+	// replicas, or nil when they are not shared.
+	sharedStates atomic.Pointer[quota.Store]
+
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
 	// Auto refresh state
 	refreshCancel context.CancelFunc
+	// refreshes spreads background refreshes out per provider.
+	refreshes refreshLimiter
+	// refreshMarkers holds, per auth, the cluster refresh marker this instance last wrote or
+	// loaded; guarded by mu.
+	refreshMarkers map[string]string
+	// gcCancel stops the stale auth sweep loop.
+	gcCancel context.CancelFunc
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -224,6 +246,13 @@ func (m *Manager) SetStore(store Store) {
 	m.store = store
 }
 
+// Store returns the underlying persistence store, or nil when none is configured.
+func (m *Manager) Store() Store {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.store
+}
+
 // SetRoundTripperProvider register a provider that returns a per-auth RoundTripper.
 func (m *Manager) SetRoundTripperProvider(p RoundTripperProvider) {
 	m.mu.Lock()
@@ -319,8 +348,7 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 		}
 
 		byAlias := make(map[string]string)
-		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
-		switch provider {
+		switch apiKeyAliasProvider(auth) {
 		case "gemini":
 			if entry := resolveGeminiAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
@@ -329,6 +357,18 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 			if entry := resolveClaudeAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
+		case "bedrock":
+			if entry := resolveBedrockAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "azure-openai":
+			if entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Deployments)
+			}
+		case "openrouter":
+			if entry := resolveOpenRouterAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
 		case "codex":
 			if entry := resolveCodexAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
@@ -470,6 +510,14 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.Index = existing.Index
 		auth.indexAssigned = existing.indexAssigned
 	}
+	if existing, ok := m.auths[auth.ID]; ok && existing != nil {
+		if auth.LastUsedAt.IsZero() {
+			auth.LastUsedAt = existing.LastUsedAt
+		}
+		if auth.FailingSince.IsZero() && auth.LastUsedAt.Equal(existing.LastUsedAt) {
+			auth.FailingSince = existing.FailingSince
+		}
+	}
 	auth.EnsureIndex()
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
@@ -514,6 +562,17 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	ctx, span := startExecuteSpan(ctx, "cliproxy.execute", normalized, req.Model)
+	defer span.End()
+	ctx = withRetryBudget(ctx, opts)
+
+	m.prewarmStandby(normalized, req.Model)
+	normalized, errShed := m.applyLoadShedding(ctx, normalized)
+	if errShed != nil {
+		span.RecordError(errShed)
+		return cliproxyexecutor.Response{}, errShed
+	}
+
 	_, maxWait := m.retrySettings()
 
 	var lastErr error
@@ -524,14 +583,16 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !attemptsLeft(ctx) {
 			break
 		}
+		span.SetAttributes(tracing.Int("cliproxy.retry_rounds", attempt+1))
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
 	}
 	if lastErr != nil {
+		span.RecordError(lastErr)
 		return cliproxyexecutor.Response{}, lastErr
 	}
 	return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
@@ -545,6 +606,17 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	ctx, span := startExecuteSpan(ctx, "cliproxy.count_tokens", normalized, req.Model)
+	defer span.End()
+	ctx = withRetryBudget(ctx, opts)
+
+	m.prewarmStandby(normalized, req.Model)
+	normalized, errShed := m.applyLoadShedding(ctx, normalized)
+	if errShed != nil {
+		span.RecordError(errShed)
+		return cliproxyexecutor.Response{}, errShed
+	}
+
 	_, maxWait := m.retrySettings()
 
 	var lastErr error
@@ -555,14 +627,16 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !attemptsLeft(ctx) {
 			break
 		}
+		span.SetAttributes(tracing.Int("cliproxy.retry_rounds", attempt+1))
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
 	}
 	if lastErr != nil {
+		span.RecordError(lastErr)
 		return cliproxyexecutor.Response{}, lastErr
 	}
 	return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
@@ -576,24 +650,37 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	ctx, span := startExecuteSpan(ctx, "cliproxy.execute_stream", normalized, req.Model)
+	defer span.End()
+	ctx = withRetryBudget(ctx, opts)
+
+	m.prewarmStandby(normalized, req.Model)
+	normalized, errShed := m.applyLoadShedding(ctx, normalized)
+	if errShed != nil {
+		span.RecordError(errShed)
+		return nil, errShed
+	}
+
 	_, maxWait := m.retrySettings()
 
 	var lastErr error
 	for attempt := 0; ; attempt++ {
-		chunks, errStream := m.executeStreamMixedOnce(ctx, normalized, req, opts)
+		chunks, errStream := m.executeStreamOnce(ctx, normalized, req, opts)
 		if errStream == nil {
 			return chunks, nil
 		}
 		lastErr = errStream
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !attemptsLeft(ctx) {
 			break
 		}
+		span.SetAttributes(tracing.Int("cliproxy.retry_rounds", attempt+1))
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return nil, errWait
 		}
 	}
 	if lastErr != nil {
+		span.RecordError(lastErr)
 		return nil, lastErr
 	}
 	return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
@@ -609,6 +696,9 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	activeProviders := append([]string(nil), providers...)
 	var lastErr error
 	for {
+		if !takeAttempt(ctx) && lastErr != nil {
+			return cliproxyexecutor.Response{}, lastErr
+		}
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, activeProviders, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
@@ -617,6 +707,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			return cliproxyexecutor.Response{}, errPick
 		}
 
+		logging.MarkDebugRequest(ctx, provider, auth.ID)
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 
@@ -630,7 +721,10 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		execCtx, attemptSpan := startAttemptSpan(execCtx, auth, provider, execReq.Model)
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		attemptSpan.RecordError(errExec)
+		attemptSpan.End()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -670,6 +764,9 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	activeProviders := append([]string(nil), providers...)
 	var lastErr error
 	for {
+		if !takeAttempt(ctx) && lastErr != nil {
+			return cliproxyexecutor.Response{}, lastErr
+		}
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, activeProviders, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
@@ -678,6 +775,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			return cliproxyexecutor.Response{}, errPick
 		}
 
+		logging.MarkDebugRequest(ctx, provider, auth.ID)
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 
@@ -691,7 +789,10 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		execCtx, attemptSpan := startAttemptSpan(execCtx, auth, provider, execReq.Model)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		attemptSpan.RecordError(errExec)
+		attemptSpan.End()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -721,7 +822,9 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	}
 }
 
-func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+// executeStreamMixedOnce starts the stream on the first auth that accepts it. claims, when
+// set, shares the auths used with concurrent attempts of the same hedged request.
+func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, claims *streamClaims) (<-chan cliproxyexecutor.StreamChunk, error) {
 	if len(providers) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
@@ -731,6 +834,10 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	activeProviders := append([]string(nil), providers...)
 	var lastErr error
 	for {
+		if !takeAttempt(ctx) && lastErr != nil {
+			return nil, lastErr
+		}
+		claims.merge(tried)
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, activeProviders, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
@@ -739,10 +846,12 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			return nil, errPick
 		}
 
+		logging.MarkDebugRequest(ctx, provider, auth.ID)
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 
 		tried[auth.ID] = struct{}{}
+		claims.add(auth.ID)
 		execCtx := ctx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
@@ -752,8 +861,11 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		execCtx, attemptSpan := startAttemptSpan(execCtx, auth, provider, execReq.Model)
 		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
 		if errStream != nil {
+			attemptSpan.RecordError(errStream)
+			attemptSpan.End()
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
 			}
@@ -778,11 +890,16 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
+			defer attemptSpan.End()
 			var failed bool
 			forward := true
 			for chunk := range streamChunks {
 				if chunk.Err != nil && !failed {
 					failed = true
+					attemptSpan.RecordError(chunk.Err)
+					if lostHedge(streamCtx) {
+						continue
+					}
 					rerr := &Error{Message: chunk.Err.Error()}
 					var se cliproxyexecutor.StatusError
 					if errors.As(chunk.Err, &se) && se != nil {
@@ -803,7 +920,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 				case out <- chunk:
 				}
 			}
-			if !failed {
+			if !failed && !lostHedge(streamCtx) {
 				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
 			}
 		}(execCtx, auth.Clone(), provider, chunks)
@@ -892,13 +1009,18 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 		cfg = &internalconfig.Config{}
 	}
 
-	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
 	upstreamModel := ""
-	switch provider {
+	switch apiKeyAliasProvider(auth) {
 	case "gemini":
 		upstreamModel = resolveUpstreamModelForGeminiAPIKey(cfg, auth, requestedModel)
 	case "claude":
 		upstreamModel = resolveUpstreamModelForClaudeAPIKey(cfg, auth, requestedModel)
+	case "bedrock":
+		upstreamModel = resolveUpstreamModelForBedrockAPIKey(cfg, auth, requestedModel)
+	case "azure-openai":
+		upstreamModel = resolveUpstreamModelForAzureOpenAIAPIKey(cfg, auth, requestedModel)
+	case "openrouter":
+		upstreamModel = resolveUpstreamModelForOpenRouterAPIKey(cfg, auth, requestedModel)
 	case "codex":
 		upstreamModel = resolveUpstreamModelForCodexAPIKey(cfg, auth, requestedModel)
 	case "vertex":
@@ -914,6 +1036,16 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 	return requestedModel
 }
 
+// apiKeyAliasProvider returns the provider whose config section holds the model aliases of
+// auth. OpenAI compatibility entries may be named like a built-in provider (e.g. "openrouter")
+// and still resolve through their own section.
+func apiKeyAliasProvider(auth *Auth) string {
+	if auth.Attributes != nil && strings.TrimSpace(auth.Attributes["compat_name"]) != "" {
+		return "openai-compatibility"
+	}
+	return strings.ToLower(strings.TrimSpace(auth.Provider))
+}
+
 // APIKeyConfigEntry is a generic interface for API key configurations.
 type APIKeyConfigEntry interface {
 	GetAPIKey() string
@@ -973,6 +1105,27 @@ func resolveClaudeAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internal
 	return resolveAPIKeyConfig(cfg.ClaudeKey, auth)
 }
 
+func resolveBedrockAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.BedrockKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.BedrockKey, auth)
+}
+
+func resolveAzureOpenAIAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.AzureOpenAIKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.AzureOpenAIKey, auth)
+}
+
+func resolveOpenRouterAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.OpenRouterKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.OpenRouterKey, auth)
+}
+
 func resolveCodexAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.CodexKey {
 	if cfg == nil {
 		return nil
@@ -1003,6 +1156,30 @@ func resolveUpstreamModelForClaudeAPIKey(cfg *internalconfig.Config, auth *Auth,
 	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
 }
 
+func resolveUpstreamModelForBedrockAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveBedrockAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
+func resolveUpstreamModelForAzureOpenAIAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Deployments))
+}
+
+func resolveUpstreamModelForOpenRouterAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveOpenRouterAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
 func resolveUpstreamModelForCodexAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	entry := resolveCodexAPIKeyConfig(cfg, auth)
 	if entry == nil {
@@ -1234,6 +1411,8 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	if result.AuthID == "" {
 		return
 	}
+	m.recordProviderHealth(result)
+	m.recordAvailability(result)
 
 	// For Antigravity 429, check actual quota BEFORE acquiring lock to avoid deadlock
 	var quotaCheckResult QuotaCheckResult
@@ -1257,6 +1436,14 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
+		// Only authentication failures start a failure streak: quota and request errors
+		// say nothing about whether the credential itself is still valid.
+		if result.Success {
+			auth.LastUsedAt = now
+			auth.FailingSince = time.Time{}
+		} else if isAuthFailureStatus(statusCode) && auth.FailingSince.IsZero() {
+			auth.FailingSince = now
+		}
 
 		if result.Success {
 			if result.Model != "" {
@@ -1379,6 +1566,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 			}
 		}
 
+		m.publishModelStates(auth)
 		_ = m.persist(ctx, auth)
 	}
 	m.mu.Unlock()
@@ -1617,6 +1805,11 @@ func statusCodeFromResult(err *Error) int {
 	return err.StatusCode()
 }
 
+// isAuthFailureStatus reports whether an upstream status means the credential was rejected.
+func isAuthFailureStatus(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
+
 func applyAuthFailureState(auth *Auth, resultErr *Error, retryAfter *time.Duration, now time.Time) {
 	if auth == nil {
 		return
@@ -1716,6 +1909,7 @@ func (m *Manager) GetByID(id string) (*Auth, bool) {
 }
 
 func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
+	ctx, trace := m.startSelectionTrace(ctx, provider, model)
 	m.mu.RLock()
 	executor, okExecutor := m.executors[provider]
 	if !okExecutor {
@@ -1733,15 +1927,25 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 	}
 	registryRef := registry.GetGlobalRegistry()
 	for _, candidate := range m.auths {
-		if candidate.Provider != provider || candidate.Disabled {
+		if candidate.Provider != provider {
+			continue
+		}
+		if candidate.Disabled {
+			trace.exclude(candidate.ID, SelectionReasonDisabled, time.Time{})
+			continue
+		}
+		if candidate.NeedsRelogin() {
+			trace.exclude(candidate.ID, SelectionReasonNeedsRelogin, time.Time{})
 			continue
 		}
 		if _, used := tried[candidate.ID]; used {
+			trace.exclude(candidate.ID, SelectionReasonTried, time.Time{})
 			continue
 		}
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			resolved := m.resolveOAuthUpstreamModelWithFallback(candidate, model, registryRef)
 			if resolved == "" || !registryRef.ClientSupportsModel(candidate.ID, resolved) {
+				trace.exclude(candidate.ID, SelectionReasonModelUnsupported, time.Time{})
 				continue
 			}
 		}
@@ -1749,9 +1953,12 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
-		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
+		errNone := &Error{Code: "auth_not_found", Message: "no auth available"}
+		m.finishSelectionTrace(ctx, trace, nil, nil, errNone)
+		return nil, nil, errNone
 	}
 	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
+	m.finishSelectionTrace(ctx, trace, candidates, selected, errPick)
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, errPick
@@ -1786,6 +1993,7 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		return nil, nil, "", &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
 
+	ctx, trace := m.startSelectionTrace(ctx, "mixed", model)
 	m.mu.RLock()
 	candidates := make([]*Auth, 0, len(m.auths))
 	modelKey := strings.TrimSpace(model)
@@ -1798,7 +2006,7 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 	}
 	registryRef := registry.GetGlobalRegistry()
 	for _, candidate := range m.auths {
-		if candidate == nil || candidate.Disabled {
+		if candidate == nil {
 			continue
 		}
 		providerKey := strings.TrimSpace(strings.ToLower(candidate.Provider))
@@ -1808,15 +2016,26 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if _, ok := providerSet[providerKey]; !ok {
 			continue
 		}
+		if candidate.Disabled {
+			trace.exclude(candidate.ID, SelectionReasonDisabled, time.Time{})
+			continue
+		}
+		if candidate.NeedsRelogin() {
+			trace.exclude(candidate.ID, SelectionReasonNeedsRelogin, time.Time{})
+			continue
+		}
 		if _, used := tried[candidate.ID]; used {
+			trace.exclude(candidate.ID, SelectionReasonTried, time.Time{})
 			continue
 		}
 		if _, ok := m.executors[providerKey]; !ok {
+			trace.exclude(candidate.ID, SelectionReasonNoExecutor, time.Time{})
 			continue
 		}
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			resolved := m.resolveOAuthUpstreamModelWithFallback(candidate, model, registryRef)
 			if resolved == "" || !registryRef.ClientSupportsModel(candidate.ID, resolved) {
+				trace.exclude(candidate.ID, SelectionReasonModelUnsupported, time.Time{})
 				continue
 			}
 		}
@@ -1824,9 +2043,12 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
-		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
+		errNone := &Error{Code: "auth_not_found", Message: "no auth available"}
+		m.finishSelectionTrace(ctx, trace, nil, nil, errNone)
+		return nil, nil, "", errNone
 	}
-	selected, errPick := m.selector.Pick(ctx, "mixed", model, opts, candidates)
+	selected, errPick := m.pickFallbackTier(ctx, model, opts, candidates)
+	m.finishSelectionTrace(ctx, trace, candidates, selected, errPick)
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, "", errPick
@@ -1930,7 +2152,10 @@ func (m *Manager) checkRefreshes(ctx context.Context) {
 			if !m.markRefreshPending(a.ID, now) {
 				continue
 			}
-			go m.refreshAuth(ctx, a.ID)
+			if !m.refreshes.enqueue(a.ID) {
+				continue
+			}
+			go m.scheduleRefresh(ctx, a.ID, a.Provider)
 		}
 	}
 }
@@ -1946,7 +2171,7 @@ func (m *Manager) snapshotAuths() []*Auth {
 }
 
 func (m *Manager) shouldRefresh(a *Auth, now time.Time) bool {
-	if a == nil || a.Disabled {
+	if a == nil || a.Disabled || a.NeedsRelogin() {
 		return false
 	}
 	if !a.NextRefreshAfter.IsZero() && now.Before(a.NextRefreshAfter) {
@@ -1982,9 +2207,16 @@ func (m *Manager) shouldRefresh(a *Auth, now time.Time) bool {
 
 	provider := strings.ToLower(a.Provider)
 	lead := ProviderRefreshLead(provider, a.Runtime)
-	if lead == nil {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if lead == nil && (cfg == nil || cfg.RefreshLimits.For(provider).SkewSeconds <= 0) {
 		return false
 	}
+	var fallback time.Duration
+	if lead != nil {
+		fallback = *lead
+	}
+	skew := RefreshSkew(cfg, provider, fallback)
+	lead = &skew
 	if *lead <= 0 {
 		if hasExpiry && !expiry.IsZero() {
 			return now.After(expiry)
@@ -2179,19 +2411,53 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	if auth == nil || exec == nil {
 		return
 	}
+	unlock, acquired := acquireRefreshLock(id)
+	if !acquired {
+		log.Debugf("refresh of %s, %s skipped: another cluster instance is refreshing it", auth.Provider, auth.ID)
+		return
+	}
+	defer unlock()
+	if m.reloadPeerRefresh(ctx, auth) {
+		return
+	}
 	cloned := auth.Clone()
+	ctx, span := tracing.Start(ctx, "cliproxy.refresh", tracing.KindInternal,
+		tracing.String("cliproxy.auth_index", cloned.EnsureIndex()),
+		tracing.String("cliproxy.provider", auth.Provider),
+	)
 	updated, err := exec.Refresh(ctx, cloned)
+	span.RecordError(err)
+	span.End()
 	if err != nil && errors.Is(err, context.Canceled) {
 		log.Debugf("refresh canceled for %s, %s", auth.Provider, auth.ID)
 		return
 	}
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
+	if err != nil && IsPermanentRefreshError(err) {
+		log.Warnf("refresh token rejected for %s, %s; re-login required: %v", auth.Provider, auth.ID, err)
+		m.mu.Lock()
+		current := m.auths[id]
+		if current != nil {
+			markNeedsRelogin(current, err, now)
+			m.auths[id] = current
+			current = current.Clone()
+		}
+		m.mu.Unlock()
+		if current != nil {
+			_ = m.persist(ctx, current)
+			m.hook.OnAuthUpdated(ctx, current)
+		}
+		return
+	}
 	if err != nil {
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil {
 			current.NextRefreshAfter = now.Add(refreshFailureBackoff)
 			current.LastError = &Error{Message: err.Error()}
+			if current.FailingSince.IsZero() {
+				current.FailingSince = now
+			}
 			m.auths[id] = current
 		}
 		m.mu.Unlock()
@@ -2210,6 +2476,9 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	updated.LastError = nil
 	updated.UpdatedAt = now
 	_, _ = m.Update(ctx, updated)
+	if marker := publishRefreshMarker(id); marker != "" {
+		m.setRefreshMarker(id, marker)
+	}
 }
 
 func (m *Manager) executorFor(provider string) ProviderExecutor {
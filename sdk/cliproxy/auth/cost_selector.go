@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+// CostSelector prefers the auths whose provider has the lowest configured per-token price
+// for the requested model. Auths of equal price are chosen by quota weighting, and the next
+// price tier is used when every auth of a cheaper tier is out of quota. Auths without a
+// configured price rank after all priced ones.
+type CostSelector struct {
+	quota QuotaWeightedSelector
+}
+
+// NewCostSelector constructs a cost-aware selector that reads quota from auth metadata.
+func NewCostSelector() *CostSelector {
+	return &CostSelector{}
+}
+
+// NewCostSelectorWithStore constructs a cost-aware selector with a quota store.
+func NewCostSelectorWithStore(store *quota.Store) *CostSelector {
+	return &CostSelector{quota: QuotaWeightedSelector{store: store}}
+}
+
+// SetStore sets the quota store used to break price ties.
+func (s *CostSelector) SetStore(store *quota.Store) {
+	if s == nil {
+		return
+	}
+	s.quota.SetStore(store)
+}
+
+// Pick selects the cheapest available auth for the model.
+func (s *CostSelector) Pick(ctx context.Context, provider, model string, opts executor.Options, auths []*Auth) (*Auth, error) {
+	available, err := getAvailableAuths(auths, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		rr := &RoundRobinSelector{}
+		return rr.Pick(ctx, provider, model, opts, auths)
+	}
+
+	tiers := costTiers(available, thinking.ParseSuffix(model).ModelName)
+	var lastErr error
+	for i, tier := range tiers {
+		selected, errPick := s.quota.Pick(ctx, provider, model, opts, tier)
+		if errPick != nil {
+			lastErr = errPick
+			continue
+		}
+		for _, costlier := range tiers[i+1:] {
+			for _, candidate := range costlier {
+				recordSelectionExclusion(ctx, candidate.ID, SelectionReasonCostlier)
+			}
+		}
+		return selected, nil
+	}
+	return nil, lastErr
+}
+
+// costTiers groups auths by the per-million-token price of model at their provider, input
+// plus output, cheapest first. Unpriced auths form the last tier.
+func costTiers(auths []*Auth, model string) [][]*Auth {
+	byCost := make(map[float64][]*Auth)
+	for _, candidate := range auths {
+		cost := math.Inf(1)
+		if price, ok := pricing.LookupProvider(candidate.Provider, model); ok {
+			cost = price.Input + price.Output
+		}
+		byCost[cost] = append(byCost[cost], candidate)
+	}
+	costs := make([]float64, 0, len(byCost))
+	for cost := range byCost {
+		costs = append(costs, cost)
+	}
+	sort.Float64s(costs)
+	tiers := make([][]*Auth, 0, len(costs))
+	for _, cost := range costs {
+		tiers = append(tiers, byCost[cost])
+	}
+	return tiers
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func TestCostSelectorPrefersCheapestProvider(t *testing.T) {
+	pricing.Set([]internalconfig.ModelPrice{
+		{Model: "gemini-2.5-flash", Provider: "vertex", Input: 0.3, Output: 2.5},
+		{Model: "gemini-2.5-flash", Provider: "gemini", Input: 0.5, Output: 3},
+	})
+	t.Cleanup(func() { pricing.Set(nil) })
+
+	model := "gemini-2.5-flash"
+	vertexA := &Auth{ID: "vertex-a", Provider: "vertex", Metadata: map[string]any{}}
+	vertexB := &Auth{ID: "vertex-b", Provider: "vertex", Metadata: map[string]any{}}
+	gemini := &Auth{ID: "gemini", Provider: "gemini"}
+	unpriced := &Auth{ID: "unpriced", Provider: "antigravity"}
+	auths := []*Auth{unpriced, gemini, vertexA, vertexB}
+
+	selector := NewCostSelector()
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		got, err := selector.Pick(context.Background(), "mixed", model+"(high)", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+		seen[got.ID]++
+	}
+	if seen["vertex-a"] != 2 || seen["vertex-b"] != 2 {
+		t.Fatalf("expected picks balanced across the cheapest tier, got %v", seen)
+	}
+
+	// Exhausted quota in the cheapest tier moves selection to the next price.
+	for _, auth := range []*Auth{vertexA, vertexB} {
+		quota.UpdateMetadata(auth.Metadata, "vertex", map[string]quota.ModelQuota{model: {Percent: 0}}, time.Now())
+	}
+	got, err := selector.Pick(context.Background(), "mixed", model, cliproxyexecutor.Options{}, auths)
+	if err != nil || got.ID != "gemini" {
+		t.Fatalf("Pick() = %v, %v; want gemini", got, err)
+	}
+}
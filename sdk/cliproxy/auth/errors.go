@@ -1,5 +1,7 @@
 package auth
 
+import cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+
 // Error describes an authentication related failure in a provider agnostic format.
 type Error struct {
 	// Code is a short machine readable identifier.
@@ -30,3 +32,23 @@ func (e *Error) StatusCode() int {
 	}
 	return e.HTTPStatus
 }
+
+// Is matches the cliproxyexecutor sentinel classifying the error by its code, or by its
+// HTTP status when the code does not decide.
+func (e *Error) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+	var kind error
+	switch e.Code {
+	case "auth_not_found", "auth_unavailable":
+		kind = cliproxyexecutor.ErrNoCredentials
+	case "needs_relogin":
+		kind = cliproxyexecutor.ErrUnauthorized
+	case "invalid_request":
+		kind = cliproxyexecutor.ErrInvalidRequest
+	default:
+		kind = cliproxyexecutor.StatusKind(e.HTTPStatus)
+	}
+	return kind != nil && kind == target
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// fallbackChain returns the configured provider order for model, or nil when the model has
+// no fallback chain.
+func (m *Manager) fallbackChain(model string) []string {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.Fallback) == 0 {
+		return nil
+	}
+	base := strings.TrimSpace(thinking.ParseSuffix(model).ModelName)
+	if chain, ok := cfg.Fallback[base]; ok {
+		return chain
+	}
+	for key, chain := range cfg.Fallback {
+		if strings.EqualFold(strings.TrimSpace(key), base) {
+			return chain
+		}
+	}
+	return nil
+}
+
+// pickFallbackTier lets the selector choose among the candidates of the first provider in
+// the fallback chain of model that still has an available auth. Without a chain all
+// candidates are offered at once.
+func (m *Manager) pickFallbackTier(ctx context.Context, model string, opts cliproxyexecutor.Options, candidates []*Auth) (*Auth, error) {
+	chain := m.fallbackChain(model)
+	if len(chain) == 0 {
+		return m.selector.Pick(ctx, "mixed", model, opts, candidates)
+	}
+	tiers := fallbackTiers(chain, candidates)
+	var lastErr error
+	for i, tier := range tiers {
+		selected, errPick := m.selector.Pick(ctx, "mixed", model, opts, tier)
+		if errPick != nil {
+			lastErr = errPick
+			continue
+		}
+		for _, later := range tiers[i+1:] {
+			for _, candidate := range later {
+				recordSelectionExclusion(ctx, candidate.ID, SelectionReasonFallback)
+			}
+		}
+		return selected, nil
+	}
+	return nil, lastErr
+}
+
+// fallbackTiers groups candidates by their position in chain. Auths of providers missing
+// from the chain form the last tier.
+func fallbackTiers(chain []string, candidates []*Auth) [][]*Auth {
+	tiers := make([][]*Auth, len(chain)+1)
+	for _, candidate := range candidates {
+		position := len(chain)
+		for i, provider := range chain {
+			if fallbackProviderMatches(provider, candidate) {
+				position = i
+				break
+			}
+		}
+		tiers[position] = append(tiers[position], candidate)
+	}
+	out := tiers[:0]
+	for _, tier := range tiers {
+		if len(tier) > 0 {
+			out = append(out, tier)
+		}
+	}
+	return out
+}
+
+// fallbackProviderMatches reports whether a provider named in a fallback chain covers auth.
+// "openai-compatible" covers every OpenAI compatibility entry; an entry may also be named
+// directly.
+func fallbackProviderMatches(provider string, auth *Auth) bool {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return false
+	}
+	if provider == strings.ToLower(strings.TrimSpace(auth.Provider)) {
+		return true
+	}
+	if auth.Attributes == nil {
+		return false
+	}
+	compatName := strings.ToLower(strings.TrimSpace(auth.Attributes["compat_name"]))
+	if compatName == "" {
+		return false
+	}
+	return provider == compatName || provider == "openai-compatible" || provider == "openai-compatibility"
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// answeringExecutor serves the "gemini" provider and answers with the auth ID.
+type answeringExecutor struct {
+	failingExecutor
+}
+
+func (e *answeringExecutor) Identifier() string { return "gemini" }
+
+func (e *answeringExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.calls.Add(1)
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func TestExecuteFollowsFallbackChain(t *testing.T) {
+	claude := &failingExecutor{}
+	gemini := &answeringExecutor{}
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(claude)
+	m.RegisterExecutor(gemini)
+	m.SetConfig(&internalconfig.Config{Fallback: map[string][]string{"shared-model": {"claude", "gemini"}}})
+	for _, auth := range []*Auth{{ID: "a-gemini", Provider: "gemini"}, {ID: "b-claude", Provider: "claude"}} {
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register: %v", errRegister)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "shared-model"}})
+		id := auth.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	resp, errExec := m.Execute(context.Background(), []string{"gemini", "claude"}, cliproxyexecutor.Request{Model: "shared-model"}, cliproxyexecutor.Options{})
+	if errExec != nil {
+		t.Fatalf("Execute error = %v", errExec)
+	}
+	if string(resp.Payload) != "a-gemini" {
+		t.Fatalf("payload = %q, want the fallback provider's", resp.Payload)
+	}
+	if claude.calls.Load() != 1 || gemini.calls.Load() != 1 {
+		t.Fatalf("calls claude=%d gemini=%d, want the chain order", claude.calls.Load(), gemini.calls.Load())
+	}
+}
+
+func TestFallbackTiersMatchesCompatibilityEntries(t *testing.T) {
+	compat := &Auth{ID: "compat", Provider: "openrouter", Attributes: map[string]string{"compat_name": "openrouter"}}
+	vertex := &Auth{ID: "vertex", Provider: "vertex"}
+	other := &Auth{ID: "other", Provider: "codex"}
+	tiers := fallbackTiers([]string{"vertex", "openai-compatible", "claude"}, []*Auth{other, compat, vertex})
+	if len(tiers) != 3 || tiers[0][0] != vertex || tiers[1][0] != compat || tiers[2][0] != other {
+		t.Fatalf("tiers = %v", tiers)
+	}
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultHedgeDelay = 5 * time.Second
+
+// errHedgeLost is the cancellation cause of the attempts of a hedged request that lost the
+// race. Their failures are not held against their auths.
+var errHedgeLost = errors.New("hedged attempt lost the race")
+
+// lostHedge reports whether ctx belongs to an attempt cancelled because another attempt of the
+// same hedged request won.
+func lostHedge(ctx context.Context) bool {
+	return ctx != nil && errors.Is(context.Cause(ctx), errHedgeLost)
+}
+
+// streamClaims records the auths used by the concurrent attempts of one hedged request so
+// that each attempt picks a different auth.
+type streamClaims struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+func (c *streamClaims) merge(into map[string]struct{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id := range c.ids {
+		into[id] = struct{}{}
+	}
+}
+
+func (c *streamClaims) add(id string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ids == nil {
+		c.ids = make(map[string]struct{})
+	}
+	c.ids[id] = struct{}{}
+}
+
+// hedgeAttempt is one attempt of a hedged request and its outcome up to the first chunk.
+type hedgeAttempt struct {
+	cancel context.CancelCauseFunc
+	err    error
+	chunks <-chan cliproxyexecutor.StreamChunk
+	first  cliproxyexecutor.StreamChunk
+	ok     bool
+}
+
+// delivered reports whether the attempt produced a first chunk without an error.
+func (a *hedgeAttempt) delivered() bool {
+	return a.err == nil && a.ok && a.first.Err == nil
+}
+
+// hedgeDelay returns how long a streaming request for providers waits for its first chunk
+// before it is hedged, and false when it is not hedged.
+func (m *Manager) hedgeDelay(providers []string) (time.Duration, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Hedging.Enabled {
+		return 0, false
+	}
+	if len(cfg.Hedging.Providers) > 0 {
+		eligible := false
+		for _, provider := range providers {
+			for _, hedged := range cfg.Hedging.Providers {
+				if strings.EqualFold(strings.TrimSpace(hedged), provider) {
+					eligible = true
+				}
+			}
+		}
+		if !eligible {
+			return 0, false
+		}
+	}
+	delay := time.Duration(cfg.Hedging.DelayMS) * time.Millisecond
+	if delay <= 0 {
+		delay = defaultHedgeDelay
+	}
+	return delay, true
+}
+
+// executeStreamOnce starts a streaming request, hedging it when configured.
+func (m *Manager) executeStreamOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	delay, ok := m.hedgeDelay(providers)
+	if !ok {
+		return m.executeStreamMixedOnce(ctx, providers, req, opts, nil)
+	}
+	return m.executeStreamHedged(ctx, providers, req, opts, delay)
+}
+
+// executeStreamHedged starts the request and, when no first chunk arrived after delay, starts
+// it again on another auth. The first attempt to deliver a chunk wins and the other one is
+// cancelled. When every attempt fails, the outcome of the last one is returned as it would
+// have been without hedging.
+func (m *Manager) executeStreamHedged(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, delay time.Duration) (<-chan cliproxyexecutor.StreamChunk, error) {
+	claims := &streamClaims{}
+	results := make(chan *hedgeAttempt, 2)
+	start := func() *hedgeAttempt {
+		attemptCtx, cancel := context.WithCancelCause(ctx)
+		attempt := &hedgeAttempt{cancel: cancel}
+		go func() {
+			attempt.chunks, attempt.err = m.executeStreamMixedOnce(attemptCtx, providers, req, opts, claims)
+			if attempt.err == nil {
+				attempt.first, attempt.ok = <-attempt.chunks
+			}
+			results <- attempt
+		}()
+		return attempt
+	}
+
+	attempts := []*hedgeAttempt{start()}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var last *hedgeAttempt
+	for running := 1; running > 0; {
+		select {
+		case <-ctx.Done():
+			for _, attempt := range attempts {
+				attempt.cancel(nil)
+			}
+			return nil, ctx.Err()
+		case <-timer.C:
+			log.Infof("hedging: no first chunk for model %s after %s, starting a second attempt", req.Model, delay)
+			attempts = append(attempts, start())
+			running++
+		case attempt := <-results:
+			running--
+			if attempt.delivered() {
+				for _, other := range attempts {
+					if other != attempt {
+						other.cancel(errHedgeLost)
+					}
+				}
+				return forwardHedgeWinner(ctx, attempt), nil
+			}
+			last = attempt
+			if len(attempts) == 1 {
+				running = 0
+			}
+		}
+	}
+	for _, attempt := range attempts {
+		if attempt != last {
+			attempt.cancel(errHedgeLost)
+		}
+	}
+	if last.err != nil {
+		last.cancel(nil)
+		return nil, last.err
+	}
+	return forwardHedgeWinner(ctx, last), nil
+}
+
+// forwardHedgeWinner streams the remaining chunks of the winning attempt after its first one.
+func forwardHedgeWinner(ctx context.Context, attempt *hedgeAttempt) <-chan cliproxyexecutor.StreamChunk {
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer attempt.cancel(nil)
+		if !attempt.ok {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case out <- attempt.first:
+		}
+		for chunk := range attempt.chunks {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- chunk:
+			}
+		}
+	}()
+	return out
+}
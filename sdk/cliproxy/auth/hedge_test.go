@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// stallingExecutor never answers for the "a-slow" auth until its request is cancelled and
+// streams one chunk right away for every other auth.
+type stallingExecutor struct {
+	failingExecutor
+	cancelled chan error
+}
+
+func (e *stallingExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	e.calls.Add(1)
+	out := make(chan cliproxyexecutor.StreamChunk, 1)
+	if auth.ID != "a-slow" {
+		out <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+		close(out)
+		return out, nil
+	}
+	go func() {
+		defer close(out)
+		<-ctx.Done()
+		e.cancelled <- context.Cause(ctx)
+		out <- cliproxyexecutor.StreamChunk{Err: ctx.Err()}
+	}()
+	return out, nil
+}
+
+func TestExecuteStreamHedgesSlowFirstChunk(t *testing.T) {
+	executor := &stallingExecutor{cancelled: make(chan error, 1)}
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(executor)
+	m.SetConfig(&internalconfig.Config{Hedging: internalconfig.HedgingConfig{Enabled: true, DelayMS: 20, Providers: []string{"Claude"}}})
+	for _, auth := range []*Auth{{ID: "a-slow", Provider: "claude"}, {ID: "b-fast", Provider: "claude"}} {
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register: %v", errRegister)
+		}
+	}
+
+	chunks, errStream := m.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if errStream != nil {
+		t.Fatalf("ExecuteStream error = %v", errStream)
+	}
+	var payload string
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error = %v", chunk.Err)
+		}
+		payload += string(chunk.Payload)
+	}
+	if payload != "b-fast" {
+		t.Fatalf("payload = %q, want the hedged attempt's", payload)
+	}
+	select {
+	case cause := <-executor.cancelled:
+		if cause != errHedgeLost {
+			t.Fatalf("slow attempt cancelled with %v", cause)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow attempt was not cancelled")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if slow, _ := m.GetByID("a-slow"); slow.LastError != nil || slow.Unavailable {
+		t.Fatalf("losing attempt counted against its auth: %+v", slow.LastError)
+	}
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSheddingErrorRate   = 0.5
+	defaultSheddingMinRequests = 20
+	defaultSheddingWindow      = 60 * time.Second
+	defaultSheddingRecover     = 60 * time.Second
+	sheddingBuckets            = 12
+	maxSheddingEvents          = 50
+)
+
+// LoadSheddingStatus describes the health of a provider as seen by the load shedder.
+type LoadSheddingStatus struct {
+	Provider  string     `json:"provider"`
+	Shedding  bool       `json:"shedding"`
+	Since     *time.Time `json:"since,omitempty"`
+	Requests  int        `json:"requests"`
+	Failures  int        `json:"failures"`
+	ErrorRate float64    `json:"error_rate"`
+}
+
+// LoadSheddingEvent records a provider entering or leaving essential-only mode.
+type LoadSheddingEvent struct {
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider"`
+	Shedding  bool      `json:"shedding"`
+	ErrorRate float64   `json:"error_rate"`
+	Requests  int       `json:"requests"`
+}
+
+type sheddingBucket struct {
+	slot     int64
+	requests int
+	failures int
+}
+
+type providerLoad struct {
+	buckets     [sheddingBuckets]sheddingBucket
+	shedding    bool
+	since       time.Time
+	lastFailure time.Time
+}
+
+// loadShedder tracks per-provider upstream error rates and switches unhealthy providers to
+// essential-only traffic until they recover.
+type loadShedder struct {
+	mu        sync.Mutex
+	providers map[string]*providerLoad
+	events    []LoadSheddingEvent
+}
+
+type sheddingSettings struct {
+	errorRate   float64
+	minRequests int
+	window      time.Duration
+	recover     time.Duration
+}
+
+func resolveSheddingSettings(cfg internalconfig.LoadSheddingConfig) sheddingSettings {
+	s := sheddingSettings{
+		errorRate:   cfg.ErrorRate,
+		minRequests: cfg.MinRequests,
+		window:      time.Duration(cfg.WindowSeconds) * time.Second,
+		recover:     time.Duration(cfg.RecoverSeconds) * time.Second,
+	}
+	if s.errorRate <= 0 || s.errorRate > 1 {
+		s.errorRate = defaultSheddingErrorRate
+	}
+	if s.minRequests <= 0 {
+		s.minRequests = defaultSheddingMinRequests
+	}
+	if s.window <= 0 {
+		s.window = defaultSheddingWindow
+	}
+	if s.recover <= 0 {
+		s.recover = defaultSheddingRecover
+	}
+	return s
+}
+
+func (p *providerLoad) totals(now time.Time, window time.Duration) (requests, failures int) {
+	size := window / sheddingBuckets
+	if size <= 0 {
+		size = time.Second
+	}
+	oldest := now.Add(-window).UnixNano() / int64(size)
+	for _, b := range p.buckets {
+		if b.slot > oldest {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return requests, failures
+}
+
+func (s *loadShedder) record(provider string, failed bool, now time.Time, settings sheddingSettings, evaluate bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.providers == nil {
+		s.providers = make(map[string]*providerLoad)
+	}
+	p := s.providers[provider]
+	if p == nil {
+		p = &providerLoad{}
+		s.providers[provider] = p
+	}
+	size := settings.window / sheddingBuckets
+	if size <= 0 {
+		size = time.Second
+	}
+	slot := now.UnixNano() / int64(size)
+	b := &p.buckets[slot%sheddingBuckets]
+	if b.slot != slot {
+		*b = sheddingBucket{slot: slot}
+	}
+	b.requests++
+	if failed {
+		b.failures++
+		p.lastFailure = now
+	}
+	if evaluate {
+		s.evaluateLocked(provider, p, now, settings)
+	}
+}
+
+// errorRate returns the request count and failure ratio of provider over the window.
+func (s *loadShedder) errorRate(provider string, now time.Time, window time.Duration) (int, float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.providers[provider]
+	if p == nil {
+		return 0, 0
+	}
+	requests, failures := p.totals(now, window)
+	if requests == 0 {
+		return 0, 0
+	}
+	return requests, float64(failures) / float64(requests)
+}
+
+// evaluateLocked moves a provider into shedding when its error rate crosses the threshold and
+// back out after a failure-free recovery period or once the rate falls below half the threshold.
+func (s *loadShedder) evaluateLocked(provider string, p *providerLoad, now time.Time, settings sheddingSettings) {
+	requests, failures := p.totals(now, settings.window)
+	rate := 0.0
+	if requests > 0 {
+		rate = float64(failures) / float64(requests)
+	}
+	switch {
+	case !p.shedding && requests >= settings.minRequests && rate >= settings.errorRate:
+		p.shedding = true
+		p.since = now
+		s.emitLocked(LoadSheddingEvent{Time: now, Provider: provider, Shedding: true, ErrorRate: rate, Requests: requests})
+		log.Warnf("load shedding: provider %s switched to essential-only traffic (error rate %.0f%% over %d requests)", provider, rate*100, requests)
+	case p.shedding && (now.Sub(p.lastFailure) >= settings.recover || (requests >= settings.minRequests && rate < settings.errorRate/2)):
+		p.shedding = false
+		p.since = now
+		s.emitLocked(LoadSheddingEvent{Time: now, Provider: provider, Shedding: false, ErrorRate: rate, Requests: requests})
+		log.Infof("load shedding: provider %s recovered (error rate %.0f%% over %d requests)", provider, rate*100, requests)
+	}
+}
+
+func (s *loadShedder) emitLocked(event LoadSheddingEvent) {
+	s.events = append(s.events, event)
+	if len(s.events) > maxSheddingEvents {
+		s.events = append([]LoadSheddingEvent(nil), s.events[len(s.events)-maxSheddingEvents:]...)
+	}
+}
+
+func (s *loadShedder) isShedding(provider string, now time.Time, settings sheddingSettings) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.providers[provider]
+	if p == nil || !p.shedding {
+		return false
+	}
+	s.evaluateLocked(provider, p, now, settings)
+	return p.shedding
+}
+
+func (s *loadShedder) status(now time.Time, settings sheddingSettings) ([]LoadSheddingStatus, []LoadSheddingEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]LoadSheddingStatus, 0, len(s.providers))
+	for provider, p := range s.providers {
+		if p.shedding {
+			s.evaluateLocked(provider, p, now, settings)
+		}
+		requests, failures := p.totals(now, settings.window)
+		entry := LoadSheddingStatus{Provider: provider, Shedding: p.shedding, Requests: requests, Failures: failures}
+		if requests > 0 {
+			entry.ErrorRate = float64(failures) / float64(requests)
+		}
+		if p.shedding {
+			since := p.since
+			entry.Since = &since
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out, append([]LoadSheddingEvent(nil), s.events...)
+}
+
+// upstreamFailure reports whether a result counts against provider health: server errors
+// and transport failures, but not client errors, rate limits or cancellations.
+func upstreamFailure(result Result) bool {
+	if result.Success || result.Error == nil {
+		return false
+	}
+	status := result.Error.StatusCode()
+	if status >= http.StatusInternalServerError {
+		return true
+	}
+	if status != 0 {
+		return false
+	}
+	msg := strings.ToLower(result.Error.Message)
+	return !strings.Contains(msg, "context canceled") && !strings.Contains(msg, "context deadline exceeded")
+}
+
+func (m *Manager) loadSheddingConfig() (internalconfig.LoadSheddingConfig, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.LoadShedding.Enabled {
+		return internalconfig.LoadSheddingConfig{}, false
+	}
+	return cfg.LoadShedding, true
+}
+
+// recordProviderHealth feeds an execution result into the provider health window used by
+// load shedding and standby pre-warming.
+func (m *Manager) recordProviderHealth(result Result) {
+	if result.Provider == "" {
+		return
+	}
+	cfg, shedding := m.loadSheddingConfig()
+	if !shedding && !m.standbyPrewarmEnabled() {
+		return
+	}
+	m.shedder.record(strings.ToLower(result.Provider), upstreamFailure(result), time.Now(), resolveSheddingSettings(cfg), shedding)
+}
+
+// applyLoadShedding drops shedding providers for non-essential requests. Traffic moves to
+// healthy providers of the same model when there are any; otherwise the request is rejected.
+func (m *Manager) applyLoadShedding(ctx context.Context, providers []string) ([]string, error) {
+	cfg, ok := m.loadSheddingConfig()
+	if !ok || essentialRequest(ctx, cfg.EssentialKeys) {
+		return providers, nil
+	}
+	settings := resolveSheddingSettings(cfg)
+	now := time.Now()
+	healthy := providers[:0:0]
+	var shed []string
+	for _, provider := range providers {
+		if m.shedder.isShedding(provider, now, settings) {
+			shed = append(shed, provider)
+			continue
+		}
+		healthy = append(healthy, provider)
+	}
+	if len(shed) == 0 {
+		return providers, nil
+	}
+	if len(healthy) > 0 {
+		return healthy, nil
+	}
+	return nil, &Error{
+		Code:       "provider_degraded",
+		Message:    fmt.Sprintf("provider %s is degraded and only serving essential traffic", strings.Join(shed, ", ")),
+		Retryable:  true,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
+
+func essentialRequest(ctx context.Context, essential []string) bool {
+	if len(essential) == 0 || ctx == nil {
+		return false
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	for _, id := range logging.ClientKeyIdentifiers(ginCtx) {
+		for _, key := range essential {
+			if id == strings.TrimSpace(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadSheddingStatus reports per-provider health and recent shedding transitions.
+func (m *Manager) LoadSheddingStatus() ([]LoadSheddingStatus, []LoadSheddingEvent) {
+	if m == nil {
+		return nil, nil
+	}
+	cfg, _ := m.loadSheddingConfig()
+	return m.shedder.status(time.Now(), resolveSheddingSettings(cfg))
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestLoadSheddingEntersAndRecovers(t *testing.T) {
+	settings := resolveSheddingSettings(internalconfig.LoadSheddingConfig{MinRequests: 4, RecoverSeconds: 30})
+	var s loadShedder
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		s.record("gemini", true, now, settings, true)
+	}
+	if s.isShedding("gemini", now, settings) {
+		t.Fatalf("shedding before min-requests was reached")
+	}
+	s.record("gemini", false, now, settings, true)
+	if !s.isShedding("gemini", now, settings) {
+		t.Fatalf("expected shedding at 75%% error rate")
+	}
+	if s.isShedding("gemini", now.Add(31*time.Second), settings) {
+		t.Fatalf("expected recovery after recover-seconds without failures")
+	}
+	_, events := s.status(now, settings)
+	if len(events) != 2 || !events[0].Shedding || events[1].Shedding {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestUpstreamFailureClassification(t *testing.T) {
+	cases := []struct {
+		err  *Error
+		want bool
+	}{
+		{&Error{HTTPStatus: http.StatusBadGateway}, true},
+		{&Error{HTTPStatus: http.StatusTooManyRequests}, false},
+		{&Error{HTTPStatus: http.StatusBadRequest}, false},
+		{&Error{Message: "dial tcp: connection refused"}, true},
+		{&Error{Message: context.Canceled.Error()}, false},
+	}
+	for _, tc := range cases {
+		if got := upstreamFailure(Result{Error: tc.err}); got != tc.want {
+			t.Errorf("upstreamFailure(%+v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestApplyLoadSheddingFallsBackToHealthyProviders(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{LoadShedding: internalconfig.LoadSheddingConfig{Enabled: true, MinRequests: 1}})
+	m.recordProviderHealth(Result{Provider: "claude", Error: &Error{HTTPStatus: http.StatusInternalServerError}})
+
+	providers, errShed := m.applyLoadShedding(context.Background(), []string{"claude", "antigravity"})
+	if errShed != nil || len(providers) != 1 || providers[0] != "antigravity" {
+		t.Fatalf("providers = %v, err = %v", providers, errShed)
+	}
+	_, errShed = m.applyLoadShedding(context.Background(), []string{"claude"})
+	var authErr *Error
+	if !errors.As(errShed, &authErr) || authErr.HTTPStatus != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 provider_degraded, got %v", errShed)
+	}
+}
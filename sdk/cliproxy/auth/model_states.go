@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+// ClearModelStates drops the per-model cooldown and quota state of an auth so the models
+// become selectable again immediately. With no models given every state is cleared. It
+// returns the models whose state was removed and false when the auth is unknown.
+func (m *Manager) ClearModelStates(ctx context.Context, authID string, models ...string) ([]string, bool) {
+	if m == nil || authID == "" {
+		return nil, false
+	}
+	now := time.Now()
+	cleared := []string{}
+
+	m.mu.Lock()
+	auth, ok := m.auths[authID]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return nil, false
+	}
+	if len(models) == 0 {
+		for model := range auth.ModelStates {
+			cleared = append(cleared, model)
+		}
+	} else {
+		for _, model := range models {
+			if _, exists := auth.ModelStates[model]; exists {
+				cleared = append(cleared, model)
+			}
+		}
+	}
+	for _, model := range cleared {
+		delete(auth.ModelStates, model)
+	}
+	if len(cleared) > 0 {
+		if len(auth.ModelStates) == 0 {
+			if !auth.Disabled {
+				clearAuthStateOnSuccess(auth, now)
+			}
+		} else {
+			updateAggregatedAvailability(auth, now)
+			if !auth.Disabled && !hasModelError(auth, now) {
+				auth.LastError = nil
+				auth.StatusMessage = ""
+				auth.Status = StatusActive
+			}
+			auth.UpdatedAt = now
+		}
+		if err := m.persist(ctx, auth); err != nil {
+			log.Warnf("failed to persist cleared model states for %s: %v", authID, err)
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Strings(cleared)
+	reg := registry.GetGlobalRegistry()
+	for _, model := range cleared {
+		reg.ClearModelQuotaExceeded(authID, model)
+		reg.ResumeClientModel(authID, model)
+	}
+	return cleared, true
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClearModelStates(t *testing.T) {
+	mgr := NewManager(nil, nil, nil)
+	ctx := context.Background()
+	next := time.Now().Add(time.Hour)
+	quota := QuotaState{Exceeded: true, Reason: "quota", NextRecoverAt: next}
+	_, _ = mgr.Register(ctx, &Auth{
+		ID:       "a",
+		Provider: "codex",
+		Status:   StatusError,
+		ModelStates: map[string]*ModelState{
+			"m1": {Status: StatusError, Unavailable: true, NextRetryAfter: next, Quota: quota},
+			"m2": {Status: StatusError, Unavailable: true, NextRetryAfter: next, Quota: quota},
+		},
+	})
+
+	cleared, ok := mgr.ClearModelStates(ctx, "a", "m1", "missing")
+	if !ok || len(cleared) != 1 || cleared[0] != "m1" {
+		t.Fatalf("cleared = %v, %v; want [m1], true", cleared, ok)
+	}
+	got, _ := mgr.GetByID("a")
+	if _, exists := got.ModelStates["m1"]; exists || got.ModelStates["m2"] == nil {
+		t.Fatalf("model states after single clear = %v", got.ModelStates)
+	}
+	if got.Status != StatusError || !got.Quota.Exceeded {
+		t.Fatalf("auth should stay in error while m2 is blocked: status=%s quota=%+v", got.Status, got.Quota)
+	}
+
+	cleared, _ = mgr.ClearModelStates(ctx, "a")
+	if len(cleared) != 1 || cleared[0] != "m2" {
+		t.Fatalf("cleared = %v, want [m2]", cleared)
+	}
+	got, _ = mgr.GetByID("a")
+	if len(got.ModelStates) != 0 || got.Status != StatusActive || got.Quota.Exceeded || got.Unavailable {
+		t.Fatalf("auth not reset after clearing all states: %+v", got)
+	}
+
+	if _, ok := mgr.ClearModelStates(ctx, "unknown"); ok {
+		t.Fatal("expected unknown auth to report false")
+	}
+}
@@ -76,8 +76,10 @@ func (s *QuotaWeightedSelector) Pick(ctx context.Context, provider, model string
 	for _, candidate := range available {
 		weight, known := s.weightFor(candidate, model, now)
 		if known && weight <= 0 {
+			recordSelectionExclusion(ctx, candidate.ID, SelectionReasonQuotaZero)
 			continue
 		}
+		recordSelectionWeight(ctx, candidate.ID, weight)
 		if !known {
 			unknownCount++
 		}
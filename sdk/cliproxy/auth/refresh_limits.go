@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	defaultRefreshMaxConcurrent = 4
+	defaultRefreshJitter        = 10 * time.Second
+	refreshRateWindow           = time.Minute
+)
+
+// refreshSettings are the resolved refresh limits of a provider. Zero means unlimited.
+type refreshSettings struct {
+	maxConcurrent int
+	perMinute     int
+	jitter        time.Duration
+}
+
+func resolveRefreshSettings(set internalconfig.RefreshLimitSet) refreshSettings {
+	s := refreshSettings{
+		maxConcurrent: set.MaxConcurrent,
+		perMinute:     set.PerMinute,
+		jitter:        time.Duration(set.JitterSeconds) * time.Second,
+	}
+	switch {
+	case s.maxConcurrent == 0:
+		s.maxConcurrent = defaultRefreshMaxConcurrent
+	case s.maxConcurrent < 0:
+		s.maxConcurrent = 0
+	}
+	if s.perMinute < 0 {
+		s.perMinute = 0
+	}
+	switch {
+	case s.jitter == 0:
+		s.jitter = defaultRefreshJitter
+	case s.jitter < 0:
+		s.jitter = 0
+	}
+	return s
+}
+
+func (m *Manager) refreshSettingsFor(provider string) refreshSettings {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return resolveRefreshSettings(internalconfig.RefreshLimitSet{})
+	}
+	return resolveRefreshSettings(cfg.RefreshLimits.For(provider))
+}
+
+// refreshLimiter tracks background refreshes per provider: the auths waiting for or running a
+// refresh, how many refreshes run, and when recent ones started.
+type refreshLimiter struct {
+	mu      sync.Mutex
+	queued  map[string]bool
+	running map[string]int
+	starts  map[string][]time.Time
+	// changed is closed and replaced whenever a refresh finishes.
+	changed chan struct{}
+}
+
+// enqueue marks an auth as having a refresh scheduled. It reports false when one already is.
+func (l *refreshLimiter) enqueue(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.queued[id] {
+		return false
+	}
+	if l.queued == nil {
+		l.queued = make(map[string]bool)
+	}
+	l.queued[id] = true
+	return true
+}
+
+func (l *refreshLimiter) dequeue(id string) {
+	l.mu.Lock()
+	delete(l.queued, id)
+	l.mu.Unlock()
+}
+
+// acquire waits until provider may start another refresh under s, or ctx is done.
+func (l *refreshLimiter) acquire(ctx context.Context, provider string, s refreshSettings) bool {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		wait := l.waitLocked(provider, s, now)
+		if wait == 0 {
+			if l.running == nil {
+				l.running = make(map[string]int)
+				l.starts = make(map[string][]time.Time)
+			}
+			l.running[provider]++
+			if s.perMinute > 0 {
+				l.starts[provider] = append(l.starts[provider], now)
+			}
+			l.mu.Unlock()
+			return true
+		}
+		if l.changed == nil {
+			l.changed = make(chan struct{})
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-expired:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+// waitLocked returns 0 when provider may start a refresh now, the time until the rate limit
+// allows one, or -1 when the concurrency cap is reached.
+func (l *refreshLimiter) waitLocked(provider string, s refreshSettings, now time.Time) time.Duration {
+	if s.maxConcurrent > 0 && l.running[provider] >= s.maxConcurrent {
+		return -1
+	}
+	if s.perMinute <= 0 {
+		return 0
+	}
+	starts := l.starts[provider]
+	cutoff := now.Add(-refreshRateWindow)
+	for len(starts) > 0 && !starts[0].After(cutoff) {
+		starts = starts[1:]
+	}
+	if l.starts != nil {
+		l.starts[provider] = starts
+	}
+	if len(starts) < s.perMinute {
+		return 0
+	}
+	return starts[len(starts)-s.perMinute].Sub(cutoff)
+}
+
+func (l *refreshLimiter) release(provider string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running[provider] > 0 {
+		l.running[provider]--
+	}
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+}
+
+// scheduleRefresh runs a due refresh after a random jitter, once the provider's concurrency
+// and rate limits allow it.
+func (m *Manager) scheduleRefresh(ctx context.Context, id, provider string) {
+	defer m.refreshes.dequeue(id)
+	s := m.refreshSettingsFor(provider)
+	if s.jitter > 0 {
+		timer := time.NewTimer(rand.N(s.jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+	if !m.refreshes.acquire(ctx, provider, s) {
+		return
+	}
+	defer m.refreshes.release(provider)
+	m.refreshAuth(ctx, id)
+}
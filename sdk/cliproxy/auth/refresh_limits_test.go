@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// refreshCountingExecutor blocks every refresh on gate and records the peak in flight.
+type refreshCountingExecutor struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	started  chan string
+	gate     chan struct{}
+}
+
+func (e *refreshCountingExecutor) Identifier() string { return "antigravity" }
+
+func (e *refreshCountingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *refreshCountingExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *refreshCountingExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.mu.Lock()
+	e.inFlight++
+	e.peak = max(e.peak, e.inFlight)
+	e.mu.Unlock()
+	e.started <- auth.ID
+	<-e.gate
+	e.mu.Lock()
+	e.inFlight--
+	e.mu.Unlock()
+	return auth, nil
+}
+
+func (e *refreshCountingExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *refreshCountingExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestScheduleRefreshCapsConcurrencyPerProvider(t *testing.T) {
+	exec := &refreshCountingExecutor{started: make(chan string, 8), gate: make(chan struct{})}
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{RefreshLimits: internalconfig.RefreshLimitsConfig{
+		RefreshLimitSet: internalconfig.RefreshLimitSet{MaxConcurrent: 8, JitterSeconds: -1},
+		Providers:       map[string]internalconfig.RefreshLimitSet{"antigravity": {MaxConcurrent: 2}},
+	}})
+	m.RegisterExecutor(exec)
+	ids := []string{"ag-1", "ag-2", "ag-3", "ag-4", "ag-5"}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "antigravity"}); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+		if !m.refreshes.enqueue(id) {
+			t.Fatalf("enqueue %s failed", id)
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			m.scheduleRefresh(context.Background(), id, "antigravity")
+		}(id)
+	}
+	if m.refreshes.enqueue("ag-1") {
+		t.Fatal("an auth with a scheduled refresh must not be queued twice")
+	}
+
+	<-exec.started
+	<-exec.started
+	select {
+	case id := <-exec.started:
+		t.Fatalf("refresh of %s started beyond the concurrency cap", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(exec.gate)
+	wg.Wait()
+	if exec.peak != 2 {
+		t.Fatalf("peak concurrent refreshes = %d, want 2", exec.peak)
+	}
+	if !m.refreshes.enqueue("ag-1") {
+		t.Fatal("finished refreshes must leave the queue")
+	}
+}
+
+func TestRefreshLimiterRate(t *testing.T) {
+	var l refreshLimiter
+	s := refreshSettings{perMinute: 2}
+	now := time.Now()
+	l.starts = map[string][]time.Time{"antigravity": {now.Add(-70 * time.Second), now.Add(-40 * time.Second), now.Add(-10 * time.Second)}}
+	if wait := l.waitLocked("antigravity", s, now); wait != 20*time.Second {
+		t.Fatalf("wait = %s, want 20s until the oldest start in the window expires", wait)
+	}
+	if wait := l.waitLocked("claude", s, now); wait != 0 {
+		t.Fatalf("other provider wait = %s, want 0", wait)
+	}
+}
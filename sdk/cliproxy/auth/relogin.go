@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MetadataNeedsRelogin stores when a refresh was permanently rejected, so the flag survives
+// restarts until the credential file is replaced by a new login.
+const MetadataNeedsRelogin = "needs_relogin_at"
+
+// permanentRefreshErrors are error fragments returned by OAuth token endpoints, or by the
+// executors themselves, when retrying the refresh cannot succeed without a new login.
+var permanentRefreshErrors = []string{
+	"invalid_grant",
+	"unauthorized_client",
+	"invalid_client",
+	"refresh_token_reused",
+	"refresh_token_expired",
+	"missing refresh token",
+	"refresh token is required",
+}
+
+// IsPermanentRefreshError reports whether err means the refresh token was rejected for good,
+// as opposed to a transient network or server failure.
+func IsPermanentRefreshError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range permanentRefreshErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsRelogin reports whether the auth was flagged because its refresh token was rejected.
+func (a *Auth) NeedsRelogin() bool {
+	if a == nil {
+		return false
+	}
+	if a.Status == StatusNeedsRelogin {
+		return true
+	}
+	_, ok := a.Metadata[MetadataNeedsRelogin]
+	return ok
+}
+
+// markNeedsRelogin flags the auth as unusable until its owner logs in again.
+func markNeedsRelogin(a *Auth, err error, now time.Time) {
+	a.Status = StatusNeedsRelogin
+	a.StatusMessage = "refresh token rejected; re-login required"
+	a.LastError = &Error{Code: "needs_relogin", Message: err.Error()}
+	a.NextRefreshAfter = time.Time{}
+	a.UpdatedAt = now
+	if a.FailingSince.IsZero() {
+		a.FailingSince = now
+	}
+	if a.Metadata == nil {
+		a.Metadata = make(map[string]any)
+	}
+	a.Metadata[MetadataNeedsRelogin] = now.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// failingRefreshExecutor fails every refresh with err.
+type failingRefreshExecutor struct {
+	err error
+}
+
+func (e *failingRefreshExecutor) Identifier() string { return "codex" }
+
+func (e *failingRefreshExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *failingRefreshExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *failingRefreshExecutor) Refresh(context.Context, *Auth) (*Auth, error) {
+	return nil, e.err
+}
+
+func (e *failingRefreshExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *failingRefreshExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestRefreshAuthClassifiesFailures(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"invalid grant", errors.New(`token refresh failed with status 400: {"error":"invalid_grant","error_description":"Token has been expired or revoked."}`), true},
+		{"missing refresh token", errors.New("missing refresh token"), true},
+		{"server error", errors.New("token refresh failed with status 503: upstream unavailable"), false},
+		{"network", errors.New("dial tcp: i/o timeout"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewManager(nil, nil, nil)
+			m.RegisterExecutor(&failingRefreshExecutor{err: tc.err})
+			if _, err := m.Register(context.Background(), &Auth{ID: "codex-1", Provider: "codex", Status: StatusActive}); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+			m.refreshAuth(context.Background(), "codex-1")
+
+			got, _ := m.GetByID("codex-1")
+			now := time.Now()
+			if got.NeedsRelogin() != tc.permanent {
+				t.Fatalf("NeedsRelogin = %v, want %v (status %q)", got.NeedsRelogin(), tc.permanent, got.Status)
+			}
+			blocked, reason, _ := isAuthBlockedForModel(got, "", now)
+			if tc.permanent {
+				if got.Status != StatusNeedsRelogin || got.Metadata[MetadataNeedsRelogin] == nil {
+					t.Fatalf("flagged auth = status %q, metadata %v", got.Status, got.Metadata)
+				}
+				if !blocked || reason != blockReasonNeedsRelogin {
+					t.Fatalf("flagged auth must be excluded from selection, blocked=%v reason=%v", blocked, reason)
+				}
+				if m.shouldRefresh(got, now.Add(24*time.Hour)) {
+					t.Fatal("flagged auth must not be refreshed again")
+				}
+				return
+			}
+			if blocked || got.NextRefreshAfter.Before(now) {
+				t.Fatalf("transient failure must back off without blocking, blocked=%v next=%v", blocked, got.NextRefreshAfter)
+			}
+		})
+	}
+}
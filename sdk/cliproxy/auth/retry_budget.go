@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"sync/atomic"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type retryBudgetContextKey struct{}
+
+// retryBudget counts the upstream attempts left to a request that capped its retries.
+type retryBudget struct {
+	left atomic.Int64
+}
+
+// withRetryBudget attaches the attempt budget requested through
+// cliproxyexecutor.MaxRetriesMetadataKey to ctx. Requests without a cap are unchanged.
+func withRetryBudget(ctx context.Context, opts cliproxyexecutor.Options) context.Context {
+	if opts.Metadata == nil {
+		return ctx
+	}
+	var retries int64
+	switch v := opts.Metadata[cliproxyexecutor.MaxRetriesMetadataKey].(type) {
+	case int:
+		retries = int64(v)
+	case int64:
+		retries = v
+	default:
+		return ctx
+	}
+	budget := &retryBudget{}
+	budget.left.Store(max(retries, 0) + 1)
+	return context.WithValue(ctx, retryBudgetContextKey{}, budget)
+}
+
+// takeAttempt consumes one attempt of the request's budget and reports whether one was left.
+func takeAttempt(ctx context.Context) bool {
+	budget, ok := ctx.Value(retryBudgetContextKey{}).(*retryBudget)
+	if !ok {
+		return true
+	}
+	return budget.left.Add(-1) >= 0
+}
+
+// attemptsLeft reports whether the request's budget allows another attempt.
+func attemptsLeft(ctx context.Context) bool {
+	budget, ok := ctx.Value(retryBudgetContextKey{}).(*retryBudget)
+	return !ok || budget.left.Load() > 0
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type failingExecutor struct {
+	calls atomic.Int32
+}
+
+func (e *failingExecutor) Identifier() string { return "claude" }
+
+func (e *failingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.calls.Add(1)
+	return cliproxyexecutor.Response{}, &Error{Message: "upstream unavailable", HTTPStatus: http.StatusServiceUnavailable}
+}
+
+func (e *failingExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	e.calls.Add(1)
+	return nil, &Error{Message: "upstream unavailable", HTTPStatus: http.StatusServiceUnavailable}
+}
+
+func (e *failingExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *failingExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *failingExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestExecuteHonoursRequestRetryCap(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		metadata  map[string]any
+		wantCalls int32
+	}{
+		{"server default tries every credential", nil, 3},
+		{"no retries", map[string]any{cliproxyexecutor.MaxRetriesMetadataKey: 0}, 1},
+		{"one retry", map[string]any{cliproxyexecutor.MaxRetriesMetadataKey: 1}, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &failingExecutor{}
+			m := NewManager(nil, nil, nil)
+			m.RegisterExecutor(executor)
+			for _, id := range []string{"a", "b", "c"} {
+				if _, errRegister := m.Register(context.Background(), &Auth{ID: id, Provider: "claude"}); errRegister != nil {
+					t.Fatalf("register: %v", errRegister)
+				}
+			}
+			opts := cliproxyexecutor.Options{Metadata: tc.metadata}
+			_, errExec := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{}, opts)
+			var authErr *Error
+			if !errors.As(errExec, &authErr) || authErr.HTTPStatus != http.StatusServiceUnavailable {
+				t.Fatalf("Execute error = %v, want the upstream error", errExec)
+			}
+			if got := executor.calls.Load(); got != tc.wantCalls {
+				t.Fatalf("upstream calls = %d, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+const (
+	defaultSelectionSampleRate = 0.01
+	defaultSelectionBuffer     = 200
+)
+
+// Selection exclusion reasons reported in SelectionDecision.Excluded.
+const (
+	SelectionReasonDisabled         = "disabled"
+	SelectionReasonTried            = "already_tried"
+	SelectionReasonModelUnsupported = "model_unsupported"
+	SelectionReasonNoExecutor       = "executor_missing"
+	SelectionReasonCooldown         = "cooldown"
+	SelectionReasonUnavailable      = "unavailable"
+	SelectionReasonQuotaZero        = "quota_zero"
+	SelectionReasonLowerPriority    = "lower_priority"
+	SelectionReasonNeedsRelogin     = "needs_relogin"
+	SelectionReasonCostlier         = "costlier"
+	SelectionReasonFallback         = "later_fallback"
+)
+
+// SelectionCandidate is an auth the selector could choose from.
+type SelectionCandidate struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider"`
+	Priority int    `json:"priority,omitempty"`
+	// Weight is set by weighted selectors; nil when the selector does not weight candidates.
+	Weight   *int `json:"weight,omitempty"`
+	Selected bool `json:"selected,omitempty"`
+}
+
+// SelectionExclusion is an auth that was not eligible, with the reason.
+type SelectionExclusion struct {
+	AuthID  string     `json:"auth_id"`
+	Reason  string     `json:"reason"`
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// SelectionDecision records how an auth was chosen for a request.
+type SelectionDecision struct {
+	Time       time.Time            `json:"time"`
+	RequestID  string               `json:"request_id,omitempty"`
+	Provider   string               `json:"provider"`
+	Model      string               `json:"model"`
+	Selected   string               `json:"selected,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	Candidates []SelectionCandidate `json:"candidates"`
+	Excluded   []SelectionExclusion `json:"excluded,omitempty"`
+}
+
+// selectionTrace collects details for a sampled selection. A nil trace records nothing.
+type selectionTrace struct {
+	mu       sync.Mutex
+	decision SelectionDecision
+	weights  map[string]int
+	excluded map[string]bool
+}
+
+type selectionTraceKey struct{}
+
+func (t *selectionTrace) exclude(authID, reason string, retryAt time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.excluded[authID] {
+		return
+	}
+	t.excluded[authID] = true
+	exclusion := SelectionExclusion{AuthID: authID, Reason: reason}
+	if !retryAt.IsZero() {
+		at := retryAt
+		exclusion.RetryAt = &at
+	}
+	t.decision.Excluded = append(t.decision.Excluded, exclusion)
+}
+
+func (t *selectionTrace) weight(authID string, weight int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.weights[authID] = weight
+	t.mu.Unlock()
+}
+
+// finish fills in the candidates handed to the selector and the outcome. Candidates the
+// selector skipped are classified with the same rules the built-in selectors apply.
+func (t *selectionTrace) finish(candidates []*Auth, selected *Auth, errPick error, now time.Time) SelectionDecision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	best, haveBest := 0, false
+	for _, candidate := range candidates {
+		if blocked, _, _ := isAuthBlockedForModel(candidate, t.decision.Model, now); !blocked {
+			if p := authPriority(candidate); !haveBest || p > best {
+				best, haveBest = p, true
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		if t.excluded[candidate.ID] {
+			continue
+		}
+		blocked, reason, next := isAuthBlockedForModel(candidate, t.decision.Model, now)
+		switch {
+		case blocked:
+			t.decision.Excluded = append(t.decision.Excluded, blockExclusion(candidate.ID, reason, next))
+			continue
+		case authPriority(candidate) < best:
+			t.decision.Excluded = append(t.decision.Excluded, SelectionExclusion{AuthID: candidate.ID, Reason: SelectionReasonLowerPriority})
+			continue
+		}
+		entry := SelectionCandidate{AuthID: candidate.ID, Provider: candidate.Provider, Priority: authPriority(candidate)}
+		if w, ok := t.weights[candidate.ID]; ok {
+			entry.Weight = &w
+		}
+		entry.Selected = selected != nil && selected.ID == candidate.ID
+		t.decision.Candidates = append(t.decision.Candidates, entry)
+	}
+	if selected != nil {
+		t.decision.Selected = selected.ID
+	}
+	if errPick != nil {
+		t.decision.Error = errPick.Error()
+	}
+	sort.Slice(t.decision.Excluded, func(i, j int) bool { return t.decision.Excluded[i].AuthID < t.decision.Excluded[j].AuthID })
+	return t.decision
+}
+
+func blockExclusion(authID string, reason blockReason, next time.Time) SelectionExclusion {
+	exclusion := SelectionExclusion{AuthID: authID, Reason: SelectionReasonUnavailable}
+	switch reason {
+	case blockReasonCooldown:
+		exclusion.Reason = SelectionReasonCooldown
+	case blockReasonDisabled:
+		exclusion.Reason = SelectionReasonDisabled
+	case blockReasonNeedsRelogin:
+		exclusion.Reason = SelectionReasonNeedsRelogin
+	}
+	if !next.IsZero() {
+		exclusion.RetryAt = &next
+	}
+	return exclusion
+}
+
+func selectionTraceFromContext(ctx context.Context) *selectionTrace {
+	if ctx == nil {
+		return nil
+	}
+	trace, _ := ctx.Value(selectionTraceKey{}).(*selectionTrace)
+	return trace
+}
+
+// recordSelectionWeight lets weighted selectors report the weight given to a candidate.
+func recordSelectionWeight(ctx context.Context, authID string, weight int) {
+	selectionTraceFromContext(ctx).weight(authID, weight)
+}
+
+// recordSelectionExclusion lets selectors report a candidate they skipped.
+func recordSelectionExclusion(ctx context.Context, authID, reason string) {
+	selectionTraceFromContext(ctx).exclude(authID, reason, time.Time{})
+}
+
+// selectionLog keeps recently sampled decisions for the management API.
+type selectionLog struct {
+	mu      sync.Mutex
+	entries []SelectionDecision
+	next    int
+	full    bool
+}
+
+func (l *selectionLog) add(decision SelectionDecision, capacity int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) != capacity {
+		l.entries = make([]SelectionDecision, capacity)
+		l.next, l.full = 0, false
+	}
+	l.entries[l.next] = decision
+	l.next = (l.next + 1) % capacity
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+func (l *selectionLog) snapshot() []SelectionDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.full {
+		return append([]SelectionDecision(nil), l.entries[:l.next]...)
+	}
+	out := make([]SelectionDecision, 0, len(l.entries))
+	out = append(out, l.entries[l.next:]...)
+	return append(out, l.entries[:l.next]...)
+}
+
+// startSelectionTrace returns a trace when routing.decision-log is enabled and the
+// request is sampled, along with a context carrying it to the selector.
+func (m *Manager) startSelectionTrace(ctx context.Context, provider, model string) (context.Context, *selectionTrace) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Routing.DecisionLog.Enabled {
+		return ctx, nil
+	}
+	rate := cfg.Routing.DecisionLog.SampleRate
+	if rate <= 0 {
+		rate = defaultSelectionSampleRate
+	}
+	if rate < 1 && rand.Float64() >= rate {
+		return ctx, nil
+	}
+	trace := &selectionTrace{
+		decision: SelectionDecision{Time: time.Now(), RequestID: logging.GetRequestID(ctx), Provider: provider, Model: model},
+		weights:  make(map[string]int),
+		excluded: make(map[string]bool),
+	}
+	return context.WithValue(ctx, selectionTraceKey{}, trace), trace
+}
+
+// finishSelectionTrace logs a sampled decision and keeps it for SelectionDecisions.
+func (m *Manager) finishSelectionTrace(ctx context.Context, trace *selectionTrace, candidates []*Auth, selected *Auth, errPick error) {
+	if trace == nil {
+		return
+	}
+	decision := trace.finish(candidates, selected, errPick, time.Now())
+	capacity := defaultSelectionBuffer
+	if cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config); cfg != nil && cfg.Routing.DecisionLog.Buffer > 0 {
+		capacity = cfg.Routing.DecisionLog.Buffer
+	}
+	m.selectionLog.add(decision, capacity)
+
+	excluded := make([]string, 0, len(decision.Excluded))
+	for _, e := range decision.Excluded {
+		excluded = append(excluded, e.AuthID+":"+e.Reason)
+	}
+	entry := logEntryWithRequestID(ctx)
+	if decision.Error != "" {
+		entry.Infof("selector decision: provider=%s model=%s candidates=%d excluded=[%s] error=%s", decision.Provider, decision.Model, len(decision.Candidates), strings.Join(excluded, " "), decision.Error)
+		return
+	}
+	entry.Infof("selector decision: provider=%s model=%s selected=%s candidates=%d excluded=[%s]", decision.Provider, decision.Model, decision.Selected, len(decision.Candidates), strings.Join(excluded, " "))
+}
+
+// SelectionDecisions returns the recently sampled selector decisions, oldest first.
+func (m *Manager) SelectionDecisions() []SelectionDecision {
+	if m == nil {
+		return nil
+	}
+	return m.selectionLog.snapshot()
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func TestSelectionTraceRecordsCandidatesAndExclusions(t *testing.T) {
+	model := "claude-sonnet-4-5"
+	now := time.Now()
+	ready := &Auth{ID: "ready", Provider: "antigravity", Metadata: map[string]any{}}
+	empty := &Auth{ID: "empty", Provider: "antigravity", Metadata: map[string]any{}}
+	cooling := &Auth{ID: "cooling", Provider: "antigravity", ModelStates: map[string]*ModelState{
+		model: {Unavailable: true, NextRetryAfter: now.Add(time.Minute), Quota: QuotaState{Exceeded: true}},
+	}}
+	low := &Auth{ID: "low", Provider: "antigravity", Attributes: map[string]string{"priority": "-1"}}
+	quota.UpdateMetadata(ready.Metadata, "antigravity", map[string]quota.ModelQuota{model: {Percent: 80}}, now)
+	quota.UpdateMetadata(empty.Metadata, "antigravity", map[string]quota.ModelQuota{model: {Percent: 0}}, now)
+
+	m := NewManager(nil, &QuotaWeightedSelector{}, nil)
+	m.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		DecisionLog: internalconfig.RoutingDecisionLogConfig{Enabled: true, SampleRate: 1, Buffer: 2},
+	}})
+
+	ctx, trace := m.startSelectionTrace(context.Background(), "antigravity", model)
+	if trace == nil {
+		t.Fatalf("expected a trace with sample-rate 1")
+	}
+	trace.exclude("tried", SelectionReasonTried, time.Time{})
+	candidates := []*Auth{ready, empty, cooling, low}
+	selected, errPick := m.selector.Pick(ctx, "antigravity", model, cliproxyexecutor.Options{}, candidates)
+	if errPick != nil {
+		t.Fatalf("pick: %v", errPick)
+	}
+	m.finishSelectionTrace(ctx, trace, candidates, selected, errPick)
+
+	decisions := m.SelectionDecisions()
+	if len(decisions) != 1 {
+		t.Fatalf("decisions = %d, want 1", len(decisions))
+	}
+	decision := decisions[0]
+	if decision.Selected != "ready" || len(decision.Candidates) != 1 || decision.Candidates[0].Weight == nil || !decision.Candidates[0].Selected {
+		t.Fatalf("unexpected candidates: %+v", decision)
+	}
+	reasons := make(map[string]string)
+	for _, e := range decision.Excluded {
+		reasons[e.AuthID] = e.Reason
+	}
+	want := map[string]string{
+		"tried":   SelectionReasonTried,
+		"empty":   SelectionReasonQuotaZero,
+		"cooling": SelectionReasonCooldown,
+		"low":     SelectionReasonLowerPriority,
+	}
+	for id, reason := range want {
+		if reasons[id] != reason {
+			t.Errorf("%s excluded as %q, want %q", id, reasons[id], reason)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		m.finishSelectionTrace(context.Background(), &selectionTrace{decision: SelectionDecision{Model: "m"}, weights: map[string]int{}, excluded: map[string]bool{}}, nil, nil, nil)
+	}
+	if got := len(m.SelectionDecisions()); got != 2 {
+		t.Fatalf("buffer holds %d decisions, want 2", got)
+	}
+}
+
+func TestSelectionTraceDisabledByDefault(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	if _, trace := m.startSelectionTrace(context.Background(), "claude", "m"); trace != nil {
+		t.Fatalf("expected no trace when routing.decision-log is disabled")
+	}
+}
@@ -32,6 +32,7 @@ const (
 	blockReasonNone blockReason = iota
 	blockReasonCooldown
 	blockReasonDisabled
+	blockReasonNeedsRelogin
 	blockReasonOther
 )
 
@@ -93,6 +94,17 @@ func (e *modelCooldownError) StatusCode() int {
 	return http.StatusTooManyRequests
 }
 
+// Is reports a cooldown as cliproxyexecutor.ErrQuotaExceeded.
+func (e *modelCooldownError) Is(target error) bool {
+	return target == cliproxyexecutor.ErrQuotaExceeded
+}
+
+// RetryAfter returns the time until the first credential leaves its cooldown.
+func (e *modelCooldownError) RetryAfter() *time.Duration {
+	resetIn := e.resetIn
+	return &resetIn
+}
+
 func (e *modelCooldownError) Headers() http.Header {
 	headers := make(http.Header)
 	headers.Set("Content-Type", "application/json")
@@ -186,6 +198,9 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 		return nil, err
 	}
 	key := provider + ":" + model
+	if index, ok := clusterOffset(key); ok {
+		return available[index%len(available)], nil
+	}
 	s.mu.Lock()
 	if s.cursors == nil {
 		s.cursors = make(map[string]int)
@@ -221,6 +236,9 @@ func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, block
 	if auth.Disabled || auth.Status == StatusDisabled {
 		return true, blockReasonDisabled, time.Time{}
 	}
+	if auth.NeedsRelogin() {
+		return true, blockReasonNeedsRelogin, time.Time{}
+	}
 	if model != "" {
 		if len(auth.ModelStates) > 0 {
 			if state, ok := auth.ModelStates[model]; ok && state != nil {
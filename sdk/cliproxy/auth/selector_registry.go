@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+// DefaultSelectorName is the routing strategy used when none or an unknown one is configured.
+const DefaultSelectorName = "round-robin"
+
+// SelectorOptions carries the shared state a selector may use.
+type SelectorOptions struct {
+	// QuotaStore holds the polled and observed quota of each auth, whatever the strategy.
+	// It is nil when the store could not be opened or the manager was built without one;
+	// quota is then only found in auth metadata.
+	QuotaStore *quota.Store
+}
+
+// SelectorFactory builds a selector for a routing strategy. It is called when the service
+// starts and whenever routing.strategy changes to the strategy.
+type SelectorFactory func(opts SelectorOptions) Selector
+
+var (
+	selectorMu        sync.RWMutex
+	selectorFactories = map[string]SelectorFactory{
+		"round-robin": func(SelectorOptions) Selector { return &RoundRobinSelector{} },
+		"fill-first":  func(SelectorOptions) Selector { return &FillFirstSelector{} },
+		"quota-weighted": func(opts SelectorOptions) Selector {
+			return NewQuotaWeightedSelectorWithStore(opts.QuotaStore)
+		},
+		"cost":   func(opts SelectorOptions) Selector { return NewCostSelectorWithStore(opts.QuotaStore) },
+		"sticky": func(SelectorOptions) Selector { return &StickySelector{} },
+	}
+	// selectorAliases maps alternative spellings of the built-in strategies to their names.
+	selectorAliases = map[string]string{
+		"":               "round-robin",
+		"roundrobin":     "round-robin",
+		"rr":             "round-robin",
+		"fillfirst":      "fill-first",
+		"ff":             "fill-first",
+		"quota-weight":   "quota-weighted",
+		"quota":          "quota-weighted",
+		"qw":             "quota-weighted",
+		"cost-aware":     "cost",
+		"cheapest":       "cost",
+		"sticky-session": "sticky",
+		"session":        "sticky",
+	}
+)
+
+// RegisterSelector makes a custom selector available as routing.strategy name, so
+// embedders can plug in their own routing policy. Names are case-insensitive; registering
+// an existing name, including a built-in one, replaces its factory.
+func RegisterSelector(name string, factory SelectorFactory) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || factory == nil {
+		return
+	}
+	selectorMu.Lock()
+	selectorFactories[name] = factory
+	selectorMu.Unlock()
+}
+
+// CanonicalSelectorName resolves a configured routing strategy to the name it is registered
+// under. ok is false for unknown strategies.
+func CanonicalSelectorName(strategy string) (name string, ok bool) {
+	name = strings.ToLower(strings.TrimSpace(strategy))
+	selectorMu.RLock()
+	defer selectorMu.RUnlock()
+	if _, exists := selectorFactories[name]; exists {
+		return name, true
+	}
+	if alias, exists := selectorAliases[name]; exists {
+		return alias, true
+	}
+	return "", false
+}
+
+// NewSelectorByName builds the selector of a routing strategy. Unknown strategies fall
+// back to round-robin; the returned name is the strategy actually used.
+func NewSelectorByName(strategy string, opts SelectorOptions) (Selector, string) {
+	name, ok := CanonicalSelectorName(strategy)
+	if !ok {
+		name = DefaultSelectorName
+	}
+	selectorMu.RLock()
+	factory := selectorFactories[name]
+	selectorMu.RUnlock()
+	if selector := factory(opts); selector != nil {
+		return selector, name
+	}
+	return &RoundRobinSelector{}, DefaultSelectorName
+}
+
+// SelectorNames lists the registered routing strategies.
+func SelectorNames() []string {
+	selectorMu.RLock()
+	names := make([]string, 0, len(selectorFactories))
+	for name := range selectorFactories {
+		names = append(names, name)
+	}
+	selectorMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+type lastAuthSelector struct {
+	store *quota.Store
+}
+
+func (s *lastAuthSelector) Pick(_ context.Context, _, _ string, _ cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	return auths[len(auths)-1], nil
+}
+
+func TestRegisterSelectorMakesStrategyAvailable(t *testing.T) {
+	store, err := quota.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("quota store: %v", err)
+	}
+	RegisterSelector("Last-Auth", func(opts SelectorOptions) Selector { return &lastAuthSelector{store: opts.QuotaStore} })
+	t.Cleanup(func() {
+		selectorMu.Lock()
+		delete(selectorFactories, "last-auth")
+		selectorMu.Unlock()
+	})
+
+	if name, ok := CanonicalSelectorName(" last-auth "); !ok || name != "last-auth" {
+		t.Fatalf("CanonicalSelectorName = %q, %v", name, ok)
+	}
+	selector, name := NewSelectorByName("LAST-AUTH", SelectorOptions{QuotaStore: store})
+	custom, ok := selector.(*lastAuthSelector)
+	if !ok || name != "last-auth" || custom.store != store {
+		t.Fatalf("NewSelectorByName = %T %q", selector, name)
+	}
+
+	if name, ok := CanonicalSelectorName("qw"); !ok || name != "quota-weighted" {
+		t.Fatalf("alias resolved to %q, %v", name, ok)
+	}
+	if _, ok := CanonicalSelectorName("unknown"); ok {
+		t.Fatal("unknown strategy resolved")
+	}
+	if selector, name := NewSelectorByName("unknown", SelectorOptions{}); name != DefaultSelectorName {
+		t.Fatalf("unknown strategy built %T %q", selector, name)
+	}
+}
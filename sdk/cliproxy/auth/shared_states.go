@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	log "github.com/sirupsen/logrus"
+)
+
+// ShareModelStates exchanges per-model states through a quota store shared with other proxy
+// replicas: the states of an auth are published after every result, and states other
+// replicas published are applied when the store syncs, so a model cooling down on one
+// replica is skipped by all of them. Per model, the most recently updated state wins.
+func (m *Manager) ShareModelStates(store *quota.Store) {
+	if m == nil || store == nil {
+		return
+	}
+	m.sharedStates.Store(store)
+	store.OnSync(m.applySharedModelStates)
+}
+
+// publishModelStates writes the model states of auth to the shared store. It is called with
+// m.mu held.
+func (m *Manager) publishModelStates(auth *Auth) {
+	store := m.sharedStates.Load()
+	if store == nil || auth == nil || len(auth.ModelStates) == 0 {
+		return
+	}
+	raw, err := json.Marshal(auth.ModelStates)
+	if err != nil {
+		log.Debugf("failed to encode model states of %s: %v", auth.ID, err)
+		return
+	}
+	store.SetModelStates(auth.ID, auth.Provider, raw)
+}
+
+// applySharedModelStates merges the model states another replica published for an auth.
+func (m *Manager) applySharedModelStates(authID string, entry *quota.StoreEntry) {
+	if entry == nil || len(entry.ModelStates) == 0 {
+		return
+	}
+	var remote map[string]*ModelState
+	if err := json.Unmarshal(entry.ModelStates, &remote); err != nil {
+		log.Debugf("failed to decode shared model states of %s: %v", authID, err)
+		return
+	}
+
+	applied := make(map[string]ModelState)
+	m.mu.Lock()
+	if auth, ok := m.auths[authID]; ok && auth != nil {
+		for model, state := range remote {
+			if state == nil {
+				continue
+			}
+			if local := auth.ModelStates[model]; local != nil && !state.UpdatedAt.After(local.UpdatedAt) {
+				continue
+			}
+			if auth.ModelStates == nil {
+				auth.ModelStates = make(map[string]*ModelState)
+			}
+			auth.ModelStates[model] = state
+			applied[model] = *state
+		}
+		if len(applied) > 0 {
+			updateAggregatedAvailability(auth, time.Now())
+		}
+	}
+	m.mu.Unlock()
+
+	reg := registry.GetGlobalRegistry()
+	for model, state := range applied {
+		switch {
+		case state.Quota.Exceeded:
+			reg.SetModelQuotaExceeded(authID, model)
+			reg.SuspendClientModel(authID, model, "quota")
+		case !state.Unavailable:
+			reg.ClearModelQuotaExceeded(authID, model)
+			reg.ResumeClientModel(authID, model)
+		}
+	}
+}
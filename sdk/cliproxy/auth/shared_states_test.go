@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+)
+
+func TestShareModelStatesPropagatesCooldownsBetweenReplicas(t *testing.T) {
+	dir := t.TempDir()
+	replicas := make([]*Manager, 2)
+	stores := make([]*quota.Store, 2)
+	for i := range replicas {
+		store, err := quota.NewStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := NewManager(nil, &RoundRobinSelector{}, nil)
+		if _, err = m.Register(context.Background(), &Auth{ID: "shared-auth", Provider: "gemini"}); err != nil {
+			t.Fatal(err)
+		}
+		m.ShareModelStates(store)
+		replicas[i], stores[i] = m, store
+	}
+
+	replicas[0].MarkResult(context.Background(), Result{
+		AuthID:   "shared-auth",
+		Provider: "gemini",
+		Model:    "gemini-2.5-pro",
+		Error:    &Error{HTTPStatus: 429, Message: "quota exhausted"},
+	})
+	if err := stores[0].Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stores[1].Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, ok := replicas[1].GetByID("shared-auth")
+	if !ok {
+		t.Fatal("auth missing on second replica")
+	}
+	state := auth.ModelStates["gemini-2.5-pro"]
+	if state == nil || !state.Unavailable || !state.Quota.Exceeded {
+		t.Fatalf("cooldown was not shared with the second replica: %+v", state)
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultStandbyErrorRate   = 0.2
+	defaultStandbyMinRequests = 5
+	defaultStandbyInterval    = 5 * time.Minute
+	standbyWarmTimeout        = 15 * time.Second
+	standbyWarmAuthLimit      = 3
+)
+
+// StandbyWarmer is implemented by executors that can open upstream connections ahead of
+// the first real request, typically with a cheap probe against the provider endpoint.
+type StandbyWarmer interface {
+	Warm(ctx context.Context, auth *Auth) error
+}
+
+// standbyPrewarm remembers when each standby provider was last warmed.
+type standbyPrewarm struct {
+	mu     sync.Mutex
+	warmed map[string]time.Time
+}
+
+// claim reports whether provider may be warmed now and records the attempt.
+func (s *standbyPrewarm) claim(provider string, now time.Time, interval time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.warmed[provider]; ok && now.Sub(last) < interval {
+		return false
+	}
+	if s.warmed == nil {
+		s.warmed = make(map[string]time.Time)
+	}
+	s.warmed[provider] = now
+	return true
+}
+
+func (m *Manager) standbyPrewarmConfig() (internalconfig.StandbyPrewarmConfig, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.StandbyPrewarm.Enabled {
+		return internalconfig.StandbyPrewarmConfig{}, false
+	}
+	return cfg.StandbyPrewarm, true
+}
+
+func (m *Manager) standbyPrewarmEnabled() bool {
+	_, ok := m.standbyPrewarmConfig()
+	return ok
+}
+
+// providerDegraded reports whether provider is shedding or its recent error rate crossed the
+// standby pre-warm threshold.
+func (m *Manager) providerDegraded(provider string, now time.Time, cfg internalconfig.StandbyPrewarmConfig) bool {
+	shedCfg, _ := m.loadSheddingConfig()
+	settings := resolveSheddingSettings(shedCfg)
+	if m.shedder.isShedding(provider, now, settings) {
+		return true
+	}
+	threshold := cfg.ErrorRate
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultStandbyErrorRate
+	}
+	minRequests := cfg.MinRequests
+	if minRequests <= 0 {
+		minRequests = defaultStandbyMinRequests
+	}
+	requests, rate := m.shedder.errorRate(provider, now, settings.window)
+	return requests >= minRequests && rate >= threshold
+}
+
+// prewarmStandby warms the healthy providers of a model in the background as soon as another
+// provider of the same model degrades, so the first failover does not pay for token refresh
+// and connection setup.
+func (m *Manager) prewarmStandby(providers []string, model string) {
+	cfg, ok := m.standbyPrewarmConfig()
+	if !ok || len(providers) < 2 {
+		return
+	}
+	now := time.Now()
+	var degraded, standby []string
+	for _, provider := range providers {
+		if m.providerDegraded(provider, now, cfg) {
+			degraded = append(degraded, provider)
+		} else {
+			standby = append(standby, provider)
+		}
+	}
+	if len(degraded) == 0 {
+		return
+	}
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultStandbyInterval
+	}
+	for _, provider := range standby {
+		if !m.standby.claim(provider, now, interval) {
+			continue
+		}
+		log.Infof("standby prewarm: warming provider %s for model %s (degraded: %s)", provider, model, strings.Join(degraded, ", "))
+		go m.warmStandbyProvider(provider, model)
+	}
+}
+
+// warmStandbyProvider refreshes credentials that are due and probes the provider endpoint
+// with the first eligible auth.
+func (m *Manager) warmStandbyProvider(provider, model string) {
+	ctx, cancel := context.WithTimeout(context.Background(), standbyWarmTimeout)
+	defer cancel()
+
+	auths := m.standbyAuths(provider, model)
+	if len(auths) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, a := range auths {
+		if m.shouldRefresh(a, now) && m.markRefreshPending(a.ID, now) {
+			m.refreshAuth(ctx, a.ID)
+		}
+	}
+
+	warmer, ok := m.executorFor(provider).(StandbyWarmer)
+	if !ok {
+		return
+	}
+	auth, found := m.GetByID(auths[0].ID)
+	if !found {
+		return
+	}
+	warmCtx := ctx
+	if rt := m.roundTripperFor(auth); rt != nil {
+		warmCtx = context.WithValue(warmCtx, roundTripperContextKey{}, rt)
+		warmCtx = context.WithValue(warmCtx, "cliproxy.roundtripper", rt)
+	}
+	if errWarm := warmer.Warm(warmCtx, auth); errWarm != nil {
+		log.Debugf("standby prewarm: probe for provider %s (auth %s) failed: %v", provider, auth.ID, errWarm)
+	}
+}
+
+// standbyAuths returns up to standbyWarmAuthLimit enabled auths of provider serving model.
+func (m *Manager) standbyAuths(provider, model string) []*Auth {
+	modelKey := strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(modelKey); parsed.ModelName != "" {
+		modelKey = strings.TrimSpace(parsed.ModelName)
+	}
+	registryRef := registry.GetGlobalRegistry()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*Auth, 0, standbyWarmAuthLimit)
+	for _, candidate := range m.auths {
+		if candidate.Provider != provider || candidate.Disabled {
+			continue
+		}
+		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
+			continue
+		}
+		out = append(out, candidate.Clone())
+		if len(out) == standbyWarmAuthLimit {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type warmingExecutor struct {
+	provider string
+	warmed   chan string
+}
+
+func (e *warmingExecutor) Identifier() string { return e.provider }
+
+func (e *warmingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *warmingExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *warmingExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *warmingExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *warmingExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func (e *warmingExecutor) Warm(_ context.Context, auth *Auth) error {
+	e.warmed <- auth.ID
+	return nil
+}
+
+func TestPrewarmStandbyWarmsHealthyProviderOnce(t *testing.T) {
+	primary := &warmingExecutor{provider: "claude", warmed: make(chan string, 4)}
+	standby := &warmingExecutor{provider: "antigravity", warmed: make(chan string, 4)}
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{StandbyPrewarm: internalconfig.StandbyPrewarmConfig{Enabled: true, MinRequests: 2}})
+	m.RegisterExecutor(primary)
+	m.RegisterExecutor(standby)
+	if _, errRegister := m.Register(context.Background(), &Auth{ID: "standby-1", Provider: "antigravity"}); errRegister != nil {
+		t.Fatalf("register: %v", errRegister)
+	}
+
+	providers := []string{"claude", "antigravity"}
+	m.prewarmStandby(providers, "")
+	for i := 0; i < 2; i++ {
+		m.recordProviderHealth(Result{Provider: "claude", Error: &Error{HTTPStatus: http.StatusBadGateway}})
+	}
+	m.prewarmStandby(providers, "")
+	m.prewarmStandby(providers, "")
+
+	select {
+	case id := <-standby.warmed:
+		if id != "standby-1" {
+			t.Fatalf("warmed %s, want standby-1", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("standby provider was not warmed")
+	}
+	select {
+	case id := <-standby.warmed:
+		t.Fatalf("standby warmed twice within the interval (%s)", id)
+	case id := <-primary.warmed:
+		t.Fatalf("degraded provider was warmed (%s)", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
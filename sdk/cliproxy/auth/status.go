@@ -16,4 +16,6 @@ const (
 	StatusError Status = "error"
 	// StatusDisabled marks the auth as intentionally disabled.
 	StatusDisabled Status = "disabled"
+	// StatusNeedsRelogin marks an auth whose refresh token was permanently rejected.
+	StatusNeedsRelogin Status = "needs_relogin"
 )
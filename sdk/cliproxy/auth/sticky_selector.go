@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// stickySessionHeader is the client header that names a conversation explicitly.
+const stickySessionHeader = "X-Session-Id"
+
+// stickySessionPaths are request fields that identify a conversation across turns.
+var stickySessionPaths = []string{"session_id", "metadata.session_id", "metadata.user_id", "prompt_cache_key", "conversation.id", "conversation"}
+
+// StickySelector keeps every turn of a conversation on the same auth, so thinking signatures
+// and server-side prompt caches stay valid. The conversation is identified by the
+// X-Session-Id header, a session field of the request, or else the first user message.
+// Auths are assigned by rendezvous hashing: when the preferred auth is unavailable the
+// conversation moves to its next preference, and adding or removing an auth only moves the
+// conversations that preferred it. Requests without a conversation are balanced round-robin.
+type StickySelector struct {
+	fallback RoundRobinSelector
+}
+
+// Pick selects the auth preferred by the conversation of the request.
+func (s *StickySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	key := conversationKey(ctx, opts)
+	if key == "" {
+		return s.fallback.Pick(ctx, provider, model, opts, auths)
+	}
+	available, err := getAvailableAuths(auths, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var selected *Auth
+	var best uint64
+	for _, candidate := range available {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(candidate.ID))
+		if score := h.Sum64(); selected == nil || score > best {
+			selected, best = candidate, score
+		}
+	}
+	return selected, nil
+}
+
+// conversationKey identifies the conversation of a request, or returns "" when the request
+// carries nothing stable across turns.
+func conversationKey(ctx context.Context, opts cliproxyexecutor.Options) string {
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			if id := strings.TrimSpace(ginCtx.GetHeader(stickySessionHeader)); id != "" {
+				return "header:" + id
+			}
+		}
+	}
+	if id := strings.TrimSpace(opts.Headers.Get(stickySessionHeader)); id != "" {
+		return "header:" + id
+	}
+	body := opts.OriginalRequest
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return ""
+	}
+	for _, path := range stickySessionPaths {
+		if value := gjson.GetBytes(body, path); value.Type == gjson.String && strings.TrimSpace(value.String()) != "" {
+			return path + ":" + value.String()
+		}
+	}
+	if first := firstUserMessage(body); first != "" {
+		sum := sha256.Sum256([]byte(first))
+		return "first-user:" + hex.EncodeToString(sum[:])
+	}
+	return ""
+}
+
+// firstUserMessage returns the raw content of the first user turn in any of the supported
+// request formats.
+func firstUserMessage(body []byte) string {
+	if input := gjson.GetBytes(body, "input"); input.Type == gjson.String {
+		return input.String()
+	}
+	for _, path := range []string{"messages", "contents", "request.contents", "input"} {
+		for _, message := range gjson.GetBytes(body, path).Array() {
+			if message.Get("role").String() != "user" {
+				continue
+			}
+			for _, field := range []string{"content", "parts"} {
+				if content := message.Get(field); content.Exists() {
+					return content.Raw
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestStickySelectorKeepsConversationOnOneAuth(t *testing.T) {
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	selector := &StickySelector{}
+	turn := func(body string) string {
+		got, err := selector.Pick(context.Background(), "mixed", "m", cliproxyexecutor.Options{OriginalRequest: []byte(body)}, auths)
+		if err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+		return got.ID
+	}
+
+	first := turn(`{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hello"}]}`)
+	later := turn(`{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hello"},{"role":"assistant","content":"hi"},{"role":"user","content":"more"}]}`)
+	if first != later {
+		t.Fatalf("conversation moved from %s to %s between turns", first, later)
+	}
+
+	var remaining []*Auth
+	for _, auth := range auths {
+		if auth.ID != first {
+			remaining = append(remaining, auth)
+		}
+	}
+	moved, err := selector.Pick(context.Background(), "mixed", "m", cliproxyexecutor.Options{OriginalRequest: []byte(`{"messages":[{"role":"user","content":"hello"}]}`)}, remaining)
+	if err != nil || moved == nil || moved.ID == first {
+		t.Fatalf("Pick() without the preferred auth = %v, %v", moved, err)
+	}
+}
+
+func TestStickySelectorPrefersSessionHeader(t *testing.T) {
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	selector := &StickySelector{}
+	picks := map[string]bool{}
+	for _, body := range []string{`{"messages":[{"role":"user","content":"one"}]}`, `{"messages":[{"role":"user","content":"two"}]}`} {
+		opts := cliproxyexecutor.Options{OriginalRequest: []byte(body), Headers: http.Header{"X-Session-Id": {"session-1"}}}
+		got, err := selector.Pick(context.Background(), "mixed", "m", opts, auths)
+		if err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+		picks[got.ID] = true
+	}
+	if len(picks) != 1 {
+		t.Fatalf("one session spread over %v", picks)
+	}
+
+	if key := conversationKey(context.Background(), cliproxyexecutor.Options{OriginalRequest: []byte(`{"model":"m"}`)}); key != "" {
+		t.Fatalf("conversationKey of a request without conversation = %q", key)
+	}
+}
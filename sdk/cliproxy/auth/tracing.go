@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+)
+
+// startExecuteSpan records one Execute, ExecuteStream or ExecuteCount call, which spans all
+// auth attempts and cooldown retries for the request.
+func startExecuteSpan(ctx context.Context, name string, providers []string, model string) (context.Context, *tracing.Span) {
+	return tracing.Start(ctx, name, tracing.KindInternal,
+		tracing.String("gen_ai.request.model", model),
+		tracing.String("cliproxy.providers", strings.Join(providers, ",")),
+	)
+}
+
+// startAttemptSpan records one executor call against a selected auth. The auth is
+// identified by its index rather than its ID, which often contains an email address.
+func startAttemptSpan(ctx context.Context, auth *Auth, provider, model string) (context.Context, *tracing.Span) {
+	return tracing.Start(ctx, "cliproxy.attempt", tracing.KindInternal,
+		tracing.String("cliproxy.auth_index", auth.EnsureIndex()),
+		tracing.String("cliproxy.provider", provider),
+		tracing.String("gen_ai.request.model", model),
+	)
+}
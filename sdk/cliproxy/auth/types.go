@@ -56,6 +56,10 @@ type Auth struct {
 	NextRefreshAfter time.Time `json:"next_refresh_after"`
 	// NextRetryAfter is the earliest time a retry should retrigger.
 	NextRetryAfter time.Time `json:"next_retry_after"`
+	// LastUsedAt records the last successful request served by this auth.
+	LastUsedAt time.Time `json:"last_used_at"`
+	// FailingSince marks the first failure of the current unbroken failure streak.
+	FailingSince time.Time `json:"failing_since"`
 	// ModelStates tracks per-model runtime availability data.
 	ModelStates map[string]*ModelState `json:"model_states,omitempty"`
 
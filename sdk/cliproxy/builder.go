@@ -5,7 +5,6 @@ package cliproxy
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -204,27 +203,17 @@ func (b *Builder) Build() (*Service, error) {
 
 		strategy := ""
 		if b.cfg != nil {
-			strategy = strings.ToLower(strings.TrimSpace(b.cfg.Routing.Strategy))
+			strategy = b.cfg.Routing.Strategy
 		}
+		strategy, _ = coreauth.CanonicalSelectorName(strategy)
 
-		var qs *quota.Store
-		if strategy == "quota-weighted" || strategy == "quota-weight" || strategy == "quota" || strategy == "qw" {
-			var storeErr error
-			qs, storeErr = quota.NewStore("")
-			if storeErr != nil {
-				log.WithError(storeErr).Warn("failed to create quota store, falling back to metadata storage")
-			}
+		// Every strategy gets the quota store, so custom selectors can weigh quota too.
+		qs, storeErr := newQuotaStore(b.cfg)
+		if storeErr != nil {
+			log.WithError(storeErr).Warn("failed to create quota store, falling back to metadata storage")
 		}
 
-		var selector coreauth.Selector
-		switch strategy {
-		case "fill-first", "fillfirst", "ff":
-			selector = &coreauth.FillFirstSelector{}
-		case "quota-weighted", "quota-weight", "quota", "qw":
-			selector = coreauth.NewQuotaWeightedSelectorWithStore(qs)
-		default:
-			selector = &coreauth.RoundRobinSelector{}
-		}
+		selector, _ := coreauth.NewSelectorByName(strategy, coreauth.SelectorOptions{QuotaStore: qs})
 
 		coreManager = coreauth.NewManager(tokenStore, selector, nil)
 		quotaStore = qs
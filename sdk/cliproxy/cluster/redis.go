@@ -0,0 +1,112 @@
+// Package cluster coordinates credential usage between CLIProxyAPI instances that share
+// credentials, so replicas behind a load balancer rotate through them together instead of
+// each starting from the first one.
+package cluster
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redis"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultKeyPrefix prefixes every key the cluster writes when no prefix is configured.
+const DefaultKeyPrefix = "cliproxy:"
+
+// leaseTimeout bounds a round-robin lease, which is taken while a request waits.
+const leaseTimeout = 250 * time.Millisecond
+
+// unlockScript deletes a lock only while it still holds the caller's token, so an expired
+// lock taken over by another instance is not released by its previous holder.
+const unlockScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// RedisCoordinator implements coreauth.Coordinator on a Redis server: round-robin offsets
+// are shared counters and locks are keys set with NX and an expiry.
+type RedisCoordinator struct {
+	client *redis.Client
+	prefix string
+	node   string
+}
+
+var _ coreauth.Coordinator = (*RedisCoordinator)(nil)
+
+// NewRedisCoordinator connects to the Redis server at rawURL. Keys are prefixed with
+// prefix, DefaultKeyPrefix when empty, so several clusters can share one server.
+func NewRedisCoordinator(rawURL, prefix string) (*RedisCoordinator, error) {
+	client, err := redis.New(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: %w", err)
+	}
+	if _, err = client.Do("PING"); err != nil {
+		return nil, fmt.Errorf("cluster: %w", err)
+	}
+	if strings.TrimSpace(prefix) == "" {
+		prefix = DefaultKeyPrefix
+	}
+	node := make([]byte, 8)
+	_, _ = rand.Read(node)
+	return &RedisCoordinator{client: client, prefix: prefix, node: hex.EncodeToString(node)}, nil
+}
+
+// Node returns the random ID this instance holds locks under.
+func (c *RedisCoordinator) Node() string { return c.node }
+
+// LeaseOffsets adds n to the shared counter named key. INCRBY is not idempotent, so it is
+// sent once with a short deadline and never retried.
+func (c *RedisCoordinator) LeaseOffsets(key string, n int64) (int64, error) {
+	reply, err := c.client.DoOnce(leaseTimeout, "INCRBY", c.prefix+key, strconv.FormatInt(n, 10))
+	if err != nil {
+		return 0, err
+	}
+	end, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("cluster: unexpected INCRBY reply %v", reply)
+	}
+	return end, nil
+}
+
+// TryLock sets the lock key unless another instance holds it.
+func (c *RedisCoordinator) TryLock(key string, ttl time.Duration) (func(), bool, error) {
+	token := c.node + ":" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	reply, err := c.client.Do("SET", c.prefix+"lock:"+key, token, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	unlock := func() {
+		if _, errUnlock := c.client.Do("EVAL", unlockScript, "1", c.prefix+"lock:"+key, token); errUnlock != nil {
+			log.Debugf("cluster: failed to release lock %s: %v", key, errUnlock)
+		}
+	}
+	return unlock, true, nil
+}
+
+// Get reads the shared value named key.
+func (c *RedisCoordinator) Get(key string) (string, bool, error) {
+	reply, err := c.client.Do("GET", c.prefix+key)
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return "", false, fmt.Errorf("cluster: unexpected GET reply %v", reply)
+	}
+	return value, true, nil
+}
+
+// Set stores the shared value named key for ttl.
+func (c *RedisCoordinator) Set(key, value string, ttl time.Duration) error {
+	_, err := c.client.Do("SET", c.prefix+key, value, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Close closes the connection to Redis.
+func (c *RedisCoordinator) Close() { c.client.Close() }
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redis/redistest"
+)
+
+func TestRedisCoordinatorSharesOffsetsAndLocks(t *testing.T) {
+	url := redistest.Start(t)
+	a, err := NewRedisCoordinator(url, "")
+	if err != nil {
+		t.Fatalf("NewRedisCoordinator: %v", err)
+	}
+	b, err := NewRedisCoordinator(url, "")
+	if err != nil {
+		t.Fatalf("NewRedisCoordinator: %v", err)
+	}
+
+	var offsets []int64
+	for _, c := range []*RedisCoordinator{a, b, a} {
+		n, errOffset := c.LeaseOffsets("rr:gemini:gemini-2.5-pro", 100)
+		if errOffset != nil {
+			t.Fatal(errOffset)
+		}
+		offsets = append(offsets, n)
+	}
+	if offsets[0] != 100 || offsets[1] != 200 || offsets[2] != 300 {
+		t.Fatalf("offset leases are not shared between instances: %v", offsets)
+	}
+
+	unlock, ok, err := a.TryLock("refresh:auth-1", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("first lock failed: %v %v", ok, err)
+	}
+	if _, ok, _ = b.TryLock("refresh:auth-1", time.Minute); ok {
+		t.Fatal("second instance acquired a held lock")
+	}
+	unlock()
+	unlockB, ok, _ := b.TryLock("refresh:auth-1", time.Minute)
+	if !ok {
+		t.Fatal("lock was not released")
+	}
+	unlock()
+	if _, ok, _ = a.TryLock("refresh:auth-1", time.Minute); ok {
+		t.Fatal("a stale unlock released another instance's lock")
+	}
+	unlockB()
+
+	if err = a.Set("refreshed:auth-1", "marker", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, errGet := b.Get("refreshed:auth-1"); errGet != nil || !ok || value != "marker" {
+		t.Fatalf("shared value not visible to another instance: %q %v %v", value, ok, errGet)
+	}
+	if _, ok, _ = b.Get("refreshed:auth-2"); ok {
+		t.Fatal("missing value reported as set")
+	}
+}
@@ -0,0 +1,336 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// Engine runs the translation and credential pooling core in-process, without the HTTP
+// server, file watcher or management API. Requests go through the same execution path as
+// the HTTP handlers, which only add request parsing and SSE framing on top.
+type Engine struct {
+	mu      sync.RWMutex
+	svc     *Service
+	handler *handlers.BaseAPIHandler
+	// custom lists providers served by executors registered through RegisterExecutor, which
+	// RegisterAuth must not replace with built-in executors.
+	custom map[string]bool
+}
+
+// EngineRequest is a model request in one of the supported inbound formats.
+type EngineRequest struct {
+	// Format is the format of Payload and of the returned response. Defaults to OpenAI chat.
+	Format sdktranslator.Format
+	// Model selects the model. When empty it is read from the payload's "model" field.
+	// Gemini payloads do not carry the model and always need it set.
+	Model string
+	// Payload is the raw request body.
+	Payload []byte
+	// Alt mirrors the Gemini "alt" query parameter and is usually empty.
+	Alt string
+}
+
+// EngineChunk is a streamed response chunk, or the error that ended the stream.
+type EngineChunk struct {
+	Payload []byte
+	Err     error
+}
+
+// EngineError is returned when a request fails. StatusCode is the HTTP status the HTTP
+// layer would have answered with. It matches the sentinel errors such as ErrQuotaExceeded
+// with errors.Is.
+type EngineError struct {
+	StatusCode int
+	Err        error
+	// retryAfter is the wait parsed from the Retry-After header of the failure.
+	retryAfter *time.Duration
+}
+
+func (e *EngineError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.StatusCode)
+	}
+	return e.Err.Error()
+}
+
+func (e *EngineError) Unwrap() error { return e.Err }
+
+// Is classifies the failure by its status when the wrapped error does not classify itself.
+func (e *EngineError) Is(target error) bool {
+	if kind := cliproxyexecutor.StatusKind(e.StatusCode); kind != nil && kind == target {
+		return true
+	}
+	return target == ErrTransient && isTransientNetError(e.Err)
+}
+
+// RetryAfter returns how long to wait before retrying, or nil when the failure carries no
+// hint.
+func (e *EngineError) RetryAfter() *time.Duration {
+	if e.retryAfter != nil {
+		return e.retryAfter
+	}
+	if wait, ok := RetryAfter(e.Err); ok {
+		return &wait
+	}
+	return nil
+}
+
+// engineFormats are the inbound formats the engine accepts.
+var engineFormats = map[sdktranslator.Format]bool{
+	sdktranslator.FormatOpenAI:         true,
+	sdktranslator.FormatOpenAIResponse: true,
+	sdktranslator.FormatClaude:         true,
+	sdktranslator.FormatGemini:         true,
+	sdktranslator.FormatGeminiCLI:      true,
+}
+
+// NewEngine returns an engine for cfg. When manager is nil an in-memory manager using the
+// round-robin selector is created; credentials are then only kept for the process lifetime.
+func NewEngine(cfg *config.Config, manager *coreauth.Manager) (*Engine, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("cliproxy: configuration is required")
+	}
+	if manager == nil {
+		manager = coreauth.NewManager(nil, &coreauth.RoundRobinSelector{}, nil)
+	}
+	manager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
+	manager.SetConfig(cfg)
+	manager.SetOAuthModelAlias(cfg.OAuthModelAlias)
+	svc := &Service{cfg: cfg, coreManager: manager}
+	svc.applyRetryConfig(cfg)
+	return &Engine{
+		svc:     svc,
+		handler: handlers.NewBaseAPIHandlers(&cfg.SDKConfig, manager),
+		custom:  make(map[string]bool),
+	}, nil
+}
+
+// Manager exposes the underlying auth manager for advanced use such as hooks or selectors.
+func (e *Engine) Manager() *coreauth.Manager {
+	return e.svc.coreManager
+}
+
+// UpdateConfig swaps the configuration and rebinds built-in executors to it.
+func (e *Engine) UpdateConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.svc.cfg = cfg
+	e.svc.coreManager.SetConfig(cfg)
+	e.svc.coreManager.SetOAuthModelAlias(cfg.OAuthModelAlias)
+	e.svc.applyRetryConfig(cfg)
+	e.handler.UpdateClients(&cfg.SDKConfig)
+	for _, auth := range e.svc.coreManager.List() {
+		if !e.custom[strings.ToLower(auth.Provider)] {
+			e.svc.ensureExecutorsForAuth(auth)
+		}
+	}
+}
+
+// RegisterExecutor installs an executor for a provider that has no built-in implementation,
+// or replaces a built-in one. Auths of that provider registered later keep using it.
+func (e *Engine) RegisterExecutor(executor coreauth.ProviderExecutor) {
+	if executor == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.custom[strings.ToLower(executor.Identifier())] = true
+	e.svc.coreManager.RegisterExecutor(executor)
+}
+
+// RegisterAuth adds or updates a credential, binds the executor for its provider and
+// registers the models it serves. Credentials of custom providers have no built-in model
+// list; pass their models to RegisterModels.
+func (e *Engine) RegisterAuth(ctx context.Context, auth *coreauth.Auth) error {
+	if auth == nil || strings.TrimSpace(auth.ID) == "" {
+		return fmt.Errorf("cliproxy: auth with an ID is required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.custom[strings.ToLower(auth.Provider)] {
+		e.svc.applyCoreAuthAddOrUpdate(ctx, auth)
+		if _, ok := e.svc.coreManager.GetByID(auth.ID); !ok {
+			return fmt.Errorf("cliproxy: failed to register auth %s", auth.ID)
+		}
+		return nil
+	}
+	auth = auth.Clone()
+	var err error
+	if _, ok := e.svc.coreManager.GetByID(auth.ID); ok {
+		_, err = e.svc.coreManager.Update(ctx, auth)
+	} else {
+		_, err = e.svc.coreManager.Register(ctx, auth)
+	}
+	return err
+}
+
+// RegisterModels makes models routable to the credential authID of provider.
+func (e *Engine) RegisterModels(authID, provider string, models []*ModelInfo) {
+	GlobalModelRegistry().RegisterClient(authID, strings.ToLower(strings.TrimSpace(provider)), models)
+}
+
+// RemoveAuth disables a credential and withdraws its models.
+func (e *Engine) RemoveAuth(ctx context.Context, id string) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.svc.applyCoreAuthRemoval(ctx, id)
+}
+
+// Models lists the models currently routable, rendered for format.
+func (e *Engine) Models(format sdktranslator.Format) []map[string]any {
+	if format == "" {
+		format = sdktranslator.FormatOpenAI
+	}
+	return GlobalModelRegistry().GetAvailableModels(format.String())
+}
+
+// Execute runs a non-streaming request and returns the response body in req.Format.
+func (e *Engine) Execute(ctx context.Context, req EngineRequest) ([]byte, error) {
+	format, model, err := e.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, errMsg := e.currentHandler().ExecuteWithAuthManager(contextOrBackground(ctx), format.String(), model, req.Payload, req.Alt)
+	if errMsg != nil {
+		return nil, engineError(errMsg)
+	}
+	return resp, nil
+}
+
+// CountTokens runs a token counting request for formats that support it (Claude, Gemini).
+func (e *Engine) CountTokens(ctx context.Context, req EngineRequest) ([]byte, error) {
+	format, model, err := e.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, errMsg := e.currentHandler().ExecuteCountWithAuthManager(contextOrBackground(ctx), format.String(), model, req.Payload, req.Alt)
+	if errMsg != nil {
+		return nil, engineError(errMsg)
+	}
+	return resp, nil
+}
+
+// ExecuteStream runs a streaming request. Chunks carry translated stream events in
+// req.Format as the HTTP handlers receive them, before SSE framing. A failure before the
+// first chunk is returned directly; later failures arrive as a final chunk with Err set.
+// The channel is closed when the stream ends or ctx is cancelled.
+func (e *Engine) ExecuteStream(ctx context.Context, req EngineRequest) (<-chan EngineChunk, error) {
+	format, model, err := e.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = contextOrBackground(ctx)
+	data, errs := e.currentHandler().ExecuteStreamWithAuthManager(ctx, format.String(), model, req.Payload, req.Alt)
+	if data == nil {
+		for errMsg := range errs {
+			if errMsg != nil {
+				return nil, engineError(errMsg)
+			}
+		}
+		return nil, &EngineError{StatusCode: http.StatusInternalServerError, Err: fmt.Errorf("stream unavailable")}
+	}
+
+	out := make(chan EngineChunk)
+	go func() {
+		defer close(out)
+		send := func(chunk EngineChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for data != nil || errs != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case payload, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				if !send(EngineChunk{Payload: payload}) {
+					return
+				}
+			case errMsg, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if errMsg != nil {
+					send(EngineChunk{Err: engineError(errMsg)})
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (e *Engine) currentHandler() *handlers.BaseAPIHandler {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.handler
+}
+
+// prepare validates the request format and resolves the model.
+func (e *Engine) prepare(req EngineRequest) (sdktranslator.Format, string, error) {
+	format := req.Format
+	if format == "" {
+		format = sdktranslator.FormatOpenAI
+	}
+	if !engineFormats[format] {
+		return "", "", &EngineError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("unsupported request format %q", format)}
+	}
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = strings.TrimSpace(gjson.GetBytes(req.Payload, "model").String())
+	}
+	if model == "" {
+		return "", "", &EngineError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("model is required")}
+	}
+	return format, model, nil
+}
+
+func engineError(errMsg *interfaces.ErrorMessage) error {
+	status := errMsg.StatusCode
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	engineErr := &EngineError{StatusCode: status, Err: errMsg.Error}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(errMsg.Addon.Get("Retry-After"))); err == nil && seconds >= 0 {
+		wait := time.Duration(seconds) * time.Second
+		engineErr.retryAfter = &wait
+	}
+	return engineErr
+}
+
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
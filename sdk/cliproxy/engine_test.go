@@ -0,0 +1,125 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+type engineTestExecutor struct{}
+
+func (engineTestExecutor) Identifier() string { return "engine-test" }
+
+func (engineTestExecutor) Execute(_ context.Context, auth *coreauth.Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"auth":"` + auth.ID + `","model":"` + req.Model + `"}`)}, nil
+}
+
+func (engineTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 3)
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("one")}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("two")}
+	ch <- cliproxyexecutor.StreamChunk{Err: errors.New("stream broke")}
+	close(ch)
+	return ch, nil
+}
+
+func (engineTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (engineTestExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"input_tokens":3}`)}, nil
+}
+
+func (engineTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not supported")
+}
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	engine, err := NewEngine(&config.Config{}, nil)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	engine.RegisterExecutor(engineTestExecutor{})
+	auth := &coreauth.Auth{ID: "engine-test-auth", Provider: "engine-test", Status: coreauth.StatusActive}
+	if err = engine.RegisterAuth(context.Background(), auth); err != nil {
+		t.Fatalf("RegisterAuth: %v", err)
+	}
+	engine.RegisterModels(auth.ID, "engine-test", []*ModelInfo{{ID: "engine-test-model", Object: "model", OwnedBy: "engine-test"}})
+	t.Cleanup(func() {
+		engine.RemoveAuth(context.Background(), auth.ID)
+		GlobalModelRegistry().UnregisterClient(auth.ID)
+	})
+	return engine
+}
+
+func TestEngineExecuteRoutesToRegisteredExecutor(t *testing.T) {
+	engine := newTestEngine(t)
+	out, err := engine.Execute(context.Background(), EngineRequest{Payload: []byte(`{"model":"engine-test-model","messages":[]}`)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(out) != `{"auth":"engine-test-auth","model":"engine-test-model"}` {
+		t.Fatalf("unexpected response: %s", out)
+	}
+
+	count, err := engine.CountTokens(context.Background(), EngineRequest{Format: sdktranslator.FormatClaude, Payload: []byte(`{"model":"engine-test-model"}`)})
+	if err != nil || string(count) != `{"input_tokens":3}` {
+		t.Fatalf("CountTokens = %s, %v", count, err)
+	}
+}
+
+func TestEngineExecuteStreamDeliversChunksThenError(t *testing.T) {
+	engine := newTestEngine(t)
+	chunks, err := engine.ExecuteStream(context.Background(), EngineRequest{
+		Format:  sdktranslator.FormatClaude,
+		Payload: []byte(`{"model":"engine-test-model","messages":[],"stream":true}`),
+	})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var payloads []string
+	var streamErr error
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+			continue
+		}
+		payloads = append(payloads, string(chunk.Payload))
+	}
+	if len(payloads) != 2 || payloads[0] != "one" || payloads[1] != "two" {
+		t.Fatalf("unexpected chunks: %v", payloads)
+	}
+	var engineErr *EngineError
+	if !errors.As(streamErr, &engineErr) {
+		t.Fatalf("expected trailing EngineError, got %v", streamErr)
+	}
+}
+
+func TestEngineRejectsInvalidRequests(t *testing.T) {
+	engine := newTestEngine(t)
+	cases := []EngineRequest{
+		{Format: "klingon", Payload: []byte(`{"model":"engine-test-model"}`)},
+		{Payload: []byte(`{"messages":[]}`)},
+	}
+	for _, req := range cases {
+		_, err := engine.Execute(context.Background(), req)
+		var engineErr *EngineError
+		if !errors.As(err, &engineErr) || engineErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("request %+v: expected 400 EngineError, got %v", req, err)
+		}
+	}
+
+	_, err := engine.Execute(context.Background(), EngineRequest{Payload: []byte(`{"model":"unknown-engine-model"}`)})
+	var engineErr *EngineError
+	if !errors.As(err, &engineErr) {
+		t.Fatalf("expected EngineError for unknown model, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package cliproxy
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Sentinel errors classifying request failures. Errors returned by the Engine, by the auth
+// manager's Execute methods and by the built-in executors match them with errors.Is:
+//
+//	if errors.Is(err, cliproxy.ErrQuotaExceeded) {
+//		wait, _ := cliproxy.RetryAfter(err)
+//		...
+//	}
+var (
+	// ErrQuotaExceeded reports a rate limit or exhausted usage quota (HTTP 402 and 429), and
+	// every credential for the model cooling down.
+	ErrQuotaExceeded = cliproxyexecutor.ErrQuotaExceeded
+	// ErrUnauthorized reports a credential the upstream rejected (HTTP 401 and 403) or one
+	// that needs a new login.
+	ErrUnauthorized = cliproxyexecutor.ErrUnauthorized
+	// ErrTransient reports a timeout, network failure or upstream 5xx worth retrying.
+	ErrTransient = cliproxyexecutor.ErrTransient
+	// ErrInvalidRequest reports a request the upstream refused regardless of the credential.
+	ErrInvalidRequest = cliproxyexecutor.ErrInvalidRequest
+	// ErrNoCredentials reports that no credential is registered or available for the model.
+	ErrNoCredentials = cliproxyexecutor.ErrNoCredentials
+)
+
+// RetryAfter returns how long the upstream asked to wait before retrying, when err or an
+// error it wraps carries that hint.
+func RetryAfter(err error) (time.Duration, bool) {
+	var provider interface{ RetryAfter() *time.Duration }
+	if !errors.As(err, &provider) {
+		return 0, false
+	}
+	if wait := provider.RetryAfter(); wait != nil {
+		return *wait, true
+	}
+	return 0, false
+}
+
+// isTransientNetError reports network failures that carry no HTTP status.
+func isTransientNetError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
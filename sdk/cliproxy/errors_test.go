@@ -0,0 +1,69 @@
+package cliproxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestEngineErrorMatchesSentinels(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Retry-After", "30")
+	err := fmt.Errorf("request: %w", engineError(&interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New("slow down"), Addon: headers}))
+	if !errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrTransient) {
+		t.Fatalf("429 must classify as quota exceeded only: %v", err)
+	}
+	if wait, ok := RetryAfter(err); !ok || wait != 30*time.Second {
+		t.Fatalf("RetryAfter = %v, %v", wait, ok)
+	}
+	var engineErr *EngineError
+	if !errors.As(err, &engineErr) || engineErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("errors.As did not find the EngineError: %v", err)
+	}
+
+	cases := []struct {
+		status int
+		err    error
+		want   error
+	}{
+		{http.StatusUnauthorized, errors.New("bad key"), ErrUnauthorized},
+		{http.StatusPaymentRequired, errors.New("billing"), ErrQuotaExceeded},
+		{http.StatusBadGateway, errors.New("upstream down"), ErrTransient},
+		{http.StatusBadRequest, errors.New("bad payload"), ErrInvalidRequest},
+		{http.StatusInternalServerError, &net.OpError{Op: "dial", Err: errors.New("refused")}, ErrTransient},
+		{http.StatusInternalServerError, &coreauth.Error{Code: "auth_not_found", Message: "no auth available"}, ErrNoCredentials},
+	}
+	for _, tc := range cases {
+		got := engineError(&interfaces.ErrorMessage{StatusCode: tc.status, Error: tc.err})
+		if !errors.Is(got, tc.want) {
+			t.Fatalf("status %d (%v) does not match %v", tc.status, tc.err, tc.want)
+		}
+	}
+	if _, ok := RetryAfter(engineError(&interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errors.New("x")})); ok {
+		t.Fatal("an error without a hint must not report a retry delay")
+	}
+}
+
+func TestAuthErrorsMatchSentinels(t *testing.T) {
+	cases := map[*coreauth.Error]error{
+		{Code: "auth_unavailable"}:                     ErrNoCredentials,
+		{Code: "needs_relogin"}:                        ErrUnauthorized,
+		{Code: "invalid_request"}:                      ErrInvalidRequest,
+		{Code: "provider_degraded", HTTPStatus: 503}:   ErrTransient,
+		{Message: "upstream said no", HTTPStatus: 403}: ErrUnauthorized,
+	}
+	for authErr, want := range cases {
+		if !errors.Is(authErr, want) {
+			t.Fatalf("%+v does not match %v", authErr, want)
+		}
+	}
+	if errors.Is(&coreauth.Error{Message: "unknown"}, ErrTransient) {
+		t.Fatal("an error without code or status must not classify")
+	}
+}
@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Sentinel errors classifying execution failures. Errors returned by the built-in executors
+// and the auth manager match them with errors.Is; executors of embedders can opt in by
+// implementing Is with StatusKind.
+var (
+	// ErrQuotaExceeded reports that the credential hit a rate limit or its usage quota.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrUnauthorized reports that the upstream rejected the credential.
+	ErrUnauthorized = errors.New("credential rejected")
+	// ErrTransient reports an upstream failure that a later retry may not see.
+	ErrTransient = errors.New("transient upstream failure")
+	// ErrInvalidRequest reports a request the upstream refused regardless of the credential.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrNoCredentials reports that no credential could serve the request.
+	ErrNoCredentials = errors.New("no credential available")
+)
+
+// StatusKind returns the sentinel error classifying an HTTP status code, or nil for codes
+// that do not indicate a failure.
+func StatusKind(code int) error {
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusPaymentRequired, http.StatusTooManyRequests:
+		return ErrQuotaExceeded
+	case http.StatusRequestTimeout:
+		return ErrTransient
+	}
+	switch {
+	case code >= 500:
+		return ErrTransient
+	case code >= 400:
+		return ErrInvalidRequest
+	}
+	return nil
+}
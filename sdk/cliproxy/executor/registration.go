@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// APIVersion is the version of the executor contract implemented by this SDK. It is bumped
+// whenever ProviderExecutor, Request, Options or Response change incompatibly.
+const APIVersion = 1
+
+// MinAPIVersion is the oldest executor contract version this SDK still accepts.
+const MinAPIVersion = 1
+
+// ErrIncompatibleAPIVersion is returned when a provider targets an unsupported contract version.
+var ErrIncompatibleAPIVersion = errors.New("executor: incompatible API version")
+
+// Capability names an optional feature a provider executor implements.
+type Capability string
+
+const (
+	// CapabilityStream marks providers that implement ExecuteStream.
+	CapabilityStream Capability = "stream"
+	// CapabilityCountTokens marks providers that implement CountTokens.
+	CapabilityCountTokens Capability = "count_tokens"
+	// CapabilityRefresh marks providers whose credentials can be refreshed.
+	CapabilityRefresh Capability = "refresh"
+	// CapabilityHTTPRequest marks providers that support raw HTTP passthrough.
+	CapabilityHTTPRequest Capability = "http_request"
+)
+
+// Registration describes an out-of-tree provider executor.
+type Registration struct {
+	// Name is the provider key, matched against Auth.Provider. It is normalised to lower case.
+	Name string `json:"name"`
+	// APIVersion is the executor contract version the provider was built against.
+	APIVersion int `json:"api_version"`
+	// Capabilities lists the optional features the provider implements.
+	Capabilities []Capability `json:"capabilities,omitempty"`
+	// ConfigSchema is an optional JSON schema for the provider's provider-plugins settings.
+	ConfigSchema json.RawMessage `json:"config_schema,omitempty"`
+}
+
+// Supports reports whether the registration declares capability c.
+func (r Registration) Supports(c Capability) bool {
+	for _, have := range r.Capabilities {
+		if have == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the registration and returns a copy with the name normalised.
+func (r Registration) Validate() (Registration, error) {
+	r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+	if r.Name == "" {
+		return r, fmt.Errorf("executor: provider name is required")
+	}
+	if r.APIVersion < MinAPIVersion || r.APIVersion > APIVersion {
+		return r, fmt.Errorf("%w: provider %s targets v%d, supported v%d-v%d", ErrIncompatibleAPIVersion, r.Name, r.APIVersion, MinAPIVersion, APIVersion)
+	}
+	if len(r.ConfigSchema) > 0 && !json.Valid(r.ConfigSchema) {
+		return r, fmt.Errorf("executor: provider %s has an invalid config schema", r.Name)
+	}
+	return r, nil
+}
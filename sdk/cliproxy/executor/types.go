@@ -10,6 +10,18 @@ import (
 // RequestedModelMetadataKey stores the client-requested model name in Options.Metadata.
 const RequestedModelMetadataKey = "requested_model"
 
+// ConversationKeyMetadataKey stores a stable per-conversation identifier in Options.Metadata
+// when system prompt deduplication is enabled and the client sent a session marker.
+const ConversationKeyMetadataKey = "conversation_key"
+
+// SystemPromptRepeatMetadataKey is set to true in Options.Metadata when the conversation
+// resent the same system prompt as its previous request.
+const SystemPromptRepeatMetadataKey = "system_prompt_repeat"
+
+// MaxRetriesMetadataKey caps, in Options.Metadata, the upstream attempts made after the first
+// one for a request, across credentials and cooldown retry rounds.
+const MaxRetriesMetadataKey = "max_retries"
+
 // Request encapsulates the translated payload that will be sent to a provider executor.
 type Request struct {
 	// Model is the upstream model identifier after translation.
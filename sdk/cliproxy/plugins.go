@@ -0,0 +1,101 @@
+package cliproxy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// ProviderFactory builds the executor of a registered provider. settings is the provider's
+// entry under provider-plugins in the config, or nil when it has none.
+type ProviderFactory func(cfg *config.Config, settings map[string]any) (coreauth.ProviderExecutor, error)
+
+type providerPlugin struct {
+	registration executor.Registration
+	factory      ProviderFactory
+}
+
+var (
+	providerPluginsMu sync.RWMutex
+	providerPlugins   = make(map[string]providerPlugin)
+)
+
+// builtinProviders cannot be replaced through RegisterProvider.
+var builtinProviders = map[string]bool{
+	"gemini": true, "vertex": true, "gemini-cli": true, "aistudio": true, "antigravity": true,
+	"claude": true, "bedrock": true, "azure-openai": true, "openrouter": true, "codex": true, "qwen": true, "iflow": true, "openai-compatibility": true,
+}
+
+// RegisterProvider compiles an out-of-tree provider into the proxy. Call it from an init
+// function in a small registration file; auths whose provider matches reg.Name are then
+// served by executors built with factory.
+func RegisterProvider(reg executor.Registration, factory ProviderFactory) error {
+	reg, err := reg.Validate()
+	if err != nil {
+		return err
+	}
+	if factory == nil {
+		return fmt.Errorf("cliproxy: provider %s has no factory", reg.Name)
+	}
+	if builtinProviders[reg.Name] {
+		return fmt.Errorf("cliproxy: provider %s is built in", reg.Name)
+	}
+	providerPluginsMu.Lock()
+	defer providerPluginsMu.Unlock()
+	if _, exists := providerPlugins[reg.Name]; exists {
+		return fmt.Errorf("cliproxy: provider %s is already registered", reg.Name)
+	}
+	providerPlugins[reg.Name] = providerPlugin{registration: reg, factory: factory}
+	return nil
+}
+
+// MustRegisterProvider is RegisterProvider for init functions; it panics on error.
+func MustRegisterProvider(reg executor.Registration, factory ProviderFactory) {
+	if err := RegisterProvider(reg, factory); err != nil {
+		panic(err)
+	}
+}
+
+// RegisteredProviders lists the registered out-of-tree providers sorted by name.
+func RegisteredProviders() []executor.Registration {
+	providerPluginsMu.RLock()
+	defer providerPluginsMu.RUnlock()
+	out := make([]executor.Registration, 0, len(providerPlugins))
+	for _, plugin := range providerPlugins {
+		out = append(out, plugin.registration)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func lookupProviderPlugin(provider string) (providerPlugin, bool) {
+	providerPluginsMu.RLock()
+	defer providerPluginsMu.RUnlock()
+	plugin, ok := providerPlugins[strings.ToLower(strings.TrimSpace(provider))]
+	return plugin, ok
+}
+
+// newPluginExecutor builds the executor of a registered provider for cfg.
+func newPluginExecutor(plugin providerPlugin, cfg *config.Config) (coreauth.ProviderExecutor, error) {
+	name := plugin.registration.Name
+	var settings map[string]any
+	if cfg != nil {
+		settings = cfg.ProviderPlugins[name]
+	}
+	exec, err := plugin.factory(cfg, settings)
+	if err != nil {
+		return nil, fmt.Errorf("cliproxy: provider %s: %w", name, err)
+	}
+	if exec == nil {
+		return nil, fmt.Errorf("cliproxy: provider %s factory returned no executor", name)
+	}
+	if id := strings.ToLower(strings.TrimSpace(exec.Identifier())); id != name {
+		return nil, fmt.Errorf("cliproxy: provider %s executor identifies as %q", name, exec.Identifier())
+	}
+	return exec, nil
+}
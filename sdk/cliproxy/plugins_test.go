@@ -0,0 +1,81 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type pluginTestExecutor struct {
+	engineTestExecutor
+	name string
+}
+
+func (e pluginTestExecutor) Identifier() string { return e.name }
+
+func TestRegisterProviderValidatesRegistration(t *testing.T) {
+	factory := func(*config.Config, map[string]any) (coreauth.ProviderExecutor, error) {
+		return pluginTestExecutor{name: "x"}, nil
+	}
+	if err := RegisterProvider(executor.Registration{Name: "plugin-old", APIVersion: executor.APIVersion + 1}, factory); !errors.Is(err, executor.ErrIncompatibleAPIVersion) {
+		t.Fatalf("expected ErrIncompatibleAPIVersion, got %v", err)
+	}
+	if err := RegisterProvider(executor.Registration{Name: "Claude", APIVersion: executor.APIVersion}, factory); err == nil {
+		t.Fatal("built-in providers must not be replaceable")
+	}
+	if err := RegisterProvider(executor.Registration{Name: "plugin-bad-schema", APIVersion: executor.APIVersion, ConfigSchema: []byte("{")}, factory); err == nil {
+		t.Fatal("invalid config schema must be rejected")
+	}
+}
+
+func TestRegisteredProviderServesMatchingAuths(t *testing.T) {
+	var gotSettings map[string]any
+	err := RegisterProvider(executor.Registration{
+		Name:         " Plugin-Test ",
+		APIVersion:   executor.APIVersion,
+		Capabilities: []executor.Capability{executor.CapabilityStream},
+	}, func(_ *config.Config, settings map[string]any) (coreauth.ProviderExecutor, error) {
+		gotSettings = settings
+		return pluginTestExecutor{name: "plugin-test"}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterProvider: %v", err)
+	}
+	if err = RegisterProvider(executor.Registration{Name: "plugin-test", APIVersion: executor.APIVersion}, nil); err == nil {
+		t.Fatal("expected duplicate or nil factory registration to fail")
+	}
+
+	found := false
+	for _, reg := range RegisteredProviders() {
+		if reg.Name == "plugin-test" {
+			found = reg.Supports(executor.CapabilityStream) && !reg.Supports(executor.CapabilityRefresh)
+		}
+	}
+	if !found {
+		t.Fatalf("registration not listed correctly: %+v", RegisteredProviders())
+	}
+
+	cfg := &config.Config{ProviderPlugins: map[string]map[string]any{"plugin-test": {"base-url": "http://example"}}}
+	engine, err := NewEngine(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := &coreauth.Auth{ID: "plugin-test-auth", Provider: "plugin-test", Status: coreauth.StatusActive}
+	if err = engine.RegisterAuth(context.Background(), auth); err != nil {
+		t.Fatalf("RegisterAuth: %v", err)
+	}
+	t.Cleanup(func() { engine.RemoveAuth(context.Background(), auth.ID) })
+	if gotSettings["base-url"] != "http://example" {
+		t.Fatalf("factory settings = %v", gotSettings)
+	}
+	engine.RegisterModels(auth.ID, "plugin-test", []*ModelInfo{{ID: "plugin-test-model", Object: "model"}})
+	t.Cleanup(func() { GlobalModelRegistry().UnregisterClient(auth.ID) })
+	out, err := engine.Execute(context.Background(), EngineRequest{Payload: []byte(`{"model":"plugin-test-model"}`)})
+	if err != nil || string(out) != `{"auth":"plugin-test-auth","model":"plugin-test-model"}` {
+		t.Fatalf("Execute = %s, %v", out, err)
+	}
+}
@@ -0,0 +1,31 @@
+package cliproxy
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/preflight"
+)
+
+// runPreflight checks the environment in the background and reports the result in the log
+// and through GET /readyz.
+func (s *Service) runPreflight(ctx context.Context) {
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	var providers []string
+	if s.coreManager != nil {
+		seen := make(map[string]bool)
+		for _, auth := range s.coreManager.List() {
+			if auth != nil && !auth.Disabled && !seen[auth.Provider] {
+				seen[auth.Provider] = true
+				providers = append(providers, auth.Provider)
+			}
+		}
+	}
+	server := s.server
+	go func() {
+		report := preflight.Run(ctx, preflight.Options{Config: cfg, Providers: providers})
+		report.Log()
+		server.SetPreflightReport(report)
+	}()
+}
@@ -0,0 +1,82 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/migration"
+)
+
+// Backend persists the entries of a Store. The store serves reads from memory and hands
+// changes to its backend on Flush; Sync reads them back, which lets a shared backend carry
+// the snapshots of other proxy replicas.
+type Backend interface {
+	// Load returns every persisted entry keyed by auth ID.
+	Load() (map[string]*StoreEntry, error)
+	// Save persists the entries of the changed auths; a nil entry removes the auth. all holds
+	// every entry for backends that rewrite the whole set.
+	Save(all, changed map[string]*StoreEntry) error
+}
+
+// fileBackend keeps the entries in one JSON file on the local node.
+type fileBackend struct {
+	path string
+}
+
+func (b *fileBackend) Load() (map[string]*StoreEntry, error) {
+	if b.path == "" {
+		return nil, nil
+	}
+	if _, err := migration.MigrateQuotaFile(b.path); err != nil {
+		return nil, fmt.Errorf("quota store: migrate failed: %w", err)
+	}
+	raw, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("quota store: read failed: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var loaded storeData
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		return nil, fmt.Errorf("quota store: unmarshal failed: %w", err)
+	}
+	return loaded.AuthQuotas, nil
+}
+
+func (b *fileBackend) Save(all, _ map[string]*StoreEntry) error {
+	if b.path == "" {
+		return nil
+	}
+	data := storeData{
+		SchemaVersion: schemaVersion,
+		WrittenAt:     time.Now().UTC(),
+		AuthQuotas:    all,
+	}
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("quota store: marshal failed: %w", err)
+	}
+
+	dir := filepath.Dir(b.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("quota store: create dir failed: %w", err)
+	}
+
+	tmpFile := b.path + ".tmp"
+	if err := os.WriteFile(tmpFile, raw, 0o600); err != nil {
+		return fmt.Errorf("quota store: write tmp failed: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, b.path); err != nil {
+		_ = os.Remove(tmpFile)
+		return fmt.Errorf("quota store: rename failed: %w", err)
+	}
+	return nil
+}
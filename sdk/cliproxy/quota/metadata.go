@@ -63,6 +63,18 @@ func GetModelQuotaFromMetadata(metadata map[string]any, model string) (ModelQuot
 	return ModelQuota{}, false
 }
 
+// ModelsFromMetadata returns every model quota stored in the metadata snapshot.
+func ModelsFromMetadata(metadata map[string]any) map[string]ModelQuota {
+	if metadata == nil {
+		return nil
+	}
+	snapshot, ok := metadata[MetadataKey].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return parseSnapshotModels(snapshot[metadataModelsKey])
+}
+
 // UpdateMetadata writes quota snapshot into the metadata map.
 // Returns true when metadata is changed.
 func UpdateMetadata(metadata map[string]any, provider string, models map[string]ModelQuota, updatedAt time.Time) bool {
@@ -0,0 +1,89 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redis"
+)
+
+// DefaultRedisKey is the hash holding the quota entries when no key is configured.
+const DefaultRedisKey = "cliproxy:quota"
+
+// RedisOptions configure a Redis-backed quota store.
+type RedisOptions struct {
+	// URL addresses the server as redis://[[user]:password@]host[:port][/db], or rediss://
+	// for TLS.
+	URL string
+	// Key is the hash holding one field per auth. Default is DefaultRedisKey.
+	Key string
+}
+
+// NewRedisStore returns a quota store kept in a Redis hash, so that proxy replicas behind a
+// load balancer share quota snapshots and model states. Reads are served from memory;
+// Sync exchanges changes with the other replicas.
+func NewRedisStore(opts RedisOptions) (*Store, error) {
+	client, err := redis.New(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("quota store: %w", err)
+	}
+	if _, err = client.Do("PING"); err != nil {
+		return nil, fmt.Errorf("quota store: %w", err)
+	}
+	backend := &redisBackend{client: client, key: strings.TrimSpace(opts.Key)}
+	if backend.key == "" {
+		backend.key = DefaultRedisKey
+	}
+	return NewStoreWithBackend(backend), nil
+}
+
+// redisBackend stores each auth's entry as JSON in one field of a Redis hash.
+type redisBackend struct {
+	client *redis.Client
+	key    string
+}
+
+func (b *redisBackend) Load() (map[string]*StoreEntry, error) {
+	reply, err := b.client.Do("HGETALL", b.key)
+	if err != nil {
+		return nil, fmt.Errorf("quota store: redis load failed: %w", err)
+	}
+	fields, _ := reply.([]any)
+	entries := make(map[string]*StoreEntry, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		authID, _ := fields[i].(string)
+		raw, _ := fields[i+1].(string)
+		var entry StoreEntry
+		if authID == "" || json.Unmarshal([]byte(raw), &entry) != nil {
+			continue
+		}
+		entries[authID] = &entry
+	}
+	return entries, nil
+}
+
+func (b *redisBackend) Save(_, changed map[string]*StoreEntry) error {
+	set := []string{"HSET", b.key}
+	del := []string{"HDEL", b.key}
+	for authID, entry := range changed {
+		if entry == nil {
+			del = append(del, authID)
+			continue
+		}
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("quota store: marshal failed: %w", err)
+		}
+		set = append(set, authID, string(raw))
+	}
+	for _, cmd := range [][]string{set, del} {
+		if len(cmd) == 2 {
+			continue
+		}
+		if _, err := b.client.Do(cmd...); err != nil {
+			return fmt.Errorf("quota store: redis save failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package quota
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redis/redistest"
+)
+
+func TestRedisStoreSharesEntriesBetweenReplicas(t *testing.T) {
+	addr := redistest.Start(t)
+	a, err := NewRedisStore(RedisOptions{URL: addr})
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+	b, err := NewRedisStore(RedisOptions{URL: addr})
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+	if !a.Shared() || a.Path() != "" {
+		t.Fatal("redis store must report a shared backend without a file path")
+	}
+
+	synced := make(map[string]*StoreEntry)
+	b.OnSync(func(authID string, entry *StoreEntry) { synced[authID] = entry })
+
+	a.Set("auth-1", "antigravity", map[string]ModelQuota{"gemini-3-pro": {Percent: 40}}, time.Now())
+	a.SetModelStates("auth-1", "antigravity", json.RawMessage(`{"gemini-3-pro":{"unavailable":true}}`))
+	if err = a.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if err = b.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if percent, ok := b.GetPercent("auth-1", "gemini-3-pro"); !ok || percent != 40 {
+		t.Fatalf("replica did not see the quota snapshot, got %v %v", percent, ok)
+	}
+	if entry := synced["auth-1"]; entry == nil || string(entry.ModelStates) != `{"gemini-3-pro":{"unavailable":true}}` {
+		t.Fatalf("replica was not notified of the model states: %+v", entry)
+	}
+
+	b.Set("auth-1", "antigravity", map[string]ModelQuota{"gemini-3-pro": {Percent: 10}}, time.Now())
+	a.Delete("auth-1")
+	a.Set("auth-2", "codex", map[string]ModelQuota{"*": {Percent: 90}}, time.Now())
+	if err = b.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if percent, _ := b.GetPercent("auth-1", "gemini-3-pro"); percent != 10 {
+		t.Fatalf("unflushed local change was overwritten, got %v", percent)
+	}
+	if err = a.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.GetEntry("auth-1"); ok {
+		t.Fatal("the later delete must win over the earlier flushed change")
+	}
+	if err = b.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.GetEntry("auth-1"); ok || synced["auth-1"] != nil {
+		t.Fatal("replica did not see the deletion")
+	}
+	if _, ok := b.GetPercent("auth-2", "*"); !ok {
+		t.Fatal("replica did not see the new auth")
+	}
+}
+
+func TestNewRedisStoreRejectsInvalidURL(t *testing.T) {
+	for _, raw := range []string{"http://localhost:6379", "redis://localhost/x"} {
+		if _, err := NewRedisStore(RedisOptions{URL: raw}); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
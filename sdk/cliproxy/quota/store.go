@@ -1,12 +1,16 @@
 package quota
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -18,6 +22,27 @@ type StoreEntry struct {
 	Provider  string                `json:"provider"`
 	UpdatedAt time.Time             `json:"updated_at"`
 	Models    map[string]ModelQuota `json:"models"`
+	// ModelStates holds the auth's per-model execution states as published by the auth
+	// manager, so replicas sharing the store see each other's cooldowns.
+	ModelStates json.RawMessage `json:"model_states,omitempty"`
+}
+
+func (e *StoreEntry) clone() *StoreEntry {
+	if e == nil {
+		return nil
+	}
+	copied := &StoreEntry{
+		Provider:  e.Provider,
+		UpdatedAt: e.UpdatedAt,
+		Models:    make(map[string]ModelQuota, len(e.Models)),
+	}
+	for k, v := range e.Models {
+		copied.Models[k] = v
+	}
+	if len(e.ModelStates) > 0 {
+		copied.ModelStates = append(json.RawMessage(nil), e.ModelStates...)
+	}
+	return copied
 }
 
 type storeData struct {
@@ -27,10 +52,12 @@ type storeData struct {
 }
 
 type Store struct {
-	mu       sync.RWMutex
-	filePath string
-	data     *storeData
-	dirty    bool
+	mu      sync.RWMutex
+	backend Backend
+	data    *storeData
+	// pending holds the auth IDs changed since the last flush.
+	pending   map[string]struct{}
+	listeners []func(authID string, entry *StoreEntry)
 }
 
 func NewStore(dir string) (*Store, error) {
@@ -44,17 +71,35 @@ func NewStore(dir string) (*Store, error) {
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return nil, fmt.Errorf("quota store: create dir failed: %w", err)
 	}
+	return NewStoreWithBackend(&fileBackend{path: filepath.Join(dir, defaultQuotaFileName)}), nil
+}
+
+// NewStoreWithBackend returns a store persisted by backend, loaded with its entries. A
+// backend that fails to load leaves the store empty.
+func NewStoreWithBackend(backend Backend) *Store {
 	s := &Store{
-		filePath: filepath.Join(dir, defaultQuotaFileName),
+		backend: backend,
 		data: &storeData{
 			SchemaVersion: schemaVersion,
 			AuthQuotas:    make(map[string]*StoreEntry),
 		},
+		pending: make(map[string]struct{}),
+	}
+	_ = s.load()
+	return s
+}
+
+// Path returns the backing quota file path, or "" when the store is not file-backed.
+func (s *Store) Path() string {
+	if s == nil {
+		return ""
 	}
-	if err := s.load(); err != nil {
-		return s, nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if file, ok := s.backend.(*fileBackend); ok {
+		return file.path
 	}
-	return s, nil
+	return ""
 }
 
 func (s *Store) SetPath(path string) {
@@ -62,10 +107,21 @@ func (s *Store) SetPath(path string) {
 		return
 	}
 	s.mu.Lock()
-	s.filePath = path
+	if file, ok := s.backend.(*fileBackend); ok {
+		file.path = path
+	}
 	s.mu.Unlock()
 }
 
+// Shared reports whether the store's backend is shared with other proxy replicas.
+func (s *Store) Shared() bool {
+	if s == nil {
+		return false
+	}
+	_, local := s.backend.(*fileBackend)
+	return !local
+}
+
 func (s *Store) GetPercent(authID, model string) (float64, bool) {
 	if s == nil {
 		return 0, false
@@ -133,15 +189,7 @@ func (s *Store) GetEntry(authID string) (*StoreEntry, bool) {
 	if !ok || entry == nil {
 		return nil, false
 	}
-	copied := &StoreEntry{
-		Provider:  entry.Provider,
-		UpdatedAt: entry.UpdatedAt,
-		Models:    make(map[string]ModelQuota, len(entry.Models)),
-	}
-	for k, v := range entry.Models {
-		copied.Models[k] = v
-	}
-	return copied, true
+	return entry.clone(), true
 }
 
 func (s *Store) Set(authID, provider string, models map[string]ModelQuota, updatedAt time.Time) bool {
@@ -155,28 +203,48 @@ func (s *Store) Set(authID, provider string, models map[string]ModelQuota, updat
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	if s.data == nil {
-		s.data = &storeData{
-			SchemaVersion: schemaVersion,
-			AuthQuotas:    make(map[string]*StoreEntry),
-		}
-	}
-	if s.data.AuthQuotas == nil {
-		s.data.AuthQuotas = make(map[string]*StoreEntry)
-	}
+	s.ensureDataLocked()
 
 	existing := s.data.AuthQuotas[authID]
 	if existing != nil && existing.Provider == provider && modelQuotaMapEqual(existing.Models, normalized) {
 		return false
 	}
 
-	s.data.AuthQuotas[authID] = &StoreEntry{
+	entry := &StoreEntry{
 		Provider:  provider,
 		UpdatedAt: updatedAt.UTC(),
 		Models:    normalized,
 	}
-	s.dirty = true
+	if existing != nil {
+		entry.ModelStates = existing.ModelStates
+	}
+	s.data.AuthQuotas[authID] = entry
+	s.pending[authID] = struct{}{}
+	return true
+}
+
+// SetModelStates stores the encoded per-model states of an auth next to its quota. It
+// returns false when they are unchanged.
+func (s *Store) SetModelStates(authID, provider string, states json.RawMessage) bool {
+	if s == nil || authID == "" {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureDataLocked()
+
+	entry := s.data.AuthQuotas[authID]
+	if entry != nil && bytes.Equal(entry.ModelStates, states) {
+		return false
+	}
+	if entry == nil {
+		entry = &StoreEntry{Provider: provider}
+	} else {
+		entry = entry.clone()
+	}
+	entry.ModelStates = append(json.RawMessage(nil), states...)
+	s.data.AuthQuotas[authID] = entry
+	s.pending[authID] = struct{}{}
 	return true
 }
 
@@ -191,7 +259,7 @@ func (s *Store) Delete(authID string) {
 	}
 	if _, ok := s.data.AuthQuotas[authID]; ok {
 		delete(s.data.AuthQuotas, authID)
-		s.dirty = true
+		s.pending[authID] = struct{}{}
 	}
 }
 
@@ -201,65 +269,152 @@ func (s *Store) Flush() error {
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if !s.dirty {
+	if len(s.pending) == 0 {
 		return nil
 	}
 	return s.saveLocked()
 }
 
-func (s *Store) load() error {
-	if s.filePath == "" {
+// Reload discards in-memory quota data and re-reads the backend, e.g. after a backup
+// restore replaced the quota file.
+func (s *Store) Reload() error {
+	if s == nil {
 		return nil
 	}
-	raw, err := os.ReadFile(s.filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("quota store: read failed: %w", err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = &storeData{
+		SchemaVersion: schemaVersion,
+		AuthQuotas:    make(map[string]*StoreEntry),
+	}
+	s.pending = make(map[string]struct{})
+	return s.load()
+}
+
+// OnSync registers fn to be called by Sync for every entry another replica changed; entry
+// is nil when the auth was removed.
+func (s *Store) OnSync(fn func(authID string, entry *StoreEntry)) {
+	if s == nil || fn == nil {
+		return
 	}
-	if len(raw) == 0 {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, fn)
+	s.mu.Unlock()
+}
+
+// Sync flushes local changes and then merges the entries persisted by other replicas
+// sharing the backend. An entry changed locally since the last flush keeps its local value.
+func (s *Store) Sync() error {
+	if s == nil {
 		return nil
 	}
-	var loaded storeData
-	if err := json.Unmarshal(raw, &loaded); err != nil {
-		return fmt.Errorf("quota store: unmarshal failed: %w", err)
+	if err := s.Flush(); err != nil {
+		return err
 	}
-	if loaded.AuthQuotas == nil {
-		loaded.AuthQuotas = make(map[string]*StoreEntry)
+	remote, err := s.backend.Load()
+	if err != nil {
+		return err
 	}
-	s.data = &loaded
-	return nil
-}
 
-func (s *Store) saveLocked() error {
-	if s.filePath == "" {
-		return nil
+	s.mu.Lock()
+	s.ensureDataLocked()
+	changed := make(map[string]*StoreEntry)
+	for authID, entry := range remote {
+		if entry == nil {
+			continue
+		}
+		if _, ok := s.pending[authID]; ok {
+			continue
+		}
+		if !storeEntryEqual(s.data.AuthQuotas[authID], entry) {
+			s.data.AuthQuotas[authID] = entry
+			changed[authID] = entry.clone()
+		}
 	}
-	s.data.WrittenAt = time.Now().UTC()
-	s.data.SchemaVersion = schemaVersion
+	for authID := range s.data.AuthQuotas {
+		if _, ok := s.pending[authID]; ok {
+			continue
+		}
+		if entry := remote[authID]; entry == nil {
+			delete(s.data.AuthQuotas, authID)
+			changed[authID] = nil
+		}
+	}
+	listeners := append([]func(string, *StoreEntry){}, s.listeners...)
+	s.mu.Unlock()
 
-	raw, err := json.MarshalIndent(s.data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("quota store: marshal failed: %w", err)
+	for authID, entry := range changed {
+		for _, fn := range listeners {
+			fn(authID, entry)
+		}
 	}
+	return nil
+}
 
-	dir := filepath.Dir(s.filePath)
-	if err := os.MkdirAll(dir, 0o700); err != nil {
-		return fmt.Errorf("quota store: create dir failed: %w", err)
+// StartSync syncs the store every interval until ctx is done.
+func (s *Store) StartSync(ctx context.Context, interval time.Duration) {
+	if s == nil || interval <= 0 {
+		return
 	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Sync(); err != nil {
+					log.Debugf("quota store: sync failed: %v", err)
+				}
+			}
+		}
+	}()
+}
 
-	tmpFile := s.filePath + ".tmp"
-	if err := os.WriteFile(tmpFile, raw, 0o600); err != nil {
-		return fmt.Errorf("quota store: write tmp failed: %w", err)
+func (s *Store) ensureDataLocked() {
+	if s.data == nil {
+		s.data = &storeData{
+			SchemaVersion: schemaVersion,
+			AuthQuotas:    make(map[string]*StoreEntry),
+		}
 	}
+	if s.data.AuthQuotas == nil {
+		s.data.AuthQuotas = make(map[string]*StoreEntry)
+	}
+	if s.pending == nil {
+		s.pending = make(map[string]struct{})
+	}
+}
 
-	if err := os.Rename(tmpFile, s.filePath); err != nil {
-		_ = os.Remove(tmpFile)
-		return fmt.Errorf("quota store: rename failed: %w", err)
+func (s *Store) load() error {
+	if s.backend == nil {
+		return nil
 	}
+	loaded, err := s.backend.Load()
+	if err != nil {
+		return err
+	}
+	if loaded == nil {
+		loaded = make(map[string]*StoreEntry)
+	}
+	s.data.AuthQuotas = loaded
+	return nil
+}
 
-	s.dirty = false
+func (s *Store) saveLocked() error {
+	if s.backend == nil {
+		return nil
+	}
+	changed := make(map[string]*StoreEntry, len(s.pending))
+	for authID := range s.pending {
+		changed[authID] = s.data.AuthQuotas[authID]
+	}
+	if err := s.backend.Save(s.data.AuthQuotas, changed); err != nil {
+		return err
+	}
+	s.data.WrittenAt = time.Now().UTC()
+	s.pending = make(map[string]struct{})
 	return nil
 }
 
@@ -302,3 +457,10 @@ func modelQuotaMapEqual(a, b map[string]ModelQuota) bool {
 	}
 	return true
 }
+
+func storeEntryEqual(a, b *StoreEntry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Provider == b.Provider && modelQuotaMapEqual(a.Models, b.Models) && bytes.Equal(a.ModelStates, b.ModelStates)
+}
@@ -1,6 +1,7 @@
 package quota
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -100,6 +101,40 @@ func TestStore_FlushAndReload(t *testing.T) {
 	}
 }
 
+func TestStore_ReloadPicksUpReplacedFile(t *testing.T) {
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	src, _ := NewStore(srcDir)
+	src.Set("auth-restored", "antigravity", map[string]ModelQuota{"gemini-3-pro": {Percent: 25.0}}, time.Now().UTC())
+	if err := src.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	dst, _ := NewStore(dstDir)
+	dst.Set("auth-stale", "antigravity", map[string]ModelQuota{"*": {Percent: 50.0}}, time.Now().UTC())
+	raw, err := os.ReadFile(src.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(dst.Path(), raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = dst.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if percent, ok := dst.GetPercent("auth-restored", "gemini-3-pro"); !ok || percent != 25.0 {
+		t.Fatalf("expected restored entry, got %v %v", percent, ok)
+	}
+	if _, ok := dst.GetPercent("auth-stale", "*"); ok {
+		t.Fatal("expected unflushed entries to be dropped on reload")
+	}
+	if err = dst.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(dst.Path()); !bytes.Equal(after, raw) {
+		t.Fatal("reload must not leave the store dirty")
+	}
+}
+
 func TestStore_Delete(t *testing.T) {
 	dir := t.TempDir()
 	store, err := NewStore(dir)
@@ -0,0 +1,59 @@
+package cliproxy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/cluster"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// defaultQuotaSyncInterval is how often a replica exchanges changes with a shared quota store.
+const defaultQuotaSyncInterval = 5 * time.Second
+
+// newQuotaStore opens the quota store selected by quota-store.backend. In cluster mode the
+// store defaults to the cluster's Redis server, so cooldowns and quota are shared.
+func newQuotaStore(cfg *config.Config) (*quota.Store, error) {
+	if cfg == nil {
+		return quota.NewStore("")
+	}
+	backend := strings.ToLower(strings.TrimSpace(cfg.QuotaStore.Backend))
+	if backend == "redis" {
+		return quota.NewRedisStore(quota.RedisOptions{URL: cfg.QuotaStore.RedisURL, Key: cfg.QuotaStore.RedisKey})
+	}
+	if backend == "" && cfg.Cluster.Enabled {
+		prefix := cfg.Cluster.KeyPrefix
+		if strings.TrimSpace(prefix) == "" {
+			prefix = cluster.DefaultKeyPrefix
+		}
+		return quota.NewRedisStore(quota.RedisOptions{URL: cfg.Cluster.RedisURL, Key: prefix + "quota"})
+	}
+	return quota.NewStore("")
+}
+
+// startCluster connects to the cluster's coordination store and makes credential selection
+// and token refreshes coordinate through it.
+func startCluster(cfg *config.Config) (*cluster.RedisCoordinator, error) {
+	if cfg == nil || !cfg.Cluster.Enabled {
+		return nil, nil
+	}
+	if backend := strings.ToLower(strings.TrimSpace(cfg.Cluster.Backend)); backend != "" && backend != "redis" {
+		return nil, fmt.Errorf("cluster: unsupported backend %q", cfg.Cluster.Backend)
+	}
+	coordinator, err := cluster.NewRedisCoordinator(cfg.Cluster.RedisURL, cfg.Cluster.KeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	coreauth.SetCoordinator(coordinator)
+	return coordinator, nil
+}
+
+func quotaSyncInterval(cfg *config.Config) time.Duration {
+	if cfg != nil && cfg.QuotaStore.SyncIntervalSeconds > 0 {
+		return time.Duration(cfg.QuotaStore.SyncIntervalSeconds) * time.Second
+	}
+	return defaultQuotaSyncInterval
+}
@@ -0,0 +1,69 @@
+package cliproxy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redis/redistest"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestClusterReplicasShareCooldownsThroughClusterRedis(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Cluster.Enabled = true
+	cfg.Cluster.RedisURL = redistest.Start(t)
+	replicas := make([]*coreauth.Manager, 2)
+	stores := make([]*quota.Store, 2)
+	for i := range replicas {
+		store, err := newQuotaStore(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !store.Shared() {
+			t.Fatal("cluster mode must default to a quota store shared on the cluster's Redis")
+		}
+		m := coreauth.NewManager(nil, &coreauth.RoundRobinSelector{}, nil)
+		if _, err = m.Register(context.Background(), &coreauth.Auth{ID: "shared-auth", Provider: "gemini"}); err != nil {
+			t.Fatal(err)
+		}
+		m.ShareModelStates(store)
+		replicas[i], stores[i] = m, store
+	}
+
+	replicas[0].MarkResult(context.Background(), coreauth.Result{
+		AuthID:   "shared-auth",
+		Provider: "gemini",
+		Model:    "gemini-2.5-pro",
+		Error:    &coreauth.Error{HTTPStatus: 429, Message: "quota exhausted"},
+	})
+	for _, store := range stores {
+		if err := store.Sync(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auth, _ := replicas[1].GetByID("shared-auth")
+	if state := auth.ModelStates["gemini-2.5-pro"]; state == nil || !state.Unavailable {
+		t.Fatalf("cooldown did not reach the other replica: %+v", state)
+	}
+}
+
+func TestBuilderGivesCustomSelectorsTheQuotaStore(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var got coreauth.SelectorOptions
+	coreauth.RegisterSelector("quota-store-test", func(opts coreauth.SelectorOptions) coreauth.Selector {
+		got = opts
+		return &coreauth.RoundRobinSelector{}
+	})
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.Routing.Strategy = "quota-store-test"
+	if _, err := NewBuilder().WithConfig(cfg).WithConfigPath(filepath.Join(t.TempDir(), "config.yaml")).Build(); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if got.QuotaStore == nil {
+		t.Fatal("custom selectors must get the quota store")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
@@ -47,7 +48,13 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *coreauth.Auth) http.
 	}
 	var transport *http.Transport
 	// Handle different proxy schemes.
-	if proxyURL.Scheme == "socks5" {
+	if unixTransport, isUnix, errUnix := util.UnixProxyTransport(proxyURL); isUnix {
+		if errUnix != nil {
+			log.Errorf("create unix socket proxy transport failed: %v", errUnix)
+			return nil
+		}
+		transport = unixTransport
+	} else if proxyURL.Scheme == "socks5" {
 		// Configure SOCKS5 proxy with optional authentication.
 		username := proxyURL.User.Username()
 		password, _ := proxyURL.User.Password()
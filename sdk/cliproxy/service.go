@@ -5,14 +5,18 @@ package cliproxy
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/migration"
 	internalquota "github.com/router-for-me/CLIProxyAPI/v6/internal/quota"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
@@ -23,12 +27,19 @@ import (
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/cluster"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/quota"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
 )
 
+// antigravityQuotaRefreshFile holds the pending Antigravity quota refreshes between restarts.
+const antigravityQuotaRefreshFile = "antigravity-quota-refresh.json"
+
+// modelListCacheFile is the default model-list cache file name in the user cache directory.
+const modelListCacheFile = "model-list-cache.json"
+
 // Service wraps the proxy server lifecycle so external programs can embed the CLI proxy.
 // It manages the complete lifecycle including authentication, file watching, HTTP server,
 // and integration with various AI service providers.
@@ -97,6 +108,13 @@ type Service struct {
 	quotaPoller *internalquota.Poller
 	// quotaPollerCancel stops the quota poller loop.
 	quotaPollerCancel context.CancelFunc
+	// quotaSyncCancel stops syncing with a shared quota store.
+	quotaSyncCancel context.CancelFunc
+	// cluster coordinates credential usage with other instances in cluster mode.
+	cluster *cluster.RedisCoordinator
+	// backupCancel stops the scheduled backup loop.
+	backupCancel context.CancelFunc
+
 	// quotaStore stores quota data in a separate file.
 	quotaStore *quota.Store
 }
@@ -346,6 +364,15 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
 }
 
+func (s *Service) applyAntigravityModelConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	if err := registry.SetAntigravityModelConfigFile(cfg.AntigravityModelConfigFile); err != nil {
+		log.WithError(err).Warn("failed to load antigravity model config file")
+	}
+}
+
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
 	if a == nil {
 		return "", "", false
@@ -366,6 +393,31 @@ func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName
 	return "", "", false
 }
 
+// startModelListCache loads the persisted model lists served while credentials register
+// their models after startup, and keeps the file refreshed until ctx is done.
+func (s *Service) startModelListCache(ctx context.Context) {
+	cache := registry.GetModelListCache()
+	settings := s.cfg.ModelListCache
+	if settings.Disable {
+		cache.Configure(registry.GetGlobalRegistry(), "", 0)
+		return
+	}
+	path := strings.TrimSpace(settings.Path)
+	if path == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			cacheDir = os.TempDir()
+		}
+		path = filepath.Join(cacheDir, "cliproxy", modelListCacheFile)
+	}
+	warmup := registry.DefaultModelListWarmup
+	if settings.WarmupSeconds > 0 {
+		warmup = time.Duration(settings.WarmupSeconds) * time.Second
+	}
+	cache.Configure(registry.GetGlobalRegistry(), path, warmup)
+	cache.Start(ctx)
+}
+
 func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 	if s == nil || a == nil {
 		return
@@ -402,6 +454,12 @@ func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
 	case "claude":
 		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
+	case "azure-openai":
+		s.coreManager.RegisterExecutor(executor.NewAzureOpenAIExecutor(s.cfg))
+	case "openrouter":
+		s.coreManager.RegisterExecutor(executor.NewOpenRouterExecutor(s.cfg))
 	case "codex":
 		s.coreManager.RegisterExecutor(executor.NewCodexExecutor(s.cfg))
 	case "qwen":
@@ -409,6 +467,15 @@ func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 	case "iflow":
 		s.coreManager.RegisterExecutor(executor.NewIFlowExecutor(s.cfg))
 	default:
+		if plugin, ok := lookupProviderPlugin(a.Provider); ok {
+			exec, err := newPluginExecutor(plugin, s.cfg)
+			if err != nil {
+				log.Errorf("failed to build executor: %v", err)
+				return
+			}
+			s.coreManager.RegisterExecutor(exec)
+			return
+		}
 		providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 		if providerKey == "" {
 			providerKey = "openai-compatibility"
@@ -458,8 +525,24 @@ func (s *Service) Run(ctx context.Context) error {
 	if err := s.ensureAuthDir(); err != nil {
 		return err
 	}
+	if s.cluster == nil {
+		coordinator, errCluster := startCluster(s.cfg)
+		if errCluster != nil {
+			log.WithError(errCluster).Error("cluster mode unavailable, coordinating credential usage locally")
+		} else if coordinator != nil {
+			s.cluster = coordinator
+			log.Infof("cluster mode enabled (node %s)", coordinator.Node())
+		}
+	}
+	if s.coreManager != nil {
+		if report := migrateAuthStore(ctx, s.coreManager.Store(), s.cfg.AuthDir); report.Migrated > 0 || report.Failed > 0 {
+			log.Infof("auth schema migration: %d of %d auths upgraded, %d failed", report.Migrated, report.Scanned, report.Failed)
+		}
+	}
 
 	s.applyRetryConfig(s.cfg)
+	s.applyAntigravityModelConfig(s.cfg)
+	s.startModelListCache(ctx)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -487,6 +570,7 @@ func (s *Service) Run(ctx context.Context) error {
 
 	// handlers no longer depend on legacy clients; pass nil slice initially
 	s.server = api.NewServer(s.cfg, s.coreManager, s.accessManager, s.configPath, s.serverOptions...)
+	s.runPreflight(ctx)
 
 	if s.authManager == nil {
 		s.authManager = newDefaultAuthManager()
@@ -527,7 +611,15 @@ func (s *Service) Run(ctx context.Context) error {
 	}()
 
 	time.Sleep(100 * time.Millisecond)
-	fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
+	if len(s.cfg.Listeners) > 0 {
+		addresses := make([]string, 0, len(s.cfg.Listeners))
+		for _, l := range s.cfg.Listeners {
+			addresses = append(addresses, l.Address)
+		}
+		fmt.Printf("API server started successfully on: %s\n", strings.Join(addresses, ", "))
+	} else {
+		fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
+	}
 
 	s.applyPprofConfig(s.cfg)
 
@@ -553,37 +645,21 @@ func (s *Service) Run(ctx context.Context) error {
 			return
 		}
 
-		nextStrategy := strings.ToLower(strings.TrimSpace(newCfg.Routing.Strategy))
 		normalizeStrategy := func(strategy string) string {
-			switch strategy {
-			case "fill-first", "fillfirst", "ff":
-				return "fill-first"
-			case "quota-weighted", "quota-weight", "quota", "qw":
-				return "quota-weighted"
-			default:
-				return "round-robin"
+			if name, ok := coreauth.CanonicalSelectorName(strategy); ok {
+				return name
 			}
+			return coreauth.DefaultSelectorName
 		}
 		previousStrategy = normalizeStrategy(previousStrategy)
-		nextStrategy = normalizeStrategy(nextStrategy)
+		nextStrategy := normalizeStrategy(newCfg.Routing.Strategy)
 		if s.coreManager != nil && previousStrategy != nextStrategy {
-			var selector coreauth.Selector
-			switch nextStrategy {
-			case "fill-first":
-				selector = &coreauth.FillFirstSelector{}
-			case "quota-weighted":
-				qwSelector := coreauth.NewQuotaWeightedSelector()
-				if s.quotaStore != nil {
-					qwSelector.SetStore(s.quotaStore)
-				}
-				selector = qwSelector
-			default:
-				selector = &coreauth.RoundRobinSelector{}
-			}
+			selector, _ := coreauth.NewSelectorByName(nextStrategy, coreauth.SelectorOptions{QuotaStore: s.quotaStore})
 			s.coreManager.SetSelector(selector)
 		}
 
 		s.applyRetryConfig(newCfg)
+		s.applyAntigravityModelConfig(newCfg)
 		s.applyPprofConfig(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
@@ -624,6 +700,7 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		s.coreManager.StartAuthGC(context.Background())
 
 		// Register the quota refresh callback for Antigravity accounts
 		s.registerAntigravityQuotaRefresh()
@@ -631,7 +708,7 @@ func (s *Service) Run(ctx context.Context) error {
 	if s.coreManager != nil && s.quotaPoller == nil {
 		if s.quotaStore == nil {
 			var storeErr error
-			s.quotaStore, storeErr = quota.NewStore("")
+			s.quotaStore, storeErr = newQuotaStore(s.cfg)
 			if storeErr != nil {
 				log.WithError(storeErr).Warn("failed to create quota store, falling back to metadata storage")
 			}
@@ -644,6 +721,28 @@ func (s *Service) Run(ctx context.Context) error {
 			s.quotaPoller.Start(pollCtx)
 		}
 	}
+	if s.coreManager != nil && s.quotaStore.Shared() && s.quotaSyncCancel == nil {
+		// Replicas sharing the store exchange quota snapshots and model cooldowns.
+		s.coreManager.ShareModelStates(s.quotaStore)
+		syncCtx, cancel := context.WithCancel(context.Background())
+		s.quotaSyncCancel = cancel
+		s.quotaStore.StartSync(syncCtx, quotaSyncInterval(s.cfg))
+	}
+	if s.coreManager != nil && s.quotaStore.Path() != "" {
+		// Keep Antigravity quota refreshes scheduled across restarts, next to the quota store.
+		refreshPath := filepath.Join(filepath.Dir(s.quotaStore.Path()), antigravityQuotaRefreshFile)
+		if errRestore := executor.RestoreQuotaRefreshes(refreshPath); errRestore != nil {
+			log.WithError(errRestore).Warn("failed to restore scheduled antigravity quota refreshes")
+		}
+	}
+	if s.server != nil {
+		s.server.SetQuotaStore(s.quotaStore)
+	}
+	if s.server != nil && s.backupCancel == nil {
+		backupCtx, cancel := context.WithCancel(context.Background())
+		s.backupCancel = cancel
+		backup.StartScheduler(backupCtx, s.backupSnapshot)
+	}
 
 	select {
 	case <-ctx.Done():
@@ -682,8 +781,22 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			s.quotaPollerCancel()
 			s.quotaPollerCancel = nil
 		}
+		if s.quotaSyncCancel != nil {
+			s.quotaSyncCancel()
+			s.quotaSyncCancel = nil
+		}
+		if s.cluster != nil {
+			coreauth.SetCoordinator(nil)
+			s.cluster.Close()
+			s.cluster = nil
+		}
+		if s.backupCancel != nil {
+			s.backupCancel()
+			s.backupCancel = nil
+		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopAuthGC()
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -712,6 +825,9 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 
 		// no legacy clients to persist
+		if errCache := registry.GetModelListCache().Refresh(); errCache != nil {
+			log.WithError(errCache).Warn("failed to save model list cache")
+		}
 
 		if s.server != nil {
 			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -729,6 +845,17 @@ func (s *Service) Shutdown(ctx context.Context) error {
 	return shutdownErr
 }
 
+// backupSnapshot returns the current config and backup sources for the backup scheduler.
+func (s *Service) backupSnapshot() (*config.Config, backup.Sources) {
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	if err := s.quotaStore.Flush(); err != nil {
+		log.Debugf("failed to flush quota store before backup: %v", err)
+	}
+	return cfg, s.server.BackupSources()
+}
+
 func (s *Service) ensureAuthDir() error {
 	info, err := os.Stat(s.cfg.AuthDir)
 	if err != nil {
@@ -826,6 +953,30 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			}
 		}
 		models = applyExcludedModels(models, excluded)
+	case "bedrock":
+		// Bedrock model IDs depend on region and inference profile, so only configured models are served.
+		if entry := s.resolveConfigBedrockKey(a); entry != nil {
+			models = buildBedrockConfigModels(entry)
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
+	case "azure-openai":
+		// Azure serves named deployments rather than model IDs, so only mapped deployments are listed.
+		if entry := s.resolveConfigAzureOpenAIKey(a); entry != nil {
+			models = buildAzureOpenAIConfigModels(entry)
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
+	case "openrouter":
+		// OpenRouter serves hundreds of models; list what the key can reach, plus configured aliases.
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		models = executor.FetchOpenRouterModels(ctx, a, s.cfg)
+		cancel()
+		if entry := s.resolveConfigOpenRouterKey(a); entry != nil {
+			models = append(models, buildConfigModels(entry.Models, "openrouter", "openrouter")...)
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
 	case "codex":
 		models = registry.GetOpenAIModels()
 		if entry := s.resolveConfigCodexKey(a); entry != nil {
@@ -1037,6 +1188,63 @@ func (s *Service) resolveConfigVertexCompatKey(auth *coreauth.Auth) *config.Vert
 	return nil
 }
 
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrRegion, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrRegion = strings.TrimSpace(auth.Attributes["region"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if strings.TrimSpace(entry.GetAPIKey()) == attrKey &&
+			strings.EqualFold(strings.TrimSpace(entry.Region), attrRegion) &&
+			strings.EqualFold(strings.TrimSpace(entry.BaseURL), attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigAzureOpenAIKey(auth *coreauth.Auth) *config.AzureOpenAIKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	for i := range s.cfg.AzureOpenAIKey {
+		entry := &s.cfg.AzureOpenAIKey[i]
+		if strings.TrimSpace(entry.GetAPIKey()) == attrKey && strings.EqualFold(strings.TrimSpace(entry.Endpoint), attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigOpenRouterKey(auth *coreauth.Auth) *config.OpenRouterKey {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	var attrKey, attrBase string
+	if auth.Attributes != nil {
+		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
+	}
+	for i := range s.cfg.OpenRouterKey {
+		entry := &s.cfg.OpenRouterKey[i]
+		if strings.TrimSpace(entry.APIKey) == attrKey && strings.EqualFold(strings.TrimSpace(entry.BaseURL), attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
 func (s *Service) resolveConfigCodexKey(auth *coreauth.Auth) *config.CodexKey {
 	if auth == nil || s.cfg == nil {
 		return nil
@@ -1268,6 +1476,61 @@ func buildClaudeConfigModels(entry *config.ClaudeKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "anthropic", "claude")
 }
 
+// buildBedrockConfigModels lists the configured Bedrock models. Thinking support is taken
+// from the matching Anthropic model, since Bedrock IDs wrap the Anthropic model name
+// (e.g. "us.anthropic.claude-sonnet-4-5-20250929-v1:0").
+func buildBedrockConfigModels(entry *config.BedrockKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	models := buildConfigModels(entry.Models, "anthropic", "claude")
+	for _, model := range models {
+		if model.Thinking != nil {
+			continue
+		}
+		if upstream := registry.LookupStaticModelInfo(bedrockAnthropicModelID(model.DisplayName)); upstream != nil {
+			model.Thinking = upstream.Thinking
+		}
+	}
+	return models
+}
+
+// bedrockAnthropicModelID strips the inference profile prefix and version suffix from a
+// Bedrock model ID.
+func bedrockAnthropicModelID(id string) string {
+	if idx := strings.Index(id, "anthropic."); idx >= 0 {
+		id = id[idx+len("anthropic."):]
+	}
+	if idx := strings.LastIndex(id, "-v"); idx > 0 {
+		id = id[:idx]
+	}
+	return id
+}
+
+// buildAzureOpenAIConfigModels lists the mapped deployments under their client-facing names.
+// Thinking support is taken from the OpenAI model the deployment serves, when configured.
+func buildAzureOpenAIConfigModels(entry *config.AzureOpenAIKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	models := buildConfigModels(entry.Deployments, "azure", "openai")
+	for _, model := range models {
+		if model.Thinking != nil {
+			continue
+		}
+		for _, deployment := range entry.Deployments {
+			if deployment.Name != model.DisplayName || deployment.Model == "" {
+				continue
+			}
+			if upstream := registry.LookupStaticModelInfo(deployment.Model); upstream != nil {
+				model.Thinking = upstream.Thinking
+			}
+			break
+		}
+	}
+	return models
+}
+
 func buildCodexConfigModels(entry *config.CodexKey) []*ModelInfo {
 	if entry == nil {
 		return nil
@@ -1497,3 +1760,48 @@ func (s *Service) registerAntigravityQuotaRefresh() {
 		return result
 	})
 }
+
+// migrateAuthStore upgrades auth metadata to the current schema through the token store, so
+// file, git, Postgres and object stores are migrated alike. The original metadata of each
+// changed auth is backed up under the auth directory before it is saved.
+func migrateAuthStore(ctx context.Context, store coreauth.Store, backupDir string) migration.Report {
+	var report migration.Report
+	if store == nil {
+		return report
+	}
+	auths, err := store.List(ctx)
+	if err != nil {
+		log.Warnf("auth schema migration: failed to list auths: %v", err)
+		return report
+	}
+	runner := migration.NewAuthRunner(backupDir)
+	for _, auth := range auths {
+		if auth == nil || auth.Metadata == nil {
+			continue
+		}
+		report.Scanned++
+		original, errMarshal := json.Marshal(auth.Metadata)
+		if errMarshal != nil {
+			report.Failed++
+			log.Warnf("auth schema migration: failed to encode %s: %v", auth.ID, errMarshal)
+			continue
+		}
+		changed, from := runner.MigrateDocument(auth.Metadata)
+		if !changed {
+			continue
+		}
+		if errBackup := runner.Backup(auth.ID, original); errBackup != nil {
+			report.Failed++
+			log.Warnf("auth schema migration: skipped %s: %v", auth.ID, errBackup)
+			continue
+		}
+		if _, errSave := store.Save(ctx, auth); errSave != nil {
+			report.Failed++
+			log.Warnf("auth schema migration: failed to save %s: %v", auth.ID, errSave)
+			continue
+		}
+		report.Migrated++
+		log.Infof("migrated auth %s from schema version %d to %d", auth.ID, from, runner.Latest())
+	}
+	return report
+}
@@ -3,6 +3,7 @@ package usage
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -18,7 +19,13 @@ type Record struct {
 	Source      string
 	RequestedAt time.Time
 	Failed      bool
-	Detail      Detail
+	// StatusCode is the upstream HTTP status, or 0 when the request failed without one.
+	StatusCode int
+	// Latency is the time from sending the request to reporting its usage.
+	Latency time.Duration
+	Detail  Detail
+	// EstimatedInputTokens is the local prompt token estimate, or 0 when none was made.
+	EstimatedInputTokens int64
 }
 
 // Detail holds the token usage breakdown.
@@ -35,6 +42,23 @@ type Plugin interface {
 	HandleUsage(ctx context.Context, record Record)
 }
 
+type observerContextKey struct{}
+
+// WithObserver returns a context whose usage records are passed to observe synchronously
+// when they are published, before plugins receive them, so a request can act on its own
+// usage before it completes. Observers are not called in aggregation-only mode.
+func WithObserver(ctx context.Context, observe func(Record)) context.Context {
+	return context.WithValue(ctx, observerContextKey{}, observe)
+}
+
+func observerFrom(ctx context.Context) (func(Record), bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	observe, ok := ctx.Value(observerContextKey{}).(func(Record))
+	return observe, ok && observe != nil
+}
+
 type queueItem struct {
 	ctx    context.Context
 	record Record
@@ -104,11 +128,17 @@ func (m *Manager) Register(plugin Plugin) {
 }
 
 // Publish enqueues a usage record for processing. If no plugin is registered
-// the record will be discarded downstream.
+// the record will be discarded downstream. In aggregation-only mode the record is
+// anonymized and detached from the request context before any plugin sees it.
 func (m *Manager) Publish(ctx context.Context, record Record) {
 	if m == nil {
 		return
 	}
+	if aggregateOnly.Load() {
+		ctx, record = context.Background(), Anonymize(record)
+	} else if observe, ok := observerFrom(ctx); ok {
+		observe(record)
+	}
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
 	m.mu.Lock()
@@ -163,6 +193,30 @@ func safeInvoke(plugin Plugin, ctx context.Context, record Record) {
 	plugin.HandleUsage(ctx, record)
 }
 
+var aggregateOnly atomic.Bool
+
+// SetAggregateOnly toggles aggregation-only mode. While enabled, every published record is
+// reduced to its provider, model, day and counters, so no plugin can retain client keys,
+// credentials or request payloads regardless of its own configuration.
+func SetAggregateOnly(enabled bool) { aggregateOnly.Store(enabled) }
+
+// AggregateOnly reports whether aggregation-only mode is enabled.
+func AggregateOnly() bool { return aggregateOnly.Load() }
+
+// Anonymize strips the identifying fields of record and truncates its timestamp to the UTC day.
+func Anonymize(record Record) Record {
+	record.APIKey = ""
+	record.AuthID = ""
+	record.AuthIndex = ""
+	record.Source = ""
+	requestedAt := record.RequestedAt
+	if requestedAt.IsZero() {
+		requestedAt = time.Now()
+	}
+	record.RequestedAt = requestedAt.UTC().Truncate(24 * time.Hour)
+	return record
+}
+
 var defaultManager = NewManager(512)
 
 // DefaultManager returns the global usage manager instance.
@@ -0,0 +1,58 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type recordingPlugin struct {
+	records chan Record
+	ctxs    chan context.Context
+}
+
+func (p *recordingPlugin) HandleUsage(ctx context.Context, record Record) {
+	p.ctxs <- ctx
+	p.records <- record
+}
+
+func TestPublishAggregateOnlyAnonymizes(t *testing.T) {
+	SetAggregateOnly(true)
+	t.Cleanup(func() { SetAggregateOnly(false) })
+
+	plugin := &recordingPlugin{records: make(chan Record, 1), ctxs: make(chan context.Context, 1)}
+	m := NewManager(1)
+	m.Register(plugin)
+	t.Cleanup(m.Stop)
+
+	requestedAt := time.Date(2026, 3, 4, 15, 30, 0, 0, time.FixedZone("CET", 3600))
+	ctx := context.WithValue(context.Background(), "gin", "request")
+	m.Publish(ctx, Record{
+		Provider:    "claude",
+		Model:       "claude-sonnet-4",
+		APIKey:      "sk-client",
+		AuthID:      "auth-1",
+		AuthIndex:   "3",
+		Source:      "user@example.com",
+		RequestedAt: requestedAt,
+		Detail:      Detail{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	})
+
+	select {
+	case got := <-plugin.records:
+		if got.APIKey != "" || got.AuthID != "" || got.AuthIndex != "" || got.Source != "" {
+			t.Errorf("identifying fields retained: %+v", got)
+		}
+		if want := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC); !got.RequestedAt.Equal(want) {
+			t.Errorf("RequestedAt = %v, want %v", got.RequestedAt, want)
+		}
+		if got.Provider != "claude" || got.Model != "claude-sonnet-4" || got.Detail.TotalTokens != 15 {
+			t.Errorf("aggregate fields lost: %+v", got)
+		}
+		if value := (<-plugin.ctxs).Value("gin"); value != nil {
+			t.Errorf("plugin received the request context: %v", value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("record not delivered")
+	}
+}
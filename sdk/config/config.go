@@ -13,6 +13,26 @@ type AccessProvider = internalconfig.AccessProvider
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type StreamCoalesceConfig = internalconfig.StreamCoalesceConfig
+type StreamCoalesceSettings = internalconfig.StreamCoalesceSettings
+type SSEDialectConfig = internalconfig.SSEDialectConfig
+type SSEDialect = internalconfig.SSEDialect
+type SystemPromptDedupConfig = internalconfig.SystemPromptDedupConfig
+type OutputBudgetConfig = internalconfig.OutputBudgetConfig
+type ConversationSummaryConfig = internalconfig.ConversationSummaryConfig
+type ResponseCacheConfig = internalconfig.ResponseCacheConfig
+type RequestNormalizationConfig = internalconfig.RequestNormalizationConfig
+type AgentModeConfig = internalconfig.AgentModeConfig
+type OutputPostProcessConfig = internalconfig.OutputPostProcessConfig
+type OutputRule = internalconfig.OutputRule
+type LanguageRoute = internalconfig.LanguageRoute
+type ContextRoutingConfig = internalconfig.ContextRoutingConfig
+type PromptTemplate = internalconfig.PromptTemplate
+type PromptTemplateMessage = internalconfig.PromptTemplateMessage
+type ScheduledJobsConfig = internalconfig.ScheduledJobsConfig
+type MessageBatchesConfig = internalconfig.MessageBatchesConfig
+type RequestLogLevels = internalconfig.RequestLogLevels
+type RequestLogSampling = internalconfig.RequestLogSampling
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
@@ -25,6 +45,12 @@ type PayloadModelRule = internalconfig.PayloadModelRule
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
+type BedrockKey = internalconfig.BedrockKey
+type BedrockModel = internalconfig.BedrockModel
+type AzureOpenAIKey = internalconfig.AzureOpenAIKey
+type AzureOpenAIDeployment = internalconfig.AzureOpenAIDeployment
+type OpenRouterKey = internalconfig.OpenRouterKey
+type OpenRouterModel = internalconfig.OpenRouterModel
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
@@ -37,6 +63,15 @@ const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
 	DefaultPanelGitHubRepository   = internalconfig.DefaultPanelGitHubRepository
+
+	RequestLogLevelNone     = internalconfig.RequestLogLevelNone
+	RequestLogLevelMetadata = internalconfig.RequestLogLevelMetadata
+	RequestLogLevelFull     = internalconfig.RequestLogLevelFull
+
+	OutputRuleRegex           = internalconfig.OutputRuleRegex
+	OutputRuleBan             = internalconfig.OutputRuleBan
+	OutputRuleStripGoogleURLs = internalconfig.OutputRuleStripGoogleURLs
+	OutputRuleCloseCodeFences = internalconfig.OutputRuleCloseCodeFences
 )
 
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
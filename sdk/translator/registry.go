@@ -3,6 +3,8 @@ package translator
 import (
 	"context"
 	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 )
 
 // Registry manages translation functions across schemas.
@@ -52,6 +54,16 @@ func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byt
 	return rawJSON
 }
 
+// TranslateRequestContext is TranslateRequest recorded as a span of the trace in ctx.
+func (r *Registry) TranslateRequestContext(ctx context.Context, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	_, span := tracing.Start(ctx, "translate request", tracing.KindInternal,
+		tracing.String("cliproxy.translate.from", from.String()),
+		tracing.String("cliproxy.translate.to", to.String()),
+	)
+	defer span.End()
+	return r.TranslateRequest(from, to, model, rawJSON, stream)
+}
+
 // HasResponseTransformer indicates whether a response translator exists.
 func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	r.mu.RLock()
@@ -65,14 +77,19 @@ func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	return false
 }
 
-// TranslateStream applies the registered streaming response translator.
+// TranslateStream applies the registered streaming response translator. With stream
+// validation enabled, the translated chunks are checked against the schema of format to.
 func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.Stream != nil {
-			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			out := fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			if streamValidation.Load() {
+				validateStreamOutput(from, to, model, rawJSON, out)
+			}
+			return out
 		}
 	}
 	return []string{string(rawJSON)}
@@ -121,6 +138,11 @@ func TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool
 	return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
 }
 
+// TranslateRequestContext is a helper on the default registry.
+func TranslateRequestContext(ctx context.Context, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	return defaultRegistry.TranslateRequestContext(ctx, from, to, model, rawJSON, stream)
+}
+
 // HasResponseTransformer inspects the default registry.
 func HasResponseTransformer(from, to Format) bool {
 	return defaultRegistry.HasResponseTransformer(from, to)
@@ -0,0 +1,305 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// validationLogInterval limits how often the same violation is logged.
+	validationLogInterval = time.Minute
+	// validationSnippetLimit bounds the chunk and upstream line quoted in a violation log.
+	validationSnippetLimit = 512
+)
+
+var (
+	streamValidation atomic.Bool
+
+	validationLogMu   sync.Mutex
+	validationLogSeen = make(map[string]time.Time)
+)
+
+// SetStreamValidation enables schema validation of translated stream chunks. Violations are
+// only logged; chunks are always passed on unchanged.
+func SetStreamValidation(enabled bool) { streamValidation.Store(enabled) }
+
+// StreamValidationEnabled reports whether translated stream chunks are validated.
+func StreamValidationEnabled() bool { return streamValidation.Load() }
+
+// ValidateStreamChunk checks one translated stream chunk against the schema of the client
+// format f and returns the violations found. Formats without a known schema always pass.
+func ValidateStreamChunk(f Format, chunk string) []string {
+	switch f {
+	case FormatOpenAI:
+		return validateOpenAIChunk(chunk)
+	case FormatClaude:
+		return validateSSEEvents(chunk, validateClaudeEvent)
+	case FormatOpenAIResponse:
+		return validateSSEEvents(chunk, validateResponsesEvent)
+	case FormatGemini:
+		return validateGeminiChunk(chunk, false)
+	case FormatGeminiCLI:
+		return validateGeminiChunk(chunk, true)
+	}
+	return nil
+}
+
+// validateStreamOutput logs the schema violations of chunks translated from upstreamLine.
+func validateStreamOutput(from, to Format, model string, upstreamLine []byte, chunks []string) {
+	for _, chunk := range chunks {
+		violations := ValidateStreamChunk(to, chunk)
+		if len(violations) == 0 {
+			continue
+		}
+		if !shouldLogViolation(string(from)+">"+string(to)+":"+violations[0], time.Now()) {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"from":     from,
+			"to":       to,
+			"model":    model,
+			"chunk":    snippet(chunk),
+			"upstream": snippet(string(upstreamLine)),
+		}).Warnf("stream validation: translated %s chunk violates schema: %s", to, strings.Join(violations, "; "))
+	}
+}
+
+func shouldLogViolation(key string, now time.Time) bool {
+	validationLogMu.Lock()
+	defer validationLogMu.Unlock()
+	if last, ok := validationLogSeen[key]; ok && now.Sub(last) < validationLogInterval {
+		return false
+	}
+	if len(validationLogSeen) > 1024 {
+		clear(validationLogSeen)
+	}
+	validationLogSeen[key] = now
+	return true
+}
+
+func snippet(s string) string {
+	if len(s) <= validationSnippetLimit {
+		return s
+	}
+	return s[:validationSnippetLimit] + "..."
+}
+
+// stripDataPrefix removes an optional SSE "data:" prefix from a JSON chunk.
+func stripDataPrefix(chunk string) string {
+	chunk = strings.TrimSpace(chunk)
+	if rest, ok := strings.CutPrefix(chunk, "data:"); ok {
+		return strings.TrimSpace(rest)
+	}
+	return chunk
+}
+
+var openAIFinishReasons = map[string]bool{"stop": true, "length": true, "tool_calls": true, "content_filter": true, "function_call": true}
+
+func validateOpenAIChunk(chunk string) []string {
+	chunk = stripDataPrefix(chunk)
+	if chunk == "" || chunk == "[DONE]" {
+		return nil
+	}
+	if !gjson.Valid(chunk) {
+		return []string{"chunk is not valid JSON"}
+	}
+	root := gjson.Parse(chunk)
+	var out []string
+	if object := root.Get("object").String(); object != "chat.completion.chunk" {
+		out = append(out, fmt.Sprintf("object is %q, want chat.completion.chunk", object))
+	}
+	out = requireType(out, root, "id", gjson.String)
+	out = requireType(out, root, "created", gjson.Number)
+	out = requireType(out, root, "model", gjson.String)
+	choices := root.Get("choices")
+	if !choices.IsArray() {
+		return append(out, "choices is not an array")
+	}
+	for i, choice := range choices.Array() {
+		prefix := fmt.Sprintf("choices[%d]", i)
+		out = requireType(out, choice, "index", gjson.Number, prefix)
+		if delta := choice.Get("delta"); !delta.IsObject() {
+			out = append(out, prefix+".delta is not an object")
+		} else {
+			for j, call := range delta.Get("tool_calls").Array() {
+				callPrefix := fmt.Sprintf("%s.delta.tool_calls[%d]", prefix, j)
+				out = requireType(out, call, "index", gjson.Number, callPrefix)
+				if args := call.Get("function.arguments"); args.Exists() && args.Type != gjson.String {
+					out = append(out, callPrefix+".function.arguments is not a string")
+				}
+			}
+		}
+		if reason := choice.Get("finish_reason"); reason.Exists() && reason.Type != gjson.Null && !openAIFinishReasons[reason.String()] {
+			out = append(out, fmt.Sprintf("%s.finish_reason %q is not a known value", prefix, reason.String()))
+		}
+	}
+	if usage := root.Get("usage"); usage.Exists() && usage.Type != gjson.Null {
+		for _, field := range []string{"prompt_tokens", "completion_tokens", "total_tokens"} {
+			out = requireType(out, usage, field, gjson.Number, "usage")
+		}
+	}
+	return out
+}
+
+// validateSSEEvents splits a chunk holding one or more "event:/data:" blocks and validates
+// the data of each against its event name.
+func validateSSEEvents(chunk string, validate func(event string, data gjson.Result) []string) []string {
+	var out []string
+	for _, block := range strings.Split(strings.ReplaceAll(chunk, "\r\n", "\n"), "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		var event, data string
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			}
+		}
+		if data == "" {
+			out = append(out, fmt.Sprintf("event %q has no data", event))
+			continue
+		}
+		if !gjson.Valid(data) {
+			out = append(out, fmt.Sprintf("event %q data is not valid JSON", event))
+			continue
+		}
+		parsed := gjson.Parse(data)
+		if typ := parsed.Get("type").String(); event != "" && typ != event {
+			out = append(out, fmt.Sprintf("event %q carries data of type %q", event, typ))
+		}
+		if event == "" {
+			event = parsed.Get("type").String()
+		}
+		out = append(out, validate(event, parsed)...)
+	}
+	return out
+}
+
+var (
+	claudeBlockTypes = map[string]bool{"text": true, "thinking": true, "redacted_thinking": true, "tool_use": true, "server_tool_use": true, "web_search_tool_result": true}
+	claudeDeltaTypes = map[string]bool{"text_delta": true, "input_json_delta": true, "thinking_delta": true, "signature_delta": true, "citations_delta": true}
+)
+
+func validateClaudeEvent(event string, data gjson.Result) []string {
+	var out []string
+	switch event {
+	case "message_start":
+		message := data.Get("message")
+		out = requireType(out, message, "id", gjson.String, "message")
+		if typ := message.Get("type").String(); typ != "message" {
+			out = append(out, fmt.Sprintf("message.type is %q, want message", typ))
+		}
+		if role := message.Get("role").String(); role != "assistant" {
+			out = append(out, fmt.Sprintf("message.role is %q, want assistant", role))
+		}
+		if !message.Get("content").IsArray() {
+			out = append(out, "message.content is not an array")
+		}
+	case "content_block_start":
+		out = requireType(out, data, "index", gjson.Number)
+		if typ := data.Get("content_block.type").String(); !claudeBlockTypes[typ] {
+			out = append(out, fmt.Sprintf("content_block.type %q is not a known block type", typ))
+		}
+	case "content_block_delta":
+		out = requireType(out, data, "index", gjson.Number)
+		if typ := data.Get("delta.type").String(); !claudeDeltaTypes[typ] {
+			out = append(out, fmt.Sprintf("delta.type %q is not a known delta type", typ))
+		}
+	case "content_block_stop":
+		out = requireType(out, data, "index", gjson.Number)
+	case "message_delta":
+		if !data.Get("delta").IsObject() {
+			out = append(out, "delta is not an object")
+		}
+		if usage := data.Get("usage"); usage.Exists() && !usage.IsObject() {
+			out = append(out, "usage is not an object")
+		}
+	case "message_stop", "ping":
+	case "error":
+		out = requireType(out, data, "error.type", gjson.String)
+		out = requireType(out, data, "error.message", gjson.String)
+	default:
+		out = append(out, fmt.Sprintf("unknown event %q", event))
+	}
+	return out
+}
+
+func validateResponsesEvent(event string, data gjson.Result) []string {
+	var out []string
+	switch {
+	case event == "error":
+	case !strings.HasPrefix(event, "response."):
+		out = append(out, fmt.Sprintf("unknown event %q", event))
+	case event == "response.created" || event == "response.in_progress" || event == "response.completed" || event == "response.incomplete" || event == "response.failed":
+		out = requireType(out, data, "response.id", gjson.String)
+		if object := data.Get("response.object").String(); object != "response" {
+			out = append(out, fmt.Sprintf("response.object is %q, want response", object))
+		}
+	case strings.HasSuffix(event, ".delta"):
+		out = requireType(out, data, "output_index", gjson.Number)
+		if delta := data.Get("delta"); !delta.Exists() {
+			out = append(out, "delta is missing")
+		}
+	case event == "response.output_item.added" || event == "response.output_item.done":
+		out = requireType(out, data, "output_index", gjson.Number)
+		out = requireType(out, data, "item.type", gjson.String)
+	}
+	return out
+}
+
+func validateGeminiChunk(chunk string, wrapped bool) []string {
+	chunk = stripDataPrefix(chunk)
+	if chunk == "" || chunk == "[DONE]" {
+		return nil
+	}
+	if !gjson.Valid(chunk) {
+		return []string{"chunk is not valid JSON"}
+	}
+	root := gjson.Parse(chunk)
+	if wrapped {
+		if !root.Get("response").IsObject() {
+			return []string{"response is not an object"}
+		}
+		root = root.Get("response")
+	}
+	var out []string
+	candidates := root.Get("candidates")
+	if !candidates.Exists() && !root.Get("usageMetadata").Exists() && !root.Get("promptFeedback").Exists() {
+		out = append(out, "chunk has no candidates, usageMetadata or promptFeedback")
+	}
+	if candidates.Exists() && !candidates.IsArray() {
+		return append(out, "candidates is not an array")
+	}
+	for i, candidate := range candidates.Array() {
+		if parts := candidate.Get("content.parts"); parts.Exists() && !parts.IsArray() {
+			out = append(out, fmt.Sprintf("candidates[%d].content.parts is not an array", i))
+		}
+	}
+	return out
+}
+
+// requireType appends a violation when path under value is missing or of another type.
+func requireType(out []string, value gjson.Result, path string, want gjson.Type, prefix ...string) []string {
+	name := path
+	if len(prefix) > 0 {
+		name = prefix[0] + "." + path
+	}
+	got := value.Get(path)
+	if !got.Exists() {
+		return append(out, name+" is missing")
+	}
+	if got.Type != want {
+		return append(out, fmt.Sprintf("%s is %s, want %s", name, got.Type, want))
+	}
+	return out
+}
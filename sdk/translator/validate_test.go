@@ -0,0 +1,64 @@
+package translator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
+)
+
+var geminiStream = []string{
+	`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]},"index":0}],"modelVersion":"gemini-2.5-pro","responseId":"r1"}`,
+	`data: {"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"lookup","args":{"q":"x"}}}]},"index":0}],"modelVersion":"gemini-2.5-pro","responseId":"r1"}`,
+	`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":2,"totalTokenCount":5},"modelVersion":"gemini-2.5-pro","responseId":"r1"}`,
+	`data: [DONE]`,
+}
+
+// TestBuiltinTranslatorsProduceValidChunks guards against false positives: the output of
+// the built-in Gemini translators must pass validation for each client format.
+func TestBuiltinTranslatorsProduceValidChunks(t *testing.T) {
+	request := []byte(`{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hi"}],"stream":true}`)
+	for _, client := range []sdktranslator.Format{sdktranslator.FormatOpenAI, sdktranslator.FormatClaude, sdktranslator.FormatOpenAIResponse} {
+		var param any
+		chunks := 0
+		for _, line := range geminiStream {
+			for _, chunk := range sdktranslator.TranslateStream(context.Background(), sdktranslator.FormatGemini, client, "gemini-2.5-pro", request, request, []byte(line), &param) {
+				chunks++
+				if violations := sdktranslator.ValidateStreamChunk(client, chunk); len(violations) > 0 {
+					t.Errorf("%s chunk %q: %v", client, chunk, violations)
+				}
+			}
+		}
+		if chunks == 0 {
+			t.Fatalf("%s: translator produced no chunks", client)
+		}
+	}
+}
+
+func TestValidateStreamChunkReportsViolations(t *testing.T) {
+	cases := []struct {
+		format sdktranslator.Format
+		chunk  string
+		want   string
+	}{
+		{sdktranslator.FormatOpenAI, `{"id":"x","object":"chat.completion","created":1,"model":"m","choices":[]}`, "object"},
+		{sdktranslator.FormatOpenAI, `{"id":"x","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{},"finish_reason":"STOP"}]}`, "finish_reason"},
+		{sdktranslator.FormatOpenAI, `{"id":"x","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":{"q":1}}}]}}]}`, "arguments"},
+		{sdktranslator.FormatOpenAI, `{"id":"x"`, "valid JSON"},
+		{sdktranslator.FormatClaude, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text\",\"text\":\"hi\"}}\n\n", "delta.type"},
+		{sdktranslator.FormatClaude, "event: message_stop\ndata: {\"type\":\"message_delta\"}\n\n", "carries data"},
+		{sdktranslator.FormatOpenAIResponse, "event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"r\"}}", "response.object"},
+		{sdktranslator.FormatGemini, `{"foo":1}`, "no candidates"},
+	}
+	for _, tc := range cases {
+		violations := sdktranslator.ValidateStreamChunk(tc.format, tc.chunk)
+		if !strings.Contains(strings.Join(violations, "; "), tc.want) {
+			t.Errorf("%s %q: violations %v, want one mentioning %q", tc.format, tc.chunk, violations, tc.want)
+		}
+	}
+	if violations := sdktranslator.ValidateStreamChunk(sdktranslator.FormatCodex, `not json`); violations != nil {
+		t.Fatalf("format without schema reported %v", violations)
+	}
+}